@@ -6,18 +6,33 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
-	_ "github.com/fayleenpc/tj-jeans/cmd/docs" // docs is generated by Swag CLI
+	"github.com/fayleenpc/tj-jeans/internal/audit"
 	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/events"
+	"github.com/fayleenpc/tj-jeans/internal/inbox"
+	"github.com/fayleenpc/tj-jeans/internal/inventory"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
 	"github.com/fayleenpc/tj-jeans/internal/monitoring"
+	"github.com/fayleenpc/tj-jeans/internal/notify"
+	"github.com/fayleenpc/tj-jeans/internal/ratelimiter"
+	"github.com/fayleenpc/tj-jeans/internal/retention"
 	swagger_docs "github.com/fayleenpc/tj-jeans/internal/swaggerdocs"
+	"github.com/fayleenpc/tj-jeans/services/auditlog"
 	"github.com/fayleenpc/tj-jeans/services/cart"
+	"github.com/fayleenpc/tj-jeans/services/eventlog"
 	"github.com/fayleenpc/tj-jeans/services/finance"
+	"github.com/fayleenpc/tj-jeans/services/gateway/messaging"
 	"github.com/fayleenpc/tj-jeans/services/gateway/payment"
+	"github.com/fayleenpc/tj-jeans/services/notifications"
 	"github.com/fayleenpc/tj-jeans/services/order"
+	"github.com/fayleenpc/tj-jeans/services/outbox"
 	"github.com/fayleenpc/tj-jeans/services/products"
+	"github.com/fayleenpc/tj-jeans/services/reviews"
 	"github.com/fayleenpc/tj-jeans/services/tokenize"
 	"github.com/fayleenpc/tj-jeans/services/users"
+	"github.com/fayleenpc/tj-jeans/services/wishlist"
 	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -147,9 +162,9 @@ func (s *APIServer) Run() error {
 	})
 
 	redisStore := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379", // Redis server address
-		Password: "",               // No password set
-		DB:       0,                // Use default DB
+		Addr:     config.Envs.RedisAddress, // Redis server address
+		Password: "",                       // No password set
+		DB:       0,                        // Use default DB
 	})
 
 	// Test the connection
@@ -159,23 +174,56 @@ func (s *APIServer) Run() error {
 	}
 	fmt.Println("Connected to Redis")
 
+	// rate limits are shared by every instance through redis
+	ratelimiter.SetDefault(ratelimiter.New(ratelimiter.NewRedisStore(redisStore), ratelimiter.PoliciesFromConfig()))
+
 	router := mux.NewRouter()
+	router.Use(audit.Middleware(audit.TransportREST))
 
 	subrouter := router.PathPrefix("/api/v1").Subrouter()
 
 	// token store
 	tokenStore := tokenize.NewStore(s.db)
 
+	// every create, update and delete is recorded in the audit log
+	auditStore := auditlog.NewStore(s.db)
+	audit.SetDefault(audit.NewRecorder(auditStore))
+
+	// mail is queued in the outbox and sent in the background with retries
+	outboxStore := outbox.NewStore(s.db)
+	mailOutbox := mailer.OutboxFromConfig(outboxStore)
+	mailer.SetDefault(mailOutbox)
+	go mailOutbox.Run(context.Background())
+
+	// orders are announced on the phone of the customer through NOTIFY_CHANNEL
+	notify.SetDefault(notify.FromConfig())
+
+	// the in-app notifications are stored in the inbox of the users and
+	// streamed live, every instance streams what any of them publishes on
+	// NOTIFICATIONS_CHANNEL
+	notificationStore := notifications.NewStore(s.db)
+	notificationHub := inbox.NewHub()
+	notificationBroker := inbox.NewRedisBroker(redisStore, config.Envs.NotificationsChannel, notificationHub)
+	inbox.SetDefault(inbox.NewInbox(notificationStore, notificationBroker))
+	go notificationBroker.Run(context.Background())
+
+	// the changes of the catalogue and the orders are logged for the watch
+	// streams of the gRPC API
+	events.SetDefault(events.LogFromConfig(eventlog.NewStore(s.db)))
+
+	// stock going down to its threshold is mailed and published on NATS
+	inventory.SetDefault(inventory.NotifierFromConfig(messaging.LowStockPublisher()))
+
 	usersStore := users.NewStore(s.db)
-	usersHandler := users.NewHandler(usersStore, tokenStore, redisStore)
+	usersHandler := users.NewHandler(usersStore, usersStore, tokenStore, tokenStore, redisStore)
 	usersHandler.RegisterRoutes(subrouter)
 
 	productStore := products.NewStore(s.db)
-	productHandler := products.NewHandler(productStore, usersStore, tokenStore, redisStore)
+	productHandler := products.NewHandler(productStore, productStore, usersStore, tokenStore, redisStore)
 	productHandler.RegisterRoutes(subrouter)
 
 	orderStore := order.NewStore(s.db)
-	cartHandler := cart.NewHandler(orderStore, productStore, usersStore, tokenStore, redisStore)
+	cartHandler := cart.NewHandler(orderStore, productStore, productStore, usersStore, tokenStore, redisStore)
 	cartHandler.RegisterRoutes(subrouter)
 
 	// payment gateway
@@ -183,14 +231,14 @@ func (s *APIServer) Run() error {
 	paymentGateway.RegisterRoutes()
 
 	// tokenize
-	tokenizeHandler := tokenize.NewHandler(tokenStore, usersStore, redisStore)
+	tokenizeHandler := tokenize.NewHandler(tokenStore, usersStore, tokenStore, tokenStore, redisStore)
 	tokenizeHandler.RegisterRoutes(subrouter)
 
 	// swagger
 	// swag := swagger.NewHandler()
 	// swag.RegisterRoutes()
 
-	// swagger
+	// swagger, the OpenAPI spec generated with the gRPC gateway
 	swagDocs := swagger_docs.NewHandler()
 	swagDocs.RegisterRoutes(subrouter)
 
@@ -202,7 +250,44 @@ func (s *APIServer) Run() error {
 	// 	log.Fatal("Failed to start http server")
 	// }
 
-	financeHandler := finance.NewHandler(orderStore)
+	// soft deleted rows stay in the trash until the retention runs out,
+	// orders go first because they reference users and products
+	go retention.JobFromConfig(
+		retention.Purge{Name: "orders", Purge: orderStore.PurgeDeletedOrders},
+		retention.Purge{Name: "products", Purge: productStore.PurgeDeletedProducts},
+		retention.Purge{Name: "users", Purge: usersStore.PurgeDeletedUsers},
+	).Run(context.Background())
+
+	// the sent and failed mail is kept a few days for the admins to look into
+	go retention.NewJob(
+		time.Hour*24*time.Duration(config.Envs.MailOutboxRetentionInDays),
+		time.Second*time.Duration(config.Envs.SoftDeletePurgeIntervalInSeconds),
+		retention.Purge{Name: "mail", Purge: outboxStore.PurgeOutboxMails},
+	).Run(context.Background())
+
+	auditHandler := auditlog.NewHandler(auditStore, usersStore, tokenStore)
+	auditHandler.RegisterRoutes(subrouter)
+
+	// customers subscribed to a sold out product are mailed once it is back
+	wishlistStore := wishlist.NewStore(s.db)
+	restockWorker := inventory.RestockWorkerFromConfig(wishlistStore)
+	inventory.SetRestockDefault(restockWorker)
+	go restockWorker.Run(context.Background())
+
+	wishlistHandler := wishlist.NewHandler(wishlistStore, wishlistStore, productStore, usersStore, tokenStore)
+	wishlistHandler.RegisterRoutes(subrouter)
+
+	notificationHandler := notifications.NewHandler(notificationStore, notificationHub, usersStore, tokenStore)
+	notificationHandler.RegisterRoutes(subrouter)
+
+	outboxHandler := outbox.NewHandler(outboxStore, usersStore, tokenStore)
+	outboxHandler.RegisterRoutes(subrouter)
+
+	reviewStore := reviews.NewStore(s.db)
+	reviewHandler := reviews.NewHandler(reviewStore, usersStore, tokenStore)
+	reviewHandler.RegisterRoutes(subrouter)
+
+	financeHandler := finance.NewHandler(orderStore, usersStore, tokenStore)
 	financeHandler.RegisterRoutes(subrouter)
 
 	log.Printf("REST + Json running at : %v\n", s.addr)
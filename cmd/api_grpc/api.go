@@ -6,18 +6,37 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"time"
 
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/events"
+	"github.com/fayleenpc/tj-jeans/internal/inventory"
 	"github.com/fayleenpc/tj-jeans/internal/monitoring"
+	"github.com/fayleenpc/tj-jeans/internal/ratelimiter"
+	"github.com/fayleenpc/tj-jeans/services/auditlog"
 	"github.com/fayleenpc/tj-jeans/services/cart"
 	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"github.com/fayleenpc/tj-jeans/services/eventlog"
+	"github.com/fayleenpc/tj-jeans/services/finance"
+	"github.com/fayleenpc/tj-jeans/services/gateway/messaging"
 	"github.com/fayleenpc/tj-jeans/services/order"
 	"github.com/fayleenpc/tj-jeans/services/products"
 	"github.com/fayleenpc/tj-jeans/services/tokenize"
 	"github.com/fayleenpc/tj-jeans/services/users"
+	"github.com/go-redis/redis/v8"
 	"github.com/opentracing/opentracing-go"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
+// shutdownTimeout is how long the calls in flight have to finish, the watch
+// streams never do and are cut.
+const shutdownTimeout = time.Second * 10
+
 type ApiServerGRPC struct {
 	srv  *grpc.Server
 	db   *sql.DB
@@ -32,33 +51,103 @@ func NewApiServerGRPC(addr string, grpcServer *grpc.Server, db *sql.DB) *ApiServ
 	}
 }
 
-func (s *ApiServerGRPC) Run() {
-	s.RunServer()
-	defer s.RunClient()
+// ServerOptions rate limits then authenticates every call, the user tokens
+// and API keys are checked against the same stores as the REST API. Calls
+// are tagged with a request ID for the audit log.
+func ServerOptions(db *sql.DB) []grpc.ServerOption {
+	userStore := users.NewStore(db)
+	tokenStore := tokenize.NewStore(db)
+	limiter := ratelimiter.Default()
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(audit.UnaryServerInterceptor(), limiter.UnaryServerInterceptor(), auth.UnaryServerInterceptor(userStore, tokenStore)),
+		grpc.ChainStreamInterceptor(audit.StreamServerInterceptor(), limiter.StreamServerInterceptor(), auth.StreamServerInterceptor(userStore, tokenStore)),
+	}
 }
 
-func (s *ApiServerGRPC) RunServer() error {
+// Run serves until ctx is done, the calls in flight are finished before it
+// returns or cut after shutdownTimeout.
+func (s *ApiServerGRPC) Run(ctx context.Context) error {
 	tracer, closer := monitoring.Jaegar()
 	opentracing.SetGlobalTracer(tracer)
 	defer closer.Close()
 	lis, err := net.Listen("tcp", s.addr)
 	if err != nil {
-		log.Fatal("Failed to listen : ", err)
+		return fmt.Errorf("failed to listen at %v: %w", s.addr, err)
+	}
+	health := s.RegisterServices()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		log.Printf("gRPC server at %v is shutting down\n", s.addr)
+		health.Shutdown()
+		graceful := make(chan struct{})
+		go func() {
+			s.srv.GracefulStop()
+			close(graceful)
+		}()
+		select {
+		case <-graceful:
+		case <-time.After(shutdownTimeout):
+			s.srv.Stop()
+		}
+	}()
+
+	log.Printf("gRPC server is running at : %v\n", s.addr)
+	if err := s.srv.Serve(lis); err != nil {
+		return err
 	}
-	defer lis.Close()
+	<-stopped
+	return nil
+}
 
-	usersService := users.NewService(s.db)
-	tokenService := tokenize.NewService(s.db)
-	productsService := products.NewService(s.db)
-	ordersService := order.NewService(s.db)
+// RegisterServices registers every service of the API with the gRPC health
+// checks and the server reflection, the health server is serving until the
+// shutdown.
+func (s *ApiServerGRPC) RegisterServices() *health.Server {
+	userStore := users.NewStore(s.db)
+	tokenStore := tokenize.NewStore(s.db)
+	productStore := products.NewStore(s.db)
+	orderStore := order.NewStore(s.db)
+	usersService := users.NewService(userStore)
+	tokenService := tokenize.NewService(tokenStore, userStore, tokenStore, redisClient())
+	productsService := products.NewService(productStore, productStore)
+	ordersService := cart.NewService(orderStore, productStore, productStore, userStore)
+	financeService := finance.NewService(orderStore)
+	audit.SetDefault(audit.NewRecorder(auditlog.NewStore(s.db)))
+	inventory.SetDefault(inventory.NotifierFromConfig(messaging.LowStockPublisher()))
+	events.SetDefault(events.LogFromConfig(eventlog.NewStore(s.db)))
 
 	users.NewHandlerServer(s.srv, usersService)
 	tokenize.NewHandlerServer(s.srv, tokenService)
+	tokenize.NewAuthHandlerServer(s.srv, tokenService)
 	products.NewHandlerServer(s.srv, productsService)
 	cart.NewHandlerServer(s.srv, ordersService)
-	log.Printf("gRPC server is running at : %v\n", s.addr)
+	cart.NewOrderItemHandlerServer(s.srv, ordersService)
+	cart.NewCheckoutHandlerServer(s.srv, ordersService)
+	finance.NewHandlerServer(s.srv, financeService)
+
+	healthServer := health.NewServer()
+	for service := range s.srv.GetServiceInfo() {
+		healthServer.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s.srv, healthServer)
+	reflection.Register(s.srv)
+	return healthServer
+}
 
-	return s.srv.Serve(lis)
+// redisClient counts the failed logins of every instance, they are counted
+// in memory when redis is down.
+func redisClient() *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: config.Envs.RedisAddress})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		log.Printf("gRPC server could not connect to Redis, the login guard is in memory: %v", err)
+		client.Close()
+		return nil
+	}
+	return client
 }
 
 func (s *ApiServerGRPC) RunClient() error {
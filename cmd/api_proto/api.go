@@ -1,60 +1,84 @@
 package api_proto
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"time"
 
-	"github.com/fayleenpc/tj-jeans/internal/monitoring"
-	"github.com/fayleenpc/tj-jeans/services/cart"
-	"github.com/fayleenpc/tj-jeans/services/order"
-	"github.com/fayleenpc/tj-jeans/services/products"
-	"github.com/fayleenpc/tj-jeans/services/tokenize"
-	"github.com/fayleenpc/tj-jeans/services/users"
-	"github.com/opentracing/opentracing-go"
+	"github.com/fayleenpc/tj-jeans/internal/gateway"
+	swagger_docs "github.com/fayleenpc/tj-jeans/internal/swaggerdocs"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
+// ApiProtobufServer serves the gRPC API as REST JSON and protobuf, the routes
+// are generated from types.proto and every call goes through the interceptors
+// of the gRPC server at grpcAddr.
 type ApiProtobufServer struct {
-	addr string
-	db   *sql.DB
+	addr     string
+	grpcAddr string
 }
 
-func NewApiProtobufServer(addr string, db *sql.DB) *ApiProtobufServer {
+func NewApiProtobufServer(addr string, grpcAddr string) *ApiProtobufServer {
 	return &ApiProtobufServer{
-		addr: addr,
-		db:   db,
+		addr:     addr,
+		grpcAddr: grpcAddr,
 	}
 }
 
-func (s *ApiProtobufServer) Run() error {
+// Run serves until ctx is done.
+func (s *ApiProtobufServer) Run(ctx context.Context) error {
+	conn, err := grpc.NewClient(s.grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
 
-	tracer, closer := monitoring.Jaegar()
-	opentracing.SetGlobalTracer(tracer)
-	defer closer.Close()
-	usersService := users.NewService(s.db)
-	tokenizeService := tokenize.NewService(s.db)
-	productsService := products.NewService(s.db)
-	ordersService := order.NewService(s.db)
+	gatewayMux := gateway.NewServeMux()
+	if err := RegisterServices(ctx, gatewayMux, conn); err != nil {
+		return err
+	}
 
 	mux := http.NewServeMux()
+	swagger_docs.NewHandler().RegisterServeMux(mux)
+	mux.Handle("/", gatewayMux)
 
-	// orders service
-	orderHandlerHTTP := cart.NewHandlerHTTP(ordersService)
-	orderHandlerHTTP.RegisterRoutes(mux)
-
-	// products servuce
-	productsHandlerHTTP := products.NewHandlerHTTP(productsService)
-	productsHandlerHTTP.RegisterRoutes(mux)
-
-	// tokenize service
-	tokenizeHandlerHTTP := tokenize.NewHandlerHTTP(tokenizeService)
-	tokenizeHandlerHTTP.RegisterRoutes(mux)
-
-	// users service
-	usersHandlerHTTP := users.NewHandlerHTTP(usersService)
-	usersHandlerHTTP.RegisterRoutes(mux)
+	srv := &http.Server{Addr: s.addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
 
 	log.Printf("REST + Protobuf running at : %v\n", s.addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
 
-	return http.ListenAndServe(s.addr, mux)
+// RegisterServices routes every service of the API to the gRPC server of
+// conn.
+func RegisterServices(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+	registers := []func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error{
+		pb.RegisterUserServiceHandler,
+		pb.RegisterTokenServiceHandler,
+		pb.RegisterAuthServiceHandler,
+		pb.RegisterProductServiceHandler,
+		pb.RegisterOrderServiceHandler,
+		pb.RegisterOrderItemServiceHandler,
+		pb.RegisterCheckoutServiceHandler,
+		pb.RegisterFinanceServiceHandler,
+	}
+	for _, register := range registers {
+		if err := register(ctx, mux, conn); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,492 @@
+package api_proto
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/gateway"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// encoding is a way for a client to talk to the gateway, it encodes the body
+// of the request and decodes the answer.
+type encoding struct {
+	name      string
+	mediaType string
+	marshal   func(proto.Message) ([]byte, error)
+	unmarshal func([]byte, proto.Message) error
+}
+
+var encodings = []encoding{
+	{
+		name:      "json",
+		mediaType: gateway.MIMEJSON,
+		marshal:   protojson.MarshalOptions{UseProtoNames: true}.Marshal,
+		unmarshal: protojson.Unmarshal,
+	},
+	{
+		name:      "protobuf",
+		mediaType: gateway.MIMEProtobuf,
+		marshal:   proto.Marshal,
+		unmarshal: proto.Unmarshal,
+	},
+}
+
+// TestRoundTrip calls every route of types.proto in both encodings, the gRPC
+// server must receive the message the client sent and the client must read
+// the message the server answered.
+func TestRoundTrip(t *testing.T) {
+	server := &echoServer{}
+	handler := newGateway(t, server)
+
+	services := pb.File_types_grpc_types_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+			for _, binding := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				verb, path := route(binding)
+				for _, enc := range encodings {
+					t.Run(fmt.Sprintf("%s %s %s", verb, path, enc.name), func(t *testing.T) {
+						roundTrip(t, handler, server, method, binding, enc)
+					})
+				}
+			}
+		}
+	}
+}
+
+func roundTrip(t *testing.T, handler http.Handler, server *echoServer, method protoreflect.MethodDescriptor, rule *annotations.HttpRule, enc encoding) {
+	verb, path := route(rule)
+	sent := newMessage(t, method.Input())
+	var body proto.Message
+	switch rule.GetBody() {
+	case "":
+	case "*":
+		fill(sent, 0)
+		body = sent.Interface()
+	default:
+		field := sent.Descriptor().Fields().ByName(protoreflect.Name(rule.GetBody()))
+		fill(sent.Mutable(field).Message(), 0)
+		body = sent.Get(field).Message().Interface()
+	}
+	// the variables of the path are set after the body, the gateway does the
+	// same when they name a field of it
+	path = pathVariable.ReplaceAllStringFunc(path, func(variable string) string {
+		return setPathField(t, sent, pathVariable.FindStringSubmatch(variable)[1])
+	})
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = enc.marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(verb, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", enc.mediaType)
+	req.Header.Set("Accept", enc.mediaType)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected %v, got %v: %s", http.StatusOK, rr.Code, rr.Body)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != enc.mediaType {
+		t.Errorf("expected the answer in %v, got %v", enc.mediaType, contentType)
+	}
+	if received := server.lastRequest(); !proto.Equal(received, sent.Interface()) {
+		t.Errorf("expected the server to receive %v, got %v", sent.Interface(), received)
+	}
+
+	answered := server.answer(method.Output())
+	if method.IsStreamingServer() {
+		for i, chunk := range readStream(t, rr.Body, enc, method.Output()) {
+			if !proto.Equal(chunk, answered) {
+				t.Errorf("chunk %d: expected %v, got %v", i, answered, chunk)
+			}
+		}
+		return
+	}
+
+	expected := answered
+	got := newMessage(t, method.Output())
+	data := rr.Body.Bytes()
+	// JSON only answers the response_body, protobuf the whole message
+	if responseBody := rule.GetResponseBody(); responseBody != "" && enc.name == "json" {
+		field := got.Descriptor().Fields().ByName(protoreflect.Name(responseBody))
+		expected = newMessage(t, method.Output()).Interface()
+		expected.ProtoReflect().Set(field, answered.ProtoReflect().Get(field))
+		data = []byte(fmt.Sprintf("{%q:%s}", responseBody, data))
+	}
+	if err := enc.unmarshal(data, got.Interface()); err != nil {
+		t.Fatalf("failed to decode the answer %s: %v", rr.Body, err)
+	}
+	if !proto.Equal(got.Interface(), expected) {
+		t.Errorf("expected the answer %v, got %v", expected, got.Interface())
+	}
+}
+
+// TestErrorStatus checks that the errors of the services and of the routing
+// are a google.rpc.Status in the encoding of the answer.
+func TestErrorStatus(t *testing.T) {
+	handler := newGateway(t, &echoServer{})
+
+	for _, enc := range encodings {
+		t.Run(enc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/finance", nil)
+			req.Header.Set("Accept", enc.mediaType)
+			req.Header.Set(runtime.MetadataHeaderPrefix+"Fail", "true")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusTooManyRequests {
+				t.Errorf("expected %v, got %v", http.StatusTooManyRequests, rr.Code)
+			}
+			if rr.Header().Get("Retry-After") == "" {
+				t.Errorf("expected a Retry-After header")
+			}
+			s := readStatus(t, rr, enc)
+			if codes.Code(s.GetCode()) != codes.ResourceExhausted || s.GetMessage() != "slow down" {
+				t.Errorf("expected the status of the server, got %v", s)
+			}
+			if len(s.GetDetails()) != 1 {
+				t.Errorf("expected the retry info in the details, got %v", s.GetDetails())
+			}
+
+			req = httptest.NewRequest(http.MethodGet, "/api/v1/missing", nil)
+			req.Header.Set("Accept", enc.mediaType)
+			rr = httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("expected %v, got %v", http.StatusNotFound, rr.Code)
+			}
+			if s := readStatus(t, rr, enc); codes.Code(s.GetCode()) != codes.NotFound {
+				t.Errorf("expected %v, got %v", codes.NotFound, s)
+			}
+		})
+	}
+}
+
+func TestAcceptNegotiation(t *testing.T) {
+	handler := newGateway(t, &echoServer{})
+	login, _ := protojson.Marshal(&pb.LoginRequest{Email: "user@gmail.com", Password: "asd"})
+
+	tests := []struct {
+		name     string
+		accept   []string
+		expected string
+	}{
+		{"should answer in the encoding of the body", nil, gateway.MIMEJSON},
+		{"should answer in the encoding of the body for a wildcard", []string{"*/*"}, gateway.MIMEJSON},
+		{"should answer in protobuf when asked", []string{gateway.MIMEProtobuf}, gateway.MIMEProtobuf},
+		{"should answer in the preferred encoding", []string{"application/json;q=0.5, application/x-protobuf"}, gateway.MIMEProtobuf},
+		{"should read every Accept header", []string{"text/html", "application/x-protobuf;q=0.9"}, gateway.MIMEProtobuf},
+		{"should ignore the encodings it doesn't know", []string{"text/html"}, gateway.MIMEJSON},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewReader(login))
+			req.Header.Set("Content-Type", gateway.MIMEJSON)
+			for _, accept := range test.accept {
+				req.Header.Add("Accept", accept)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected %v, got %v: %s", http.StatusOK, rr.Code, rr.Body)
+			}
+			if contentType := rr.Header().Get("Content-Type"); contentType != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, contentType)
+			}
+		})
+	}
+}
+
+func readStatus(t *testing.T, rr *httptest.ResponseRecorder, enc encoding) *spb.Status {
+	t.Helper()
+	if contentType := rr.Header().Get("Content-Type"); contentType != enc.mediaType {
+		t.Errorf("expected the status in %v, got %v", enc.mediaType, contentType)
+	}
+	s := &spb.Status{}
+	if err := enc.unmarshal(rr.Body.Bytes(), s); err != nil {
+		t.Fatalf("failed to decode the status %s: %v", rr.Body, err)
+	}
+	return s
+}
+
+// readStream reads the chunks of a server stream, JSON is a line per chunk
+// and protobuf a length delimited Any per chunk.
+func readStream(t *testing.T, body io.Reader, enc encoding, output protoreflect.MessageDescriptor) []proto.Message {
+	t.Helper()
+	var chunks []proto.Message
+	if enc.name == "json" {
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			var chunk map[string]json.RawMessage
+			if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+				t.Fatal(err)
+			}
+			message := newMessage(t, output).Interface()
+			if err := protojson.Unmarshal(chunk["result"], message); err != nil {
+				t.Fatalf("failed to decode the chunk %s: %v", scanner.Bytes(), err)
+			}
+			chunks = append(chunks, message)
+		}
+		return chunks
+	}
+	data, _ := io.ReadAll(body)
+	for len(data) > 0 {
+		buf, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		data = data[n:]
+		chunk := &anypb.Any{}
+		if err := proto.Unmarshal(buf, chunk); err != nil {
+			t.Fatal(err)
+		}
+		message, err := chunk.UnmarshalNew()
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, message)
+	}
+	if len(chunks) == 0 {
+		t.Errorf("expected the chunks of the stream")
+	}
+	return chunks
+}
+
+// echoServer answers every method of the API, it keeps the last request and
+// answers a message with every field set.
+type echoServer struct {
+	mu      sync.Mutex
+	request proto.Message
+}
+
+func (s *echoServer) lastRequest() proto.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.request
+}
+
+func (s *echoServer) answer(output protoreflect.MessageDescriptor) proto.Message {
+	mt, _ := protoregistry.GlobalTypes.FindMessageByName(output.FullName())
+	m := mt.New()
+	fill(m, 0)
+	return m.Interface()
+}
+
+func (s *echoServer) handle(srv any, stream grpc.ServerStream) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	if len(md.Get("fail")) > 0 {
+		return utils.RetryAfterError("slow down", time.Minute)
+	}
+	fullName, _ := grpc.MethodFromServerStream(stream)
+	method, err := findMethod(fullName)
+	if err != nil {
+		return err
+	}
+	mt, _ := protoregistry.GlobalTypes.FindMessageByName(method.Input().FullName())
+	request := mt.New().Interface()
+	if err := stream.RecvMsg(request); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.request = request
+	s.mu.Unlock()
+
+	answer := s.answer(method.Output())
+	if err := stream.SendMsg(answer); err != nil {
+		return err
+	}
+	if method.IsStreamingServer() {
+		return stream.SendMsg(answer)
+	}
+	return nil
+}
+
+// findMethod finds the descriptor of a method named /package.Service/Method.
+func findMethod(fullName string) (protoreflect.MethodDescriptor, error) {
+	var service, method string
+	for i := len(fullName) - 1; i > 0; i-- {
+		if fullName[i] == '/' {
+			service, method = fullName[1:i], fullName[i+1:]
+			break
+		}
+	}
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, err
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("unknown method %v", fullName)
+	}
+	return md, nil
+}
+
+// newGateway serves the gateway of every service in front of server.
+func newGateway(t *testing.T, server *echoServer) http.Handler {
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer(grpc.UnknownServiceHandler(server.handle))
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	gatewayMux := gateway.NewServeMux()
+	if err := RegisterServices(context.Background(), gatewayMux, conn); err != nil {
+		t.Fatal(err)
+	}
+	return gatewayMux
+}
+
+var pathVariable = regexp.MustCompile(`\{([^}=]+)(?:=[^}]*)?\}`)
+
+func route(rule *annotations.HttpRule) (string, string) {
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		return pattern.Custom.GetKind(), pattern.Custom.GetPath()
+	}
+	return "", ""
+}
+
+func newMessage(t *testing.T, d protoreflect.MessageDescriptor) protoreflect.Message {
+	t.Helper()
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(d.FullName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mt.New()
+}
+
+// setPathField sets the field a variable of the path names, like user.id,
+// and returns the segment of the path holding it.
+func setPathField(t *testing.T, m protoreflect.Message, fieldPath string) string {
+	t.Helper()
+	names := strings.Split(fieldPath, ".")
+	for _, name := range names[:len(names)-1] {
+		m = m.Mutable(m.Descriptor().Fields().ByName(protoreflect.Name(name))).Message()
+	}
+	field := m.Descriptor().Fields().ByName(protoreflect.Name(names[len(names)-1]))
+	if field == nil {
+		t.Fatalf("unknown field %v in the path", fieldPath)
+	}
+	if field.Kind() == protoreflect.StringKind {
+		m.Set(field, protoreflect.ValueOfString("segment"))
+		return "segment"
+	}
+	m.Set(field, scalar(field))
+	return fmt.Sprint(m.Get(field).Interface())
+}
+
+// fill sets every field of m, the nested messages a few levels deep.
+func fill(m protoreflect.Message, depth int) {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if oneof := field.ContainingOneof(); oneof != nil && m.WhichOneof(oneof) != nil {
+			continue
+		}
+		switch {
+		case field.IsMap():
+			if field.MapValue().Message() == nil {
+				m.Mutable(field).Map().Set(scalar(field.MapKey()).MapKey(), scalar(field.MapValue()))
+			}
+		case field.IsList():
+			list := m.Mutable(field).List()
+			if field.Message() == nil {
+				list.Append(scalar(field))
+			} else if depth < 3 {
+				fill(list.AppendMutable().Message(), depth+1)
+			}
+		case field.Message() != nil:
+			if depth < 3 {
+				fill(m.Mutable(field).Message(), depth+1)
+			}
+		default:
+			m.Set(field, scalar(field))
+		}
+	}
+}
+
+func scalar(field protoreflect.FieldDescriptor) protoreflect.Value {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(true)
+	case protoreflect.EnumKind:
+		values := field.Enum().Values()
+		return protoreflect.ValueOfEnum(values.Get(values.Len() - 1).Number())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(7)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(7)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(7)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(7)
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(1.5)
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(1.5)
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(field.Name()))
+	}
+	return protoreflect.ValueOfString(string(field.Name()))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fayleenpc/tj-jeans/internal/catalog"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/db"
+	"github.com/fayleenpc/tj-jeans/services/products"
+	mysqlCfg "github.com/go-sql-driver/mysql"
+)
+
+const usage = `usage:
+  catalog export [-format csv|json] [-o file]
+  catalog import [-format csv|json] [-dry-run] file`
+
+// catalog exports the products to a CSV or JSON file and imports them back
+// by SKU, the files are the ones of the /products/import and /products/export
+// endpoints. import exits with 1 when a row failed.
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	db, err := db.NewMySQLStorage(mysqlCfg.Config{
+		User:                 config.Envs.DBUser,
+		Passwd:               config.Envs.DBPassword,
+		Addr:                 config.Envs.DBAddress,
+		DBName:               config.Envs.DBName,
+		Net:                  "tcp",
+		AllowNativePasswords: true,
+		ParseTime:            true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	store := products.NewStore(db)
+
+	switch os.Args[1] {
+	case "export":
+		flags := flag.NewFlagSet("export", flag.ExitOnError)
+		format := flags.String("format", catalog.FormatCSV, "csv or json")
+		out := flags.String("o", "", "the file to write, stdout by default")
+		flags.Parse(os.Args[2:])
+
+		var w io.Writer = os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		all, err := store.GetProducts()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := catalog.Export(w, *format, all); err != nil {
+			log.Fatal(err)
+		}
+	case "import":
+		flags := flag.NewFlagSet("import", flag.ExitOnError)
+		format := flags.String("format", "", "csv or json, the extension of the file by default")
+		dryRun := flags.Bool("dry-run", false, "only report what would change")
+		flags.Parse(os.Args[2:])
+		if flags.NArg() != 1 {
+			log.Fatal(usage)
+		}
+
+		path := flags.Arg(0)
+		if *format == "" {
+			*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		rows, rowErrors, err := catalog.Parse(f, *format)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		result := catalog.NewImporter(store).Import(context.Background(), rows, rowErrors, *dryRun, nil)
+		for _, e := range result.Errors {
+			field := ""
+			if e.Field != "" {
+				field = " " + e.Field
+			}
+			fmt.Fprintf(os.Stderr, "row %d%s: %s\n", e.Row, field, e.Message)
+		}
+		verb := "imported"
+		if result.DryRun {
+			verb = "would import"
+		}
+		fmt.Printf("%s %d of %d rows, %d created and %d updated\n", verb, result.Created+result.Updated, result.Total, result.Created, result.Updated)
+		if len(result.Errors) > 0 {
+			os.Exit(1)
+		}
+	default:
+		log.Fatal(usage)
+	}
+}
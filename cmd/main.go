@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/fayleenpc/tj-jeans/cmd/api"
+	"github.com/fayleenpc/tj-jeans/cmd/api_grpc"
 	"github.com/fayleenpc/tj-jeans/cmd/api_proto"
 	"github.com/fayleenpc/tj-jeans/internal/config"
 	"github.com/fayleenpc/tj-jeans/internal/db"
@@ -13,30 +18,7 @@ import (
 	"github.com/fayleenpc/tj-jeans/services/tokenize"
 	"github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
-)
-
-// @title           Swagger Example API
-// @version         1.0
-// @description     This is a sample server celler server.
-// @termsOfService  http://swagger.io/terms/
-
-// @contact.name   API Support
-// @contact.url    http://www.swagger.io/support
-// @contact.email  support@swagger.io
-
-// @license.name  Apache 2.0
-// @license.url   http://www.apache.org/licenses/LICENSE-2.0.html
-
-// @host      localhost:8080
-// @BasePath  /api/v1
-
-// @securityDefinitions.basic  BasicAuth
-
-// @externalDocs.description  OpenAPI
-// @externalDocs.url          https://swagger.io/resources/open-api/
-
-var (
-	grpcAddr = ":2000"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -55,22 +37,38 @@ func main() {
 	}
 	initStorage(db)
 
-	// gRPC API
-	// grpcApiServer := api_grpc.NewApiServerGRPC(":8082", grpc.NewServer(), db)
-
-	// go grpcApiServer.Run()
+	// the gRPC API finishes its calls in flight on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// REST PROTOBUF API
-	restApiProtobuf := api_proto.NewApiProtobufServer(":8082", db)
-
-	go restApiProtobuf.Run()
+	// gRPC API
+	grpcApiServer := api_grpc.NewApiServerGRPC(":"+config.Envs.PortGRPC, grpc.NewServer(api_grpc.ServerOptions(db)...), db)
+	grpcStopped := make(chan struct{})
+	go func() {
+		defer close(grpcStopped)
+		if err := grpcApiServer.Run(ctx); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+
+	// REST PROTOBUF API, the gateway of the gRPC API
+	restApiProtobuf := api_proto.NewApiProtobufServer(":"+config.Envs.PortProto, "localhost:"+config.Envs.PortGRPC)
+
+	go func() {
+		if err := restApiProtobuf.Run(ctx); err != nil {
+			log.Printf("gRPC gateway stopped: %v", err)
+		}
+	}()
 
 	// REST API
 	restApiServer := api.NewAPIServer(":8081", db)
 
 	go restApiServer.Run()
 
-	startWeb(db)
+	go startWeb(db)
+
+	<-ctx.Done()
+	<-grpcStopped
 }
 
 func startWeb(db *sql.DB) {
@@ -88,7 +86,7 @@ func startWeb(db *sql.DB) {
 	// web
 	web := web.NewHandler(tokenStore)
 	web.RegisterRoutes(router)
-	log.Fatal(http.ListenAndServe(":8080", router))
+	log.Fatal(http.ListenAndServe(":"+config.Envs.PortWeb, router))
 }
 
 func initStorage(db *sql.DB) {
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS verification_tokens;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+  `userId` INT UNSIGNED NOT NULL,
+  `revokedAt` TIMESTAMP(3) NOT NULL,
+
+  PRIMARY KEY (userId),
+  FOREIGN KEY (`userId`) REFERENCES users(`id`)
+);
\ No newline at end of file
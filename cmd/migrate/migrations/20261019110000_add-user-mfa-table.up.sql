@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS user_mfa (
+  `userId` INT UNSIGNED NOT NULL,
+  `secret` VARCHAR(64) NOT NULL,
+  `enabled` BOOLEAN NOT NULL DEFAULT FALSE,
+  `lastUsedStep` BIGINT NOT NULL DEFAULT 0,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `enabledAt` TIMESTAMP NULL DEFAULT NULL,
+
+  PRIMARY KEY (userId),
+  FOREIGN KEY (`userId`) REFERENCES users(`id`)
+);
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `userId` INT UNSIGNED NOT NULL,
+  `provider` VARCHAR(64) NOT NULL,
+  `subject` VARCHAR(255) NOT NULL,
+  `email` VARCHAR(255) NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `lastLoginAt` TIMESTAMP NULL DEFAULT NULL,
+
+  PRIMARY KEY (id),
+  UNIQUE KEY (provider, subject),
+  FOREIGN KEY (`userId`) REFERENCES users(`id`)
+);
\ No newline at end of file
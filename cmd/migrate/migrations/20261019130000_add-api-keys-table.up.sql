@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `userId` INT UNSIGNED NOT NULL,
+  `name` VARCHAR(255) NOT NULL,
+  `prefix` VARCHAR(16) NOT NULL,
+  `keyHash` CHAR(64) NOT NULL,
+  `scopes` VARCHAR(1024) NOT NULL,
+  `expiresAt` TIMESTAMP NULL DEFAULT NULL,
+  `lastUsedAt` TIMESTAMP NULL DEFAULT NULL,
+  `revokedAt` TIMESTAMP NULL DEFAULT NULL,
+  `createdBy` INT UNSIGNED NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  UNIQUE KEY (keyHash),
+  FOREIGN KEY (`userId`) REFERENCES users(`id`),
+  FOREIGN KEY (`createdBy`) REFERENCES users(`id`)
+);
\ No newline at end of file
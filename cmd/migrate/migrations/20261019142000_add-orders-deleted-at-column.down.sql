@@ -0,0 +1 @@
+ALTER TABLE orders DROP COLUMN `deletedAt`;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE orders ADD COLUMN `deletedAt` TIMESTAMP NULL DEFAULT NULL;
\ No newline at end of file
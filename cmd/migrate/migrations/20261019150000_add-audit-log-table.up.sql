@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `actorId` INT UNSIGNED NOT NULL DEFAULT 0,
+  `apiKeyId` INT UNSIGNED NOT NULL DEFAULT 0,
+  `action` VARCHAR(32) NOT NULL,
+  `entity` VARCHAR(64) NOT NULL,
+  `entityId` INT UNSIGNED NOT NULL,
+  `changes` JSON NOT NULL,
+  `ip` VARCHAR(64) NOT NULL,
+  `requestId` VARCHAR(64) NOT NULL,
+  `transport` VARCHAR(16) NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  INDEX (`actorId`),
+  INDEX (`entity`, `entityId`),
+  INDEX (`requestId`),
+  INDEX (`createdAt`)
+);
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS stock_movements (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `productId` INT UNSIGNED NOT NULL,
+  `type` ENUM('receipt', 'sale', 'return', 'adjustment', 'reservation') NOT NULL,
+  `qty` INT NOT NULL,
+  `reason` VARCHAR(255) NOT NULL,
+  `reference` VARCHAR(255) NOT NULL,
+  `actorId` INT UNSIGNED NOT NULL DEFAULT 0,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  FOREIGN KEY (`productId`) REFERENCES products(`id`) ON DELETE CASCADE,
+  INDEX (`productId`, `createdAt`),
+  INDEX (`reference`)
+);
\ No newline at end of file
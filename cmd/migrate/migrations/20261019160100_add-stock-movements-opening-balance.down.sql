@@ -0,0 +1 @@
+DELETE FROM stock_movements WHERE `reference` = 'migration:opening-balance';
\ No newline at end of file
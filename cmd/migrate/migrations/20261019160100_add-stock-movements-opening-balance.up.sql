@@ -0,0 +1,2 @@
+INSERT INTO stock_movements (`productId`, `type`, `qty`, `reason`, `reference`)
+SELECT `id`, 'adjustment', `qty`, 'opening balance', 'migration:opening-balance' FROM products WHERE `qty` <> 0;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS stock_thresholds;
\ No newline at end of file
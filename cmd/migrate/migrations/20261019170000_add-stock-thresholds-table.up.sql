@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS stock_thresholds (
+  `productId` INT UNSIGNED NOT NULL,
+  `threshold` INT UNSIGNED NOT NULL,
+  `updatedAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (`productId`),
+  FOREIGN KEY (`productId`) REFERENCES products(`id`) ON DELETE CASCADE
+);
\ No newline at end of file
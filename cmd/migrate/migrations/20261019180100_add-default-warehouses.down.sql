@@ -0,0 +1 @@
+DELETE FROM warehouses WHERE `id` IN (1, 2);
\ No newline at end of file
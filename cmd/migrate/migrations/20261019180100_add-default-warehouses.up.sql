@@ -0,0 +1,3 @@
+INSERT INTO warehouses (`id`, `name`, `address`, `latitude`, `longitude`) VALUES
+  (1, 'Jakarta Shop', 'Jakarta', -6.208800, 106.845600),
+  (2, 'Bandung Warehouse', 'Bandung', -6.917500, 107.619100);
\ No newline at end of file
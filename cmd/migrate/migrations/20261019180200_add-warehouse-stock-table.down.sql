@@ -0,0 +1 @@
+DROP TABLE IF EXISTS warehouse_stock;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+  `warehouseId` INT UNSIGNED NOT NULL,
+  `productId` INT UNSIGNED NOT NULL,
+  `qty` INT NOT NULL,
+
+  PRIMARY KEY (`warehouseId`, `productId`),
+  FOREIGN KEY (`warehouseId`) REFERENCES warehouses(`id`),
+  FOREIGN KEY (`productId`) REFERENCES products(`id`) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DELETE FROM warehouse_stock;
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT INTO warehouse_stock (`warehouseId`, `productId`, `qty`)
+SELECT 1, `id`, `qty` FROM products WHERE `qty` <> 0;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE stock_movements DROP FOREIGN KEY `stock_movements_ibfk_2`, DROP COLUMN `warehouseId`;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE stock_movements ALTER COLUMN `warehouseId` SET DEFAULT 1;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE stock_movements ALTER COLUMN `warehouseId` DROP DEFAULT;
\ No newline at end of file
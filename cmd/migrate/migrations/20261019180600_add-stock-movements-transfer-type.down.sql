@@ -0,0 +1 @@
+ALTER TABLE stock_movements MODIFY `type` ENUM('receipt', 'sale', 'return', 'adjustment', 'reservation') NOT NULL;
\ No newline at end of file
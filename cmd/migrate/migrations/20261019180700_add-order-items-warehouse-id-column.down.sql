@@ -0,0 +1 @@
+ALTER TABLE order_items DROP FOREIGN KEY `order_items_ibfk_3`, DROP COLUMN `warehouseId`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE order_items
+  ADD COLUMN `warehouseId` INT UNSIGNED NOT NULL DEFAULT 1,
+  ADD FOREIGN KEY (`warehouseId`) REFERENCES warehouses(`id`);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE order_items ALTER COLUMN `warehouseId` SET DEFAULT 1;
\ No newline at end of file
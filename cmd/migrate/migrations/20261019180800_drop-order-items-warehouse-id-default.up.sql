@@ -0,0 +1 @@
+ALTER TABLE order_items ALTER COLUMN `warehouseId` DROP DEFAULT;
\ No newline at end of file
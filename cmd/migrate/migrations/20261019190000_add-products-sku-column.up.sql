@@ -0,0 +1 @@
+ALTER TABLE products ADD COLUMN `sku` VARCHAR(64) NULL DEFAULT NULL, ADD UNIQUE KEY (`sku`);
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE products SET `sku` = NULL WHERE `sku` = CONCAT('TJ-', LPAD(`id`, 5, '0'));
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE products SET `sku` = CONCAT('TJ-', LPAD(`id`, 5, '0')) WHERE `sku` IS NULL;
\ No newline at end of file
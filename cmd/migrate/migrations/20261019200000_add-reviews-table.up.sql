@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS reviews (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `productId` INT UNSIGNED NOT NULL,
+  `userId` INT UNSIGNED NOT NULL,
+  `rating` TINYINT UNSIGNED NOT NULL,
+  `body` TEXT NOT NULL,
+  `photos` JSON NOT NULL,
+  `status` ENUM('pending', 'approved', 'hidden') NOT NULL DEFAULT 'pending',
+  `reply` TEXT NULL,
+  `repliedAt` TIMESTAMP NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY (`productId`, `userId`),
+  INDEX (`status`),
+  FOREIGN KEY (`productId`) REFERENCES products(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`userId`) REFERENCES users(`id`) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE products DROP COLUMN `reviewCount`, DROP COLUMN `rating`;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE products ADD COLUMN `rating` DECIMAL(3, 2) NOT NULL DEFAULT 0, ADD COLUMN `reviewCount` INT UNSIGNED NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS wishlist;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS wishlist (
+  `userId` INT UNSIGNED NOT NULL,
+  `productId` INT UNSIGNED NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (`userId`, `productId`),
+  FOREIGN KEY (`userId`) REFERENCES users(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`productId`) REFERENCES products(`id`) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS restock_subscriptions;
\ No newline at end of file
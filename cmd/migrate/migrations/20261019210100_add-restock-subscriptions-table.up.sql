@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS restock_subscriptions (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `userId` INT UNSIGNED NOT NULL,
+  `productId` INT UNSIGNED NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `expiresAt` TIMESTAMP NOT NULL,
+  `notifiedAt` TIMESTAMP NULL,
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY (`userId`, `productId`),
+  INDEX (`productId`, `notifiedAt`),
+  INDEX (`expiresAt`),
+  FOREIGN KEY (`userId`) REFERENCES users(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`productId`) REFERENCES products(`id`) ON DELETE CASCADE
+);
\ No newline at end of file
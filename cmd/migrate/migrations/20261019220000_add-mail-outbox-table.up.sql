@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS mail_outbox (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `template` VARCHAR(64) NOT NULL,
+  `recipients` TEXT NOT NULL,
+  `subject` VARCHAR(255) NOT NULL,
+  `textBody` MEDIUMTEXT NOT NULL,
+  `htmlBody` MEDIUMTEXT NOT NULL,
+  `attempts` INT UNSIGNED NOT NULL DEFAULT 0,
+  `lastError` VARCHAR(1024) NOT NULL DEFAULT '',
+  `nextAttemptAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `sentAt` TIMESTAMP NULL,
+  `failedAt` TIMESTAMP NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (`id`),
+  INDEX (`sentAt`, `failedAt`, `nextAttemptAt`),
+  INDEX (`template`)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE orders DROP COLUMN `phoneNumber`;
\ No newline at end of file
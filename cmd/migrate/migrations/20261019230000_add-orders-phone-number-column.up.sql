@@ -0,0 +1 @@
+ALTER TABLE orders ADD COLUMN `phoneNumber` VARCHAR(255) NOT NULL DEFAULT '';
\ No newline at end of file
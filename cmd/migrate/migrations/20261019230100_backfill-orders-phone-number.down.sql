@@ -0,0 +1 @@
+UPDATE orders o JOIN users u ON u.id = o.userId SET o.phoneNumber = '' WHERE o.phoneNumber = u.phoneNumber;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE orders o JOIN users u ON u.id = o.userId SET o.phoneNumber = u.phoneNumber WHERE o.phoneNumber = '';
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS notifications (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `userId` INT UNSIGNED NOT NULL,
+  `kind` VARCHAR(64) NOT NULL,
+  `title` VARCHAR(255) NOT NULL,
+  `body` VARCHAR(1024) NOT NULL,
+  `link` VARCHAR(255) NOT NULL DEFAULT '',
+  `readAt` TIMESTAMP NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (`id`),
+  INDEX (`userId`, `readAt`),
+  FOREIGN KEY (`userId`) REFERENCES users(`id`) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS domain_events;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS domain_events (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `topic` VARCHAR(32) NOT NULL,
+  `kind` VARCHAR(32) NOT NULL,
+  `entityId` INT UNSIGNED NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (`id`),
+  INDEX (`topic`, `id`)
+);
\ No newline at end of file
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/db"
+	"github.com/fayleenpc/tj-jeans/services/products"
+	mysqlCfg "github.com/go-sql-driver/mysql"
+)
+
+// reconcile reports the products whose cached products.qty or warehouse_stock
+// drifted from the sum of their stock ledger, it exits with 1 when there is drift so it can
+// run from cron or CI.
+func main() {
+	db, err := db.NewMySQLStorage(mysqlCfg.Config{
+		User:                 config.Envs.DBUser,
+		Passwd:               config.Envs.DBPassword,
+		Addr:                 config.Envs.DBAddress,
+		DBName:               config.Envs.DBName,
+		Net:                  "tcp",
+		AllowNativePasswords: true,
+		ParseTime:            true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	drift, err := products.NewStore(db).GetStockDrift()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(drift) == 0 {
+		fmt.Println("stock is reconciled, products.qty matches the ledger")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PRODUCT\tWAREHOUSE\tNAME\tQTY\tLEDGER\tDRIFT")
+	for _, d := range drift {
+		warehouse := "all"
+		if d.WarehouseID != 0 {
+			warehouse = fmt.Sprint(d.WarehouseID)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%+d\n", d.ProductID, warehouse, d.Name, d.Quantity, d.LedgerQty, d.Quantity-d.LedgerQty)
+	}
+	w.Flush()
+	os.Exit(1)
+}
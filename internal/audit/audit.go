@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+const (
+	EntityUser      = "user"
+	EntityProduct   = "product"
+	EntityOrder     = "order"
+	EntityOrderItem = "order_item"
+	EntityAPIKey    = "api_key"
+	EntityReview    = "review"
+	// EntityStock is the stock of a product, its ID is the product's.
+	EntityStock     = "stock"
+	EntityWarehouse = "warehouse"
+)
+
+// personalFields are recorded as changed without their values, the log is
+// append-only and deleted accounts must not leave personal data behind.
+var personalFields = map[string]bool{
+	"first_name":   true,
+	"last_name":    true,
+	"email":        true,
+	"phone_number": true,
+	"address":      true,
+}
+
+const redacted = "[redacted]"
+
+// secretFields are left out of the log, the gRPC messages carry the password
+// hashes the JSON types hide.
+var secretFields = map[string]bool{
+	"password": true,
+}
+
+// Recorder writes the audit log entries of the actions made by the handlers
+// and the services.
+type Recorder struct {
+	store types.AuditStore
+	now   func() time.Time
+}
+
+func NewRecorder(store types.AuditStore) *Recorder {
+	return &Recorder{store: store, now: time.Now}
+}
+
+var recorder *Recorder
+
+// SetDefault sets the recorder used by Record, nothing is recorded until the
+// servers set one.
+func SetDefault(r *Recorder) {
+	recorder = r
+}
+
+// Record writes an entry with the default recorder, see Recorder.Record.
+func Record(ctx context.Context, action string, entity string, entityID int, before any, after any) {
+	if recorder == nil {
+		return
+	}
+	recorder.Record(ctx, action, entity, entityID, before, after)
+}
+
+// Record writes an entry for the action on the entity, the actor and the
+// request come from the context. Before is nil for creates and after is nil
+// for deletes. A failed write is logged and doesn't fail the action, it has
+// already happened.
+func (rec *Recorder) Record(ctx context.Context, action string, entity string, entityID int, before any, after any) {
+	diff, err := Diff(before, after)
+	if err != nil {
+		log.Printf("failed to diff the audit log of %s %s %v: %v", action, entity, entityID, err)
+		return
+	}
+	for name, change := range diff {
+		if secretFields[name] {
+			delete(diff, name)
+			continue
+		}
+		if personalFields[name] {
+			diff[name] = Change{Before: redact(change.Before), After: redact(change.After)}
+		}
+	}
+	changes, err := json.Marshal(diff)
+	if err != nil {
+		log.Printf("failed to encode the audit log of %s %s %v: %v", action, entity, entityID, err)
+		return
+	}
+	info := RequestInfoFromContext(ctx)
+	entry := types.AuditLog{
+		ActorID:   auth.GetUserIDFromContext(ctx),
+		APIKeyID:  auth.GetAPIKeyIDFromContext(ctx),
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Changes:   changes,
+		IP:        info.IP,
+		RequestID: info.ID,
+		Transport: info.Transport,
+		CreatedAt: rec.now(),
+	}
+	if entry.ActorID < 0 {
+		entry.ActorID = 0
+	}
+	if err := rec.store.CreateAuditLog(entry); err != nil {
+		log.Printf("failed to write the audit log of %s %s %v: %v", action, entity, entityID, err)
+	}
+}
+
+// Change is the value of a field before and after an action.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Diff compares the JSON fields of before and after and returns the ones that
+// changed, fields hidden from JSON like password hashes are never recorded.
+func Diff(before any, after any) (map[string]Change, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for name, value := range beforeFields {
+		if afterValue, ok := afterFields[name]; !ok || !reflect.DeepEqual(value, afterValue) {
+			changes[name] = Change{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = Change{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func redact(v any) any {
+	if v == nil {
+		return nil
+	}
+	return redacted
+}
+
+func jsonFields(v any) (map[string]any, error) {
+	fields := map[string]any{}
+	if v == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return fields, nil
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
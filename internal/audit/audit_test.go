@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+func TestDiff(t *testing.T) {
+	type product struct {
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+		Stock int     `json:"stock"`
+	}
+	before := product{Name: "Jeans", Price: 100, Stock: 3}
+	after := product{Name: "Jeans", Price: 120, Stock: 3}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes["price"].Before != 100.0 || changes["price"].After != 120.0 {
+		t.Errorf("expected only the price to change, got %+v", changes)
+	}
+
+	changes, err = Diff(nil, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 || changes["name"].Before != nil || changes["name"].After != "Jeans" {
+		t.Errorf("expected every field to be created, got %+v", changes)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	store := &mockAuditStore{}
+	rec := NewRecorder(store)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	rec.now = func() time.Time { return now }
+
+	ctx := context.WithValue(context.Background(), auth.UserKey, 7)
+	ctx = ContextWithRequestInfo(ctx, RequestInfo{ID: "req-1", IP: "10.0.0.1", Transport: TransportREST})
+	before := map[string]any{"id": 2, "first_name": "Jane", "password": "hash", "role": "customer"}
+	after := map[string]any{"id": 2, "first_name": "Janet", "password": "other hash", "role": "admin"}
+
+	rec.Record(ctx, ActionUpdate, EntityUser, 2, before, after)
+
+	if len(store.entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(store.entries))
+	}
+	entry := store.entries[0]
+	if entry.ActorID != 7 || entry.RequestID != "req-1" || entry.IP != "10.0.0.1" || entry.Transport != TransportREST || !entry.CreatedAt.Equal(now) {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	var changes map[string]Change
+	if err := json.Unmarshal(entry.Changes, &changes); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := changes["password"]; ok {
+		t.Error("expected the password not to be recorded")
+	}
+	if changes["first_name"].Before != redacted || changes["first_name"].After != redacted {
+		t.Errorf("expected the name to be redacted, got %+v", changes["first_name"])
+	}
+	if changes["role"].Before != "customer" || changes["role"].After != "admin" {
+		t.Errorf("expected the role change to be recorded, got %+v", changes["role"])
+	}
+	if _, ok := changes["id"]; ok {
+		t.Error("expected unchanged fields not to be recorded")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var info RequestInfo
+	handler := Middleware(TransportREST)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = RequestInfoFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "from-the-client")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if info.ID != "from-the-client" || rr.Header().Get(RequestIDHeader) != "from-the-client" || info.Transport != TransportREST {
+		t.Errorf("expected the request ID of the client to be kept, got %+v", info)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "not valid\n")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if len(info.ID) != 32 || rr.Header().Get(RequestIDHeader) != info.ID {
+		t.Errorf("expected a new request ID, got %q", info.ID)
+	}
+}
+
+type mockAuditStore struct {
+	entries []types.AuditLog
+}
+
+func (m *mockAuditStore) CreateAuditLog(entry types.AuditLog) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+func (m *mockAuditStore) GetAuditLogs(types.AuditLogFilter) ([]types.AuditLog, error) {
+	return m.entries, nil
+}
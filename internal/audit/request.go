@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	TransportREST     = "rest"
+	TransportProtobuf = "protobuf"
+	TransportGRPC     = "grpc"
+)
+
+// RequestIDHeader is read from the clients and proxies when set, and sent
+// back so entries can be matched with the logs of the request.
+const RequestIDHeader = "X-Request-Id"
+
+type contextKey string
+
+const requestInfoKey contextKey = "auditRequestInfo"
+
+// RequestInfo is the request an action was made in.
+type RequestInfo struct {
+	ID        string
+	IP        string
+	Transport string
+}
+
+func ContextWithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey, info)
+}
+
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey).(RequestInfo)
+	return info
+}
+
+// Middleware tags every request with an ID and the client address for the
+// entries written while it is handled.
+func Middleware(transport string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+			ctx := ContextWithRequestInfo(r.Context(), RequestInfo{ID: requestID, IP: utils.ClientIP(r), Transport: transport})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+var (
+	grpcTrustedProxiesOnce sync.Once
+	grpcTrustedProxies     []*net.IPNet
+)
+
+// UnaryServerInterceptor does what Middleware does for gRPC calls, the ID is
+// read from the x-request-id metadata.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(contextWithGRPCRequestInfo(ctx), req)
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: contextWithGRPCRequestInfo(ss.Context())})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func contextWithGRPCRequestInfo(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := ""
+	if ids := md.Get("x-request-id"); len(ids) > 0 {
+		requestID = ids[0]
+	}
+	if !validRequestID(requestID) {
+		requestID = newRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID))
+
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	grpcTrustedProxiesOnce.Do(func() {
+		grpcTrustedProxies = utils.ParseTrustedProxies(config.Envs.TrustedProxies)
+	})
+	ip := utils.ClientIPWithProxies(remoteAddr, md.Get("x-forwarded-for"), grpcTrustedProxies)
+	return ContextWithRequestInfo(ctx, RequestInfo{ID: requestID, IP: ip, Transport: TransportGRPC})
+}
+
+// validRequestID keeps IDs sent by clients short and printable.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
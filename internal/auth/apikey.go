@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/gorilla/mux"
+)
+
+const APIKeyIDKey contextKey = "apiKeyID"
+
+// apiKeyTouchInterval limits how often the last use of a key is written.
+const apiKeyTouchInterval = time.Minute
+
+var errInvalidAPIKey = fmt.Errorf("invalid api key")
+
+// GenerateAPIKey returns a new key and its prefix, the prefix is kept in the
+// clear to tell keys apart in listings.
+func GenerateAPIKey() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key := "tjk_" + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:12], nil
+}
+
+// A scope is "<resource>:<read|write|*>" or "*" for everything. The resource
+// of a REST route is its first segment after /api/v1, e.g. products for
+// /api/v1/products/{productID}, GET and HEAD requests read it.
+var scopePattern = regexp.MustCompile(`^(\*|[a-z_]+:(read|write|\*))$`)
+
+func ValidScope(scope string) bool {
+	return scopePattern.MatchString(scope)
+}
+
+func ScopeAllows(scopes []string, required string) bool {
+	resource, _, _ := strings.Cut(required, ":")
+	for _, scope := range scopes {
+		if scope == "*" || scope == required || scope == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+func RequiredScope(method string, route string) string {
+	route = strings.TrimPrefix(strings.TrimPrefix(route, "/api/v1"), "/")
+	resource, _, _ := strings.Cut(route, "/")
+	if method == http.MethodGet || method == http.MethodHead {
+		return resource + ":read"
+	}
+	return resource + ":write"
+}
+
+// credentialRoutes manage the credentials of a user, they always need the
+// tokens of a signed in user so a leaked key can't be turned into more access.
+var credentialRoutes = []string{"/api/v1/me/password", "/api/v1/me/mfa", "/api/v1/api_keys", "/api/v1/logout"}
+
+func isCredentialRoute(route string) bool {
+	for _, prefix := range credentialRoutes {
+		if strings.HasPrefix(route, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthenticateAPIKey returns the user of an active key that has scope, the
+// key acts with the role of its user like the user tokens do.
+func AuthenticateAPIKey(key string, scope string, userStore types.UserStore, tokenStore types.TokenStore, now time.Time) (*types.User, *types.APIKey, error) {
+	k, err := tokenStore.GetAPIKeyByHash(HashToken(key))
+	if err != nil {
+		return nil, nil, errInvalidAPIKey
+	}
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)) {
+		return nil, nil, errInvalidAPIKey
+	}
+	if !ScopeAllows(k.Scopes, scope) {
+		return nil, nil, fmt.Errorf("api key %v is missing scope %v", k.Prefix, scope)
+	}
+	u, err := userStore.GetUserByID(k.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user by id: %v", err)
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchInterval {
+		if err := tokenStore.TouchAPIKey(k.ID, now); err != nil {
+			log.Printf("failed to record use of api key %v: %v", k.Prefix, err)
+		}
+	}
+	return u, k, nil
+}
+
+func withAPIKey(handlerFunc http.HandlerFunc, w http.ResponseWriter, r *http.Request, apiKey string, userStore types.UserStore, tokenStore types.TokenStore) {
+	route := r.URL.Path
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			route = template
+		}
+	}
+	if isCredentialRoute(route) {
+		log.Printf("api key used on credential route %v", route)
+		permissionDenied(w)
+		return
+	}
+
+	u, k, err := AuthenticateAPIKey(apiKey, RequiredScope(r.Method, route), userStore, tokenStore, time.Now())
+	if err != nil {
+		log.Printf("failed to authenticate api key: %v", err)
+		permissionDenied(w)
+		return
+	}
+
+	ctx := ContextWithUser(r.Context(), u)
+	ctx = context.WithValue(ctx, APIKeyIDKey, k.ID)
+	log.Printf("USER DATA BACKEND ( userID=%v, userRole=%v, apiKey=%v )\n", u.ID, u.Role, k.Prefix)
+	handlerFunc(w, r.WithContext(ctx))
+}
+
+// ContextWithUser sets the user values the handlers read from the context.
+func ContextWithUser(ctx context.Context, u *types.User) context.Context {
+	ctx = context.WithValue(ctx, UserKey, u.ID)
+	ctx = context.WithValue(ctx, UserRoleKey, u.Role)
+	ctx = context.WithValue(ctx, UserEmailKey, u.Email)
+	ctx = context.WithValue(ctx, UserNameKey, u.FirstName+" "+u.LastName)
+	ctx = context.WithValue(ctx, UserPhoneNumberKey, u.PhoneNumber)
+	return context.WithValue(ctx, userAddressKey, u.Address)
+}
+
+// GetAPIKeyIDFromContext returns the key the request was made with, 0 for user tokens.
+func GetAPIKeyIDFromContext(ctx context.Context) int {
+	apiKeyID, _ := ctx.Value(APIKeyIDKey).(int)
+	return apiKeyID
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, prefix) || len(prefix) != 12 {
+		t.Errorf("unexpected key %q with prefix %q", key, prefix)
+	}
+	other, _, _ := GenerateAPIKey()
+	if key == other {
+		t.Error("expected keys to be random")
+	}
+}
+
+func TestScopes(t *testing.T) {
+	for scope, valid := range map[string]bool{
+		"*":              true,
+		"products:read":  true,
+		"orders:write":   true,
+		"products:*":     true,
+		"products":       false,
+		"products:admin": false,
+		"Products:read":  false,
+	} {
+		if ValidScope(scope) != valid {
+			t.Errorf("expected ValidScope(%q) to be %v", scope, valid)
+		}
+	}
+
+	tests := []struct {
+		scopes   []string
+		required string
+		allowed  bool
+	}{
+		{[]string{"products:read"}, "products:read", true},
+		{[]string{"products:read"}, "products:write", false},
+		{[]string{"products:*"}, "products:write", true},
+		{[]string{"orders:*"}, "products:read", false},
+		{[]string{"*"}, "orders:write", true},
+	}
+	for _, test := range tests {
+		if ScopeAllows(test.scopes, test.required) != test.allowed {
+			t.Errorf("expected ScopeAllows(%v, %q) to be %v", test.scopes, test.required, test.allowed)
+		}
+	}
+}
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method string
+		route  string
+		scope  string
+	}{
+		{"GET", "/api/v1/products/{productID}", "products:read"},
+		{"POST", "/api/v1/products", "products:write"},
+		{"DELETE", "/api/v1/cart/checkout", "cart:write"},
+	}
+	for _, test := range tests {
+		if scope := RequiredScope(test.method, test.route); scope != test.scope {
+			t.Errorf("expected %v %v to need %q, got %q", test.method, test.route, test.scope, scope)
+		}
+	}
+
+	if scope := GRPCRequiredScope("/types.ProductService/GetProducts"); scope != "products:read" {
+		t.Errorf("expected products:read, got %q", scope)
+	}
+	if scope := GRPCRequiredScope("/types.TokenService/CreateAPIKey"); scope != "tokens:write" {
+		t.Errorf("expected tokens:write, got %q", scope)
+	}
+	if scope := GRPCRequiredScope("/types.OrderItemService/GetOrderItems"); scope != "order_items:read" {
+		t.Errorf("expected the scope of the order_items routes, got %q", scope)
+	}
+	if scope := GRPCRequiredScope("/types.CheckoutService/Checkout"); scope != "cart:write" {
+		t.Errorf("expected the scope of the cart routes, got %q", scope)
+	}
+}
@@ -16,6 +16,8 @@ import (
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	"github.com/fayleenpc/tj-jeans/internal/utils"
 	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type contextKey string
@@ -66,6 +68,7 @@ func CreateJWT(access, secret []byte, userID int, userRole string, userName stri
 	var secretTokenString, accessTokenString string
 	var err error
 	expiration := time.Second * time.Duration(config.Envs.JWTExpirationInSeconds)
+	issuedAt := time.Now().UnixMilli()
 	secretToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"userID":          strconv.Itoa(userID),
 		"userRole":        userRole,
@@ -74,6 +77,7 @@ func CreateJWT(access, secret []byte, userID int, userRole string, userName stri
 		"userPhoneNumber": userPhoneNumber,
 		"userAddress":     userAddress,
 		"expiredAt":       time.Now().Add(expiration).Unix(),
+		"issuedAt":        issuedAt,
 	})
 	secretTokenString, _ = secretToken.SignedString(secret)
 	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
@@ -84,6 +88,7 @@ func CreateJWT(access, secret []byte, userID int, userRole string, userName stri
 		"userPhoneNumber": userPhoneNumber,
 		"userAddress":     userAddress,
 		"expiredAt":       time.Now().Add(time.Minute * 1).Unix(),
+		"issuedAt":        issuedAt,
 	})
 	accessTokenString, err = accessToken.SignedString(access)
 
@@ -99,71 +104,28 @@ func CreateJWT(access, secret []byte, userID int, userRole string, userName stri
 func WithJWTAuth(handlerFunc http.HandlerFunc, userStore types.UserStore, tokenStore types.TokenStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("request path : %v\n", r.RequestURI)
-		var (
-			JWT_SECRET_TOKEN  *jwt.Token
-			JWT_ACCESS_TOKEN  *jwt.Token
-			JWT_SECRET_STRING string
-			JWT_ACCESS_STRING string
-		)
-		JWT_ACCESS_STRING, JWT_SECRET_STRING = getTokenFromRequest(r)
-
-		t2, err := tokenStore.GetBlacklistTokenByString(JWT_ACCESS_STRING)
-		if t2.Token == JWT_ACCESS_STRING && err == nil {
-			log.Printf("blacklisted token: %v", t2)
-			permissionDenied(w)
+		if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+			withAPIKey(handlerFunc, w, r, apiKey, userStore, tokenStore)
 			return
 		}
 
-		t1, err := tokenStore.GetBlacklistTokenByString(JWT_SECRET_STRING)
-		if t1.Token == JWT_SECRET_STRING && err == nil {
-			log.Printf("blacklisted token: %v", t1)
-			permissionDenied(w)
-			return
-		}
+		JWT_ACCESS_STRING, JWT_SECRET_STRING := getTokenFromRequest(r)
 		log.Printf("JWT_ACCESS_TOKEN=%v\n", JWT_ACCESS_STRING)
 		log.Printf("JWT_SECRET_TOKEN=%v\n", JWT_SECRET_STRING)
-		// validate the JWT Access Token
-		JWT_SECRET_TOKEN, err = validateSecretToken(JWT_SECRET_STRING)
-		if err != nil {
-			log.Printf("failed to validate secret token: %v", err)
-			permissionDenied(w)
-			return
-		}
-
-		// validate the JWT Secret Token
-		JWT_ACCESS_TOKEN, err = validateAccessToken(JWT_ACCESS_STRING)
+		u, claims, err := authenticateTokens(JWT_ACCESS_STRING, JWT_SECRET_STRING, userStore, tokenStore)
 		if err != nil {
-			log.Printf("failed to validate access token: %v", err)
+			log.Printf("failed to authenticate tokens: %v", err)
 			permissionDenied(w)
 			return
 		}
 
-		if !JWT_ACCESS_TOKEN.Valid {
-			log.Println("invalid access token")
-			permissionDenied(w)
-			return
-		}
-		if !JWT_SECRET_TOKEN.Valid {
-			log.Println("invalid secret token")
-			permissionDenied(w)
-			return
-		}
-
-		// if is we need to fetch the userID from the DB (id from the token)
-		claims := JWT_ACCESS_TOKEN.Claims.(jwt.MapClaims)
 		userID_str := claims["userID"].(string)
 		userRole := claims["userRole"].(string)
 		userEmail := claims["userEmail"].(string)
 		userPhoneNumber := claims["userPhoneNumber"].(string)
 		userAddress := claims["userAddress"].(string)
 		userID, _ := strconv.Atoi(userID_str)
-		u, err := userStore.GetUserByID(userID)
 		userName := u.FirstName + " " + u.LastName
-		if err != nil {
-			log.Printf("failed to get user by id: %v", err)
-			permissionDenied(w)
-			return
-		}
 
 		// set context "userID" to the userID
 		ctx := r.Context()
@@ -191,6 +153,68 @@ func WithJWTAuth(handlerFunc http.HandlerFunc, userStore types.UserStore, tokenS
 	}
 }
 
+// authenticateTokens checks the access and secret token pair, neither may be
+// blacklisted nor revoked, and returns the user and the claims of the access token.
+func authenticateTokens(accessString string, secretString string, userStore types.UserStore, tokenStore types.TokenStore) (*types.User, jwt.MapClaims, error) {
+	t2, err := tokenStore.GetBlacklistTokenByString(accessString)
+	if err == nil && t2.Token == accessString {
+		return nil, nil, fmt.Errorf("blacklisted token: %v", t2)
+	}
+
+	t1, err := tokenStore.GetBlacklistTokenByString(secretString)
+	if err == nil && t1.Token == secretString {
+		return nil, nil, fmt.Errorf("blacklisted token: %v", t1)
+	}
+
+	// validate the JWT Secret Token
+	secretToken, err := validateSecretToken(secretString)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to validate secret token: %v", err)
+	}
+
+	// validate the JWT Access Token
+	accessToken, err := validateAccessToken(accessString)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to validate access token: %v", err)
+	}
+
+	if !accessToken.Valid {
+		return nil, nil, fmt.Errorf("invalid access token")
+	}
+	if !secretToken.Valid {
+		return nil, nil, fmt.Errorf("invalid secret token")
+	}
+
+	// if is we need to fetch the userID from the DB (id from the token)
+	claims := accessToken.Claims.(jwt.MapClaims)
+	userID_str, _ := claims["userID"].(string)
+	userID, _ := strconv.Atoi(userID_str)
+
+	if IsTokenRevoked(secretToken, userID, tokenStore) {
+		return nil, nil, fmt.Errorf("revoked tokens for user: %v", userID)
+	}
+	u, err := userStore.GetUserByID(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user by id: %v", err)
+	}
+	return u, claims, nil
+}
+
+// IsTokenRevoked reports whether the token was issued before the user tokens
+// were revoked, e.g. after a password change or reset.
+func IsTokenRevoked(token *jwt.Token, userID int, tokenStore types.TokenStore) bool {
+	revokedAt, err := tokenStore.GetUserTokensRevokedAt(userID)
+	if err != nil {
+		return false
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	issuedAt, ok := claims["issuedAt"].(float64)
+	if !ok {
+		return true
+	}
+	return int64(issuedAt) < revokedAt.UnixMilli()
+}
+
 func getTokenFromRequest(r *http.Request) (string, string) {
 	authHeader := r.Header.Get("Authorization")
 	authXHeader := r.Header.Get("Authorization-X")
@@ -232,51 +256,6 @@ func ShouldRenew(w http.ResponseWriter, r *http.Request) (string, bool) {
 	return renewed, true
 }
 
-func ShouldRevoke(w http.ResponseWriter, r *http.Request) bool {
-
-	revoked := RevokeToken(r)
-	log.Printf("location : %v\n Revoked : %v\n", r.URL.Path, revoked == http.StatusOK)
-
-	return revoked == http.StatusOK
-}
-
-func RevokeToken(r *http.Request) int {
-	var payload struct {
-		AccessToken string `json:"access_token"`
-		SecretToken string `json:"secret_token"`
-	}
-	accessToken, err := session.GetJWTAccessToken(r)
-	if err != nil {
-		return int(http.StatusBadRequest)
-	}
-	secretToken, err := session.GetJWTSecretToken(r)
-	if err != nil {
-		return int(http.StatusBadRequest)
-	}
-	payload.AccessToken = accessToken
-	payload.SecretToken = secretToken
-
-	m, err := json.Marshal(payload)
-	if err != nil {
-		log.Fatal(err)
-	}
-	req, err := http.NewRequest("POST", config.Envs.PublicHost+":"+config.Envs.Port+"/service/logout", bytes.NewBuffer(m))
-
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer req.Body.Close()
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer res.Body.Close()
-
-	log.Println("req /service/logout : ", res.Status)
-	return int(res.StatusCode)
-}
-
 func RenewAccessToken(r *http.Request) string {
 	var payload struct {
 		AccessToken string `json:"access_token"`
@@ -368,6 +347,15 @@ func GetUserRoleFromContext(ctx context.Context) string {
 	return userRole
 }
 
+// RequireRole returns a PermissionDenied status unless the user of the context
+// has the role, every transport turns it into its own permission error.
+func RequireRole(ctx context.Context, role string) error {
+	if GetUserRoleFromContext(ctx) != role {
+		return status.Error(codes.PermissionDenied, "permission denied")
+	}
+	return nil
+}
+
 func GetUserIDFromSession(v string, userStore types.UserStore) int {
 	token, err := validateAccessToken(v)
 	// if is we need to fetch the userID from the DB (id from the token)
@@ -447,6 +435,7 @@ func BridgeAdmin(w http.ResponseWriter, r *http.Request) bool {
 			if ok {
 				if renewed, ok := ShouldRenew(w, r); ok {
 					session.SetJWTAccessToken(w, renewed)
+					r.Header.Set("Authorization", renewed)
 					return true
 				}
 				return true
@@ -467,6 +456,7 @@ func BridgeCommon(w http.ResponseWriter, r *http.Request) bool {
 		if _, ok := CheckExpired(r); ok {
 			if renewed, ok := ShouldRenew(w, r); ok {
 				session.SetJWTAccessToken(w, renewed)
+				r.Header.Set("Authorization", renewed)
 				return true
 			}
 
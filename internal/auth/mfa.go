@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew is the number of periods accepted before and after the current
+	// one, to tolerate clock drift between the server and the authenticator.
+	totpSkew = 1
+
+	recoveryCodesCount = 10
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random base32 encoded secret for a new authenticator.
+func GenerateTOTPSecret() (string, error) {
+	bytes := make([]byte, 20)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(bytes), nil
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI rendered as a QR code by the
+// storefront so the secret can be scanned by an authenticator app.
+func TOTPProvisioningURI(secret string, issuer string, account string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", strconv.Itoa(totpDigits))
+	v.Set("period", strconv.Itoa(totpPeriod))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// TOTPStep returns the time step of t as defined in RFC 6238.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// TOTPCode returns the code of the secret for the time step of t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	return totpCodeAtStep(secret, TOTPStep(t))
+}
+
+// ValidateTOTP checks the code against the steps around t and returns the
+// matching step, so the caller can refuse a code that was already used.
+func ValidateTOTP(secret string, code string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := TOTPStep(t)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := totpCodeAtStep(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func totpCodeAtStep(secret string, step int64) (string, error) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// GenerateRecoveryCodes returns single use codes the user can log in with
+// when the authenticator is lost, only their HashToken is stored.
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		bytes := make([]byte, 6)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32NoPadding.EncodeToString(bytes))
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode lets users type recovery codes without the dash or in upper case.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) > 5 {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
+
+// mfaPendingKey is kept apart from the secret token key so a pending token can
+// never be used as a secret token, e.g. on /refresh.
+func mfaPendingKey() []byte {
+	return []byte(config.Envs.JWTSecret + ":mfa-pending")
+}
+
+// CreateMFAPendingJWT returns the short lived token handed out after the
+// password step of the login, it's only good to finish the second step.
+func CreateMFAPendingJWT(userID int, now time.Time) (string, error) {
+	expiration := time.Second * time.Duration(config.Envs.MFAPendingExpirationInSeconds)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userID":    strconv.Itoa(userID),
+		"purpose":   "mfa_pending",
+		"expiredAt": now.Add(expiration).Unix(),
+	})
+	return token.SignedString(mfaPendingKey())
+}
+
+// ValidateMFAPendingJWT returns the userID of a pending token that is not expired at now.
+func ValidateMFAPendingJWT(tokenString string, now time.Time) (int, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return mfaPendingKey(), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, fmt.Errorf("invalid mfa token")
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if purpose, _ := claims["purpose"].(string); purpose != "mfa_pending" {
+		return 0, fmt.Errorf("invalid mfa token")
+	}
+	expiredAt, ok := claims["expiredAt"].(float64)
+	if !ok || !now.Before(time.Unix(int64(expiredAt), 0)) {
+		return 0, fmt.Errorf("expired mfa token")
+	}
+	userID_str, _ := claims["userID"].(string)
+	userID, err := strconv.Atoi(userID_str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mfa token")
+	}
+	return userID, nil
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 SHA1 test vectors, truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := TOTPCode(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatalf("error generating code: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected code %v at %v, got %v", want, unix, got)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	code, _ := TOTPCode(secret, now)
+
+	if step, ok := ValidateTOTP(secret, code, now.Add(29*time.Second)); !ok || step != TOTPStep(now) {
+		t.Errorf("expected code to be valid within the skew, got step %v ok %v", step, ok)
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(-61*time.Second)); ok {
+		t.Error("expected code to be invalid outside the skew")
+	}
+	if _, ok := ValidateTOTP(secret, "000000x", now); ok {
+		t.Error("expected malformed code to be invalid")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("SECRET", "TJ Jeans", "admin@gmail.com")
+	if !strings.HasPrefix(uri, "otpauth://totp/TJ%20Jeans:admin@gmail.com?") {
+		t.Errorf("unexpected provisioning uri %v", uri)
+	}
+	if !strings.Contains(uri, "secret=SECRET") || !strings.Contains(uri, "issuer=TJ+Jeans") {
+		t.Errorf("expected secret and issuer in provisioning uri %v", uri)
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || seen[code] {
+			t.Errorf("unexpected recovery code %v", code)
+		}
+		seen[code] = true
+		if NormalizeRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", ""))) != code {
+			t.Errorf("expected %v to be normalized", code)
+		}
+	}
+}
+
+func TestMFAPendingJWT(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	token, err := CreateMFAPendingJWT(7, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if userID, err := ValidateMFAPendingJWT(token, now.Add(time.Minute)); err != nil || userID != 7 {
+		t.Errorf("expected pending token for user 7, got %v %v", userID, err)
+	}
+	if _, err := ValidateMFAPendingJWT(token, now.Add(time.Hour)); err == nil {
+		t.Error("expected pending token to be expired")
+	}
+	if _, err := validateSecretToken(token); err == nil {
+		t.Error("expected pending token not to be a valid secret token")
+	}
+}
@@ -35,3 +35,18 @@ func TestComparePasswords(t *testing.T) {
 	}
 
 }
+
+func TestHashToken(t *testing.T) {
+	hash := HashToken("token")
+	if hash == "" || hash == "token" {
+		t.Errorf("expected token to be hashed, got %v", hash)
+	}
+
+	if hash != HashToken("token") {
+		t.Errorf("expected hash to be deterministic")
+	}
+
+	if hash == HashToken("other-token") {
+		t.Errorf("expected different tokens to have different hashes")
+	}
+}
@@ -0,0 +1,327 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/go-playground/validator"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Columns are the header of the CSV files, the JSON objects use the same
+// names.
+var Columns = []string{"sku", "name", "description", "merchant", "category", "currency", "image", "price", "qty"}
+
+// ContentType returns the media type of the format, ok is false for an
+// unknown one.
+func ContentType(format string) (string, bool) {
+	switch format {
+	case FormatCSV:
+		return "text/csv", true
+	case FormatJSON:
+		return "application/json", true
+	}
+	return "", false
+}
+
+// FormatFromContentType is the format of a request body, an empty string for
+// an unknown media type.
+func FormatFromContentType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(mediaType) {
+	case "text/csv", "application/csv":
+		return FormatCSV
+	case "application/json":
+		return FormatJSON
+	}
+	return ""
+}
+
+// Row is a row of an import file with the line it was read from.
+type Row struct {
+	Line int
+	types.ProductImportRow
+}
+
+// Parse reads the rows of a file and validates them. A row that can't be read
+// or isn't valid is reported and left out, the error is only set when the
+// file as a whole can't be read, e.g. a CSV header with missing columns.
+// A SKU seen twice keeps its first row.
+func Parse(r io.Reader, format string) ([]Row, []types.ProductImportError, error) {
+	var rows []Row
+	var rowErrors []types.ProductImportError
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, rowErrors, err = parseCSV(r)
+	case FormatJSON:
+		rows, rowErrors, err = parseJSON(r)
+	default:
+		return nil, nil, fmt.Errorf("unknown format %q, expected csv or json", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valid := make([]Row, 0, len(rows))
+	seen := make(map[string]int, len(rows))
+	for _, row := range rows {
+		row.SKU = strings.TrimSpace(row.SKU)
+		if errs := validateRow(row); len(errs) > 0 {
+			rowErrors = append(rowErrors, errs...)
+			continue
+		}
+		if first, ok := seen[row.SKU]; ok {
+			rowErrors = append(rowErrors, types.ProductImportError{Row: row.Line, SKU: row.SKU, Field: "sku", Message: fmt.Sprintf("duplicate sku, first seen in row %d", first)})
+			continue
+		}
+		seen[row.SKU] = row.Line
+		valid = append(valid, row)
+	}
+	sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+	return valid, rowErrors, nil
+}
+
+func parseCSV(r io.Reader) ([]Row, []types.ProductImportError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("the file is empty")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"sku", "name", "currency", "price", "qty"} {
+		if _, ok := index[name]; !ok {
+			return nil, nil, fmt.Errorf("the header has no %s column", name)
+		}
+	}
+
+	var rows []Row
+	var rowErrors []types.ProductImportError
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rowErrors = append(rowErrors, types.ProductImportError{Row: line, Message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		value := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row := types.ProductImportRow{
+			SKU:         value("sku"),
+			Name:        value("name"),
+			Description: value("description"),
+			Merchant:    value("merchant"),
+			Category:    value("category"),
+			Currency:    value("currency"),
+			Image:       value("image"),
+		}
+		if row.Price, err = strconv.ParseFloat(value("price"), 64); err != nil {
+			rowErrors = append(rowErrors, types.ProductImportError{Row: line, SKU: row.SKU, Field: "price", Message: "not a number"})
+			continue
+		}
+		if row.Quantity, err = strconv.Atoi(value("qty")); err != nil {
+			rowErrors = append(rowErrors, types.ProductImportError{Row: line, SKU: row.SKU, Field: "qty", Message: "not a whole number"})
+			continue
+		}
+		rows = append(rows, Row{Line: line, ProductImportRow: row})
+	}
+	return rows, rowErrors, nil
+}
+
+func parseJSON(r io.Reader) ([]Row, []types.ProductImportError, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, nil, fmt.Errorf("expected a JSON array of products: %v", err)
+	}
+	var rows []Row
+	var rowErrors []types.ProductImportError
+	for i, data := range raw {
+		var row types.ProductImportRow
+		if err := json.Unmarshal(data, &row); err != nil {
+			rowError := types.ProductImportError{Row: i + 1, Message: err.Error()}
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				rowError.Field, rowError.Message = typeErr.Field, "expected a "+typeErr.Type.String()
+			}
+			rowErrors = append(rowErrors, rowError)
+			continue
+		}
+		rows = append(rows, Row{Line: i + 1, ProductImportRow: row})
+	}
+	return rows, rowErrors, nil
+}
+
+func validateRow(row Row) []types.ProductImportError {
+	err := utils.Validate.Struct(row.ProductImportRow)
+	if err == nil {
+		return nil
+	}
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []types.ProductImportError{{Row: row.Line, SKU: row.SKU, Message: err.Error()}}
+	}
+	rowErrors := make([]types.ProductImportError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		rowErrors = append(rowErrors, types.ProductImportError{
+			Row:     row.Line,
+			SKU:     row.SKU,
+			Field:   jsonName(fe.StructField()),
+			Message: validationMessage(fe),
+		})
+	}
+	return rowErrors
+}
+
+func jsonName(structField string) string {
+	field, ok := reflect.TypeOf(types.ProductImportRow{}).FieldByName(structField)
+	if !ok {
+		return structField
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		return fmt.Sprintf("is longer than %s characters", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	}
+	return fmt.Sprintf("failed the %s check", fe.Tag())
+}
+
+// RowFromProduct is the row of a product in an export.
+func RowFromProduct(p types.Product) types.ProductImportRow {
+	return types.ProductImportRow{
+		SKU:         p.SKU,
+		Name:        p.Name,
+		Description: p.Description,
+		Merchant:    p.Merchant,
+		Category:    p.Category,
+		Currency:    p.Currency,
+		Image:       p.Image,
+		Price:       p.Price,
+		Quantity:    p.Quantity,
+	}
+}
+
+// Encoder writes the rows of an export one at a time so the catalogue can be
+// streamed, Close ends the file.
+type Encoder interface {
+	Encode(types.ProductImportRow) error
+	Close() error
+}
+
+func NewEncoder(w io.Writer, format string) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(Columns); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{w: cw}, nil
+	case FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, expected csv or json", format)
+}
+
+// Export writes the products in the format.
+func Export(w io.Writer, format string, products []types.Product) error {
+	enc, err := NewEncoder(w, format)
+	if err != nil {
+		return err
+	}
+	for _, p := range products {
+		if err := enc.Encode(RowFromProduct(p)); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Encode(row types.ProductImportRow) error {
+	return e.w.Write([]string{
+		row.SKU,
+		row.Name,
+		row.Description,
+		row.Merchant,
+		row.Category,
+		row.Currency,
+		row.Image,
+		strconv.FormatFloat(row.Price, 'f', -1, 64),
+		strconv.Itoa(row.Quantity),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonEncoder writes a JSON array an element at a time.
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Encode(row types.ProductImportRow) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	prefix := ",\n  "
+	if e.count == 0 {
+		prefix = "[\n  "
+	}
+	e.count++
+	_, err = io.WriteString(e.w, prefix+string(data))
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
@@ -0,0 +1,179 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		file := "\ufeffSKU,Name,Currency,Price,Qty\n" +
+			"TJ-00001,Slim Jeans,IDR,250000,5\n" +
+			"TJ-00002,Denim Jacket,IDR,abc,3\n" +
+			"TJ-00001,Slim Jeans Again,IDR,250000,5\n" +
+			",Belt,IDR,50000,-1\n"
+		rows, rowErrors, err := Parse(strings.NewReader(file), FormatCSV)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1 || rows[0].Line != 2 || rows[0].SKU != "TJ-00001" || rows[0].Price != 250000 || rows[0].Quantity != 5 {
+			t.Errorf("unexpected rows %+v", rows)
+		}
+		want := []types.ProductImportError{
+			{Row: 3, SKU: "TJ-00002", Field: "price", Message: "not a number"},
+			{Row: 4, SKU: "TJ-00001", Field: "sku", Message: "duplicate sku, first seen in row 2"},
+			{Row: 5, Field: "sku", Message: "is required"},
+			{Row: 5, Field: "qty", Message: "must be at least 0"},
+		}
+		if len(rowErrors) != len(want) {
+			t.Fatalf("expected %d errors, got %+v", len(want), rowErrors)
+		}
+		for i := range want {
+			if rowErrors[i] != want[i] {
+				t.Errorf("expected %+v, got %+v", want[i], rowErrors[i])
+			}
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		file := `[{"sku":"TJ-00001","name":"Slim Jeans","currency":"IDR","price":250000,"qty":5},{"sku":"TJ-00002","name":"Denim Jacket","currency":"IDR","price":"free","qty":3}]`
+		rows, rowErrors, err := Parse(strings.NewReader(file), FormatJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1 || rows[0].Line != 1 {
+			t.Errorf("unexpected rows %+v", rows)
+		}
+		if len(rowErrors) != 1 || rowErrors[0].Row != 2 || rowErrors[0].Field != "price" {
+			t.Errorf("unexpected errors %+v", rowErrors)
+		}
+	})
+	t.Run("invalid files", func(t *testing.T) {
+		if _, _, err := Parse(strings.NewReader("sku,name\nTJ-00001,Slim Jeans\n"), FormatCSV); err == nil {
+			t.Error("expected a header with missing columns to fail")
+		}
+		if _, _, err := Parse(strings.NewReader(`{"sku":"TJ-00001"}`), FormatJSON); err == nil {
+			t.Error("expected a JSON object to fail")
+		}
+		if _, _, err := Parse(strings.NewReader(""), "xml"); err == nil {
+			t.Error("expected an unknown format to fail")
+		}
+	})
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	products := []types.Product{
+		{SKU: "TJ-00001", Name: "Slim Jeans", Description: "Stretch, dark wash", Currency: "IDR", Price: 249999.5, Quantity: 5},
+		{SKU: "TJ-00002", Name: `Jacket "Classic"`, Merchant: "TJ", Category: "jackets", Currency: "IDR", Image: "/img/2.png", Price: 400000, Quantity: 0},
+	}
+	for _, format := range []string{FormatCSV, FormatJSON} {
+		var buf bytes.Buffer
+		if err := Export(&buf, format, products); err != nil {
+			t.Fatal(err)
+		}
+		rows, rowErrors, err := Parse(&buf, format)
+		if err != nil || len(rowErrors) != 0 {
+			t.Fatalf("%s: unexpected errors %v %+v", format, err, rowErrors)
+		}
+		if len(rows) != len(products) {
+			t.Fatalf("%s: expected %d rows, got %d", format, len(products), len(rows))
+		}
+		for i, p := range products {
+			if rows[i].ProductImportRow != RowFromProduct(p) {
+				t.Errorf("%s: expected %+v, got %+v", format, RowFromProduct(p), rows[i].ProductImportRow)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Export(&buf, FormatJSON, nil); err != nil || buf.String() != "[]\n" {
+		t.Errorf("expected an empty array, got %q %v", buf.String(), err)
+	}
+}
+
+type mockProductStore struct {
+	types.ProductStore
+	products []types.Product
+}
+
+func (m *mockProductStore) GetProductBySKU(sku string) (*types.Product, error) {
+	for _, p := range m.products {
+		if p.SKU == sku {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+func (m *mockProductStore) CreateProduct(p types.Product) (int64, error) {
+	p.ID = len(m.products) + 1
+	m.products = append(m.products, p)
+	return int64(p.ID), nil
+}
+
+func TestJobs(t *testing.T) {
+	store := &mockProductStore{}
+	rows := []Row{
+		{Line: 2, ProductImportRow: types.ProductImportRow{SKU: "TJ-00001", Name: "Slim Jeans", Currency: "IDR", Price: 1}},
+		{Line: 3, ProductImportRow: types.ProductImportRow{SKU: "TJ-00002", Name: "Denim Jacket", Currency: "IDR", Price: 1}},
+	}
+	jobs := NewJobs(time.Hour)
+	t.Cleanup(jobs.Stop)
+	job := jobs.Start(context.Background(), NewImporter(store), rows, nil, false)
+	if job.Status != types.ImportPending || job.Total != 2 {
+		t.Errorf("unexpected job %+v", job)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for job.Status != types.ImportDone && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		job, _ = jobs.Get(job.ID)
+	}
+	if job.Status != types.ImportDone || job.Processed != 2 || job.Result == nil || job.Result.Created != 2 || job.FinishedAt == nil {
+		t.Fatalf("unexpected job %+v", job)
+	}
+
+	jobs.now = func() time.Time { return job.FinishedAt.Add(2 * time.Hour) }
+	jobs.Start(context.Background(), NewImporter(store), nil, nil, true)
+	if _, ok := jobs.Get(job.ID); ok {
+		t.Error("expected the finished job to be forgotten after the retention")
+	}
+}
+
+// blockingProductStore holds every new product until release is closed.
+type blockingProductStore struct {
+	mockProductStore
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (m *blockingProductStore) CreateProduct(p types.Product) (int64, error) {
+	m.entered <- struct{}{}
+	<-m.release
+	return m.mockProductStore.CreateProduct(p)
+}
+
+func TestJobsStop(t *testing.T) {
+	store := &blockingProductStore{entered: make(chan struct{}, 3), release: make(chan struct{})}
+	rows := []Row{
+		{Line: 2, ProductImportRow: types.ProductImportRow{SKU: "TJ-00001", Name: "Slim Jeans", Currency: "IDR", Price: 1}},
+		{Line: 3, ProductImportRow: types.ProductImportRow{SKU: "TJ-00002", Name: "Denim Jacket", Currency: "IDR", Price: 1}},
+		{Line: 4, ProductImportRow: types.ProductImportRow{SKU: "TJ-00003", Name: "Belt", Currency: "IDR", Price: 1}},
+	}
+	jobs := NewJobs(time.Hour)
+	job := jobs.Start(context.Background(), NewImporter(store), rows, nil, false)
+
+	<-store.entered
+	// stopped while the first row is being imported
+	jobs.stop()
+	close(store.release)
+	jobs.Stop()
+
+	job, _ = jobs.Get(job.ID)
+	if job.Status != types.ImportStopped || job.Processed != 1 || len(store.products) != 1 {
+		t.Errorf("expected the job to stop after its first row, got %+v with %d products", job, len(store.products))
+	}
+}
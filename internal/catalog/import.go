@@ -0,0 +1,215 @@
+package catalog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/events"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+// Importer upserts the rows of an import by SKU, the stock of a new product
+// is its opening stock and a changed one is adjusted through the ledger like
+// any other product update.
+type Importer struct {
+	store types.ProductStore
+}
+
+func NewImporter(store types.ProductStore) *Importer {
+	return &Importer{store: store}
+}
+
+// Import applies the rows, a dry run only looks up which ones would be
+// created or updated. The rows that fail are reported with the parse errors
+// and the others are still applied, until ctx is done. progress is called
+// after every row when set.
+func (im *Importer) Import(ctx context.Context, rows []Row, rowErrors []types.ProductImportError, dryRun bool, progress func(processed int)) types.ProductImportResult {
+	result := types.ProductImportResult{
+		DryRun: dryRun,
+		Total:  len(rows) + countRows(rowErrors),
+		Errors: append([]types.ProductImportError{}, rowErrors...),
+	}
+	for i, row := range rows {
+		if ctx.Err() != nil {
+			break
+		}
+		created, err := im.importRow(ctx, row, dryRun)
+		switch {
+		case err != nil:
+			result.Errors = append(result.Errors, types.ProductImportError{Row: row.Line, SKU: row.SKU, Message: err.Error()})
+		case created:
+			result.Created++
+		default:
+			result.Updated++
+		}
+		if progress != nil {
+			progress(i + 1)
+		}
+	}
+	return result
+}
+
+func (im *Importer) importRow(ctx context.Context, row Row, dryRun bool) (bool, error) {
+	existing, err := im.store.GetProductBySKU(row.SKU)
+	if err != nil {
+		return false, err
+	}
+	product := types.Product{
+		SKU:         row.SKU,
+		Name:        row.Name,
+		Description: row.Description,
+		Merchant:    row.Merchant,
+		Category:    row.Category,
+		Currency:    row.Currency,
+		Image:       row.Image,
+		Price:       row.Price,
+		Quantity:    row.Quantity,
+	}
+	if existing == nil {
+		if dryRun {
+			return true, nil
+		}
+		id, err := im.store.CreateProduct(product)
+		if err != nil {
+			return false, err
+		}
+		product.ID = int(id)
+		audit.Record(ctx, audit.ActionCreate, audit.EntityProduct, product.ID, nil, product)
+		events.Publish(events.TopicProduct, events.KindCreated, product.ID)
+		return true, nil
+	}
+
+	if dryRun {
+		return false, nil
+	}
+	product.ID = existing.ID
+	if _, err := im.store.UpdateProduct(product, auth.GetUserIDFromContext(ctx)); err != nil {
+		return false, err
+	}
+	product.CreatedAt = existing.CreatedAt
+	audit.Record(ctx, audit.ActionUpdate, audit.EntityProduct, product.ID, existing, product)
+	events.Publish(events.TopicProduct, events.KindUpdated, product.ID)
+	return false, nil
+}
+
+// countRows counts the rows of the errors, a row can fail on several fields.
+func countRows(rowErrors []types.ProductImportError) int {
+	rows := make(map[int]bool, len(rowErrors))
+	for _, e := range rowErrors {
+		rows[e.Row] = true
+	}
+	return len(rows)
+}
+
+// Jobs runs the imports of large files in the background and keeps their
+// progress, finished jobs are forgotten after retention. Stop ends the
+// imports still running.
+type Jobs struct {
+	mu        sync.Mutex
+	jobs      map[string]*types.ProductImportJob
+	retention time.Duration
+	now       func() time.Time
+
+	stopped context.Context
+	stop    context.CancelFunc
+	running sync.WaitGroup
+}
+
+func NewJobs(retention time.Duration) *Jobs {
+	stopped, stop := context.WithCancel(context.Background())
+	return &Jobs{jobs: map[string]*types.ProductImportJob{}, retention: retention, now: time.Now, stopped: stopped, stop: stop}
+}
+
+// Start runs the import in the background and returns the pending job. The
+// context only carries the actor and the request for the audit log, the job
+// outlives the request that started it until Stop.
+func (j *Jobs) Start(ctx context.Context, importer *Importer, rows []Row, rowErrors []types.ProductImportError, dryRun bool) types.ProductImportJob {
+	j.mu.Lock()
+	j.prune()
+	job := &types.ProductImportJob{
+		ID:        newJobID(),
+		Status:    types.ImportPending,
+		Total:     len(rows),
+		CreatedAt: j.now(),
+	}
+	j.jobs[job.ID] = job
+	snapshot := *job
+	j.mu.Unlock()
+
+	ctx = jobContext{Context: j.stopped, values: ctx}
+	j.running.Add(1)
+	go func() {
+		defer j.running.Done()
+		j.update(job.ID, func(job *types.ProductImportJob) { job.Status = types.ImportRunning })
+		processed := 0
+		result := importer.Import(ctx, rows, rowErrors, dryRun, func(n int) {
+			processed = n
+			j.update(job.ID, func(job *types.ProductImportJob) { job.Processed = n })
+		})
+		j.update(job.ID, func(job *types.ProductImportJob) {
+			finishedAt := j.now()
+			job.Status = types.ImportDone
+			if processed < len(rows) {
+				job.Status = types.ImportStopped
+			}
+			job.Result = &result
+			job.FinishedAt = &finishedAt
+		})
+	}()
+	return snapshot
+}
+
+// Stop stops the running imports after their current row and waits for them.
+func (j *Jobs) Stop() {
+	j.stop()
+	j.running.Wait()
+}
+
+// Get returns a copy of the job, ok is false for an unknown or forgotten one.
+func (j *Jobs) Get(id string) (types.ProductImportJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return types.ProductImportJob{}, false
+	}
+	return *job, true
+}
+
+func (j *Jobs) update(id string, apply func(job *types.ProductImportJob)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, ok := j.jobs[id]; ok {
+		apply(job)
+	}
+}
+
+func (j *Jobs) prune() {
+	for id, job := range j.jobs {
+		if job.FinishedAt != nil && j.now().Sub(*job.FinishedAt) > j.retention {
+			delete(j.jobs, id)
+		}
+	}
+}
+
+// jobContext is done when the jobs are stopped and has the values of the
+// request which started the job.
+type jobContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c jobContext) Value(key any) any {
+	return c.values.Value(key)
+}
+
+func newJobID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
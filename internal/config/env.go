@@ -5,25 +5,80 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	PublicHost             string
-	Port                   string
-	PortProto              string
-	PortGRPC               string
-	DBUser                 string
-	DBPassword             string
-	DBAddress              string
-	DBName                 string
-	JWTExpirationInSeconds int64
-	JWTSecret              string
-	JWTRefresh             string
-	SMTP_User              string
-	SMTP_Password          string
+	PublicHost                           string
+	Port                                 string
+	PortProto                            string
+	PortGRPC                             string
+	PortWeb                              string
+	WebAPITransport                      string
+	DBUser                               string
+	DBPassword                           string
+	DBAddress                            string
+	DBName                               string
+	JWTExpirationInSeconds               int64
+	JWTSecret                            string
+	JWTRefresh                           string
+	EmailVerificationExpirationInSeconds int64
+	EmailVerificationResendInSeconds     int64
+	PasswordResetExpirationInSeconds     int64
+	MFAPendingExpirationInSeconds        int64
+	MFAIssuer                            string
+	LoginMaxAccountFailures              int64
+	LoginMaxIPFailures                   int64
+	LoginMaxIPAccounts                   int64
+	LoginFailureWindowInSeconds          int64
+	LoginLockoutInSeconds                int64
+	LoginDelayBaseInMilliseconds         int64
+	LoginDelayMaxInMilliseconds          int64
+	TrustedProxies                       string
+	RateLimitPolicies                    string
+	OIDCProviders                        []OIDCProvider
+	OIDCStateExpirationInSeconds         int64
+	SoftDeleteRetentionInDays            int64
+	SoftDeletePurgeIntervalInSeconds     int64
+	LowStockThreshold                    int64
+	LowStockAlertEmails                  string
+	ReorderWindowInDays                  int64
+	ReorderCoverInDays                   int64
+	DefaultWarehouseID                   int64
+	StockAllocationStrategy              string
+	ImportSyncRows                       int64
+	ImportMaxBytes                       int64
+	ImportJobRetentionInSeconds          int64
+	BackInStockTTLInDays                 int64
+	BackInStockIntervalInSeconds         int64
+	SMTP_User                            string
+	SMTP_Password                        string
+	SMTPHost                             string
+	SMTPPort                             string
+	SMTPFrom                             string
+	MailBackend                          string
+	MailCaptureDir                       string
+	MailLocale                           string
+	MailMaxAttempts                      int64
+	MailRetryInSeconds                   int64
+	MailOutboxIntervalInSeconds          int64
+	MailOutboxRetentionInDays            int64
+	MerchantName                         string
+	MerchantPhone                        string
+	NotifyChannel                        string
+	NotifyLocale                         string
+	WhatsAppAPIURL                       string
+	WhatsAppPhoneNumberID                string
+	WhatsAppAccessToken                  string
+	SMSAPIURL                            string
+	SMSAPIKey                            string
+	SMSSender                            string
+	RedisAddress                         string
+	NotificationsChannel                 string
+	EventsPollIntervalInSeconds          int64
 }
 
 var Envs = initConfig()
@@ -32,22 +87,105 @@ func initConfig() Config {
 	godotenv.Load()
 	// init_vault()
 	return Config{
-		PublicHost:             getEnv("PUBLIC_HOST", "http://localhost"),
-		Port:                   getEnv("PORT", "8081"),
-		PortProto:              getEnv("PORT_PROTO", "8082"),
-		PortGRPC:               getEnv("PORT_GRPC", "8083"),
-		DBUser:                 getEnv("DB_USER", "root"),
-		DBPassword:             getEnv("DB_PASSWORD", ""),
-		DBAddress:              fmt.Sprintf("%s:%s", getEnv("DB_HOST", "127.0.0.1"), getEnv("DB_PORT", "3306")),
-		DBName:                 getEnv("DB_NAME", "tj-jeans"),
-		JWTSecret:              getEnv("JWT_SECRET", ""),
-		JWTRefresh:             getEnv("JWT_REFRESH", ""),
-		JWTExpirationInSeconds: getEnvAsInt("JWT_EXP", 300),
-		SMTP_User:              getEnv("SMTP_USER", ""),
-		SMTP_Password:          getEnv("SMTP_PASSWORD", ""),
+		PublicHost:                           getEnv("PUBLIC_HOST", "http://localhost"),
+		Port:                                 getEnv("PORT", "8081"),
+		PortProto:                            getEnv("PORT_PROTO", "8082"),
+		PortGRPC:                             getEnv("PORT_GRPC", "8083"),
+		PortWeb:                              getEnv("PORT_WEB", "8080"),
+		WebAPITransport:                      getEnv("WEB_API_TRANSPORT", "rest"),
+		DBUser:                               getEnv("DB_USER", "root"),
+		DBPassword:                           getEnv("DB_PASSWORD", ""),
+		DBAddress:                            fmt.Sprintf("%s:%s", getEnv("DB_HOST", "127.0.0.1"), getEnv("DB_PORT", "3306")),
+		DBName:                               getEnv("DB_NAME", "tj-jeans"),
+		JWTSecret:                            getEnv("JWT_SECRET", ""),
+		JWTRefresh:                           getEnv("JWT_REFRESH", ""),
+		JWTExpirationInSeconds:               getEnvAsInt("JWT_EXP", 300),
+		EmailVerificationExpirationInSeconds: getEnvAsInt("EMAIL_VERIFICATION_EXP", 3600*24),
+		EmailVerificationResendInSeconds:     getEnvAsInt("EMAIL_VERIFICATION_RESEND", 60),
+		PasswordResetExpirationInSeconds:     getEnvAsInt("PASSWORD_RESET_EXP", 60*30),
+		MFAPendingExpirationInSeconds:        getEnvAsInt("MFA_PENDING_EXP", 60*5),
+		MFAIssuer:                            getEnv("MFA_ISSUER", "TJ Jeans"),
+		LoginMaxAccountFailures:              getEnvAsInt("LOGIN_MAX_ACCOUNT_FAILURES", 5),
+		LoginMaxIPFailures:                   getEnvAsInt("LOGIN_MAX_IP_FAILURES", 50),
+		LoginMaxIPAccounts:                   getEnvAsInt("LOGIN_MAX_IP_ACCOUNTS", 10),
+		LoginFailureWindowInSeconds:          getEnvAsInt("LOGIN_FAILURE_WINDOW", 60*15),
+		LoginLockoutInSeconds:                getEnvAsInt("LOGIN_LOCKOUT", 60*15),
+		LoginDelayBaseInMilliseconds:         getEnvAsInt("LOGIN_DELAY_BASE_MS", 250),
+		LoginDelayMaxInMilliseconds:          getEnvAsInt("LOGIN_DELAY_MAX_MS", 5000),
+		TrustedProxies:                       getEnv("TRUSTED_PROXIES", "127.0.0.1,::1"),
+		RateLimitPolicies:                    getEnv("RATE_LIMIT_POLICIES", "default=120/1m;POST /api/v1/login=20/1m;POST /api/v1/register=10/1m;POST /api/v1/password/forgot=5/1m;POST /api/v1/verify/resend=5/1m"),
+		OIDCProviders:                        getOIDCProviders(getEnv("OIDC_PROVIDERS", "")),
+		OIDCStateExpirationInSeconds:         getEnvAsInt("OIDC_STATE_EXP", 60*10),
+		SoftDeleteRetentionInDays:            getEnvAsInt("SOFT_DELETE_RETENTION_DAYS", 30),
+		SoftDeletePurgeIntervalInSeconds:     getEnvAsInt("SOFT_DELETE_PURGE_INTERVAL", 3600),
+		LowStockThreshold:                    getEnvAsInt("LOW_STOCK_THRESHOLD", 5),
+		LowStockAlertEmails:                  getEnv("LOW_STOCK_ALERT_EMAILS", ""),
+		ReorderWindowInDays:                  getEnvAsInt("REORDER_WINDOW_DAYS", 30),
+		ReorderCoverInDays:                   getEnvAsInt("REORDER_COVER_DAYS", 14),
+		DefaultWarehouseID:                   getEnvAsInt("DEFAULT_WAREHOUSE_ID", 1),
+		StockAllocationStrategy:              getEnv("STOCK_ALLOCATION_STRATEGY", "nearest"),
+		ImportSyncRows:                       getEnvAsInt("IMPORT_SYNC_ROWS", 200),
+		ImportMaxBytes:                       getEnvAsInt("IMPORT_MAX_BYTES", 10<<20),
+		ImportJobRetentionInSeconds:          getEnvAsInt("IMPORT_JOB_RETENTION", 3600*24),
+		BackInStockTTLInDays:                 getEnvAsInt("BACK_IN_STOCK_TTL_DAYS", 90),
+		BackInStockIntervalInSeconds:         getEnvAsInt("BACK_IN_STOCK_INTERVAL", 60),
+		SMTP_User:                            getEnv("SMTP_USER", ""),
+		SMTP_Password:                        getEnv("SMTP_PASSWORD", ""),
+		SMTPHost:                             getEnv("SMTP_HOST", ""),
+		SMTPPort:                             getEnv("SMTP_PORT", "2525"),
+		SMTPFrom:                             getEnv("SMTP_FROM", "TJ Jeans <no-reply@tj-jeans.id>"),
+		MailBackend:                          getEnv("MAIL_BACKEND", "capture"),
+		MailCaptureDir:                       getEnv("MAIL_CAPTURE_DIR", ""),
+		MailLocale:                           getEnv("MAIL_LOCALE", "id"),
+		MailMaxAttempts:                      getEnvAsInt("MAIL_MAX_ATTEMPTS", 5),
+		MailRetryInSeconds:                   getEnvAsInt("MAIL_RETRY", 60),
+		MailOutboxIntervalInSeconds:          getEnvAsInt("MAIL_OUTBOX_INTERVAL", 10),
+		MailOutboxRetentionInDays:            getEnvAsInt("MAIL_OUTBOX_RETENTION_DAYS", 7),
+		MerchantName:                         getEnv("MERCHANT_NAME", "TJ Jeans"),
+		MerchantPhone:                        getEnv("MERCHANT_PHONE", "6289505208391"),
+		NotifyChannel:                        getEnv("NOTIFY_CHANNEL", "fake"),
+		NotifyLocale:                         getEnv("NOTIFY_LOCALE", "id"),
+		WhatsAppAPIURL:                       getEnv("WHATSAPP_API_URL", "https://graph.facebook.com/v20.0"),
+		WhatsAppPhoneNumberID:                getEnv("WHATSAPP_PHONE_NUMBER_ID", ""),
+		WhatsAppAccessToken:                  getEnv("WHATSAPP_ACCESS_TOKEN", ""),
+		SMSAPIURL:                            getEnv("SMS_API_URL", ""),
+		SMSAPIKey:                            getEnv("SMS_API_KEY", ""),
+		SMSSender:                            getEnv("SMS_SENDER", "TJJeans"),
+		RedisAddress:                         getEnv("REDIS_ADDRESS", "localhost:6379"),
+		NotificationsChannel:                 getEnv("NOTIFICATIONS_CHANNEL", "notifications"),
+		EventsPollIntervalInSeconds:          getEnvAsInt("EVENTS_POLL_INTERVAL", 2),
 	}
 }
 
+// OIDCProvider is an OpenID Connect issuer users can login with, providers
+// are listed in OIDC_PROVIDERS, e.g. "google,microsoft", and each one is
+// configured by OIDC_<NAME>_ISSUER_URL, OIDC_<NAME>_CLIENT_ID and
+// OIDC_<NAME>_CLIENT_SECRET.
+type OIDCProvider struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+}
+
+func getOIDCProviders(names string) []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProvider{
+			Name:         name,
+			IssuerURL:    getEnv(prefix+"ISSUER_URL", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+		})
+	}
+	return providers
+}
+
 func init_vault() {
 	// Initialize Vault client
 	config := api.DefaultConfig()
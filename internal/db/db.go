@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"log"
 
 	"github.com/go-sql-driver/mysql"
@@ -14,3 +15,10 @@ func NewMySQLStorage(cfg mysql.Config) (*sql.DB, error) {
 	}
 	return db, nil
 }
+
+// IsDuplicateEntry reports whether err is MySQL refusing a row that has the
+// value of a unique key of another row.
+func IsDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
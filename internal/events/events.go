@@ -0,0 +1,181 @@
+// Package events is the log of the domain events of the catalogue and the
+// orders. The services publish to it and the streams of the gRPC API replay
+// it from a cursor then follow it.
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	TopicProduct = "product"
+	TopicOrder   = "order"
+)
+
+const (
+	KindCreated  = "created"
+	KindUpdated  = "updated"
+	KindStock    = "stock"
+	KindDeleted  = "deleted"
+	KindRestored = "restored"
+)
+
+const watchBatch = 100
+
+// Log stores the events and wakes the watchers of this server at once, the
+// watchers of the other servers see them on their next poll.
+type Log struct {
+	store    types.EventStore
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	waiters map[chan struct{}]struct{}
+}
+
+func NewLog(store types.EventStore, interval time.Duration) *Log {
+	return &Log{
+		store:    store,
+		interval: interval,
+		now:      time.Now,
+		waiters:  make(map[chan struct{}]struct{}),
+	}
+}
+
+// LogFromConfig polls for the events of the other servers every
+// EVENTS_POLL_INTERVAL seconds.
+func LogFromConfig(store types.EventStore) *Log {
+	return NewLog(store, time.Second*time.Duration(config.Envs.EventsPollIntervalInSeconds))
+}
+
+var eventLog atomic.Pointer[Log]
+
+// SetDefault sets the log used by Publish, Head and Watch, nothing is
+// published until the servers set one.
+func SetDefault(l *Log) {
+	eventLog.Store(l)
+}
+
+// Publish records the event with the default log, see Log.Publish.
+func Publish(topic string, kind string, entityID int) {
+	if l := eventLog.Load(); l != nil {
+		l.Publish(topic, kind, entityID)
+	}
+}
+
+// Head is the cursor of the newest event of the topic in the default log.
+func Head(topic string) (int64, error) {
+	l := eventLog.Load()
+	if l == nil {
+		return 0, status.Error(codes.Unavailable, "the event log is not available")
+	}
+	return l.Head(topic)
+}
+
+// Watch follows the topic in the default log, see Log.Watch.
+func Watch(ctx context.Context, topic string, cursor int64, send func([]types.DomainEvent) error) error {
+	l := eventLog.Load()
+	if l == nil {
+		return status.Error(codes.Unavailable, "the event log is not available")
+	}
+	return l.Watch(ctx, topic, cursor, send)
+}
+
+// Publish records the change of the entity and wakes the watchers. It runs
+// once the change is committed, outside of its transaction, so the log is at
+// most once: a failed write is logged and doesn't fail the change, which has
+// already happened, and the watchers never see that event.
+func (l *Log) Publish(topic string, kind string, entityID int) {
+	_, err := l.store.CreateEvent(types.DomainEvent{
+		Topic:     topic,
+		Kind:      kind,
+		EntityID:  entityID,
+		CreatedAt: l.now(),
+	})
+	if err != nil {
+		log.Printf("failed to publish the %s event of %s %d: %v", kind, topic, entityID, err)
+		return
+	}
+	l.wake()
+}
+
+func (l *Log) Head(topic string) (int64, error) {
+	return l.store.GetLastEventID(topic)
+}
+
+// Watch sends the events of the topic after the cursor in batches, the
+// stored ones first then the new ones as they are published, until ctx is
+// done or send fails. A cursor of 0 starts at the newest event.
+func (l *Log) Watch(ctx context.Context, topic string, cursor int64, send func([]types.DomainEvent) error) error {
+	// subscribed before the first read so a publish in between isn't missed
+	wake := l.subscribe()
+	defer l.unsubscribe(wake)
+
+	if cursor <= 0 {
+		head, err := l.store.GetLastEventID(topic)
+		if err != nil {
+			return err
+		}
+		cursor = head
+	}
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+	for {
+		for {
+			events, err := l.store.GetEvents(topic, cursor, watchBatch)
+			if err != nil {
+				return err
+			}
+			if len(events) > 0 {
+				if err := send(events); err != nil {
+					return err
+				}
+				cursor = events[len(events)-1].ID
+			}
+			if len(events) < watchBatch {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *Log) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+	l.mu.Lock()
+	l.waiters[ch] = struct{}{}
+	l.mu.Unlock()
+	return ch
+}
+
+func (l *Log) unsubscribe(ch chan struct{}) {
+	l.mu.Lock()
+	delete(l.waiters, ch)
+	l.mu.Unlock()
+}
+
+func (l *Log) wake() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.waiters {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
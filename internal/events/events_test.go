@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWatch(t *testing.T) {
+	store := &memoryEventStore{}
+	// a poll that never comes, the watchers must be woken by the publishes
+	l := NewLog(store, time.Hour)
+	l.Publish(TopicProduct, KindCreated, 1)
+	l.Publish(TopicOrder, KindCreated, 1)
+	l.Publish(TopicProduct, KindStock, 1)
+
+	watch := func(cursor int64) (chan types.DomainEvent, func() error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		received := make(chan types.DomainEvent, 16)
+		done := make(chan error, 1)
+		go func() {
+			done <- l.Watch(ctx, TopicProduct, cursor, func(events []types.DomainEvent) error {
+				for _, e := range events {
+					received <- e
+				}
+				return nil
+			})
+		}()
+		stop := func() error {
+			cancel()
+			return <-done
+		}
+		return received, stop
+	}
+	next := func(received chan types.DomainEvent) types.DomainEvent {
+		t.Helper()
+		select {
+		case e := <-received:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("expected an event")
+		}
+		return types.DomainEvent{}
+	}
+
+	t.Run("should replay the events after the cursor then follow the new ones", func(t *testing.T) {
+		received, stop := watch(1)
+		if e := next(received); e.ID != 3 || e.Kind != KindStock {
+			t.Errorf("expected the stock event after the cursor, got %+v", e)
+		}
+		l.Publish(TopicOrder, KindUpdated, 1)
+		l.Publish(TopicProduct, KindUpdated, 2)
+		if e := next(received); e.ID != 5 || e.EntityID != 2 || e.Topic != TopicProduct {
+			t.Errorf("expected the new product event, got %+v", e)
+		}
+		if err := stop(); status.Code(err) != codes.Canceled {
+			t.Errorf("expected %v, got %v", codes.Canceled, err)
+		}
+	})
+	t.Run("should start at the newest event without a cursor", func(t *testing.T) {
+		received, stop := watch(0)
+		defer stop()
+		// wait for the watcher to read the head
+		time.Sleep(time.Millisecond * 50)
+		l.Publish(TopicProduct, KindDeleted, 1)
+		if e := next(received); e.ID != 6 || e.Kind != KindDeleted {
+			t.Errorf("expected only the new event, got %+v", e)
+		}
+	})
+}
+
+func TestWatchWithoutLog(t *testing.T) {
+	SetDefault(nil)
+	err := Watch(context.Background(), TopicOrder, 0, func([]types.DomainEvent) error { return nil })
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected %v, got %v", codes.Unavailable, err)
+	}
+	Publish(TopicOrder, KindCreated, 1)
+}
+
+type memoryEventStore struct {
+	mu     sync.Mutex
+	events []types.DomainEvent
+}
+
+func (m *memoryEventStore) CreateEvent(e types.DomainEvent) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.ID = int64(len(m.events) + 1)
+	m.events = append(m.events, e)
+	return e.ID, nil
+}
+
+func (m *memoryEventStore) GetEvents(topic string, afterID int64, limit int) ([]types.DomainEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := make([]types.DomainEvent, 0)
+	for _, e := range m.events {
+		if e.Topic == topic && e.ID > afterID && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *memoryEventStore) GetLastEventID(topic string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var id int64
+	for _, e := range m.events {
+		if e.Topic == topic {
+			id = e.ID
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,290 @@
+// Package gateway serves the gRPC API as REST, the routes are generated from
+// the google.api.http annotations of types.proto so both transports call the
+// same services with the same messages.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	// MIMEProtobuf asks for the binary messages instead of JSON, in the
+	// Content-Type of the body or the Accept of the answer. The answer is
+	// always the whole response message, even for the routes that send a
+	// single field of it as JSON, and a stream is a sequence of length
+	// delimited google.protobuf.Any holding either a message of the stream or
+	// the google.rpc.Status that ended it.
+	MIMEProtobuf = "application/x-protobuf"
+	// MIMEJSON is the protojson encoding of the messages with the field names
+	// of types.proto, the default when the client doesn't ask for protobuf.
+	MIMEJSON = "application/json"
+)
+
+// forwardedHeaders are sent to the gRPC server as metadata besides the
+// standard ones like Authorization, the auth interceptor reads the secret
+// token and the API key from them.
+var forwardedHeaders = map[string]bool{
+	"Authorization-X": true,
+	"X-Api-Key":       true,
+	"X-Request-Id":    true,
+}
+
+// returnedHeaders are the metadata of the answer written back as headers
+// without the Grpc-Metadata- prefix.
+var returnedHeaders = map[string]bool{
+	"X-Request-Id":          true,
+	"X-Ratelimit-Limit":     true,
+	"X-Ratelimit-Remaining": true,
+	"X-Ratelimit-Reset":     true,
+	"Retry-After":           true,
+}
+
+// NewServeMux is the reverse proxy of the gRPC API. JSON keeps the field
+// names of the proto like the REST API, and MIMEProtobuf sends the messages
+// as they are. Errors are a google.rpc.Status in the encoding of the answer.
+func NewServeMux(opts ...runtime.ServeMuxOption) *runtime.ServeMux {
+	jsonMarshaler := &runtime.HTTPBodyMarshaler{
+		Marshaler: &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		},
+	}
+	options := []runtime.ServeMuxOption{
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, jsonMarshaler),
+		runtime.WithMarshalerOption(MIMEJSON, jsonMarshaler),
+		runtime.WithMarshalerOption(MIMEProtobuf, &protoMarshaler{}),
+		runtime.WithIncomingHeaderMatcher(incomingHeader),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+		runtime.WithForwardResponseRewriter(rewriteResponse),
+		runtime.WithErrorHandler(writeError),
+		runtime.WithRoutingErrorHandler(writeRoutingError),
+		runtime.WithMiddlewares(trace, negotiate),
+	}
+	return runtime.NewServeMux(append(options, opts...)...)
+}
+
+// protoMarshaler is the runtime.ProtoMarshaller with the content type of
+// MIMEProtobuf and the framing of the streams.
+type protoMarshaler struct {
+	runtime.ProtoMarshaller
+}
+
+func (*protoMarshaler) ContentType(any) string {
+	return MIMEProtobuf
+}
+
+// Marshal encodes a message, the chunks of a stream come as the maps the
+// runtime wraps them in for JSON.
+func (m *protoMarshaler) Marshal(v any) ([]byte, error) {
+	switch chunk := v.(type) {
+	case map[string]any:
+		return marshalChunk(chunk["result"])
+	case map[string]proto.Message:
+		return marshalChunk(chunk["error"])
+	}
+	return m.ProtoMarshaller.Marshal(v)
+}
+
+// Unmarshal decodes a message, the routes with a body field decode into a
+// pointer to the field.
+func (m *protoMarshaler) Unmarshal(data []byte, v any) error {
+	if field := reflect.ValueOf(v); field.Kind() == reflect.Pointer && field.Elem().Kind() == reflect.Pointer {
+		if field.Elem().IsNil() {
+			field.Elem().Set(reflect.New(field.Elem().Type().Elem()))
+		}
+		v = field.Elem().Interface()
+	}
+	return m.ProtoMarshaller.Unmarshal(data, v)
+}
+
+func (m *protoMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
+	return runtime.DecoderFunc(func(v any) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return m.Unmarshal(data, v)
+	})
+}
+
+// Delimiter is empty, the chunks carry their own length.
+func (*protoMarshaler) Delimiter() []byte {
+	return nil
+}
+
+func marshalChunk(v any) ([]byte, error) {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return nil, errors.New("unable to marshal non proto chunk")
+	}
+	chunk, err := anypb.New(message)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := proto.Marshal(chunk)
+	if err != nil {
+		return nil, err
+	}
+	return protowire.AppendBytes(nil, buf), nil
+}
+
+type protobufAnswerKey struct{}
+
+// negotiate picks the encoding of the answer. The runtime only matches an
+// Accept holding exactly one media type, so the one the client prefers is
+// put back alone, and a wildcard leaves the answer in the encoding of the
+// body.
+func negotiate(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		accept := acceptedMediaType(r.Header.Values("Accept"))
+		if accept == "" {
+			r.Header.Del("Accept")
+		} else {
+			r.Header.Set("Accept", accept)
+		}
+		contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if accept == MIMEProtobuf || (accept == "" && contentType == MIMEProtobuf) {
+			r = r.WithContext(context.WithValue(r.Context(), protobufAnswerKey{}, true))
+		}
+		next(w, r, pathParams)
+	}
+}
+
+// acceptedMediaType is the supported media type with the highest quality in
+// the Accept headers, empty when the client takes anything.
+func acceptedMediaType(accept []string) string {
+	best, bestQuality := "", 0.0
+	for _, header := range accept {
+		for _, v := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(v))
+			if err != nil {
+				continue
+			}
+			quality := 1.0
+			if q, ok := params["q"]; ok {
+				if quality, err = strconv.ParseFloat(q, 64); err != nil {
+					continue
+				}
+			}
+			if quality <= bestQuality {
+				continue
+			}
+			switch mediaType {
+			case MIMEProtobuf, MIMEJSON:
+				best = mediaType
+			case "*/*", "application/*":
+				best = ""
+			default:
+				continue
+			}
+			bestQuality = quality
+		}
+	}
+	return best
+}
+
+// rewriteResponse sends the whole message in protobuf, a response_body
+// field may be a repeated one which has no encoding of its own.
+func rewriteResponse(ctx context.Context, response proto.Message) (any, error) {
+	if protobuf, _ := ctx.Value(protobufAnswerKey{}).(bool); protobuf {
+		return response.ProtoReflect().Interface(), nil
+	}
+	return response, nil
+}
+
+func incomingHeader(key string) (string, bool) {
+	if forwardedHeaders[textproto.CanonicalMIMEHeaderKey(key)] {
+		return key, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+func outgoingHeader(key string) (string, bool) {
+	if header := textproto.CanonicalMIMEHeaderKey(key); returnedHeaders[header] {
+		return header, true
+	}
+	return fmt.Sprintf("%s%s", runtime.MetadataHeaderPrefix, key), true
+}
+
+func writeError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	// the headers of the answer, e.g. the rate limit, are sent with the error
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		for key, values := range md.HeaderMD {
+			if header, ok := outgoingHeader(key); ok {
+				for _, v := range values {
+					w.Header().Add(header, v)
+				}
+			}
+		}
+	}
+	s := status.Convert(err)
+	utils.SetRetryAfter(w, s)
+	writeStatus(w, marshaler, utils.HTTPStatusFromCode(s.Code()), s)
+}
+
+func writeRoutingError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+	code := codes.Unknown
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusMethodNotAllowed:
+		code = codes.Unimplemented
+	}
+	writeStatus(w, marshaler, httpStatus, status.New(code, http.StatusText(httpStatus)))
+}
+
+// writeStatus answers with s as a google.rpc.Status in the encoding of
+// marshaler.
+func writeStatus(w http.ResponseWriter, marshaler runtime.Marshaler, httpStatus int, s *status.Status) {
+	body := s.Proto()
+	buf, err := marshaler.Marshal(body)
+	if err != nil {
+		log.Printf("failed to marshal the status %v: %v", s.Code(), err)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("%s", s.Message()))
+		return
+	}
+	w.Header().Set("Content-Type", marshaler.ContentType(body))
+	w.WriteHeader(httpStatus)
+	w.Write(buf)
+}
+
+// trace opens a span named after the route, like the spans of the REST
+// handlers.
+func trace(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		route := r.URL.Path
+		if pattern, ok := runtime.HTTPPattern(r.Context()); ok {
+			route = pattern.String()
+		}
+		span := opentracing.GlobalTracer().StartSpan(r.Method + " " + route)
+		defer span.Finish()
+
+		span.SetTag(string(ext.Component), "http")
+		span.SetTag("http.method", r.Method)
+
+		next(w, r.WithContext(opentracing.ContextWithSpan(r.Context(), span)), pathParams)
+	}
+}
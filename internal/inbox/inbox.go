@@ -0,0 +1,197 @@
+package inbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+const (
+	LocaleID = "id"
+	LocaleEN = "en"
+)
+
+// texts are the title and the body of the notifications by locale, the
+// title takes the order ID and the body the formatted total.
+var texts = map[string]map[string][2]string{
+	types.NotificationOrderCreated: {
+		LocaleID: {"Pesanan #%d diterima", "Pesanan sebesar %s sudah kami terima dan menunggu pembayaran."},
+		LocaleEN: {"Order #%d received", "We received your order of %s, it is waiting for the payment."},
+	},
+	types.NotificationOrderPaid: {
+		LocaleID: {"Pembayaran pesanan #%d diterima", "Pembayaran %s sudah kami terima, pesanan sedang disiapkan."},
+		LocaleEN: {"Payment of order #%d received", "We received the payment of %s, your order is being prepared."},
+	},
+	types.NotificationOrderShipped: {
+		LocaleID: {"Pesanan #%d dikirim", "Pesanan sebesar %s sudah dikirim dan dalam perjalanan."},
+		LocaleEN: {"Order #%d shipped", "Your order of %s has shipped and is on its way."},
+	},
+	types.NotificationNewOrder: {
+		LocaleID: {"Pesanan baru #%d", "Pesanan baru sebesar %s menunggu pembayaran."},
+		LocaleEN: {"New order #%d", "A new order of %s is waiting for the payment."},
+	},
+	types.NotificationPaymentReceived: {
+		LocaleID: {"Pesanan #%d dibayar", "Pembayaran %s diterima, pesanan siap disiapkan."},
+		LocaleEN: {"Order #%d paid", "The payment of %s was received, the order is ready to be prepared."},
+	},
+}
+
+// Order is the notification of an order event to the user, the admins get
+// a link to the order in the dashboard.
+func Order(kind string, locale string, userID int, orderID int, total string) types.Notification {
+	byLocale, ok := texts[kind]
+	if !ok {
+		return types.Notification{UserID: userID, Kind: kind, Title: fmt.Sprintf("#%d", orderID), Body: total}
+	}
+	text, ok := byLocale[locale]
+	if !ok {
+		text = byLocale[defaultLocale()]
+	}
+	n := types.Notification{
+		UserID: userID,
+		Kind:   kind,
+		Title:  fmt.Sprintf(text[0], orderID),
+		Body:   fmt.Sprintf(text[1], total),
+	}
+	switch kind {
+	case types.NotificationNewOrder, types.NotificationPaymentReceived:
+		n.Link = fmt.Sprintf("/admin/orders/%d", orderID)
+	}
+	return n
+}
+
+// defaultLocale is NOTIFY_LOCALE, the locale of the phone notifications.
+func defaultLocale() string {
+	switch config.Envs.NotifyLocale {
+	case LocaleID, LocaleEN:
+		return config.Envs.NotifyLocale
+	}
+	return LocaleID
+}
+
+// Hub hands the notifications to the streams open on this server, a stream
+// that can't keep up misses the notification and gets it from the inbox.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan types.Notification]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[int]map[chan types.Notification]struct{})}
+}
+
+// Subscribe streams the notifications of the user until cancel is called.
+func (h *Hub) Subscribe(userID int) (<-chan types.Notification, func()) {
+	ch := make(chan types.Notification, 16)
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan types.Notification]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[userID][ch]; !ok {
+			return
+		}
+		delete(h.subscribers[userID], ch)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+		close(ch)
+	}
+	return ch, cancel
+}
+
+func (h *Hub) Deliver(n types.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[n.UserID] {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
+
+// Publish delivers on this server only, it is the broker of a single
+// server.
+func (h *Hub) Publish(ctx context.Context, n types.Notification) error {
+	h.Deliver(n)
+	return nil
+}
+
+// Broker fans a stored notification out to the hubs of every server.
+type Broker interface {
+	Publish(ctx context.Context, n types.Notification) error
+}
+
+// Inbox stores the notifications of the users and publishes them to their
+// open pages.
+type Inbox struct {
+	store  types.NotificationStore
+	broker Broker
+	now    func() time.Time
+}
+
+func NewInbox(store types.NotificationStore, broker Broker) *Inbox {
+	return &Inbox{store: store, broker: broker, now: time.Now}
+}
+
+// Notify stores the notification and publishes it, the notification is in
+// the inbox even when it couldn't be published.
+func (i *Inbox) Notify(ctx context.Context, n types.Notification) error {
+	n.CreatedAt = i.now()
+	id, err := i.store.CreateNotification(n)
+	if err != nil {
+		return err
+	}
+	n.ID = int(id)
+	return i.broker.Publish(ctx, n)
+}
+
+// NotifyRole notifies every user of the role.
+func (i *Inbox) NotifyRole(ctx context.Context, role string, n types.Notification) error {
+	userIDs, err := i.store.GetUserIDsByRole(role)
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		n.UserID = userID
+		if err := i.Notify(ctx, n); err != nil {
+			log.Printf("failed to notify %s to user %d: %v", n.Kind, userID, err)
+		}
+	}
+	return nil
+}
+
+var inbox *Inbox
+
+// SetDefault sets the inbox used by Send and SendRole, nothing is sent
+// until the servers set one.
+func SetDefault(i *Inbox) {
+	inbox = i
+}
+
+// Send notifies the user of the notification with the default inbox.
+func Send(ctx context.Context, n types.Notification) error {
+	if inbox == nil {
+		return nil
+	}
+	return inbox.Notify(ctx, n)
+}
+
+// SendRole notifies every user of the role with the default inbox.
+func SendRole(ctx context.Context, role string, n types.Notification) error {
+	if inbox == nil {
+		return nil
+	}
+	return inbox.NotifyRole(ctx, role, n)
+}
@@ -0,0 +1,125 @@
+package inbox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+func TestOrder(t *testing.T) {
+	n := Order(types.NotificationOrderPaid, LocaleEN, 7, 42, "IDR 150,000")
+	if n.UserID != 7 || n.Kind != types.NotificationOrderPaid || n.Title != "Payment of order #42 received" || !strings.Contains(n.Body, "IDR 150,000") {
+		t.Errorf("unexpected notification %+v", n)
+	}
+	if n.Link != "" {
+		t.Errorf("expected no link for the customer, got %q", n.Link)
+	}
+
+	n = Order(types.NotificationNewOrder, "fr", 0, 42, "IDR 150.000")
+	if n.Title == "" || strings.Contains(n.Title, "%") || n.Link != "/admin/orders/42" {
+		t.Errorf("unexpected notification %+v", n)
+	}
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	live, cancel := hub.Subscribe(1)
+	other, cancelOther := hub.Subscribe(2)
+	defer cancelOther()
+
+	hub.Deliver(types.Notification{ID: 1, UserID: 1})
+	select {
+	case n := <-live:
+		if n.ID != 1 {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	default:
+		t.Fatal("expected the notification to be delivered")
+	}
+	select {
+	case n := <-other:
+		t.Errorf("expected nothing for another user, got %+v", n)
+	default:
+	}
+
+	cancel()
+	cancel()
+	if _, ok := <-live; ok {
+		t.Error("expected the stream to be closed")
+	}
+	hub.Deliver(types.Notification{ID: 2, UserID: 1})
+}
+
+func TestInbox(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := &mockNotificationStore{admins: []int{3, 4}}
+	hub := NewHub()
+	i := NewInbox(store, hub)
+	i.now = func() time.Time { return now }
+
+	live, cancel := hub.Subscribe(4)
+	defer cancel()
+
+	if err := i.Notify(context.Background(), Order(types.NotificationOrderCreated, LocaleID, 7, 42, "IDR 150.000")); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.NotifyRole(context.Background(), "admin", Order(types.NotificationNewOrder, LocaleID, 0, 42, "IDR 150.000")); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.created) != 3 || store.created[0].UserID != 7 || store.created[1].UserID != 3 || store.created[2].UserID != 4 {
+		t.Fatalf("unexpected notifications %+v", store.created)
+	}
+	if !store.created[0].CreatedAt.Equal(now) {
+		t.Errorf("expected the notification to be created now, got %v", store.created[0].CreatedAt)
+	}
+	select {
+	case n := <-live:
+		if n.ID != 3 || n.Kind != types.NotificationNewOrder {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	default:
+		t.Error("expected the admin to get the notification live")
+	}
+}
+
+func TestStream(t *testing.T) {
+	live := make(chan types.Notification, 2)
+	live <- types.Notification{ID: 2, UserID: 1, Title: "replayed already"}
+	live <- types.Notification{ID: 3, UserID: 1, Title: "Pesanan #42 dikirim"}
+	close(live)
+
+	req := httptest.NewRequest(http.MethodGet, "/me/notifications/stream", nil)
+	rr := httptest.NewRecorder()
+	if err := Stream(rr, req, []types.Notification{{ID: 2, UserID: 1}}, live); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("unexpected content type %q", rr.Header().Get("Content-Type"))
+	}
+	body := rr.Body.String()
+	if strings.Count(body, "event: notification") != 2 || !strings.Contains(body, "id: 2\n") || !strings.Contains(body, "id: 3\n") {
+		t.Errorf("unexpected stream %q", body)
+	}
+	if strings.Contains(body, "replayed already") {
+		t.Errorf("expected the replayed notification to be skipped, got %q", body)
+	}
+}
+
+type mockNotificationStore struct {
+	types.NotificationStore
+	created []types.Notification
+	admins  []int
+}
+
+func (m *mockNotificationStore) CreateNotification(n types.Notification) (int64, error) {
+	m.created = append(m.created, n)
+	return int64(len(m.created)), nil
+}
+func (m *mockNotificationStore) GetUserIDsByRole(role string) ([]int, error) {
+	return m.admins, nil
+}
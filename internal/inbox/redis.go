@@ -0,0 +1,54 @@
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisBroker publishes the notifications on a redis channel every server
+// subscribes to, so a stream gets the notifications of any server.
+type RedisBroker struct {
+	client  *redis.Client
+	channel string
+	hub     *Hub
+}
+
+func NewRedisBroker(client *redis.Client, channel string, hub *Hub) *RedisBroker {
+	return &RedisBroker{client: client, channel: channel, hub: hub}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, n types.Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+// Run delivers the notifications published by every server to the hub until
+// the context is done.
+func (b *RedisBroker) Run(ctx context.Context) {
+	sub := b.client.Subscribe(ctx, b.channel)
+	defer sub.Close()
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var n types.Notification
+			if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+				log.Printf("invalid notification on %s: %v", b.channel, err)
+				continue
+			}
+			b.hub.Deliver(n)
+		}
+	}
+}
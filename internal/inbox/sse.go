@@ -0,0 +1,71 @@
+package inbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+// heartbeat keeps the idle streams open through the proxies.
+const heartbeat = 25 * time.Second
+
+// Stream writes the missed notifications then the live ones as server-sent
+// events until the client goes away. The ID of each event is the ID of the
+// notification, a reconnecting EventSource sends it back as Last-Event-ID.
+func Stream(w http.ResponseWriter, r *http.Request, missed []types.Notification, live <-chan types.Notification) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming is not supported")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	lastID := 0
+	for _, n := range missed {
+		if err := writeEvent(w, n); err != nil {
+			return err
+		}
+		lastID = n.ID
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return err
+			}
+		case n, ok := <-live:
+			if !ok {
+				return nil
+			}
+			// a notification stored while the missed ones were read
+			if n.ID <= lastID {
+				continue
+			}
+			if err := writeEvent(w, n); err != nil {
+				return err
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, n types.Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: notification\ndata: %s\n\n", n.ID, data)
+	return err
+}
@@ -0,0 +1,145 @@
+package inventory
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	StrategyNearest   = "nearest"
+	StrategyMostStock = "most_stock"
+)
+
+// Location is a point on the map in degrees.
+type Location struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Source is the stock of a product in a warehouse that a line can be
+// allocated from.
+type Source struct {
+	WarehouseID int
+	Location    Location
+	Quantity    int
+}
+
+// Allocation is the part of an order line shipped from a warehouse.
+type Allocation struct {
+	WarehouseID int `json:"warehouse_id"`
+	Quantity    int `json:"qty"`
+}
+
+// Allocate splits qty over the sources by the strategy: nearest takes from
+// the warehouses closest to dest first and most_stock from the ones holding
+// the most. A warehouse that can't cover the whole line gives what it has
+// and the rest comes from the next one. nearest falls back to most_stock when
+// dest is nil, e.g. the shipping address isn't a city we know.
+func Allocate(qty int, sources []Source, strategy string, dest *Location) ([]Allocation, error) {
+	if qty <= 0 {
+		return nil, fmt.Errorf("invalid quantity %d", qty)
+	}
+	if strategy != StrategyNearest && strategy != StrategyMostStock {
+		return nil, fmt.Errorf("unknown allocation strategy %q", strategy)
+	}
+
+	sorted := make([]Source, 0, len(sources))
+	for _, source := range sources {
+		if source.Quantity > 0 {
+			sorted = append(sorted, source)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if strategy == StrategyNearest && dest != nil {
+			da, db := Distance(*dest, a.Location), Distance(*dest, b.Location)
+			if da != db {
+				return da < db
+			}
+		}
+		if a.Quantity != b.Quantity {
+			return a.Quantity > b.Quantity
+		}
+		return a.WarehouseID < b.WarehouseID
+	})
+
+	var allocations []Allocation
+	left := qty
+	for _, source := range sorted {
+		if left == 0 {
+			break
+		}
+		n := min(left, source.Quantity)
+		allocations = append(allocations, Allocation{WarehouseID: source.WarehouseID, Quantity: n})
+		left -= n
+	}
+	if left > 0 {
+		return nil, fmt.Errorf("not enough stock, %d short", left)
+	}
+	return allocations, nil
+}
+
+const earthRadiusInKm = 6371.0
+
+// Distance is the great-circle distance between a and b in kilometres.
+func Distance(a Location, b Location) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusInKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// cities are the places the shipping addresses are located by, the addresses
+// are free text and don't carry coordinates.
+var cities = map[string]Location{
+	"jakarta":     {-6.2088, 106.8456},
+	"bogor":       {-6.5950, 106.8166},
+	"depok":       {-6.4025, 106.7942},
+	"tangerang":   {-6.1783, 106.6319},
+	"bekasi":      {-6.2383, 106.9756},
+	"serang":      {-6.1200, 106.1503},
+	"karawang":    {-6.3227, 107.3376},
+	"purwakarta":  {-6.5569, 107.4431},
+	"bandung":     {-6.9175, 107.6191},
+	"cimahi":      {-6.8722, 107.5425},
+	"sumedang":    {-6.8586, 107.9167},
+	"garut":       {-7.2279, 107.9087},
+	"tasikmalaya": {-7.3274, 108.2207},
+	"sukabumi":    {-6.9277, 106.9300},
+	"cianjur":     {-6.8168, 107.1425},
+	"cirebon":     {-6.7320, 108.5523},
+	"semarang":    {-6.9667, 110.4167},
+	"yogyakarta":  {-7.7956, 110.3695},
+	"surakarta":   {-7.5755, 110.8243},
+	"solo":        {-7.5755, 110.8243},
+	"surabaya":    {-7.2575, 112.7521},
+	"malang":      {-7.9666, 112.6326},
+	"denpasar":    {-8.6705, 115.2126},
+	"medan":       {3.5952, 98.6722},
+	"palembang":   {-2.9761, 104.7754},
+	"lampung":     {-5.3971, 105.2668},
+	"pontianak":   {-0.0263, 109.3425},
+	"balikpapan":  {-1.2379, 116.8529},
+	"makassar":    {-5.1477, 119.4327},
+}
+
+// Locate finds the city of an address. The city is written last in
+// Indonesian addresses, after streets that are often named after other
+// cities, so the match closest to the end wins.
+func Locate(address string) (Location, bool) {
+	address = strings.ToLower(address)
+	best, bestAt := Location{}, -1
+	for city, location := range cities {
+		if at := strings.LastIndex(address, city); at > bestAt {
+			best, bestAt = location, at
+		}
+	}
+	return best, bestAt >= 0
+}
@@ -0,0 +1,64 @@
+package inventory
+
+import (
+	"fmt"
+	"testing"
+)
+
+var (
+	jakarta = Location{-6.2088, 106.8456}
+	bandung = Location{-6.9175, 107.6191}
+)
+
+func TestAllocate(t *testing.T) {
+	sources := []Source{
+		{WarehouseID: 1, Location: jakarta, Quantity: 3},
+		{WarehouseID: 2, Location: bandung, Quantity: 10},
+	}
+	cimahi, _ := Locate("Jl. Jakarta No. 12, Cimahi")
+
+	tests := []struct {
+		name     string
+		qty      int
+		strategy string
+		dest     *Location
+		want     string
+	}{
+		{"nearest covers the line", 2, StrategyNearest, &jakarta, "[{1 2}]"},
+		{"nearest splits the line", 5, StrategyNearest, &jakarta, "[{1 3} {2 2}]"},
+		{"nearest by the city of the address", 5, StrategyNearest, &cimahi, "[{2 5}]"},
+		{"nearest without a destination", 5, StrategyNearest, nil, "[{2 5}]"},
+		{"most stock", 2, StrategyMostStock, &jakarta, "[{2 2}]"},
+		{"most stock splits the line", 12, StrategyMostStock, &jakarta, "[{2 10} {1 2}]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Allocate(tt.qty, sources, tt.strategy, tt.dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("expected %s, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := Allocate(14, sources, StrategyNearest, &jakarta); err == nil {
+		t.Error("expected an error when the warehouses don't hold enough stock")
+	}
+	if _, err := Allocate(1, sources, "cheapest", &jakarta); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+func TestLocate(t *testing.T) {
+	if location, ok := Locate("Jl. Asia Afrika 8, BANDUNG 40111"); !ok || location != bandung {
+		t.Errorf("expected Bandung, got %v %v", location, ok)
+	}
+	if _, ok := Locate("Jl. Mawar 1, Atlantis"); ok {
+		t.Error("expected an unknown city not to be located")
+	}
+	if d := Distance(jakarta, bandung); d < 110 || d > 125 {
+		t.Errorf("expected Jakarta and Bandung to be about 117 km apart, got %.1f", d)
+	}
+}
@@ -0,0 +1,118 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
+)
+
+// Alert is sent when the stock of a product goes down to or below its
+// threshold.
+type Alert struct {
+	ProductID int       `json:"product_id"`
+	Name      string    `json:"name"`
+	Quantity  int       `json:"qty"`
+	Previous  int       `json:"previous_qty"`
+	Threshold int       `json:"threshold"`
+	Reference string    `json:"reference"`
+	At        time.Time `json:"at"`
+}
+
+// Crossed reports whether a change of the stock from before to after went
+// down to the threshold, stock that is already low doesn't alert again until
+// it is restocked above it. A threshold of 0 turns the alerts off.
+func Crossed(before int, after int, threshold int) bool {
+	return threshold > 0 && before > threshold && after <= threshold
+}
+
+// Notifier mails the alerts to the recipients and publishes them as events,
+// either one is skipped when it isn't set.
+type Notifier struct {
+	recipients []string
+	sendMail   func(to []string, alert Alert) error
+	publish    func(data []byte) error
+}
+
+func NewNotifier(recipients []string, sendMail func(to []string, alert Alert) error, publish func(data []byte) error) *Notifier {
+	return &Notifier{recipients: recipients, sendMail: sendMail, publish: publish}
+}
+
+var notifier *Notifier
+
+// SetDefault sets the notifier used by Notify, nothing is sent until the
+// servers set one.
+func SetDefault(n *Notifier) {
+	notifier = n
+}
+
+// Notify sends the alert with the default notifier in the background, the
+// stock has already moved and checkout shouldn't wait on the mail server.
+func Notify(alert Alert) {
+	if notifier == nil {
+		return
+	}
+	go func() {
+		if err := notifier.Notify(alert); err != nil {
+			log.Printf("failed to send the low-stock alert of product %d: %v", alert.ProductID, err)
+		}
+	}()
+}
+
+func (n *Notifier) Notify(alert Alert) error {
+	var errs []error
+	if n.sendMail != nil && len(n.recipients) > 0 {
+		errs = append(errs, n.sendMail(n.recipients, alert))
+	}
+	if n.publish != nil {
+		data, err := json.Marshal(alert)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, n.publish(data))
+	}
+	return errors.Join(errs...)
+}
+
+// ReorderQuantity suggests how much to order so the stock covers coverDays
+// of sales at the velocity of the last windowDays and stays above the
+// threshold.
+func ReorderQuantity(sold int, windowDays int, coverDays int, qty int, threshold int) int {
+	if windowDays <= 0 {
+		return 0
+	}
+	needed := int(math.Ceil(DailySales(sold, windowDays)*float64(coverDays))) + threshold
+	if needed <= qty {
+		return 0
+	}
+	return needed - qty
+}
+
+// DailySales is the sales velocity of a product.
+func DailySales(sold int, windowDays int) float64 {
+	if windowDays <= 0 {
+		return 0
+	}
+	return float64(sold) / float64(windowDays)
+}
+
+// NotifierFromConfig mails the alerts to LOW_STOCK_ALERT_EMAILS and publishes
+// them with publish, which is nil when there is no event bus.
+func NotifierFromConfig(publish func(data []byte) error) *Notifier {
+	var recipients []string
+	for _, email := range strings.Split(config.Envs.LowStockAlertEmails, ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			recipients = append(recipients, email)
+		}
+	}
+	// mailed in MAIL_LOCALE, the admins have no language of their own
+	sendMail := func(to []string, alert Alert) error {
+		return mailer.SendLowStockEmail("", to, alert.Name, alert.Quantity, alert.Threshold)
+	}
+	return NewNotifier(recipients, sendMail, publish)
+}
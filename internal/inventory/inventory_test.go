@@ -0,0 +1,73 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestCrossed(t *testing.T) {
+	tests := []struct {
+		before, after, threshold int
+		want                     bool
+	}{
+		{10, 5, 5, true},
+		{10, 2, 5, true},
+		{10, 6, 5, false},
+		{5, 3, 5, false},
+		{3, 8, 5, false},
+		{10, 0, 0, false},
+	}
+	for _, tt := range tests {
+		if got := Crossed(tt.before, tt.after, tt.threshold); got != tt.want {
+			t.Errorf("Crossed(%d, %d, %d) = %v, want %v", tt.before, tt.after, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var mailed []string
+	var published []Alert
+	n := NewNotifier([]string{"stock@tj-jeans.id"},
+		func(to []string, alert Alert) error {
+			mailed = append(mailed, fmt.Sprint(to, " ", alert.Name))
+			return nil
+		},
+		func(data []byte) error {
+			var alert Alert
+			if err := json.Unmarshal(data, &alert); err != nil {
+				return err
+			}
+			published = append(published, alert)
+			return fmt.Errorf("nats is down")
+		},
+	)
+
+	err := n.Notify(Alert{ProductID: 3, Name: "Slim Fit 32", Quantity: 2, Previous: 6, Threshold: 5})
+	if err == nil {
+		t.Error("expected the failed publish to be returned")
+	}
+	if len(mailed) != 1 || mailed[0] != "[stock@tj-jeans.id] Slim Fit 32" {
+		t.Errorf("unexpected mails %v", mailed)
+	}
+	if len(published) != 1 || published[0].ProductID != 3 {
+		t.Errorf("unexpected events %v", published)
+	}
+}
+
+func TestReorderQuantity(t *testing.T) {
+	// 60 sold in 30 days is 2 a day, 14 days of cover and a threshold of 5
+	// need 33 in stock
+	if got := ReorderQuantity(60, 30, 14, 10, 5); got != 23 {
+		t.Errorf("expected to reorder 23, got %d", got)
+	}
+	if got := ReorderQuantity(60, 30, 14, 40, 5); got != 0 {
+		t.Errorf("expected no reorder with enough stock, got %d", got)
+	}
+	if got := ReorderQuantity(0, 30, 14, 0, 5); got != 5 {
+		t.Errorf("expected to reorder up to the threshold, got %d", got)
+	}
+	if got := ReorderQuantity(1, 30, 14, 0, 0); got != 1 {
+		t.Errorf("expected partial days of sales to round up, got %d", got)
+	}
+}
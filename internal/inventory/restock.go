@@ -0,0 +1,131 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+const restockBatch = 100
+
+// Restocked reports whether a change of the stock from before to after
+// brought a sold out product back.
+func Restocked(before int, after int) bool {
+	return before <= 0 && after > 0
+}
+
+// RestockWorker mails the subscribers of the products that are back in
+// stock. A subscription is claimed before its mail is sent so it is mailed
+// at most once even with several servers running the worker.
+type RestockWorker struct {
+	store    types.RestockStore
+	send     func(types.RestockNotice) error
+	interval time.Duration
+	wake     chan struct{}
+	now      func() time.Time
+}
+
+func NewRestockWorker(store types.RestockStore, send func(types.RestockNotice) error, interval time.Duration) *RestockWorker {
+	return &RestockWorker{
+		store:    store,
+		send:     send,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+// RestockWorkerFromConfig mails a link to the product on the storefront and
+// checks every BACK_IN_STOCK_INTERVAL seconds.
+func RestockWorkerFromConfig(store types.RestockStore) *RestockWorker {
+	// mailed in MAIL_LOCALE, the subscription doesn't keep a language
+	send := func(n types.RestockNotice) error {
+		link := fmt.Sprintf("%s:%s/products?product_id=%d", config.Envs.PublicHost, config.Envs.PortWeb, n.ProductID)
+		return mailer.SendBackInStockEmail("", n.Email, n.FirstName, n.ProductName, link)
+	}
+	return NewRestockWorker(store, send, time.Second*time.Duration(config.Envs.BackInStockIntervalInSeconds))
+}
+
+var restockWorker *RestockWorker
+
+// SetRestockDefault sets the worker woken by WakeRestock.
+func SetRestockDefault(w *RestockWorker) {
+	restockWorker = w
+}
+
+// WakeRestock runs the default worker now instead of on its next tick, it is
+// called once a restock is committed.
+func WakeRestock() {
+	if restockWorker != nil {
+		restockWorker.Wake()
+	}
+}
+
+func (w *RestockWorker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunOnce forgets the expired subscriptions and mails the due ones, it
+// returns how many were mailed. A failed mail is logged and not retried, the
+// subscriber can subscribe again.
+func (w *RestockWorker) RunOnce() int {
+	now := w.now()
+	if n, err := w.store.ExpireRestockSubscriptions(now); err != nil {
+		log.Printf("failed to expire the back-in-stock subscriptions: %v", err)
+	} else if n > 0 {
+		log.Printf("expired %d back-in-stock subscriptions", n)
+	}
+
+	sent := 0
+	for {
+		notices, err := w.store.GetDueRestockNotices(now, restockBatch)
+		if err != nil {
+			log.Printf("failed to get the back-in-stock subscriptions: %v", err)
+			return sent
+		}
+		for _, n := range notices {
+			claimed, err := w.store.ClaimRestockNotice(n.SubscriptionID, now)
+			if err != nil {
+				log.Printf("failed to claim the back-in-stock subscription %d: %v", n.SubscriptionID, err)
+				return sent
+			}
+			if !claimed {
+				continue
+			}
+			if err := w.send(n); err != nil {
+				log.Printf("failed to mail %s that product %d is back in stock: %v", n.Email, n.ProductID, err)
+				continue
+			}
+			sent++
+		}
+		if len(notices) < restockBatch {
+			return sent
+		}
+	}
+}
+
+// Run mails the due subscriptions on every interval or when woken, until the
+// context is done.
+func (w *RestockWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	w.RunOnce()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.RunOnce()
+		case <-w.wake:
+			w.RunOnce()
+		}
+	}
+}
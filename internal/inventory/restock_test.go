@@ -0,0 +1,92 @@
+package inventory
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+func TestRestocked(t *testing.T) {
+	tests := []struct {
+		before, after int
+		want          bool
+	}{
+		{0, 5, true},
+		{0, 1, true},
+		{2, 5, false},
+		{0, 0, false},
+		{5, 0, false},
+	}
+	for _, tt := range tests {
+		if got := Restocked(tt.before, tt.after); got != tt.want {
+			t.Errorf("Restocked(%d, %d) = %v, want %v", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
+
+type mockRestockStore struct {
+	types.RestockStore
+	due      []types.RestockNotice
+	claimed  map[int]bool
+	expired  time.Time
+	getCalls int
+}
+
+func (m *mockRestockStore) GetDueRestockNotices(now time.Time, limit int) ([]types.RestockNotice, error) {
+	m.getCalls++
+	var due []types.RestockNotice
+	for _, n := range m.due {
+		if !m.claimed[n.SubscriptionID] {
+			due = append(due, n)
+		}
+	}
+	return due, nil
+}
+func (m *mockRestockStore) ClaimRestockNotice(id int, at time.Time) (bool, error) {
+	if m.claimed[id] {
+		return false, nil
+	}
+	m.claimed[id] = true
+	return true, nil
+}
+func (m *mockRestockStore) ExpireRestockSubscriptions(now time.Time) (int64, error) {
+	m.expired = now
+	return 0, nil
+}
+
+func TestRestockWorker(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := &mockRestockStore{
+		due: []types.RestockNotice{
+			{SubscriptionID: 1, Email: "ayu@tj-jeans.id", ProductID: 3, ProductName: "Slim Jeans"},
+			{SubscriptionID: 2, Email: "budi@tj-jeans.id", ProductID: 3, ProductName: "Slim Jeans"},
+			{SubscriptionID: 3, Email: "citra@tj-jeans.id", ProductID: 4, ProductName: "Denim Jacket"},
+		},
+		claimed: map[int]bool{2: true},
+	}
+	var mailed []string
+	w := NewRestockWorker(store, func(n types.RestockNotice) error {
+		if n.SubscriptionID == 3 {
+			return fmt.Errorf("mail server is down")
+		}
+		mailed = append(mailed, n.Email)
+		return nil
+	}, time.Minute)
+	w.now = func() time.Time { return now }
+
+	if sent := w.RunOnce(); sent != 1 {
+		t.Errorf("expected 1 mail to be sent, got %d", sent)
+	}
+	if fmt.Sprint(mailed) != "[ayu@tj-jeans.id]" {
+		t.Errorf("expected the subscription claimed by another worker to be skipped, got %v", mailed)
+	}
+	if !store.expired.Equal(now) {
+		t.Errorf("expected the subscriptions to be expired at %v, got %v", now, store.expired)
+	}
+
+	if sent := w.RunOnce(); sent != 0 || len(mailed) != 1 {
+		t.Errorf("expected the mailed subscriptions not to be mailed again, got %d %v", sent, mailed)
+	}
+}
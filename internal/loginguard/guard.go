@@ -0,0 +1,205 @@
+package loginguard
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+)
+
+const (
+	EventAccountLocked      = "login.account_locked"
+	EventIPLocked           = "login.ip_locked"
+	EventCredentialStuffing = "login.credential_stuffing"
+	EventAccountUnlocked    = "login.account_unlocked"
+)
+
+// Event is emitted for suspicious login patterns.
+type Event struct {
+	Type  string
+	Email string
+	IP    string
+	Count int64
+	At    time.Time
+}
+
+type Policy struct {
+	// MaxAccountFailures locks the account after that many failures in Window.
+	MaxAccountFailures int64
+	// MaxIPFailures locks the client address after that many failures in Window.
+	MaxIPFailures int64
+	// MaxIPAccounts is the number of distinct emails failing from one address
+	// in Window before it is treated as credential stuffing and locked.
+	MaxIPAccounts int64
+	Window        time.Duration
+	Lockout       time.Duration
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+}
+
+func PolicyFromConfig() Policy {
+	return Policy{
+		MaxAccountFailures: config.Envs.LoginMaxAccountFailures,
+		MaxIPFailures:      config.Envs.LoginMaxIPFailures,
+		MaxIPAccounts:      config.Envs.LoginMaxIPAccounts,
+		Window:             time.Second * time.Duration(config.Envs.LoginFailureWindowInSeconds),
+		Lockout:            time.Second * time.Duration(config.Envs.LoginLockoutInSeconds),
+		BaseDelay:          time.Millisecond * time.Duration(config.Envs.LoginDelayBaseInMilliseconds),
+		MaxDelay:           time.Millisecond * time.Duration(config.Envs.LoginDelayMaxInMilliseconds),
+	}
+}
+
+// Guard counts failed logins per account and per client address. Accounts
+// are keyed by email whether they exist or not, so the answers for unknown
+// emails and wrong passwords stay the same.
+type Guard struct {
+	store  Store
+	policy Policy
+	// Audit receives the suspicious login events, they are logged by default.
+	Audit func(Event)
+	// Now is the clock of the events, tests replace it.
+	Now func() time.Time
+}
+
+func New(store Store, policy Policy) *Guard {
+	return &Guard{store: store, policy: policy, Audit: logEvent, Now: time.Now}
+}
+
+func logEvent(e Event) {
+	log.Printf("audit: %v email=%v ip=%v count=%v at=%v", e.Type, e.Email, e.IP, e.Count, e.At.Format(time.RFC3339))
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func accountFailuresKey(email string) string { return "login:fail:account:" + email }
+func ipFailuresKey(ip string) string         { return "login:fail:ip:" + ip }
+func ipAccountsKey(ip string) string         { return "login:accounts:ip:" + ip }
+func accountLockKey(email string) string     { return "login:lock:account:" + email }
+func ipLockKey(ip string) string             { return "login:lock:ip:" + ip }
+func unlockTokenKey(hash string) string      { return "login:unlock:" + hash }
+
+// Check returns how long the client must wait when the account or the
+// address is locked, otherwise the delay to apply before checking the password.
+func (g *Guard) Check(ctx context.Context, email string, ip string) (retryAfter time.Duration, delay time.Duration, err error) {
+	email = normalizeEmail(email)
+	for _, key := range []string{accountLockKey(email), ipLockKey(ip)} {
+		ttl, err := g.store.TTL(ctx, key)
+		if err != nil {
+			return 0, 0, err
+		}
+		if ttl > retryAfter {
+			retryAfter = ttl
+		}
+	}
+	if retryAfter > 0 {
+		return retryAfter, 0, nil
+	}
+
+	accountFailures, err := g.store.Get(ctx, accountFailuresKey(email))
+	if err != nil {
+		return 0, 0, err
+	}
+	ipFailures, err := g.store.Get(ctx, ipFailuresKey(ip))
+	if err != nil {
+		return 0, 0, err
+	}
+	return 0, g.delay(max(accountFailures, ipFailures)), nil
+}
+
+// delay doubles with every failure, from BaseDelay up to MaxDelay.
+func (g *Guard) delay(failures int64) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	delay := g.policy.BaseDelay
+	for i := int64(1); i < failures && delay < g.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, g.policy.MaxDelay)
+}
+
+// Fail records a failed login and reports whether the account just got
+// locked, so the caller can send the unlock email.
+func (g *Guard) Fail(ctx context.Context, email string, ip string) (bool, error) {
+	email = normalizeEmail(email)
+	now := g.Now()
+
+	accountFailures, err := g.store.Incr(ctx, accountFailuresKey(email), g.policy.Window)
+	if err != nil {
+		return false, err
+	}
+	ipFailures, err := g.store.Incr(ctx, ipFailuresKey(ip), g.policy.Window)
+	if err != nil {
+		return false, err
+	}
+	ipAccounts, err := g.store.AddToSet(ctx, ipAccountsKey(ip), email, g.policy.Window)
+	if err != nil {
+		return false, err
+	}
+
+	if ipAccounts == g.policy.MaxIPAccounts {
+		g.Audit(Event{Type: EventCredentialStuffing, Email: email, IP: ip, Count: ipAccounts, At: now})
+	}
+	if ipFailures >= g.policy.MaxIPFailures || ipAccounts >= g.policy.MaxIPAccounts {
+		if err := g.store.Set(ctx, ipLockKey(ip), "1", g.policy.Lockout); err != nil {
+			return false, err
+		}
+		if ipFailures == g.policy.MaxIPFailures {
+			g.Audit(Event{Type: EventIPLocked, Email: email, IP: ip, Count: ipFailures, At: now})
+		}
+	}
+
+	if accountFailures >= g.policy.MaxAccountFailures {
+		if err := g.store.Set(ctx, accountLockKey(email), "1", g.policy.Lockout); err != nil {
+			return false, err
+		}
+		if accountFailures == g.policy.MaxAccountFailures {
+			g.Audit(Event{Type: EventAccountLocked, Email: email, IP: ip, Count: accountFailures, At: now})
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Succeed clears the failures of the account, the address keeps its own.
+func (g *Guard) Succeed(ctx context.Context, email string) error {
+	return g.store.Del(ctx, accountFailuresKey(normalizeEmail(email)))
+}
+
+// IssueUnlockToken returns a single use token that lifts the lock of the
+// account, it's only valid as long as the lockout.
+func (g *Guard) IssueUnlockToken(ctx context.Context, email string) (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	token := base64.URLEncoding.EncodeToString(bytes)
+	if err := g.store.Set(ctx, unlockTokenKey(auth.HashToken(token)), normalizeEmail(email), g.policy.Lockout); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Unlock lifts the lock and clears the failures of the account of the token.
+func (g *Guard) Unlock(ctx context.Context, token string, ip string) (string, error) {
+	email, err := g.store.Take(ctx, unlockTokenKey(auth.HashToken(token)))
+	if err != nil {
+		return "", err
+	}
+	if email == "" {
+		return "", fmt.Errorf("invalid or expired token")
+	}
+	if err := g.store.Del(ctx, accountLockKey(email), accountFailuresKey(email)); err != nil {
+		return "", err
+	}
+	g.Audit(Event{Type: EventAccountUnlocked, Email: email, IP: ip, At: g.Now()})
+	return email, nil
+}
@@ -0,0 +1,102 @@
+package loginguard
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestGuard(now *time.Time) (*Guard, *[]Event) {
+	clock := func() time.Time { return *now }
+	g := New(NewMemoryStore(clock), Policy{
+		MaxAccountFailures: 3,
+		MaxIPFailures:      10,
+		MaxIPAccounts:      4,
+		Window:             time.Minute * 15,
+		Lockout:            time.Minute * 15,
+		BaseDelay:          time.Millisecond * 100,
+		MaxDelay:           time.Millisecond * 300,
+	})
+	events := &[]Event{}
+	g.Audit = func(e Event) { *events = append(*events, e) }
+	g.Now = clock
+	return g, events
+}
+
+func TestGuardProgressiveDelayAndLockout(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	g, events := newTestGuard(&now)
+
+	wantDelays := []time.Duration{0, time.Millisecond * 100, time.Millisecond * 200}
+	for i, want := range wantDelays {
+		retryAfter, delay, err := g.Check(ctx, "User@Gmail.com", "10.0.0.1")
+		if err != nil || retryAfter != 0 || delay != want {
+			t.Fatalf("attempt %d: expected delay %v, got %v retry after %v err %v", i, want, delay, retryAfter, err)
+		}
+		locked, _ := g.Fail(ctx, "user@gmail.com", "10.0.0.1")
+		if locked != (i == len(wantDelays)-1) {
+			t.Errorf("attempt %d: unexpected lock %v", i, locked)
+		}
+	}
+
+	retryAfter, _, _ := g.Check(ctx, "user@gmail.com", "10.0.0.2")
+	if retryAfter != time.Minute*15 {
+		t.Errorf("expected account to be locked from any address, got retry after %v", retryAfter)
+	}
+	if len(*events) != 1 || (*events)[0].Type != EventAccountLocked {
+		t.Errorf("expected account locked event, got %+v", *events)
+	}
+
+	now = now.Add(time.Minute * 16)
+	if retryAfter, _, _ := g.Check(ctx, "user@gmail.com", "10.0.0.1"); retryAfter != 0 {
+		t.Errorf("expected lock to expire, got retry after %v", retryAfter)
+	}
+}
+
+func TestGuardUnlock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	g, _ := newTestGuard(&now)
+
+	for i := 0; i < 3; i++ {
+		g.Fail(ctx, "user@gmail.com", "10.0.0.1")
+	}
+	token, err := g.IssueUnlockToken(ctx, "user@gmail.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.Unlock(ctx, "unknown", "10.0.0.1"); err == nil {
+		t.Error("expected unknown token to fail")
+	}
+	if email, err := g.Unlock(ctx, token, "10.0.0.1"); err != nil || email != "user@gmail.com" {
+		t.Errorf("expected user@gmail.com to be unlocked, got %v %v", email, err)
+	}
+	if _, err := g.Unlock(ctx, token, "10.0.0.1"); err == nil {
+		t.Error("expected unlock token to be single use")
+	}
+	if retryAfter, _, _ := g.Check(ctx, "user@gmail.com", "10.0.0.3"); retryAfter != 0 {
+		t.Errorf("expected account to be unlocked, got retry after %v", retryAfter)
+	}
+}
+
+func TestGuardCredentialStuffing(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	g, events := newTestGuard(&now)
+
+	for _, email := range []string{"a@gmail.com", "b@gmail.com", "c@gmail.com", "d@gmail.com"} {
+		g.Fail(ctx, email, "10.0.0.9")
+	}
+
+	if retryAfter, _, _ := g.Check(ctx, "e@gmail.com", "10.0.0.9"); retryAfter == 0 {
+		t.Error("expected address to be locked")
+	}
+	if retryAfter, _, _ := g.Check(ctx, "e@gmail.com", "10.0.0.1"); retryAfter != 0 {
+		t.Error("expected other addresses not to be locked")
+	}
+	if len(*events) != 1 || (*events)[0].Type != EventCredentialStuffing {
+		t.Errorf("expected credential stuffing event, got %+v", *events)
+	}
+}
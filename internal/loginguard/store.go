@@ -0,0 +1,190 @@
+package loginguard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Store keeps the counters and locks, every key expires on its own.
+type Store interface {
+	Get(ctx context.Context, key string) (int64, error)
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	AddToSet(ctx context.Context, key string, member string, window time.Duration) (int64, error)
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Take(ctx context.Context, key string) (string, error)
+	Del(ctx context.Context, keys ...string) error
+}
+
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (int64, error) {
+	n, err := s.client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+// Incr starts the window on the first failure, later ones don't extend it.
+func (s *RedisStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (s *RedisStore) AddToSet(ctx context.Context, key string, member string, window time.Duration) (int64, error) {
+	var card *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, member)
+		pipe.ExpireNX(ctx, key, window)
+		card = pipe.SCard(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return card.Val(), nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *RedisStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// negative when the key is missing or never expires
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string) (string, error) {
+	value, err := s.client.GetDel(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return value, err
+}
+
+func (s *RedisStore) Del(ctx context.Context, keys ...string) error {
+	return s.client.Del(ctx, keys...).Err()
+}
+
+type memoryEntry struct {
+	value     string
+	count     int64
+	members   map[string]bool
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the counters in the process, it's used when no redis
+// client is configured, e.g. in tests.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	now     func() time.Time
+}
+
+func NewMemoryStore(now func() time.Time) *MemoryStore {
+	return &MemoryStore{entries: map[string]*memoryEntry{}, now: now}
+}
+
+func (s *MemoryStore) entry(key string) *memoryEntry {
+	e, ok := s.entries[key]
+	if ok && !s.now().Before(e.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+func (s *MemoryStore) entryOrNew(key string, window time.Duration) *memoryEntry {
+	e := s.entry(key)
+	if e == nil {
+		e = &memoryEntry{members: map[string]bool{}, expiresAt: s.now().Add(window)}
+		s.entries[key] = e
+	}
+	return e
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.entry(key); e != nil {
+		return e.count, nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entryOrNew(key, window)
+	e.count++
+	return e.count, nil
+}
+
+func (s *MemoryStore) AddToSet(ctx context.Context, key string, member string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entryOrNew(key, window)
+	e.members[member] = true
+	return int64(len(e.members)), nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = &memoryEntry{value: value, members: map[string]bool{}, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.entry(key); e != nil {
+		return e.expiresAt.Sub(s.now()), nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entry(key)
+	if e == nil {
+		return "", nil
+	}
+	delete(s.entries, key)
+	return e.value, nil
+}
+
+func (s *MemoryStore) Del(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
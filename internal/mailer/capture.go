@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Capture is a backend keeping the mail instead of sending it, tests assert
+// on the captured messages and MAIL_BACKEND=capture uses it locally. With a
+// directory every message is also written there as an .eml file.
+type Capture struct {
+	mu       sync.Mutex
+	dir      string
+	messages []Message
+}
+
+func NewCapture(dir string) *Capture {
+	return &Capture{dir: dir}
+}
+
+func (c *Capture) Send(m *Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, *m)
+	if c.dir == "" {
+		return nil
+	}
+	now := time.Now()
+	raw, err := m.Bytes(from(), now)
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(c.dir, fmt.Sprintf("%s-%03d-%s.eml", now.Format("20060102T150405"), len(c.messages), m.Template))
+	if err := os.WriteFile(name, raw, 0o644); err != nil {
+		return err
+	}
+	log.Printf("captured %s mail to %v in %s", m.Template, m.To, name)
+	return nil
+}
+
+// Messages returns the captured messages, oldest first.
+func (c *Capture) Messages() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.messages...)
+}
+
+// Last returns the last captured message, it is nil when there is none.
+func (c *Capture) Last() *Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.messages) == 0 {
+		return nil
+	}
+	m := c.messages[len(c.messages)-1]
+	return &m
+}
+
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = nil
+}
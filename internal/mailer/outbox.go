@@ -0,0 +1,233 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+const (
+	outboxBatch = 100
+	// outboxLease is how long a claimed mail is left to its worker before
+	// another one may send it, a worker dying mid-send delays the mail by
+	// this much.
+	outboxLease = 5 * time.Minute
+	// lastErrorMax is the size of the lastError column.
+	lastErrorMax = 1024
+)
+
+// Outbox queues the mail in the database and sends it in the background, a
+// failed send is retried with an exponential backoff until maxAttempts is
+// reached and the mail is marked failed.
+type Outbox struct {
+	store       types.MailOutboxStore
+	backend     Backend
+	maxAttempts int
+	retry       time.Duration
+	interval    time.Duration
+	wake        chan struct{}
+	now         func() time.Time
+}
+
+func NewOutbox(store types.MailOutboxStore, backend Backend, maxAttempts int, retry time.Duration, interval time.Duration) *Outbox {
+	return &Outbox{
+		store:       store,
+		backend:     backend,
+		maxAttempts: maxAttempts,
+		retry:       retry,
+		interval:    interval,
+		wake:        make(chan struct{}, 1),
+		now:         time.Now,
+	}
+}
+
+// OutboxFromConfig sends through MAIL_BACKEND, checks every
+// MAIL_OUTBOX_INTERVAL seconds and retries MAIL_MAX_ATTEMPTS times starting
+// MAIL_RETRY seconds apart.
+func OutboxFromConfig(store types.MailOutboxStore) *Outbox {
+	return NewOutbox(
+		store,
+		BackendFromConfig(),
+		int(config.Envs.MailMaxAttempts),
+		time.Second*time.Duration(config.Envs.MailRetryInSeconds),
+		time.Second*time.Duration(config.Envs.MailOutboxIntervalInSeconds),
+	)
+}
+
+// Enqueue stores the message to be sent by the next run, the worker is woken
+// so it doesn't wait for its tick.
+func (o *Outbox) Enqueue(m *Message) error {
+	_, err := o.store.CreateOutboxMail(types.OutboxMail{
+		Template:      m.Template,
+		To:            m.To,
+		Subject:       m.Subject,
+		Text:          m.Text,
+		HTML:          m.HTML,
+		NextAttemptAt: o.now(),
+	})
+	if err != nil {
+		return err
+	}
+	o.Wake()
+	return nil
+}
+
+func (o *Outbox) Wake() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunOnce sends the due mail and returns how many were sent. Each mail is
+// claimed first so several servers can run the outbox.
+func (o *Outbox) RunOnce() int {
+	now := o.now()
+	sent := 0
+	for {
+		mails, err := o.store.GetDueOutboxMails(now, outboxBatch)
+		if err != nil {
+			log.Printf("failed to get the mail outbox: %v", err)
+			return sent
+		}
+		for _, mail := range mails {
+			claimed, err := o.store.ClaimOutboxMail(mail.ID, now, now.Add(outboxLease))
+			if err != nil {
+				log.Printf("failed to claim mail %d: %v", mail.ID, err)
+				return sent
+			}
+			if !claimed {
+				continue
+			}
+			if o.send(mail) {
+				sent++
+			}
+		}
+		if len(mails) < outboxBatch {
+			return sent
+		}
+	}
+}
+
+// send sends a claimed mail and records the outcome, it is false when the
+// mail failed.
+func (o *Outbox) send(mail types.OutboxMail) bool {
+	err := o.backend.Send(&Message{
+		Template: mail.Template,
+		To:       mail.To,
+		Subject:  mail.Subject,
+		Text:     mail.Text,
+		HTML:     mail.HTML,
+	})
+	now := o.now()
+	if err == nil {
+		if err := o.store.MarkOutboxMailSent(mail.ID, now); err != nil {
+			log.Printf("failed to mark mail %d as sent: %v", mail.ID, err)
+		}
+		return true
+	}
+
+	attempts := mail.Attempts + 1
+	var next *time.Time
+	if attempts < o.maxAttempts {
+		at := now.Add(o.Backoff(attempts))
+		next = &at
+		log.Printf("failed to send %s mail %d to %v, attempt %d, retrying at %v: %v", mail.Template, mail.ID, mail.To, attempts, at, err)
+	} else {
+		log.Printf("failed to send %s mail %d to %v, giving up after %d attempts: %v", mail.Template, mail.ID, mail.To, attempts, err)
+	}
+	lastError := err.Error()
+	if len(lastError) > lastErrorMax {
+		lastError = lastError[:lastErrorMax]
+	}
+	if err := o.store.MarkOutboxMailFailed(mail.ID, attempts, lastError, next, now); err != nil {
+		log.Printf("failed to record the failure of mail %d: %v", mail.ID, err)
+	}
+	return false
+}
+
+// Backoff is the wait before the next attempt once attempts have failed, it
+// doubles every attempt.
+func (o *Outbox) Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 16 {
+		attempts = 16
+	}
+	return o.retry << (attempts - 1)
+}
+
+// Run sends the due mail on every interval or when woken, until the context
+// is done.
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	o.RunOnce()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.RunOnce()
+		case <-o.wake:
+			o.RunOnce()
+		}
+	}
+}
+
+var (
+	outbox atomic.Pointer[Outbox]
+
+	// backendMu guards backend, it is set on the first mail sent concurrently
+	// by the handlers.
+	backendMu sync.Mutex
+	backend   Backend
+)
+
+// SetDefault sets the outbox the mail is queued in, the mail is sent right
+// away through the backend until the servers set one.
+func SetDefault(o *Outbox) {
+	outbox.Store(o)
+}
+
+// WakeOutbox runs the default outbox now instead of on its next tick.
+func WakeOutbox() {
+	if o := outbox.Load(); o != nil {
+		o.Wake()
+	}
+}
+
+// SetBackend sets the backend the mail is sent through without an outbox,
+// nil goes back to MAIL_BACKEND.
+func SetBackend(b Backend) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	backend = b
+}
+
+func defaultBackend() Backend {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	if backend == nil {
+		backend = BackendFromConfig()
+	}
+	return backend
+}
+
+// Send renders the template in the locale and queues it for the recipients.
+func Send(name string, locale string, to []string, data any) error {
+	m, err := Render(name, locale, to, data)
+	if err != nil {
+		return err
+	}
+	if o := outbox.Load(); o != nil {
+		return o.Enqueue(m)
+	}
+	return defaultBackend().Send(m)
+}
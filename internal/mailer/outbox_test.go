@@ -0,0 +1,145 @@
+package mailer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+type mockOutboxStore struct {
+	types.MailOutboxStore
+	mails []types.OutboxMail
+}
+
+func (m *mockOutboxStore) CreateOutboxMail(mail types.OutboxMail) (int64, error) {
+	mail.ID = len(m.mails) + 1
+	m.mails = append(m.mails, mail)
+	return int64(mail.ID), nil
+}
+func (m *mockOutboxStore) GetDueOutboxMails(now time.Time, limit int) ([]types.OutboxMail, error) {
+	var due []types.OutboxMail
+	for _, mail := range m.mails {
+		if mail.SentAt == nil && mail.FailedAt == nil && !mail.NextAttemptAt.After(now) {
+			due = append(due, mail)
+		}
+	}
+	return due, nil
+}
+func (m *mockOutboxStore) ClaimOutboxMail(id int, now time.Time, until time.Time) (bool, error) {
+	mail := &m.mails[id-1]
+	if mail.NextAttemptAt.After(now) {
+		return false, nil
+	}
+	mail.NextAttemptAt = until
+	return true, nil
+}
+func (m *mockOutboxStore) MarkOutboxMailSent(id int, at time.Time) error {
+	m.mails[id-1].SentAt = &at
+	return nil
+}
+func (m *mockOutboxStore) MarkOutboxMailFailed(id int, attempts int, lastError string, nextAttemptAt *time.Time, at time.Time) error {
+	mail := &m.mails[id-1]
+	mail.Attempts = attempts
+	mail.LastError = lastError
+	if nextAttemptAt == nil {
+		mail.FailedAt = &at
+	} else {
+		mail.NextAttemptAt = *nextAttemptAt
+	}
+	return nil
+}
+
+// flakyBackend fails the first sends then hands over to the capture.
+type flakyBackend struct {
+	failures int
+	capture  *Capture
+}
+
+func (b *flakyBackend) Send(m *Message) error {
+	if b.failures > 0 {
+		b.failures--
+		return fmt.Errorf("connection refused")
+	}
+	return b.capture.Send(m)
+}
+
+func TestOutbox(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	newOutbox := func(failures int) (*Outbox, *mockOutboxStore, *Capture) {
+		store := &mockOutboxStore{}
+		capture := NewCapture("")
+		o := NewOutbox(store, &flakyBackend{failures: failures, capture: capture}, 3, time.Minute, time.Minute)
+		o.now = func() time.Time { return now }
+		return o, store, capture
+	}
+	message := &Message{Template: TemplateShipped, To: []string{"ayu@tj-jeans.id"}, Subject: "Shipped", Text: "text", HTML: "<p>html</p>"}
+
+	t.Run("should send the queued mail", func(t *testing.T) {
+		o, store, capture := newOutbox(0)
+		if err := o.Enqueue(message); err != nil {
+			t.Fatal(err)
+		}
+		if sent := o.RunOnce(); sent != 1 {
+			t.Errorf("expected 1 mail to be sent, got %d", sent)
+		}
+		if len(capture.Messages()) != 1 || capture.Last().Subject != "Shipped" {
+			t.Errorf("unexpected captured mail %+v", capture.Messages())
+		}
+		if store.mails[0].SentAt == nil {
+			t.Error("expected the mail to be marked as sent")
+		}
+		if sent := o.RunOnce(); sent != 0 {
+			t.Errorf("expected the sent mail not to be sent again, got %d", sent)
+		}
+	})
+	t.Run("should retry with a backoff", func(t *testing.T) {
+		o, store, capture := newOutbox(2)
+		o.Enqueue(message)
+
+		o.RunOnce()
+		if mail := store.mails[0]; mail.Attempts != 1 || !mail.NextAttemptAt.Equal(now.Add(time.Minute)) || mail.LastError != "connection refused" {
+			t.Errorf("expected the first retry a minute later, got %+v", mail)
+		}
+		if sent := o.RunOnce(); sent != 0 {
+			t.Errorf("expected the mail to wait for its retry, got %d sent", sent)
+		}
+
+		now = now.Add(time.Minute)
+		o.RunOnce()
+		if mail := store.mails[0]; mail.Attempts != 2 || !mail.NextAttemptAt.Equal(now.Add(2*time.Minute)) {
+			t.Errorf("expected the second retry two minutes later, got %+v", mail)
+		}
+
+		now = now.Add(2 * time.Minute)
+		if sent := o.RunOnce(); sent != 1 || len(capture.Messages()) != 1 {
+			t.Errorf("expected the third attempt to be sent, got %d", sent)
+		}
+	})
+	t.Run("should give up after the max attempts", func(t *testing.T) {
+		o, store, capture := newOutbox(5)
+		o.Enqueue(message)
+		for i := 0; i < 5; i++ {
+			now = now.Add(time.Hour)
+			o.RunOnce()
+		}
+		if mail := store.mails[0]; mail.Attempts != 3 || mail.FailedAt == nil {
+			t.Errorf("expected the mail to fail after 3 attempts, got %+v", mail)
+		}
+		if len(capture.Messages()) != 0 {
+			t.Errorf("expected no mail to be sent, got %+v", capture.Messages())
+		}
+	})
+	t.Run("should queue the mail sent while an outbox is set", func(t *testing.T) {
+		o, store, _ := newOutbox(0)
+		SetDefault(o)
+		defer SetDefault(nil)
+		if err := SendBackInStockEmail(LocaleEN, "ayu@tj-jeans.id", "Ayu", "Slim Jeans", "http://localhost:8080/products?product_id=3"); err != nil {
+			t.Fatal(err)
+		}
+		if len(store.mails) != 1 || store.mails[0].Template != TemplateBackInStock || store.mails[0].SentAt != nil {
+			t.Errorf("expected the mail to be queued, got %+v", store.mails)
+		}
+	})
+}
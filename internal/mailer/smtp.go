@@ -4,18 +4,13 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"net/mail"
 	"net/smtp"
+	"time"
 
 	"github.com/fayleenpc/tj-jeans/internal/config"
 )
 
-const (
-	smtpServer = "sandbox.smtp.mailtrap.io"
-	smtpPort   = "2525"
-)
-
-var EmailVerificationTokens = map[string]string{} // Temporary storage for tokens
-
 func GenerateToken() (string, error) {
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
@@ -24,16 +19,122 @@ func GenerateToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(bytes), nil
 }
 
-func SendVerificationEmail(user string, token string) error {
-	from := config.Envs.SMTP_User
-	subject := "Email Verification From TJ Jeans"
-	body := fmt.Sprintf("Please verify your email by clicking the following link: %s/verify?token=%s", config.Envs.PublicHost, token)
+// Backend delivers a rendered message.
+type Backend interface {
+	Send(m *Message) error
+}
+
+// SMTP is a backend sending through an SMTP server with PLAIN auth.
+type SMTP struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	From     string
+}
+
+func (s *SMTP) Send(m *Message) error {
+	msg, err := m.Bytes(s.From, time.Now())
+	if err != nil {
+		return err
+	}
+	// the envelope sender is the address of From
+	sender := s.User
+	if addr, err := mail.ParseAddress(s.From); err == nil {
+		sender = addr.Address
+	}
+	auth := smtp.PlainAuth("", s.User, s.Password, s.Host)
+	return smtp.SendMail(s.Host+":"+s.Port, auth, sender, m.To, msg)
+}
+
+// BackendFromConfig is the SMTP server of SMTP_HOST and SMTP_PORT when
+// MAIL_BACKEND is smtp, or a capture writing to MAIL_CAPTURE_DIR. Nothing is
+// mailed out until both are set.
+func BackendFromConfig() Backend {
+	if config.Envs.MailBackend != "smtp" || config.Envs.SMTPHost == "" {
+		return NewCapture(config.Envs.MailCaptureDir)
+	}
+	return &SMTP{
+		Host:     config.Envs.SMTPHost,
+		Port:     config.Envs.SMTPPort,
+		User:     config.Envs.SMTP_User,
+		Password: config.Envs.SMTP_Password,
+		From:     from(),
+	}
+}
+
+// from is SMTP_FROM, the SMTP user when it isn't set.
+func from() string {
+	if config.Envs.SMTPFrom != "" {
+		return config.Envs.SMTPFrom
+	}
+	return config.Envs.SMTP_User
+}
+
+// OrderLine is a line of an order in the order mail.
+type OrderLine struct {
+	Name     string
+	Quantity int
+	Price    float64
+}
+
+// OrderMail is what the order confirmation, payment received and shipped
+// mail show, the lines are only listed by the confirmation.
+type OrderMail struct {
+	ID        int
+	FirstName string
+	Currency  string
+	Total     float64
+	Address   string
+	Lines     []OrderLine
+}
+
+func SendVerificationEmail(locale string, user string, token string) error {
+	return Send(TemplateVerification, locale, []string{user}, map[string]any{
+		"Link": fmt.Sprintf("%s:%s/api/v1/verify?token=%s", config.Envs.PublicHost, config.Envs.Port, token),
+	})
+}
 
-	msg := []byte("To: " + user + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"\r\n" +
-		body)
+func SendPasswordResetEmail(locale string, user string, token string) error {
+	return Send(TemplatePasswordReset, locale, []string{user}, map[string]any{
+		"Link":             fmt.Sprintf("%s:%s/service?reset_token=%s", config.Envs.PublicHost, config.Envs.PortWeb, token),
+		"ExpiresInMinutes": config.Envs.PasswordResetExpirationInSeconds / 60,
+	})
+}
+
+func SendAccountUnlockEmail(locale string, user string, token string) error {
+	return Send(TemplateAccountUnlock, locale, []string{user}, map[string]any{
+		"Link": fmt.Sprintf("%s:%s/api/v1/login/unlock?token=%s", config.Envs.PublicHost, config.Envs.Port, token),
+	})
+}
+
+func SendLowStockEmail(locale string, to []string, product string, qty int, threshold int) error {
+	return Send(TemplateLowStock, locale, to, map[string]any{
+		"Product":   product,
+		"Quantity":  qty,
+		"Threshold": threshold,
+		"Link":      fmt.Sprintf("%s:%s/admin/inventory", config.Envs.PublicHost, config.Envs.PortWeb),
+	})
+}
+
+func SendBackInStockEmail(locale string, user string, firstName string, product string, link string) error {
+	return Send(TemplateBackInStock, locale, []string{user}, map[string]any{
+		"FirstName": firstName,
+		"Product":   product,
+		"Link":      link,
+	})
+}
 
-	auth := smtp.PlainAuth("", config.Envs.SMTP_User, config.Envs.SMTP_Password, smtpServer)
-	return smtp.SendMail(smtpServer+":"+smtpPort, auth, from, []string{user}, msg)
+// SendOrderEmail sends the order mail of the template, one of
+// TemplateOrderConfirmation, TemplatePaymentReceived or TemplateShipped.
+func SendOrderEmail(name string, locale string, user string, order OrderMail) error {
+	return Send(name, locale, []string{user}, map[string]any{
+		"ID":        order.ID,
+		"FirstName": order.FirstName,
+		"Currency":  order.Currency,
+		"Total":     order.Total,
+		"Address":   order.Address,
+		"Lines":     order.Lines,
+		"Link":      fmt.Sprintf("%s:%s/products", config.Envs.PublicHost, config.Envs.PortWeb),
+	})
 }
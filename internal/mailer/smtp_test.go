@@ -1,6 +1,10 @@
 package mailer
 
 import (
+	"fmt"
+	"html"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/fayleenpc/tj-jeans/internal/config"
@@ -28,9 +32,52 @@ func TestSendVerificationEmail(t *testing.T) {
 		t.Error("expected token to be not empty")
 	}
 
-	if err := SendVerificationEmail(config.Envs.SMTP_User, token); err != nil {
+	capture := NewCapture("")
+	SetBackend(capture)
+	defer SetBackend(nil)
+
+	if err := SendVerificationEmail(LocaleEN, "ayu@tj-jeans.id", token); err != nil {
 		t.Errorf("error send verification email, %v", err)
 	}
 
+	m := capture.Last()
+	if m == nil {
+		t.Fatal("expected the verification email to be captured")
+	}
+	if m.Template != TemplateVerification || len(m.To) != 1 || m.To[0] != "ayu@tj-jeans.id" {
+		t.Errorf("unexpected message %+v", m)
+	}
+	link := fmt.Sprintf("%s:%s/api/v1/verify?token=%s", config.Envs.PublicHost, config.Envs.Port, token)
+	if !strings.Contains(m.Text, link) || !strings.Contains(m.HTML, html.EscapeString(link)) {
+		t.Errorf("expected the verification link %s in both parts, got %q and %q", link, m.Text, m.HTML)
+	}
+
 	t.Logf("verification code : %v", token)
 }
+
+func TestSendConcurrently(t *testing.T) {
+	mailBackend := config.Envs.MailBackend
+	config.Envs.MailBackend = "capture"
+	SetBackend(nil)
+	defer func() {
+		config.Envs.MailBackend = mailBackend
+		SetBackend(nil)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := SendAccountUnlockEmail(LocaleEN, "ayu@tj-jeans.id", "token"); err != nil {
+				t.Errorf("error send unlock email, %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the backend of MAIL_BACKEND is created once for every sender
+	if n := len(defaultBackend().(*Capture).Messages()); n != 10 {
+		t.Errorf("expected 10 captured mails, got %d", n)
+	}
+}
@@ -0,0 +1,144 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+)
+
+const (
+	LocaleID = "id"
+	LocaleEN = "en"
+)
+
+const (
+	TemplateVerification      = "verification"
+	TemplatePasswordReset     = "password_reset"
+	TemplateAccountUnlock     = "account_unlock"
+	TemplateLowStock          = "low_stock"
+	TemplateBackInStock       = "back_in_stock"
+	TemplateOrderConfirmation = "order_confirmation"
+	TemplatePaymentReceived   = "payment_received"
+	TemplateShipped           = "shipped"
+)
+
+var locales = []string{LocaleID, LocaleEN}
+
+var templateNames = []string{
+	TemplateVerification,
+	TemplatePasswordReset,
+	TemplateAccountUnlock,
+	TemplateLowStock,
+	TemplateBackInStock,
+	TemplateOrderConfirmation,
+	TemplatePaymentReceived,
+	TemplateShipped,
+}
+
+// Every template is a <name>.txt defining the "subject" and the "text" and a
+// <name>.html defining the "content" of the layout.html of its locale.
+//
+//go:embed templates
+var templateFS embed.FS
+
+type mailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var templates = parseTemplates()
+
+func parseTemplates() map[string]map[string]mailTemplate {
+	parsed := make(map[string]map[string]mailTemplate, len(locales))
+	for _, locale := range locales {
+		funcs := map[string]any{"money": moneyFunc(locale)}
+		layout := htmltemplate.Must(htmltemplate.New("layout.html").Funcs(funcs).ParseFS(templateFS, "templates/"+locale+"/layout.html"))
+		parsed[locale] = make(map[string]mailTemplate, len(templateNames))
+		for _, name := range templateNames {
+			html := htmltemplate.Must(htmltemplate.Must(layout.Clone()).ParseFS(templateFS, "templates/"+locale+"/"+name+".html"))
+			text := texttemplate.Must(texttemplate.New(name+".txt").Funcs(funcs).ParseFS(templateFS, "templates/"+locale+"/"+name+".txt"))
+			parsed[locale][name] = mailTemplate{text: text, html: html}
+		}
+	}
+	return parsed
+}
+
+// Locale picks the locale of the mail from an Accept-Language header, the
+// first supported language wins and MAIL_LOCALE is the fallback.
+func Locale(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag = strings.ToLower(strings.TrimSpace(strings.SplitN(tag, ";", 2)[0]))
+		lang := strings.SplitN(tag, "-", 2)[0]
+		if _, ok := templates[lang]; ok {
+			return lang
+		}
+	}
+	if _, ok := templates[config.Envs.MailLocale]; ok {
+		return config.Envs.MailLocale
+	}
+	return LocaleID
+}
+
+// Render renders the template in the locale for the recipients, an unknown
+// locale is rendered in the default one.
+func Render(name string, locale string, to []string, data any) (*Message, error) {
+	t, ok := templates[Locale(locale)][name]
+	if !ok {
+		return nil, fmt.Errorf("unknown mail template %q", name)
+	}
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := t.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, err
+	}
+	if err := t.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return nil, err
+	}
+	return &Message{
+		Template: name,
+		To:       to,
+		Subject:  strings.TrimSpace(subject.String()),
+		Text:     strings.TrimSpace(text.String()) + "\n",
+		HTML:     html.String(),
+	}, nil
+}
+
+func moneyFunc(locale string) func(currency string, amount float64) string {
+	return func(currency string, amount float64) string {
+		return FormatMoney(locale, currency, amount)
+	}
+}
+
+// FormatMoney formats an amount with the thousands separator of the locale,
+// e.g. IDR 150.000 in Indonesian and IDR 150,000 in English.
+func FormatMoney(locale string, currency string, amount float64) string {
+	separator := "."
+	if locale == LocaleEN {
+		separator = ","
+	}
+	if currency == "" {
+		currency = "IDR"
+	}
+	digits := strconv.FormatInt(int64(math.Round(math.Abs(amount))), 10)
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteString(separator)
+		}
+		grouped.WriteRune(d)
+	}
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+	return currency + " " + sign + grouped.String()
+}
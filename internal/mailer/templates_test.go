@@ -0,0 +1,132 @@
+package mailer
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	data := map[string]any{
+		"Link":             "http://localhost:8080/x?token=abc",
+		"ExpiresInMinutes": 30,
+		"Product":          "Slim Jeans",
+		"Quantity":         2,
+		"Threshold":        5,
+		"FirstName":        "Ayu",
+		"ID":               42,
+		"Currency":         "IDR",
+		"Total":            450000.0,
+		"Address":          "Jl. Malioboro 1, Yogyakarta",
+		"Lines":            []OrderLine{{Name: "Slim Jeans", Quantity: 3, Price: 450000}},
+	}
+	for _, locale := range locales {
+		for _, name := range templateNames {
+			m, err := Render(name, locale, []string{"ayu@tj-jeans.id"}, data)
+			if err != nil {
+				t.Errorf("%s/%s: %v", locale, name, err)
+				continue
+			}
+			if m.Subject == "" || m.Text == "" || m.HTML == "" {
+				t.Errorf("%s/%s: expected a subject, a text and an HTML part, got %+v", locale, name, m)
+			}
+			if strings.Contains(m.Text+m.HTML, "<no value>") {
+				t.Errorf("%s/%s: missing data in %q", locale, name, m.Text)
+			}
+			if !strings.Contains(m.HTML, `<html lang="`+locale+`">`) {
+				t.Errorf("%s/%s: expected the layout of the locale", locale, name)
+			}
+		}
+	}
+
+	id, _ := Render(TemplateOrderConfirmation, LocaleID, nil, data)
+	en, _ := Render(TemplateOrderConfirmation, LocaleEN, nil, data)
+	if !strings.Contains(id.Text, "IDR 450.000") || !strings.Contains(en.Text, "IDR 450,000") {
+		t.Errorf("expected the total formatted by locale, got %q and %q", id.Text, en.Text)
+	}
+	if id.Subject == en.Subject {
+		t.Errorf("expected the subject to be translated, got %q", id.Subject)
+	}
+
+	if _, err := Render("unknown", LocaleEN, nil, data); err == nil {
+		t.Error("expected an unknown template to fail")
+	}
+}
+
+func TestLocale(t *testing.T) {
+	tests := map[string]string{
+		"en-US,en;q=0.9":     LocaleEN,
+		"id-ID,id;q=0.9":     LocaleID,
+		"fr-FR, en;q=0.5":    LocaleEN,
+		"EN":                 LocaleEN,
+		"":                   LocaleID,
+		"de-DE,fr;q=0.8":     LocaleID,
+		"ms-MY, id-ID;q=0.7": LocaleID,
+	}
+	for header, want := range tests {
+		if got := Locale(header); got != want {
+			t.Errorf("Locale(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	m := &Message{
+		To:      []string{"ayu@tj-jeans.id", "budi@tj-jeans.id"},
+		Subject: "Pesanan TJ Jeans Anda #42",
+		Text:    "Halo Ayu,\nTotal: IDR 450.000\n",
+		HTML:    `<p style="margin:0">Halo Ayu, <a href="http://localhost/x?a=1&amp;b=2">lihat</a></p>`,
+	}
+	raw, err := m.Bytes("TJ Jeans <no-reply@tj-jeans.id>", time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != m.Subject {
+		t.Errorf("expected subject %q, got %q (%v)", m.Subject, subject, err)
+	}
+	if to := msg.Header.Get("To"); to != "ayu@tj-jeans.id, budi@tj-jeans.id" {
+		t.Errorf("unexpected recipients %q", to)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %q (%v)", mediaType, err)
+	}
+
+	// multipart decodes the quoted-printable parts
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(part)
+		parts = append(parts, part.Header.Get("Content-Type")+"|"+strings.ReplaceAll(string(body), "\r\n", "\n"))
+	}
+	want := []string{
+		"text/plain; charset=UTF-8|" + m.Text,
+		"text/html; charset=UTF-8|" + m.HTML,
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("expected %d parts, got %d", len(want), len(parts))
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("part %d: expected %q, got %q", i, want[i], parts[i])
+		}
+	}
+}
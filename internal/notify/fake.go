@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Fake logs the rendered notifications instead of sending them, it is the
+// channel used locally and by the tests.
+type Fake struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Notify(ctx context.Context, n Notification) error {
+	text, err := Render(n)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, n)
+	log.Printf("notification %s to %s: %s", n.Template, n.To, text)
+	return nil
+}
+
+// Sent returns the notifications, oldest first.
+func (f *Fake) Sent() []Notification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Notification(nil), f.sent...)
+}
@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+)
+
+const (
+	LocaleID = "id"
+	LocaleEN = "en"
+)
+
+const (
+	TemplateOrderCreated = "order_created"
+	TemplateOrderPaid    = "order_paid"
+	TemplateOrderShipped = "order_shipped"
+)
+
+// templates are the texts of the notifications by locale, {{1}}, {{2}}...
+// are filled with the params in order. The WhatsApp channel sends the
+// template of the same name approved in the business account instead, it
+// must have the same params.
+var templates = map[string]map[string]string{
+	TemplateOrderCreated: {
+		LocaleID: "Halo {{1}}, terima kasih telah berbelanja di {{4}}. Pesanan #{{2}} sebesar {{3}} sudah kami terima dan menunggu pembayaran. Pertanyaan? Hubungi kami di {{5}}.",
+		LocaleEN: "Hi {{1}}, thank you for shopping at {{4}}. We received your order #{{2}} of {{3}} and are waiting for the payment. Questions? Contact us at {{5}}.",
+	},
+	TemplateOrderPaid: {
+		LocaleID: "Halo {{1}}, pembayaran {{3}} untuk pesanan #{{2}} sudah kami terima. Pesanan sedang disiapkan oleh {{4}}. Pertanyaan? Hubungi kami di {{5}}.",
+		LocaleEN: "Hi {{1}}, we received the payment of {{3}} for order #{{2}}. {{4}} is preparing it. Questions? Contact us at {{5}}.",
+	},
+	TemplateOrderShipped: {
+		LocaleID: "Halo {{1}}, pesanan #{{2}} ({{3}}) dari {{4}} sudah dikirim dan dalam perjalanan. Pertanyaan? Hubungi kami di {{5}}.",
+		LocaleEN: "Hi {{1}}, your order #{{2}} ({{3}}) from {{4}} has shipped and is on its way. Questions? Contact us at {{5}}.",
+	},
+}
+
+// Notification is a templated message to a phone number.
+type Notification struct {
+	To       string
+	Template string
+	Locale   string
+	Params   []string
+}
+
+// Notifier sends notifications over a channel.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Order is the notification of an order event to the phone of the order,
+// the merchant contact comes from MERCHANT_NAME and MERCHANT_PHONE.
+func Order(template string, locale string, phone string, firstName string, orderID int, total string) Notification {
+	return Notification{
+		To:       phone,
+		Template: template,
+		Locale:   locale,
+		Params:   []string{firstName, strconv.Itoa(orderID), total, config.Envs.MerchantName, config.Envs.MerchantPhone},
+	}
+}
+
+// Locale picks the locale of the notification from an Accept-Language
+// header, NOTIFY_LOCALE is the fallback.
+func Locale(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag = strings.ToLower(strings.TrimSpace(strings.SplitN(tag, ";", 2)[0]))
+		switch lang := strings.SplitN(tag, "-", 2)[0]; lang {
+		case LocaleID, LocaleEN:
+			return lang
+		}
+	}
+	switch config.Envs.NotifyLocale {
+	case LocaleID, LocaleEN:
+		return config.Envs.NotifyLocale
+	}
+	return LocaleID
+}
+
+// Render fills the text of the template in the locale of the notification.
+func Render(n Notification) (string, error) {
+	texts, ok := templates[n.Template]
+	if !ok {
+		return "", fmt.Errorf("unknown notification template %q", n.Template)
+	}
+	text, ok := texts[n.Locale]
+	if !ok {
+		text = texts[Locale("")]
+	}
+	for i, param := range n.Params {
+		text = strings.ReplaceAll(text, "{{"+strconv.Itoa(i+1)+"}}", param)
+	}
+	return text, nil
+}
+
+// NormalizePhone turns an Indonesian phone number into the international
+// digits the channels expect, e.g. 0895-0520-8391 into 6289505208391.
+func NormalizePhone(phone string) (string, error) {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	normalized := digits.String()
+	switch {
+	case strings.HasPrefix(normalized, "0"):
+		normalized = "62" + strings.TrimLeft(normalized, "0")
+	case strings.HasPrefix(normalized, "8"):
+		normalized = "62" + normalized
+	}
+	if len(normalized) < 10 || len(normalized) > 15 {
+		return "", fmt.Errorf("invalid phone number %q", phone)
+	}
+	return normalized, nil
+}
+
+var notifier Notifier
+
+// SetDefault sets the notifier used by Send, nothing is sent until the
+// servers set one.
+func SetDefault(n Notifier) {
+	notifier = n
+}
+
+// Send sends the notification with the default notifier once its phone
+// number is normalized.
+func Send(ctx context.Context, n Notification) error {
+	if notifier == nil {
+		return nil
+	}
+	to, err := NormalizePhone(n.To)
+	if err != nil {
+		return err
+	}
+	n.To = to
+	if _, ok := templates[n.Template][n.Locale]; !ok {
+		n.Locale = Locale("")
+	}
+	return notifier.Notify(ctx, n)
+}
+
+// FromConfig is the notifier of NOTIFY_CHANNEL, whatsapp, sms or fake.
+func FromConfig() Notifier {
+	switch config.Envs.NotifyChannel {
+	case "whatsapp":
+		return NewWhatsApp(config.Envs.WhatsAppAPIURL, config.Envs.WhatsAppPhoneNumberID, config.Envs.WhatsAppAccessToken)
+	case "sms":
+		return NewSMS(config.Envs.SMSAPIURL, config.Envs.SMSAPIKey, config.Envs.SMSSender)
+	default:
+		return NewFake()
+	}
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	for phone, expected := range map[string]string{
+		"6289505208391":     "6289505208391",
+		"0895-0520-8391":    "6289505208391",
+		"89505208391":       "6289505208391",
+		"+62 895 0520 8391": "6289505208391",
+	} {
+		normalized, err := NormalizePhone(phone)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", phone, err)
+		}
+		if normalized != expected {
+			t.Errorf("expected %q to normalize to %q, got %q", phone, expected, normalized)
+		}
+	}
+	for _, phone := range []string{"", "0812", "not a phone", "62895052083910000"} {
+		if _, err := NormalizePhone(phone); err == nil {
+			t.Errorf("expected %q to be invalid", phone)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	n := Order(TemplateOrderCreated, LocaleID, "6289505208391", "Budi", 42, "IDR 150.000")
+	text, err := Render(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "Halo Budi") || !strings.Contains(text, "#42 sebesar IDR 150.000") {
+		t.Errorf("unexpected text %q", text)
+	}
+
+	n.Locale = LocaleEN
+	if text, _ := Render(n); !strings.Contains(text, "Hi Budi") {
+		t.Errorf("unexpected text %q", text)
+	}
+
+	n.Locale = "fr"
+	if text, _ := Render(n); text == "" || strings.Contains(text, "{{") {
+		t.Errorf("expected the fallback locale, got %q", text)
+	}
+
+	if _, err := Render(Notification{Template: "unknown"}); err == nil {
+		t.Error("expected an unknown template to fail")
+	}
+}
+
+func TestWhatsApp(t *testing.T) {
+	var auth string
+	var msg whatsAppMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/123/messages" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&msg)
+		if msg.To == "6280000000000" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"Template name does not exist","code":132001}}`))
+			return
+		}
+		w.Write([]byte(`{"messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	w := NewWhatsApp(server.URL+"/", "123", "secret")
+	n := Order(TemplateOrderShipped, LocaleEN, "6289505208391", "Budi", 42, "IDR 150,000")
+	if err := w.Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("unexpected authorization %q", auth)
+	}
+	if msg.To != n.To || msg.Type != "template" || msg.Template.Name != TemplateOrderShipped || msg.Template.Language.Code != LocaleEN {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	if len(msg.Template.Components) != 1 || len(msg.Template.Components[0].Parameters) != len(n.Params) || msg.Template.Components[0].Parameters[0].Text != "Budi" {
+		t.Errorf("unexpected components %+v", msg.Template.Components)
+	}
+
+	n.To = "6280000000000"
+	if err := w.Notify(context.Background(), n); err == nil || !strings.Contains(err.Error(), "Template name does not exist") {
+		t.Errorf("expected the API error, got %v", err)
+	}
+}
+
+func TestSMS(t *testing.T) {
+	var auth string
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&payload)
+		if payload["to"] == "6280000000000" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	s := NewSMS(server.URL, "key", "TJJeans")
+	n := Order(TemplateOrderPaid, LocaleID, "6289505208391", "Budi", 42, "IDR 150.000")
+	if err := s.Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer key" || payload["from"] != "TJJeans" || payload["to"] != n.To || !strings.Contains(payload["text"], "pesanan #42") {
+		t.Errorf("unexpected request %q %+v", auth, payload)
+	}
+
+	n.To = "6280000000000"
+	if err := s.Notify(context.Background(), n); err == nil {
+		t.Error("expected an unavailable gateway to fail")
+	}
+}
+
+func TestSend(t *testing.T) {
+	defer SetDefault(nil)
+	if err := Send(context.Background(), Notification{To: "invalid"}); err != nil {
+		t.Errorf("expected nothing to be sent without a notifier, got %v", err)
+	}
+
+	fake := NewFake()
+	SetDefault(fake)
+	if err := Send(context.Background(), Order(TemplateOrderCreated, "fr", "0895-0520-8391", "Budi", 42, "IDR 150.000")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Send(context.Background(), Order(TemplateOrderCreated, LocaleID, "123", "Budi", 42, "IDR 150.000")); err == nil {
+		t.Error("expected an invalid phone to fail")
+	}
+	sent := fake.Sent()
+	if len(sent) != 1 || sent[0].To != "6289505208391" || sent[0].Locale != Locale("") {
+		t.Errorf("unexpected notifications %+v", sent)
+	}
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SMS sends the rendered notifications through an HTTP SMS gateway taking
+// {"from", "to", "text"} as JSON with a bearer API key.
+type SMS struct {
+	client *http.Client
+	apiURL string
+	apiKey string
+	sender string
+}
+
+func NewSMS(apiURL string, apiKey string, sender string) *SMS {
+	return &SMS{client: &http.Client{Timeout: 10 * time.Second}, apiURL: apiURL, apiKey: apiKey, sender: sender}
+}
+
+func (s *SMS) Notify(ctx context.Context, n Notification) error {
+	text, err := Render(n)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(map[string]string{"from": s.sender, "to": n.To, "text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("sms: unexpected status %d", res.StatusCode)
+	}
+	return nil
+}
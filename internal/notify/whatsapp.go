@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WhatsApp sends the notifications as template messages of the WhatsApp
+// Business Cloud API, the templates must be approved in the business account
+// with the name and the params of the notification templates.
+type WhatsApp struct {
+	client        *http.Client
+	apiURL        string
+	phoneNumberID string
+	accessToken   string
+}
+
+func NewWhatsApp(apiURL string, phoneNumberID string, accessToken string) *WhatsApp {
+	return &WhatsApp{
+		client:        &http.Client{Timeout: 10 * time.Second},
+		apiURL:        strings.TrimRight(apiURL, "/"),
+		phoneNumberID: phoneNumberID,
+		accessToken:   accessToken,
+	}
+}
+
+type whatsAppParameter struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type whatsAppComponent struct {
+	Type       string              `json:"type"`
+	Parameters []whatsAppParameter `json:"parameters"`
+}
+
+type whatsAppMessage struct {
+	MessagingProduct string `json:"messaging_product"`
+	To               string `json:"to"`
+	Type             string `json:"type"`
+	Template         struct {
+		Name     string `json:"name"`
+		Language struct {
+			Code string `json:"code"`
+		} `json:"language"`
+		Components []whatsAppComponent `json:"components"`
+	} `json:"template"`
+}
+
+type whatsAppError struct {
+	Error struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+	} `json:"error"`
+}
+
+func (w *WhatsApp) Notify(ctx context.Context, n Notification) error {
+	msg := whatsAppMessage{MessagingProduct: "whatsapp", To: n.To, Type: "template"}
+	msg.Template.Name = n.Template
+	msg.Template.Language.Code = n.Locale
+	body := whatsAppComponent{Type: "body", Parameters: []whatsAppParameter{}}
+	for _, param := range n.Params {
+		body.Parameters = append(body.Parameters, whatsAppParameter{Type: "text", Text: param})
+	}
+	msg.Template.Components = []whatsAppComponent{body}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.apiURL+"/"+w.phoneNumberID+"/messages", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+w.accessToken)
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		var apiErr whatsAppError
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("whatsapp: %s (code %d)", apiErr.Error.Message, apiErr.Error.Code)
+		}
+		return fmt.Errorf("whatsapp: unexpected status %d", res.StatusCode)
+	}
+	return nil
+}
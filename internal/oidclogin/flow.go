@@ -0,0 +1,91 @@
+package oidclogin
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// Flow is a login in progress, it's handed to the client as a signed token
+// so the API keeps no state between the redirect and the callback.
+type Flow struct {
+	Provider string
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+func NewFlow(provider string) (Flow, error) {
+	state, err := randomString()
+	if err != nil {
+		return Flow{}, err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return Flow{}, err
+	}
+	return Flow{Provider: provider, State: state, Nonce: nonce, Verifier: oauth2.GenerateVerifier()}, nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// flowKey is kept apart from the other token keys so a flow token can only
+// be used to finish a login.
+func flowKey() []byte {
+	return []byte(config.Envs.JWTSecret + ":oidc-flow")
+}
+
+// Token signs the flow, it expires after OIDC_STATE_EXP.
+func (f Flow) Token(now time.Time) (string, error) {
+	expiration := time.Second * time.Duration(config.Envs.OIDCStateExpirationInSeconds)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"provider":  f.Provider,
+		"state":     f.State,
+		"nonce":     f.Nonce,
+		"verifier":  f.Verifier,
+		"purpose":   "oidc_flow",
+		"expiredAt": now.Add(expiration).Unix(),
+	})
+	return token.SignedString(flowKey())
+}
+
+// ParseFlowToken returns the flow of a token that is not expired at now.
+func ParseFlowToken(tokenString string, now time.Time) (Flow, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return flowKey(), nil
+	})
+	if err != nil || !token.Valid {
+		return Flow{}, fmt.Errorf("invalid login flow")
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if purpose, _ := claims["purpose"].(string); purpose != "oidc_flow" {
+		return Flow{}, fmt.Errorf("invalid login flow")
+	}
+	expiredAt, ok := claims["expiredAt"].(float64)
+	if !ok || !now.Before(time.Unix(int64(expiredAt), 0)) {
+		return Flow{}, fmt.Errorf("expired login flow")
+	}
+	flow := Flow{}
+	flow.Provider, _ = claims["provider"].(string)
+	flow.State, _ = claims["state"].(string)
+	flow.Nonce, _ = claims["nonce"].(string)
+	flow.Verifier, _ = claims["verifier"].(string)
+	if flow.Provider == "" || flow.State == "" || flow.Nonce == "" || flow.Verifier == "" {
+		return Flow{}, fmt.Errorf("invalid login flow")
+	}
+	return flow, nil
+}
@@ -0,0 +1,28 @@
+package oidclogin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlowToken(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	flow, err := NewFlow("google")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := flow.Token(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed, err := ParseFlowToken(token, now.Add(time.Minute)); err != nil || parsed != flow {
+		t.Errorf("expected %+v, got %+v %v", flow, parsed, err)
+	}
+	if _, err := ParseFlowToken(token, now.Add(time.Hour)); err == nil {
+		t.Error("expected expired flow to fail")
+	}
+	if _, err := ParseFlowToken(token[:len(token)-2], now); err == nil {
+		t.Error("expected tampered flow to fail")
+	}
+}
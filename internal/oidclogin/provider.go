@@ -0,0 +1,133 @@
+package oidclogin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"golang.org/x/oauth2"
+)
+
+var ErrUnknownProvider = fmt.Errorf("unknown login provider")
+
+// Claims are the profile fields read from a verified ID token.
+type Claims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	PhoneNumber   string `json:"phone_number"`
+}
+
+// Provider is a discovered OpenID Connect issuer.
+type Provider struct {
+	Name     string
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// AuthCodeURL returns where to send the user to login at the provider, the
+// request is bound to the flow by its state, nonce and PKCE challenge.
+func (p *Provider) AuthCodeURL(flow Flow) string {
+	return p.oauth2.AuthCodeURL(flow.State, oidc.Nonce(flow.Nonce), oauth2.S256ChallengeOption(flow.Verifier))
+}
+
+// Exchange redeems the authorization code and verifies the ID token issued
+// for the flow.
+func (p *Provider) Exchange(ctx context.Context, code string, flow Flow) (*Claims, error) {
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(flow.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %v", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("missing id token")
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %v", err)
+	}
+	if idToken.Nonce != flow.Nonce {
+		return nil, fmt.Errorf("invalid id token nonce")
+	}
+	claims := new(Claims)
+	if err := idToken.Claims(claims); err != nil {
+		return nil, err
+	}
+	claims.Subject = idToken.Subject
+	return claims, nil
+}
+
+// Providers discovers the configured providers on first use so an issuer
+// that is down doesn't keep the API from starting.
+type Providers struct {
+	mu          sync.Mutex
+	configs     map[string]config.OIDCProvider
+	providers   map[string]*Provider
+	redirectURL func(name string) string
+}
+
+func New(configs []config.OIDCProvider, redirectURL func(name string) string) *Providers {
+	p := &Providers{
+		configs:     map[string]config.OIDCProvider{},
+		providers:   map[string]*Provider{},
+		redirectURL: redirectURL,
+	}
+	for _, c := range configs {
+		p.configs[c.Name] = c
+	}
+	return p
+}
+
+// NewFromConfig returns the providers of OIDC_PROVIDERS, the provider sends
+// the user back to the web server which finishes the login on the API.
+func NewFromConfig() *Providers {
+	return New(config.Envs.OIDCProviders, func(name string) string {
+		return fmt.Sprintf("%s:%s/service/login/oidc/%s/callback", config.Envs.PublicHost, config.Envs.PortWeb, name)
+	})
+}
+
+// Names returns the configured providers in a stable order.
+func (p *Providers) Names() []string {
+	names := make([]string, 0, len(p.configs))
+	for name := range p.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *Providers) Get(ctx context.Context, name string) (*Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if provider, ok := p.providers[name]; ok {
+		return provider, nil
+	}
+	c, ok := p.configs[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	discovered, err := oidc.NewProvider(ctx, c.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover %v: %v", name, err)
+	}
+	provider := &Provider{
+		Name: name,
+		oauth2: oauth2.Config{
+			ClientID:     c.ClientID,
+			ClientSecret: c.ClientSecret,
+			Endpoint:     discovered.Endpoint(),
+			RedirectURL:  p.redirectURL(name),
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: c.ClientID}),
+	}
+	p.providers[name] = provider
+	return provider, nil
+}
@@ -0,0 +1,90 @@
+package ratelimiter
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcTrustedProxiesOnce sync.Once
+	grpcTrustedProxies     []*net.IPNet
+)
+
+// UnaryServerInterceptor applies the policies to gRPC calls, the route of a
+// call is its full method name, e.g. /types_grpc.ProductService/GetProducts.
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.allowGRPC(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor takes a single request when the stream is opened.
+func (l *Limiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.allowGRPC(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (l *Limiter) allowGRPC(ctx context.Context, fullMethod string) error {
+	policy, result, err := l.Allow(ctx, "", fullMethod, GRPCClientKey(ctx))
+	if err != nil {
+		log.Printf("rate limiter unavailable: %v", err)
+		return nil
+	}
+
+	md := metadata.Pairs(
+		"x-ratelimit-limit", strconv.FormatInt(policy.Limit, 10),
+		"x-ratelimit-remaining", strconv.FormatInt(result.Remaining, 10),
+		"x-ratelimit-reset", strconv.FormatInt(ceilSeconds(result.ResetAfter), 10),
+	)
+	if !result.Allowed {
+		md.Set("retry-after", strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+	}
+	if err := grpc.SetHeader(ctx, md); err != nil {
+		log.Printf("failed to set rate limit headers: %v", err)
+	}
+
+	if !result.Allowed {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %vs", ceilSeconds(result.RetryAfter))
+	}
+	return nil
+}
+
+// GRPCClientKey identifies the client of a call like ClientKey, the limiter
+// runs before auth so a call is mostly keyed by its address. x-forwarded-for
+// is only honoured when the peer is a trusted proxy.
+func GRPCClientKey(ctx context.Context) string {
+	if userID := auth.GetUserIDFromContext(ctx); userID > 0 {
+		return "user:" + strconv.Itoa(userID)
+	}
+	if apiKeyID := auth.GetAPIKeyIDFromContext(ctx); apiKeyID > 0 {
+		return "apikey:" + strconv.Itoa(apiKeyID)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	grpcTrustedProxiesOnce.Do(func() {
+		grpcTrustedProxies = utils.ParseTrustedProxies(config.Envs.TrustedProxies)
+	})
+	return "ip:" + utils.ClientIPWithProxies(remoteAddr, md.Get("x-forwarded-for"), grpcTrustedProxies)
+}
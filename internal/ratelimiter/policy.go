@@ -0,0 +1,75 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy allows Limit requests per Period for each client, with bursts of
+// up to Limit requests.
+type Policy struct {
+	Limit  int64
+	Period time.Duration
+}
+
+// Policies maps a route to its policy, a route is either "METHOD /path/template"
+// for REST, "/path/template" for every method or a gRPC full method name.
+type Policies map[string]Policy
+
+const defaultRoute = "default"
+
+// ParsePolicies parses policies written as `route=limit/period` separated by
+// semicolons, e.g. "default=120/1m;POST /api/v1/login=20/1m".
+func ParsePolicies(v string) (Policies, error) {
+	policies := Policies{}
+	for _, entry := range strings.Split(v, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid rate limit policy %q", entry)
+		}
+		route, rule := strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1:])
+		limit, period, ok := strings.Cut(rule, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit policy %q", entry)
+		}
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid rate limit in %q", entry)
+		}
+		if period == "" {
+			return nil, fmt.Errorf("invalid rate limit period in %q", entry)
+		}
+		if period[0] < '0' || period[0] > '9' {
+			period = "1" + period
+		}
+		d, err := time.ParseDuration(period)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid rate limit period in %q", entry)
+		}
+		policies[route] = Policy{Limit: n, Period: d}
+	}
+	if _, ok := policies[defaultRoute]; !ok {
+		return nil, fmt.Errorf("missing default rate limit policy")
+	}
+	return policies, nil
+}
+
+// Lookup returns the policy of the route and the key it's counted under,
+// routes without their own policy share the default one per client.
+func (p Policies) Lookup(method string, route string) (string, Policy) {
+	if method != "" {
+		if policy, ok := p[method+" "+route]; ok {
+			return method + " " + route, policy
+		}
+	}
+	if policy, ok := p[route]; ok {
+		return route, policy
+	}
+	return defaultRoute, p[defaultRoute]
+}
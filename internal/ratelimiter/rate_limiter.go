@@ -1,29 +1,120 @@
 package ratelimiter
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
-	"golang.org/x/time/rate"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
 )
 
-var limiter = rate.NewLimiter(1, 1)
+// Limiter applies the policy of each route to every client separately.
+type Limiter struct {
+	store    Store
+	policies Policies
+}
+
+func New(store Store, policies Policies) *Limiter {
+	return &Limiter{store: store, policies: policies}
+}
+
+// PoliciesFromConfig parses RATE_LIMIT_POLICIES, a broken value falls back
+// to the default policy only so the servers still start.
+func PoliciesFromConfig() Policies {
+	policies, err := ParsePolicies(config.Envs.RateLimitPolicies)
+	if err != nil {
+		log.Printf("invalid rate limit policies, using the default one: %v", err)
+		return Policies{defaultRoute: {Limit: 120, Period: time.Minute}}
+	}
+	return policies
+}
+
+var limiter = New(NewMemoryStore(time.Now), PoliciesFromConfig())
+
+// SetDefault replaces the limiter used by WithRateLimiter, the servers set
+// one backed by redis so the limits hold across instances.
+func SetDefault(l *Limiter) {
+	limiter = l
+}
+
+// Default returns the limiter used by WithRateLimiter.
+func Default() *Limiter {
+	return limiter
+}
+
+// Allow takes a request of the client from the bucket of the route.
+func (l *Limiter) Allow(ctx context.Context, method string, route string, client string) (Policy, Result, error) {
+	name, policy := l.policies.Lookup(method, route)
+	result, err := l.store.Take(ctx, name+"|"+client, policy)
+	return policy, result, err
+}
 
 func WithRateLimiter(handlerFunc http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Check if the request is allowed
+		limiter.Middleware(handlerFunc)(w, r)
+	}
+}
 
-		if !limiter.Allow() {
-			// Rate limit exceeded
-			log.Printf("Rate limit exceeded, %v .\n", http.StatusTooManyRequests)
-			// utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("Rate limit exceeded"))
-			// return
+func (l *Limiter) Middleware(handlerFunc http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policy, result, err := l.Allow(r.Context(), r.Method, routeOf(r), ClientKey(r))
+		if err != nil {
+			// a limiter outage shouldn't take the API down
+			log.Printf("rate limiter unavailable: %v", err)
+			handlerFunc(w, r)
+			return
+		}
+
+		setHeaders(w.Header(), policy, result)
+		if !result.Allowed {
+			log.Printf("Rate limit exceeded, %v %v %v.\n", r.Method, r.URL.Path, http.StatusTooManyRequests)
+			utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("rate limit exceeded"))
+			return
 		}
 
-		// Handle the request
-		h, m, s := time.Now().Clock()
-		log.Printf("Request allowed at %v, %v, %v. \n", h, m, s)
 		handlerFunc(w, r)
 	}
 }
+
+func setHeaders(h http.Header, policy Policy, result Result) {
+	h.Set("X-RateLimit-Limit", strconv.FormatInt(policy.Limit, 10))
+	h.Set("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.ResetAfter), 10))
+	if !result.Allowed {
+		h.Set("Retry-After", strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+// routeOf returns the path template of the gorilla route so every product
+// shares the bucket of /products/{productID}, other routers use the path.
+func routeOf(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// ClientKey identifies the client by user, then by API key, then by address.
+// The user and the key are only known once auth has checked them, a key sent
+// to a public route could be made up and is ignored.
+func ClientKey(r *http.Request) string {
+	if userID := auth.GetUserIDFromContext(r.Context()); userID > 0 {
+		return "user:" + strconv.Itoa(userID)
+	}
+	if apiKeyID := auth.GetAPIKeyIDFromContext(r.Context()); apiKeyID > 0 {
+		return "apikey:" + strconv.Itoa(apiKeyID)
+	}
+	return "ip:" + utils.ClientIP(r)
+}
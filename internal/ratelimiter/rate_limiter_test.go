@@ -0,0 +1,178 @@
+package ratelimiter
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies("default=120/1m; POST /api/v1/login=20/m;/api/v1/products=5/10s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, route, name string
+		policy              Policy
+	}{
+		{"POST", "/api/v1/login", "POST /api/v1/login", Policy{Limit: 20, Period: time.Minute}},
+		{"GET", "/api/v1/login", "default", Policy{Limit: 120, Period: time.Minute}},
+		{"GET", "/api/v1/products", "/api/v1/products", Policy{Limit: 5, Period: time.Second * 10}},
+	}
+	for _, test := range tests {
+		name, policy := policies.Lookup(test.method, test.route)
+		if name != test.name || policy != test.policy {
+			t.Errorf("%v %v: expected %v %+v, got %v %+v", test.method, test.route, test.name, test.policy, name, policy)
+		}
+	}
+
+	for _, v := range []string{"POST /api/v1/login=20/1m", "default=0/1m", "default=10", "default=10/", "default=10/soon"} {
+		if _, err := ParsePolicies(v); err == nil {
+			t.Errorf("expected %q to be invalid", v)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(func() time.Time { return now })
+	policy := Policy{Limit: 3, Period: time.Second * 3}
+
+	for i := int64(2); i >= 0; i-- {
+		result, _ := store.Take(ctx, "ip:10.0.0.1", policy)
+		if !result.Allowed || result.Remaining != i {
+			t.Fatalf("expected request to be allowed with %v remaining, got %+v", i, result)
+		}
+	}
+	result, _ := store.Take(ctx, "ip:10.0.0.1", policy)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Errorf("expected request to be denied for 1s, got %+v", result)
+	}
+	if result, _ := store.Take(ctx, "ip:10.0.0.2", policy); !result.Allowed {
+		t.Errorf("expected other clients to have their own bucket, got %+v", result)
+	}
+
+	now = now.Add(time.Second)
+	if result, _ := store.Take(ctx, "ip:10.0.0.1", policy); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("expected a request to be refilled after 1s, got %+v", result)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	l := New(NewMemoryStore(func() time.Time { return now }), Policies{
+		"default":            {Limit: 100, Period: time.Minute},
+		"POST /api/v1/login": {Limit: 2, Period: time.Minute},
+	})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/login", l.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).Methods(http.MethodPost)
+
+	login := func(remoteAddr string, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/login", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := login("10.0.0.1:5000", ""); rr.Code != http.StatusOK {
+			t.Fatalf("attempt %d: expected status code %d, got %d", i, http.StatusOK, rr.Code)
+		}
+	}
+	rr := login("10.0.0.1:5000", "")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status code %d, got %d", http.StatusTooManyRequests, rr.Code)
+	}
+	for header, want := range map[string]string{
+		"Retry-After":           "30",
+		"X-RateLimit-Limit":     "2",
+		"X-RateLimit-Remaining": "0",
+		"X-RateLimit-Reset":     "60",
+	} {
+		if got := rr.Header().Get(header); got != want {
+			t.Errorf("expected %v %v, got %q", header, want, got)
+		}
+	}
+
+	// an untrusted client can't pick its own address
+	if rr := login("10.0.0.1:5000", "10.0.0.7"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected spoofed X-Forwarded-For to be ignored, got %d", rr.Code)
+	}
+	// a made-up API key is not a bucket of its own, only one auth checked is
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-API-Key", "tjk_made_up")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected an unchecked X-API-Key to be ignored, got %d", rr.Code)
+	}
+	// behind the web server each browser has its own bucket
+	if rr := login("127.0.0.1:5000", "10.0.0.8"); rr.Code != http.StatusOK {
+		t.Errorf("expected forwarded client to be allowed, got %d", rr.Code)
+	}
+}
+
+func TestClientIPWithProxies(t *testing.T) {
+	proxies := utils.ParseTrustedProxies("127.0.0.1,10.1.0.0/16")
+
+	tests := []struct {
+		remoteAddr   string
+		forwardedFor []string
+		want         string
+	}{
+		{"203.0.113.5:4000", []string{"10.0.0.7"}, "203.0.113.5"},
+		{"127.0.0.1:4000", []string{"203.0.113.9"}, "203.0.113.9"},
+		{"127.0.0.1:4000", []string{"6.6.6.6, 203.0.113.9, 10.1.2.3"}, "203.0.113.9"},
+		{"127.0.0.1:4000", nil, "127.0.0.1"},
+	}
+	for _, test := range tests {
+		if got := utils.ClientIPWithProxies(test.remoteAddr, test.forwardedFor, proxies); got != test.want {
+			t.Errorf("%v %v: expected %v, got %v", test.remoteAddr, test.forwardedFor, test.want, got)
+		}
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	l := New(NewMemoryStore(func() time.Time { return now }), Policies{
+		"default": {Limit: 1, Period: time.Minute},
+	})
+	interceptor := l.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/types_grpc.ProductService/GetProducts"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	call := func(apiKey string) error {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", apiKey))
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}})
+		_, err := interceptor(ctx, nil, info, handler)
+		return err
+	}
+
+	if err := call("tjk_first"); err != nil {
+		t.Fatalf("expected call to be allowed, got %v", err)
+	}
+	// the limiter runs before auth, a new key on every call doesn't help
+	if err := call("tjk_second"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected %v, got %v", codes.ResourceExhausted, err)
+	}
+}
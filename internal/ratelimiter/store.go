@@ -0,0 +1,114 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Result is the state of the bucket of a client after a request.
+type Result struct {
+	Allowed   bool
+	Remaining int64
+	// RetryAfter is how long to wait before the next request is allowed.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again.
+	ResetAfter time.Duration
+}
+
+// Store takes one request from the bucket of key, buckets follow the
+// generic cell rate algorithm so only a single timestamp is kept per key.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// gcra computes the new theoretical arrival time of the bucket from the
+// stored one, tat is zero for an empty bucket.
+func gcra(now time.Time, tat time.Time, policy Policy) (time.Time, Result) {
+	interval := policy.Period / time.Duration(policy.Limit)
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(interval)
+	allowAt := newTat.Add(-policy.Period)
+	if now.Before(allowAt) {
+		return tat, Result{Allowed: false, RetryAfter: allowAt.Sub(now), ResetAfter: tat.Sub(now)}
+	}
+	resetAfter := newTat.Sub(now)
+	return newTat, Result{Allowed: true, Remaining: int64((policy.Period - resetAfter) / interval), ResetAfter: resetAfter}
+}
+
+// gcraScript is gcra in Lua so the check and the update are atomic across
+// instances, times are in milliseconds from the clock of redis.
+var gcraScript = redis.NewScript(`
+local period = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local interval = period / limit
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - period
+if now < allow_at then
+	return {0, 0, math.ceil(allow_at - now), math.ceil(tat - now)}
+end
+redis.call('SET', KEYS[1], tostring(new_tat), 'PX', math.ceil(new_tat - now))
+return {1, math.floor((period - (new_tat - now)) / interval), 0, math.ceil(new_tat - now)}
+`)
+
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	values, err := gcraScript.Run(ctx, s.client, []string{"ratelimit:" + key}, policy.Period.Milliseconds(), policy.Limit).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:    values[0] == 1,
+		Remaining:  values[1],
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+const maxMemoryBuckets = 10000
+
+// MemoryStore keeps the buckets in the process, it's used when no redis
+// client is configured, e.g. in tests.
+type MemoryStore struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+	now  func() time.Time
+}
+
+func NewMemoryStore(now func() time.Time) *MemoryStore {
+	return &MemoryStore{tats: map[string]time.Time{}, now: now}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if len(s.tats) > maxMemoryBuckets {
+		// full buckets carry no state
+		for k, tat := range s.tats {
+			if tat.Before(now) {
+				delete(s.tats, k)
+			}
+		}
+	}
+	tat, result := gcra(now, s.tats[key], policy)
+	s.tats[key] = tat
+	return result, nil
+}
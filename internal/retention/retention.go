@@ -0,0 +1,80 @@
+package retention
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+)
+
+// PurgeFunc removes the rows that were soft deleted before the cutoff and
+// returns how many were removed.
+type PurgeFunc func(before time.Time) (int64, error)
+
+// Purge is a named PurgeFunc, the name is only used for logging.
+type Purge struct {
+	Name  string
+	Purge PurgeFunc
+}
+
+// Job purges soft deleted rows once they are older than Retention. Purges run
+// in the order they are given, so rows referencing others go first.
+type Job struct {
+	Retention time.Duration
+	Interval  time.Duration
+	Purges    []Purge
+	now       func() time.Time
+}
+
+func NewJob(retention time.Duration, interval time.Duration, purges ...Purge) *Job {
+	return &Job{
+		Retention: retention,
+		Interval:  interval,
+		Purges:    purges,
+		now:       time.Now,
+	}
+}
+
+func JobFromConfig(purges ...Purge) *Job {
+	return NewJob(
+		time.Hour*24*time.Duration(config.Envs.SoftDeleteRetentionInDays),
+		time.Second*time.Duration(config.Envs.SoftDeletePurgeIntervalInSeconds),
+		purges...,
+	)
+}
+
+// RunOnce purges everything deleted before now minus the retention. A failing
+// purge is logged and does not stop the others.
+func (j *Job) RunOnce() map[string]int64 {
+	before := j.now().Add(-j.Retention)
+	purged := make(map[string]int64, len(j.Purges))
+	for _, p := range j.Purges {
+		n, err := p.Purge(before)
+		if err != nil {
+			log.Printf("failed to purge %s: %v", p.Name, err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("purged %d %s", n, p.Name)
+		}
+		purged[p.Name] = n
+	}
+	return purged
+}
+
+// Run purges on every interval until the context is done.
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	j.RunOnce()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.RunOnce()
+		}
+	}
+}
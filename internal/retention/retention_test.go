@@ -0,0 +1,42 @@
+package retention
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRunOnce(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	var order []string
+	var cutoff time.Time
+	purge := func(name string, n int64, err error) Purge {
+		return Purge{Name: name, Purge: func(before time.Time) (int64, error) {
+			order = append(order, name)
+			cutoff = before
+			return n, err
+		}}
+	}
+
+	job := NewJob(time.Hour*24*30, time.Hour,
+		purge("orders", 2, nil),
+		purge("products", 0, fmt.Errorf("db is down")),
+		purge("users", 1, nil),
+	)
+	job.now = func() time.Time { return now }
+
+	purged := job.RunOnce()
+
+	if fmt.Sprint(order) != "[orders products users]" {
+		t.Errorf("expected the purges to run in order, got %v", order)
+	}
+	if !cutoff.Equal(now.AddDate(0, 0, -30)) {
+		t.Errorf("expected the cutoff to be 30 days ago, got %v", cutoff)
+	}
+	if purged["orders"] != 2 || purged["users"] != 1 {
+		t.Errorf("unexpected purged counts %v", purged)
+	}
+	if _, ok := purged["products"]; ok {
+		t.Error("expected the failed purge not to be counted")
+	}
+}
@@ -51,6 +51,29 @@ func GetJWTSecretToken(r *http.Request) (string, error) {
 	return c.Value, nil
 }
 
+// SetOIDCFlowToken keeps the flow of a login with a provider until the
+// provider redirects back, the cookie is only sent to the callback.
+func SetOIDCFlowToken(w http.ResponseWriter, v string, maxAge int) {
+	c := &http.Cookie{
+		Name:     "oidc_flow",
+		Value:    v,
+		Path:     "/service/login/oidc",
+		MaxAge:   maxAge,
+		Secure:   false,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	http.SetCookie(w, c)
+}
+
+func GetOIDCFlowToken(r *http.Request) (string, error) {
+	c, err := r.Cookie("oidc_flow")
+	if err != nil {
+		return "", err
+	}
+	return c.Value, nil
+}
+
 type Sss struct {
 	server chan map[string]string
 	mu     sync.RWMutex
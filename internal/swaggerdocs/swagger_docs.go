@@ -1,10 +1,30 @@
 package swagger_docs
 
 import (
+	_ "embed"
+	"net/http"
+
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/swaggo/swag"
 )
 
+// spec is generated by protoc-gen-openapiv2 with the gRPC gateway, see the
+// gen target of the Makefile.
+//
+//go:embed api.swagger.json
+var spec string
+
+type doc struct{}
+
+func (doc) ReadDoc() string {
+	return spec
+}
+
+func init() {
+	swag.Register(swag.Name, doc{})
+}
+
 type Handler struct {
 }
 
@@ -12,6 +32,14 @@ func NewHandler() *Handler {
 	return &Handler{}
 }
 
+// RegisterRoutes serves the Swagger UI of the spec at /swagger/index.html and
+// the spec at /swagger/doc.json.
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/swagger/", httpSwagger.WrapHandler)
+	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+}
+
+// RegisterServeMux serves the same routes under /api/v1 like the subrouter
+// of RegisterRoutes.
+func (h *Handler) RegisterServeMux(mux *http.ServeMux) {
+	mux.Handle("GET /api/v1/swagger/", httpSwagger.WrapHandler)
 }
@@ -2,15 +2,30 @@ package types
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"time"
-
-	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
 )
 
+// ErrNotFound is wrapped by the stores when the record asked for doesn't
+// exist, the services turn it into a NotFound status.
+var ErrNotFound = errors.New("not found")
+
+// ErrEmailTaken is wrapped by the stores when a user is created with the email
+// of another account, the account may be in the trash where it can't be
+// looked up but still holds its email until it is purged.
+var ErrEmailTaken = errors.New("email taken by another account")
+
 type FinanceReport struct {
-	OrderCount     int `json:"order_count"`
-	TotalItemsSold int `json:"total_items_sold"`
-	TotalRevenue   int `json:"total_revenue"`
+	OrderCount     int     `json:"order_count"`
+	TotalItemsSold int     `json:"total_items_sold"`
+	TotalRevenue   float64 `json:"total_revenue"`
+}
+
+// FinanceService is the report of the admin dashboard the REST and gRPC
+// transports translate to.
+type FinanceService interface {
+	GetFinanceReport(context.Context) (*FinanceReport, error)
 }
 
 type UserStore interface {
@@ -22,19 +37,33 @@ type UserStore interface {
 	DeleteUserByID(int) (int64, error)
 	DeleteUser(User) (int64, error)
 	UpdateUser(User) (int64, error)
+	UpdatePasswordByUserID(int, string) error
 	CreateUser(User) error
+	GetDeletedUsers() ([]User, error)
+	RestoreUserByID(int) (int64, error)
+}
+
+// AccountStore holds what a user can see and remove of their own account.
+type AccountStore interface {
+	UpdateProfile(User) (int64, error)
+	GetOrdersByUserID(int) ([]Order, error)
+	GetOrderItemsByOrderIDs([]int) ([]OrderItem, error)
+	AnonymiseUser(int, time.Time) error
 }
 
+// UserService is the users context the REST, protobuf and gRPC transports
+// translate to, the admin checks and the audit log are done here.
 type UserService interface {
-	GetUsers(context.Context, *pb.GetUsersRequest) (*pb.GetUsersResponse, error)
-	GetUsersByIDs(context.Context, *pb.GetUsersByIDsRequest) (*pb.GetUsersByIDsResponse, error)
-	UpdateVerifiedUserByEmail(context.Context, *pb.UpdateVerifiedUserByEmailRequest) error
-	GetUserByEmail(context.Context, *pb.GetUserByEmailRequest) (*pb.GetUserByEmailResponse, error)
-	GetUserByID(context.Context, *pb.GetUserByIDRequest) (*pb.GetUserByIDResponse, error)
-	DeleteUserByID(context.Context, *pb.DeleteUserByIDRequest) (*pb.DeleteUserByIDResponse, error)
-	DeleteUser(context.Context, *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error)
-	UpdateUser(context.Context, *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error)
-	CreateUser(context.Context, *pb.CreateUserRequest) (*pb.CreateUserResponse, error)
+	GetUsers(context.Context) ([]User, error)
+	GetUsersByIDs(context.Context, []int) ([]User, error)
+	GetUserByID(context.Context, int) (*User, error)
+	GetUserByEmail(context.Context, string) (*User, error)
+	CreateUser(context.Context, User) error
+	UpdateVerifiedUserByEmail(context.Context, string) error
+	UpdateUser(context.Context, User) (int64, error)
+	DeleteUserByID(context.Context, int) (int64, error)
+	GetDeletedUsers(context.Context) ([]User, error)
+	RestoreUserByID(context.Context, int) (*User, error)
 }
 
 type ProductStore interface {
@@ -44,17 +73,27 @@ type ProductStore interface {
 	CreateProduct(Product) (int64, error)
 	DeleteProductByID(int) (int64, error)
 	DeleteProduct(Product) (int64, error)
-	UpdateProduct(Product) (int64, error)
+	UpdateProduct(Product, int) (int64, error)
+	GetDeletedProducts() ([]Product, error)
+	RestoreProductByID(int) (int64, error)
+	GetProductBySKU(string) (*Product, error)
+	GetProductsPage(afterID int, limit int) ([]Product, error)
 }
 
+// ProductService is the catalogue the REST, protobuf and gRPC transports
+// translate to, the admin checks and the audit log are done here.
 type ProductService interface {
-	GetProducts(context.Context, *pb.GetProductsRequest) (*pb.GetProductsResponse, error)
-	GetProductsByIDs(context.Context, *pb.GetProductsByIDsRequest) (*pb.GetProductsByIDsResponse, error)
-	GetProductByID(context.Context, *pb.GetProductByIDRequest) (*pb.GetProductByIDResponse, error)
-	CreateProduct(context.Context, *pb.CreateProductRequest) (*pb.CreateProductResponse, error)
-	DeleteProductByID(context.Context, *pb.DeleteProductByIDRequest) (*pb.DeleteProductByIDResponse, error)
-	DeleteProduct(context.Context, *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error)
-	UpdateProduct(context.Context, *pb.UpdateProductRequest) (*pb.UpdateProductResponse, error)
+	GetProducts(context.Context) ([]Product, error)
+	GetProductsByIDs(context.Context, []int) ([]Product, error)
+	GetProductByID(context.Context, int) (*Product, error)
+	CreateProduct(context.Context, Product) (int64, error)
+	UpdateProduct(context.Context, Product) (int64, error)
+	DeleteProductByID(context.Context, int) (int64, error)
+	GetDeletedProducts(context.Context) ([]Product, error)
+	RestoreProductByID(context.Context, int) (*Product, error)
+	ExportProducts(context.Context, func(Product) error) error
+	StreamProducts(ctx context.Context, cursor int, pageSize int, send func(ProductPage) error) error
+	WatchProducts(ctx context.Context, cursor int64, send func(ProductEvent) error) error
 }
 
 type OrderStore interface {
@@ -68,39 +107,109 @@ type OrderStore interface {
 	GetOrderItems() ([]OrderItem, error)
 	GetOrderItemsByIDs([]int) ([]OrderItem, error)
 	GetOrderItemsByID(int) (*OrderItem, error)
-	CreateOrderItem(OrderItem) error
+	CreateOrderItem(OrderItem) (int64, error)
 	DeleteOrderItemByID(int) (int64, error)
 	DeleteOrderItem(OrderItem) (int64, error)
 	UpdateOrderItem(OrderItem) (int64, error)
+	GetDeletedOrders() ([]Order, error)
+	RestoreOrderByID(int) (int64, error)
 }
 
+// OrderService is the orders context the REST, protobuf and gRPC transports
+// translate to, the admin checks, the audit log and the announcements of the
+// status changes are done here.
 type OrderService interface {
-	GetOrders(context.Context, *pb.GetOrdersRequest) (*pb.GetOrdersResponse, error)
-	GetOrdersByIDs(context.Context, *pb.GetOrdersByIDsRequest) (*pb.GetOrdersByIDsResponse, error)
-	GetOrderByID(context.Context, *pb.GetOrderByIDRequest) (*pb.GetOrderByIDResponse, error)
-	CreateOrder(context.Context, *pb.CreateOrderRequest) (*pb.CreateOrderResponse, error)
-	DeleteOrderByID(context.Context, *pb.DeleteOrderByIDRequest) (*pb.DeleteOrderByIDResponse, error)
-	DeleteOrder(context.Context, *pb.DeleteOrderRequest) (*pb.DeleteOrderResponse, error)
-	UpdateOrder(context.Context, *pb.UpdateOrderRequest) (*pb.UpdateOrderResponse, error)
-	// GetOrderItems() ([]OrderItem, error)
-	// GetOrderItemsByIDs([]int) ([]OrderItem, error)
-	// GetOrderItemsByID(int) (*OrderItem, error)
-	// CreateOrderItem(OrderItem) error
-	// DeleteOrderItemByID(int) (int64, error)
-	// DeleteOrderItem(OrderItem) (int64, error)
-	// UpdateOrderItem(OrderItem) (int64, error)
+	GetOrders(context.Context) ([]Order, error)
+	GetOrdersByIDs(context.Context, []int) ([]Order, error)
+	GetOrderByID(context.Context, int) (*Order, error)
+	CreateOrder(context.Context, Order) (int64, error)
+	UpdateOrder(context.Context, Order) (int64, error)
+	DeleteOrderByID(context.Context, int) (int64, error)
+	GetDeletedOrders(context.Context) ([]Order, error)
+	RestoreOrderByID(context.Context, int) (*Order, error)
+	WatchOrders(ctx context.Context, cursor int64, send func(OrderEvent) error) error
+}
+
+// OrderItemService is the lines of the orders the REST and gRPC transports
+// translate to, they are made by the checkout and only the admins change
+// them.
+type OrderItemService interface {
+	GetOrderItems(context.Context) ([]OrderItem, error)
+	GetOrderItemsByIDs(context.Context, []int) ([]OrderItem, error)
+	GetOrderItemByID(context.Context, int) (*OrderItem, error)
+	UpdateOrderItem(context.Context, OrderItem) (int64, error)
+	DeleteOrderItemByID(context.Context, int) (int64, error)
+}
+
+// CheckoutService turns the cart of the signed in user into an order, the
+// locale is the one of the order confirmation.
+type CheckoutService interface {
+	Checkout(ctx context.Context, items []CartItem, locale string) (*ResponseCart, error)
 }
 
 type TokenStore interface {
 	GetBlacklistedTokens() ([]Token, error)
 	CreateBlacklistTokens(Token) (*Token, error)
 	GetBlacklistTokenByString(string) (*Token, error)
+	CreateVerificationToken(VerificationToken) error
+	GetVerificationTokenByHash(string) (*VerificationToken, error)
+	GetLatestVerificationTokenByEmail(string) (*VerificationToken, error)
+	UseVerificationToken(int) (int64, error)
+	RevokeVerificationTokensByEmail(string) error
+	CreatePasswordResetToken(PasswordResetToken) error
+	GetPasswordResetTokenByHash(string) (*PasswordResetToken, error)
+	UsePasswordResetToken(int) (int64, error)
+	RevokePasswordResetTokensByUserID(int) error
+	RevokeUserTokens(int) error
+	GetUserTokensRevokedAt(int) (time.Time, error)
+	APIKeyStore
+}
+
+type APIKeyStore interface {
+	CreateAPIKey(APIKey) (int, error)
+	GetAPIKeys() ([]APIKey, error)
+	GetAPIKeyByID(int) (*APIKey, error)
+	GetAPIKeyByHash(string) (*APIKey, error)
+	RevokeAPIKey(int, time.Time) (int64, error)
+	TouchAPIKey(int, time.Time) error
 }
 
+type MFAStore interface {
+	GetUserMFA(int) (*UserMFA, error)
+	CreateUserMFA(UserMFA) error
+	EnableUserMFA(int, time.Time) error
+	DisableUserMFA(int) error
+	UseUserMFAStep(int, int64) (int64, error)
+	ReplaceRecoveryCodes(int, []string) error
+	UseRecoveryCode(int, string, time.Time) (int64, error)
+}
+
+type IdentityStore interface {
+	GetUserIdentity(string, string) (*UserIdentity, error)
+	GetUserIdentitiesByUserID(int) ([]UserIdentity, error)
+	CreateUserIdentity(UserIdentity) error
+	UpdateUserIdentityLogin(int, string, time.Time) error
+}
+
+// TokenService holds the blacklisted tokens and the API keys the REST,
+// protobuf and gRPC transports translate to.
 type TokenService interface {
-	GetBlacklistedTokens(context.Context, *pb.GetBlacklistedTokensRequest) (*pb.GetBlacklistedTokensResponse, error)
-	CreateBlacklistTokens(context.Context, *pb.CreateBlacklistTokenRequest) (*pb.CreateBlacklistTokenResponse, error)
-	GetBlacklistTokenByString(context.Context, *pb.GetBlacklistTokenByStringRequest) (*pb.GetBlacklistTokenByStringResponse, error)
+	GetBlacklistedTokens(context.Context) ([]Token, error)
+	CreateBlacklistToken(context.Context, Token) (*Token, error)
+	GetBlacklistTokenByString(context.Context, string) (*Token, error)
+	CreateAPIKey(context.Context, CreateAPIKeyPayload) (*APIKey, string, error)
+	GetAPIKeys(context.Context) ([]APIKey, error)
+	RevokeAPIKey(context.Context, int) error
+}
+
+// AuthService is the login of the users the REST and gRPC transports
+// translate to, the ip is the client's for the login guard and the locale is
+// the one of the unlock email.
+type AuthService interface {
+	Login(ctx context.Context, payload LoginUserPayload, ip string, locale string) (*ResponseLogin, error)
+	LoginMFA(ctx context.Context, payload LoginMFAPayload, ip string, locale string) (*ResponseLoginMFA, error)
+	Refresh(context.Context, RefreshTokenPayload) (*RefreshTokenPayload, error)
+	Logout(context.Context, RefreshTokenPayload) error
 }
 
 type CartItem struct {
@@ -109,46 +218,54 @@ type CartItem struct {
 }
 
 type User struct {
-	ID          int       `json:"id"`
-	FirstName   string    `json:"first_name"`
-	LastName    string    `json:"last_name"`
-	Email       string    `json:"email"`
-	Password    string    `json:"-"`
-	Verified    bool      `json:"-"`
-	Role        string    `json:"-"`
-	PhoneNumber string    `json:"phone_number"`
-	Address     string    `json:"address"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          int        `json:"id"`
+	FirstName   string     `json:"first_name"`
+	LastName    string     `json:"last_name"`
+	Email       string     `json:"email"`
+	Password    string     `json:"-"`
+	Verified    bool       `json:"-"`
+	Role        string     `json:"-"`
+	PhoneNumber string     `json:"phone_number"`
+	Address     string     `json:"address"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
 
 type Order struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Total     float64   `json:"total"`
-	Status    string    `json:"status"`
-	Address   string    `json:"address"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Total       float64    `json:"total"`
+	Status      string     `json:"status"`
+	Address     string     `json:"address"`
+	PhoneNumber string     `json:"phone_number"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
 
 type OrderItem struct {
-	ID        int     `json:"id"`
-	OrderID   int     `json:"order_id"`
-	ProductID int     `json:"product_id"`
-	Quantity  int     `json:"qty"`
-	Price     float64 `json:"price"`
+	ID          int     `json:"id"`
+	OrderID     int     `json:"order_id"`
+	ProductID   int     `json:"product_id"`
+	Quantity    int     `json:"qty"`
+	Price       float64 `json:"price"`
+	WarehouseID int     `json:"warehouse_id"`
 }
 
 type Product struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Merchant    string    `json:"merchant"`
-	Category    string    `json:"category"`
-	Currency    string    `json:"currency"`
-	Image       string    `json:"image"`
-	Price       float64   `json:"price"`
-	Quantity    int       `json:"qty"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          int        `json:"id"`
+	SKU         string     `json:"sku"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Merchant    string     `json:"merchant"`
+	Category    string     `json:"category"`
+	Currency    string     `json:"currency"`
+	Image       string     `json:"image"`
+	Price       float64    `json:"price"`
+	Quantity    int        `json:"qty"`
+	Rating      float64    `json:"rating"`
+	ReviewCount int        `json:"review_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
 
 type ResponseProduct struct {
@@ -167,8 +284,8 @@ type RegisterUserPayload struct {
 }
 
 type ResponseRegister struct {
-	URL   string `json:"verify_url"`
-	Error string `json:"error"`
+	Message string `json:"message"`
+	Error   string `json:"error"`
 }
 
 type LoginUserPayload struct {
@@ -177,9 +294,13 @@ type LoginUserPayload struct {
 }
 
 type ResponseLogin struct {
-	AccessToken string `json:"access_token"`
-	SecretToken string `json:"secret_token"`
-	Error       string `json:"error"`
+	AccessToken          string `json:"access_token"`
+	SecretToken          string `json:"secret_token"`
+	MFARequired          bool   `json:"mfa_required,omitempty"`
+	MFAEnrolmentRequired bool   `json:"mfa_enrolment_required,omitempty"`
+	MFAToken             string `json:"mfa_token,omitempty"`
+	ProfileIncomplete    bool   `json:"profile_incomplete,omitempty"`
+	Error                string `json:"error"`
 }
 
 type CartCheckoutPayload struct {
@@ -268,6 +389,123 @@ type Token struct {
 	CreatedAt time.Time
 }
 
+type VerificationToken struct {
+	ID        int
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// UpdateProfilePayload only changes the fields that are sent, the email
+// can't be changed here as it is what the account is verified with.
+type UpdateProfilePayload struct {
+	FirstName   *string `json:"first_name" validate:"omitempty,min=1,max=255"`
+	LastName    *string `json:"last_name" validate:"omitempty,min=1,max=255"`
+	PhoneNumber *string `json:"phone_number" validate:"omitempty,min=12,max=12"`
+	Address     *string `json:"address" validate:"omitempty,min=1,max=255"`
+}
+
+type DeleteAccountPayload struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AccountExport is everything kept about a user, it is what
+// GET /api/v1/me/export downloads.
+type AccountExport struct {
+	ExportedAt time.Time             `json:"exported_at"`
+	Profile    User                  `json:"profile"`
+	Addresses  []string              `json:"addresses"`
+	Orders     []AccountExportOrder  `json:"orders"`
+	Sessions   AccountExportSessions `json:"sessions"`
+}
+
+type AccountExportOrder struct {
+	Order
+	Items []OrderItem `json:"items"`
+}
+
+type AccountExportSessions struct {
+	TokensRevokedAt *time.Time     `json:"tokens_revoked_at"`
+	Identities      []UserIdentity `json:"identities"`
+	APIKeys         []APIKey       `json:"api_keys"`
+}
+
+type ResendVerificationPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type PasswordResetToken struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordPayload struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=3,max=130"`
+}
+
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=3,max=130"`
+}
+
+type UserMFA struct {
+	UserID       int
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+	CreatedAt    time.Time
+	EnabledAt    *time.Time
+}
+
+type MFAPendingPayload struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+type LoginMFAPayload struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type MFACodePayload struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type ResponseMFAEnrolment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	Error           string `json:"error"`
+}
+
+type ResponseLoginMFA struct {
+	AccessToken   string   `json:"access_token"`
+	SecretToken   string   `json:"secret_token"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	Error         string   `json:"error"`
+}
+
+type ResponseRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Error         string   `json:"error"`
+}
+
+type ResponsePassword struct {
+	Message     string `json:"message"`
+	AccessToken string `json:"access_token"`
+	SecretToken string `json:"secret_token"`
+	Error       string `json:"error"`
+}
+
 type RefreshTokenPayload struct {
 	AccessToken string `json:"access_token"`
 	SecretToken string `json:"secret_token"`
@@ -276,3 +514,487 @@ type RefreshTokenPayload struct {
 type ResponseRefreshToken struct {
 	AccessToken string `json:"access_token"`
 }
+
+// UserIdentity links a user to the subject of an external OpenID Connect provider.
+type UserIdentity struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"-"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+type OIDCCallbackPayload struct {
+	Code      string `json:"code" validate:"required"`
+	State     string `json:"state" validate:"required"`
+	FlowToken string `json:"flow_token" validate:"required"`
+}
+
+type ResponseOIDCProviders struct {
+	Providers []string `json:"providers"`
+	Error     string   `json:"error"`
+}
+
+type ResponseOIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	FlowToken        string `json:"flow_token"`
+	Error            string `json:"error"`
+}
+
+// APIKey is a long lived credential of a user for scripts and partners, only
+// the hash of the key is stored, Prefix identifies it in listings.
+type APIKey struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedBy  int        `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateAPIKeyPayload struct {
+	UserID    int        `json:"user_id" validate:"required"`
+	Name      string     `json:"name" validate:"required,max=255"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type ResponseAPIKey struct {
+	APIKey *APIKey `json:"api_key"`
+	// Key is only returned when the key is created
+	Key   string `json:"key,omitempty"`
+	Error string `json:"error"`
+}
+
+// AuditLog is an entry of the append-only record of the create, update and
+// delete actions, Changes holds the fields that changed with their before
+// and after values.
+type AuditLog struct {
+	ID        int             `json:"id"`
+	ActorID   int             `json:"actor_id"`
+	APIKeyID  int             `json:"api_key_id,omitempty"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Changes   json.RawMessage `json:"changes"`
+	IP        string          `json:"ip"`
+	RequestID string          `json:"request_id"`
+	Transport string          `json:"transport"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditLogFilter narrows the audit log, zero values match everything.
+type AuditLogFilter struct {
+	ActorID   int
+	Action    string
+	Entity    string
+	EntityID  int
+	RequestID string
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	Offset    int
+}
+
+type AuditStore interface {
+	CreateAuditLog(AuditLog) error
+	GetAuditLogs(AuditLogFilter) ([]AuditLog, error)
+}
+
+const (
+	StockReceipt     = "receipt"
+	StockSale        = "sale"
+	StockReturn      = "return"
+	StockAdjustment  = "adjustment"
+	StockReservation = "reservation"
+	StockTransfer    = "transfer"
+)
+
+// StockMovement is an entry of the stock ledger of a product in a warehouse,
+// Quantity is signed: receipts and returns add stock, sales take it,
+// adjustments, reservations and transfers go either way. warehouse_stock
+// caches the sum of the ledger per warehouse and products.qty over all of
+// them.
+type StockMovement struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	Type        string    `json:"type"`
+	Quantity    int       `json:"qty"`
+	Reason      string    `json:"reason"`
+	Reference   string    `json:"reference"`
+	ActorID     int       `json:"actor_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	WarehouseID int       `json:"warehouse_id"`
+}
+
+// StockDrift is a product whose cached quantity doesn't match its ledger,
+// WarehouseID is 0 for the products.qty total.
+type StockDrift struct {
+	ProductID   int    `json:"product_id"`
+	WarehouseID int    `json:"warehouse_id,omitempty"`
+	Name        string `json:"name"`
+	Quantity    int    `json:"qty"`
+	LedgerQty   int    `json:"ledger_qty"`
+}
+
+type Warehouse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WarehouseStock is the stock of a product in a warehouse.
+type WarehouseStock struct {
+	WarehouseID int `json:"warehouse_id"`
+	ProductID   int `json:"product_id"`
+	Quantity    int `json:"qty"`
+}
+
+type WarehousePayload struct {
+	Name      string  `json:"name" validate:"required,max=255"`
+	Address   string  `json:"address" validate:"required"`
+	Latitude  float64 `json:"latitude" validate:"gte=-90,lte=90"`
+	Longitude float64 `json:"longitude" validate:"gte=-180,lte=180"`
+}
+
+type StockTransferPayload struct {
+	FromWarehouseID int    `json:"from_warehouse_id" validate:"required"`
+	ToWarehouseID   int    `json:"to_warehouse_id" validate:"required,nefield=FromWarehouseID"`
+	Quantity        int    `json:"qty" validate:"required,gt=0"`
+	Reference       string `json:"reference" validate:"max=255"`
+}
+
+// StockLevel is the stock of a product with its low-stock threshold and the
+// units sold over the window of a report.
+type StockLevel struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"qty"`
+	Threshold int    `json:"threshold"`
+	Sold      int    `json:"sold"`
+}
+
+type ReorderSuggestion struct {
+	StockLevel
+	DailySales float64 `json:"daily_sales"`
+	ReorderQty int     `json:"reorder_qty"`
+}
+
+type StockThresholdPayload struct {
+	Threshold int `json:"threshold" validate:"gte=0"`
+}
+
+type StockReceiptPayload struct {
+	WarehouseID int    `json:"warehouse_id" validate:"required"`
+	Quantity    int    `json:"qty" validate:"required,gt=0"`
+	Reason      string `json:"reason"`
+	Reference   string `json:"reference" validate:"required,max=255"`
+}
+
+type StockCountPayload struct {
+	WarehouseID int    `json:"warehouse_id" validate:"required"`
+	Quantity    int    `json:"qty" validate:"gte=0"`
+	Reference   string `json:"reference" validate:"max=255"`
+}
+
+type StockStore interface {
+	RecordStockMovement(StockMovement) (int64, error)
+	CountStock(warehouseID int, productID int, qty int, reference string, actorID int) (*StockMovement, error)
+	GetStockMovements(productID int) ([]StockMovement, error)
+	GetStockDrift() ([]StockDrift, error)
+	SetStockThreshold(productID int, threshold int) error
+	GetStockLevels(since time.Time) ([]StockLevel, error)
+	TransferStock(productID int, fromWarehouseID int, toWarehouseID int, qty int, reference string, actorID int) error
+	GetWarehouses() ([]Warehouse, error)
+	CreateWarehouse(Warehouse) (int64, error)
+	GetWarehouseStock(warehouseID int) ([]WarehouseStock, error)
+	GetStockByProductIDs(productIDs []int) ([]WarehouseStock, error)
+}
+
+// ProductImportRow is a product of a catalogue import or export, the rows are
+// upserted by SKU.
+type ProductImportRow struct {
+	SKU         string  `json:"sku" validate:"required,max=64"`
+	Name        string  `json:"name" validate:"required,max=255"`
+	Description string  `json:"description"`
+	Merchant    string  `json:"merchant" validate:"max=255"`
+	Category    string  `json:"category" validate:"max=255"`
+	Currency    string  `json:"currency" validate:"required,max=255"`
+	Image       string  `json:"image" validate:"max=255"`
+	Price       float64 `json:"price" validate:"gte=0"`
+	Quantity    int     `json:"qty" validate:"gte=0"`
+}
+
+// ProductImportError is a row of an import that was skipped, Row is the line
+// of a CSV file, header included, or the index of a JSON array from 1.
+type ProductImportError struct {
+	Row     int    `json:"row"`
+	SKU     string `json:"sku,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type ProductImportResult struct {
+	DryRun  bool                 `json:"dry_run"`
+	Total   int                  `json:"total"`
+	Created int                  `json:"created"`
+	Updated int                  `json:"updated"`
+	Errors  []ProductImportError `json:"errors"`
+}
+
+const (
+	ImportPending = "pending"
+	ImportRunning = "running"
+	ImportDone    = "done"
+	// ImportStopped is a job the server stopped before its last row, the
+	// result has the rows applied until then.
+	ImportStopped = "stopped"
+)
+
+// ProductImportJob is an import run in the background, Processed counts the
+// rows done so far.
+type ProductImportJob struct {
+	ID         string               `json:"id"`
+	Status     string               `json:"status"`
+	Total      int                  `json:"total"`
+	Processed  int                  `json:"processed"`
+	Result     *ProductImportResult `json:"result,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+}
+
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewHidden   = "hidden"
+)
+
+// Review is the rating of a customer who bought the product, it is only shown
+// and counted in the rating of the product once approved.
+type Review struct {
+	ID        int        `json:"id"`
+	ProductID int        `json:"product_id"`
+	UserID    int        `json:"user_id"`
+	Rating    int        `json:"rating"`
+	Body      string     `json:"body"`
+	Photos    []string   `json:"photos"`
+	Status    string     `json:"status"`
+	Reply     string     `json:"reply,omitempty"`
+	RepliedAt *time.Time `json:"replied_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ReviewPayload struct {
+	ProductID int      `json:"product_id" validate:"required"`
+	Rating    int      `json:"rating" validate:"required,min=1,max=5"`
+	Body      string   `json:"body" validate:"required,max=2000"`
+	Photos    []string `json:"photos" validate:"max=5,dive,url"`
+}
+
+type ReviewStatusPayload struct {
+	Status string `json:"status" validate:"required,oneof=approved hidden"`
+}
+
+type ReviewReplyPayload struct {
+	Reply string `json:"reply" validate:"required,max=2000"`
+}
+
+type ReviewFilter struct {
+	ProductID int
+	UserID    int
+	Status    string
+	Limit     int
+	Offset    int
+}
+
+type ReviewStore interface {
+	CreateReview(Review) (int64, error)
+	HasPurchased(userID int, productID int) (bool, error)
+	GetReviews(ReviewFilter) ([]Review, error)
+	GetReviewByID(int) (*Review, error)
+	SetReviewStatus(id int, status string) error
+	ReplyToReview(id int, reply string, at time.Time) error
+}
+
+// WishlistItem is a product saved by a customer with its current price and
+// stock.
+type WishlistItem struct {
+	ProductID int       `json:"product_id"`
+	Name      string    `json:"name"`
+	Currency  string    `json:"currency"`
+	Image     string    `json:"image"`
+	Price     float64   `json:"price"`
+	Quantity  int       `json:"qty"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+type WishlistStore interface {
+	GetWishlist(userID int) ([]WishlistItem, error)
+	AddToWishlist(userID int, productID int) error
+	RemoveFromWishlist(userID int, productID int) (int64, error)
+}
+
+// RestockSubscription asks to be mailed once a sold out product is back in
+// stock, it is mailed once and forgotten at ExpiresAt.
+type RestockSubscription struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	ProductID  int        `json:"product_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+}
+
+// RestockNotice is a subscription whose product is back in stock, with what
+// the mail needs.
+type RestockNotice struct {
+	SubscriptionID int
+	Email          string
+	FirstName      string
+	ProductID      int
+	ProductName    string
+}
+
+type RestockStore interface {
+	SubscribeRestock(userID int, productID int, expiresAt time.Time) error
+	UnsubscribeRestock(userID int, productID int) (int64, error)
+	GetRestockSubscriptions(userID int) ([]RestockSubscription, error)
+	GetDueRestockNotices(now time.Time, limit int) ([]RestockNotice, error)
+	ClaimRestockNotice(subscriptionID int, at time.Time) (bool, error)
+	ExpireRestockSubscriptions(now time.Time) (int64, error)
+}
+
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed"
+)
+
+// OutboxMail is a rendered mail waiting in the outbox, it is retried until
+// it is sent or failed after MAIL_MAX_ATTEMPTS.
+type OutboxMail struct {
+	ID            int        `json:"id"`
+	Template      string     `json:"template"`
+	To            []string   `json:"to"`
+	Subject       string     `json:"subject"`
+	Text          string     `json:"-"`
+	HTML          string     `json:"-"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	FailedAt      *time.Time `json:"failed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type OutboxFilter struct {
+	Status   string
+	Template string
+	Limit    int
+	Offset   int
+}
+
+type MailOutboxStore interface {
+	CreateOutboxMail(OutboxMail) (int64, error)
+	GetOutboxMails(OutboxFilter) ([]OutboxMail, error)
+	GetDueOutboxMails(now time.Time, limit int) ([]OutboxMail, error)
+	ClaimOutboxMail(id int, now time.Time, until time.Time) (bool, error)
+	MarkOutboxMailSent(id int, at time.Time) error
+	MarkOutboxMailFailed(id int, attempts int, lastError string, nextAttemptAt *time.Time, at time.Time) error
+	RetryOutboxMail(id int, at time.Time) (int64, error)
+}
+
+const (
+	NotificationOrderCreated    = "order_created"
+	NotificationOrderPaid       = "order_paid"
+	NotificationOrderShipped    = "order_shipped"
+	NotificationNewOrder        = "new_order"
+	NotificationPaymentReceived = "payment_received"
+)
+
+// Notification is an entry of the in-app inbox of a user, it is also
+// streamed live to the open pages of the user.
+type Notification struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Kind      string     `json:"kind"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Link      string     `json:"link,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationInbox is a page of the inbox of a user with the count of the
+// unread notifications.
+type NotificationInbox struct {
+	Unread        int            `json:"unread"`
+	Notifications []Notification `json:"notifications"`
+}
+
+type NotificationFilter struct {
+	Unread  bool
+	AfterID int
+	Limit   int
+	Offset  int
+}
+
+type NotificationStore interface {
+	CreateNotification(Notification) (int64, error)
+	GetNotifications(userID int, filter NotificationFilter) ([]Notification, error)
+	CountUnreadNotifications(userID int) (int, error)
+	MarkNotificationRead(id int, userID int, at time.Time) (int64, error)
+	MarkAllNotificationsRead(userID int, at time.Time) (int64, error)
+	GetUserIDsByRole(role string) ([]int, error)
+}
+
+// DomainEvent is a change of a product or an order in the event log, the ID
+// is the cursor the streams resume after.
+type DomainEvent struct {
+	ID        int64     `json:"id"`
+	Topic     string    `json:"topic"`
+	Kind      string    `json:"kind"`
+	EntityID  int       `json:"entity_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type EventStore interface {
+	CreateEvent(DomainEvent) (int64, error)
+	GetEvents(topic string, afterID int64, limit int) ([]DomainEvent, error)
+	GetLastEventID(topic string) (int64, error)
+}
+
+// ProductPage is a page of the catalogue streamed in the order of the IDs,
+// Cursor resumes the stream after it and WatchCursor watches the changes made
+// since the stream began.
+type ProductPage struct {
+	Products    []Product `json:"products"`
+	Cursor      int       `json:"cursor"`
+	WatchCursor int64     `json:"watch_cursor"`
+}
+
+// ProductEvent is a change of the catalogue with the product as it is now, a
+// deleted product only has its ID.
+type ProductEvent struct {
+	Cursor  int64   `json:"cursor"`
+	Kind    string  `json:"kind"`
+	Product Product `json:"product"`
+}
+
+// OrderEvent is a new or changed order with the order as it is now, a
+// deleted order only has its ID.
+type OrderEvent struct {
+	Cursor int64  `json:"cursor"`
+	Kind   string `json:"kind"`
+	Order  Order  `json:"order"`
+}
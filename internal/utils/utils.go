@@ -1,14 +1,22 @@
 package utils
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/fayleenpc/tj-jeans/internal/config"
 	"github.com/go-playground/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 var Validate = validator.New()
@@ -20,32 +28,6 @@ func ParseJSON(r *http.Request, payload any) error {
 	return json.NewDecoder(r.Body).Decode(payload)
 }
 
-func CraftJSON(method string, url string, m []byte, r *http.Request) ([]byte, int, error) {
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(m))
-	if err != nil {
-		return nil, http.StatusBadRequest, err
-	}
-	// req = req.WithContext(r.Context())
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-
-	defer req.Body.Close()
-	log.Printf("who's url [%v]\n", url)
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-
-		return nil, http.StatusBadRequest, err
-	}
-	defer res.Body.Close()
-
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	log.Printf("response body : %s\n", resBody)
-	return resBody, res.StatusCode, nil
-}
-
 func WriteJSON(w http.ResponseWriter, status int, v any) error {
 	w.Header().Add("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -56,6 +38,135 @@ func WriteError(w http.ResponseWriter, status int, err error) {
 	WriteJSON(w, status, map[string]string{"error": err.Error()})
 }
 
+// RetryAfterError is a ResourceExhausted status telling the client when to
+// try again, the REST transports answer it with a Retry-After header.
+func RetryAfterError(message string, retryAfter time.Duration) error {
+	s := status.New(codes.ResourceExhausted, message)
+	if detailed, err := s.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		s = detailed
+	}
+	return s.Err()
+}
+
+// WriteStatusError writes an error returned by a service with the HTTP status
+// of its code, errors without a code are internal errors. A denied permission
+// is a 401 like the rest of the API.
+func WriteStatusError(w http.ResponseWriter, err error) {
+	s := status.Convert(err)
+	SetRetryAfter(w, s)
+	WriteError(w, HTTPStatusFromCode(s.Code()), fmt.Errorf("%s", s.Message()))
+}
+
+// HTTPStatusFromCode is the HTTP status the REST transports answer a code
+// with.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return http.StatusUnauthorized
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// SetRetryAfter sets the Retry-After header from the RetryInfo of s, if any.
+func SetRetryAfter(w http.ResponseWriter, s *status.Status) {
+	for _, detail := range s.Details() {
+		if retry, ok := detail.(*errdetails.RetryInfo); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retry.GetRetryDelay().AsDuration().Seconds())+1))
+		}
+	}
+}
+
 // func AuthMiddlewareChain(middlewares ...http.HandlerFunc) {
 
 // }
+
+var (
+	trustedProxiesOnce sync.Once
+	trustedProxies     []*net.IPNet
+)
+
+// ParseTrustedProxies parses a comma separated list of addresses or CIDRs.
+func ParseTrustedProxies(list string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, v := range strings.Split(list, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			if strings.Contains(v, ":") {
+				v += "/128"
+			} else {
+				v += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			log.Printf("invalid trusted proxy %v: %v", v, err)
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+func isTrustedProxy(ip string, proxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range proxies {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client that sent the request, the
+// X-Forwarded-For header is only honoured when set by a trusted proxy.
+func ClientIP(r *http.Request) string {
+	trustedProxiesOnce.Do(func() {
+		trustedProxies = ParseTrustedProxies(config.Envs.TrustedProxies)
+	})
+	return ClientIPWithProxies(r.RemoteAddr, r.Header.Values("X-Forwarded-For"), trustedProxies)
+}
+
+// ClientIPWithProxies walks the forwarded addresses from the closest hop and
+// returns the first one that isn't a trusted proxy.
+func ClientIPWithProxies(remoteAddr string, forwardedFor []string, proxies []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		ip = remoteAddr
+	}
+	if !isTrustedProxy(ip, proxies) {
+		return ip
+	}
+	var hops []string
+	for _, header := range forwardedFor {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !isTrustedProxy(hop, proxies) {
+			break
+		}
+	}
+	return ip
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+// GetAuditLogs is a page of the audit log for the admins, newest first. It is
+// only served by the REST API.
+func (c *Client) GetAuditLogs(ctx context.Context, filter types.AuditLogFilter) ([]types.AuditLog, error) {
+	query := url.Values{}
+	if filter.ActorID > 0 {
+		query.Set("actor_id", strconv.Itoa(filter.ActorID))
+	}
+	if filter.Action != "" {
+		query.Set("action", filter.Action)
+	}
+	if filter.Entity != "" {
+		query.Set("entity", filter.Entity)
+	}
+	if filter.EntityID > 0 {
+		query.Set("entity_id", strconv.Itoa(filter.EntityID))
+	}
+	if filter.RequestID != "" {
+		query.Set("request_id", filter.RequestID)
+	}
+	if filter.From != nil {
+		query.Set("from", filter.From.Format(time.RFC3339))
+	}
+	if filter.To != nil {
+		query.Set("to", filter.To.Format(time.RFC3339))
+	}
+	addPage(query, filter.Limit, filter.Offset)
+	var entries []types.AuditLog
+	return entries, c.rest(ctx, http.MethodGet, "/audit_logs", query, nil, &entries)
+}
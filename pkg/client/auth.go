@@ -0,0 +1,281 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Login checks the credentials, with two-factor authentication the tokens are
+// only given by LoginMFA. The caller makes a Session of the tokens.
+func (c *Client) Login(ctx context.Context, payload types.LoginUserPayload) (*types.ResponseLogin, error) {
+	ctx = anonymous(ctx)
+	if c.rpc == nil {
+		var response types.ResponseLogin
+		return &response, c.rest(ctx, http.MethodPost, "/login", nil, payload, &response)
+	}
+	var res *pb.LoginResponse
+	err := c.send(ctx, func(ctx context.Context) (err error) {
+		res, err = c.auth.Login(ctx, &pb.LoginRequest{Email: payload.Email, Password: payload.Password, Locale: locale(ctx)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.ResponseLogin{
+		AccessToken:          res.GetAccessToken(),
+		SecretToken:          res.GetSecretToken(),
+		MFARequired:          res.GetMfaRequired(),
+		MFAEnrolmentRequired: res.GetMfaEnrolmentRequired(),
+		MFAToken:             res.GetMfaToken(),
+	}, nil
+}
+
+// LoginMFA ends a login with the code of the authenticator or a recovery
+// code.
+func (c *Client) LoginMFA(ctx context.Context, payload types.LoginMFAPayload) (*types.ResponseLoginMFA, error) {
+	ctx = anonymous(ctx)
+	if c.rpc == nil {
+		var response types.ResponseLoginMFA
+		return &response, c.rest(ctx, http.MethodPost, "/login/mfa", nil, payload, &response)
+	}
+	var res *pb.LoginMFAResponse
+	err := c.send(ctx, func(ctx context.Context) (err error) {
+		res, err = c.auth.LoginMFA(ctx, &pb.LoginMFARequest{MfaToken: payload.MFAToken, Code: payload.Code, Locale: locale(ctx)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.ResponseLoginMFA{
+		AccessToken:   res.GetAccessToken(),
+		SecretToken:   res.GetSecretToken(),
+		RecoveryCodes: res.GetRecoveryCodes(),
+	}, nil
+}
+
+// LoginMFAEnroll starts the enrolment an admin must finish before its first
+// login.
+func (c *Client) LoginMFAEnroll(ctx context.Context, payload types.MFAPendingPayload) (*types.ResponseMFAEnrolment, error) {
+	var response types.ResponseMFAEnrolment
+	return &response, c.rest(anonymous(ctx), http.MethodPost, "/login/mfa/enroll", nil, payload, &response)
+}
+
+// Refresh gives a new access token for the secret token, the old one is
+// blacklisted. The sessions are refreshed by the Client, this is for the
+// callers keeping their tokens themselves.
+func (c *Client) Refresh(ctx context.Context, access string, secret string) (*types.RefreshTokenPayload, error) {
+	ctx = anonymous(ctx)
+	if c.rpc == nil {
+		var response types.RefreshTokenPayload
+		return &response, c.rest(ctx, http.MethodPost, "/refresh", nil, types.RefreshTokenPayload{AccessToken: access, SecretToken: secret}, &response)
+	}
+	var res *pb.RefreshResponse
+	err := c.send(ctx, func(ctx context.Context) (err error) {
+		res, err = c.auth.Refresh(ctx, &pb.RefreshRequest{AccessToken: access, SecretToken: secret})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.RefreshTokenPayload{AccessToken: res.GetAccessToken(), SecretToken: res.GetSecretToken()}, nil
+}
+
+// Logout blacklists both tokens of the session of ctx.
+func (c *Client) Logout(ctx context.Context) error {
+	s := c.session(ctx)
+	if s == nil {
+		return status.Error(codes.Unauthenticated, "there is no session to logout")
+	}
+	if c.rpc == nil {
+		return c.rest(ctx, http.MethodPost, "/logout", nil, nil, nil)
+	}
+	return c.send(ctx, func(ctx context.Context) error {
+		// the tokens of the session may have been refreshed on the way
+		access, secret := s.Tokens()
+		_, err := c.auth.Logout(ctx, &pb.LogoutRequest{AccessToken: access, SecretToken: secret})
+		return err
+	})
+}
+
+// Register creates an unverified customer, the link to verify it is mailed.
+func (c *Client) Register(ctx context.Context, payload types.RegisterUserPayload) (*types.ResponseRegister, error) {
+	var response types.ResponseRegister
+	return &response, c.rest(anonymous(ctx), http.MethodPost, "/register", nil, payload, &response)
+}
+
+// Verify verifies the email of the token of the mailed link.
+func (c *Client) Verify(ctx context.Context, token string) (string, error) {
+	var message string
+	return message, c.rest(anonymous(ctx), http.MethodGet, "/verify", url.Values{"token": {token}}, nil, &message)
+}
+
+func (c *Client) ResendVerification(ctx context.Context, payload types.ResendVerificationPayload) (*types.ResponsePassword, error) {
+	var response types.ResponsePassword
+	return &response, c.rest(anonymous(ctx), http.MethodPost, "/verify/resend", nil, payload, &response)
+}
+
+// UnlockLogin lifts the lockout of the token of the mailed link.
+func (c *Client) UnlockLogin(ctx context.Context, token string) (string, error) {
+	var message string
+	return message, c.rest(anonymous(ctx), http.MethodGet, "/login/unlock", url.Values{"token": {token}}, nil, &message)
+}
+
+func (c *Client) ForgotPassword(ctx context.Context, payload types.ForgotPasswordPayload) (*types.ResponsePassword, error) {
+	var response types.ResponsePassword
+	return &response, c.rest(anonymous(ctx), http.MethodPost, "/password/forgot", nil, payload, &response)
+}
+
+func (c *Client) ResetPassword(ctx context.Context, payload types.ResetPasswordPayload) (*types.ResponsePassword, error) {
+	var response types.ResponsePassword
+	return &response, c.rest(anonymous(ctx), http.MethodPost, "/password/reset", nil, payload, &response)
+}
+
+// ChangePassword revokes every session of the user but this one, which is
+// given new tokens.
+func (c *Client) ChangePassword(ctx context.Context, payload types.ChangePasswordPayload) (*types.ResponsePassword, error) {
+	var response types.ResponsePassword
+	if err := c.rest(ctx, http.MethodPost, "/me/password", nil, payload, &response); err != nil {
+		return nil, err
+	}
+	if s := c.session(ctx); s != nil && response.AccessToken != "" && response.SecretToken != "" {
+		s.set(response.AccessToken, response.SecretToken)
+	}
+	return &response, nil
+}
+
+// EnrollMFA starts the enrolment of the signed in user, VerifyMFA enables it
+// with a first code.
+func (c *Client) EnrollMFA(ctx context.Context) (*types.ResponseMFAEnrolment, error) {
+	var response types.ResponseMFAEnrolment
+	return &response, c.rest(ctx, http.MethodPost, "/me/mfa/enroll", nil, nil, &response)
+}
+
+func (c *Client) VerifyMFA(ctx context.Context, payload types.MFACodePayload) (*types.ResponseRecoveryCodes, error) {
+	var response types.ResponseRecoveryCodes
+	return &response, c.rest(ctx, http.MethodPost, "/me/mfa/verify", nil, payload, &response)
+}
+
+func (c *Client) DisableMFA(ctx context.Context, payload types.MFACodePayload) error {
+	return c.rest(ctx, http.MethodPost, "/me/mfa/disable", nil, payload, nil)
+}
+
+func (c *Client) RegenerateRecoveryCodes(ctx context.Context, payload types.MFACodePayload) (*types.ResponseRecoveryCodes, error) {
+	var response types.ResponseRecoveryCodes
+	return &response, c.rest(ctx, http.MethodPost, "/me/mfa/recovery_codes", nil, payload, &response)
+}
+
+func (c *Client) OIDCProviders(ctx context.Context) (*types.ResponseOIDCProviders, error) {
+	var response types.ResponseOIDCProviders
+	return &response, c.rest(anonymous(ctx), http.MethodGet, "/login/oidc", nil, nil, &response)
+}
+
+// OIDCAuthorize starts a login with the provider, the flow token is given
+// back to OIDCCallback with the code of the provider.
+func (c *Client) OIDCAuthorize(ctx context.Context, provider string) (*types.ResponseOIDCAuthorization, error) {
+	var response types.ResponseOIDCAuthorization
+	return &response, c.rest(anonymous(ctx), http.MethodGet, "/login/oidc/"+url.PathEscape(provider), nil, nil, &response)
+}
+
+func (c *Client) OIDCCallback(ctx context.Context, provider string, payload types.OIDCCallbackPayload) (*types.ResponseLogin, error) {
+	var response types.ResponseLogin
+	return &response, c.rest(anonymous(ctx), http.MethodPost, "/login/oidc/"+url.PathEscape(provider)+"/callback", nil, payload, &response)
+}
+
+// CreateAPIKey gives the key once, only its prefix is kept by the API.
+func (c *Client) CreateAPIKey(ctx context.Context, payload types.CreateAPIKeyPayload) (*types.ResponseAPIKey, error) {
+	if c.rpc == nil {
+		var response types.ResponseAPIKey
+		return &response, c.rest(ctx, http.MethodPost, "/api_keys", nil, payload, &response)
+	}
+	var res *pb.CreateAPIKeyResponse
+	err := c.send(ctx, func(ctx context.Context) (err error) {
+		res, err = c.tokens.CreateAPIKey(ctx, &pb.CreateAPIKeyRequest{
+			UserId:    int32(payload.UserID),
+			Name:      payload.Name,
+			Scopes:    payload.Scopes,
+			ExpiresAt: timeToPB(payload.ExpiresAt),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	apiKey := apiKeyFromPB(res.GetApiKey())
+	return &types.ResponseAPIKey{APIKey: &apiKey, Key: res.GetKey()}, nil
+}
+
+func (c *Client) GetAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	if c.rpc == nil {
+		var keys []types.APIKey
+		return keys, c.rest(ctx, http.MethodGet, "/api_keys", nil, nil, &keys)
+	}
+	var res *pb.GetAPIKeysResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.tokens.GetAPIKeys(ctx, &pb.GetAPIKeysRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return apiKeysFromPB(res.GetApiKeys()), nil
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, id int) error {
+	if c.rpc == nil {
+		return c.rest(ctx, http.MethodDelete, "/api_keys/"+strconv.Itoa(id), nil, nil, nil)
+	}
+	return c.call(ctx, func(ctx context.Context) error {
+		_, err := c.tokens.RevokeAPIKey(ctx, &pb.RevokeAPIKeyRequest{Id: int32(id)})
+		return err
+	})
+}
+
+func (c *Client) GetBlacklistedTokens(ctx context.Context) ([]types.Token, error) {
+	if c.rpc == nil {
+		var tokens []types.Token
+		return tokens, c.rest(ctx, http.MethodGet, "/blacklisted_tokens", nil, nil, &tokens)
+	}
+	var res *pb.GetBlacklistedTokensResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.tokens.GetBlacklistedTokens(ctx, &pb.GetBlacklistedTokensRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokensFromPB(res.GetTokens()), nil
+}
+
+// BlacklistToken revokes a token, an admin only call of types.proto.
+func (c *Client) BlacklistToken(ctx context.Context, token string) error {
+	if c.rpc == nil {
+		return rpcOnly("BlacklistToken")
+	}
+	return c.call(ctx, func(ctx context.Context) error {
+		_, err := c.tokens.CreateBlacklistToken(ctx, &pb.CreateBlacklistTokenRequest{Token: &pb.Token{Token: token}})
+		return err
+	})
+}
+
+func (c *Client) GetBlacklistedToken(ctx context.Context, token string) (*types.Token, error) {
+	if c.rpc == nil {
+		return nil, rpcOnly("GetBlacklistedToken")
+	}
+	var res *pb.GetBlacklistTokenByStringResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.tokens.GetBlacklistTokenByString(ctx, &pb.GetBlacklistTokenByStringRequest{Token: token})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	t := tokenFromPB(res.GetToken())
+	return &t, nil
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+)
+
+// Checkout orders the items for the signed in user and returns the order with
+// its total. It is never sent twice, a checkout which got no answer may have
+// been placed.
+func (c *Client) Checkout(ctx context.Context, items []types.CartItem) (*types.ResponseCart, error) {
+	if c.rpc == nil {
+		var response types.ResponseCart
+		return &response, c.rest(ctx, http.MethodPost, "/cart/checkout", nil, types.CartCheckoutPayload{Items: items}, &response)
+	}
+	var res *pb.CheckoutResponse
+	err := c.send(ctx, func(ctx context.Context) (err error) {
+		res, err = c.checkout.Checkout(ctx, &pb.CheckoutRequest{Items: cartItemsToPB(items), Locale: locale(ctx)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.ResponseCart{
+		Total:   res.GetTotalPrice(),
+		OrderID: int(res.GetOrderId()),
+		Items:   productsFromPB(res.GetItems()),
+	}, nil
+}
+
+// CreateInvoice asks the payment gateway of the REST API for the invoice of
+// an order.
+func (c *Client) CreateInvoice(ctx context.Context, payload types.InvoicePayload) (*types.InvoiceResponse, error) {
+	var invoice types.InvoiceResponse
+	return &invoice, c.rest(ctx, http.MethodPost, "/payment/invoices", nil, payload, &invoice)
+}
@@ -0,0 +1,341 @@
+// Package client is the Go SDK of the API. A Client calls the services over
+// REST JSON, the protobuf gateway or gRPC with the same typed methods, keeps
+// the access token of its session fresh and tries again when the API asks it
+// to. Errors are gRPC statuses whatever the transport, like the services.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Transport is the way a Client talks to the API.
+type Transport string
+
+const (
+	// REST is the JSON API of cmd/api, e.g. http://localhost:8081.
+	REST Transport = "rest"
+	// Protobuf is the gateway of cmd/api_proto answering protobuf, e.g.
+	// http://localhost:8082.
+	Protobuf Transport = "protobuf"
+	// GRPC is the server of cmd/api_grpc, e.g. localhost:8083.
+	GRPC Transport = "grpc"
+)
+
+const (
+	defaultRetries    = 3
+	defaultBackoff    = time.Millisecond * 200
+	defaultMaxBackoff = time.Second * 5
+)
+
+type Options struct {
+	Transport Transport
+	// Address is the base URL of the REST API or of the gateway, or the
+	// target of the gRPC server.
+	Address string
+	// RESTAddress is the base URL of the REST API for the routes types.proto
+	// doesn't have, like the inventory or the reviews. It is Address with
+	// REST, without it those methods fail with Unimplemented.
+	RESTAddress string
+	// HTTPClient sends the requests of REST and Protobuf, http.DefaultClient
+	// by default.
+	HTTPClient *http.Client
+	// DialOptions of GRPC, an insecure connection by default.
+	DialOptions []grpc.DialOption
+	// APIKey authenticates the calls made without a session.
+	APIKey string
+	// Session is used by the calls whose context has none, see WithSession.
+	Session *Session
+	// Retries is how many times a call is tried again, 3 by default and none
+	// when negative. Backoff is the wait before the first retry, it doubles
+	// up to MaxBackoff. A Retry-After of the API longer than MaxBackoff is
+	// returned to the caller instead.
+	Retries    int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Client is safe for concurrent use, it is made once and shared.
+type Client struct {
+	opts Options
+	http *http.Client
+	// conn is the connection of GRPC, closed by Close
+	conn *grpc.ClientConn
+	// rpc carries the calls of the service clients, nil with REST
+	rpc grpc.ClientConnInterface
+
+	users      pb.UserServiceClient
+	products   pb.ProductServiceClient
+	orders     pb.OrderServiceClient
+	orderItems pb.OrderItemServiceClient
+	tokens     pb.TokenServiceClient
+	checkout   pb.CheckoutServiceClient
+	auth       pb.AuthServiceClient
+	finance    pb.FinanceServiceClient
+}
+
+func New(opts Options) (*Client, error) {
+	if opts.Transport == "" {
+		opts.Transport = REST
+	}
+	if opts.Retries == 0 {
+		opts.Retries = defaultRetries
+	} else if opts.Retries < 0 {
+		opts.Retries = 0
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaultBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+	c := &Client{http: opts.HTTPClient}
+	if c.http == nil {
+		c.http = http.DefaultClient
+	}
+
+	switch opts.Transport {
+	case REST:
+		opts.Address = strings.TrimSuffix(opts.Address, "/")
+		if opts.RESTAddress == "" {
+			opts.RESTAddress = opts.Address
+		}
+	case Protobuf:
+		opts.Address = strings.TrimSuffix(opts.Address, "/")
+		c.rpc = &gatewayConn{address: opts.Address, http: c.http}
+	case GRPC:
+		dialOptions := opts.DialOptions
+		if len(dialOptions) == 0 {
+			dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+		}
+		conn, err := grpc.NewClient(opts.Address, dialOptions...)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+		c.rpc = conn
+	default:
+		return nil, fmt.Errorf("unknown transport %q", opts.Transport)
+	}
+	opts.RESTAddress = strings.TrimSuffix(opts.RESTAddress, "/")
+	c.opts = opts
+
+	if c.rpc != nil {
+		c.users = pb.NewUserServiceClient(c.rpc)
+		c.products = pb.NewProductServiceClient(c.rpc)
+		c.orders = pb.NewOrderServiceClient(c.rpc)
+		c.orderItems = pb.NewOrderItemServiceClient(c.rpc)
+		c.tokens = pb.NewTokenServiceClient(c.rpc)
+		c.checkout = pb.NewCheckoutServiceClient(c.rpc)
+		c.auth = pb.NewAuthServiceClient(c.rpc)
+		c.finance = pb.NewFinanceServiceClient(c.rpc)
+	}
+	return c, nil
+}
+
+func (c *Client) Transport() Transport {
+	return c.opts.Transport
+}
+
+// Close closes the connection of GRPC, the HTTP client is the caller's.
+func (c *Client) Close() error {
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+type contextKey int
+
+const (
+	sessionKey contextKey = iota
+	anonymousKey
+	clientIPKey
+	localeKey
+)
+
+// WithSession makes the calls of ctx on behalf of the session instead of the
+// one of the options.
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey, s)
+}
+
+// WithClientIP sends the address of the end user as X-Forwarded-For, for a
+// server like the web one calling on behalf of its visitors. The API only
+// trusts it from its trusted proxies.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// WithLocale sends the language of the end user as Accept-Language, the mails
+// and the messages of the API are in it.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey, locale)
+}
+
+// anonymous makes the calls of ctx without the session nor the API key, for
+// the logins and the refresh which carry their own credentials.
+func anonymous(ctx context.Context) context.Context {
+	return context.WithValue(ctx, anonymousKey, true)
+}
+
+func locale(ctx context.Context) string {
+	l, _ := ctx.Value(localeKey).(string)
+	return l
+}
+
+func (c *Client) session(ctx context.Context) *Session {
+	if a, _ := ctx.Value(anonymousKey).(bool); a {
+		return nil
+	}
+	if s, ok := ctx.Value(sessionKey).(*Session); ok {
+		return s
+	}
+	return c.opts.Session
+}
+
+// outgoing adds the credentials and the end user of ctx to the metadata the
+// caller may have set, e.g. an x-request-id. Every transport sends them from
+// there.
+func (c *Client) outgoing(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	if s := c.session(ctx); s != nil {
+		access, secret := s.Tokens()
+		md.Set("authorization", access)
+		md.Set("authorization-x", secret)
+	} else if a, _ := ctx.Value(anonymousKey).(bool); !a && c.opts.APIKey != "" {
+		md.Set("x-api-key", c.opts.APIKey)
+	}
+	if ip, _ := ctx.Value(clientIPKey).(string); ip != "" {
+		md.Set("x-forwarded-for", ip)
+	}
+	if l := locale(ctx); l != "" {
+		md.Set("accept-language", l)
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// call runs a call which can be sent twice, send one which must not, e.g. a
+// checkout. Only an Unavailable call is retried.
+func (c *Client) call(ctx context.Context, call func(ctx context.Context) error) error {
+	return c.do(ctx, true, call)
+}
+
+func (c *Client) send(ctx context.Context, call func(ctx context.Context) error) error {
+	return c.do(ctx, false, call)
+}
+
+func (c *Client) do(ctx context.Context, idempotent bool, call func(ctx context.Context) error) error {
+	return c.retry(ctx, idempotent, func(ctx context.Context, _ func()) error {
+		return call(ctx)
+	})
+}
+
+// retry runs call until it succeeds or fails for good. A stream reports its
+// progress so the retries of a watch start over once it moved on.
+func (c *Client) retry(ctx context.Context, idempotent bool, call func(ctx context.Context, progress func()) error) error {
+	for attempt := 0; ; attempt++ {
+		progressed := false
+		err := c.authorized(ctx, func(ctx context.Context) error {
+			return call(ctx, func() { progressed = true })
+		})
+		if err == nil {
+			return nil
+		}
+		var stop *stopError
+		if errors.As(err, &stop) {
+			return stop.err
+		}
+		if progressed {
+			attempt = 0
+		}
+		delay, ok := c.retryDelay(err, idempotent, attempt)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay is the wait before the next attempt. The API is asked again when
+// it was busy, a ResourceExhausted is rejected before anything is done, and
+// when it was unreachable if the call can be sent twice.
+func (c *Client) retryDelay(err error, idempotent bool, attempt int) (time.Duration, bool) {
+	if attempt >= c.opts.Retries {
+		return 0, false
+	}
+	switch status.Code(err) {
+	case codes.ResourceExhausted:
+	case codes.Unavailable:
+		if !idempotent {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	delay := c.opts.Backoff << attempt
+	if delay <= 0 || delay > c.opts.MaxBackoff {
+		delay = c.opts.MaxBackoff
+	}
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			after := info.GetRetryDelay().AsDuration()
+			if after > c.opts.MaxBackoff {
+				return 0, false
+			}
+			if after > delay {
+				delay = after
+			}
+		}
+	}
+	return delay, true
+}
+
+// stopError is an error of the caller, e.g. of the send of a stream, it is
+// returned as it is without a retry.
+type stopError struct {
+	err error
+}
+
+func (e *stopError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stopError) Unwrap() error {
+	return e.err
+}
+
+func stop(err error) error {
+	return &stopError{err: err}
+}
+
+// restOnly is the error of the methods of the REST API when it has no
+// address.
+func restOnly(method string) error {
+	return status.Errorf(codes.Unimplemented, "%s is only served by the REST API, set Options.RESTAddress", method)
+}
+
+// rpcOnly is the error of the methods of types.proto the REST API doesn't
+// have.
+func rpcOnly(method string) error {
+	return status.Errorf(codes.Unimplemented, "%s is only served by the Protobuf and GRPC transports", method)
+}
@@ -0,0 +1,357 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	api_proto "github.com/fayleenpc/tj-jeans/cmd/api_proto"
+	"github.com/fayleenpc/tj-jeans/internal/gateway"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestREST(t *testing.T) {
+	t.Run("should retry a read the API couldn't answer", func(t *testing.T) {
+		var hits atomic.Int32
+		c := newRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if hits.Add(1) < 3 {
+				writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "try again"})
+				return
+			}
+			writeJSON(w, http.StatusOK, types.Product{ID: 1, Name: "jeans"})
+		})
+
+		product, err := c.GetProductByID(context.Background(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if product.Name != "jeans" {
+			t.Errorf("expected jeans, got %v", product.Name)
+		}
+		if hits.Load() != 3 {
+			t.Errorf("expected 3 requests, got %v", hits.Load())
+		}
+	})
+
+	t.Run("should not send a checkout twice", func(t *testing.T) {
+		var hits atomic.Int32
+		c := newRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "try again"})
+		})
+
+		_, err := c.Checkout(context.Background(), []types.CartItem{{ProductID: 1, Quantity: 1}})
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("expected %v, got %v", codes.Unavailable, err)
+		}
+		if hits.Load() != 1 {
+			t.Errorf("expected 1 request, got %v", hits.Load())
+		}
+	})
+
+	t.Run("should give the error of the API as a status", func(t *testing.T) {
+		c := newRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "60")
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "too many requests"})
+		})
+
+		_, err := c.GetProducts(context.Background())
+		s := status.Convert(err)
+		if s.Code() != codes.ResourceExhausted || s.Message() != "too many requests" {
+			t.Fatalf("expected %v too many requests, got %v", codes.ResourceExhausted, err)
+		}
+		if len(s.Details()) != 1 || s.Details()[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration() != time.Minute {
+			t.Errorf("expected a retry in a minute, got %v", s.Details())
+		}
+	})
+
+	t.Run("should refresh an expiring access token before the call", func(t *testing.T) {
+		expiring, fresh := newAccessToken(t, time.Second), newAccessToken(t, time.Hour)
+		c := newRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v1/refresh":
+				var payload types.RefreshTokenPayload
+				json.NewDecoder(r.Body).Decode(&payload)
+				if payload.AccessToken != expiring || payload.SecretToken != "secret" {
+					writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+					return
+				}
+				writeJSON(w, http.StatusOK, types.RefreshTokenPayload{AccessToken: fresh})
+			default:
+				if r.Header.Get("Authorization") != fresh {
+					writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+					return
+				}
+				writeJSON(w, http.StatusOK, types.User{ID: 1})
+			}
+		})
+
+		var refreshed string
+		s := NewSession(expiring, "secret", func(access string, secret string) { refreshed = access })
+		if _, err := c.GetMe(WithSession(context.Background(), s)); err != nil {
+			t.Fatal(err)
+		}
+		if refreshed != fresh {
+			t.Errorf("expected the refreshed token to be reported")
+		}
+	})
+
+	t.Run("should not refresh a fresh access token the API denies", func(t *testing.T) {
+		var refreshes atomic.Int32
+		c := newRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/v1/refresh" {
+				refreshes.Add(1)
+			}
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "permission denied"})
+		})
+
+		s := NewSession(newAccessToken(t, time.Hour), "secret", nil)
+		_, err := c.GetUsers(WithSession(context.Background(), s))
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("expected %v, got %v", codes.PermissionDenied, err)
+		}
+		if refreshes.Load() != 0 {
+			t.Errorf("expected no refresh, got %v", refreshes.Load())
+		}
+	})
+
+	t.Run("should fail the methods of types.proto only", func(t *testing.T) {
+		c := newRESTClient(t, func(w http.ResponseWriter, r *http.Request) {})
+
+		if _, err := c.GetProductsByIDs(context.Background(), []int{1}); status.Code(err) != codes.Unimplemented {
+			t.Errorf("expected %v, got %v", codes.Unimplemented, err)
+		}
+	})
+}
+
+// TestRPC runs the same calls over GRPC and over the gateway of Protobuf.
+func TestRPC(t *testing.T) {
+	for _, transport := range []Transport{GRPC, Protobuf} {
+		t.Run(string(transport), func(t *testing.T) {
+			t.Run("should send the session and decode the answer", func(t *testing.T) {
+				server := &productServer{}
+				c := newRPCClient(t, transport, server, &authServer{})
+
+				s := NewSession(newAccessToken(t, time.Hour), "secret", nil)
+				product, err := c.GetProductByID(WithSession(context.Background(), s), 7)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if product.ID != 7 || product.Name != "jeans" {
+					t.Errorf("expected product 7, got %+v", product)
+				}
+				if access, _ := s.Tokens(); server.lastAuthorization() != access {
+					t.Errorf("expected the access token to be sent, got %q", server.lastAuthorization())
+				}
+			})
+
+			t.Run("should give the error of the server as it is", func(t *testing.T) {
+				c := newRPCClient(t, transport, &productServer{}, &authServer{})
+
+				_, err := c.GetProductByID(context.Background(), 404)
+				if s := status.Convert(err); s.Code() != codes.NotFound || s.Message() != "product not found" {
+					t.Errorf("expected %v product not found, got %v", codes.NotFound, err)
+				}
+			})
+
+			t.Run("should refresh a rejected access token once", func(t *testing.T) {
+				auth := &authServer{fresh: newAccessToken(t, time.Hour)}
+				server := &productServer{want: auth.fresh}
+				c := newRPCClient(t, transport, server, auth)
+
+				var refreshed string
+				s := NewSession("opaque", "secret", func(access string, secret string) { refreshed = access })
+				if _, err := c.GetProductByID(WithSession(context.Background(), s), 7); err != nil {
+					t.Fatal(err)
+				}
+				if refreshed != auth.fresh {
+					t.Errorf("expected the refreshed token to be reported")
+				}
+				if server.lastAuthorization() != auth.fresh {
+					t.Errorf("expected the call to be sent again with the refreshed token")
+				}
+			})
+
+			t.Run("should resume a broken watch after the last event", func(t *testing.T) {
+				server := &productServer{breakAfter: 2}
+				c := newRPCClient(t, transport, server, &authServer{})
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				var cursors []int64
+				err := c.WatchProducts(ctx, 0, func(e types.ProductEvent) error {
+					cursors = append(cursors, e.Cursor)
+					if len(cursors) == 4 {
+						cancel()
+					}
+					return nil
+				})
+				if status.Code(err) != codes.Canceled {
+					t.Errorf("expected %v, got %v", codes.Canceled, err)
+				}
+				for i, cursor := range cursors {
+					if cursor != int64(i+1) {
+						t.Fatalf("expected the cursors 1 to 4, got %v", cursors)
+					}
+				}
+				if len(cursors) != 4 {
+					t.Errorf("expected 4 events, got %v", cursors)
+				}
+			})
+		})
+	}
+}
+
+func newRESTClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := New(Options{Transport: REST, Address: server.URL, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// newRPCClient serves the fakes on a gRPC server in memory, Protobuf reaches
+// it through the gateway of cmd/api_proto.
+func newRPCClient(t *testing.T, transport Transport, products pb.ProductServiceServer, auth pb.AuthServiceServer) *Client {
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	pb.RegisterProductServiceServer(grpcServer, products)
+	pb.RegisterAuthServiceServer(grpcServer, auth)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	dialOptions := []grpc.DialOption{
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	opts := Options{Transport: transport, Address: "passthrough:///bufnet", DialOptions: dialOptions, Backoff: time.Millisecond}
+	if transport == Protobuf {
+		conn, err := grpc.NewClient("passthrough:///bufnet", dialOptions...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		gatewayMux := gateway.NewServeMux()
+		if err := api_proto.RegisterServices(context.Background(), gatewayMux, conn); err != nil {
+			t.Fatal(err)
+		}
+		server := httptest.NewServer(gatewayMux)
+		t.Cleanup(server.Close)
+		opts = Options{Transport: Protobuf, Address: server.URL, Backoff: time.Millisecond}
+	}
+
+	c, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// newAccessToken is an access token expiring in ttl, the Client only reads
+// its claims.
+func newAccessToken(t *testing.T, ttl time.Duration) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"expiredAt": time.Now().Add(ttl).Unix(),
+		"nonce":     time.Now().UnixNano(),
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+type productServer struct {
+	pb.UnimplementedProductServiceServer
+	// want is the only access token accepted when set
+	want string
+	// breakAfter breaks the first watch after as many events
+	breakAfter int
+
+	mu            sync.Mutex
+	authorization string
+	watches       int
+}
+
+func (s *productServer) lastAuthorization() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authorization
+}
+
+func (s *productServer) GetProductByID(ctx context.Context, req *pb.GetProductByIDRequest) (*pb.GetProductByIDResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	authorization := ""
+	if values := md.Get("authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+	s.mu.Lock()
+	s.authorization = authorization
+	s.mu.Unlock()
+
+	if s.want != "" && authorization != s.want {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	if req.GetId() == 404 {
+		return nil, status.Error(codes.NotFound, "product not found")
+	}
+	return &pb.GetProductByIDResponse{Product: &pb.Product{Id: req.GetId(), Name: "jeans"}}, nil
+}
+
+func (s *productServer) WatchProducts(req *pb.WatchProductsRequest, stream grpc.ServerStreamingServer[pb.ProductEvent]) error {
+	s.mu.Lock()
+	s.watches++
+	first := s.watches == 1
+	s.mu.Unlock()
+
+	cursor := req.GetCursor()
+	for sent := 0; ; sent++ {
+		if first && s.breakAfter > 0 && sent == s.breakAfter {
+			return status.Error(codes.Unavailable, "the event log went away")
+		}
+		cursor++
+		if err := stream.Send(&pb.ProductEvent{Cursor: cursor, Kind: "updated", Product: &pb.Product{Id: 1}}); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+}
+
+type authServer struct {
+	pb.UnimplementedAuthServiceServer
+	fresh string
+}
+
+func (s *authServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.RefreshResponse, error) {
+	if s.fresh == "" || req.GetSecretToken() != "secret" {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return &pb.RefreshResponse{AccessToken: s.fresh}, nil
+}
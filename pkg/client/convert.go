@@ -0,0 +1,248 @@
+package client
+
+import (
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+)
+
+// The mappers are the reverse of the ones of the servers in
+// services/*/grpc.go, a timestamp of 0 is no time.
+
+func timeFromPB(unix int64) *time.Time {
+	if unix == 0 {
+		return nil
+	}
+	t := time.Unix(unix, 0)
+	return &t
+}
+
+func timeToPB(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+func idsToPB(ids []int) []int32 {
+	idsPB := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		idsPB = append(idsPB, int32(id))
+	}
+	return idsPB
+}
+
+func productFromPB(p *pb.Product) types.Product {
+	return types.Product{
+		ID:          int(p.GetId()),
+		SKU:         p.GetSku(),
+		Name:        p.GetName(),
+		Description: p.GetDescription(),
+		Merchant:    p.GetMerchant(),
+		Category:    p.GetCategory(),
+		Currency:    p.GetCurrency(),
+		Image:       p.GetImage(),
+		Price:       p.GetPrice(),
+		Quantity:    int(p.GetQuantity()),
+		Rating:      p.GetRating(),
+		ReviewCount: int(p.GetReviewCount()),
+		CreatedAt:   time.Unix(p.GetCreatedAt(), 0),
+		DeletedAt:   timeFromPB(p.GetDeletedAt()),
+	}
+}
+
+func productsFromPB(products []*pb.Product) []types.Product {
+	ps := make([]types.Product, 0, len(products))
+	for _, p := range products {
+		ps = append(ps, productFromPB(p))
+	}
+	return ps
+}
+
+// productToPB is the product of a request, the timestamps are the store's.
+func productToPB(p types.Product) *pb.Product {
+	return &pb.Product{
+		Id:          int32(p.ID),
+		Sku:         p.SKU,
+		Name:        p.Name,
+		Description: p.Description,
+		Merchant:    p.Merchant,
+		Category:    p.Category,
+		Currency:    p.Currency,
+		Image:       p.Image,
+		Price:       p.Price,
+		Quantity:    int32(p.Quantity),
+	}
+}
+
+func orderFromPB(o *pb.Order) types.Order {
+	return types.Order{
+		ID:          int(o.GetId()),
+		UserID:      int(o.GetUserId()),
+		Total:       o.GetTotal(),
+		Status:      o.GetStatus(),
+		Address:     o.GetAddress(),
+		PhoneNumber: o.GetPhoneNumber(),
+		CreatedAt:   time.Unix(o.GetCreatedAt(), 0),
+		DeletedAt:   timeFromPB(o.GetDeletedAt()),
+	}
+}
+
+func ordersFromPB(orders []*pb.Order) []types.Order {
+	os := make([]types.Order, 0, len(orders))
+	for _, o := range orders {
+		os = append(os, orderFromPB(o))
+	}
+	return os
+}
+
+func orderToPB(o types.Order) *pb.Order {
+	return &pb.Order{
+		Id:          int32(o.ID),
+		UserId:      int32(o.UserID),
+		Total:       o.Total,
+		Status:      o.Status,
+		Address:     o.Address,
+		PhoneNumber: o.PhoneNumber,
+	}
+}
+
+func orderItemFromPB(i *pb.OrderItem) types.OrderItem {
+	return types.OrderItem{
+		ID:          int(i.GetId()),
+		OrderID:     int(i.GetOrderId()),
+		ProductID:   int(i.GetProductId()),
+		Quantity:    int(i.GetQuantity()),
+		Price:       i.GetPrice(),
+		WarehouseID: int(i.GetWarehouseId()),
+	}
+}
+
+func orderItemsFromPB(orderItems []*pb.OrderItem) []types.OrderItem {
+	items := make([]types.OrderItem, 0, len(orderItems))
+	for _, i := range orderItems {
+		items = append(items, orderItemFromPB(i))
+	}
+	return items
+}
+
+func orderItemToPB(i types.OrderItem) *pb.OrderItem {
+	return &pb.OrderItem{
+		Id:          int32(i.ID),
+		OrderId:     int32(i.OrderID),
+		ProductId:   int32(i.ProductID),
+		Quantity:    int32(i.Quantity),
+		Price:       i.Price,
+		WarehouseId: int32(i.WarehouseID),
+	}
+}
+
+func userFromPB(u *pb.User) types.User {
+	return types.User{
+		ID:          int(u.GetId()),
+		FirstName:   u.GetFirstName(),
+		LastName:    u.GetLastName(),
+		Email:       u.GetEmail(),
+		Verified:    u.GetVerified(),
+		Role:        u.GetRole(),
+		PhoneNumber: u.GetPhoneNumber(),
+		Address:     u.GetAddress(),
+		CreatedAt:   time.Unix(u.GetCreatedAt(), 0),
+		DeletedAt:   timeFromPB(u.GetDeletedAt()),
+	}
+}
+
+func usersFromPB(users []*pb.User) []types.User {
+	us := make([]types.User, 0, len(users))
+	for _, u := range users {
+		us = append(us, userFromPB(u))
+	}
+	return us
+}
+
+// userToPB sends the password for CreateUser, the other calls ignore it.
+func userToPB(u types.User) *pb.User {
+	return &pb.User{
+		Id:          int32(u.ID),
+		FirstName:   u.FirstName,
+		LastName:    u.LastName,
+		Email:       u.Email,
+		Password:    u.Password,
+		Verified:    u.Verified,
+		Role:        u.Role,
+		PhoneNumber: u.PhoneNumber,
+		Address:     u.Address,
+	}
+}
+
+func tokenFromPB(t *pb.Token) types.Token {
+	return types.Token{
+		ID:        int(t.GetId()),
+		Token:     t.GetToken(),
+		CreatedAt: time.Unix(t.GetCreatedAt(), 0),
+	}
+}
+
+func tokensFromPB(tokens []*pb.Token) []types.Token {
+	ts := make([]types.Token, 0, len(tokens))
+	for _, t := range tokens {
+		ts = append(ts, tokenFromPB(t))
+	}
+	return ts
+}
+
+func apiKeyFromPB(k *pb.APIKey) types.APIKey {
+	return types.APIKey{
+		ID:         int(k.GetId()),
+		UserID:     int(k.GetUserId()),
+		Name:       k.GetName(),
+		Prefix:     k.GetPrefix(),
+		Scopes:     k.GetScopes(),
+		ExpiresAt:  timeFromPB(k.GetExpiresAt()),
+		LastUsedAt: timeFromPB(k.GetLastUsedAt()),
+		RevokedAt:  timeFromPB(k.GetRevokedAt()),
+		CreatedBy:  int(k.GetCreatedBy()),
+		CreatedAt:  time.Unix(k.GetCreatedAt(), 0),
+	}
+}
+
+func apiKeysFromPB(keys []*pb.APIKey) []types.APIKey {
+	ks := make([]types.APIKey, 0, len(keys))
+	for _, k := range keys {
+		ks = append(ks, apiKeyFromPB(k))
+	}
+	return ks
+}
+
+func cartItemsToPB(items []types.CartItem) []*pb.CartItem {
+	itemsPB := make([]*pb.CartItem, 0, len(items))
+	for _, i := range items {
+		itemsPB = append(itemsPB, &pb.CartItem{ProductId: int32(i.ProductID), Quantity: int32(i.Quantity)})
+	}
+	return itemsPB
+}
+
+func productPageFromPB(p *pb.ProductPage) types.ProductPage {
+	return types.ProductPage{
+		Products:    productsFromPB(p.GetProducts()),
+		Cursor:      int(p.GetCursor()),
+		WatchCursor: p.GetWatchCursor(),
+	}
+}
+
+func productEventFromPB(e *pb.ProductEvent) types.ProductEvent {
+	return types.ProductEvent{
+		Cursor:  e.GetCursor(),
+		Kind:    e.GetKind(),
+		Product: productFromPB(e.GetProduct()),
+	}
+}
+
+func orderEventFromPB(e *pb.OrderEvent) types.OrderEvent {
+	return types.OrderEvent{
+		Cursor: e.GetCursor(),
+		Kind:   e.GetKind(),
+		Order:  orderFromPB(e.GetOrder()),
+	}
+}
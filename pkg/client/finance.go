@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+)
+
+// GetFinanceReport is the revenue of the admin dashboard.
+func (c *Client) GetFinanceReport(ctx context.Context) (*types.FinanceReport, error) {
+	if c.rpc == nil {
+		var report types.FinanceReport
+		return &report, c.rest(ctx, http.MethodGet, "/finance", nil, nil, &report)
+	}
+	var res *pb.GetFinanceReportResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.finance.GetFinanceReport(ctx, &pb.GetFinanceReportRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.FinanceReport{
+		OrderCount:     int(res.GetOrderCount()),
+		TotalItemsSold: int(res.GetTotalItemsSold()),
+		TotalRevenue:   res.GetTotalRevenue(),
+	}, nil
+}
@@ -0,0 +1,372 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// mimeProtobuf is gateway.MIMEProtobuf, the gateway answers the whole
+// response message in it and a stream as length delimited
+// google.protobuf.Any.
+const mimeProtobuf = "application/x-protobuf"
+
+// plainHeaders are the metadata the gateway reads from headers of their own,
+// the others are sent as Grpc-Metadata-<key>.
+var plainHeaders = map[string]bool{
+	"authorization":   true,
+	"authorization-x": true,
+	"x-api-key":       true,
+	"x-request-id":    true,
+	"x-forwarded-for": true,
+	"accept-language": true,
+}
+
+var pathVariable = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// gatewayConn carries the calls of the generated clients to the gateway of
+// cmd/api_proto. The route of a method is the google.api.http option of
+// types.proto the gateway is generated from, so both always agree.
+type gatewayConn struct {
+	address string
+	http    *http.Client
+}
+
+func (g *gatewayConn) Invoke(ctx context.Context, method string, args any, reply any, _ ...grpc.CallOption) error {
+	res, err := g.request(ctx, method, args.(proto.Message))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return transportError(ctx, err)
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		return gatewayError(res, body)
+	}
+	if err := proto.Unmarshal(body, reply.(proto.Message)); err != nil {
+		return status.Errorf(codes.Internal, "failed to decode the answer of %s: %v", method, err)
+	}
+	return nil
+}
+
+func (g *gatewayConn) NewStream(ctx context.Context, _ *grpc.StreamDesc, method string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	return &gatewayStream{conn: g, ctx: ctx, method: method}, nil
+}
+
+// request sends the request message of the method along its route.
+func (g *gatewayConn) request(ctx context.Context, method string, msg proto.Message) (*http.Response, error) {
+	rule, err := httpRule(method)
+	if err != nil {
+		return nil, err
+	}
+	verb, pattern := ruleVerb(rule)
+	m := msg.ProtoReflect()
+
+	used := make(map[string]bool)
+	var fillErr error
+	path := pathVariable.ReplaceAllStringFunc(pattern, func(variable string) string {
+		fieldPath := pathVariable.FindStringSubmatch(variable)[1]
+		v, ok := fieldValue(m, fieldPath)
+		if !ok {
+			fillErr = status.Errorf(codes.InvalidArgument, "%s needs %s", method, fieldPath)
+			return ""
+		}
+		used[strings.Split(fieldPath, ".")[0]] = true
+		return url.PathEscape(v)
+	})
+	if fillErr != nil {
+		return nil, fillErr
+	}
+
+	var body io.Reader
+	query := url.Values{}
+	switch rule.GetBody() {
+	case "":
+		addQuery(query, "", m, used)
+	case "*":
+		buf, err := proto.Marshal(msg)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		body = bytes.NewReader(buf)
+	default:
+		field := m.Descriptor().Fields().ByName(protoreflect.Name(rule.GetBody()))
+		if field == nil {
+			return nil, status.Errorf(codes.Internal, "%s has no body field %s", method, rule.GetBody())
+		}
+		buf, err := proto.Marshal(m.Get(field).Message().Interface())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	target := g.address + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, verb, target, body)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", mimeProtobuf)
+	}
+	req.Header.Set("Accept", mimeProtobuf)
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for key, values := range md {
+		header := http.CanonicalHeaderKey(key)
+		if !plainHeaders[key] {
+			header = "Grpc-Metadata-" + header
+		}
+		for _, v := range values {
+			req.Header.Add(header, v)
+		}
+	}
+	res, err := g.http.Do(req)
+	if err != nil {
+		return nil, transportError(ctx, err)
+	}
+	return res, nil
+}
+
+// httpRule is the primary google.api.http option of the method, e.g. of
+// /types.ProductService/GetProducts.
+func httpRule(method string) (*annotations.HttpRule, error) {
+	name := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(method, "/"), "/", "."))
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return nil, status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+	methodDesc, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+	rule, _ := proto.GetExtension(methodDesc.Options(), annotations.E_Http).(*annotations.HttpRule)
+	if rule == nil {
+		return nil, status.Errorf(codes.Unimplemented, "%s has no route", method)
+	}
+	return rule, nil
+}
+
+func ruleVerb(rule *annotations.HttpRule) (string, string) {
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Custom:
+		return pattern.Custom.GetKind(), pattern.Custom.GetPath()
+	}
+	return "", ""
+}
+
+// fieldValue is the value of a field path like product.id in the request.
+func fieldValue(m protoreflect.Message, fieldPath string) (string, bool) {
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		field := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			return "", false
+		}
+		if i < len(names)-1 {
+			if field.Kind() != protoreflect.MessageKind {
+				return "", false
+			}
+			m = m.Get(field).Message()
+			continue
+		}
+		return formatValue(field, m.Get(field)), true
+	}
+	return "", false
+}
+
+// addQuery sends the fields a bodiless route doesn't have in its path as the
+// query, by the names of types.proto.
+func addQuery(query url.Values, prefix string, m protoreflect.Message, used map[string]bool) {
+	m.Range(func(field protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := prefix + string(field.Name())
+		if prefix == "" && used[name] {
+			return true
+		}
+		switch {
+		case field.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				query.Add(name, formatValue(field, list.Get(i)))
+			}
+		case field.IsMap():
+		case field.Kind() == protoreflect.MessageKind:
+			addQuery(query, name+".", v.Message(), nil)
+		default:
+			query.Add(name, formatValue(field, v))
+		}
+		return true
+	})
+}
+
+func formatValue(field protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(v.Bool())
+	case protoreflect.BytesKind:
+		return base64.URLEncoding.EncodeToString(v.Bytes())
+	case protoreflect.EnumKind:
+		if value := field.Enum().Values().ByNumber(v.Enum()); value != nil {
+			return string(value.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// gatewayError is the google.rpc.Status the gateway answered, or one from the
+// HTTP status when it is not, e.g. from a proxy in between.
+func gatewayError(res *http.Response, body []byte) error {
+	var s spb.Status
+	if err := proto.Unmarshal(body, &s); err == nil && s.GetCode() != 0 {
+		return status.ErrorProto(&s)
+	}
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		message = http.StatusText(res.StatusCode)
+	}
+	return restError(&http.Response{StatusCode: res.StatusCode, Header: res.Header, Body: io.NopCloser(strings.NewReader(message))})
+}
+
+// gatewayStream is a server stream of the gateway, SendMsg sends the request
+// and RecvMsg reads the chunks of the answer.
+type gatewayStream struct {
+	conn   *gatewayConn
+	ctx    context.Context
+	method string
+	res    *http.Response
+	reader *bufio.Reader
+}
+
+func (s *gatewayStream) Header() (metadata.MD, error) {
+	return metadata.MD{}, nil
+}
+
+func (s *gatewayStream) Trailer() metadata.MD {
+	return metadata.MD{}
+}
+
+func (s *gatewayStream) CloseSend() error {
+	return nil
+}
+
+func (s *gatewayStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *gatewayStream) SendMsg(m any) error {
+	if s.res != nil {
+		return status.Errorf(codes.Internal, "%s is a server stream", s.method)
+	}
+	res, err := s.conn.request(s.ctx, s.method, m.(proto.Message))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		// the stream may have failed in its first chunk
+		if chunk, err := readChunk(bufio.NewReader(bytes.NewReader(body))); err == nil {
+			if chunkErr := chunkStatus(chunk); chunkErr != nil {
+				return chunkErr
+			}
+		}
+		return gatewayError(res, body)
+	}
+	s.res = res
+	s.reader = bufio.NewReader(res.Body)
+	return nil
+}
+
+func (s *gatewayStream) RecvMsg(m any) error {
+	if s.reader == nil {
+		return status.Errorf(codes.Internal, "%s was not sent", s.method)
+	}
+	chunk, err := readChunk(s.reader)
+	if err != nil {
+		s.res.Body.Close()
+		if errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+		return transportError(s.ctx, err)
+	}
+	if err := chunkStatus(chunk); err != nil {
+		s.res.Body.Close()
+		return err
+	}
+	if err := chunk.UnmarshalTo(m.(proto.Message)); err != nil {
+		s.res.Body.Close()
+		return status.Errorf(codes.Internal, "failed to decode the stream of %s: %v", s.method, err)
+	}
+	return nil
+}
+
+// readChunk reads a length delimited google.protobuf.Any.
+func readChunk(r *bufio.Reader) (*anypb.Any, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	var chunk anypb.Any
+	if err := proto.Unmarshal(buf, &chunk); err != nil {
+		return nil, err
+	}
+	return &chunk, nil
+}
+
+// chunkStatus is the error of a chunk holding the status which ended the
+// stream.
+func chunkStatus(chunk *anypb.Any) error {
+	var s spb.Status
+	if !chunk.MessageIs(&s) {
+		return nil
+	}
+	if err := chunk.UnmarshalTo(&s); err != nil {
+		return status.Errorf(codes.Internal, "failed to decode the status of the stream: %v", err)
+	}
+	if s.GetCode() == 0 {
+		return nil
+	}
+	return status.ErrorProto(&s)
+}
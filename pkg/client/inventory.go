@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+// The inventory is only served by the REST API.
+
+func (c *Client) GetStockMovements(ctx context.Context, productID int) ([]types.StockMovement, error) {
+	var movements []types.StockMovement
+	return movements, c.rest(ctx, http.MethodGet, stockPath(productID, ""), nil, nil, &movements)
+}
+
+func (c *Client) CreateStockReceipt(ctx context.Context, productID int, payload types.StockReceiptPayload) (*types.StockMovement, error) {
+	var movement types.StockMovement
+	return &movement, c.rest(ctx, http.MethodPost, stockPath(productID, "/receipts"), nil, payload, &movement)
+}
+
+// CreateStockCount sets the stock of the warehouse to the quantity counted,
+// the movement is nil when the count matched it.
+func (c *Client) CreateStockCount(ctx context.Context, productID int, payload types.StockCountPayload) (*types.StockMovement, error) {
+	var movement types.StockMovement
+	if err := c.rest(ctx, http.MethodPost, stockPath(productID, "/counts"), nil, payload, &movement); err != nil {
+		return nil, err
+	}
+	// the API answers {"adjusted": false} for a count which matched
+	if movement.ID == 0 {
+		return nil, nil
+	}
+	return &movement, nil
+}
+
+func (c *Client) CreateStockTransfer(ctx context.Context, productID int, payload types.StockTransferPayload) error {
+	return c.rest(ctx, http.MethodPost, stockPath(productID, "/transfers"), nil, payload, nil)
+}
+
+func (c *Client) SetStockThreshold(ctx context.Context, productID int, payload types.StockThresholdPayload) error {
+	return c.rest(ctx, http.MethodPut, stockPath(productID, "/threshold"), nil, payload, nil)
+}
+
+func (c *Client) GetProductWarehouseStock(ctx context.Context, productID int) ([]types.WarehouseStock, error) {
+	var stock []types.WarehouseStock
+	return stock, c.rest(ctx, http.MethodGet, stockPath(productID, "/warehouses"), nil, nil, &stock)
+}
+
+func (c *Client) GetLowStock(ctx context.Context) ([]types.StockLevel, error) {
+	var low []types.StockLevel
+	return low, c.rest(ctx, http.MethodGet, "/stock/low", nil, nil, &low)
+}
+
+// GetReorderSuggestions suggests the orders covering cover days of the sales
+// of the last window days, a 0 is the default of the API.
+func (c *Client) GetReorderSuggestions(ctx context.Context, window int, cover int) ([]types.ReorderSuggestion, error) {
+	query := url.Values{}
+	if window > 0 {
+		query.Set("window", strconv.Itoa(window))
+	}
+	if cover > 0 {
+		query.Set("cover", strconv.Itoa(cover))
+	}
+	var suggestions []types.ReorderSuggestion
+	return suggestions, c.rest(ctx, http.MethodGet, "/stock/reorder", query, nil, &suggestions)
+}
+
+func (c *Client) GetStockDrift(ctx context.Context) ([]types.StockDrift, error) {
+	var drift []types.StockDrift
+	return drift, c.rest(ctx, http.MethodGet, "/stock/drift", nil, nil, &drift)
+}
+
+func (c *Client) GetWarehouses(ctx context.Context) ([]types.Warehouse, error) {
+	var warehouses []types.Warehouse
+	return warehouses, c.rest(ctx, http.MethodGet, "/stock/warehouses", nil, nil, &warehouses)
+}
+
+func (c *Client) CreateWarehouse(ctx context.Context, payload types.WarehousePayload) (*types.Warehouse, error) {
+	var warehouse types.Warehouse
+	return &warehouse, c.rest(ctx, http.MethodPost, "/stock/warehouses", nil, payload, &warehouse)
+}
+
+func (c *Client) GetWarehouseStock(ctx context.Context, warehouseID int) ([]types.WarehouseStock, error) {
+	var stock []types.WarehouseStock
+	return stock, c.rest(ctx, http.MethodGet, "/stock/warehouses/"+strconv.Itoa(warehouseID), nil, nil, &stock)
+}
+
+func stockPath(productID int, path string) string {
+	return "/products/" + strconv.Itoa(productID) + "/stock" + path
+}
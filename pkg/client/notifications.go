@@ -0,0 +1,120 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The notifications are only served by the REST API.
+
+// GetNotifications is a page of the inbox of the signed in user with its
+// unread count.
+func (c *Client) GetNotifications(ctx context.Context, filter types.NotificationFilter) (*types.NotificationInbox, error) {
+	query := url.Values{}
+	if filter.Unread {
+		query.Set("unread", "true")
+	}
+	addPage(query, filter.Limit, filter.Offset)
+	var inbox types.NotificationInbox
+	return &inbox, c.rest(ctx, http.MethodGet, "/me/notifications", query, nil, &inbox)
+}
+
+func (c *Client) MarkNotificationRead(ctx context.Context, id int) error {
+	return c.rest(ctx, http.MethodPost, "/me/notifications/"+strconv.Itoa(id)+"/read", nil, nil, nil)
+}
+
+// MarkAllNotificationsRead returns how many notifications were unread.
+func (c *Client) MarkAllNotificationsRead(ctx context.Context) (int64, error) {
+	var response struct {
+		Read int64 `json:"read"`
+	}
+	return response.Read, c.rest(ctx, http.MethodPost, "/me/notifications/read", nil, nil, &response)
+}
+
+// NotificationStream is the live inbox of the signed in user, it ends with
+// the context it was opened with or Close.
+type NotificationStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	lastID int
+}
+
+// OpenNotifications opens the live inbox, the notifications after lastID are
+// sent first. Only the opening is tried again, a caller reopens a broken
+// stream with the ID of the last notification it received.
+func (c *Client) OpenNotifications(ctx context.Context, lastID int) (*NotificationStream, error) {
+	header := http.Header{"Accept": {"text/event-stream"}}
+	if lastID > 0 {
+		header.Set("Last-Event-ID", strconv.Itoa(lastID))
+	}
+	var stream *NotificationStream
+	err := c.call(ctx, func(ctx context.Context) error {
+		res, err := c.restRequest(ctx, http.MethodGet, "/me/notifications/stream", nil, header, nil)
+		if err != nil {
+			return err
+		}
+		stream = &NotificationStream{body: res.Body, reader: bufio.NewReader(res.Body), lastID: lastID}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Recv waits for the next notification, io.EOF is the end of the stream.
+func (s *NotificationStream) Recv() (*types.Notification, error) {
+	var data strings.Builder
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if data.Len() == 0 {
+				continue
+			}
+			var n types.Notification
+			if err := json.Unmarshal([]byte(data.String()), &n); err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to decode the notification: %v", err)
+			}
+			s.lastID = n.ID
+			return &n, nil
+		}
+		// the comments are the heartbeats of the API, the id is the one of
+		// the notification
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		if field == "data" {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+}
+
+// LastID is the ID of the last notification received, to reopen the stream
+// with.
+func (s *NotificationStream) LastID() int {
+	return s.lastID
+}
+
+func (s *NotificationStream) Close() error {
+	return s.body.Close()
+}
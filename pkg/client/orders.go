@@ -0,0 +1,265 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+)
+
+func (c *Client) GetOrders(ctx context.Context) ([]types.Order, error) {
+	if c.rpc == nil {
+		var orders []types.Order
+		return orders, c.rest(ctx, http.MethodGet, "/orders", nil, nil, &orders)
+	}
+	var res *pb.GetOrdersResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orders.GetOrders(ctx, &pb.GetOrdersRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ordersFromPB(res.GetOrders()), nil
+}
+
+func (c *Client) GetOrdersByIDs(ctx context.Context, ids []int) ([]types.Order, error) {
+	if c.rpc == nil {
+		return nil, rpcOnly("GetOrdersByIDs")
+	}
+	var res *pb.GetOrdersByIDsResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orders.GetOrdersByIDs(ctx, &pb.GetOrdersByIDsRequest{Ids: idsToPB(ids)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ordersFromPB(res.GetOrders()), nil
+}
+
+func (c *Client) GetOrderByID(ctx context.Context, id int) (*types.Order, error) {
+	if c.rpc == nil {
+		var order types.Order
+		return &order, c.rest(ctx, http.MethodGet, "/orders/"+strconv.Itoa(id), nil, nil, &order)
+	}
+	var res *pb.GetOrderByIDResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orders.GetOrderByID(ctx, &pb.GetOrderByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	o := orderFromPB(res.GetOrder())
+	return &o, nil
+}
+
+// CreateOrder creates an order without its items, the orders of the
+// customers are made by Checkout.
+func (c *Client) CreateOrder(ctx context.Context, order types.Order) (int64, error) {
+	if c.rpc == nil {
+		return 0, rpcOnly("CreateOrder")
+	}
+	var res *pb.CreateOrderResponse
+	err := c.send(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orders.CreateOrder(ctx, &pb.CreateOrderRequest{Order: orderToPB(order)})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.GetId(), nil
+}
+
+// UpdateOrder updates the order of order.ID and returns how many were
+// updated.
+func (c *Client) UpdateOrder(ctx context.Context, order types.Order) (int64, error) {
+	if c.rpc == nil {
+		var response struct {
+			UpdatedID int64 `json:"updated_id"`
+		}
+		return response.UpdatedID, c.rest(ctx, http.MethodPatch, "/orders/"+strconv.Itoa(order.ID)+"/update", nil, order, &response)
+	}
+	var res *pb.UpdateOrderResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orders.UpdateOrder(ctx, &pb.UpdateOrderRequest{Order: orderToPB(order)})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.GetUpdatedCount(), nil
+}
+
+// DeleteOrderByID moves the order to the trash and returns how many were
+// deleted.
+func (c *Client) DeleteOrderByID(ctx context.Context, id int) (int64, error) {
+	if c.rpc == nil {
+		var response struct {
+			DeletedID int64 `json:"deleted_id"`
+		}
+		return response.DeletedID, c.rest(ctx, http.MethodDelete, "/orders/"+strconv.Itoa(id)+"/delete", nil, nil, &response)
+	}
+	var res *pb.DeleteOrderByIDResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orders.DeleteOrderByID(ctx, &pb.DeleteOrderByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.GetDeletedCount(), nil
+}
+
+func (c *Client) GetDeletedOrders(ctx context.Context) ([]types.Order, error) {
+	if c.rpc == nil {
+		var orders []types.Order
+		return orders, c.rest(ctx, http.MethodGet, "/orders/trash", nil, nil, &orders)
+	}
+	var res *pb.GetDeletedOrdersResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orders.GetDeletedOrders(ctx, &pb.GetDeletedOrdersRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ordersFromPB(res.GetOrders()), nil
+}
+
+// RestoreOrderByID takes the order out of the trash and returns it.
+func (c *Client) RestoreOrderByID(ctx context.Context, id int) (*types.Order, error) {
+	if c.rpc == nil {
+		var order types.Order
+		return &order, c.rest(ctx, http.MethodPost, "/orders/"+strconv.Itoa(id)+"/restore", nil, nil, &order)
+	}
+	err := c.call(ctx, func(ctx context.Context) error {
+		_, err := c.orders.RestoreOrderByID(ctx, &pb.RestoreOrderByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.GetOrderByID(ctx, id)
+}
+
+// WatchOrders sends the changes of the orders after cursor to send until ctx
+// is done. A broken watch goes on from the last event sent.
+func (c *Client) WatchOrders(ctx context.Context, cursor int64, send func(types.OrderEvent) error) error {
+	if c.rpc == nil {
+		return rpcOnly("WatchOrders")
+	}
+	return c.retry(ctx, true, func(ctx context.Context, progress func()) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stream, err := c.orders.WatchOrders(ctx, &pb.WatchOrdersRequest{Cursor: cursor})
+		if err != nil {
+			return err
+		}
+		for {
+			e, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := send(orderEventFromPB(e)); err != nil {
+				return stop(err)
+			}
+			cursor = e.GetCursor()
+			progress()
+		}
+	})
+}
+
+func (c *Client) GetOrderItems(ctx context.Context) ([]types.OrderItem, error) {
+	if c.rpc == nil {
+		var orderItems []types.OrderItem
+		return orderItems, c.rest(ctx, http.MethodGet, "/order_items", nil, nil, &orderItems)
+	}
+	var res *pb.GetOrderItemsResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orderItems.GetOrderItems(ctx, &pb.GetOrderItemsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderItemsFromPB(res.GetOrderItems()), nil
+}
+
+func (c *Client) GetOrderItemsByIDs(ctx context.Context, ids []int) ([]types.OrderItem, error) {
+	if c.rpc == nil {
+		return nil, rpcOnly("GetOrderItemsByIDs")
+	}
+	var res *pb.GetOrderItemsByIDsResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orderItems.GetOrderItemsByIDs(ctx, &pb.GetOrderItemsByIDsRequest{Ids: idsToPB(ids)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderItemsFromPB(res.GetOrderItems()), nil
+}
+
+func (c *Client) GetOrderItemByID(ctx context.Context, id int) (*types.OrderItem, error) {
+	if c.rpc == nil {
+		var orderItem types.OrderItem
+		return &orderItem, c.rest(ctx, http.MethodGet, "/order_items/"+strconv.Itoa(id), nil, nil, &orderItem)
+	}
+	var res *pb.GetOrderItemByIDResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orderItems.GetOrderItemByID(ctx, &pb.GetOrderItemByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	i := orderItemFromPB(res.GetOrderItem())
+	return &i, nil
+}
+
+// UpdateOrderItem updates the order item of orderItem.ID and returns how many
+// were updated.
+func (c *Client) UpdateOrderItem(ctx context.Context, orderItem types.OrderItem) (int64, error) {
+	if c.rpc == nil {
+		var response struct {
+			UpdatedID int64 `json:"updated_id"`
+		}
+		return response.UpdatedID, c.rest(ctx, http.MethodPatch, "/order_items/"+strconv.Itoa(orderItem.ID)+"/update", nil, orderItem, &response)
+	}
+	var res *pb.UpdateOrderItemResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orderItems.UpdateOrderItem(ctx, &pb.UpdateOrderItemRequest{OrderItem: orderItemToPB(orderItem)})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.GetUpdatedCount(), nil
+}
+
+func (c *Client) DeleteOrderItemByID(ctx context.Context, id int) (int64, error) {
+	if c.rpc == nil {
+		var response struct {
+			DeletedID int64 `json:"deleted_id"`
+		}
+		return response.DeletedID, c.rest(ctx, http.MethodDelete, "/order_items/"+strconv.Itoa(id)+"/delete", nil, nil, &response)
+	}
+	var res *pb.DeleteOrderItemByIDResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.orderItems.DeleteOrderItemByID(ctx, &pb.DeleteOrderItemByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.GetDeletedCount(), nil
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+// The mail outbox is only served by the REST API.
+
+func (c *Client) GetOutboxMails(ctx context.Context, filter types.OutboxFilter) ([]types.OutboxMail, error) {
+	query := url.Values{}
+	if filter.Status != "" {
+		query.Set("status", filter.Status)
+	}
+	if filter.Template != "" {
+		query.Set("template", filter.Template)
+	}
+	addPage(query, filter.Limit, filter.Offset)
+	var mails []types.OutboxMail
+	return mails, c.rest(ctx, http.MethodGet, "/mail_outbox", query, nil, &mails)
+}
+
+// RetryOutboxMail sends a failed mail again with the next run of the outbox.
+func (c *Client) RetryOutboxMail(ctx context.Context, id int) error {
+	return c.rest(ctx, http.MethodPost, "/mail_outbox/"+strconv.Itoa(id)+"/retry", nil, nil, nil)
+}
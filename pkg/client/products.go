@@ -0,0 +1,326 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (c *Client) GetProducts(ctx context.Context) ([]types.Product, error) {
+	if c.rpc == nil {
+		var products []types.Product
+		return products, c.rest(ctx, http.MethodGet, "/products", nil, nil, &products)
+	}
+	var res *pb.GetProductsResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.products.GetProducts(ctx, &pb.GetProductsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return productsFromPB(res.GetProducts()), nil
+}
+
+func (c *Client) GetProductsByIDs(ctx context.Context, ids []int) ([]types.Product, error) {
+	if c.rpc == nil {
+		return nil, rpcOnly("GetProductsByIDs")
+	}
+	var res *pb.GetProductsByIDsResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.products.GetProductsByIDs(ctx, &pb.GetProductsByIDsRequest{Ids: idsToPB(ids)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return productsFromPB(res.GetProducts()), nil
+}
+
+func (c *Client) GetProductByID(ctx context.Context, id int) (*types.Product, error) {
+	if c.rpc == nil {
+		var product types.Product
+		return &product, c.rest(ctx, http.MethodGet, "/products/"+strconv.Itoa(id), nil, nil, &product)
+	}
+	var res *pb.GetProductByIDResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.products.GetProductByID(ctx, &pb.GetProductByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	p := productFromPB(res.GetProduct())
+	return &p, nil
+}
+
+// CreateProduct returns the id of the new product.
+func (c *Client) CreateProduct(ctx context.Context, product types.Product) (int64, error) {
+	if c.rpc == nil {
+		var response struct {
+			CreatedID int64 `json:"created_id"`
+		}
+		return response.CreatedID, c.rest(ctx, http.MethodPost, "/products", nil, product, &response)
+	}
+	var res *pb.CreateProductResponse
+	err := c.send(ctx, func(ctx context.Context) (err error) {
+		res, err = c.products.CreateProduct(ctx, &pb.CreateProductRequest{Product: productToPB(product)})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.GetId(), nil
+}
+
+// UpdateProduct updates the product of product.ID and returns how many were
+// updated.
+func (c *Client) UpdateProduct(ctx context.Context, product types.Product) (int64, error) {
+	if c.rpc == nil {
+		var response struct {
+			UpdatedID int64 `json:"updated_id"`
+		}
+		return response.UpdatedID, c.rest(ctx, http.MethodPatch, "/products/"+strconv.Itoa(product.ID)+"/update", nil, product, &response)
+	}
+	var res *pb.UpdateProductResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.products.UpdateProduct(ctx, &pb.UpdateProductRequest{Product: productToPB(product)})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.GetUpdatedCount(), nil
+}
+
+// DeleteProductByID moves the product to the trash and returns how many were
+// deleted.
+func (c *Client) DeleteProductByID(ctx context.Context, id int) (int64, error) {
+	if c.rpc == nil {
+		var response struct {
+			DeletedID int64 `json:"deleted_id"`
+		}
+		return response.DeletedID, c.rest(ctx, http.MethodDelete, "/products/"+strconv.Itoa(id)+"/delete", nil, nil, &response)
+	}
+	var res *pb.DeleteProductByIDResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.products.DeleteProductByID(ctx, &pb.DeleteProductByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.GetDeletedCount(), nil
+}
+
+func (c *Client) GetDeletedProducts(ctx context.Context) ([]types.Product, error) {
+	if c.rpc == nil {
+		var products []types.Product
+		return products, c.rest(ctx, http.MethodGet, "/products/trash", nil, nil, &products)
+	}
+	var res *pb.GetDeletedProductsResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.products.GetDeletedProducts(ctx, &pb.GetDeletedProductsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return productsFromPB(res.GetProducts()), nil
+}
+
+// RestoreProductByID takes the product out of the trash and returns it.
+func (c *Client) RestoreProductByID(ctx context.Context, id int) (*types.Product, error) {
+	if c.rpc == nil {
+		var product types.Product
+		return &product, c.rest(ctx, http.MethodPost, "/products/"+strconv.Itoa(id)+"/restore", nil, nil, &product)
+	}
+	err := c.call(ctx, func(ctx context.Context) error {
+		_, err := c.products.RestoreProductByID(ctx, &pb.RestoreProductByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.GetProductByID(ctx, id)
+}
+
+// ExportProducts sends every product of the catalogue to send. An export
+// which failed after its first product is not tried again, send would see
+// the products twice.
+func (c *Client) ExportProducts(ctx context.Context, send func(types.Product) error) error {
+	if c.rpc == nil {
+		return rpcOnly("ExportProducts")
+	}
+	return c.retry(ctx, true, func(ctx context.Context, _ func()) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stream, err := c.products.ExportProducts(ctx, &pb.ExportProductsRequest{})
+		if err != nil {
+			return err
+		}
+		sent := false
+		for {
+			p, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				if sent {
+					return stop(err)
+				}
+				return err
+			}
+			sent = true
+			if err := send(productFromPB(p)); err != nil {
+				return stop(err)
+			}
+		}
+	})
+}
+
+// StreamProducts sends the catalogue to send page by page from the product
+// after cursor, a page size of 0 is the default of the API. A broken stream
+// goes on from the last page sent.
+func (c *Client) StreamProducts(ctx context.Context, cursor int, pageSize int, send func(types.ProductPage) error) error {
+	if c.rpc == nil {
+		return rpcOnly("StreamProducts")
+	}
+	return c.retry(ctx, true, func(ctx context.Context, progress func()) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stream, err := c.products.StreamProducts(ctx, &pb.StreamProductsRequest{Cursor: int32(cursor), PageSize: int32(pageSize)})
+		if err != nil {
+			return err
+		}
+		for {
+			page, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := send(productPageFromPB(page)); err != nil {
+				return stop(err)
+			}
+			cursor = int(page.GetCursor())
+			progress()
+		}
+	})
+}
+
+// WatchProducts sends the changes of the catalogue after cursor to send until
+// ctx is done, the WatchCursor of StreamProducts follows the snapshot it
+// sent. A broken watch goes on from the last event sent.
+func (c *Client) WatchProducts(ctx context.Context, cursor int64, send func(types.ProductEvent) error) error {
+	if c.rpc == nil {
+		return rpcOnly("WatchProducts")
+	}
+	return c.retry(ctx, true, func(ctx context.Context, progress func()) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stream, err := c.products.WatchProducts(ctx, &pb.WatchProductsRequest{Cursor: cursor})
+		if err != nil {
+			return err
+		}
+		for {
+			e, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := send(productEventFromPB(e)); err != nil {
+				return stop(err)
+			}
+			cursor = e.GetCursor()
+			progress()
+		}
+	})
+}
+
+// ImportProducts uploads a CSV or JSON file of the catalogue. A small file is
+// imported at once and its result returned, a large one is imported in the
+// background and its job returned, see GetImportJob.
+func (c *Client) ImportProducts(ctx context.Context, format string, file io.Reader, dryRun bool) (*types.ProductImportResult, *types.ProductImportJob, error) {
+	contentType, ok := importContentTypes[format]
+	if !ok {
+		return nil, nil, status.Error(codes.InvalidArgument, "unknown format, expected csv or json")
+	}
+	body, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "failed to read the file: %v", err)
+	}
+	query := url.Values{"format": {format}, "dry_run": {strconv.FormatBool(dryRun)}}
+	var result *types.ProductImportResult
+	var job *types.ProductImportJob
+	err = c.send(ctx, func(ctx context.Context) error {
+		res, err := c.restRequest(ctx, http.MethodPost, "/products/import", query, http.Header{"Accept": {mimeJSON}, "Content-Type": {contentType}}, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode == http.StatusAccepted {
+			job = &types.ProductImportJob{}
+			return decodeJSON(res, job)
+		}
+		result = &types.ProductImportResult{}
+		return decodeJSON(res, result)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, job, nil
+}
+
+// importContentTypes are the media types of the formats of the imports, the
+// ones of internal/catalog.
+var importContentTypes = map[string]string{
+	"csv":  "text/csv",
+	"json": mimeJSON,
+}
+
+func (c *Client) GetImportJob(ctx context.Context, id string) (*types.ProductImportJob, error) {
+	var job types.ProductImportJob
+	return &job, c.rest(ctx, http.MethodGet, "/products/imports/"+url.PathEscape(id), nil, nil, &job)
+}
+
+// Download is a file of the API, the caller closes it.
+type Download struct {
+	io.ReadCloser
+	ContentType        string
+	ContentDisposition string
+}
+
+// DownloadProducts downloads the catalogue as a CSV or JSON file in the
+// format of the imports.
+func (c *Client) DownloadProducts(ctx context.Context, format string) (*Download, error) {
+	var download *Download
+	err := c.call(ctx, func(ctx context.Context) error {
+		res, err := c.restRequest(ctx, http.MethodGet, "/products/export", url.Values{"format": {format}}, nil, nil)
+		if err != nil {
+			return err
+		}
+		download = &Download{
+			ReadCloser:         res.Body,
+			ContentType:        res.Header.Get("Content-Type"),
+			ContentDisposition: res.Header.Get("Content-Disposition"),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return download, nil
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const mimeJSON = "application/json"
+
+// rest calls a route of the REST API and decodes its JSON answer into out,
+// the answer is dropped when out is nil. The reads, puts and deletes can be
+// sent twice.
+func (c *Client) rest(ctx context.Context, method string, path string, query url.Values, body any, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed to encode the body of %s %s: %v", method, path, err)
+		}
+	}
+	idempotent := method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete
+	return c.do(ctx, idempotent, func(ctx context.Context) error {
+		header := http.Header{"Accept": {mimeJSON}}
+		var reader io.Reader
+		if payload != nil {
+			header.Set("Content-Type", mimeJSON)
+			reader = bytes.NewReader(payload)
+		}
+		res, err := c.restRequest(ctx, method, path, query, header, reader)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if out == nil {
+			io.Copy(io.Discard, res.Body)
+			return nil
+		}
+		return decodeJSON(res, out)
+	})
+}
+
+func decodeJSON(res *http.Response, out any) error {
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return status.Errorf(codes.Internal, "failed to decode the answer of %s %s: %v", res.Request.Method, res.Request.URL.Path, err)
+	}
+	return nil
+}
+
+// restRequest sends a request to the REST API with the metadata of ctx as
+// headers, an answer of 400 or more is returned as its status. The caller
+// closes the body of the response.
+func (c *Client) restRequest(ctx context.Context, method string, path string, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	if c.opts.RESTAddress == "" {
+		return nil, restOnly(method + " " + path)
+	}
+	target := c.opts.RESTAddress + "/api/v1" + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for key, values := range md {
+		for _, v := range values {
+			req.Header.Add(http.CanonicalHeaderKey(key), v)
+		}
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, transportError(ctx, err)
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		defer res.Body.Close()
+		return nil, restError(res)
+	}
+	return res, nil
+}
+
+// transportError is the status of a request which got no answer.
+func transportError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+// restError is the status of an error of the REST API, the message is the
+// one of its {"error": ...} and a Retry-After is kept as a RetryInfo.
+func restError(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+	message := http.StatusText(res.StatusCode)
+	var answer struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &answer); err == nil && answer.Error != "" {
+		message = answer.Error
+	} else if text := strings.TrimSpace(string(body)); text != "" && err != nil {
+		message = text
+	}
+	s := status.New(codeFromHTTPStatus(res.StatusCode), message)
+	if after := retryAfter(res.Header); after > 0 {
+		if detailed, err := s.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(after)}); err == nil {
+			s = detailed
+		}
+	}
+	return s.Err()
+}
+
+// codeFromHTTPStatus is the reverse of utils.HTTPStatusFromCode, as close as
+// the status allows.
+func codeFromHTTPStatus(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented, http.StatusMethodNotAllowed:
+		return codes.Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	}
+	if httpStatus >= http.StatusInternalServerError {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// retryAfter reads a Retry-After in seconds or as a date.
+func retryAfter(header http.Header) time.Duration {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Second * time.Duration(seconds)
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
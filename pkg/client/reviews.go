@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+// The reviews are only served by the REST API.
+
+// GetProductReviews is a page of the approved reviews of the product, the
+// limit and the offset of the filter are used.
+func (c *Client) GetProductReviews(ctx context.Context, productID int, filter types.ReviewFilter) ([]types.Review, error) {
+	query := url.Values{}
+	addPage(query, filter.Limit, filter.Offset)
+	var reviews []types.Review
+	return reviews, c.rest(ctx, http.MethodGet, "/products/"+strconv.Itoa(productID)+"/reviews", query, nil, &reviews)
+}
+
+// CreateReview reviews a product the signed in user bought, it is pending
+// until an admin approves it.
+func (c *Client) CreateReview(ctx context.Context, payload types.ReviewPayload) (*types.Review, error) {
+	var review types.Review
+	return &review, c.rest(ctx, http.MethodPost, "/reviews", nil, payload, &review)
+}
+
+// GetReviews is the moderation queue of the admins.
+func (c *Client) GetReviews(ctx context.Context, filter types.ReviewFilter) ([]types.Review, error) {
+	query := url.Values{}
+	if filter.Status != "" {
+		query.Set("status", filter.Status)
+	}
+	if filter.ProductID > 0 {
+		query.Set("product_id", strconv.Itoa(filter.ProductID))
+	}
+	addPage(query, filter.Limit, filter.Offset)
+	var reviews []types.Review
+	return reviews, c.rest(ctx, http.MethodGet, "/reviews", query, nil, &reviews)
+}
+
+func (c *Client) SetReviewStatus(ctx context.Context, id int, payload types.ReviewStatusPayload) (*types.Review, error) {
+	var review types.Review
+	return &review, c.rest(ctx, http.MethodPut, "/reviews/"+strconv.Itoa(id)+"/status", nil, payload, &review)
+}
+
+func (c *Client) ReplyToReview(ctx context.Context, id int, payload types.ReviewReplyPayload) (*types.Review, error) {
+	var review types.Review
+	return &review, c.rest(ctx, http.MethodPut, "/reviews/"+strconv.Itoa(id)+"/reply", nil, payload, &review)
+}
+
+// addPage sets the limit and the offset of a list, a 0 is the default of the
+// API.
+func addPage(query url.Values, limit int, offset int) {
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+}
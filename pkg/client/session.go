@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// refreshMargin is how long before its expiry the access token is refreshed,
+// so it doesn't expire on its way to the API.
+const refreshMargin = time.Second * 10
+
+// Session is the access and the secret token of a signed in user. The access
+// token lives a minute, the Client refreshes it with the secret token before
+// it expires and when the API rejects it.
+type Session struct {
+	mu        sync.Mutex
+	access    string
+	secret    string
+	onRefresh func(access string, secret string)
+
+	// refreshing lets one call refresh the tokens, the API blacklists the
+	// access token it refreshed
+	refreshing sync.Mutex
+}
+
+// NewSession is the session of the tokens of a login. onRefresh, if any, is
+// called with the new tokens, e.g. to set the cookies of a browser.
+func NewSession(access string, secret string, onRefresh func(access string, secret string)) *Session {
+	return &Session{access: access, secret: secret, onRefresh: onRefresh}
+}
+
+func (s *Session) Tokens() (access string, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.access, s.secret
+}
+
+func (s *Session) set(access string, secret string) {
+	s.mu.Lock()
+	s.access, s.secret = access, secret
+	s.mu.Unlock()
+	if s.onRefresh != nil {
+		s.onRefresh(access, secret)
+	}
+}
+
+// expiresAt reads the expiry of the access token, the Client can't check the
+// signature and doesn't need to.
+func expiresAt(access string) (time.Time, bool) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(access, claims); err != nil {
+		return time.Time{}, false
+	}
+	expiredAt, ok := claims["expiredAt"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(expiredAt), 0), true
+}
+
+// expiring tells if the access token is about to expire, or has no expiry
+// the Client can read when unknown is true.
+func expiring(access string, unknown bool) bool {
+	at, ok := expiresAt(access)
+	if !ok {
+		return unknown
+	}
+	return time.Until(at) < refreshMargin
+}
+
+// authorized runs call with the credentials of ctx. The access token is
+// refreshed before it expires, and once more when the API rejects one the
+// Client couldn't vouch for. A rejected token which is still fresh is a
+// denial of the user and is returned as it is.
+func (c *Client) authorized(ctx context.Context, call func(ctx context.Context) error) error {
+	s := c.session(ctx)
+	if s == nil {
+		return call(c.outgoing(ctx))
+	}
+	access, secret := s.Tokens()
+	if access != "" && secret != "" && expiring(access, false) {
+		if err := c.refreshSession(ctx, s, access); err != nil {
+			return err
+		}
+	}
+	access, _ = s.Tokens()
+	err := call(c.outgoing(ctx))
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.PermissionDenied:
+	default:
+		return err
+	}
+	if _, isStop := err.(*stopError); isStop || !expiring(access, true) {
+		return err
+	}
+	if refreshErr := c.refreshSession(ctx, s, access); refreshErr != nil {
+		return err
+	}
+	return call(c.outgoing(ctx))
+}
+
+// refreshSession refreshes the tokens of the session unless another call
+// already replaced the rejected access token.
+func (c *Client) refreshSession(ctx context.Context, s *Session, rejected string) error {
+	s.refreshing.Lock()
+	defer s.refreshing.Unlock()
+	access, secret := s.Tokens()
+	if access != rejected {
+		return nil
+	}
+	if secret == "" {
+		return status.Error(codes.Unauthenticated, "the session has no secret token")
+	}
+	refreshed, err := c.Refresh(ctx, access, secret)
+	if err != nil {
+		return err
+	}
+	if refreshed.SecretToken == "" {
+		refreshed.SecretToken = secret
+	}
+	s.set(refreshed.AccessToken, refreshed.SecretToken)
+	return nil
+}
@@ -0,0 +1,73 @@
+package client
+
+import "github.com/fayleenpc/tj-jeans/internal/types"
+
+// The types of the API, the modules outside this one can't import
+// internal/types.
+type (
+	APIKey                    = types.APIKey
+	AccountExport             = types.AccountExport
+	AuditLog                  = types.AuditLog
+	AuditLogFilter            = types.AuditLogFilter
+	CartItem                  = types.CartItem
+	ChangePasswordPayload     = types.ChangePasswordPayload
+	CreateAPIKeyPayload       = types.CreateAPIKeyPayload
+	DeleteAccountPayload      = types.DeleteAccountPayload
+	FinanceReport             = types.FinanceReport
+	ForgotPasswordPayload     = types.ForgotPasswordPayload
+	InvoicePayload            = types.InvoicePayload
+	InvoiceResponse           = types.InvoiceResponse
+	LoginMFAPayload           = types.LoginMFAPayload
+	LoginUserPayload          = types.LoginUserPayload
+	MFACodePayload            = types.MFACodePayload
+	MFAPendingPayload         = types.MFAPendingPayload
+	Notification              = types.Notification
+	NotificationFilter        = types.NotificationFilter
+	NotificationInbox         = types.NotificationInbox
+	OIDCCallbackPayload       = types.OIDCCallbackPayload
+	Order                     = types.Order
+	OrderEvent                = types.OrderEvent
+	OrderItem                 = types.OrderItem
+	OutboxFilter              = types.OutboxFilter
+	OutboxMail                = types.OutboxMail
+	Product                   = types.Product
+	ProductEvent              = types.ProductEvent
+	ProductImportJob          = types.ProductImportJob
+	ProductImportResult       = types.ProductImportResult
+	ProductPage               = types.ProductPage
+	RefreshTokenPayload       = types.RefreshTokenPayload
+	RegisterUserPayload       = types.RegisterUserPayload
+	ReorderSuggestion         = types.ReorderSuggestion
+	ResendVerificationPayload = types.ResendVerificationPayload
+	ResetPasswordPayload      = types.ResetPasswordPayload
+	ResponseAPIKey            = types.ResponseAPIKey
+	ResponseCart              = types.ResponseCart
+	ResponseLogin             = types.ResponseLogin
+	ResponseLoginMFA          = types.ResponseLoginMFA
+	ResponseMFAEnrolment      = types.ResponseMFAEnrolment
+	ResponseOIDCAuthorization = types.ResponseOIDCAuthorization
+	ResponseOIDCProviders     = types.ResponseOIDCProviders
+	ResponsePassword          = types.ResponsePassword
+	ResponseRecoveryCodes     = types.ResponseRecoveryCodes
+	ResponseRegister          = types.ResponseRegister
+	RestockSubscription       = types.RestockSubscription
+	Review                    = types.Review
+	ReviewFilter              = types.ReviewFilter
+	ReviewPayload             = types.ReviewPayload
+	ReviewReplyPayload        = types.ReviewReplyPayload
+	ReviewStatusPayload       = types.ReviewStatusPayload
+	StockCountPayload         = types.StockCountPayload
+	StockDrift                = types.StockDrift
+	StockLevel                = types.StockLevel
+	StockMovement             = types.StockMovement
+	StockReceiptPayload       = types.StockReceiptPayload
+	StockThresholdPayload     = types.StockThresholdPayload
+	StockTransferPayload      = types.StockTransferPayload
+	Token                     = types.Token
+	UpdateProfilePayload      = types.UpdateProfilePayload
+	User                      = types.User
+	Warehouse                 = types.Warehouse
+	WarehousePayload          = types.WarehousePayload
+	WarehouseStock            = types.WarehouseStock
+	WishlistItem              = types.WishlistItem
+)
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+)
+
+// GetMe is the profile of the signed in user.
+func (c *Client) GetMe(ctx context.Context) (*types.User, error) {
+	var user types.User
+	return &user, c.rest(ctx, http.MethodGet, "/me", nil, nil, &user)
+}
+
+func (c *Client) UpdateMe(ctx context.Context, payload types.UpdateProfilePayload) (*types.User, error) {
+	var user types.User
+	return &user, c.rest(ctx, http.MethodPatch, "/me", nil, payload, &user)
+}
+
+// DeleteMe erases the account of the signed in user, its tokens are revoked.
+func (c *Client) DeleteMe(ctx context.Context, payload types.DeleteAccountPayload) error {
+	return c.rest(ctx, http.MethodDelete, "/me", nil, payload, nil)
+}
+
+func (c *Client) ExportMe(ctx context.Context) (*types.AccountExport, error) {
+	var export types.AccountExport
+	return &export, c.rest(ctx, http.MethodGet, "/me/export", nil, nil, &export)
+}
+
+func (c *Client) GetUsers(ctx context.Context) ([]types.User, error) {
+	if c.rpc == nil {
+		var users []types.User
+		return users, c.rest(ctx, http.MethodGet, "/users", nil, nil, &users)
+	}
+	var res *pb.GetUsersResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.users.GetUsers(ctx, &pb.GetUsersRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usersFromPB(res.GetUsers()), nil
+}
+
+func (c *Client) GetUsersByIDs(ctx context.Context, ids []int) ([]types.User, error) {
+	if c.rpc == nil {
+		return nil, rpcOnly("GetUsersByIDs")
+	}
+	var res *pb.GetUsersByIDsResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.users.GetUsersByIDs(ctx, &pb.GetUsersByIDsRequest{Ids: idsToPB(ids)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usersFromPB(res.GetUsers()), nil
+}
+
+func (c *Client) GetUserByID(ctx context.Context, id int) (*types.User, error) {
+	if c.rpc == nil {
+		var user types.User
+		return &user, c.rest(ctx, http.MethodGet, "/users/"+strconv.Itoa(id), nil, nil, &user)
+	}
+	var res *pb.GetUserByIDResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.users.GetUserByID(ctx, &pb.GetUserByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	u := userFromPB(res.GetUser())
+	return &u, nil
+}
+
+func (c *Client) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	if c.rpc == nil {
+		return nil, rpcOnly("GetUserByEmail")
+	}
+	var res *pb.GetUserByEmailResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.users.GetUserByEmail(ctx, &pb.GetUserByEmailRequest{Email: email})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	u := userFromPB(res.GetUser())
+	return &u, nil
+}
+
+// CreateUser creates a user with its password, the customers sign up with
+// Register.
+func (c *Client) CreateUser(ctx context.Context, user types.User) error {
+	if c.rpc == nil {
+		return rpcOnly("CreateUser")
+	}
+	return c.send(ctx, func(ctx context.Context) error {
+		_, err := c.users.CreateUser(ctx, &pb.CreateUserRequest{User: userToPB(user)})
+		return err
+	})
+}
+
+func (c *Client) UpdateVerifiedUserByEmail(ctx context.Context, email string) error {
+	if c.rpc == nil {
+		return rpcOnly("UpdateVerifiedUserByEmail")
+	}
+	return c.call(ctx, func(ctx context.Context) error {
+		_, err := c.users.UpdateVerifiedUserByEmail(ctx, &pb.UpdateVerifiedUserByEmailRequest{Email: email})
+		return err
+	})
+}
+
+// UpdateUser updates the user of user.ID and returns how many were updated.
+func (c *Client) UpdateUser(ctx context.Context, user types.User) (int64, error) {
+	if c.rpc == nil {
+		var response struct {
+			UpdatedID int64 `json:"updated_id"`
+		}
+		return response.UpdatedID, c.rest(ctx, http.MethodPatch, "/users/"+strconv.Itoa(user.ID)+"/update", nil, user, &response)
+	}
+	var res *pb.UpdateUserResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.users.UpdateUser(ctx, &pb.UpdateUserRequest{User: userToPB(user)})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.GetUpdatedCount(), nil
+}
+
+// DeleteUserByID moves the user to the trash and returns how many were
+// deleted.
+func (c *Client) DeleteUserByID(ctx context.Context, id int) (int64, error) {
+	if c.rpc == nil {
+		var response struct {
+			DeletedID int64 `json:"deleted_id"`
+		}
+		return response.DeletedID, c.rest(ctx, http.MethodDelete, "/users/"+strconv.Itoa(id)+"/delete", nil, nil, &response)
+	}
+	var res *pb.DeleteUserByIDResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.users.DeleteUserByID(ctx, &pb.DeleteUserByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.GetDeletedCount(), nil
+}
+
+func (c *Client) GetDeletedUsers(ctx context.Context) ([]types.User, error) {
+	if c.rpc == nil {
+		var users []types.User
+		return users, c.rest(ctx, http.MethodGet, "/users/trash", nil, nil, &users)
+	}
+	var res *pb.GetDeletedUsersResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.users.GetDeletedUsers(ctx, &pb.GetDeletedUsersRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usersFromPB(res.GetUsers()), nil
+}
+
+// RestoreUserByID takes the user out of the trash and returns it.
+func (c *Client) RestoreUserByID(ctx context.Context, id int) (*types.User, error) {
+	if c.rpc == nil {
+		var user types.User
+		return &user, c.rest(ctx, http.MethodPost, "/users/"+strconv.Itoa(id)+"/restore", nil, nil, &user)
+	}
+	err := c.call(ctx, func(ctx context.Context) error {
+		_, err := c.users.RestoreUserByID(ctx, &pb.RestoreUserByIDRequest{Id: int32(id)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.GetUserByID(ctx, id)
+}
+
+// The wishlist and the back in stock subscriptions are only served by the
+// REST API, each call answers the whole list.
+
+func (c *Client) GetWishlist(ctx context.Context) ([]types.WishlistItem, error) {
+	var items []types.WishlistItem
+	return items, c.rest(ctx, http.MethodGet, "/me/wishlist", nil, nil, &items)
+}
+
+func (c *Client) AddToWishlist(ctx context.Context, productID int) ([]types.WishlistItem, error) {
+	var items []types.WishlistItem
+	return items, c.rest(ctx, http.MethodPut, "/me/wishlist/"+strconv.Itoa(productID), nil, nil, &items)
+}
+
+func (c *Client) RemoveFromWishlist(ctx context.Context, productID int) ([]types.WishlistItem, error) {
+	var items []types.WishlistItem
+	return items, c.rest(ctx, http.MethodDelete, "/me/wishlist/"+strconv.Itoa(productID), nil, nil, &items)
+}
+
+func (c *Client) GetBackInStock(ctx context.Context) ([]types.RestockSubscription, error) {
+	var subscriptions []types.RestockSubscription
+	return subscriptions, c.rest(ctx, http.MethodGet, "/me/back_in_stock", nil, nil, &subscriptions)
+}
+
+func (c *Client) SubscribeBackInStock(ctx context.Context, productID int) ([]types.RestockSubscription, error) {
+	var subscriptions []types.RestockSubscription
+	return subscriptions, c.rest(ctx, http.MethodPut, "/me/back_in_stock/"+strconv.Itoa(productID), nil, nil, &subscriptions)
+}
+
+func (c *Client) UnsubscribeBackInStock(ctx context.Context, productID int) ([]types.RestockSubscription, error) {
+	var subscriptions []types.RestockSubscription
+	return subscriptions, c.rest(ctx, http.MethodDelete, "/me/back_in_stock/"+strconv.Itoa(productID), nil, nil, &subscriptions)
+}
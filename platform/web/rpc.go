@@ -1,91 +1,235 @@
 package web
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/fayleenpc/tj-jeans/internal/auth"
 	"github.com/fayleenpc/tj-jeans/internal/config"
-	"github.com/fayleenpc/tj-jeans/internal/loadbalancer"
 	"github.com/fayleenpc/tj-jeans/internal/session"
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/fayleenpc/tj-jeans/pkg/client"
+	"github.com/fayleenpc/tj-jeans/services/auditlog"
+	"github.com/fayleenpc/tj-jeans/services/notifications"
+	"github.com/fayleenpc/tj-jeans/services/reviews"
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	lb = loadbalancer.NewLoadBalancer()
+	api = newAPIClient()
 )
 
+// newAPIClient is the client of the API behind the pages, over the transport
+// of WEB_API_TRANSPORT. The routes types.proto doesn't have, like the
+// inventory, always go to the REST API.
+func newAPIClient() *client.Client {
+	restAddress := config.Envs.PublicHost + ":" + config.Envs.Port
+	opts := client.Options{
+		Transport:   client.Transport(config.Envs.WebAPITransport),
+		Address:     restAddress,
+		RESTAddress: restAddress,
+	}
+	switch opts.Transport {
+	case client.Protobuf:
+		opts.Address = config.Envs.PublicHost + ":" + config.Envs.PortProto
+	case client.GRPC:
+		opts.Address = grpcAddress()
+	}
+	c, err := client.New(opts)
+	if err != nil {
+		log.Fatalf("failed to create the client of the API: %v", err)
+	}
+	return c
+}
+
+// grpcAddress is the gRPC server of the API on the host of PUBLIC_HOST, the
+// target of a gRPC client has no scheme.
+func grpcAddress() string {
+	host := config.Envs.PublicHost
+	if u, err := url.Parse(host); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return net.JoinHostPort(host, config.Envs.PortGRPC)
+}
+
+// apiContext makes the calls of a request on behalf of the session of its
+// cookies and of the visitor, the tokens the client refreshes are set back
+// on the cookies.
+func apiContext(w http.ResponseWriter, r *http.Request) context.Context {
+	ctx := client.WithClientIP(r.Context(), utils.ClientIP(r))
+	if locale := r.Header.Get("Accept-Language"); locale != "" {
+		ctx = client.WithLocale(ctx, locale)
+	}
+	access := r.Header.Get("Authorization")
+	if access == "" {
+		return ctx
+	}
+	return client.WithSession(ctx, client.NewSession(access, r.Header.Get("Authorization-X"), func(access string, secret string) {
+		session.SetJWTAccessToken(w, access)
+		session.SetJWTSecretToken(w, secret)
+		r.Header.Set("Authorization", access)
+		r.Header.Set("Authorization-X", secret)
+	}))
+}
+
+// apiError is the HTTP status and the message a page is answered with for an
+// error of the API.
+func apiError(err error) (int, string) {
+	s := status.Convert(err)
+	return utils.HTTPStatusFromCode(s.Code()), s.Message()
+}
+
 func logout(w http.ResponseWriter, r *http.Request, callback func()) {
 	callback()
 }
 
 func login(w http.ResponseWriter, r *http.Request, callback func(status int, responseLogin types.ResponseLogin)) {
 	var payload types.LoginUserPayload
-	var response types.ResponseLogin
 	if err := utils.ParseJSON(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	m, err := json.Marshal(payload)
+	response, err := api.Login(apiContext(w, r), payload)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		code, message := apiError(err)
+		log.Printf("location %v, got response status %v\n", r.URL.Path, code)
+		callback(code, types.ResponseLogin{Error: message})
 		return
 	}
+	log.Printf("location %v, got response status %v\n", r.URL.Path, http.StatusOK)
 
-	resBody, status, err := utils.CraftJSON("POST", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/login", m, r)
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+	// with 2FA the session is only set once the code is verified by loginMFA
+	if !response.MFARequired {
+		session.SetJWTAccessToken(w, response.AccessToken)
+		session.SetJWTSecretToken(w, response.SecretToken)
+	}
+	callback(http.StatusOK, *response)
+}
+
+func loginMFA(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponseLoginMFA)) {
+	var payload types.LoginMFAPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+
+	response, err := api.LoginMFA(apiContext(w, r), payload)
+	if err != nil {
+		code, message := apiError(err)
+		log.Printf("location %v, got response status %v\n", r.URL.Path, code)
+		callback(code, types.ResponseLoginMFA{Error: message})
 		return
 	}
-	log.Printf("location %v, got response %+v, payload %v\n", r.URL.Path, response, payload)
+	log.Printf("location %v, got response status %v\n", r.URL.Path, http.StatusOK)
 
-	session.SetJWTAccessToken(w, response.AccessToken)
-	session.SetJWTSecretToken(w, response.SecretToken)
-	callback(status, response)
+	if response.AccessToken != "" && response.SecretToken != "" {
+		session.SetJWTAccessToken(w, response.AccessToken)
+		session.SetJWTSecretToken(w, response.SecretToken)
+	}
+	callback(http.StatusOK, *response)
 }
 
-func register(w http.ResponseWriter, r *http.Request, callback func(status int, responseRegister types.ResponseRegister)) {
-	var payload types.RegisterUserPayload
-	var response types.ResponseRegister
+func loginMFAEnroll(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponseMFAEnrolment)) {
+	var payload types.MFAPendingPayload
 	if err := utils.ParseJSON(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	m, err := json.Marshal(payload)
+	response, err := api.LoginMFAEnroll(apiContext(w, r), payload)
+	if err != nil {
+		code, message := apiError(err)
+		log.Printf("location %v, got response status %v\n", r.URL.Path, code)
+		callback(code, types.ResponseMFAEnrolment{Error: message})
+		return
+	}
+	log.Printf("location %v, got response status %v\n", r.URL.Path, http.StatusOK)
+	callback(http.StatusOK, *response)
+}
+
+func oidcProviders(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponseOIDCProviders)) {
+	response, err := api.OIDCProviders(apiContext(w, r))
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		code, message := apiError(err)
+		callback(code, types.ResponseOIDCProviders{Error: message})
 		return
 	}
+	callback(http.StatusOK, *response)
+}
+
+func oidcAuthorize(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponseOIDCAuthorization)) {
+	response, err := api.OIDCAuthorize(apiContext(w, r), mux.Vars(r)["provider"])
+	if err != nil {
+		code, message := apiError(err)
+		log.Printf("location %v, got response status %v\n", r.URL.Path, code)
+		callback(code, types.ResponseOIDCAuthorization{Error: message})
+		return
+	}
+	log.Printf("location %v, got response status %v\n", r.URL.Path, http.StatusOK)
+
+	if response.FlowToken != "" {
+		session.SetOIDCFlowToken(w, response.FlowToken, int(config.Envs.OIDCStateExpirationInSeconds))
+	}
+	callback(http.StatusOK, *response)
+}
+
+func oidcCallback(w http.ResponseWriter, r *http.Request, callback func(status int, responseLogin types.ResponseLogin)) {
+	flowToken, _ := session.GetOIDCFlowToken(r)
+	// the flow is single use, whatever the outcome
+	session.SetOIDCFlowToken(w, "", -1)
 
-	resBody, status, err := utils.CraftJSON("POST", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/register", m, r)
+	payload := types.OIDCCallbackPayload{
+		Code:      r.URL.Query().Get("code"),
+		State:     r.URL.Query().Get("state"),
+		FlowToken: flowToken,
+	}
+	response, err := api.OIDCCallback(apiContext(w, r), mux.Vars(r)["provider"], payload)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		code, message := apiError(err)
+		log.Printf("location %v, got response status %v\n", r.URL.Path, code)
+		callback(code, types.ResponseLogin{Error: message})
+		return
+	}
+	log.Printf("location %v, got response status %v\n", r.URL.Path, http.StatusOK)
+
+	if response.AccessToken != "" && response.SecretToken != "" {
+		session.SetJWTAccessToken(w, response.AccessToken)
+		session.SetJWTSecretToken(w, response.SecretToken)
+	}
+	callback(http.StatusOK, *response)
+}
+
+func register(w http.ResponseWriter, r *http.Request, callback func(status int, responseRegister types.ResponseRegister)) {
+	var payload types.RegisterUserPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+	response, err := api.Register(apiContext(w, r), payload)
+	if err != nil {
+		code, message := apiError(err)
+		callback(code, types.ResponseRegister{Error: message})
 		return
 	}
 	log.Printf("location %v, got response %+v\n", r.URL.Path, response)
-	callback(status, response)
+	callback(http.StatusCreated, *response)
 }
 
 func refresh(w http.ResponseWriter, r *http.Request, callback func(status int, responseRefresh types.ResponseRefreshToken)) {
 	var payload types.RefreshTokenPayload
-	var response types.ResponseRefreshToken
 
 	if r.Header.Get("Authorization") != "" && r.Header.Get("Authorization-X") != "" {
 		payload.AccessToken = r.Header.Get("Authorization")
@@ -96,125 +240,121 @@ func refresh(w http.ResponseWriter, r *http.Request, callback func(status int, r
 			return
 		}
 	}
-	log.Printf("[check payload /service/refresh] : %+v\n", payload)
-	m, err := json.Marshal(payload)
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
-		return
-	}
 
-	resBody, status, err := utils.CraftJSON("POST", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/refresh", m, r)
+	refreshed, err := api.Refresh(apiContext(w, r), payload.AccessToken, payload.SecretToken)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
-		return
-	}
-
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		utils.WriteStatusError(w, err)
 		return
 	}
-
-	log.Printf("location %v, got response %s\n", r.URL.Path, response)
-	callback(status, response)
+	log.Printf("location %v, got response status %v\n", r.URL.Path, http.StatusOK)
+	callback(http.StatusOK, types.ResponseRefreshToken{AccessToken: refreshed.AccessToken})
 }
 
-func createProducts(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponseProduct)) {
-	var payload types.Product
-	var response types.ResponseProduct
-
+func forgotPassword(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponsePassword)) {
+	var payload types.ForgotPasswordPayload
 	if err := utils.ParseJSON(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	m, err := json.Marshal(payload)
-
+	response, err := api.ForgotPassword(apiContext(w, r), payload)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		code, message := apiError(err)
+		callback(code, types.ResponsePassword{Error: message})
+		return
 	}
+	log.Printf("location %v, got response %+v\n", r.URL.Path, response)
+	callback(http.StatusAccepted, *response)
+}
 
-	// resBody, status, err := utils.CraftJSON("POST", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/products", m, r)
-	// do loadbalancer for frequent products
-	resBody, status, err := utils.CraftJSON("POST", lb.GetBackend()+"/api/v1/products", m, r)
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+func resetPassword(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponsePassword)) {
+	var payload types.ResetPasswordPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+	response, err := api.ResetPassword(apiContext(w, r), payload)
+	if err != nil {
+		code, message := apiError(err)
+		callback(code, types.ResponsePassword{Error: message})
 		return
 	}
-
 	log.Printf("location %v, got response %+v\n", r.URL.Path, response)
-	callback(status, response)
+	callback(http.StatusOK, *response)
 }
 
-func updateProducts(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponseProduct)) {
-	var payload types.Product
-	var response types.ResponseProduct
-
+func changePassword(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponsePassword)) {
+	var payload types.ChangePasswordPayload
 	if err := utils.ParseJSON(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	m, err := json.Marshal(payload)
-
+	// every previous session is revoked, the client sets the new tokens of
+	// this one on the cookies
+	response, err := api.ChangePassword(apiContext(w, r), payload)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		code, message := apiError(err)
+		callback(code, types.ResponsePassword{Error: message})
 		return
 	}
+	log.Printf("location %v, got response status %v\n", r.URL.Path, http.StatusOK)
+	callback(http.StatusOK, *response)
+}
 
-	resBody, status, err := utils.CraftJSON("PATCH", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/products", m, r)
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+func createProducts(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponseProduct)) {
+	var payload types.Product
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+	id, err := api.CreateProduct(apiContext(w, r), payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
 		return
 	}
+	payload.ID = int(id)
 
-	log.Printf("location %v, got response %+v\n", r.URL.Path, response)
-	callback(status, response)
+	log.Printf("location %v, created product %v\n", r.URL.Path, id)
+	callback(http.StatusOK, types.ResponseProduct{CreatedProduct: payload})
 }
 
-func deleteProducts(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponseProduct)) {
+func updateProducts(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponseProduct)) {
 	var payload types.Product
-	var response types.ResponseProduct
-
 	if err := utils.ParseJSON(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	m, err := json.Marshal(payload)
-
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+	if _, err := api.UpdateProduct(apiContext(w, r), payload); err != nil {
+		utils.WriteStatusError(w, err)
 		return
 	}
 
-	resBody, status, err := utils.CraftJSON("DELETE", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/products", m, r)
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+	log.Printf("location %v, updated product %v\n", r.URL.Path, payload.ID)
+	callback(http.StatusOK, types.ResponseProduct{UpdatedProduct: payload})
+}
+
+func deleteProducts(w http.ResponseWriter, r *http.Request, callback func(status int, response types.ResponseProduct)) {
+	var payload types.Product
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+	if _, err := api.DeleteProductByID(apiContext(w, r), payload.ID); err != nil {
+		utils.WriteStatusError(w, err)
 		return
 	}
 
-	log.Printf("location %v, got response %+v\n", r.URL.Path, response)
-	callback(status, response)
+	log.Printf("location %v, deleted product %v\n", r.URL.Path, payload.ID)
+	callback(http.StatusOK, types.ResponseProduct{DeletedProduct: payload})
 }
 
 func handleInvoice(w http.ResponseWriter, r *http.Request, req types.CartCheckoutPayload, res types.ResponseCart, callback func(req types.InvoicePayload, responseInvoice types.InvoiceResponse)) {
 	var invoicePayload types.InvoicePayload
-	var invoiceResponse types.InvoiceResponse
 
 	_ = req
 	// invoices payment gateway
@@ -225,474 +365,396 @@ func handleInvoice(w http.ResponseWriter, r *http.Request, req types.CartCheckou
 	invoicePayload.Customer.PhoneNumber = auth.GetUserPhoneNumberFromSession(r.Header.Get("Authorization"))
 	invoicePayload.Items = res.Items
 
-	marshalInvoice, err := json.Marshal(invoicePayload)
+	invoiceResponse, err := api.CreateInvoice(apiContext(w, r), invoicePayload)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
-		return
-	}
-	resBodyInvoice, statusInvoice, err := utils.CraftJSON("POST", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/payment/invoices", marshalInvoice, r)
-	if err != nil {
-		utils.WriteError(w, statusInvoice, err)
-		return
-	}
-
-	if err := json.Unmarshal(resBodyInvoice, &invoiceResponse); err != nil {
-		utils.WriteError(w, statusInvoice, err)
+		utils.WriteStatusError(w, err)
 		return
 	}
 
-	// log.Printf("location %v, set invoice %+v\n", r.URL.Path, invoicePayload)
-	// log.Printf("location %v, got invoice %+v\n", r.URL.Path, invoiceResponse)
-	// log.Printf("response final at /payment/invoices %v\n", string(resBodyInvoice))
-
-	callback(invoicePayload, invoiceResponse)
-
+	callback(invoicePayload, *invoiceResponse)
 }
 
 func handleCart(w http.ResponseWriter, r *http.Request, callback func(req types.CartCheckoutPayload, res types.ResponseCart)) {
 	var cart types.CartCheckoutPayload
-	var response types.ResponseCart
-
 	if err := utils.ParseJSON(r, &cart); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	marshalCart, err := json.Marshal(cart)
+	response, err := api.Checkout(apiContext(w, r), cart.Items)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
-		return
-	}
-	resBodyCart, statusCart, err := utils.CraftJSON("POST", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/cart/checkout", marshalCart, r)
-	if err != nil {
-		utils.WriteError(w, statusCart, err)
-		return
-	}
-
-	if err := json.Unmarshal(resBodyCart, &response); err != nil {
-		utils.WriteError(w, statusCart, err)
+		utils.WriteStatusError(w, err)
 		return
 	}
-	callback(cart, response)
+	callback(cart, *response)
 }
 
-func getOrderItems(r *http.Request) ([]types.OrderItem, error) {
-	var payload []types.OrderItem
-	req, err := http.NewRequest("GET", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/order_items", nil)
+// pathID is the ID of a path variable of the page.
+func pathID(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
 	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("invalid %s", strings.ReplaceAll(name, "_", " "))
 	}
+	return id, nil
+}
 
-	if err := json.Unmarshal(resBody, &payload); err != nil {
-		return nil, err
-	}
-	return payload, nil
+func getOrderItems(w http.ResponseWriter, r *http.Request) ([]types.OrderItem, error) {
+	return api.GetOrderItems(apiContext(w, r))
 }
 
-func getOrderItemByID(r *http.Request) (*types.OrderItem, error) {
-	orderItemID := mux.Vars(r)["order_item_id"]
-	var payload types.OrderItem
-	req, err := http.NewRequest("GET", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/order_items"+fmt.Sprintf("/%v", orderItemID), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := io.ReadAll(res.Body)
+// getAuditLogs forwards the filter in the query of the page to the audit log
+// API.
+func getAuditLogs(w http.ResponseWriter, r *http.Request) ([]types.AuditLog, error) {
+	filter, err := auditlog.ParseFilter(r.URL.Query())
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(resBody, &payload); err != nil {
-		return nil, err
-	}
-	return &payload, nil
+	return api.GetAuditLogs(apiContext(w, r), filter)
 }
 
-func updateOrderItemByID(r *http.Request) (any, error) {
-	orderItemID := mux.Vars(r)["order_item_id"]
-	var payload types.OrderItem
-	var response struct {
-		ID               int             `json:"updated_id"`
-		OldOrderItem     types.OrderItem `json:"old_order_item"`
-		UpdatedOrderItem types.OrderItem `json:"updated_order_item"`
-	}
-	if err := utils.ParseJSON(r, &payload); err != nil {
-		return nil, err
-	}
-	m, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequest("PATCH", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/order_items"+fmt.Sprintf("/%v/update", orderItemID), bytes.NewBuffer(m))
+func getOrderItemByID(w http.ResponseWriter, r *http.Request) (*types.OrderItem, error) {
+	orderItemID, err := pathID(r, "order_item_id")
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		return nil, err
-	}
-	return response, nil
+	return api.GetOrderItemByID(apiContext(w, r), orderItemID)
 }
 
-func deleteOrderItemByID(r *http.Request) (any, error) {
-	orderItemID := mux.Vars(r)["order_item_id"]
-	var response struct {
-		ID      int              `json:"deleted_id"`
-		Deleted *types.OrderItem `json:"deleted_order_item"`
-	}
-	req, err := http.NewRequest("DELETE", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/order_items"+fmt.Sprintf("/%v/delete", orderItemID), nil)
+// updateOrderItemByID and the other updates return how many rows changed.
+func updateOrderItemByID(w http.ResponseWriter, r *http.Request) (int64, error) {
+	orderItemID, err := pathID(r, "order_item_id")
 	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		return nil, err
+	var payload types.OrderItem
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		return 0, err
 	}
-	return &response, nil
+	payload.ID = orderItemID
+	return api.UpdateOrderItem(apiContext(w, r), payload)
 }
 
-func getOrders(r *http.Request) ([]types.Order, error) {
-	var payload []types.Order
-	req, err := http.NewRequest("GET", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/orders", nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
+func deleteOrderItemByID(w http.ResponseWriter, r *http.Request) (int64, error) {
+	orderItemID, err := pathID(r, "order_item_id")
 	if err != nil {
-		return nil, err
-	}
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+		return 0, err
 	}
+	return api.DeleteOrderItemByID(apiContext(w, r), orderItemID)
+}
 
-	if err := json.Unmarshal(resBody, &payload); err != nil {
-		return nil, err
-	}
-	return payload, nil
+func getOrders(w http.ResponseWriter, r *http.Request) ([]types.Order, error) {
+	return api.GetOrders(apiContext(w, r))
 }
 
-func getOrderByID(r *http.Request) (*types.Order, error) {
-	orderID := mux.Vars(r)["order_id"]
-	var payload types.Order
-	req, err := http.NewRequest("GET", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/orders"+fmt.Sprintf("/%v", orderID), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := io.ReadAll(res.Body)
+func getOrderByID(w http.ResponseWriter, r *http.Request) (*types.Order, error) {
+	orderID, err := pathID(r, "order_id")
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(resBody, &payload); err != nil {
-		return nil, err
-	}
-	return &payload, nil
+	return api.GetOrderByID(apiContext(w, r), orderID)
 }
 
-func updateOrderByID(r *http.Request) (any, error) {
-	orderID := mux.Vars(r)["order_id"]
-	var payload types.Order
-	var response struct {
-		ID           int         `json:"updated_id"`
-		OldOrder     types.Order `json:"old_order"`
-		UpdatedOrder types.Order `json:"updated_order"`
+func updateOrderByID(w http.ResponseWriter, r *http.Request) (int64, error) {
+	orderID, err := pathID(r, "order_id")
+	if err != nil {
+		return 0, err
 	}
+	var payload types.Order
 	if err := utils.ParseJSON(r, &payload); err != nil {
-		return nil, err
-	}
-	m, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	req, err := http.NewRequest("PATCH", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/orders"+fmt.Sprintf("/%v/update", orderID), bytes.NewBuffer(m))
+	payload.ID = orderID
+	return api.UpdateOrder(apiContext(w, r), payload)
+}
+
+func deleteOrderByID(w http.ResponseWriter, r *http.Request) (int64, error) {
+	orderID, err := pathID(r, "order_id")
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	return api.DeleteOrderByID(apiContext(w, r), orderID)
+}
+
+func getCustomers(w http.ResponseWriter, r *http.Request) ([]types.User, error) {
+	return api.GetUsers(apiContext(w, r))
+}
+
+func getCustomerByID(w http.ResponseWriter, r *http.Request) (*types.User, error) {
+	customerID, err := pathID(r, "customer_id")
 	if err != nil {
 		return nil, err
 	}
-	resBody, err := io.ReadAll(res.Body)
+	return api.GetUserByID(apiContext(w, r), customerID)
+}
+
+func updateCustomerByID(w http.ResponseWriter, r *http.Request) (int64, error) {
+	customerID, err := pathID(r, "customer_id")
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		return nil, err
+	var payload types.User
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		return 0, err
 	}
-	return response, nil
+	payload.ID = customerID
+	return api.UpdateUser(apiContext(w, r), payload)
 }
 
-func deleteOrderByID(r *http.Request) (any, error) {
-	orderID := mux.Vars(r)["order_id"]
-	var response struct {
-		ID      int          `json:"deleted_id"`
-		Deleted *types.Order `json:"deleted_order"`
-	}
-	req, err := http.NewRequest("DELETE", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/orders"+fmt.Sprintf("/%v/delete", orderID), nil)
+func deleteCustomerByID(w http.ResponseWriter, r *http.Request) (int64, error) {
+	customerID, err := pathID(r, "customer_id")
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
+	return api.DeleteUserByID(apiContext(w, r), customerID)
+}
+
+func getProducts(w http.ResponseWriter, r *http.Request) ([]types.Product, error) {
+	return api.GetProducts(apiContext(w, r))
+}
+
+func getProductByID(w http.ResponseWriter, r *http.Request) (*types.Product, error) {
+	productID, err := pathID(r, "product_id")
 	if err != nil {
 		return nil, err
 	}
-	resBody, err := io.ReadAll(res.Body)
+	return api.GetProductByID(apiContext(w, r), productID)
+}
+
+func updateProductByID(w http.ResponseWriter, r *http.Request) (int64, error) {
+	productID, err := pathID(r, "product_id")
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		return nil, err
+	var payload types.Product
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		return 0, err
 	}
-	return &response, nil
+	payload.ID = productID
+	return api.UpdateProduct(apiContext(w, r), payload)
 }
 
-func getCustomers(r *http.Request) ([]types.User, error) {
-	var payload []types.User
-	req, err := http.NewRequest("GET", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/users", nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
+func deleteProductByID(w http.ResponseWriter, r *http.Request) (int64, error) {
+	productID, err := pathID(r, "product_id")
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
+	return api.DeleteProductByID(apiContext(w, r), productID)
+}
 
-	resBody, err := io.ReadAll(res.Body)
+func getStockMovements(w http.ResponseWriter, r *http.Request) ([]types.StockMovement, error) {
+	productID, err := pathID(r, "product_id")
 	if err != nil {
 		return nil, err
 	}
+	return api.GetStockMovements(apiContext(w, r), productID)
+}
 
-	if err := json.Unmarshal(resBody, &payload); err != nil {
-		return nil, err
-	}
-	return payload, nil
+func getStockDrift(w http.ResponseWriter, r *http.Request) ([]types.StockDrift, error) {
+	return api.GetStockDrift(apiContext(w, r))
 }
 
-func getCustomerByID(r *http.Request) (*types.User, error) {
-	customerID := mux.Vars(r)["customer_id"]
-	var payload types.User
-	req, err := http.NewRequest("GET", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/users"+fmt.Sprintf("/%v", customerID), nil)
+// createStockReceipt, createStockCount and createStockTransfer forward the
+// forms of the inventory page.
+func createStockReceipt(w http.ResponseWriter, r *http.Request) error {
+	productID, err := pathID(r, "product_id")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
+	qty, err := strconv.Atoi(r.FormValue("qty"))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid quantity")
 	}
-	resBody, err := io.ReadAll(res.Body)
+	warehouseID, err := strconv.Atoi(r.FormValue("warehouse_id"))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid warehouse")
 	}
-	if err := json.Unmarshal(resBody, &payload); err != nil {
-		return nil, err
-	}
-	return &payload, nil
+	payload := types.StockReceiptPayload{WarehouseID: warehouseID, Quantity: qty, Reason: r.FormValue("reason"), Reference: r.FormValue("reference")}
+	_, err = api.CreateStockReceipt(apiContext(w, r), productID, payload)
+	return err
 }
 
-func updateCustomerByID(r *http.Request) (any, error) {
-	customerID := mux.Vars(r)["customer_id"]
-	var payload types.User
-	var response struct {
-		ID              int        `json:"updated_id"`
-		OldCustomer     types.User `json:"old_customer"`
-		UpdatedCustomer types.User `json:"updated_customer"`
-	}
-	if err := utils.ParseJSON(r, &payload); err != nil {
-		return nil, err
-	}
-	m, err := json.Marshal(payload)
+func createStockCount(w http.ResponseWriter, r *http.Request) error {
+	productID, err := pathID(r, "product_id")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	req, err := http.NewRequest("PATCH", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/users"+fmt.Sprintf("/%v/update", customerID), bytes.NewBuffer(m))
+	qty, err := strconv.Atoi(r.FormValue("qty"))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid quantity")
 	}
-	res, err := http.DefaultClient.Do(req)
+	warehouseID, err := strconv.Atoi(r.FormValue("warehouse_id"))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid warehouse")
 	}
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		return nil, err
-	}
-	return response, nil
+	payload := types.StockCountPayload{WarehouseID: warehouseID, Quantity: qty, Reference: r.FormValue("reference")}
+	_, err = api.CreateStockCount(apiContext(w, r), productID, payload)
+	return err
 }
 
-func deleteCustomerByID(r *http.Request) (any, error) {
-	customerID := mux.Vars(r)["customer_id"]
-	var response struct {
-		ID      int         `json:"deleted_id"`
-		Deleted *types.User `json:"deleted_customer"`
+func createStockTransfer(w http.ResponseWriter, r *http.Request) error {
+	productID, err := pathID(r, "product_id")
+	if err != nil {
+		return err
 	}
-	req, err := http.NewRequest("DELETE", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/users/"+fmt.Sprintf("/%v/delete", customerID), nil)
+	qty, err := strconv.Atoi(r.FormValue("qty"))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid quantity")
 	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
+	from, err := strconv.Atoi(r.FormValue("from_warehouse_id"))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid warehouse")
 	}
-	resBody, err := io.ReadAll(res.Body)
+	to, err := strconv.Atoi(r.FormValue("to_warehouse_id"))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid warehouse")
 	}
-	if err := json.Unmarshal(resBody, &response); err != nil {
+	payload := types.StockTransferPayload{FromWarehouseID: from, ToWarehouseID: to, Quantity: qty, Reference: r.FormValue("reference")}
+	return api.CreateStockTransfer(apiContext(w, r), productID, payload)
+}
+
+func getProductWarehouseStock(w http.ResponseWriter, r *http.Request) ([]types.WarehouseStock, error) {
+	productID, err := pathID(r, "product_id")
+	if err != nil {
 		return nil, err
 	}
-	return &response, nil
+	return api.GetProductWarehouseStock(apiContext(w, r), productID)
+}
+
+func getWarehouses(w http.ResponseWriter, r *http.Request) ([]types.Warehouse, error) {
+	return api.GetWarehouses(apiContext(w, r))
 }
 
-func getProducts(r *http.Request) ([]types.Product, error) {
-	var payload []types.Product
-	req, err := http.NewRequest("GET", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/products", nil)
+func getWarehouseStock(w http.ResponseWriter, r *http.Request) ([]types.WarehouseStock, error) {
+	warehouseID, err := pathID(r, "warehouse_id")
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
+	return api.GetWarehouseStock(apiContext(w, r), warehouseID)
+}
+
+func createWarehouse(w http.ResponseWriter, r *http.Request) error {
+	latitude, err := strconv.ParseFloat(r.FormValue("latitude"), 64)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid latitude")
 	}
-	resBody, err := io.ReadAll(res.Body)
+	longitude, err := strconv.ParseFloat(r.FormValue("longitude"), 64)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid longitude")
 	}
+	payload := types.WarehousePayload{Name: r.FormValue("name"), Address: r.FormValue("address"), Latitude: latitude, Longitude: longitude}
+	_, err = api.CreateWarehouse(apiContext(w, r), payload)
+	return err
+}
 
-	if err := json.Unmarshal(resBody, &payload); err != nil {
-		return nil, err
-	}
-	return payload, nil
+func getReorderSuggestions(w http.ResponseWriter, r *http.Request) ([]types.ReorderSuggestion, error) {
+	return api.GetReorderSuggestions(apiContext(w, r), 0, 0)
 }
 
-func getProductByID(r *http.Request) (*types.Product, error) {
-	productID := mux.Vars(r)["product_id"]
-	var payload types.Product
-	req, err := http.NewRequest("GET", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/products"+fmt.Sprintf("/%v", productID), nil)
+func setStockThreshold(w http.ResponseWriter, r *http.Request) error {
+	productID, err := pathID(r, "product_id")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
+	threshold, err := strconv.Atoi(r.FormValue("threshold"))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid threshold")
 	}
-	resBody, err := io.ReadAll(res.Body)
+	return api.SetStockThreshold(apiContext(w, r), productID, types.StockThresholdPayload{Threshold: threshold})
+}
+
+// importProducts uploads the file of the form, the format is its extension.
+// A large file is imported in the background and only the job is returned.
+func importProducts(w http.ResponseWriter, r *http.Request) (*types.ProductImportResult, *types.ProductImportJob, error) {
+	file, header, err := r.FormFile("file")
 	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(resBody, &payload); err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("no file to import: %v", err)
 	}
-	return &payload, nil
+	defer file.Close()
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	return api.ImportProducts(apiContext(w, r), format, file, r.FormValue("dry_run") == "on")
 }
 
-func updateProductByID(r *http.Request) (any, error) {
-	productID := mux.Vars(r)["product_id"]
-	var payload types.Product
-	var response struct {
-		ID             int           `json:"updated_id"`
-		OldProduct     types.Product `json:"old_product"`
-		UpdatedProduct types.Product `json:"updated_product"`
-	}
-	if err := utils.ParseJSON(r, &payload); err != nil {
-		return nil, err
-	}
-	m, err := json.Marshal(payload)
+func getImportJob(w http.ResponseWriter, r *http.Request) (*types.ProductImportJob, error) {
+	return api.GetImportJob(apiContext(w, r), mux.Vars(r)["job_id"])
+}
+
+// exportProducts streams the export of the API to the browser as a download.
+func exportProducts(w http.ResponseWriter, r *http.Request) error {
+	download, err := api.DownloadProducts(apiContext(w, r), r.URL.Query().Get("format"))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	req, err := http.NewRequest("PATCH", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/products"+fmt.Sprintf("/%v/update", productID), bytes.NewBuffer(m))
-	if err != nil {
-		return nil, err
+	defer download.Close()
+	w.Header().Set("Content-Type", download.ContentType)
+	w.Header().Set("Content-Disposition", download.ContentDisposition)
+	_, err = io.Copy(w, download)
+	return err
+}
+
+// getReviews forwards the filter in the query of the page, the pending reviews
+// by default.
+func getReviews(w http.ResponseWriter, r *http.Request) ([]types.Review, error) {
+	query := r.URL.Query()
+	if !query.Has("status") {
+		query.Set("status", types.ReviewPending)
 	}
-	res, err := http.DefaultClient.Do(req)
+	filter, err := reviews.ParseFilter(query)
 	if err != nil {
 		return nil, err
 	}
-	resBody, err := io.ReadAll(res.Body)
+	return api.GetReviews(apiContext(w, r), filter)
+}
+
+func setReviewStatus(w http.ResponseWriter, r *http.Request) error {
+	reviewID, err := pathID(r, "review_id")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		return nil, err
-	}
-	return response, nil
+	_, err = api.SetReviewStatus(apiContext(w, r), reviewID, types.ReviewStatusPayload{Status: r.FormValue("status")})
+	return err
 }
 
-func deleteProductByID(r *http.Request) (any, error) {
-	productID := mux.Vars(r)["product_id"]
-	var response struct {
-		ID      int            `json:"deleted_id"`
-		Deleted *types.Product `json:"deleted_product"`
-	}
-	req, err := http.NewRequest("DELETE", config.Envs.PublicHost+":"+config.Envs.Port+"/api/v1/products/"+fmt.Sprintf("/%v/delete", productID), nil)
+func replyToReview(w http.ResponseWriter, r *http.Request) error {
+	reviewID, err := pathID(r, "review_id")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	req.Header.Set("Authorization", r.Header.Get("Authorization"))
-	req.Header.Set("Authorization-X", r.Header.Get("Authorization-X"))
-	res, err := http.DefaultClient.Do(req)
+	_, err = api.ReplyToReview(apiContext(w, r), reviewID, types.ReviewReplyPayload{Reply: r.FormValue("reply")})
+	return err
+}
+
+func getNotifications(w http.ResponseWriter, r *http.Request) (*types.NotificationInbox, error) {
+	filter, err := notifications.ParseFilter(r.URL.Query())
 	if err != nil {
-		return nil, err
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
-	resBody, err := io.ReadAll(res.Body)
+	return api.GetNotifications(apiContext(w, r), filter)
+}
+
+func markNotificationRead(w http.ResponseWriter, r *http.Request) error {
+	notificationID, err := pathID(r, "notification_id")
 	if err != nil {
-		return nil, err
+		return status.Error(codes.InvalidArgument, err.Error())
 	}
-	if err := json.Unmarshal(resBody, &response); err != nil {
-		return nil, err
+	return api.MarkNotificationRead(apiContext(w, r), notificationID)
+}
+
+func markAllNotificationsRead(w http.ResponseWriter, r *http.Request) error {
+	_, err := api.MarkAllNotificationsRead(apiContext(w, r))
+	return err
+}
+
+// openNotificationStream opens the notification stream of the session on the
+// API from the Last-Event-ID of the page, it is closed with the page. The
+// caller closes the stream.
+func openNotificationStream(w http.ResponseWriter, r *http.Request) (*client.NotificationStream, error) {
+	lastID := 0
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("invalid Last-Event-ID")
+		}
+		lastID = id
 	}
-	return &response, nil
+	return api.OpenNotifications(apiContext(w, r), lastID)
 }
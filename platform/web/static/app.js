@@ -256,16 +256,13 @@ register_submit.addEventListener('click', () => {
     .then(data => {
         console.log(body_register_json)
         console.log(data)
-        if (data.verify_url) {
+        if (data.message) {
             // alert('success register')
             let type = 'success';
             let icon = 'fa-solid fa-circle-check';
             let title = 'Daftar Akun Berhasil';
-            let text = `Kamu telah berhasil mendaftarkan akun , mohon verifikasi dengan mengakses link berikut (dalam 5 detik), agar akun dapat digunakan : ${data.verify_url}`;
+            let text = 'Kamu telah berhasil mendaftarkan akun, mohon cek email kamu dan verifikasi akun melalui link yang dikirimkan agar akun dapat digunakan.';
             createToast(type, icon, title, text);
-            // setTimeout(() => window.location = `${data.verify_url}`, 5000)
-            setTimeout(() => window.open(`${data.verify_url}`), 5000)
-            // window.open(`${data.verify_url}`)
         }
         
         if (data != null && data.error) {
@@ -344,8 +341,6 @@ closeCart.addEventListener('click', () => {
 
 checkoutCart.addEventListener('click', () => {
     // console.log("Authorization : ", localStorage.getItem("Authorization"))
-    var messageForWhatsapp = ``
-    var total = 0
     if (cart_json != ``) {
         fetch("/api/v1/cart/checkout", {
             method: "POST",
@@ -381,7 +376,7 @@ checkoutCart.addEventListener('click', () => {
                 "customer": {
                     "name": "TJ Jeans", 
                     "email": "${localStorage.getItem('username')}", 
-                    "phone_number": "089505208391"
+                    "phone_number": ""
                 }, 
                 "items": ${JSON.stringify(data.items)}
                 }`
@@ -393,15 +388,8 @@ checkoutCart.addEventListener('click', () => {
                     }
                 }).then(response => response.json())
                 .then(data => {
-                    console.log(data)
-                    messageForWhatsapp += `Atas nama data penjual\n\nMerchant : ${data.billing_address.name}\nNo Telp : ${data.billing_address.phone_number}\n\nAtas nama data pembeli (isi data dengan lengkap)\n\nNama : \nEmail: ${localStorage.getItem('username')}\nNo Telp : \nAlamat Tujuan Pengiriman : \nBarang Yang Dibeli : \n`
-                    for (i = 0; i < data.items.length; i++) {
-                        messageForWhatsapp += `Produk -> ${data.items[i].name} -> Jumlah ${data.items[i].qty} -> Harga Satuan ${data.items[i].unit_price}\n`
-                        total += data.items[i].qty * data.items[i].unit_price
-                    }
-                    messageForWhatsapp += `\nUntuk pembayaran totalnya seharga Rp ${total} (silahkan konfirmasi jika data sudah benar), lalu akses url berikut ${data.payment.redirect_url}\nMohon mengirimkan bukti transfer ketika sudah maka proses pengiriman dapat dilakukan, terima kasih.`
-
-                    window.open('http://wa.me/089505208391?text='+ encodeURIComponent(messageForWhatsapp))
+                    // the order is sent to the phone of the customer by the API
+                    window.open(data.payment.redirect_url)
                 })
             }
         })
@@ -555,22 +543,6 @@ const changeQuantityCart = (product_id, type) => {
     addCartToMemory();
 }
 
-// const initApp_Demo = () => {
-//     // get data product
-//     fetch('/static/products.json')
-//     .then(response => response.json())
-//     .then(data => {
-//         products = data;
-//         addDataToHTML();
-
-//         // get data cart from memory
-        // if(localStorage.getItem('cart')){
-        //     cart = JSON.parse(localStorage.getItem('cart'));
-        //     addCartToHTML();
-        // }
-//     })
-// }
-// initApp_Demo();
 // fetch('/api/v1/product').then(response => response.json()).then(data => console.log(data))
 
 const initApp = () => {
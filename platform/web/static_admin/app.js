@@ -15,7 +15,56 @@ let toggle = document.querySelector(".toggle");
 let navigation = document.querySelector(".navigation");
 let main = document.querySelector(".main");
 
-toggle.onclick = function () {
-  navigation.classList.toggle("active");
-  main.classList.toggle("active");
-};
+if (toggle) {
+  toggle.onclick = function () {
+    navigation.classList.toggle("active");
+    main.classList.toggle("active");
+  };
+}
+
+// Live notifications, the new and paid orders show up without a refresh
+let liveNotifications = document.createElement("div");
+liveNotifications.className = "liveNotifications";
+document.body.appendChild(liveNotifications);
+
+function showLiveNotification(notification) {
+  let item = document.createElement("a");
+  item.className = "liveNotification";
+  item.href = notification.link || "#";
+  item.innerHTML = `<strong></strong><span></span>`;
+  item.querySelector("strong").textContent = notification.title;
+  item.querySelector("span").textContent = notification.body;
+  item.addEventListener("click", () => {
+    fetch(`/notifications/${notification.id}/read`, { method: "POST" });
+  });
+  liveNotifications.appendChild(item);
+  setTimeout(() => item.remove(), 8000);
+}
+
+// the orders table is swapped with the one of a fresh render of the page
+function refreshOrders() {
+  let tbody = document.querySelector(".recentOrders tbody");
+  if (!tbody || location.pathname != "/admin/orders") {
+    return;
+  }
+  fetch(location.href)
+    .then((response) => response.text())
+    .then((html) => {
+      let page = new DOMParser().parseFromString(html, "text/html");
+      let fresh = page.querySelector(".recentOrders tbody");
+      if (fresh) {
+        tbody.innerHTML = fresh.innerHTML;
+      }
+    });
+}
+
+if (window.EventSource) {
+  let stream = new EventSource("/notifications/stream");
+  stream.addEventListener("notification", (event) => {
+    let notification = JSON.parse(event.data);
+    showLiveNotification(notification);
+    if (notification.kind == "new_order" || notification.kind == "payment_received") {
+      refreshOrders();
+    }
+  });
+}
@@ -43,7 +43,17 @@ func Login_Register(username string, role string) templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<div class=\"wrapper\" id=\"login_register_page\"><div class=\"form-box\"><div class=\"login-container\" id=\"login\"><div class=\"top\"><span>Belum punya akun? <a href=\"#\" onclick=\"register()\">Daftar</a></span><header>Masuk</header></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Email\" id=\"login_email_input\"> <i class=\"bx bx-user\"></i></div><div class=\"input-box\"><input type=\"password\" class=\"input-field\" placeholder=\"Password\" id=\"login_password_input\"> <i class=\"bx bx-lock-alt\"></i></div><div class=\"input-box\"><input type=\"submit\" class=\"submit\" value=\"Masuk\"></div><div class=\"two-col\"><div class=\"one\"><input type=\"checkbox\" id=\"login-check\"> <label for=\"login-check\">Ingatkan Saya</label></div><div class=\"two\"><label><a href=\"#\">Lupa Password?</a></label></div></div></div><div class=\"register-container\" id=\"register\"><div class=\"top\"><span>Sudah punya akun? <a href=\"#\" onclick=\"login()\">Masuk</a></span><header>Daftar</header></div><div class=\"two-forms\"><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Nama Depan\" id=\"register_firstname_input\"> <i class=\"bx bx-user\"></i></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Nama Belakang\" id=\"register_lastname_input\"> <i class=\"bx bx-user\"></i></div></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Email\" id=\"register_email_input\"> <i class=\"bx bx-envelope\"></i></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Nomor Telepon\" id=\"register_phoneNumber_input\"> <i class=\"bx bx-phone\"></i></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Alamat Rumah/Pengiriman\" id=\"register_address_input\"> <i class=\"bx bx-package\"></i></div><div class=\"input-box\"><input type=\"password\" class=\"input-field\" placeholder=\"Password\" id=\"register_password_input\"> <i class=\"bx bx-lock-alt\"></i></div><div class=\"input-box\"><input type=\"submit\" class=\"submit\" value=\"Daftar\"></div><div class=\"two-col\"><div class=\"one\"><input type=\"checkbox\" id=\"register-check\"> <label for=\"register-check\">Ingatkan Saya</label></div><div class=\"two\"><label><a href=\"#\">Syarat & Ketentuan</a></label></div></div></div></div></div>")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<div class=\"wrapper\" id=\"login_register_page\"><div class=\"form-box\"><div class=\"login-container\" id=\"login\"><div class=\"top\"><span>Belum punya akun? <a href=\"#\" onclick=\"register()\">Daftar</a></span><header>Masuk</header></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Email\" id=\"login_email_input\"> <i class=\"bx bx-user\"></i></div><div class=\"input-box\"><input type=\"password\" class=\"input-field\" placeholder=\"Password\" id=\"login_password_input\"> <i class=\"bx bx-lock-alt\"></i></div><div class=\"input-box\"><input type=\"submit\" class=\"submit\" value=\"Masuk\"></div><div class=\"oidc-providers\" id=\"oidc_providers\"></div><div class=\"two-col\"><div class=\"one\"><input type=\"checkbox\" id=\"login-check\"> <label for=\"login-check\">Ingatkan Saya</label></div><div class=\"two\"><label><a href=\"#\" onclick=\"password(&#39;forgot&#39;)\">Lupa Password?</a></label></div></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if username != "" {
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<div class=\"two-col\"><div class=\"two\"><label><a href=\"#\" onclick=\"password(&#39;change&#39;)\">Ganti Password</a></label></div></div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div><div class=\"register-container\" id=\"register\"><div class=\"top\"><span>Sudah punya akun? <a href=\"#\" onclick=\"login()\">Masuk</a></span><header>Daftar</header></div><div class=\"two-forms\"><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Nama Depan\" id=\"register_firstname_input\"> <i class=\"bx bx-user\"></i></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Nama Belakang\" id=\"register_lastname_input\"> <i class=\"bx bx-user\"></i></div></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Email\" id=\"register_email_input\"> <i class=\"bx bx-envelope\"></i></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Nomor Telepon\" id=\"register_phoneNumber_input\"> <i class=\"bx bx-phone\"></i></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Alamat Rumah/Pengiriman\" id=\"register_address_input\"> <i class=\"bx bx-package\"></i></div><div class=\"input-box\"><input type=\"password\" class=\"input-field\" placeholder=\"Password\" id=\"register_password_input\"> <i class=\"bx bx-lock-alt\"></i></div><div class=\"input-box\"><input type=\"submit\" class=\"submit\" value=\"Daftar\"></div><div class=\"two-col\"><div class=\"one\"><input type=\"checkbox\" id=\"register-check\"> <label for=\"register-check\">Ingatkan Saya</label></div><div class=\"two\"><label><a href=\"#\">Syarat & Ketentuan</a></label></div></div></div><div class=\"password-container\" id=\"password\"><div class=\"password-form\" id=\"password_forgot\"><div class=\"top\"><span>Sudah ingat password? <a href=\"#\" onclick=\"login()\">Masuk</a></span><header>Lupa Password</header></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Email\" id=\"forgot_email_input\"> <i class=\"bx bx-envelope\"></i></div><div class=\"input-box\"><input type=\"submit\" class=\"submit\" value=\"Kirim Link Reset\" id=\"forgot_submit\"></div></div><div class=\"password-form\" id=\"password_reset\"><div class=\"top\"><span>Sudah ingat password? <a href=\"#\" onclick=\"login()\">Masuk</a></span><header>Reset Password</header></div><div class=\"input-box\"><input type=\"password\" class=\"input-field\" placeholder=\"Password Baru\" id=\"reset_password_input\"> <i class=\"bx bx-lock-alt\"></i></div><div class=\"input-box\"><input type=\"submit\" class=\"submit\" value=\"Reset Password\" id=\"reset_submit\"></div></div><div class=\"password-form\" id=\"password_mfa\"><div class=\"top\"><span>Bukan akun kamu? <a href=\"#\" onclick=\"login()\">Masuk</a></span><header>Verifikasi 2 Langkah</header></div><div class=\"input-box\" id=\"mfa_enrolment\" style=\"display: none;\"><span>Pindai atau buka <a href=\"#\" id=\"mfa_provisioning_uri\">link ini</a> di aplikasi authenticator, atau masukkan kode rahasia <b id=\"mfa_secret\"></b></span></div><div class=\"input-box\"><input type=\"text\" class=\"input-field\" placeholder=\"Kode Authenticator / Kode Pemulihan\" id=\"mfa_code_input\"> <i class=\"bx bx-shield\"></i></div><div class=\"input-box\"><input type=\"submit\" class=\"submit\" value=\"Verifikasi\" id=\"mfa_submit\"></div></div><div class=\"password-form\" id=\"password_change\"><div class=\"top\"><span>Batal ganti password? <a href=\"#\" onclick=\"login()\">Kembali</a></span><header>Ganti Password</header></div><div class=\"input-box\"><input type=\"password\" class=\"input-field\" placeholder=\"Password Sekarang\" id=\"change_current_password_input\"> <i class=\"bx bx-lock-alt\"></i></div><div class=\"input-box\"><input type=\"password\" class=\"input-field\" placeholder=\"Password Baru\" id=\"change_new_password_input\"> <i class=\"bx bx-lock-alt\"></i></div><div class=\"input-box\"><input type=\"submit\" class=\"submit\" value=\"Ganti Password\" id=\"change_submit\"></div></div></div></div></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -59,7 +69,7 @@ func Login_Register(username string, role string) templ.Component {
 					}()
 				}
 				ctx = templ.InitializeContext(ctx)
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<script>\r\n        var a = document.getElementById(\"loginBtn\");\r\n        var b = document.getElementById(\"registerBtn\");\r\n        var x = document.getElementById(\"login\");\r\n        var y = document.getElementById(\"register\");\r\n\r\n        let register_submit = document.querySelector('.register-container .submit')\r\n        let login_submit = document.querySelector('.login-container .submit')\r\n        \r\n        \r\n        function createToast(type, icon, title, text){\r\n            let newToast = document.createElement('div');\r\n            newToast.innerHTML = `\r\n                <div class=\"toast ${type}\">\r\n                    <i class=\"${icon}\"></i>\r\n                    <div class=\"content\">\r\n                        <div class=\"title\">${title}</div>\r\n                        <span>${text}</span>\r\n                    </div>\r\n                    <i class=\"fa-solid fa-xmark\" onclick=\"(this.parentElement).remove()\"></i>\r\n                </div>`;\r\n            notifications.appendChild(newToast);\r\n            newToast.timeOut = setTimeout(\r\n                ()=>newToast.remove(), 5000\r\n            )\r\n        }\r\n\r\n        function login() {\r\n            x.style.left = \"4px\";\r\n            y.style.right = \"-520px\";\r\n            // a.className += \" white-btn\";\r\n            // b.className = \"btn\";\r\n            x.style.opacity = 1;\r\n            y.style.opacity = 0;\r\n        }\r\n\r\n        function register() {\r\n            x.style.left = \"-510px\";\r\n            y.style.right = \"5px\";\r\n            // a.className = \"btn\";\r\n            // b.className += \" white-btn\";\r\n            x.style.opacity = 0;\r\n            y.style.opacity = 1;\r\n        }\r\n\r\n        login_submit.addEventListener('click', () => {\r\n            let email_login = document.querySelector('#login_email_input')\r\n            let password_login = document.querySelector('#login_password_input')\r\n            // let start_login_json = `{ `;\r\n            // let body_login_json = ` \"email\": \"${username_login.value}\", \"password\": \"${password_login.value}\"`;\r\n            // let end_login_json = ` }`;\r\n            // let login_json = start_login_json + body_login_json + end_login_json\r\n            try {\r\n                fetch(\"/service/login\", {\r\n                method: \"POST\",\r\n                body: JSON.stringify({email: `${email_login.value}`, password: `${password_login.value}`}),\r\n                headers: {\r\n                    \"Content-Type\": \"application/json; charset=UTF-8\"\r\n                }\r\n            }).then(response => response.json())\r\n            .then((data) => {\r\n                // console.log(body_login_json)\r\n                console.log(data)\r\n                // console.log(response.headers.getSetCookie())\r\n                if (data.error) {\r\n                    // alert(data.error)\r\n                    let type = 'error';\r\n                    let icon = 'fa-solid fa-circle-exclamation';\r\n                    let title = 'Masuk Akun Gagal';\r\n                    let text = 'Masukkan username/email dan password dengan benar, beralih ke halaman utama.';\r\n                    createToast(type, icon, title, text);\r\n\r\n                    setTimeout(() => window.location = 'http://localhost:8080', 5000)\r\n                    \r\n                    \r\n                } \r\n                \r\n                if (data.access_token) {\r\n                    console.log(data.access_token)\r\n                    let type = 'success';\r\n                    let icon = 'fa-solid fa-circle-check';\r\n                    let title = 'Masuk Akun Berhasil';\r\n                    let text = 'Kamu berhasil login juga dapat berbelanja di menu produk untuk checkout sekaligus bayar.';\r\n                    createToast(type, icon, title, text);\r\n                    setTimeout(() => window.location = 'http://localhost:8080', 5000)\r\n                    }\r\n                }\r\n            )\r\n\r\n            } catch(error) {\r\n                console.log(error)\r\n            }\r\n            \r\n            \r\n            // console.log(username_login.value)\r\n            // console.log(password_login.value)\r\n    \r\n    \r\n        })\r\n\r\n        register_submit.addEventListener('click', () => {\r\n            // {\r\n            //     \"email\": \"me@me.com\",\r\n            //     \"password\": \"asd\",\r\n            //     \"firstName\": \"tiago\",\r\n            //     \"lastName\": \"user\"\r\n            // }\r\n            let first_name_register = document.querySelector('#register_firstname_input')\r\n            let last_name_register = document.querySelector('#register_lastname_input')\r\n            let email_register = document.querySelector('#register_email_input')\r\n            let phone_number_register = document.querySelector('#register_phoneNumber_input')\r\n            let address_register = document.querySelector(`#register_address_input`)\r\n            let password_register = document.querySelector('#register_password_input')\r\n            // let start_register_json = `{ `;\r\n            // let body_register_json = `\"email\": \"${email_register.value}\", \"password\": \"${password_register.value}\", \"firstName\": \"${firstName_register.value}\", \"lastName\": \"${lastName_register.value}\"`;\r\n            // let end_register_json = ` }`;\r\n            // let register_json = start_register_json + body_register_json + end_register_json\r\n            // console.log(register_json)\r\n            // alert('clicked register')\r\n\r\n            fetch(\"/service/register\", {\r\n                method: \"POST\",\r\n                body: JSON.stringify({email: `${email_register.value}`, address: `${address_register.value}`,phone_number: `${phone_number_register.value}`, password: `${password_register.value}`, first_name: `${first_name_register.value}`, last_name: `${last_name_register.value}`}),\r\n                headers: {\r\n                \"Content-Type\": \"application/json; charset=UTF-8\"\r\n                }\r\n            }).then(response => response.json())\r\n            .then(data => {\r\n                // console.log(body_register_json)\r\n                console.log(data)\r\n                if (data.verify_url != \"\") {\r\n                    // alert('success register')\r\n                    let type = 'success';\r\n                    let icon = 'fa-solid fa-circle-check';\r\n                    let title = 'Daftar Akun Berhasil';\r\n                    let text = `Kamu telah berhasil mendaftarkan akun , mohon verifikasi dengan mengakses link berikut (dalam 5 detik), agar akun dapat digunakan : ${data.verify_url}`;\r\n                    createToast(type, icon, title, text);\r\n                    // setTimeout(() => window.location = `${data.verify_url}`, 5000)\r\n                    setTimeout(() => window.open(`${data.verify_url}`), 5000)\r\n                    // window.open(`${data.verify_url}`)\r\n                }\r\n                \r\n                if (data.error) {\r\n                    // alert(data.error)\r\n                    let type = 'error';\r\n                    let icon = 'fa-solid fa-circle-exclamation';\r\n                    let title = 'Daftar Akun Gagal';\r\n                    let text = 'Kamu gagal melakukan daftar akun lalu akan beralih ke halaman utama.';\r\n                    createToast(type, icon, title, text);\r\n                    setTimeout(() => window.location = 'http://localhost:8080', 5000)\r\n                } \r\n                \r\n            })\r\n            \r\n        })\r\n    </script>")
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<script>\n        var a = document.getElementById(\"loginBtn\");\n        var b = document.getElementById(\"registerBtn\");\n        var x = document.getElementById(\"login\");\n        var y = document.getElementById(\"register\");\n        var z = document.getElementById(\"password\");\n\n        let register_submit = document.querySelector('.register-container .submit')\n        let login_submit = document.querySelector('.login-container .submit')\n        \n        \n        function createToast(type, icon, title, text){\n            let newToast = document.createElement('div');\n            newToast.innerHTML = `\n                <div class=\"toast ${type}\">\n                    <i class=\"${icon}\"></i>\n                    <div class=\"content\">\n                        <div class=\"title\">${title}</div>\n                        <span>${text}</span>\n                    </div>\n                    <i class=\"fa-solid fa-xmark\" onclick=\"(this.parentElement).remove()\"></i>\n                </div>`;\n            notifications.appendChild(newToast);\n            newToast.timeOut = setTimeout(\n                ()=>newToast.remove(), 5000\n            )\n        }\n\n        function login() {\n            x.style.left = \"4px\";\n            y.style.right = \"-520px\";\n            z.style.right = \"-520px\";\n            // a.className += \" white-btn\";\n            // b.className = \"btn\";\n            x.style.opacity = 1;\n            y.style.opacity = 0;\n            z.style.opacity = 0;\n        }\n\n        function register() {\n            x.style.left = \"-510px\";\n            y.style.right = \"5px\";\n            z.style.right = \"-520px\";\n            // a.className = \"btn\";\n            // b.className += \" white-btn\";\n            x.style.opacity = 0;\n            y.style.opacity = 1;\n            z.style.opacity = 0;\n        }\n\n        function password(mode) {\n            document.querySelectorAll('.password-form').forEach((form) => form.style.display = \"none\");\n            document.getElementById(`password_${mode}`).style.display = \"flex\";\n            x.style.left = \"-510px\";\n            y.style.right = \"-520px\";\n            z.style.right = \"5px\";\n            x.style.opacity = 0;\n            y.style.opacity = 0;\n            z.style.opacity = 1;\n        }\n\n        let reset_token = new URLSearchParams(window.location.search).get('reset_token')\n        if (reset_token) {\n            password('reset')\n        }\n\n        let mfa_token = ''\n\n        fetch(\"/service/login/oidc\").then(response => response.json())\n        .then((data) => {\n            (data.providers || []).forEach((provider) => {\n                let link = document.createElement('a');\n                link.className = 'submit';\n                link.href = `/service/login/oidc/${encodeURIComponent(provider)}`;\n                link.textContent = `Masuk dengan ${provider.charAt(0).toUpperCase() + provider.slice(1)}`;\n                document.querySelector('#oidc_providers').appendChild(link);\n            })\n        })\n\n        let oidc_params = new URLSearchParams(window.location.search)\n        if (oidc_params.get('oidc_error')) {\n            createToast('error', 'fa-solid fa-circle-exclamation', 'Masuk Akun Gagal', oidc_params.get('oidc_error'));\n        }\n        if (oidc_params.get('profile_incomplete')) {\n            createToast('success', 'fa-solid fa-circle-check', 'Masuk Akun Berhasil', 'Lengkapi nomor telepon dan alamat pengiriman kamu sebelum checkout.');\n        }\n\n        function enrolMFA() {\n            fetch(\"/service/login/mfa/enroll\", {\n                method: \"POST\",\n                body: JSON.stringify({mfa_token: `${mfa_token}`}),\n                headers: {\n                    \"Content-Type\": \"application/json; charset=UTF-8\"\n                }\n            }).then(response => response.json())\n            .then((data) => {\n                if (data.error) {\n                    createToast('error', 'fa-solid fa-circle-exclamation', 'Verifikasi 2 Langkah Gagal', data.error);\n                    return\n                }\n                document.querySelector('#mfa_provisioning_uri').href = data.provisioning_uri\n                document.querySelector('#mfa_secret').textContent = data.secret\n                document.querySelector('#mfa_enrolment').style.display = \"block\"\n            })\n        }\n\n        if (oidc_params.get('mfa_token')) {\n            mfa_token = oidc_params.get('mfa_token')\n            if (oidc_params.get('mfa_enrolment_required')) {\n                enrolMFA()\n            }\n            password('mfa')\n        }\n\n        document.querySelector('#mfa_submit').addEventListener('click', () => {\n            let code_mfa = document.querySelector('#mfa_code_input')\n            fetch(\"/service/login/mfa\", {\n                method: \"POST\",\n                body: JSON.stringify({mfa_token: `${mfa_token}`, code: `${code_mfa.value}`}),\n                headers: {\n                    \"Content-Type\": \"application/json; charset=UTF-8\"\n                }\n            }).then(response => response.json())\n            .then((data) => {\n                if (data.error) {\n                    createToast('error', 'fa-solid fa-circle-exclamation', 'Verifikasi 2 Langkah Gagal', data.error);\n                    return\n                }\n                let text = 'Kamu berhasil login juga dapat berbelanja di menu produk untuk checkout sekaligus bayar.';\n                if (data.recovery_codes) {\n                    text = 'Simpan kode pemulihan yang tampil di tempat yang aman, kode hanya ditampilkan sekali.';\n                    document.querySelector('#mfa_enrolment').textContent = `Kode pemulihan : ${data.recovery_codes.join(', ')}`\n                    document.querySelector('#mfa_enrolment').style.display = \"block\"\n                }\n                createToast('success', 'fa-solid fa-circle-check', 'Masuk Akun Berhasil', text);\n                setTimeout(() => window.location = '/', data.recovery_codes ? 30000 : 5000)\n            })\n        })\n\n        function submitPassword(url, body, title, text) {\n            fetch(url, {\n                method: \"POST\",\n                body: JSON.stringify(body),\n                headers: {\n                    \"Content-Type\": \"application/json; charset=UTF-8\"\n                }\n            }).then(response => response.json())\n            .then((data) => {\n                console.log(data)\n                if (data.error) {\n                    createToast('error', 'fa-solid fa-circle-exclamation', `${title} Gagal`, data.error);\n                    return\n                }\n                createToast('success', 'fa-solid fa-circle-check', `${title} Berhasil`, text);\n                setTimeout(() => window.location = '/service', 5000)\n            })\n        }\n\n        document.querySelector('#forgot_submit').addEventListener('click', () => {\n            let email_forgot = document.querySelector('#forgot_email_input')\n            submitPassword(\"/service/password/forgot\", {email: `${email_forgot.value}`}, 'Lupa Password', 'Jika email terdaftar, link reset password telah dikirim ke email kamu.')\n        })\n\n        document.querySelector('#reset_submit').addEventListener('click', () => {\n            let password_reset = document.querySelector('#reset_password_input')\n            submitPassword(\"/service/password/reset\", {token: `${reset_token}`, password: `${password_reset.value}`}, 'Reset Password', 'Password kamu telah direset, silakan masuk kembali.')\n        })\n\n        document.querySelector('#change_submit').addEventListener('click', () => {\n            let current_password_change = document.querySelector('#change_current_password_input')\n            let new_password_change = document.querySelector('#change_new_password_input')\n            submitPassword(\"/service/password/change\", {current_password: `${current_password_change.value}`, new_password: `${new_password_change.value}`}, 'Ganti Password', 'Password kamu telah diganti, sesi lain telah dikeluarkan.')\n        })\n\n        login_submit.addEventListener('click', () => {\n            let email_login = document.querySelector('#login_email_input')\n            let password_login = document.querySelector('#login_password_input')\n            // let start_login_json = `{ `;\n            // let body_login_json = ` \"email\": \"${username_login.value}\", \"password\": \"${password_login.value}\"`;\n            // let end_login_json = ` }`;\n            // let login_json = start_login_json + body_login_json + end_login_json\n            try {\n                fetch(\"/service/login\", {\n                method: \"POST\",\n                body: JSON.stringify({email: `${email_login.value}`, password: `${password_login.value}`}),\n                headers: {\n                    \"Content-Type\": \"application/json; charset=UTF-8\"\n                }\n            }).then(response => response.json())\n            .then((data) => {\n                // console.log(body_login_json)\n                console.log(data)\n                // console.log(response.headers.getSetCookie())\n                if (data.error) {\n                    // alert(data.error)\n                    let type = 'error';\n                    let icon = 'fa-solid fa-circle-exclamation';\n                    let title = 'Masuk Akun Gagal';\n                    let text = 'Masukkan username/email dan password dengan benar, beralih ke halaman utama.';\n                    createToast(type, icon, title, text);\n\n                    setTimeout(() => window.location = 'http://localhost:8080', 5000)\n                    \n                    \n                } \n                \n                if (data.mfa_required) {\n                    mfa_token = data.mfa_token\n                    if (data.mfa_enrolment_required) {\n                        enrolMFA()\n                    }\n                    password('mfa')\n                    return\n                }\n\n                if (data.access_token) {\n                    console.log(data.access_token)\n                    let type = 'success';\n                    let icon = 'fa-solid fa-circle-check';\n                    let title = 'Masuk Akun Berhasil';\n                    let text = 'Kamu berhasil login juga dapat berbelanja di menu produk untuk checkout sekaligus bayar.';\n                    createToast(type, icon, title, text);\n                    setTimeout(() => window.location = 'http://localhost:8080', 5000)\n                    }\n                }\n            )\n\n            } catch(error) {\n                console.log(error)\n            }\n            \n            \n            // console.log(username_login.value)\n            // console.log(password_login.value)\n    \n    \n        })\n\n        register_submit.addEventListener('click', () => {\n            // {\n            //     \"email\": \"me@me.com\",\n            //     \"password\": \"asd\",\n            //     \"firstName\": \"tiago\",\n            //     \"lastName\": \"user\"\n            // }\n            let first_name_register = document.querySelector('#register_firstname_input')\n            let last_name_register = document.querySelector('#register_lastname_input')\n            let email_register = document.querySelector('#register_email_input')\n            let phone_number_register = document.querySelector('#register_phoneNumber_input')\n            let address_register = document.querySelector(`#register_address_input`)\n            let password_register = document.querySelector('#register_password_input')\n            // let start_register_json = `{ `;\n            // let body_register_json = `\"email\": \"${email_register.value}\", \"password\": \"${password_register.value}\", \"firstName\": \"${firstName_register.value}\", \"lastName\": \"${lastName_register.value}\"`;\n            // let end_register_json = ` }`;\n            // let register_json = start_register_json + body_register_json + end_register_json\n            // console.log(register_json)\n            // alert('clicked register')\n\n            fetch(\"/service/register\", {\n                method: \"POST\",\n                body: JSON.stringify({email: `${email_register.value}`, address: `${address_register.value}`,phone_number: `${phone_number_register.value}`, password: `${password_register.value}`, first_name: `${first_name_register.value}`, last_name: `${last_name_register.value}`}),\n                headers: {\n                \"Content-Type\": \"application/json; charset=UTF-8\"\n                }\n            }).then(response => response.json())\n            .then(data => {\n                // console.log(body_register_json)\n                console.log(data)\n                if (data.message) {\n                    // alert('success register')\n                    let type = 'success';\n                    let icon = 'fa-solid fa-circle-check';\n                    let title = 'Daftar Akun Berhasil';\n                    let text = 'Kamu telah berhasil mendaftarkan akun, mohon cek email kamu dan verifikasi akun melalui link yang dikirimkan agar akun dapat digunakan.';\n                    createToast(type, icon, title, text);\n                }\n                \n                if (data.error) {\n                    // alert(data.error)\n                    let type = 'error';\n                    let icon = 'fa-solid fa-circle-exclamation';\n                    let title = 'Daftar Akun Gagal';\n                    let text = 'Kamu gagal melakukan daftar akun lalu akan beralih ke halaman utama.';\n                    createToast(type, icon, title, text);\n                    setTimeout(() => window.location = 'http://localhost:8080', 5000)\n                } \n                \n            })\n            \n        })\n    </script>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -87,7 +87,7 @@ func Page(nav bool, username string, role string) templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<script>\r\n        let notifications = document.querySelector('.notifications');\r\n        function myMenuFunction() {\r\n                var i = document.getElementById(\"navMenu\");\r\n\r\n                if(i.className === \"nav-menu\") {\r\n                    i.className += \" responsive\";\r\n                } else {\r\n                    i.className = \"nav-menu\";\r\n                }\r\n        }\r\n        \r\n        function hasActiveClass(el, className)\r\n        {\r\n            if (el.classList) {\r\n                return el.classList.contains(className);\r\n            }\r\n            return !!el.className.match(new RegExp('(\\\\s|^)' + className + '(\\\\s|$)'));\r\n        }\r\n\r\n        function addActiveClass(el, className)\r\n        {\r\n            if (el.classList)\r\n                el.classList.add(className)\r\n            else if (!hasClass(el, className))\r\n                el.className += \" \" + className;\r\n        }\r\n\r\n        function removeActiveClass(el, className)\r\n        {\r\n            if (el.classList)\r\n                el.classList.remove(className)\r\n            else if (hasClass(el, className))\r\n            {\r\n                var reg = new RegExp('(\\\\s|^)' + className + '(\\\\s|$)');\r\n                el.className = el.className.replace(reg, ' ');\r\n            }\r\n        }\r\n    </script>")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<script>\r\n        let notifications = document.querySelector('.notifications');\r\n        function myMenuFunction() {\r\n                var i = document.getElementById(\"navMenu\");\r\n\r\n                if(i.className === \"nav-menu\") {\r\n                    i.className += \" responsive\";\r\n                } else {\r\n                    i.className = \"nav-menu\";\r\n                }\r\n        }\r\n        \r\n        function hasActiveClass(el, className)\r\n        {\r\n            if (el.classList) {\r\n                return el.classList.contains(className);\r\n            }\r\n            return !!el.className.match(new RegExp('(\\\\s|^)' + className + '(\\\\s|$)'));\r\n        }\r\n\r\n        function addActiveClass(el, className)\r\n        {\r\n            if (el.classList)\r\n                el.classList.add(className)\r\n            else if (!hasClass(el, className))\r\n                el.className += \" \" + className;\r\n        }\r\n\r\n        function removeActiveClass(el, className)\r\n        {\r\n            if (el.classList)\r\n                el.classList.remove(className)\r\n            else if (hasClass(el, className))\r\n            {\r\n                var reg = new RegExp('(\\\\s|^)' + className + '(\\\\s|$)');\r\n                el.className = el.className.replace(reg, ' ');\r\n            }\r\n        }\r\n        // the payment and the shipping of the orders show up live\r\n        if (window.EventSource) {\r\n            let notificationStream = new EventSource('/notifications/stream');\r\n            notificationStream.addEventListener('notification', (event) => {\r\n                let notification = JSON.parse(event.data);\r\n                let toast = document.createElement('div');\r\n                toast.innerHTML = `\r\n                    <div class=\"toast info\">\r\n                        <i class=\"fa-solid fa-bell\"></i>\r\n                        <div class=\"content\">\r\n                            <div class=\"title\"></div>\r\n                            <span></span>\r\n                        </div>\r\n                        <i class=\"fa-solid fa-xmark\" onclick=\"(this.parentElement).remove()\"></i>\r\n                    </div>`;\r\n                toast.querySelector('.title').textContent = notification.title;\r\n                toast.querySelector('.content span').textContent = notification.body;\r\n                notifications.appendChild(toast);\r\n                setTimeout(() => toast.remove(), 8000);\r\n            })\r\n        }\r\n    </script>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -56,7 +56,7 @@ func Products(products []types.Product, username string, role string) templ.Comp
 					return templ_7745c5c3_Err
 				}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div></div><div class=\"cartTab\"><h1>Keranjang Belanja</h1><div class=\"listCart\"></div><div class=\"btn\"><button class=\"close\">TUTUP</button> <button class=\"checkOut\">CHECKOUT</button></div></div><script>\r\n        let listProductHTML = document.querySelector('.listProduct');\r\n        let listCartHTML = document.querySelector('.listCart');\r\n        let iconCart = document.querySelector('.icon-cart');\r\n        let iconCartSpan = document.querySelector('.icon-cart span');\r\n        let body = document.querySelector('body');\r\n        let closeCart = document.querySelector('.close');\r\n        let products = [];\r\n        let cart = [];\r\n        let checkoutCart = document.querySelector('.checkOut');\r\n\r\n\r\n\r\n        let yippie = document.getElementsByClassName('yippie')\r\n    \r\n        function createToast(type, icon, title, text){\r\n            let newToast = document.createElement('div');\r\n            newToast.innerHTML = `\r\n                <div class=\"toast ${type}\">\r\n                    <i class=\"${icon}\"></i>\r\n                    <div class=\"content\">\r\n                        <div class=\"title\">${title}</div>\r\n                        <span>${text}</span>\r\n                    </div>\r\n                    <i class=\"fa-solid fa-xmark\" onclick=\"(this.parentElement).remove()\"></i>\r\n                </div>`;\r\n            notifications.appendChild(newToast);\r\n            newToast.timeOut = setTimeout(\r\n                ()=>newToast.remove(), 5000\r\n            )\r\n        }\r\n\r\n        iconCart.addEventListener('click', () => {\r\n            body.classList.toggle('showCart');\r\n        })\r\n\r\n        closeCart.addEventListener('click', () => {\r\n            body.classList.toggle('showCart');\r\n        })\r\n\r\n        checkoutCart.addEventListener('click', () => {\r\n            var messageForWhatsapp = ``\r\n            var total = 0\r\n\r\n            if (localStorage.getItem(\"cart\") != ``) {\r\n                fetch(\"/cart/checkout\", {\r\n                    method: \"POST\",\r\n                    body: `{ \"items\" : ${ JSON.stringify(cart) } }`,\r\n                    headers: {\r\n                        \"Content-Type\": \"application/json; charset=UTF-8\"\r\n                    }\r\n                }).then(response => response.json())\r\n                .then(data => {\r\n                    console.log(data)\r\n                    if (data.error) {\r\n                        // alert(data.error)\r\n                        let type = 'warning';\r\n                        let icon = 'fa-solid fa-triangle-exclamation';\r\n                        let title = 'Simpan Keranjang Belanja Gagal';\r\n                        let text = 'Kamu harus login terlebih dahulu untuk melakukan penyimpanan pembelian.';\r\n                        createToast(type, icon, title, text);\r\n                    } \r\n                    else {\r\n                        let type = 'success';\r\n                        let icon = 'fa-solid fa-circle-check';\r\n                        let title = 'Simpan Keranjang Belanja Berhasil';\r\n                        let text = 'Kamu telah menyimpan barang pembelian dan melakukan checkout/pembelian ke payment xendit/midtrans.';\r\n                        createToast(type, icon, title, text);\r\n                        \r\n                    }\r\n                })\r\n            }\r\n        })\r\n\r\n\r\n\r\n        \r\n        listProductHTML.addEventListener('click', (event) => {\r\n            let positionClick = event.target;\r\n            \r\n            if(positionClick.classList.contains('addCart')){\r\n                let id_product = positionClick.parentElement.dataset.id;\r\n                \r\n                addToCart(id_product);\r\n            }\r\n        })\r\n        const addToCart = (product_id) => {\r\n            let positionThisProductInCart = cart.findIndex((value) => value.product_id == product_id);\r\n            if(cart.length <= 0){\r\n                cart = [{\r\n                    product_id: Number(product_id),\r\n                    qty: 1\r\n                }];\r\n            }else if(positionThisProductInCart < 0){\r\n                cart.push({\r\n                    product_id: Number(product_id),\r\n                    qty: 1\r\n                });\r\n            }else{\r\n                cart[positionThisProductInCart].qty = cart[positionThisProductInCart].qty + 1;\r\n            }\r\n            addCartToHTML();\r\n            addCartToMemory();\r\n        }\r\n        const addCartToMemory = () => {\r\n            localStorage.setItem('cart', JSON.stringify(cart));\r\n        }\r\n        const addCartToHTML = () => {\r\n            listCartHTML.innerHTML = '';\r\n            let totalQuantity = 0;\r\n            \r\n            if(cart.length > 0){\r\n                cart.forEach((item, index) => {\r\n                    totalQuantity = totalQuantity +  item.qty;\r\n                    let newItem = document.createElement('div');\r\n                    newItem.classList.add('item');\r\n                    newItem.dataset.id = item.product_id;\r\n\r\n                    let positionProduct = products.findIndex((value) => value.id == item.product_id);\r\n                    let info = products[positionProduct];\r\n                    listCartHTML.appendChild(newItem);\r\n                    newItem.innerHTML = `\r\n                    <div class=\"image\">\r\n                            <img src=\"/platform/web/static/${info.image}\">\r\n                        </div>\r\n                        <div class=\"name\">\r\n                        ${info.name}\r\n                        </div>\r\n                        <div class=\"totalPrice\">IDR ${info.price * item.qty}</div>\r\n                        <div class=\"quantity\">\r\n                            <span class=\"minus\"><</span>\r\n                            <span>${item.qty}</span>\r\n                            <span class=\"plus\">></span>\r\n                        </div>\r\n                    `;\r\n                    \r\n                    \r\n                })\r\n\r\n\r\n            }\r\n            iconCartSpan.innerText = totalQuantity;\r\n        }\r\n\r\n        listCartHTML.addEventListener('click', (event) => {\r\n            let positionClick = event.target;\r\n            if(positionClick.classList.contains('minus') || positionClick.classList.contains('plus')){\r\n                let product_id = positionClick.parentElement.parentElement.dataset.id;\r\n                let type = 'minus';\r\n                if(positionClick.classList.contains('plus')){\r\n                    type = 'plus';\r\n                }\r\n                changeQuantityCart(product_id, type);\r\n            }\r\n        })\r\n        const changeQuantityCart = (product_id, type) => {\r\n            let positionItemInCart = cart.findIndex((value) => value.product_id == product_id);\r\n            if(positionItemInCart >= 0){\r\n                let info = cart[positionItemInCart];\r\n                switch (type) {\r\n                    case 'plus':\r\n                        cart[positionItemInCart].quantity = cart[positionItemInCart].quantity + 1;\r\n                        break;\r\n                \r\n                    default:\r\n                        let changeQuantity = cart[positionItemInCart].quantity - 1;\r\n                        if (changeQuantity > 0) {\r\n                            cart[positionItemInCart].quantity = changeQuantity;\r\n                        }else{\r\n                            cart.splice(positionItemInCart, 1);\r\n                        }\r\n                        break;\r\n                }\r\n            }\r\n            addCartToHTML();\r\n            addCartToMemory();\r\n        }\r\n\r\n        const initApp = () => {\r\n            // get data product\r\n            fetch('/products/get')\r\n            .then(response => response.json())\r\n            .then(data => {\r\n                // console.log(data)\r\n                products = data;\r\n                \r\n\r\n                // get data cart from memory\r\n                if(localStorage.getItem('cart')){\r\n                    cart = JSON.parse(localStorage.getItem('cart'));\r\n                    addCartToHTML();\r\n                }\r\n                addCart = document.querySelector('.addCart')\r\n                \r\n            })\r\n        }\r\n\r\n\r\n        initApp();\r\n\r\n    </script>")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div></div><div class=\"cartTab\"><h1>Keranjang Belanja</h1><div class=\"listCart\"></div><div class=\"btn\"><button class=\"close\">TUTUP</button> <button class=\"checkOut\">CHECKOUT</button></div></div><script>\r\n        let listProductHTML = document.querySelector('.listProduct');\r\n        let listCartHTML = document.querySelector('.listCart');\r\n        let iconCart = document.querySelector('.icon-cart');\r\n        let iconCartSpan = document.querySelector('.icon-cart span');\r\n        let body = document.querySelector('body');\r\n        let closeCart = document.querySelector('.close');\r\n        let products = [];\r\n        let cart = [];\r\n        let checkoutCart = document.querySelector('.checkOut');\r\n\r\n\r\n\r\n        let yippie = document.getElementsByClassName('yippie')\r\n    \r\n        function createToast(type, icon, title, text){\r\n            let newToast = document.createElement('div');\r\n            newToast.innerHTML = `\r\n                <div class=\"toast ${type}\">\r\n                    <i class=\"${icon}\"></i>\r\n                    <div class=\"content\">\r\n                        <div class=\"title\">${title}</div>\r\n                        <span>${text}</span>\r\n                    </div>\r\n                    <i class=\"fa-solid fa-xmark\" onclick=\"(this.parentElement).remove()\"></i>\r\n                </div>`;\r\n            notifications.appendChild(newToast);\r\n            newToast.timeOut = setTimeout(\r\n                ()=>newToast.remove(), 5000\r\n            )\r\n        }\r\n\r\n        iconCart.addEventListener('click', () => {\r\n            body.classList.toggle('showCart');\r\n        })\r\n\r\n        closeCart.addEventListener('click', () => {\r\n            body.classList.toggle('showCart');\r\n        })\r\n\r\n        checkoutCart.addEventListener('click', () => {\r\n            if (localStorage.getItem(\"cart\") != ``) {\r\n                fetch(\"/cart/checkout\", {\r\n                    method: \"POST\",\r\n                    body: `{ \"items\" : ${ JSON.stringify(cart) } }`,\r\n                    headers: {\r\n                        \"Content-Type\": \"application/json; charset=UTF-8\"\r\n                    }\r\n                }).then(response => response.json())\r\n                .then(data => {\r\n                    console.log(data)\r\n                    if (data.error) {\r\n                        // alert(data.error)\r\n                        let type = 'warning';\r\n                        let icon = 'fa-solid fa-triangle-exclamation';\r\n                        let title = 'Simpan Keranjang Belanja Gagal';\r\n                        let text = 'Kamu harus login terlebih dahulu untuk melakukan penyimpanan pembelian.';\r\n                        createToast(type, icon, title, text);\r\n                    } \r\n                    else {\r\n                        let type = 'success';\r\n                        let icon = 'fa-solid fa-circle-check';\r\n                        let title = 'Simpan Keranjang Belanja Berhasil';\r\n                        let text = 'Kamu telah menyimpan barang pembelian dan melakukan checkout/pembelian ke payment xendit/midtrans. Detail pesanan dikirim ke nomor telepon kamu.';\r\n                        createToast(type, icon, title, text);\r\n                        \r\n                    }\r\n                })\r\n            }\r\n        })\r\n\r\n\r\n\r\n        \r\n        listProductHTML.addEventListener('click', (event) => {\r\n            let positionClick = event.target;\r\n            \r\n            if(positionClick.classList.contains('addCart')){\r\n                let id_product = positionClick.parentElement.dataset.id;\r\n                \r\n                addToCart(id_product);\r\n            }\r\n        })\r\n        const addToCart = (product_id) => {\r\n            let positionThisProductInCart = cart.findIndex((value) => value.product_id == product_id);\r\n            if(cart.length <= 0){\r\n                cart = [{\r\n                    product_id: Number(product_id),\r\n                    qty: 1\r\n                }];\r\n            }else if(positionThisProductInCart < 0){\r\n                cart.push({\r\n                    product_id: Number(product_id),\r\n                    qty: 1\r\n                });\r\n            }else{\r\n                cart[positionThisProductInCart].qty = cart[positionThisProductInCart].qty + 1;\r\n            }\r\n            addCartToHTML();\r\n            addCartToMemory();\r\n        }\r\n        const addCartToMemory = () => {\r\n            localStorage.setItem('cart', JSON.stringify(cart));\r\n        }\r\n        const addCartToHTML = () => {\r\n            listCartHTML.innerHTML = '';\r\n            let totalQuantity = 0;\r\n            \r\n            if(cart.length > 0){\r\n                cart.forEach((item, index) => {\r\n                    totalQuantity = totalQuantity +  item.qty;\r\n                    let newItem = document.createElement('div');\r\n                    newItem.classList.add('item');\r\n                    newItem.dataset.id = item.product_id;\r\n\r\n                    let positionProduct = products.findIndex((value) => value.id == item.product_id);\r\n                    let info = products[positionProduct];\r\n                    listCartHTML.appendChild(newItem);\r\n                    newItem.innerHTML = `\r\n                    <div class=\"image\">\r\n                            <img src=\"/platform/web/static/${info.image}\">\r\n                        </div>\r\n                        <div class=\"name\">\r\n                        ${info.name}\r\n                        </div>\r\n                        <div class=\"totalPrice\">IDR ${info.price * item.qty}</div>\r\n                        <div class=\"quantity\">\r\n                            <span class=\"minus\"><</span>\r\n                            <span>${item.qty}</span>\r\n                            <span class=\"plus\">></span>\r\n                        </div>\r\n                    `;\r\n                    \r\n                    \r\n                })\r\n\r\n\r\n            }\r\n            iconCartSpan.innerText = totalQuantity;\r\n        }\r\n\r\n        listCartHTML.addEventListener('click', (event) => {\r\n            let positionClick = event.target;\r\n            if(positionClick.classList.contains('minus') || positionClick.classList.contains('plus')){\r\n                let product_id = positionClick.parentElement.parentElement.dataset.id;\r\n                let type = 'minus';\r\n                if(positionClick.classList.contains('plus')){\r\n                    type = 'plus';\r\n                }\r\n                changeQuantityCart(product_id, type);\r\n            }\r\n        })\r\n        const changeQuantityCart = (product_id, type) => {\r\n            let positionItemInCart = cart.findIndex((value) => value.product_id == product_id);\r\n            if(positionItemInCart >= 0){\r\n                let info = cart[positionItemInCart];\r\n                switch (type) {\r\n                    case 'plus':\r\n                        cart[positionItemInCart].quantity = cart[positionItemInCart].quantity + 1;\r\n                        break;\r\n                \r\n                    default:\r\n                        let changeQuantity = cart[positionItemInCart].quantity - 1;\r\n                        if (changeQuantity > 0) {\r\n                            cart[positionItemInCart].quantity = changeQuantity;\r\n                        }else{\r\n                            cart.splice(positionItemInCart, 1);\r\n                        }\r\n                        break;\r\n                }\r\n            }\r\n            addCartToHTML();\r\n            addCartToMemory();\r\n        }\r\n\r\n        const initApp = () => {\r\n            // get data product\r\n            fetch('/products/get')\r\n            .then(response => response.json())\r\n            .then(data => {\r\n                // console.log(data)\r\n                products = data;\r\n                \r\n\r\n                // get data cart from memory\r\n                if(localStorage.getItem('cart')){\r\n                    cart = JSON.parse(localStorage.getItem('cart'));\r\n                    addCartToHTML();\r\n                }\r\n                addCart = document.querySelector('.addCart')\r\n                \r\n            })\r\n        }\r\n\r\n\r\n        initApp();\r\n\r\n    </script>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -1,12 +1,14 @@
 package web
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 
 	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/inbox"
 	"github.com/fayleenpc/tj-jeans/internal/session"
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	"github.com/fayleenpc/tj-jeans/internal/utils"
@@ -39,17 +41,44 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/service", auth.WithCookie(h.showServicePage, h.store)).Methods("GET")
 	router.HandleFunc("/service/register", auth.WithCookie(h.handleRegisterService, h.store)).Methods("POST")
 	router.HandleFunc("/service/login", h.handleLoginService).Methods("POST")
+	router.HandleFunc("/service/login/mfa", h.handleLoginMFAService).Methods("POST")
+	router.HandleFunc("/service/login/mfa/enroll", h.handleLoginMFAEnrollService).Methods("POST")
+	router.HandleFunc("/service/login/oidc", h.handleOIDCProvidersService).Methods("GET")
+	router.HandleFunc("/service/login/oidc/{provider}", h.handleOIDCAuthorizeService).Methods("GET")
+	router.HandleFunc("/service/login/oidc/{provider}/callback", h.handleOIDCCallbackService).Methods("GET")
 	router.HandleFunc("/service/logout", auth.WithCookie(h.handleLogoutService, h.store)).Methods("POST")
 	router.HandleFunc("/service/refresh", auth.WithCookie(h.handleRefreshService, h.store)).Methods("POST")
+	router.HandleFunc("/service/password/forgot", h.handleForgotPasswordService).Methods("POST")
+	router.HandleFunc("/service/password/reset", h.handleResetPasswordService).Methods("POST")
+	router.HandleFunc("/service/password/change", auth.WithCookie(h.handleChangePasswordService, h.store)).Methods("POST")
 
 	router.HandleFunc("/cart/checkout", auth.WithCookie(h.handleCheckoutService, h.store)).Methods("POST")
 
+	router.HandleFunc("/notifications", auth.WithCookie(h.handleGetNotifications, h.store)).Methods("GET")
+	router.HandleFunc("/notifications/stream", auth.WithCookie(h.handleStreamNotifications, h.store)).Methods("GET")
+	router.HandleFunc("/notifications/read", auth.WithCookie(h.handleMarkAllNotificationsRead, h.store)).Methods("POST")
+	router.HandleFunc("/notifications/{notification_id}/read", auth.WithCookie(h.handleMarkNotificationRead, h.store)).Methods("POST")
+
 	router.HandleFunc("/admin", auth.WithCookie(h.showAdminPage, h.store)).Methods("GET")
 	router.HandleFunc("/admin/order_items", auth.WithCookie(h.showAdminOrderItemsPage, h.store)).Methods("GET")
 	router.HandleFunc("/admin/order_items/{order_item_id}", auth.WithCookie(h.handleGetOrderItemByID, h.store)).Methods("GET")
 	router.HandleFunc("/admin/order_items/{order_item_id}/update", auth.WithCookie(h.handleUpdateOrderItemByID, h.store)).Methods("PATCH")
 	router.HandleFunc("/admin/order_items/{order_item_id}/delete", auth.WithCookie(h.handleDeleteOrderItemByID, h.store)).Methods("DELETE")
 
+	router.HandleFunc("/admin/audit_logs", auth.WithCookie(h.showAdminAuditLogsPage, h.store)).Methods("GET")
+	router.HandleFunc("/admin/reviews", auth.WithCookie(h.showAdminReviewsPage, h.store)).Methods("GET")
+	router.HandleFunc("/admin/reviews/{review_id}/status", auth.WithCookie(h.handleSetReviewStatus, h.store)).Methods("POST")
+	router.HandleFunc("/admin/reviews/{review_id}/reply", auth.WithCookie(h.handleReplyToReview, h.store)).Methods("POST")
+
+	router.HandleFunc("/admin/inventory", auth.WithCookie(h.showAdminInventoryPage, h.store)).Methods("GET")
+	router.HandleFunc("/admin/inventory/warehouses", auth.WithCookie(h.handleCreateWarehouse, h.store)).Methods("POST")
+	router.HandleFunc("/admin/inventory/warehouses/{warehouse_id}", auth.WithCookie(h.showAdminWarehousePage, h.store)).Methods("GET")
+	router.HandleFunc("/admin/inventory/{product_id}", auth.WithCookie(h.showAdminStockPage, h.store)).Methods("GET")
+	router.HandleFunc("/admin/inventory/{product_id}/receipts", auth.WithCookie(h.handleCreateStockReceipt, h.store)).Methods("POST")
+	router.HandleFunc("/admin/inventory/{product_id}/counts", auth.WithCookie(h.handleCreateStockCount, h.store)).Methods("POST")
+	router.HandleFunc("/admin/inventory/{product_id}/transfers", auth.WithCookie(h.handleCreateStockTransfer, h.store)).Methods("POST")
+	router.HandleFunc("/admin/inventory/{product_id}/threshold", auth.WithCookie(h.handleSetStockThreshold, h.store)).Methods("POST")
+
 	router.HandleFunc("/admin/orders", auth.WithCookie(h.showAdminOrdersPage, h.store)).Methods("GET")
 	router.HandleFunc("/admin/orders/{order_id}", auth.WithCookie(h.handleGetOrderByID, h.store)).Methods("GET")
 	router.HandleFunc("/admin/orders/{order_id}/update", auth.WithCookie(h.handleUpdateOrderByID, h.store)).Methods("PATCH")
@@ -64,53 +93,101 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/admin/products", auth.WithCookie(h.handleCreateProducts, h.store)).Methods("POST")
 	router.HandleFunc("/admin/products", auth.WithCookie(h.handleUpdateProducts, h.store)).Methods("PATCH")
 	router.HandleFunc("/admin/products", auth.WithCookie(h.handleDeleteProducts, h.store)).Methods("DELETE")
+	router.HandleFunc("/admin/products/import", auth.WithCookie(h.handleImportProducts, h.store)).Methods("POST")
+	router.HandleFunc("/admin/products/imports/{job_id}", auth.WithCookie(h.showAdminImportJobPage, h.store)).Methods("GET")
+	router.HandleFunc("/admin/products/export", auth.WithCookie(h.handleExportProducts, h.store)).Methods("GET")
 	router.HandleFunc("/admin/products/{product_id}", auth.WithCookie(h.handleGetProductByID, h.store)).Methods("GET")
 	router.HandleFunc("/admin/products/{product_id}/update", auth.WithCookie(h.handleUpdateProductByID, h.store)).Methods("PATCH")
 	router.HandleFunc("/admin/products/{product_id}/delete", auth.WithCookie(h.handleDeleteProductByID, h.store)).Methods("DELETE")
 }
 
 func (h *Handler) handleGetProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := getProducts(r)
+	products, err := getProducts(w, r)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteStatusError(w, err)
 		return
 	}
 	utils.WriteJSON(w, http.StatusOK, products)
 }
 
-func messageWhatsapp(r *http.Request, req types.InvoicePayload, responseInvoice types.InvoiceResponse) string {
-	body := ""
-	for k, v := range req.Items {
-		body += fmt.Sprintf("\n\t\t%v - %v, %v, qty : %v, %v %v.\n", k+1, v.Name, v.Category, v.Quantity, v.Currency, v.Price)
-	}
-	messageForWhatsapp := fmt.Sprintf("\nAtas nama data penjual\n\tMerchant : %v\n\tNo Telp : %v\n\nAtas nama data pembeli (isi data dengan lengkap)\n\tNama : %v\n\tEmail: %v\n\tNo Telp : %v\n\tAlamat Tujuan Pengiriman : %v\n\tBarang Yang Dibeli : \n\t%v\nUntuk pembayaran totalnya seharga %v %v (silahkan konfirmasi jika data sudah benar),lalu akses url berikut %v\nMohon mengirimkan bukti transfer ketika sudah maka proses pengiriman dapat dilakukan, terima kasih.",
-		"TJ Jeans",
-		"0895-0520-8391",
-		req.Customer.Name,
-		req.Customer.Email,
-		req.Customer.PhoneNumber,
-		auth.GetUserAddressFromSession(r.Header.Get("Authorization")),
-		body,
-		responseInvoice.TransactionValues.Currency,
-		int64(responseInvoice.TransactionValues.Total),
-		responseInvoice.Payment.RedirectURL,
-	)
-	log.Println(messageForWhatsapp)
-	return messageForWhatsapp
-}
-
 // Request is guarded by Authorization Header (access_token) for every commit
 func (h *Handler) handleCheckoutService(w http.ResponseWriter, r *http.Request) {
 	handleCart(w, r, func(req types.CartCheckoutPayload, responseCart types.ResponseCart) {
 		// log.Printf("location %v, got response %+v\n", r.URL.Path, responseCart)
+		// the API notifies the phone of the customer of the order
 		handleInvoice(w, r, req, responseCart, func(req types.InvoicePayload, responseInvoice types.InvoiceResponse) {
-			_ = messageWhatsapp(r, req, responseInvoice)
 			utils.WriteJSON(w, http.StatusOK, responseInvoice)
 		})
 	})
 
 }
 
+// Request is guarded by Authorization Header (access_token) for every commit
+func (h *Handler) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	inbox, err := getNotifications(w, r)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, inbox)
+}
+
+// the notifications of the API are relayed to the page as they come, a page
+// without a session gets 204 which stops its EventSource from reconnecting
+func (h *Handler) handleStreamNotifications(w http.ResponseWriter, r *http.Request) {
+	if _, ok := w.(http.Flusher); r.Header.Get("Authorization") == "" || !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	stream, err := openNotificationStream(w, r)
+	if err != nil {
+		log.Println(err)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	defer stream.Close()
+
+	live := make(chan types.Notification)
+	go func() {
+		defer close(live)
+		for {
+			notification, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					log.Println(err)
+				}
+				return
+			}
+			select {
+			case live <- *notification:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}()
+	if err := inbox.Stream(w, r, nil, live); err != nil {
+		log.Println(err)
+	}
+}
+
+// Request is guarded by Authorization Header (access_token) for every commit
+func (h *Handler) handleMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	if err := markNotificationRead(w, r); err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Request is guarded by Authorization Header (access_token) for every commit
+func (h *Handler) handleMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	if err := markAllNotificationsRead(w, r); err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // Request is guarded by Authorization Header (access_token) for every commit
 func (h *Handler) handleCreateProducts(w http.ResponseWriter, r *http.Request) {
 	createProducts(w, r, func(status int, response types.ResponseProduct) {
@@ -134,11 +211,14 @@ func (h *Handler) handleDeleteProducts(w http.ResponseWriter, r *http.Request) {
 
 // Request is guarded by Authorization-X and Authorization Header or JSON POST (access_token, secret_token) for every commit
 func (h *Handler) handleLogoutService(w http.ResponseWriter, r *http.Request) {
-	if ok := auth.ShouldRevoke(w, r); ok {
-		session.SetJWTAccessToken(w, "")
-		session.SetJWTSecretToken(w, "")
-		w.WriteHeader(http.StatusNoContent)
+	if err := api.Logout(apiContext(w, r)); err != nil {
+		log.Println(err)
+		utils.WriteStatusError(w, err)
+		return
 	}
+	session.SetJWTAccessToken(w, "")
+	session.SetJWTSecretToken(w, "")
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // Request is guarded by Authorization-X and Authorization Header or JSON POST (access_token, secret_token) for every commit
@@ -162,6 +242,81 @@ func (h *Handler) handleLoginService(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) handleLoginMFAService(w http.ResponseWriter, r *http.Request) {
+	loginMFA(w, r, func(status int, response types.ResponseLoginMFA) {
+		utils.WriteJSON(w, status, response)
+	})
+}
+
+func (h *Handler) handleLoginMFAEnrollService(w http.ResponseWriter, r *http.Request) {
+	loginMFAEnroll(w, r, func(status int, response types.ResponseMFAEnrolment) {
+		utils.WriteJSON(w, status, response)
+	})
+}
+
+func (h *Handler) handleOIDCProvidersService(w http.ResponseWriter, r *http.Request) {
+	oidcProviders(w, r, func(status int, response types.ResponseOIDCProviders) {
+		utils.WriteJSON(w, status, response)
+	})
+}
+
+// the login with a provider happens in full page redirects, errors are
+// handed back to the service page which shows them
+func (h *Handler) handleOIDCAuthorizeService(w http.ResponseWriter, r *http.Request) {
+	oidcAuthorize(w, r, func(status int, response types.ResponseOIDCAuthorization) {
+		if status != http.StatusOK {
+			http.Redirect(w, r, "/service?oidc_error="+url.QueryEscape(response.Error), http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, response.AuthorizationURL, http.StatusFound)
+	})
+}
+
+func (h *Handler) handleOIDCCallbackService(w http.ResponseWriter, r *http.Request) {
+	if providerError := r.URL.Query().Get("error"); providerError != "" {
+		session.SetOIDCFlowToken(w, "", -1)
+		http.Redirect(w, r, "/service?oidc_error="+url.QueryEscape(providerError), http.StatusFound)
+		return
+	}
+	oidcCallback(w, r, func(status int, response types.ResponseLogin) {
+		query := url.Values{}
+		switch {
+		case status != http.StatusOK:
+			query.Set("oidc_error", response.Error)
+		case response.MFARequired:
+			query.Set("mfa_token", response.MFAToken)
+			if response.MFAEnrolmentRequired {
+				query.Set("mfa_enrolment_required", "true")
+			}
+		case response.ProfileIncomplete:
+			query.Set("profile_incomplete", "true")
+		default:
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, "/service?"+query.Encode(), http.StatusFound)
+	})
+}
+
+func (h *Handler) handleForgotPasswordService(w http.ResponseWriter, r *http.Request) {
+	forgotPassword(w, r, func(status int, response types.ResponsePassword) {
+		utils.WriteJSON(w, status, response)
+	})
+}
+
+func (h *Handler) handleResetPasswordService(w http.ResponseWriter, r *http.Request) {
+	resetPassword(w, r, func(status int, response types.ResponsePassword) {
+		utils.WriteJSON(w, status, response)
+	})
+}
+
+// Request is guarded by Authorization-X and Authorization Header for every commit
+func (h *Handler) handleChangePasswordService(w http.ResponseWriter, r *http.Request) {
+	changePassword(w, r, func(status int, response types.ResponsePassword) {
+		utils.WriteJSON(w, status, response)
+	})
+}
+
 func (h *Handler) showHomePage(w http.ResponseWriter, r *http.Request) {
 	if auth.BridgeCommon(w, r) {
 		views.Home(auth.GetUserNameFromSession(r.Header.Get("Authorization")), auth.GetUserRoleFromSession(r.Header.Get("Authorization"))).Render(r.Context(), w)
@@ -171,7 +326,7 @@ func (h *Handler) showHomePage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) showProductsPage(w http.ResponseWriter, r *http.Request) {
-	ps, _ := getProducts(r)
+	ps, _ := getProducts(w, r)
 	if auth.BridgeCommon(w, r) {
 		views.Products(ps, auth.GetUserNameFromSession(r.Header.Get("Authorization")), auth.GetUserRoleFromSession(r.Header.Get("Authorization"))).Render(r.Context(), w)
 	} else {
@@ -200,18 +355,15 @@ func (h *Handler) showServicePage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) showAdminPage(w http.ResponseWriter, r *http.Request) {
-	var report types.FinanceReport
-
 	if auth.BridgeAdmin(w, r) {
-		response, code, err := utils.CraftJSON("GET", "http://localhost:8081/api/v1/finance", nil, r)
+		report, err := api.GetFinanceReport(apiContext(w, r))
 		if err != nil {
-			log.Fatal(err)
-		}
-		if err := json.Unmarshal(response, &report); err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
-		log.Printf("response from [code=%v] [finance-report] %+v\n", code, &report)
-		views_admin.Home(auth.GetUserNameFromSession(r.Header.Get("Authorization")), report).Render(r.Context(), w)
+		log.Printf("response from [finance-report] %+v\n", report)
+		views_admin.Home(auth.GetUserNameFromSession(r.Header.Get("Authorization")), *report).Render(r.Context(), w)
 	} else {
 		views_admin.Error().Render(r.Context(), w)
 	}
@@ -221,10 +373,12 @@ func (h *Handler) showAdminPage(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) showAdminOrderItemsPage(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		orderItems, err := getOrderItems(r)
+		orderItems, err := getOrderItems(w, r)
 
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		views_admin.Order_Items(auth.GetUserNameFromSession(r.Header.Get("Authorization")), orderItems).Render(r.Context(), w)
 	} else {
@@ -233,13 +387,265 @@ func (h *Handler) showAdminOrderItemsPage(w http.ResponseWriter, r *http.Request
 
 }
 
+func (h *Handler) showAdminReviewsPage(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		reviews, err := getReviews(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		products, err := getProducts(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		views_admin.Reviews(auth.GetUserNameFromSession(r.Header.Get("Authorization")), reviews, products, r.URL.Query()).Render(r.Context(), w)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) handleSetReviewStatus(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		if err := setReviewStatus(w, r); err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		http.Redirect(w, r, "/admin/reviews?"+r.URL.RawQuery, http.StatusSeeOther)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) handleReplyToReview(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		if err := replyToReview(w, r); err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		http.Redirect(w, r, "/admin/reviews?"+r.URL.RawQuery, http.StatusSeeOther)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) showAdminAuditLogsPage(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		auditLogs, err := getAuditLogs(w, r)
+
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		views_admin.Audit_Logs(auth.GetUserNameFromSession(r.Header.Get("Authorization")), auditLogs, r.URL.Query()).Render(r.Context(), w)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) showAdminInventoryPage(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		products, err := getProducts(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		drift, err := getStockDrift(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		suggestions, err := getReorderSuggestions(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		warehouses, err := getWarehouses(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		views_admin.Inventory(auth.GetUserNameFromSession(r.Header.Get("Authorization")), products, drift, suggestions, warehouses).Render(r.Context(), w)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) showAdminStockPage(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		product, err := getProductByID(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		movements, err := getStockMovements(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		warehouses, err := getWarehouses(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		stock, err := getProductWarehouseStock(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		views_admin.Stock(auth.GetUserNameFromSession(r.Header.Get("Authorization")), *product, movements, warehouses, stock).Render(r.Context(), w)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) showAdminWarehousePage(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		warehouses, err := getWarehouses(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		var warehouse *types.Warehouse
+		for i := range warehouses {
+			if fmt.Sprint(warehouses[i].ID) == mux.Vars(r)["warehouse_id"] {
+				warehouse = &warehouses[i]
+			}
+		}
+		if warehouse == nil {
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		stock, err := getWarehouseStock(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		products, err := getProducts(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		views_admin.Warehouse(auth.GetUserNameFromSession(r.Header.Get("Authorization")), *warehouse, stock, products).Render(r.Context(), w)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) handleCreateWarehouse(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		if err := createWarehouse(w, r); err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		http.Redirect(w, r, "/admin/inventory", http.StatusSeeOther)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) handleCreateStockTransfer(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		if err := createStockTransfer(w, r); err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/admin/inventory/%v", mux.Vars(r)["product_id"]), http.StatusSeeOther)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) handleCreateStockReceipt(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		if err := createStockReceipt(w, r); err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/admin/inventory/%v", mux.Vars(r)["product_id"]), http.StatusSeeOther)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) handleCreateStockCount(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		if err := createStockCount(w, r); err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/admin/inventory/%v", mux.Vars(r)["product_id"]), http.StatusSeeOther)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) handleSetStockThreshold(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		if err := setStockThreshold(w, r); err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/admin/inventory/%v", mux.Vars(r)["product_id"]), http.StatusSeeOther)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
 func (h *Handler) handleGetOrderItemByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		orderItem, err := getOrderItemByID(r)
+		orderItem, err := getOrderItemByID(w, r)
 
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(orderItem)
 	} else {
@@ -251,10 +657,12 @@ func (h *Handler) handleGetOrderItemByID(w http.ResponseWriter, r *http.Request)
 func (h *Handler) handleUpdateOrderItemByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		responseUpdateOrderItemByID, err := updateOrderItemByID(r)
+		responseUpdateOrderItemByID, err := updateOrderItemByID(w, r)
 
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(responseUpdateOrderItemByID)
 
@@ -267,10 +675,12 @@ func (h *Handler) handleUpdateOrderItemByID(w http.ResponseWriter, r *http.Reque
 func (h *Handler) handleDeleteOrderItemByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		responseDeleteOrderItemByID, err := deleteOrderItemByID(r)
+		responseDeleteOrderItemByID, err := deleteOrderItemByID(w, r)
 
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(responseDeleteOrderItemByID)
 
@@ -284,10 +694,12 @@ func (h *Handler) handleDeleteOrderItemByID(w http.ResponseWriter, r *http.Reque
 func (h *Handler) showAdminOrdersPage(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		orders, err := getOrders(r)
+		orders, err := getOrders(w, r)
 
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		views_admin.Orders(auth.GetUserNameFromSession(r.Header.Get("Authorization")), orders).Render(r.Context(), w)
 
@@ -299,10 +711,12 @@ func (h *Handler) showAdminOrdersPage(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleGetOrderByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		order, err := getOrderByID(r)
+		order, err := getOrderByID(w, r)
 
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(order)
 
@@ -316,10 +730,12 @@ func (h *Handler) handleGetOrderByID(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleUpdateOrderByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		responseUpdateOrderByID, err := updateOrderByID(r)
+		responseUpdateOrderByID, err := updateOrderByID(w, r)
 
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(responseUpdateOrderByID)
 	} else {
@@ -331,9 +747,11 @@ func (h *Handler) handleUpdateOrderByID(w http.ResponseWriter, r *http.Request)
 func (h *Handler) handleDeleteOrderByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		responseDeleteOrderByID, err := deleteOrderByID(r)
+		responseDeleteOrderByID, err := deleteOrderByID(w, r)
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(responseDeleteOrderByID)
 	} else {
@@ -345,9 +763,11 @@ func (h *Handler) handleDeleteOrderByID(w http.ResponseWriter, r *http.Request)
 func (h *Handler) showAdminCustomersPage(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		customers, err := getCustomers(r)
+		customers, err := getCustomers(w, r)
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		views_admin.Customers(auth.GetUserNameFromSession(r.Header.Get("Authorization")), customers).Render(r.Context(), w)
 	} else {
@@ -358,9 +778,11 @@ func (h *Handler) showAdminCustomersPage(w http.ResponseWriter, r *http.Request)
 func (h *Handler) handleGetCustomerByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		customer, err := getCustomerByID(r)
+		customer, err := getCustomerByID(w, r)
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(customer)
 	} else {
@@ -372,9 +794,11 @@ func (h *Handler) handleGetCustomerByID(w http.ResponseWriter, r *http.Request)
 func (h *Handler) handleUpdateCustomerByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		responseUpdateCustomerByID, err := updateCustomerByID(r)
+		responseUpdateCustomerByID, err := updateCustomerByID(w, r)
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(responseUpdateCustomerByID)
 	} else {
@@ -386,9 +810,11 @@ func (h *Handler) handleUpdateCustomerByID(w http.ResponseWriter, r *http.Reques
 func (h *Handler) handleDeleteCustomerByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		responseDeleteCustomerByID, err := deleteCustomerByID(r)
+		responseDeleteCustomerByID, err := deleteCustomerByID(w, r)
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(responseDeleteCustomerByID)
 	} else {
@@ -400,9 +826,11 @@ func (h *Handler) handleDeleteCustomerByID(w http.ResponseWriter, r *http.Reques
 func (h *Handler) showAdminProductsPage(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		products, err := getProducts(r)
+		products, err := getProducts(w, r)
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		views_admin.Products(auth.GetUserNameFromSession(r.Header.Get("Authorization")), products).Render(r.Context(), w)
 	} else {
@@ -411,12 +839,63 @@ func (h *Handler) showAdminProductsPage(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func (h *Handler) handleImportProducts(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		result, job, err := importProducts(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		if job != nil {
+			http.Redirect(w, r, fmt.Sprintf("/admin/products/imports/%v", job.ID), http.StatusSeeOther)
+			return
+		}
+		views_admin.ProductImport(auth.GetUserNameFromSession(r.Header.Get("Authorization")), *result).Render(r.Context(), w)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) showAdminImportJobPage(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		job, err := getImportJob(w, r)
+		if err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
+		}
+		views_admin.ProductImportJob(auth.GetUserNameFromSession(r.Header.Get("Authorization")), *job).Render(r.Context(), w)
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
+func (h *Handler) handleExportProducts(w http.ResponseWriter, r *http.Request) {
+
+	if auth.BridgeAdmin(w, r) {
+		if err := exportProducts(w, r); err != nil {
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+		}
+	} else {
+		views_admin.Error().Render(r.Context(), w)
+	}
+
+}
+
 func (h *Handler) handleGetProductByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		product, err := getProductByID(r)
+		product, err := getProductByID(w, r)
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(product)
 		views_admin_components.Product_Details(*product).Render(r.Context(), w)
@@ -429,9 +908,11 @@ func (h *Handler) handleGetProductByID(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleUpdateProductByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		responseUpdateProductByID, err := updateProductByID(r)
+		responseUpdateProductByID, err := updateProductByID(w, r)
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(responseUpdateProductByID)
 	} else {
@@ -443,9 +924,11 @@ func (h *Handler) handleUpdateProductByID(w http.ResponseWriter, r *http.Request
 func (h *Handler) handleDeleteProductByID(w http.ResponseWriter, r *http.Request) {
 
 	if auth.BridgeAdmin(w, r) {
-		responseDeleteProductByID, err := deleteProductByID(r)
+		responseDeleteProductByID, err := deleteProductByID(w, r)
 		if err != nil {
-			log.Fatal(err)
+			log.Println(err)
+			views_admin.Error().Render(r.Context(), w)
+			return
 		}
 		log.Println(responseDeleteProductByID)
 	} else {
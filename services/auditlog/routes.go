@@ -0,0 +1,113 @@
+package auditlog
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/ratelimiter"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type Handler struct {
+	store      types.AuditStore
+	userStore  types.UserStore
+	tokenStore types.TokenStore
+}
+
+func NewHandler(store types.AuditStore, userStore types.UserStore, tokenStore types.TokenStore) *Handler {
+	return &Handler{store: store, userStore: userStore, tokenStore: tokenStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/audit_logs", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetAuditLogs), h.userStore, h.tokenStore)).Methods("GET")
+}
+
+func (h *Handler) handleGetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetAuditLogs")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entries, err := h.store.GetAuditLogs(filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, entries)
+}
+
+// ParseFilter reads the filter of the audit log from the query of a request.
+func ParseFilter(query url.Values) (types.AuditLogFilter, error) {
+	filter := types.AuditLogFilter{
+		Action:    query.Get("action"),
+		Entity:    query.Get("entity"),
+		RequestID: query.Get("request_id"),
+		Limit:     defaultLimit,
+	}
+	ints := map[string]*int{
+		"actor_id":  &filter.ActorID,
+		"entity_id": &filter.EntityID,
+		"limit":     &filter.Limit,
+		"offset":    &filter.Offset,
+	}
+	for name, field := range ints {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("invalid %s", name)
+		}
+		*field = n
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+
+	times := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, field := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s, expected an RFC 3339 time", name)
+		}
+		*field = &t
+	}
+	return filter, nil
+}
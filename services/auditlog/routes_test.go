@@ -0,0 +1,112 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/gorilla/mux"
+)
+
+func TestAuditLogServiceHandler(t *testing.T) {
+	serve := func(store *mockAuditStore, role string, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(req.Context(), auth.UserKey, 1)
+		ctx = context.WithValue(ctx, auth.UserRoleKey, role)
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		handler := NewHandler(store, nil, nil)
+		router.HandleFunc("/audit_logs", handler.handleGetAuditLogs).Methods("GET")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should only let admins see the audit log", func(t *testing.T) {
+		if rr := serve(&mockAuditStore{}, "customer", "/audit_logs"); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+	t.Run("should fail if the filter is invalid", func(t *testing.T) {
+		if rr := serve(&mockAuditStore{}, "admin", "/audit_logs?from=yesterday"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+	t.Run("should list the filtered audit log", func(t *testing.T) {
+		store := &mockAuditStore{entries: []types.AuditLog{{ID: 2, Action: "update", Entity: "product", EntityID: 3}}}
+		rr := serve(store, "admin", "/audit_logs?entity=product&entity_id=3")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if store.filter.Entity != "product" || store.filter.EntityID != 3 {
+			t.Errorf("unexpected filter %+v", store.filter)
+		}
+		var entries []types.AuditLog
+		json.NewDecoder(rr.Body).Decode(&entries)
+		if len(entries) != 1 || entries[0].ID != 2 {
+			t.Errorf("unexpected audit log %+v", entries)
+		}
+	})
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Limit != defaultLimit || filter.From != nil || filter.To != nil {
+		t.Errorf("unexpected default filter %+v", filter)
+	}
+
+	filter, err = ParseFilter(url.Values{
+		"actor_id":   {"4"},
+		"action":     {"delete"},
+		"request_id": {"abc"},
+		"from":       {"2026-10-01T00:00:00Z"},
+		"to":         {"2026-10-02T00:00:00Z"},
+		"limit":      {"10000"},
+		"offset":     {"20"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.ActorID != 4 || filter.Action != "delete" || filter.RequestID != "abc" || filter.Offset != 20 {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+	if filter.Limit != maxLimit {
+		t.Errorf("expected the limit to be capped at %d, got %d", maxLimit, filter.Limit)
+	}
+	if filter.From == nil || filter.To == nil || !filter.From.Before(*filter.To) {
+		t.Errorf("unexpected time range %v %v", filter.From, filter.To)
+	}
+
+	for _, query := range []url.Values{{"actor_id": {"x"}}, {"offset": {"-1"}}, {"to": {"2026-10-02"}}} {
+		if _, err := ParseFilter(query); err == nil {
+			t.Errorf("expected %v to be invalid", query)
+		}
+	}
+}
+
+type mockAuditStore struct {
+	entries []types.AuditLog
+	filter  types.AuditLogFilter
+}
+
+func (m *mockAuditStore) CreateAuditLog(entry types.AuditLog) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+func (m *mockAuditStore) GetAuditLogs(filter types.AuditLogFilter) ([]types.AuditLog, error) {
+	m.filter = filter
+	return m.entries, nil
+}
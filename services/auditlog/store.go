@@ -0,0 +1,109 @@
+package auditlog
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Signature of Audit Store
+// CreateAuditLog(types.AuditLog) error
+// GetAuditLogs(types.AuditLogFilter) ([]types.AuditLog, error)
+//
+// the table is append-only, there is no update or delete and the database
+// refuses them too.
+
+func (s *Store) CreateAuditLog(entry types.AuditLog) error {
+	_, err := s.db.Exec(
+		"INSERT INTO audit_log (actorId, apiKeyId, action, entity, entityId, changes, ip, requestId, transport, createdAt) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.ActorID, entry.APIKeyID, entry.Action, entry.Entity, entry.EntityID, string(entry.Changes), entry.IP, entry.RequestID, entry.Transport, entry.CreatedAt,
+	)
+	return err
+}
+
+func (s *Store) GetAuditLogs(filter types.AuditLogFilter) ([]types.AuditLog, error) {
+	var conditions []string
+	var args []any
+	if filter.ActorID != 0 {
+		conditions = append(conditions, "actorId = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.Entity != "" {
+		conditions = append(conditions, "entity = ?")
+		args = append(args, filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		conditions = append(conditions, "entityId = ?")
+		args = append(args, filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		conditions = append(conditions, "requestId = ?")
+		args = append(args, filter.RequestID)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "createdAt >= ?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "createdAt < ?")
+		args = append(args, *filter.To)
+	}
+
+	query := "SELECT * FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]types.AuditLog, 0)
+	for rows.Next() {
+		entry, err := scanRowIntoAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, rows.Err()
+}
+
+func scanRowIntoAuditLog(rows *sql.Rows) (*types.AuditLog, error) {
+	entry := new(types.AuditLog)
+	var changes string
+	err := rows.Scan(
+		&entry.ID,
+		&entry.ActorID,
+		&entry.APIKeyID,
+		&entry.Action,
+		&entry.Entity,
+		&entry.EntityID,
+		&changes,
+		&entry.IP,
+		&entry.RequestID,
+		&entry.Transport,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entry.Changes = []byte(changes)
+	return entry, nil
+}
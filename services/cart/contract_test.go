@@ -0,0 +1,514 @@
+package cart
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/gateway"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestOrderContract runs the same scenario on the REST, the gateway and the
+// gRPC transports, they share the service so they must answer alike.
+func TestOrderContract(t *testing.T) {
+	transports := []struct {
+		name string
+		new  func(t *testing.T, service *Service) orderClient
+	}{
+		{"rest", newRESTOrderClient},
+		{"gateway", newGatewayOrderClient},
+		{"grpc", newGRPCOrderClient},
+	}
+	for _, transport := range transports {
+		t.Run(transport.name, func(t *testing.T) {
+			store := newMemoryOrderStore(types.Order{ID: 1, UserID: 1, Total: 150, Status: "pending", Address: "Jl. Braga 1"})
+			client := transport.new(t, NewService(store, &mockProductsStore{}, &mockStockStore{}, &mockUserStore{}))
+
+			expect := func(step string, got codes.Code, want codes.Code) {
+				t.Helper()
+				if got != want {
+					t.Errorf("%s: expected %v, got %v", step, want, got)
+				}
+			}
+
+			code, _ := client.get("customer", 1)
+			expect("customer get", code, codes.PermissionDenied)
+			code, orderStatus := client.get("admin", 1)
+			expect("admin get", code, codes.OK)
+			if orderStatus != "pending" {
+				t.Errorf("expected the status of order 1, got %q", orderStatus)
+			}
+			code, _ = client.get("admin", 9)
+			expect("get missing", code, codes.NotFound)
+
+			processing := types.Order{ID: 1, UserID: 1, Total: 150, Status: "processing", Address: "Jl. Braga 1"}
+			expect("customer update", client.update("customer", processing), codes.PermissionDenied)
+			expect("admin update", client.update("admin", processing), codes.OK)
+			code, orderStatus = client.get("admin", 1)
+			expect("get updated", code, codes.OK)
+			if orderStatus != "processing" {
+				t.Errorf("expected the updated status, got %q", orderStatus)
+			}
+			expect("update missing", client.update("admin", types.Order{ID: 9, Status: "processing"}), codes.NotFound)
+
+			expect("customer delete", client.delete("customer", 1), codes.PermissionDenied)
+			expect("admin delete", client.delete("admin", 1), codes.OK)
+			code, _ = client.get("admin", 1)
+			expect("get deleted", code, codes.NotFound)
+			expect("delete missing", client.delete("admin", 9), codes.NotFound)
+
+			expect("customer restore", client.restore("customer", 1), codes.PermissionDenied)
+			expect("admin restore", client.restore("admin", 1), codes.OK)
+			expect("restore kept", client.restore("admin", 1), codes.NotFound)
+			code, _ = client.get("admin", 1)
+			expect("get restored", code, codes.OK)
+		})
+	}
+}
+
+// TestOrderItemContract is TestOrderContract for the lines of the orders on
+// the REST and the gRPC transports.
+func TestOrderItemContract(t *testing.T) {
+	transports := []struct {
+		name string
+		new  func(t *testing.T, service *Service) orderItemClient
+	}{
+		{"rest", newRESTOrderItemClient},
+		{"grpc", newGRPCOrderItemClient},
+	}
+	for _, transport := range transports {
+		t.Run(transport.name, func(t *testing.T) {
+			store := newMemoryOrderStore(types.Order{ID: 1, UserID: 1, Total: 150, Status: "pending"})
+			store.items[1] = types.OrderItem{ID: 1, OrderID: 1, ProductID: 1, Quantity: 1, Price: 150, WarehouseID: 1}
+			client := transport.new(t, NewService(store, &mockProductsStore{}, &mockStockStore{}, &mockUserStore{}))
+
+			expect := func(step string, got codes.Code, want codes.Code) {
+				t.Helper()
+				if got != want {
+					t.Errorf("%s: expected %v, got %v", step, want, got)
+				}
+			}
+
+			code, _ := client.get("customer", 1)
+			expect("customer get", code, codes.PermissionDenied)
+			code, quantity := client.get("admin", 1)
+			expect("admin get", code, codes.OK)
+			if quantity != 1 {
+				t.Errorf("expected the quantity of order item 1, got %d", quantity)
+			}
+			code, _ = client.get("admin", 9)
+			expect("get missing", code, codes.NotFound)
+
+			corrected := types.OrderItem{ID: 1, OrderID: 1, ProductID: 1, Quantity: 2, Price: 150, WarehouseID: 1}
+			expect("customer update", client.update("customer", corrected), codes.PermissionDenied)
+			expect("admin update", client.update("admin", corrected), codes.OK)
+			code, quantity = client.get("admin", 1)
+			expect("get updated", code, codes.OK)
+			if quantity != 2 {
+				t.Errorf("expected the updated quantity, got %d", quantity)
+			}
+			expect("update missing", client.update("admin", types.OrderItem{ID: 9, Quantity: 1}), codes.NotFound)
+
+			expect("customer delete", client.delete("customer", 1), codes.PermissionDenied)
+			expect("admin delete", client.delete("admin", 1), codes.OK)
+			code, _ = client.get("admin", 1)
+			expect("get deleted", code, codes.NotFound)
+		})
+	}
+}
+
+// TestCheckoutRequiresUser checks the checkout is refused before the cart is
+// read when nobody is signed in.
+func TestCheckoutRequiresUser(t *testing.T) {
+	service := NewService(newMemoryOrderStore(), &mockProductsStore{}, &mockStockStore{}, &mockUserStore{})
+	conn := dialContractServer(t, func(s *grpc.Server) { NewCheckoutHandlerServer(s, service) })
+	client := pb.NewCheckoutServiceClient(conn)
+
+	_, err := client.Checkout(context.Background(), &pb.CheckoutRequest{Items: []*pb.CartItem{{ProductId: 1, Quantity: 1}}})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected %v, got %v", codes.Unauthenticated, status.Code(err))
+	}
+
+	signedIn := context.WithValue(context.Background(), auth.UserKey, 1)
+	if _, err := service.Checkout(signedIn, nil, ""); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected an empty cart to be %v, got %v", codes.InvalidArgument, status.Code(err))
+	}
+	_, err = service.Checkout(signedIn, []types.CartItem{{ProductID: 1, Quantity: 0}}, "")
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected a line without quantity to be %v, got %v", codes.InvalidArgument, status.Code(err))
+	}
+}
+
+// orderClient is a transport of the contract, it answers with the code of
+// the call and the status of the order it read.
+type orderClient interface {
+	get(role string, id int) (codes.Code, string)
+	update(role string, o types.Order) codes.Code
+	delete(role string, id int) codes.Code
+	restore(role string, id int) codes.Code
+}
+
+type httpOrderClient struct {
+	t       *testing.T
+	handler http.Handler
+	// prefix is where the transport mounts its routes
+	prefix string
+	// body is the order in the messages of the transport
+	body func(types.Order) any
+}
+
+func newRESTOrderClient(t *testing.T, service *Service) orderClient {
+	h := &Handler{service: service}
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/{order_id}/restore", h.handleRestoreOrderByID).Methods("POST")
+	router.HandleFunc("/orders/{order_id}", h.handleGetOrderByID).Methods("GET")
+	router.HandleFunc("/orders/{order_id}/update", h.handleUpdateOrderByID).Methods("PATCH")
+	router.HandleFunc("/orders/{order_id}/delete", h.handleDeleteOrderByID).Methods("DELETE")
+	return &httpOrderClient{t: t, handler: router, body: func(o types.Order) any { return o }}
+}
+
+func newGatewayOrderClient(t *testing.T, service *Service) orderClient {
+	conn := dialContractServer(t, func(s *grpc.Server) { NewHandlerServer(s, service) })
+	gatewayMux := gateway.NewServeMux()
+	if err := pb.RegisterOrderServiceHandler(context.Background(), gatewayMux, conn); err != nil {
+		t.Fatal(err)
+	}
+	return &httpOrderClient{t: t, handler: gatewayMux, prefix: "/api/v1", body: func(o types.Order) any { return orderToPB(o) }}
+}
+
+func (c *httpOrderClient) do(role string, method string, path string, body any) (codes.Code, map[string]any) {
+	c.t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+	req, err := http.NewRequest(method, c.prefix+path, &payload)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserRoleKey, role))
+	// the gateway sends it in the metadata of the call
+	req.Header.Set(runtime.MetadataHeaderPrefix+"Role", role)
+	rr := httptest.NewRecorder()
+	c.handler.ServeHTTP(rr, req)
+
+	var response map[string]any
+	json.NewDecoder(rr.Body).Decode(&response)
+	return codeOfHTTPStatus(rr.Code), response
+}
+
+func (c *httpOrderClient) get(role string, id int) (codes.Code, string) {
+	code, response := c.do(role, http.MethodGet, fmt.Sprintf("/orders/%d", id), nil)
+	orderStatus, _ := response["status"].(string)
+	return code, orderStatus
+}
+
+func (c *httpOrderClient) update(role string, o types.Order) codes.Code {
+	code, _ := c.do(role, http.MethodPatch, fmt.Sprintf("/orders/%d/update", o.ID), c.body(o))
+	return code
+}
+
+func (c *httpOrderClient) delete(role string, id int) codes.Code {
+	code, _ := c.do(role, http.MethodDelete, fmt.Sprintf("/orders/%d/delete", id), nil)
+	return code
+}
+
+func (c *httpOrderClient) restore(role string, id int) codes.Code {
+	code, _ := c.do(role, http.MethodPost, fmt.Sprintf("/orders/%d/restore", id), nil)
+	return code
+}
+
+type grpcOrderClient struct {
+	client pb.OrderServiceClient
+}
+
+func newGRPCOrderClient(t *testing.T, service *Service) orderClient {
+	conn := dialContractServer(t, func(s *grpc.Server) { NewHandlerServer(s, service) })
+	return &grpcOrderClient{client: pb.NewOrderServiceClient(conn)}
+}
+
+func (c *grpcOrderClient) get(role string, id int) (codes.Code, string) {
+	response, err := c.client.GetOrderByID(withContractRole(role), &pb.GetOrderByIDRequest{Id: int32(id)})
+	return status.Code(err), response.GetOrder().GetStatus()
+}
+
+func (c *grpcOrderClient) update(role string, o types.Order) codes.Code {
+	_, err := c.client.UpdateOrder(withContractRole(role), &pb.UpdateOrderRequest{Order: orderToPB(o)})
+	return status.Code(err)
+}
+
+func (c *grpcOrderClient) delete(role string, id int) codes.Code {
+	_, err := c.client.DeleteOrderByID(withContractRole(role), &pb.DeleteOrderByIDRequest{Id: int32(id)})
+	return status.Code(err)
+}
+
+func (c *grpcOrderClient) restore(role string, id int) codes.Code {
+	_, err := c.client.RestoreOrderByID(withContractRole(role), &pb.RestoreOrderByIDRequest{Id: int32(id)})
+	return status.Code(err)
+}
+
+// orderItemClient is a transport of the order items contract, it answers
+// with the code of the call and the quantity of the order item it read.
+type orderItemClient interface {
+	get(role string, id int) (codes.Code, int)
+	update(role string, o types.OrderItem) codes.Code
+	delete(role string, id int) codes.Code
+}
+
+type restOrderItemClient struct {
+	httpOrderClient
+}
+
+func newRESTOrderItemClient(t *testing.T, service *Service) orderItemClient {
+	h := &Handler{service: service}
+	router := mux.NewRouter()
+	router.HandleFunc("/order_items/{order_item_id}", h.handleGetOrderItemByID).Methods("GET")
+	router.HandleFunc("/order_items/{order_item_id}/update", h.handleUpdateOrderItemByID).Methods("PATCH")
+	router.HandleFunc("/order_items/{order_item_id}/delete", h.handleDeleteOrderItemByID).Methods("DELETE")
+	return &restOrderItemClient{httpOrderClient{t: t, handler: router}}
+}
+
+func (c *restOrderItemClient) get(role string, id int) (codes.Code, int) {
+	code, response := c.do(role, http.MethodGet, fmt.Sprintf("/order_items/%d", id), nil)
+	quantity, _ := response["qty"].(float64)
+	return code, int(quantity)
+}
+
+func (c *restOrderItemClient) update(role string, o types.OrderItem) codes.Code {
+	code, _ := c.do(role, http.MethodPatch, fmt.Sprintf("/order_items/%d/update", o.ID), o)
+	return code
+}
+
+func (c *restOrderItemClient) delete(role string, id int) codes.Code {
+	code, _ := c.do(role, http.MethodDelete, fmt.Sprintf("/order_items/%d/delete", id), nil)
+	return code
+}
+
+type grpcOrderItemClient struct {
+	client pb.OrderItemServiceClient
+}
+
+func newGRPCOrderItemClient(t *testing.T, service *Service) orderItemClient {
+	conn := dialContractServer(t, func(s *grpc.Server) { NewOrderItemHandlerServer(s, service) })
+	return &grpcOrderItemClient{client: pb.NewOrderItemServiceClient(conn)}
+}
+
+func (c *grpcOrderItemClient) get(role string, id int) (codes.Code, int) {
+	response, err := c.client.GetOrderItemByID(withContractRole(role), &pb.GetOrderItemByIDRequest{Id: int32(id)})
+	return status.Code(err), int(response.GetOrderItem().GetQuantity())
+}
+
+func (c *grpcOrderItemClient) update(role string, o types.OrderItem) codes.Code {
+	_, err := c.client.UpdateOrderItem(withContractRole(role), &pb.UpdateOrderItemRequest{OrderItem: orderItemToPB(o)})
+	return status.Code(err)
+}
+
+func (c *grpcOrderItemClient) delete(role string, id int) codes.Code {
+	_, err := c.client.DeleteOrderItemByID(withContractRole(role), &pb.DeleteOrderItemByIDRequest{Id: int32(id)})
+	return status.Code(err)
+}
+
+// dialContractServer serves the registered services in memory, the role of a
+// call is sent in its metadata and put in the context like the auth
+// interceptor does.
+func dialContractServer(t *testing.T, register func(*grpc.Server)) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if roles := md.Get("role"); len(roles) > 0 {
+			ctx = context.WithValue(ctx, auth.UserRoleKey, roles[0])
+		}
+		return handler(ctx, req)
+	}))
+	register(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withContractRole(role string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "role", role)
+}
+
+// codeOfHTTPStatus is the reverse of utils.WriteStatusError for the codes of
+// the contract.
+func codeOfHTTPStatus(code int) codes.Code {
+	switch code {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return codes.OK
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	}
+	return codes.Internal
+}
+
+// memoryOrderStore keeps the orders and their items in memory, the rest is
+// the mock's.
+type memoryOrderStore struct {
+	mockOrderStore
+	mu     sync.Mutex
+	orders map[int]types.Order
+	items  map[int]types.OrderItem
+	nextID int
+}
+
+func newMemoryOrderStore(orders ...types.Order) *memoryOrderStore {
+	m := &memoryOrderStore{orders: map[int]types.Order{}, items: map[int]types.OrderItem{}}
+	for _, o := range orders {
+		m.orders[o.ID] = o
+		if o.ID > m.nextID {
+			m.nextID = o.ID
+		}
+	}
+	return m
+}
+
+func (m *memoryOrderStore) list(deleted bool) []types.Order {
+	orders := []types.Order{}
+	for _, o := range m.orders {
+		if (o.DeletedAt != nil) == deleted {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders
+}
+
+func (m *memoryOrderStore) GetOrders() ([]types.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.list(false), nil
+}
+func (m *memoryOrderStore) GetOrdersByIDs(ids []int) ([]types.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	orders := []types.Order{}
+	for _, id := range ids {
+		if o, ok := m.orders[id]; ok && o.DeletedAt == nil {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+func (m *memoryOrderStore) GetOrderByID(id int) (*types.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[id]
+	if !ok || o.DeletedAt != nil {
+		return nil, fmt.Errorf("order %w", types.ErrNotFound)
+	}
+	return &o, nil
+}
+func (m *memoryOrderStore) CreateOrder(o types.Order) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	o.ID = m.nextID
+	o.CreatedAt = time.Now()
+	m.orders[o.ID] = o
+	return int64(o.ID), nil
+}
+func (m *memoryOrderStore) UpdateOrder(o types.Order) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.orders[o.ID]
+	if !ok {
+		return 0, nil
+	}
+	stored.Status = o.Status
+	stored.Address = o.Address
+	m.orders[o.ID] = stored
+	return 1, nil
+}
+func (m *memoryOrderStore) DeleteOrderByID(id int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[id]
+	if !ok || o.DeletedAt != nil {
+		return 0, nil
+	}
+	now := time.Now()
+	o.DeletedAt = &now
+	m.orders[id] = o
+	return 1, nil
+}
+func (m *memoryOrderStore) DeleteOrder(o types.Order) (int64, error) {
+	return m.DeleteOrderByID(o.ID)
+}
+func (m *memoryOrderStore) GetDeletedOrders() ([]types.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.list(true), nil
+}
+func (m *memoryOrderStore) RestoreOrderByID(id int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[id]
+	if !ok || o.DeletedAt == nil {
+		return 0, nil
+	}
+	o.DeletedAt = nil
+	m.orders[id] = o
+	return 1, nil
+}
+func (m *memoryOrderStore) GetOrderItemsByID(id int) (*types.OrderItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.items[id]
+	if !ok {
+		return nil, fmt.Errorf("order item %w", types.ErrNotFound)
+	}
+	return &o, nil
+}
+func (m *memoryOrderStore) UpdateOrderItem(o types.OrderItem) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.items[o.ID]; !ok {
+		return 0, nil
+	}
+	m.items[o.ID] = o
+	return 1, nil
+}
+func (m *memoryOrderStore) DeleteOrderItemByID(id int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.items[id]; !ok {
+		return 0, nil
+	}
+	delete(m.items, id)
+	return 1, nil
+}
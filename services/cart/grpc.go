@@ -1,17 +1,276 @@
 package cart
 
 import (
+	"context"
+
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
 	"google.golang.org/grpc"
 )
 
+// HandlerServer is the gRPC transport of the orders, it only converts the
+// messages of the service.
 type HandlerServer struct {
 	pb.UnimplementedOrderServiceServer
 	service types.OrderService
 }
 
+func NewServer(service types.OrderService) *HandlerServer {
+	return &HandlerServer{service: service}
+}
+
 func NewHandlerServer(grpcServer *grpc.Server, service types.OrderService) {
-	handler := &HandlerServer{service: service}
-	pb.RegisterOrderServiceServer(grpcServer, handler)
+	pb.RegisterOrderServiceServer(grpcServer, NewServer(service))
+}
+
+func (h *HandlerServer) GetOrders(ctx context.Context, req *pb.GetOrdersRequest) (*pb.GetOrdersResponse, error) {
+	orders, err := h.service.GetOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetOrdersResponse{Orders: ordersToPB(orders)}, nil
+}
+
+func (h *HandlerServer) GetOrdersByIDs(ctx context.Context, req *pb.GetOrdersByIDsRequest) (*pb.GetOrdersByIDsResponse, error) {
+	ids := make([]int, 0, len(req.GetIds()))
+	for _, id := range req.GetIds() {
+		ids = append(ids, int(id))
+	}
+	orders, err := h.service.GetOrdersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetOrdersByIDsResponse{Orders: ordersToPB(orders)}, nil
+}
+
+func (h *HandlerServer) GetOrderByID(ctx context.Context, req *pb.GetOrderByIDRequest) (*pb.GetOrderByIDResponse, error) {
+	order, err := h.service.GetOrderByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetOrderByIDResponse{Order: orderToPB(*order)}, nil
+}
+
+func (h *HandlerServer) CreateOrder(ctx context.Context, req *pb.CreateOrderRequest) (*pb.CreateOrderResponse, error) {
+	id, err := h.service.CreateOrder(ctx, orderFromPB(req.GetOrder()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateOrderResponse{Id: id}, nil
+}
+
+func (h *HandlerServer) UpdateOrder(ctx context.Context, req *pb.UpdateOrderRequest) (*pb.UpdateOrderResponse, error) {
+	updated, err := h.service.UpdateOrder(ctx, orderFromPB(req.GetOrder()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateOrderResponse{UpdatedCount: updated}, nil
+}
+
+func (h *HandlerServer) DeleteOrderByID(ctx context.Context, req *pb.DeleteOrderByIDRequest) (*pb.DeleteOrderByIDResponse, error) {
+	deleted, err := h.service.DeleteOrderByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteOrderByIDResponse{DeletedCount: deleted}, nil
+}
+
+func (h *HandlerServer) DeleteOrder(ctx context.Context, req *pb.DeleteOrderRequest) (*pb.DeleteOrderResponse, error) {
+	deleted, err := h.service.DeleteOrderByID(ctx, int(req.GetOrder().GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteOrderResponse{DeletedCount: deleted}, nil
+}
+
+func (h *HandlerServer) GetDeletedOrders(ctx context.Context, req *pb.GetDeletedOrdersRequest) (*pb.GetDeletedOrdersResponse, error) {
+	orders, err := h.service.GetDeletedOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetDeletedOrdersResponse{Orders: ordersToPB(orders)}, nil
+}
+
+func (h *HandlerServer) RestoreOrderByID(ctx context.Context, req *pb.RestoreOrderByIDRequest) (*pb.RestoreOrderByIDResponse, error) {
+	if _, err := h.service.RestoreOrderByID(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+	return &pb.RestoreOrderByIDResponse{RestoredCount: 1}, nil
+}
+
+// WatchOrders streams the new and changed orders as they happen.
+func (h *HandlerServer) WatchOrders(req *pb.WatchOrdersRequest, stream pb.OrderService_WatchOrdersServer) error {
+	return h.service.WatchOrders(stream.Context(), req.GetCursor(), func(e types.OrderEvent) error {
+		return stream.Send(&pb.OrderEvent{Cursor: e.Cursor, Kind: e.Kind, Order: orderToPB(e.Order)})
+	})
+}
+
+func orderToPB(o types.Order) *pb.Order {
+	orderPB := &pb.Order{
+		Id:          int32(o.ID),
+		UserId:      int32(o.UserID),
+		Total:       o.Total,
+		Status:      o.Status,
+		Address:     o.Address,
+		PhoneNumber: o.PhoneNumber,
+		CreatedAt:   o.CreatedAt.Unix(),
+	}
+	if o.DeletedAt != nil {
+		orderPB.DeletedAt = o.DeletedAt.Unix()
+	}
+	return orderPB
+}
+
+func ordersToPB(orders []types.Order) []*pb.Order {
+	ordersPB := make([]*pb.Order, 0, len(orders))
+	for _, o := range orders {
+		ordersPB = append(ordersPB, orderToPB(o))
+	}
+	return ordersPB
+}
+
+// orderFromPB is the order of a request, the timestamps are the store's.
+func orderFromPB(o *pb.Order) types.Order {
+	return types.Order{
+		ID:          int(o.GetId()),
+		UserID:      int(o.GetUserId()),
+		Total:       o.GetTotal(),
+		Status:      o.GetStatus(),
+		Address:     o.GetAddress(),
+		PhoneNumber: o.GetPhoneNumber(),
+	}
+}
+
+// OrderItemHandlerServer is the gRPC transport of the order items.
+type OrderItemHandlerServer struct {
+	pb.UnimplementedOrderItemServiceServer
+	service types.OrderItemService
+}
+
+func NewOrderItemServer(service types.OrderItemService) *OrderItemHandlerServer {
+	return &OrderItemHandlerServer{service: service}
+}
+
+func NewOrderItemHandlerServer(grpcServer *grpc.Server, service types.OrderItemService) {
+	pb.RegisterOrderItemServiceServer(grpcServer, NewOrderItemServer(service))
+}
+
+func (h *OrderItemHandlerServer) GetOrderItems(ctx context.Context, req *pb.GetOrderItemsRequest) (*pb.GetOrderItemsResponse, error) {
+	orderItems, err := h.service.GetOrderItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetOrderItemsResponse{OrderItems: orderItemsToPB(orderItems)}, nil
+}
+
+func (h *OrderItemHandlerServer) GetOrderItemsByIDs(ctx context.Context, req *pb.GetOrderItemsByIDsRequest) (*pb.GetOrderItemsByIDsResponse, error) {
+	ids := make([]int, 0, len(req.GetIds()))
+	for _, id := range req.GetIds() {
+		ids = append(ids, int(id))
+	}
+	orderItems, err := h.service.GetOrderItemsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetOrderItemsByIDsResponse{OrderItems: orderItemsToPB(orderItems)}, nil
+}
+
+func (h *OrderItemHandlerServer) GetOrderItemByID(ctx context.Context, req *pb.GetOrderItemByIDRequest) (*pb.GetOrderItemByIDResponse, error) {
+	orderItem, err := h.service.GetOrderItemByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetOrderItemByIDResponse{OrderItem: orderItemToPB(*orderItem)}, nil
+}
+
+func (h *OrderItemHandlerServer) UpdateOrderItem(ctx context.Context, req *pb.UpdateOrderItemRequest) (*pb.UpdateOrderItemResponse, error) {
+	updated, err := h.service.UpdateOrderItem(ctx, orderItemFromPB(req.GetOrderItem()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateOrderItemResponse{UpdatedCount: updated}, nil
+}
+
+func (h *OrderItemHandlerServer) DeleteOrderItemByID(ctx context.Context, req *pb.DeleteOrderItemByIDRequest) (*pb.DeleteOrderItemByIDResponse, error) {
+	deleted, err := h.service.DeleteOrderItemByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteOrderItemByIDResponse{DeletedCount: deleted}, nil
+}
+
+// CheckoutHandlerServer is the gRPC transport of the checkout, the user is
+// the one signed in by the auth interceptor.
+type CheckoutHandlerServer struct {
+	pb.UnimplementedCheckoutServiceServer
+	service types.CheckoutService
+}
+
+func NewCheckoutServer(service types.CheckoutService) *CheckoutHandlerServer {
+	return &CheckoutHandlerServer{service: service}
+}
+
+func NewCheckoutHandlerServer(grpcServer *grpc.Server, service types.CheckoutService) {
+	pb.RegisterCheckoutServiceServer(grpcServer, NewCheckoutServer(service))
+}
+
+func (h *CheckoutHandlerServer) Checkout(ctx context.Context, req *pb.CheckoutRequest) (*pb.CheckoutResponse, error) {
+	items := make([]types.CartItem, 0, len(req.GetItems()))
+	for _, item := range req.GetItems() {
+		items = append(items, types.CartItem{ProductID: int(item.GetProductId()), Quantity: int(item.GetQuantity())})
+	}
+	checkout, err := h.service.Checkout(ctx, items, mailer.Locale(req.GetLocale()))
+	if err != nil {
+		return nil, err
+	}
+	ordered := make([]*pb.Product, 0, len(checkout.Items))
+	for _, p := range checkout.Items {
+		ordered = append(ordered, checkoutItemToPB(p))
+	}
+	return &pb.CheckoutResponse{OrderId: int32(checkout.OrderID), TotalPrice: checkout.Total, Items: ordered}, nil
+}
+
+// checkoutItemToPB is an ordered product, the quantity is the ordered one.
+func checkoutItemToPB(p types.Product) *pb.Product {
+	return &pb.Product{
+		Id:       int32(p.ID),
+		Sku:      p.SKU,
+		Name:     p.Name,
+		Merchant: p.Merchant,
+		Currency: p.Currency,
+		Image:    p.Image,
+		Price:    p.Price,
+		Quantity: int32(p.Quantity),
+	}
+}
+
+func orderItemToPB(o types.OrderItem) *pb.OrderItem {
+	return &pb.OrderItem{
+		Id:          int32(o.ID),
+		OrderId:     int32(o.OrderID),
+		ProductId:   int32(o.ProductID),
+		Quantity:    int32(o.Quantity),
+		Price:       o.Price,
+		WarehouseId: int32(o.WarehouseID),
+	}
+}
+
+func orderItemsToPB(orderItems []types.OrderItem) []*pb.OrderItem {
+	orderItemsPB := make([]*pb.OrderItem, 0, len(orderItems))
+	for _, o := range orderItems {
+		orderItemsPB = append(orderItemsPB, orderItemToPB(o))
+	}
+	return orderItemsPB
+}
+
+func orderItemFromPB(o *pb.OrderItem) types.OrderItem {
+	return types.OrderItem{
+		ID:          int(o.GetId()),
+		OrderID:     int(o.GetOrderId()),
+		ProductID:   int(o.GetProductId()),
+		Quantity:    int(o.GetQuantity()),
+		Price:       o.GetPrice(),
+		WarehouseID: int(o.GetWarehouseId()),
+	}
 }
@@ -1,11 +1,41 @@
 package cart
 
 import (
+	"context"
 	"fmt"
+	"log"
 
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/events"
+	"github.com/fayleenpc/tj-jeans/internal/inbox"
+	"github.com/fayleenpc/tj-jeans/internal/inventory"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
+	"github.com/fayleenpc/tj-jeans/internal/notify"
 	"github.com/fayleenpc/tj-jeans/internal/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// orderEvent is the mail, the phone notification and the in-app
+// notification of an order event, admin is the in-app notification of the
+// admins if they get one.
+type orderEvent struct {
+	mail         string
+	notification string
+	inbox        string
+	admin        string
+}
+
+var orderCreated = orderEvent{mailer.TemplateOrderConfirmation, notify.TemplateOrderCreated, types.NotificationOrderCreated, types.NotificationNewOrder}
+
+// orderStatusEvents are what the customer gets when an order moves to the
+// status.
+var orderStatusEvents = map[string]orderEvent{
+	"paid":    {mailer.TemplatePaymentReceived, notify.TemplateOrderPaid, types.NotificationOrderPaid, types.NotificationPaymentReceived},
+	"shipped": {mailer.TemplateShipped, notify.TemplateOrderShipped, types.NotificationOrderShipped, ""},
+}
+
 func getCartItemsIDs(items []types.CartItem) ([]int, error) {
 	productIDs := make([]int, len(items))
 	for i, item := range items {
@@ -17,49 +47,259 @@ func getCartItemsIDs(items []types.CartItem) ([]int, error) {
 	return productIDs, nil
 }
 
-func (h *Handler) createOrder(ps []types.Product, items []types.CartItem, userID int) (int, float64, error) {
+func (s *Service) createOrder(ctx context.Context, ps []types.Product, items []types.CartItem, userID int) (int, float64, error) {
 	productMap := make(map[int]types.Product)
 	for _, product := range ps {
 		productMap[product.ID] = product
 	}
 	// check if all products are actually in stock
 	if err := checkIfCartIsInStock(items, productMap); err != nil {
-		return 0, 0, nil
+		return 0, 0, status.Error(codes.FailedPrecondition, err.Error())
 	}
 	// calculate the total price
 	totalPrice := calculateTotalPrice(items, productMap)
-	// reduce quantity of products in our db
-	for _, item := range items {
-		product := productMap[item.ProductID]
-		product.Quantity -= item.Quantity
-
-		h.productStore.UpdateProduct(product)
-	}
 	// get user address by id
-	user, err := h.userStore.GetUserByID(userID)
+	user, err := s.userStore.GetUserByID(userID)
+	if err != nil || user == nil {
+		return 0, 0, status.Errorf(codes.NotFound, "user %v not found", userID)
+	}
+	// allocate the lines to the warehouses
+	allocations, err := s.allocateItems(items, user.Address)
 	if err != nil {
-		return 0, 0, nil
+		return 0, 0, err
 	}
 	// create the order
-	orderID, err := h.store.CreateOrder(types.Order{
-		UserID:  userID,
-		Total:   totalPrice,
-		Status:  "pending",
-		Address: user.Address,
-	})
+	order := types.Order{
+		UserID:      userID,
+		Total:       totalPrice,
+		Status:      "pending",
+		Address:     user.Address,
+		PhoneNumber: user.PhoneNumber,
+	}
+	orderID, err := s.store.CreateOrder(order)
 	if err != nil {
 		return 0, 0, err
 	}
-	// create order items
+	order.ID = int(orderID)
+	audit.Record(ctx, audit.ActionCreate, audit.EntityOrder, order.ID, nil, order)
+	// take the products out of stock of their warehouses through the ledger,
+	// the sales made before a failed one are put back and the order is
+	// cancelled
+	reference := fmt.Sprintf("order:%d", orderID)
+	sold := make([]types.StockMovement, 0, len(items))
+	for i, item := range items {
+		for _, allocation := range allocations[i] {
+			sale := types.StockMovement{
+				ProductID:   item.ProductID,
+				Type:        types.StockSale,
+				Quantity:    -allocation.Quantity,
+				Reason:      "checkout",
+				Reference:   reference,
+				ActorID:     userID,
+				WarehouseID: allocation.WarehouseID,
+			}
+			if _, err := s.stockStore.RecordStockMovement(sale); err != nil {
+				s.cancelCheckout(ctx, order, sold)
+				return 0, 0, err
+			}
+			sold = append(sold, sale)
+		}
+	}
+	// create order items, a line split over warehouses gets an item per
+	// warehouse, like for the sales the order is cancelled when one fails
+	for i, item := range items {
+		for _, allocation := range allocations[i] {
+			orderItem := types.OrderItem{
+				OrderID:     int(orderID),
+				ProductID:   item.ProductID,
+				Quantity:    allocation.Quantity,
+				Price:       productMap[item.ProductID].Price,
+				WarehouseID: allocation.WarehouseID,
+			}
+			orderItemID, err := s.store.CreateOrderItem(orderItem)
+			if err != nil {
+				s.cancelCheckout(ctx, order, sold)
+				return 0, 0, err
+			}
+			orderItem.ID = int(orderItemID)
+			audit.Record(ctx, audit.ActionCreate, audit.EntityOrderItem, orderItem.ID, nil, orderItem)
+		}
+	}
+	// published once the order has its items
+	events.Publish(events.TopicOrder, events.KindCreated, order.ID)
+	return int(orderID), totalPrice, nil
+}
+
+// allocateItems splits the items over the warehouses holding them by
+// STOCK_ALLOCATION_STRATEGY, the allocations are in the order of the items.
+// The nearest warehouses are found by the city of the shipping address.
+func (s *Service) allocateItems(items []types.CartItem, address string) ([][]inventory.Allocation, error) {
+	warehouses, err := s.stockStore.GetWarehouses()
+	if err != nil {
+		return nil, err
+	}
+	productIDs, err := getCartItemsIDs(items)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	stock, err := s.stockStore.GetStockByProductIDs(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	var dest *inventory.Location
+	if location, ok := inventory.Locate(address); ok {
+		dest = &location
+	}
+	allocations, err := allocateItems(items, warehouses, stock, config.Envs.StockAllocationStrategy, dest)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return allocations, nil
+}
+
+func allocateItems(items []types.CartItem, warehouses []types.Warehouse, stock []types.WarehouseStock, strategy string, dest *inventory.Location) ([][]inventory.Allocation, error) {
+	locations := make(map[int]inventory.Location, len(warehouses))
+	for _, w := range warehouses {
+		locations[w.ID] = inventory.Location{Latitude: w.Latitude, Longitude: w.Longitude}
+	}
+	sources := make(map[int][]inventory.Source)
+	for _, s := range stock {
+		sources[s.ProductID] = append(sources[s.ProductID], inventory.Source{
+			WarehouseID: s.WarehouseID,
+			Location:    locations[s.WarehouseID],
+			Quantity:    s.Quantity,
+		})
+	}
+
+	allocations := make([][]inventory.Allocation, len(items))
+	for i, item := range items {
+		allocated, err := inventory.Allocate(item.Quantity, sources[item.ProductID], strategy, dest)
+		if err != nil {
+			return nil, fmt.Errorf("product %d: %v", item.ProductID, err)
+		}
+		// the same product can be on several lines, the next one gets
+		// what is left
+		for _, a := range allocated {
+			for j := range sources[item.ProductID] {
+				if sources[item.ProductID][j].WarehouseID == a.WarehouseID {
+					sources[item.ProductID][j].Quantity -= a.Quantity
+				}
+			}
+		}
+		allocations[i] = allocated
+	}
+	return allocations, nil
+}
+
+// cancelCheckout puts the sold stock back in its warehouses and cancels the
+// order.
+func (s *Service) cancelCheckout(ctx context.Context, order types.Order, sold []types.StockMovement) {
+	for _, sale := range sold {
+		_, err := s.stockStore.RecordStockMovement(types.StockMovement{
+			ProductID:   sale.ProductID,
+			Type:        types.StockAdjustment,
+			Quantity:    -sale.Quantity,
+			Reason:      "checkout cancelled",
+			Reference:   sale.Reference,
+			ActorID:     order.UserID,
+			WarehouseID: sale.WarehouseID,
+		})
+		if err != nil {
+			log.Printf("failed to put back the stock of product %d in warehouse %d for order %d: %v", sale.ProductID, sale.WarehouseID, order.ID, err)
+		}
+	}
+	cancelled := order
+	cancelled.Status = "cancelled"
+	if _, err := s.store.UpdateOrder(cancelled); err != nil {
+		log.Printf("failed to cancel order %d: %v", order.ID, err)
+		return
+	}
+	audit.Record(ctx, audit.ActionUpdate, audit.EntityOrder, order.ID, order, cancelled)
+	events.Publish(events.TopicOrder, events.KindUpdated, order.ID)
+}
+
+// announceOrder mails the customer and notifies the phone of the order and
+// the inbox of the customer and the admins of the event, a failure is logged
+// and doesn't fail the request, the order is already saved. The
+// notifications are sent in the background.
+func (s *Service) announceOrder(event orderEvent, locale string, order types.Order, currency string, lines []mailer.OrderLine) {
+	u, err := s.userStore.GetUserByID(order.UserID)
+	if err != nil || u == nil {
+		log.Printf("failed to get the customer of order %d to announce: %v", order.ID, err)
+		return
+	}
+	err = mailer.SendOrderEmail(event.mail, locale, u.Email, mailer.OrderMail{
+		ID:        order.ID,
+		FirstName: u.FirstName,
+		Currency:  currency,
+		Total:     order.Total,
+		Address:   order.Address,
+		Lines:     lines,
+	})
+	if err != nil {
+		log.Printf("failed to mail %s of order %d to %v: %v", event.mail, order.ID, u.Email, err)
+	}
+
+	phone := order.PhoneNumber
+	if phone == "" {
+		phone = u.PhoneNumber
+	}
+	total := mailer.FormatMoney(locale, currency, order.Total)
+	n := notify.Order(event.notification, locale, phone, u.FirstName, order.ID, total)
+	go func() {
+		if err := notify.Send(context.Background(), n); err != nil {
+			log.Printf("failed to notify %s of order %d to %v: %v", event.notification, order.ID, phone, err)
+		}
+		if err := inbox.Send(context.Background(), inbox.Order(event.inbox, locale, order.UserID, order.ID, total)); err != nil {
+			log.Printf("failed to notify %s of order %d to user %d: %v", event.inbox, order.ID, order.UserID, err)
+		}
+		if event.admin == "" {
+			return
+		}
+		// the admins read the dashboard in NOTIFY_LOCALE
+		if err := inbox.SendRole(context.Background(), "admin", inbox.Order(event.admin, "", 0, order.ID, total)); err != nil {
+			log.Printf("failed to notify %s of order %d to the admins: %v", event.admin, order.ID, err)
+		}
+	}()
+}
+
+// orderLines are the lines of the order confirmation in the order of the
+// items, with the currency of the products.
+func orderLines(items []types.CartItem, ps []types.Product) ([]mailer.OrderLine, string) {
+	productMap := make(map[int]types.Product, len(ps))
+	for _, product := range ps {
+		productMap[product.ID] = product
+	}
+	var currency string
+	lines := make([]mailer.OrderLine, 0, len(items))
 	for _, item := range items {
-		h.store.CreateOrderItem(types.OrderItem{
-			OrderID:   int(orderID),
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     productMap[item.ProductID].Price,
+		product := productMap[item.ProductID]
+		if currency == "" {
+			currency = product.Currency
+		}
+		lines = append(lines, mailer.OrderLine{
+			Name:     product.Name,
+			Quantity: item.Quantity,
+			Price:    product.Price * float64(item.Quantity),
 		})
 	}
-	return int(orderID), totalPrice, nil
+	return lines, currency
+}
+
+// checkoutItems are the products of the items in their order with the
+// ordered quantity.
+func checkoutItems(items []types.CartItem, ps []types.Product) []types.Product {
+	productMap := make(map[int]types.Product, len(ps))
+	for _, product := range ps {
+		productMap[product.ID] = product
+	}
+	products := make([]types.Product, 0, len(items))
+	for _, item := range items {
+		product := productMap[item.ProductID]
+		product.Quantity = item.Quantity
+		products = append(products, product)
+	}
+	return products
 }
 
 func calculateTotalPrice(cartItems []types.CartItem, products map[int]types.Product) float64 {
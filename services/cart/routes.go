@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
 	"github.com/fayleenpc/tj-jeans/internal/ratelimiter"
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	"github.com/fayleenpc/tj-jeans/internal/utils"
@@ -18,19 +19,23 @@ import (
 
 type Handler struct {
 	store        types.OrderStore
+	service      *Service
 	productStore types.ProductStore
+	stockStore   types.StockStore
 	userStore    types.UserStore
 	tokenStore   types.TokenStore
 	redisStore   *redis.Client
 }
 
-func NewHandler(store types.OrderStore, productStore types.ProductStore, userStore types.UserStore, tokenStore types.TokenStore, redisStore *redis.Client) *Handler {
-	return &Handler{store: store, productStore: productStore, userStore: userStore, tokenStore: tokenStore, redisStore: redisStore}
+func NewHandler(store types.OrderStore, productStore types.ProductStore, stockStore types.StockStore, userStore types.UserStore, tokenStore types.TokenStore, redisStore *redis.Client) *Handler {
+	return &Handler{store: store, service: NewService(store, productStore, stockStore, userStore), productStore: productStore, stockStore: stockStore, userStore: userStore, tokenStore: tokenStore, redisStore: redisStore}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/cart/checkout", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleCheckout), h.userStore, h.tokenStore)).Methods("POST")
 	router.HandleFunc("/orders", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetOrders), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/orders/trash", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetDeletedOrders), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/orders/{order_id}/restore", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleRestoreOrderByID), h.userStore, h.tokenStore)).Methods("POST")
 	router.HandleFunc("/orders/{order_id}", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetOrderByID), h.userStore, h.tokenStore)).Methods("GET")
 	router.HandleFunc("/orders/{order_id}/update", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleUpdateOrderByID), h.userStore, h.tokenStore)).Methods("PATCH")
 	router.HandleFunc("/orders/{order_id}/delete", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleDeleteOrderByID), h.userStore, h.tokenStore)).Methods("DELETE")
@@ -46,18 +51,14 @@ func (h *Handler) handleGetOrders(w http.ResponseWriter, r *http.Request) {
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
-	if userRole == "admin" {
-		orders, err := h.store.GetOrders()
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
-			return
-		}
-
-		utils.WriteJSON(w, http.StatusOK, orders)
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+
+	orders, err := h.service.GetOrders(r.Context())
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
 	}
+
+	utils.WriteJSON(w, http.StatusOK, orders)
 }
 
 func (h *Handler) handleGetOrderByID(w http.ResponseWriter, r *http.Request) {
@@ -66,23 +67,18 @@ func (h *Handler) handleGetOrderByID(w http.ResponseWriter, r *http.Request) {
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	orderID, err := strconv.Atoi(mux.Vars(r)["order_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		order, err := h.store.GetOrderByID(orderID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, order)
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+		return
 	}
-
+	order, err := h.service.GetOrderByID(r.Context(), orderID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, order)
 }
 
 func (h *Handler) handleUpdateOrderByID(w http.ResponseWriter, r *http.Request) {
@@ -91,37 +87,32 @@ func (h *Handler) handleUpdateOrderByID(w http.ResponseWriter, r *http.Request)
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	orderID, err := strconv.Atoi(mux.Vars(r)["order_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		var payload types.Order
-		if err := utils.ParseJSON(r, &payload); err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		oldOrder, err := h.store.GetOrderByID(orderID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		if oldOrder.ID != payload.ID {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		updatedOrderID, err := h.store.UpdateOrder(payload)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"updated_id": updatedOrderID, "old_order": oldOrder, "updated_order": payload})
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+		return
 	}
-
+	var payload types.Order
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.ID != orderID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("the order id does not match the path"))
+		return
+	}
+	oldOrder, err := h.service.GetOrderByID(r.Context(), orderID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	updatedOrderID, err := h.service.UpdateOrder(r.Context(), payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"updated_id": updatedOrderID, "old_order": oldOrder, "updated_order": payload})
 }
 
 func (h *Handler) handleDeleteOrderByID(w http.ResponseWriter, r *http.Request) {
@@ -130,28 +121,23 @@ func (h *Handler) handleDeleteOrderByID(w http.ResponseWriter, r *http.Request)
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	orderID, err := strconv.Atoi(mux.Vars(r)["order_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		oldOrder, err := h.store.GetOrderByID(orderID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		deletedOldOrderID, err := h.store.DeleteOrderByID(oldOrder.ID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"deleted_id": deletedOldOrderID, "deleted_order": oldOrder})
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+		return
 	}
-
+	oldOrder, err := h.service.GetOrderByID(r.Context(), orderID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	deletedOldOrderID, err := h.service.DeleteOrderByID(r.Context(), oldOrder.ID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"deleted_id": deletedOldOrderID, "deleted_order": oldOrder})
 }
 
 func (h *Handler) handleGetOrderItems(w http.ResponseWriter, r *http.Request) {
@@ -160,18 +146,14 @@ func (h *Handler) handleGetOrderItems(w http.ResponseWriter, r *http.Request) {
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
-	if userRole == "admin" {
-		orderItems, err := h.store.GetOrderItems()
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
-			return
-		}
-
-		utils.WriteJSON(w, http.StatusOK, orderItems)
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+
+	orderItems, err := h.service.GetOrderItems(r.Context())
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
 	}
+
+	utils.WriteJSON(w, http.StatusOK, orderItems)
 }
 
 func (h *Handler) handleGetOrderItemByID(w http.ResponseWriter, r *http.Request) {
@@ -180,23 +162,18 @@ func (h *Handler) handleGetOrderItemByID(w http.ResponseWriter, r *http.Request)
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	orderItemID, err := strconv.Atoi(mux.Vars(r)["order_item_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		orderItem, err := h.store.GetOrderItemsByID(orderItemID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, orderItem)
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid order item id"))
+		return
 	}
-
+	orderItem, err := h.service.GetOrderItemByID(r.Context(), orderItemID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, orderItem)
 }
 
 func (h *Handler) handleUpdateOrderItemByID(w http.ResponseWriter, r *http.Request) {
@@ -205,37 +182,32 @@ func (h *Handler) handleUpdateOrderItemByID(w http.ResponseWriter, r *http.Reque
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	orderItemID, err := strconv.Atoi(mux.Vars(r)["order_item_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		var payload types.OrderItem
-		if err := utils.ParseJSON(r, &payload); err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		oldOrderItem, err := h.store.GetOrderItemsByID(orderItemID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		if oldOrderItem.ID != payload.ID {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		updatedOrderItemID, err := h.store.UpdateOrderItem(payload)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"updated_id": updatedOrderItemID, "old_order_item": oldOrderItem, "updated_order_item": payload})
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid order item id"))
+		return
 	}
-
+	var payload types.OrderItem
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.ID != orderItemID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("the order item id does not match the path"))
+		return
+	}
+	oldOrderItem, err := h.service.GetOrderItemByID(r.Context(), orderItemID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	updatedOrderItemID, err := h.service.UpdateOrderItem(r.Context(), payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"updated_id": updatedOrderItemID, "old_order_item": oldOrderItem, "updated_order_item": payload})
 }
 
 func (h *Handler) handleDeleteOrderItemByID(w http.ResponseWriter, r *http.Request) {
@@ -244,43 +216,25 @@ func (h *Handler) handleDeleteOrderItemByID(w http.ResponseWriter, r *http.Reque
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	orderItemID, err := strconv.Atoi(mux.Vars(r)["order_item_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		oldOrderItem, err := h.store.GetOrderItemsByID(orderItemID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		deletedOldOrderID, err := h.store.DeleteOrderItemByID(oldOrderItem.ID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"deleted_id": deletedOldOrderID, "deleted_order_item": oldOrderItem})
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid order item id"))
+		return
 	}
-
+	oldOrderItem, err := h.service.GetOrderItemByID(r.Context(), orderItemID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	deletedOldOrderItemID, err := h.service.DeleteOrderItemByID(r.Context(), oldOrderItem.ID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"deleted_id": deletedOldOrderItemID, "deleted_order_item": oldOrderItem})
 }
 
-// handleCheckout godoc
-//
-//	@Summary		Checkout a products using JWT Token ( accessToken )
-//	@Description	Checkout a products using JWT Token ( accessToken ), with login credentials ( role admin & customer )
-//	@Tags			cart
-//	@Accept			json
-//	@Produce		json
-//	@Success		200	{object}	types.CartCheckoutPayload
-//	@Failure		400	{object}	error
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
-//	@Security		ApiKeyAuth
-//	@Router			/api/v1/cart/checkout [post]
 func (h *Handler) handleCheckout(w http.ResponseWriter, r *http.Request) {
 	span := opentracing.GlobalTracer().StartSpan("handleCheckout")
 	defer span.Finish()
@@ -288,8 +242,6 @@ func (h *Handler) handleCheckout(w http.ResponseWriter, r *http.Request) {
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
 
-	userID := auth.GetUserIDFromContext(r.Context())
-
 	var cart types.CartCheckoutPayload
 	if err := utils.ParseJSON(r, &cart); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
@@ -301,41 +253,49 @@ func (h *Handler) handleCheckout(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errv))
 		return
 	}
-	// get product
-	productIDs, err := getCartItemsIDs(cart.Items)
+
+	checkout, err := h.service.Checkout(r.Context(), cart.Items, mailer.Locale(r.Header.Get("Accept-Language")))
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteStatusError(w, err)
 		return
 	}
-	ps, err := h.productStore.GetProductsByIDs(productIDs)
+
+	utils.WriteJSON(w, http.StatusOK, checkout)
+}
+
+func (h *Handler) handleGetDeletedOrders(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetDeletedOrders")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	orders, err := h.service.GetDeletedOrders(r.Context())
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteStatusError(w, err)
 		return
 	}
-	orderId, totalPrice, err := h.createOrder(ps, cart.Items, userID)
+
+	utils.WriteJSON(w, http.StatusOK, orders)
+}
+
+func (h *Handler) handleRestoreOrderByID(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleRestoreOrderByID")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	orderID, err := strconv.Atoi(mux.Vars(r)["order_id"])
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
 		return
 	}
 
-	// helper payment
-	newPs := []types.Product(ps)
-	for i := 0; i < len(cart.Items); i++ {
-		newPs[i].Quantity = cart.Items[i].Quantity
+	order, err := h.service.RestoreOrderByID(r.Context(), orderID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
 	}
-	// d, err := json.Marshal(map[string]any{
-	// 	"total_price": totalPrice,
-	// 	"order_id":    orderId,
-	// 	"items":       newPs,
-	// })
-	// if err != nil {
-	// 	utils.WriteError(w, http.StatusInternalServerError, err)
-	// }
-	// span.SetTag("payload checkout", d)
-
-	utils.WriteJSON(w, http.StatusOK, map[string]any{
-		"total_price": totalPrice,
-		"order_id":    orderId,
-		"items":       newPs,
-	})
+	utils.WriteJSON(w, http.StatusOK, order)
 }
@@ -1,10 +1,12 @@
 package cart
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	"github.com/gorilla/mux"
@@ -15,7 +17,7 @@ func TestProductsServiceHandler(t *testing.T) {
 	tokenStore := &mockTokenStore{}
 	productsStore := &mockProductsStore{}
 	store := &mockOrderStore{}
-	handler := NewHandler(store, productsStore, userStore, tokenStore, nil)
+	handler := NewHandler(store, productsStore, &mockStockStore{}, userStore, tokenStore, nil)
 
 	t.Run("should fail handle the cart/checkout", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodPost, "/cart/checkout", nil)
@@ -51,6 +53,58 @@ func TestProductsServiceHandler(t *testing.T) {
 	})
 }
 
+func TestCheckoutCancelledWhenAnItemFails(t *testing.T) {
+	store := &failingItemOrderStore{memoryOrderStore: newMemoryOrderStore()}
+	stockStore := &checkoutStockStore{}
+	service := NewService(store, &mockProductsStore{}, stockStore, &checkoutUserStore{})
+
+	products := []types.Product{{ID: 1, Name: "Slim Jeans", Price: 100, Quantity: 5}}
+	_, _, err := service.createOrder(context.Background(), products, []types.CartItem{{ProductID: 1, Quantity: 2}}, 1)
+	if err == nil {
+		t.Fatal("expected the checkout to fail")
+	}
+	if o := store.orders[1]; o.Status != "cancelled" {
+		t.Errorf("expected the order to be cancelled, got %+v", o)
+	}
+	if len(stockStore.movements) != 2 || stockStore.movements[1].Type != types.StockAdjustment || stockStore.movements[1].Quantity != 2 {
+		t.Errorf("expected the sale to be put back, got %+v", stockStore.movements)
+	}
+}
+
+// failingItemOrderStore fails to save the lines of the orders.
+type failingItemOrderStore struct {
+	*memoryOrderStore
+}
+
+func (m *failingItemOrderStore) CreateOrderItem(types.OrderItem) (int64, error) {
+	return 0, fmt.Errorf("failed to save the order item")
+}
+
+// checkoutStockStore holds 5 of product 1 in warehouse 1.
+type checkoutStockStore struct {
+	mockStockStore
+	movements []types.StockMovement
+}
+
+func (m *checkoutStockStore) RecordStockMovement(movement types.StockMovement) (int64, error) {
+	m.movements = append(m.movements, movement)
+	return int64(len(m.movements)), nil
+}
+func (m *checkoutStockStore) GetWarehouses() ([]types.Warehouse, error) {
+	return []types.Warehouse{{ID: 1, Name: "Jakarta"}}, nil
+}
+func (m *checkoutStockStore) GetStockByProductIDs([]int) ([]types.WarehouseStock, error) {
+	return []types.WarehouseStock{{WarehouseID: 1, ProductID: 1, Quantity: 5}}, nil
+}
+
+type checkoutUserStore struct {
+	mockUserStore
+}
+
+func (m *checkoutUserStore) GetUserByID(id int) (*types.User, error) {
+	return &types.User{ID: id, Address: "Jl. Braga 1", PhoneNumber: "081234567890"}, nil
+}
+
 type mockOrderStore struct{}
 
 func (m *mockOrderStore) GetOrders() ([]types.Order, error) { return nil, nil }
@@ -64,6 +118,8 @@ func (m *mockOrderStore) CreateOrder(types.Order) (int64, error) { return 0, nil
 func (m *mockOrderStore) DeleteOrderByID(id int) (int64, error) { return 0, nil }
 
 func (m *mockOrderStore) DeleteOrder(order types.Order) (int64, error) { return 0, nil }
+func (m *mockOrderStore) GetDeletedOrders() ([]types.Order, error)     { return nil, nil }
+func (m *mockOrderStore) RestoreOrderByID(int) (int64, error)          { return 0, nil }
 
 func (m *mockOrderStore) UpdateOrder(order types.Order) (int64, error) { return 0, nil }
 
@@ -75,7 +131,7 @@ func (m *mockOrderStore) GetOrderItemsByIDs(ordersItemsIDs []int) ([]types.Order
 
 func (m *mockOrderStore) GetOrderItemsByID(int) (*types.OrderItem, error) { return nil, nil }
 
-func (m *mockOrderStore) CreateOrderItem(types.OrderItem) error { return nil }
+func (m *mockOrderStore) CreateOrderItem(types.OrderItem) (int64, error) { return 0, nil }
 
 func (m *mockOrderStore) DeleteOrderItemByID(id int) (int64, error) { return 0, nil }
 
@@ -91,7 +147,11 @@ func (m *mockProductsStore) GetProducts() ([]types.Product, error) {
 func (m *mockProductsStore) GetProductsByIDs([]int) ([]types.Product, error) {
 	return []types.Product{}, nil
 }
-func (m *mockProductsStore) GetProductByID(id int) (*types.Product, error) { return nil, nil }
+func (m *mockProductsStore) GetProductsPage(int, int) ([]types.Product, error) {
+	return []types.Product{}, nil
+}
+func (m *mockProductsStore) GetProductByID(id int) (*types.Product, error)  { return nil, nil }
+func (m *mockProductsStore) GetProductBySKU(string) (*types.Product, error) { return nil, nil }
 func (m *mockProductsStore) CreateProduct(types.Product) (int64, error) {
 	return 0, nil
 }
@@ -99,9 +159,29 @@ func (m *mockProductsStore) DeleteProductByID(id int) (int64, error) { return 0,
 func (m *mockProductsStore) DeleteProduct(types.Product) (int64, error) {
 	return 0, nil
 }
-func (m *mockProductsStore) UpdateProduct(types.Product) (int64, error) {
+func (m *mockProductsStore) UpdateProduct(types.Product, int) (int64, error) {
 	return 0, nil
 }
+func (m *mockProductsStore) GetDeletedProducts() ([]types.Product, error) { return nil, nil }
+func (m *mockProductsStore) RestoreProductByID(int) (int64, error)        { return 0, nil }
+
+type mockStockStore struct{}
+
+func (m *mockStockStore) RecordStockMovement(types.StockMovement) (int64, error) { return 0, nil }
+func (m *mockStockStore) CountStock(int, int, int, string, int) (*types.StockMovement, error) {
+	return nil, nil
+}
+func (m *mockStockStore) GetStockMovements(int) ([]types.StockMovement, error)  { return nil, nil }
+func (m *mockStockStore) GetStockDrift() ([]types.StockDrift, error)            { return nil, nil }
+func (m *mockStockStore) SetStockThreshold(int, int) error                      { return nil }
+func (m *mockStockStore) GetStockLevels(time.Time) ([]types.StockLevel, error)  { return nil, nil }
+func (m *mockStockStore) TransferStock(int, int, int, int, string, int) error   { return nil }
+func (m *mockStockStore) GetWarehouses() ([]types.Warehouse, error)             { return nil, nil }
+func (m *mockStockStore) CreateWarehouse(types.Warehouse) (int64, error)        { return 0, nil }
+func (m *mockStockStore) GetWarehouseStock(int) ([]types.WarehouseStock, error) { return nil, nil }
+func (m *mockStockStore) GetStockByProductIDs([]int) ([]types.WarehouseStock, error) {
+	return nil, nil
+}
 
 type mockTokenStore struct{}
 
@@ -113,6 +193,36 @@ func (m *mockTokenStore) GetBlacklistTokenByString(string) (*types.Token, error)
 	return nil, nil
 }
 
+func (m *mockTokenStore) CreateVerificationToken(types.VerificationToken) error { return nil }
+func (m *mockTokenStore) GetVerificationTokenByHash(string) (*types.VerificationToken, error) {
+	return nil, nil
+}
+func (m *mockTokenStore) GetLatestVerificationTokenByEmail(string) (*types.VerificationToken, error) {
+	return nil, nil
+}
+func (m *mockTokenStore) UseVerificationToken(int) (int64, error)      { return 0, nil }
+func (m *mockTokenStore) RevokeVerificationTokensByEmail(string) error { return nil }
+func (m *mockTokenStore) CreatePasswordResetToken(types.PasswordResetToken) error {
+	return nil
+}
+func (m *mockTokenStore) GetPasswordResetTokenByHash(string) (*types.PasswordResetToken, error) {
+	return nil, nil
+}
+func (m *mockTokenStore) UsePasswordResetToken(int) (int64, error)    { return 0, nil }
+func (m *mockTokenStore) RevokePasswordResetTokensByUserID(int) error { return nil }
+func (m *mockTokenStore) RevokeUserTokens(int) error                  { return nil }
+func (m *mockTokenStore) GetUserTokensRevokedAt(int) (time.Time, error) {
+	return time.Time{}, nil
+}
+func (m *mockTokenStore) CreateAPIKey(types.APIKey) (int, error)     { return 0, nil }
+func (m *mockTokenStore) GetAPIKeys() ([]types.APIKey, error)        { return nil, nil }
+func (m *mockTokenStore) GetAPIKeyByID(int) (*types.APIKey, error)   { return nil, nil }
+func (m *mockTokenStore) RevokeAPIKey(int, time.Time) (int64, error) { return 0, nil }
+func (m *mockTokenStore) TouchAPIKey(int, time.Time) error           { return nil }
+func (m *mockTokenStore) GetAPIKeyByHash(string) (*types.APIKey, error) {
+	return nil, fmt.Errorf("api key not found")
+}
+
 type mockUserStore struct{}
 
 func (m *mockUserStore) GetUsers() ([]types.User, error)                   { return nil, nil }
@@ -124,5 +234,8 @@ func (m *mockUserStore) GetUserByEmail(string) (*types.User, error) {
 func (m *mockUserStore) GetUserByID(id int) (*types.User, error)   { return nil, nil }
 func (m *mockUserStore) DeleteUserByID(id int) (int64, error)      { return 0, nil }
 func (m *mockUserStore) DeleteUser(user types.User) (int64, error) { return 0, nil }
+func (m *mockUserStore) GetDeletedUsers() ([]types.User, error)    { return nil, nil }
+func (m *mockUserStore) RestoreUserByID(int) (int64, error)        { return 0, nil }
 func (m *mockUserStore) UpdateUser(user types.User) (int64, error) { return 0, nil }
+func (m *mockUserStore) UpdatePasswordByUserID(int, string) error  { return nil }
 func (m *mockUserStore) CreateUser(types.User) error               { return nil }
@@ -0,0 +1,296 @@
+package cart
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/events"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Service is the orders and the checkout behind the REST, protobuf and gRPC
+// transports. Errors are statuses, see utils.WriteStatusError.
+type Service struct {
+	store        types.OrderStore
+	productStore types.ProductStore
+	stockStore   types.StockStore
+	userStore    types.UserStore
+}
+
+func NewService(store types.OrderStore, productStore types.ProductStore, stockStore types.StockStore, userStore types.UserStore) *Service {
+	return &Service{store: store, productStore: productStore, stockStore: stockStore, userStore: userStore}
+}
+
+func (s *Service) GetOrders(ctx context.Context) ([]types.Order, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	return s.store.GetOrders()
+}
+
+func (s *Service) GetOrdersByIDs(ctx context.Context, ids []int) ([]types.Order, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []types.Order{}, nil
+	}
+	return s.store.GetOrdersByIDs(ids)
+}
+
+func (s *Service) GetOrderByID(ctx context.Context, id int) (*types.Order, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	return s.getOrder(id)
+}
+
+// CreateOrder saves an order made by an admin, the customers order through
+// the checkout.
+func (s *Service) CreateOrder(ctx context.Context, order types.Order) (int64, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return 0, err
+	}
+	if _, err := s.userStore.GetUserByID(order.UserID); err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "user %v not found", order.UserID)
+	}
+	if order.Status == "" {
+		order.Status = "pending"
+	}
+	id, err := s.store.CreateOrder(order)
+	if err != nil {
+		return 0, err
+	}
+	order.ID = int(id)
+	audit.Record(ctx, audit.ActionCreate, audit.EntityOrder, order.ID, nil, order)
+	events.Publish(events.TopicOrder, events.KindCreated, order.ID)
+	return id, nil
+}
+
+// UpdateOrder updates the status and the address of the order, the customer
+// is told when it is paid or shipped. The announcements are in the default
+// locale since the request is the admin's.
+func (s *Service) UpdateOrder(ctx context.Context, order types.Order) (int64, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return 0, err
+	}
+	before, err := s.getOrder(order.ID)
+	if err != nil {
+		return 0, err
+	}
+	updated, err := s.store.UpdateOrder(order)
+	if err != nil {
+		return 0, err
+	}
+	audit.Record(ctx, audit.ActionUpdate, audit.EntityOrder, order.ID, before, order)
+	events.Publish(events.TopicOrder, events.KindUpdated, order.ID)
+	if event, ok := orderStatusEvents[order.Status]; ok && order.Status != before.Status {
+		announced := order
+		announced.UserID = before.UserID
+		announced.Total = before.Total
+		announced.PhoneNumber = before.PhoneNumber
+		s.announceOrder(event, "", announced, "", nil)
+	}
+	return updated, nil
+}
+
+// DeleteOrderByID moves the order to the trash.
+func (s *Service) DeleteOrderByID(ctx context.Context, id int) (int64, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return 0, err
+	}
+	before, err := s.getOrder(id)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := s.store.DeleteOrderByID(id)
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		audit.Record(ctx, audit.ActionDelete, audit.EntityOrder, id, before, nil)
+		events.Publish(events.TopicOrder, events.KindDeleted, id)
+	}
+	return deleted, nil
+}
+
+func (s *Service) GetDeletedOrders(ctx context.Context) ([]types.Order, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	return s.store.GetDeletedOrders()
+}
+
+// RestoreOrderByID takes the order out of the trash and returns it.
+func (s *Service) RestoreOrderByID(ctx context.Context, id int) (*types.Order, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	restored, err := s.store.RestoreOrderByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if restored == 0 {
+		return nil, status.Errorf(codes.NotFound, "order %v is not in the trash", id)
+	}
+	order, err := s.getOrder(id)
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.ActionRestore, audit.EntityOrder, id, nil, order)
+	events.Publish(events.TopicOrder, events.KindRestored, id)
+	return order, nil
+}
+
+// Checkout orders the items for the signed in user, the stock is taken from
+// the warehouses and the customer is told in the locale.
+func (s *Service) Checkout(ctx context.Context, items []types.CartItem, locale string) (*types.ResponseCart, error) {
+	userID := auth.GetUserIDFromContext(ctx)
+	if userID <= 0 {
+		return nil, status.Error(codes.Unauthenticated, "sign in to checkout")
+	}
+	if len(items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "cart is empty")
+	}
+	productIDs, err := getCartItemsIDs(items)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	ps, err := s.productStore.GetProductsByIDs(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	orderID, totalPrice, err := s.createOrder(ctx, ps, items, userID)
+	if err != nil {
+		return nil, err
+	}
+	lines, currency := orderLines(items, ps)
+	if order, err := s.store.GetOrderByID(orderID); err == nil && order != nil {
+		s.announceOrder(orderCreated, locale, *order, currency, lines)
+	}
+	return &types.ResponseCart{Total: totalPrice, OrderID: orderID, Items: checkoutItems(items, ps)}, nil
+}
+
+func (s *Service) GetOrderItems(ctx context.Context) ([]types.OrderItem, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	return s.store.GetOrderItems()
+}
+
+func (s *Service) GetOrderItemsByIDs(ctx context.Context, ids []int) ([]types.OrderItem, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []types.OrderItem{}, nil
+	}
+	return s.store.GetOrderItemsByIDs(ids)
+}
+
+func (s *Service) GetOrderItemByID(ctx context.Context, id int) (*types.OrderItem, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	return s.getOrderItem(id)
+}
+
+// UpdateOrderItem corrects a line of an order, the stock it took is left as
+// it is.
+func (s *Service) UpdateOrderItem(ctx context.Context, orderItem types.OrderItem) (int64, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return 0, err
+	}
+	before, err := s.getOrderItem(orderItem.ID)
+	if err != nil {
+		return 0, err
+	}
+	updated, err := s.store.UpdateOrderItem(orderItem)
+	if err != nil {
+		return 0, err
+	}
+	audit.Record(ctx, audit.ActionUpdate, audit.EntityOrderItem, orderItem.ID, before, orderItem)
+	events.Publish(events.TopicOrder, events.KindUpdated, before.OrderID)
+	return updated, nil
+}
+
+func (s *Service) DeleteOrderItemByID(ctx context.Context, id int) (int64, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return 0, err
+	}
+	before, err := s.getOrderItem(id)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := s.store.DeleteOrderItemByID(id)
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		audit.Record(ctx, audit.ActionDelete, audit.EntityOrderItem, id, before, nil)
+		events.Publish(events.TopicOrder, events.KindUpdated, before.OrderID)
+	}
+	return deleted, nil
+}
+
+// WatchOrders sends the new and changed orders after the cursor as they
+// happen, until ctx is done. The orders are read when their events are sent,
+// a change to an order trashed since is skipped for its delete.
+func (s *Service) WatchOrders(ctx context.Context, cursor int64, send func(types.OrderEvent) error) error {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return err
+	}
+	return events.Watch(ctx, events.TopicOrder, cursor, func(batch []types.DomainEvent) error {
+		ids := make([]int, 0, len(batch))
+		for _, e := range batch {
+			ids = append(ids, e.EntityID)
+		}
+		orders, err := s.store.GetOrdersByIDs(ids)
+		if err != nil {
+			return err
+		}
+		byID := make(map[int]types.Order, len(orders))
+		for _, o := range orders {
+			byID[o.ID] = o
+		}
+		for _, e := range batch {
+			o, ok := byID[e.EntityID]
+			if e.Kind == events.KindDeleted {
+				o, ok = types.Order{ID: e.EntityID}, true
+			}
+			if !ok {
+				continue
+			}
+			if err := send(types.OrderEvent{Cursor: e.ID, Kind: e.Kind, Order: o}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) getOrder(id int) (*types.Order, error) {
+	order, err := s.store.GetOrderByID(id)
+	if errors.Is(err, types.ErrNotFound) || (err == nil && order == nil) {
+		return nil, status.Errorf(codes.NotFound, "order %v not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *Service) getOrderItem(id int) (*types.OrderItem, error) {
+	orderItem, err := s.store.GetOrderItemsByID(id)
+	if errors.Is(err, types.ErrNotFound) || (err == nil && orderItem == nil) {
+		return nil, status.Errorf(codes.NotFound, "order item %v not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return orderItem, nil
+}
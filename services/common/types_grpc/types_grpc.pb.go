@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
 // - protoc-gen-go-grpc v1.5.1
-// - protoc             v5.27.4
+// - protoc             (unknown)
 // source: types_grpc/types.proto
 
 package types_grpc
@@ -28,6 +28,8 @@ const (
 	UserService_DeleteUser_FullMethodName                = "/types.UserService/DeleteUser"
 	UserService_UpdateUser_FullMethodName                = "/types.UserService/UpdateUser"
 	UserService_CreateUser_FullMethodName                = "/types.UserService/CreateUser"
+	UserService_GetDeletedUsers_FullMethodName           = "/types.UserService/GetDeletedUsers"
+	UserService_RestoreUserByID_FullMethodName           = "/types.UserService/RestoreUserByID"
 )
 
 // UserServiceClient is the client API for UserService service.
@@ -45,6 +47,8 @@ type UserServiceClient interface {
 	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error)
 	UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*UpdateUserResponse, error)
 	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*CreateUserResponse, error)
+	GetDeletedUsers(ctx context.Context, in *GetDeletedUsersRequest, opts ...grpc.CallOption) (*GetDeletedUsersResponse, error)
+	RestoreUserByID(ctx context.Context, in *RestoreUserByIDRequest, opts ...grpc.CallOption) (*RestoreUserByIDResponse, error)
 }
 
 type userServiceClient struct {
@@ -145,6 +149,26 @@ func (c *userServiceClient) CreateUser(ctx context.Context, in *CreateUserReques
 	return out, nil
 }
 
+func (c *userServiceClient) GetDeletedUsers(ctx context.Context, in *GetDeletedUsersRequest, opts ...grpc.CallOption) (*GetDeletedUsersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDeletedUsersResponse)
+	err := c.cc.Invoke(ctx, UserService_GetDeletedUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RestoreUserByID(ctx context.Context, in *RestoreUserByIDRequest, opts ...grpc.CallOption) (*RestoreUserByIDResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreUserByIDResponse)
+	err := c.cc.Invoke(ctx, UserService_RestoreUserByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility.
@@ -160,6 +184,8 @@ type UserServiceServer interface {
 	DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error)
 	UpdateUser(context.Context, *UpdateUserRequest) (*UpdateUserResponse, error)
 	CreateUser(context.Context, *CreateUserRequest) (*CreateUserResponse, error)
+	GetDeletedUsers(context.Context, *GetDeletedUsersRequest) (*GetDeletedUsersResponse, error)
+	RestoreUserByID(context.Context, *RestoreUserByIDRequest) (*RestoreUserByIDResponse, error)
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -197,6 +223,12 @@ func (UnimplementedUserServiceServer) UpdateUser(context.Context, *UpdateUserReq
 func (UnimplementedUserServiceServer) CreateUser(context.Context, *CreateUserRequest) (*CreateUserResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
 }
+func (UnimplementedUserServiceServer) GetDeletedUsers(context.Context, *GetDeletedUsersRequest) (*GetDeletedUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeletedUsers not implemented")
+}
+func (UnimplementedUserServiceServer) RestoreUserByID(context.Context, *RestoreUserByIDRequest) (*RestoreUserByIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreUserByID not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 func (UnimplementedUserServiceServer) testEmbeddedByValue()                     {}
 
@@ -380,6 +412,42 @@ func _UserService_CreateUser_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_GetDeletedUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeletedUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetDeletedUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetDeletedUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetDeletedUsers(ctx, req.(*GetDeletedUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RestoreUserByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreUserByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RestoreUserByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RestoreUserByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RestoreUserByID(ctx, req.(*RestoreUserByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -423,19 +491,32 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateUser",
 			Handler:    _UserService_CreateUser_Handler,
 		},
+		{
+			MethodName: "GetDeletedUsers",
+			Handler:    _UserService_GetDeletedUsers_Handler,
+		},
+		{
+			MethodName: "RestoreUserByID",
+			Handler:    _UserService_RestoreUserByID_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "types_grpc/types.proto",
 }
 
 const (
-	ProductService_GetProducts_FullMethodName       = "/types.ProductService/GetProducts"
-	ProductService_GetProductsByIDs_FullMethodName  = "/types.ProductService/GetProductsByIDs"
-	ProductService_GetProductByID_FullMethodName    = "/types.ProductService/GetProductByID"
-	ProductService_CreateProduct_FullMethodName     = "/types.ProductService/CreateProduct"
-	ProductService_DeleteProductByID_FullMethodName = "/types.ProductService/DeleteProductByID"
-	ProductService_DeleteProduct_FullMethodName     = "/types.ProductService/DeleteProduct"
-	ProductService_UpdateProduct_FullMethodName     = "/types.ProductService/UpdateProduct"
+	ProductService_GetProducts_FullMethodName        = "/types.ProductService/GetProducts"
+	ProductService_GetProductsByIDs_FullMethodName   = "/types.ProductService/GetProductsByIDs"
+	ProductService_GetProductByID_FullMethodName     = "/types.ProductService/GetProductByID"
+	ProductService_CreateProduct_FullMethodName      = "/types.ProductService/CreateProduct"
+	ProductService_DeleteProductByID_FullMethodName  = "/types.ProductService/DeleteProductByID"
+	ProductService_DeleteProduct_FullMethodName      = "/types.ProductService/DeleteProduct"
+	ProductService_UpdateProduct_FullMethodName      = "/types.ProductService/UpdateProduct"
+	ProductService_GetDeletedProducts_FullMethodName = "/types.ProductService/GetDeletedProducts"
+	ProductService_RestoreProductByID_FullMethodName = "/types.ProductService/RestoreProductByID"
+	ProductService_ExportProducts_FullMethodName     = "/types.ProductService/ExportProducts"
+	ProductService_StreamProducts_FullMethodName     = "/types.ProductService/StreamProducts"
+	ProductService_WatchProducts_FullMethodName      = "/types.ProductService/WatchProducts"
 )
 
 // ProductServiceClient is the client API for ProductService service.
@@ -451,6 +532,11 @@ type ProductServiceClient interface {
 	DeleteProductByID(ctx context.Context, in *DeleteProductByIDRequest, opts ...grpc.CallOption) (*DeleteProductByIDResponse, error)
 	DeleteProduct(ctx context.Context, in *DeleteProductRequest, opts ...grpc.CallOption) (*DeleteProductResponse, error)
 	UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*UpdateProductResponse, error)
+	GetDeletedProducts(ctx context.Context, in *GetDeletedProductsRequest, opts ...grpc.CallOption) (*GetDeletedProductsResponse, error)
+	RestoreProductByID(ctx context.Context, in *RestoreProductByIDRequest, opts ...grpc.CallOption) (*RestoreProductByIDResponse, error)
+	ExportProducts(ctx context.Context, in *ExportProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Product], error)
+	StreamProducts(ctx context.Context, in *StreamProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProductPage], error)
+	WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProductEvent], error)
 }
 
 type productServiceClient struct {
@@ -531,6 +617,83 @@ func (c *productServiceClient) UpdateProduct(ctx context.Context, in *UpdateProd
 	return out, nil
 }
 
+func (c *productServiceClient) GetDeletedProducts(ctx context.Context, in *GetDeletedProductsRequest, opts ...grpc.CallOption) (*GetDeletedProductsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDeletedProductsResponse)
+	err := c.cc.Invoke(ctx, ProductService_GetDeletedProducts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productServiceClient) RestoreProductByID(ctx context.Context, in *RestoreProductByIDRequest, opts ...grpc.CallOption) (*RestoreProductByIDResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreProductByIDResponse)
+	err := c.cc.Invoke(ctx, ProductService_RestoreProductByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productServiceClient) ExportProducts(ctx context.Context, in *ExportProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Product], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ProductService_ServiceDesc.Streams[0], ProductService_ExportProducts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportProductsRequest, Product]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductService_ExportProductsClient = grpc.ServerStreamingClient[Product]
+
+func (c *productServiceClient) StreamProducts(ctx context.Context, in *StreamProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProductPage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ProductService_ServiceDesc.Streams[1], ProductService_StreamProducts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamProductsRequest, ProductPage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductService_StreamProductsClient = grpc.ServerStreamingClient[ProductPage]
+
+func (c *productServiceClient) WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProductEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ProductService_ServiceDesc.Streams[2], ProductService_WatchProducts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchProductsRequest, ProductEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductService_WatchProductsClient = grpc.ServerStreamingClient[ProductEvent]
+
 // ProductServiceServer is the server API for ProductService service.
 // All implementations must embed UnimplementedProductServiceServer
 // for forward compatibility.
@@ -544,6 +707,11 @@ type ProductServiceServer interface {
 	DeleteProductByID(context.Context, *DeleteProductByIDRequest) (*DeleteProductByIDResponse, error)
 	DeleteProduct(context.Context, *DeleteProductRequest) (*DeleteProductResponse, error)
 	UpdateProduct(context.Context, *UpdateProductRequest) (*UpdateProductResponse, error)
+	GetDeletedProducts(context.Context, *GetDeletedProductsRequest) (*GetDeletedProductsResponse, error)
+	RestoreProductByID(context.Context, *RestoreProductByIDRequest) (*RestoreProductByIDResponse, error)
+	ExportProducts(*ExportProductsRequest, grpc.ServerStreamingServer[Product]) error
+	StreamProducts(*StreamProductsRequest, grpc.ServerStreamingServer[ProductPage]) error
+	WatchProducts(*WatchProductsRequest, grpc.ServerStreamingServer[ProductEvent]) error
 	mustEmbedUnimplementedProductServiceServer()
 }
 
@@ -575,6 +743,21 @@ func (UnimplementedProductServiceServer) DeleteProduct(context.Context, *DeleteP
 func (UnimplementedProductServiceServer) UpdateProduct(context.Context, *UpdateProductRequest) (*UpdateProductResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateProduct not implemented")
 }
+func (UnimplementedProductServiceServer) GetDeletedProducts(context.Context, *GetDeletedProductsRequest) (*GetDeletedProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeletedProducts not implemented")
+}
+func (UnimplementedProductServiceServer) RestoreProductByID(context.Context, *RestoreProductByIDRequest) (*RestoreProductByIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreProductByID not implemented")
+}
+func (UnimplementedProductServiceServer) ExportProducts(*ExportProductsRequest, grpc.ServerStreamingServer[Product]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportProducts not implemented")
+}
+func (UnimplementedProductServiceServer) StreamProducts(*StreamProductsRequest, grpc.ServerStreamingServer[ProductPage]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamProducts not implemented")
+}
+func (UnimplementedProductServiceServer) WatchProducts(*WatchProductsRequest, grpc.ServerStreamingServer[ProductEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchProducts not implemented")
+}
 func (UnimplementedProductServiceServer) mustEmbedUnimplementedProductServiceServer() {}
 func (UnimplementedProductServiceServer) testEmbeddedByValue()                        {}
 
@@ -722,6 +905,75 @@ func _ProductService_UpdateProduct_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProductService_GetDeletedProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeletedProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).GetDeletedProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_GetDeletedProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).GetDeletedProducts(ctx, req.(*GetDeletedProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductService_RestoreProductByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreProductByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).RestoreProductByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_RestoreProductByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).RestoreProductByID(ctx, req.(*RestoreProductByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductService_ExportProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportProductsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductServiceServer).ExportProducts(m, &grpc.GenericServerStream[ExportProductsRequest, Product]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductService_ExportProductsServer = grpc.ServerStreamingServer[Product]
+
+func _ProductService_StreamProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamProductsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductServiceServer).StreamProducts(m, &grpc.GenericServerStream[StreamProductsRequest, ProductPage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductService_StreamProductsServer = grpc.ServerStreamingServer[ProductPage]
+
+func _ProductService_WatchProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchProductsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductServiceServer).WatchProducts(m, &grpc.GenericServerStream[WatchProductsRequest, ProductEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductService_WatchProductsServer = grpc.ServerStreamingServer[ProductEvent]
+
 // ProductService_ServiceDesc is the grpc.ServiceDesc for ProductService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -757,19 +1009,46 @@ var ProductService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateProduct",
 			Handler:    _ProductService_UpdateProduct_Handler,
 		},
+		{
+			MethodName: "GetDeletedProducts",
+			Handler:    _ProductService_GetDeletedProducts_Handler,
+		},
+		{
+			MethodName: "RestoreProductByID",
+			Handler:    _ProductService_RestoreProductByID_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportProducts",
+			Handler:       _ProductService_ExportProducts_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamProducts",
+			Handler:       _ProductService_StreamProducts_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchProducts",
+			Handler:       _ProductService_WatchProducts_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "types_grpc/types.proto",
 }
 
 const (
-	OrderService_GetOrders_FullMethodName       = "/types.OrderService/GetOrders"
-	OrderService_GetOrdersByIDs_FullMethodName  = "/types.OrderService/GetOrdersByIDs"
-	OrderService_GetOrderByID_FullMethodName    = "/types.OrderService/GetOrderByID"
-	OrderService_CreateOrder_FullMethodName     = "/types.OrderService/CreateOrder"
-	OrderService_DeleteOrderByID_FullMethodName = "/types.OrderService/DeleteOrderByID"
-	OrderService_DeleteOrder_FullMethodName     = "/types.OrderService/DeleteOrder"
-	OrderService_UpdateOrder_FullMethodName     = "/types.OrderService/UpdateOrder"
+	OrderService_GetOrders_FullMethodName        = "/types.OrderService/GetOrders"
+	OrderService_GetOrdersByIDs_FullMethodName   = "/types.OrderService/GetOrdersByIDs"
+	OrderService_GetOrderByID_FullMethodName     = "/types.OrderService/GetOrderByID"
+	OrderService_CreateOrder_FullMethodName      = "/types.OrderService/CreateOrder"
+	OrderService_DeleteOrderByID_FullMethodName  = "/types.OrderService/DeleteOrderByID"
+	OrderService_DeleteOrder_FullMethodName      = "/types.OrderService/DeleteOrder"
+	OrderService_UpdateOrder_FullMethodName      = "/types.OrderService/UpdateOrder"
+	OrderService_GetDeletedOrders_FullMethodName = "/types.OrderService/GetDeletedOrders"
+	OrderService_RestoreOrderByID_FullMethodName = "/types.OrderService/RestoreOrderByID"
+	OrderService_WatchOrders_FullMethodName      = "/types.OrderService/WatchOrders"
 )
 
 // OrderServiceClient is the client API for OrderService service.
@@ -785,6 +1064,9 @@ type OrderServiceClient interface {
 	DeleteOrderByID(ctx context.Context, in *DeleteOrderByIDRequest, opts ...grpc.CallOption) (*DeleteOrderByIDResponse, error)
 	DeleteOrder(ctx context.Context, in *DeleteOrderRequest, opts ...grpc.CallOption) (*DeleteOrderResponse, error)
 	UpdateOrder(ctx context.Context, in *UpdateOrderRequest, opts ...grpc.CallOption) (*UpdateOrderResponse, error)
+	GetDeletedOrders(ctx context.Context, in *GetDeletedOrdersRequest, opts ...grpc.CallOption) (*GetDeletedOrdersResponse, error)
+	RestoreOrderByID(ctx context.Context, in *RestoreOrderByIDRequest, opts ...grpc.CallOption) (*RestoreOrderByIDResponse, error)
+	WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error)
 }
 
 type orderServiceClient struct {
@@ -865,6 +1147,45 @@ func (c *orderServiceClient) UpdateOrder(ctx context.Context, in *UpdateOrderReq
 	return out, nil
 }
 
+func (c *orderServiceClient) GetDeletedOrders(ctx context.Context, in *GetDeletedOrdersRequest, opts ...grpc.CallOption) (*GetDeletedOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDeletedOrdersResponse)
+	err := c.cc.Invoke(ctx, OrderService_GetDeletedOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderServiceClient) RestoreOrderByID(ctx context.Context, in *RestoreOrderByIDRequest, opts ...grpc.CallOption) (*RestoreOrderByIDResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreOrderByIDResponse)
+	err := c.cc.Invoke(ctx, OrderService_RestoreOrderByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderServiceClient) WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderService_ServiceDesc.Streams[0], OrderService_WatchOrders_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchOrdersRequest, OrderEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderService_WatchOrdersClient = grpc.ServerStreamingClient[OrderEvent]
+
 // OrderServiceServer is the server API for OrderService service.
 // All implementations must embed UnimplementedOrderServiceServer
 // for forward compatibility.
@@ -878,6 +1199,9 @@ type OrderServiceServer interface {
 	DeleteOrderByID(context.Context, *DeleteOrderByIDRequest) (*DeleteOrderByIDResponse, error)
 	DeleteOrder(context.Context, *DeleteOrderRequest) (*DeleteOrderResponse, error)
 	UpdateOrder(context.Context, *UpdateOrderRequest) (*UpdateOrderResponse, error)
+	GetDeletedOrders(context.Context, *GetDeletedOrdersRequest) (*GetDeletedOrdersResponse, error)
+	RestoreOrderByID(context.Context, *RestoreOrderByIDRequest) (*RestoreOrderByIDResponse, error)
+	WatchOrders(*WatchOrdersRequest, grpc.ServerStreamingServer[OrderEvent]) error
 	mustEmbedUnimplementedOrderServiceServer()
 }
 
@@ -909,6 +1233,15 @@ func (UnimplementedOrderServiceServer) DeleteOrder(context.Context, *DeleteOrder
 func (UnimplementedOrderServiceServer) UpdateOrder(context.Context, *UpdateOrderRequest) (*UpdateOrderResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateOrder not implemented")
 }
+func (UnimplementedOrderServiceServer) GetDeletedOrders(context.Context, *GetDeletedOrdersRequest) (*GetDeletedOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeletedOrders not implemented")
+}
+func (UnimplementedOrderServiceServer) RestoreOrderByID(context.Context, *RestoreOrderByIDRequest) (*RestoreOrderByIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreOrderByID not implemented")
+}
+func (UnimplementedOrderServiceServer) WatchOrders(*WatchOrdersRequest, grpc.ServerStreamingServer[OrderEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrders not implemented")
+}
 func (UnimplementedOrderServiceServer) mustEmbedUnimplementedOrderServiceServer() {}
 func (UnimplementedOrderServiceServer) testEmbeddedByValue()                      {}
 
@@ -1056,6 +1389,53 @@ func _OrderService_UpdateOrder_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderService_GetDeletedOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeletedOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).GetDeletedOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderService_GetDeletedOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).GetDeletedOrders(ctx, req.(*GetDeletedOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderService_RestoreOrderByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreOrderByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).RestoreOrderByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderService_RestoreOrderByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).RestoreOrderByID(ctx, req.(*RestoreOrderByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderService_WatchOrders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrdersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderServiceServer).WatchOrders(m, &grpc.GenericServerStream[WatchOrdersRequest, OrderEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderService_WatchOrdersServer = grpc.ServerStreamingServer[OrderEvent]
+
 // OrderService_ServiceDesc is the grpc.ServiceDesc for OrderService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1091,8 +1471,22 @@ var OrderService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateOrder",
 			Handler:    _OrderService_UpdateOrder_Handler,
 		},
+		{
+			MethodName: "GetDeletedOrders",
+			Handler:    _OrderService_GetDeletedOrders_Handler,
+		},
+		{
+			MethodName: "RestoreOrderByID",
+			Handler:    _OrderService_RestoreOrderByID_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchOrders",
+			Handler:       _OrderService_WatchOrders_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "types_grpc/types.proto",
 }
 
@@ -1100,6 +1494,9 @@ const (
 	TokenService_GetBlacklistedTokens_FullMethodName      = "/types.TokenService/GetBlacklistedTokens"
 	TokenService_CreateBlacklistToken_FullMethodName      = "/types.TokenService/CreateBlacklistToken"
 	TokenService_GetBlacklistTokenByString_FullMethodName = "/types.TokenService/GetBlacklistTokenByString"
+	TokenService_CreateAPIKey_FullMethodName              = "/types.TokenService/CreateAPIKey"
+	TokenService_GetAPIKeys_FullMethodName                = "/types.TokenService/GetAPIKeys"
+	TokenService_RevokeAPIKey_FullMethodName              = "/types.TokenService/RevokeAPIKey"
 )
 
 // TokenServiceClient is the client API for TokenService service.
@@ -1111,6 +1508,9 @@ type TokenServiceClient interface {
 	GetBlacklistedTokens(ctx context.Context, in *GetBlacklistedTokensRequest, opts ...grpc.CallOption) (*GetBlacklistedTokensResponse, error)
 	CreateBlacklistToken(ctx context.Context, in *CreateBlacklistTokenRequest, opts ...grpc.CallOption) (*CreateBlacklistTokenResponse, error)
 	GetBlacklistTokenByString(ctx context.Context, in *GetBlacklistTokenByStringRequest, opts ...grpc.CallOption) (*GetBlacklistTokenByStringResponse, error)
+	CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error)
+	GetAPIKeys(ctx context.Context, in *GetAPIKeysRequest, opts ...grpc.CallOption) (*GetAPIKeysResponse, error)
+	RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*RevokeAPIKeyResponse, error)
 }
 
 type tokenServiceClient struct {
@@ -1151,6 +1551,36 @@ func (c *tokenServiceClient) GetBlacklistTokenByString(ctx context.Context, in *
 	return out, nil
 }
 
+func (c *tokenServiceClient) CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAPIKeyResponse)
+	err := c.cc.Invoke(ctx, TokenService_CreateAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tokenServiceClient) GetAPIKeys(ctx context.Context, in *GetAPIKeysRequest, opts ...grpc.CallOption) (*GetAPIKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAPIKeysResponse)
+	err := c.cc.Invoke(ctx, TokenService_GetAPIKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tokenServiceClient) RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*RevokeAPIKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeAPIKeyResponse)
+	err := c.cc.Invoke(ctx, TokenService_RevokeAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TokenServiceServer is the server API for TokenService service.
 // All implementations must embed UnimplementedTokenServiceServer
 // for forward compatibility.
@@ -1160,6 +1590,9 @@ type TokenServiceServer interface {
 	GetBlacklistedTokens(context.Context, *GetBlacklistedTokensRequest) (*GetBlacklistedTokensResponse, error)
 	CreateBlacklistToken(context.Context, *CreateBlacklistTokenRequest) (*CreateBlacklistTokenResponse, error)
 	GetBlacklistTokenByString(context.Context, *GetBlacklistTokenByStringRequest) (*GetBlacklistTokenByStringResponse, error)
+	CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	GetAPIKeys(context.Context, *GetAPIKeysRequest) (*GetAPIKeysResponse, error)
+	RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*RevokeAPIKeyResponse, error)
 	mustEmbedUnimplementedTokenServiceServer()
 }
 
@@ -1179,6 +1612,15 @@ func (UnimplementedTokenServiceServer) CreateBlacklistToken(context.Context, *Cr
 func (UnimplementedTokenServiceServer) GetBlacklistTokenByString(context.Context, *GetBlacklistTokenByStringRequest) (*GetBlacklistTokenByStringResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBlacklistTokenByString not implemented")
 }
+func (UnimplementedTokenServiceServer) CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAPIKey not implemented")
+}
+func (UnimplementedTokenServiceServer) GetAPIKeys(context.Context, *GetAPIKeysRequest) (*GetAPIKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAPIKeys not implemented")
+}
+func (UnimplementedTokenServiceServer) RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*RevokeAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeAPIKey not implemented")
+}
 func (UnimplementedTokenServiceServer) mustEmbedUnimplementedTokenServiceServer() {}
 func (UnimplementedTokenServiceServer) testEmbeddedByValue()                      {}
 
@@ -1254,24 +1696,764 @@ func _TokenService_GetBlacklistTokenByString_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
-// TokenService_ServiceDesc is the grpc.ServiceDesc for TokenService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var TokenService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "types.TokenService",
-	HandlerType: (*TokenServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "GetBlacklistedTokens",
-			Handler:    _TokenService_GetBlacklistedTokens_Handler,
-		},
-		{
-			MethodName: "CreateBlacklistToken",
-			Handler:    _TokenService_CreateBlacklistToken_Handler,
-		},
-		{
-			MethodName: "GetBlacklistTokenByString",
-			Handler:    _TokenService_GetBlacklistTokenByString_Handler,
+func _TokenService_CreateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TokenServiceServer).CreateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TokenService_CreateAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TokenServiceServer).CreateAPIKey(ctx, req.(*CreateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TokenService_GetAPIKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAPIKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TokenServiceServer).GetAPIKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TokenService_GetAPIKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TokenServiceServer).GetAPIKeys(ctx, req.(*GetAPIKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TokenService_RevokeAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TokenServiceServer).RevokeAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TokenService_RevokeAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TokenServiceServer).RevokeAPIKey(ctx, req.(*RevokeAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TokenService_ServiceDesc is the grpc.ServiceDesc for TokenService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TokenService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "types.TokenService",
+	HandlerType: (*TokenServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBlacklistedTokens",
+			Handler:    _TokenService_GetBlacklistedTokens_Handler,
+		},
+		{
+			MethodName: "CreateBlacklistToken",
+			Handler:    _TokenService_CreateBlacklistToken_Handler,
+		},
+		{
+			MethodName: "GetBlacklistTokenByString",
+			Handler:    _TokenService_GetBlacklistTokenByString_Handler,
+		},
+		{
+			MethodName: "CreateAPIKey",
+			Handler:    _TokenService_CreateAPIKey_Handler,
+		},
+		{
+			MethodName: "GetAPIKeys",
+			Handler:    _TokenService_GetAPIKeys_Handler,
+		},
+		{
+			MethodName: "RevokeAPIKey",
+			Handler:    _TokenService_RevokeAPIKey_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "types_grpc/types.proto",
+}
+
+const (
+	OrderItemService_GetOrderItems_FullMethodName       = "/types.OrderItemService/GetOrderItems"
+	OrderItemService_GetOrderItemsByIDs_FullMethodName  = "/types.OrderItemService/GetOrderItemsByIDs"
+	OrderItemService_GetOrderItemByID_FullMethodName    = "/types.OrderItemService/GetOrderItemByID"
+	OrderItemService_UpdateOrderItem_FullMethodName     = "/types.OrderItemService/UpdateOrderItem"
+	OrderItemService_DeleteOrderItemByID_FullMethodName = "/types.OrderItemService/DeleteOrderItemByID"
+)
+
+// OrderItemServiceClient is the client API for OrderItemService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OrderItemServiceClient interface {
+	GetOrderItems(ctx context.Context, in *GetOrderItemsRequest, opts ...grpc.CallOption) (*GetOrderItemsResponse, error)
+	GetOrderItemsByIDs(ctx context.Context, in *GetOrderItemsByIDsRequest, opts ...grpc.CallOption) (*GetOrderItemsByIDsResponse, error)
+	GetOrderItemByID(ctx context.Context, in *GetOrderItemByIDRequest, opts ...grpc.CallOption) (*GetOrderItemByIDResponse, error)
+	UpdateOrderItem(ctx context.Context, in *UpdateOrderItemRequest, opts ...grpc.CallOption) (*UpdateOrderItemResponse, error)
+	DeleteOrderItemByID(ctx context.Context, in *DeleteOrderItemByIDRequest, opts ...grpc.CallOption) (*DeleteOrderItemByIDResponse, error)
+}
+
+type orderItemServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrderItemServiceClient(cc grpc.ClientConnInterface) OrderItemServiceClient {
+	return &orderItemServiceClient{cc}
+}
+
+func (c *orderItemServiceClient) GetOrderItems(ctx context.Context, in *GetOrderItemsRequest, opts ...grpc.CallOption) (*GetOrderItemsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrderItemsResponse)
+	err := c.cc.Invoke(ctx, OrderItemService_GetOrderItems_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderItemServiceClient) GetOrderItemsByIDs(ctx context.Context, in *GetOrderItemsByIDsRequest, opts ...grpc.CallOption) (*GetOrderItemsByIDsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrderItemsByIDsResponse)
+	err := c.cc.Invoke(ctx, OrderItemService_GetOrderItemsByIDs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderItemServiceClient) GetOrderItemByID(ctx context.Context, in *GetOrderItemByIDRequest, opts ...grpc.CallOption) (*GetOrderItemByIDResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrderItemByIDResponse)
+	err := c.cc.Invoke(ctx, OrderItemService_GetOrderItemByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderItemServiceClient) UpdateOrderItem(ctx context.Context, in *UpdateOrderItemRequest, opts ...grpc.CallOption) (*UpdateOrderItemResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateOrderItemResponse)
+	err := c.cc.Invoke(ctx, OrderItemService_UpdateOrderItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderItemServiceClient) DeleteOrderItemByID(ctx context.Context, in *DeleteOrderItemByIDRequest, opts ...grpc.CallOption) (*DeleteOrderItemByIDResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteOrderItemByIDResponse)
+	err := c.cc.Invoke(ctx, OrderItemService_DeleteOrderItemByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OrderItemServiceServer is the server API for OrderItemService service.
+// All implementations must embed UnimplementedOrderItemServiceServer
+// for forward compatibility.
+type OrderItemServiceServer interface {
+	GetOrderItems(context.Context, *GetOrderItemsRequest) (*GetOrderItemsResponse, error)
+	GetOrderItemsByIDs(context.Context, *GetOrderItemsByIDsRequest) (*GetOrderItemsByIDsResponse, error)
+	GetOrderItemByID(context.Context, *GetOrderItemByIDRequest) (*GetOrderItemByIDResponse, error)
+	UpdateOrderItem(context.Context, *UpdateOrderItemRequest) (*UpdateOrderItemResponse, error)
+	DeleteOrderItemByID(context.Context, *DeleteOrderItemByIDRequest) (*DeleteOrderItemByIDResponse, error)
+	mustEmbedUnimplementedOrderItemServiceServer()
+}
+
+// UnimplementedOrderItemServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrderItemServiceServer struct{}
+
+func (UnimplementedOrderItemServiceServer) GetOrderItems(context.Context, *GetOrderItemsRequest) (*GetOrderItemsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrderItems not implemented")
+}
+func (UnimplementedOrderItemServiceServer) GetOrderItemsByIDs(context.Context, *GetOrderItemsByIDsRequest) (*GetOrderItemsByIDsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrderItemsByIDs not implemented")
+}
+func (UnimplementedOrderItemServiceServer) GetOrderItemByID(context.Context, *GetOrderItemByIDRequest) (*GetOrderItemByIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrderItemByID not implemented")
+}
+func (UnimplementedOrderItemServiceServer) UpdateOrderItem(context.Context, *UpdateOrderItemRequest) (*UpdateOrderItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateOrderItem not implemented")
+}
+func (UnimplementedOrderItemServiceServer) DeleteOrderItemByID(context.Context, *DeleteOrderItemByIDRequest) (*DeleteOrderItemByIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteOrderItemByID not implemented")
+}
+func (UnimplementedOrderItemServiceServer) mustEmbedUnimplementedOrderItemServiceServer() {}
+func (UnimplementedOrderItemServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeOrderItemServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrderItemServiceServer will
+// result in compilation errors.
+type UnsafeOrderItemServiceServer interface {
+	mustEmbedUnimplementedOrderItemServiceServer()
+}
+
+func RegisterOrderItemServiceServer(s grpc.ServiceRegistrar, srv OrderItemServiceServer) {
+	// If the following call pancis, it indicates UnimplementedOrderItemServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OrderItemService_ServiceDesc, srv)
+}
+
+func _OrderItemService_GetOrderItems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderItemsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderItemServiceServer).GetOrderItems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderItemService_GetOrderItems_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderItemServiceServer).GetOrderItems(ctx, req.(*GetOrderItemsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderItemService_GetOrderItemsByIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderItemsByIDsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderItemServiceServer).GetOrderItemsByIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderItemService_GetOrderItemsByIDs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderItemServiceServer).GetOrderItemsByIDs(ctx, req.(*GetOrderItemsByIDsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderItemService_GetOrderItemByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderItemByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderItemServiceServer).GetOrderItemByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderItemService_GetOrderItemByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderItemServiceServer).GetOrderItemByID(ctx, req.(*GetOrderItemByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderItemService_UpdateOrderItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateOrderItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderItemServiceServer).UpdateOrderItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderItemService_UpdateOrderItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderItemServiceServer).UpdateOrderItem(ctx, req.(*UpdateOrderItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderItemService_DeleteOrderItemByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteOrderItemByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderItemServiceServer).DeleteOrderItemByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderItemService_DeleteOrderItemByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderItemServiceServer).DeleteOrderItemByID(ctx, req.(*DeleteOrderItemByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OrderItemService_ServiceDesc is the grpc.ServiceDesc for OrderItemService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OrderItemService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "types.OrderItemService",
+	HandlerType: (*OrderItemServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetOrderItems",
+			Handler:    _OrderItemService_GetOrderItems_Handler,
+		},
+		{
+			MethodName: "GetOrderItemsByIDs",
+			Handler:    _OrderItemService_GetOrderItemsByIDs_Handler,
+		},
+		{
+			MethodName: "GetOrderItemByID",
+			Handler:    _OrderItemService_GetOrderItemByID_Handler,
+		},
+		{
+			MethodName: "UpdateOrderItem",
+			Handler:    _OrderItemService_UpdateOrderItem_Handler,
+		},
+		{
+			MethodName: "DeleteOrderItemByID",
+			Handler:    _OrderItemService_DeleteOrderItemByID_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "types_grpc/types.proto",
+}
+
+const (
+	CheckoutService_Checkout_FullMethodName = "/types.CheckoutService/Checkout"
+)
+
+// CheckoutServiceClient is the client API for CheckoutService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CheckoutServiceClient interface {
+	Checkout(ctx context.Context, in *CheckoutRequest, opts ...grpc.CallOption) (*CheckoutResponse, error)
+}
+
+type checkoutServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCheckoutServiceClient(cc grpc.ClientConnInterface) CheckoutServiceClient {
+	return &checkoutServiceClient{cc}
+}
+
+func (c *checkoutServiceClient) Checkout(ctx context.Context, in *CheckoutRequest, opts ...grpc.CallOption) (*CheckoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckoutResponse)
+	err := c.cc.Invoke(ctx, CheckoutService_Checkout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CheckoutServiceServer is the server API for CheckoutService service.
+// All implementations must embed UnimplementedCheckoutServiceServer
+// for forward compatibility.
+type CheckoutServiceServer interface {
+	Checkout(context.Context, *CheckoutRequest) (*CheckoutResponse, error)
+	mustEmbedUnimplementedCheckoutServiceServer()
+}
+
+// UnimplementedCheckoutServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCheckoutServiceServer struct{}
+
+func (UnimplementedCheckoutServiceServer) Checkout(context.Context, *CheckoutRequest) (*CheckoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Checkout not implemented")
+}
+func (UnimplementedCheckoutServiceServer) mustEmbedUnimplementedCheckoutServiceServer() {}
+func (UnimplementedCheckoutServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeCheckoutServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CheckoutServiceServer will
+// result in compilation errors.
+type UnsafeCheckoutServiceServer interface {
+	mustEmbedUnimplementedCheckoutServiceServer()
+}
+
+func RegisterCheckoutServiceServer(s grpc.ServiceRegistrar, srv CheckoutServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCheckoutServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CheckoutService_ServiceDesc, srv)
+}
+
+func _CheckoutService_Checkout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckoutServiceServer).Checkout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CheckoutService_Checkout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckoutServiceServer).Checkout(ctx, req.(*CheckoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CheckoutService_ServiceDesc is the grpc.ServiceDesc for CheckoutService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CheckoutService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "types.CheckoutService",
+	HandlerType: (*CheckoutServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Checkout",
+			Handler:    _CheckoutService_Checkout_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "types_grpc/types.proto",
+}
+
+const (
+	AuthService_Login_FullMethodName    = "/types.AuthService/Login"
+	AuthService_LoginMFA_FullMethodName = "/types.AuthService/LoginMFA"
+	AuthService_Refresh_FullMethodName  = "/types.AuthService/Refresh"
+	AuthService_Logout_FullMethodName   = "/types.AuthService/Logout"
+)
+
+// AuthServiceClient is the client API for AuthService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AuthServiceClient interface {
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	LoginMFA(ctx context.Context, in *LoginMFARequest, opts ...grpc.CallOption) (*LoginMFAResponse, error)
+	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error)
+	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error)
+}
+
+type authServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuthServiceClient(cc grpc.ClientConnInterface) AuthServiceClient {
+	return &authServiceClient{cc}
+}
+
+func (c *authServiceClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, AuthService_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) LoginMFA(ctx context.Context, in *LoginMFARequest, opts ...grpc.CallOption) (*LoginMFAResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginMFAResponse)
+	err := c.cc.Invoke(ctx, AuthService_LoginMFA_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefreshResponse)
+	err := c.cc.Invoke(ctx, AuthService_Refresh_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authServiceClient) Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogoutResponse)
+	err := c.cc.Invoke(ctx, AuthService_Logout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuthServiceServer is the server API for AuthService service.
+// All implementations must embed UnimplementedAuthServiceServer
+// for forward compatibility.
+type AuthServiceServer interface {
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	LoginMFA(context.Context, *LoginMFARequest) (*LoginMFAResponse, error)
+	Refresh(context.Context, *RefreshRequest) (*RefreshResponse, error)
+	Logout(context.Context, *LogoutRequest) (*LogoutResponse, error)
+	mustEmbedUnimplementedAuthServiceServer()
+}
+
+// UnimplementedAuthServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAuthServiceServer struct{}
+
+func (UnimplementedAuthServiceServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedAuthServiceServer) LoginMFA(context.Context, *LoginMFARequest) (*LoginMFAResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoginMFA not implemented")
+}
+func (UnimplementedAuthServiceServer) Refresh(context.Context, *RefreshRequest) (*RefreshResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refresh not implemented")
+}
+func (UnimplementedAuthServiceServer) Logout(context.Context, *LogoutRequest) (*LogoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedAuthServiceServer) mustEmbedUnimplementedAuthServiceServer() {}
+func (UnimplementedAuthServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeAuthServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuthServiceServer will
+// result in compilation errors.
+type UnsafeAuthServiceServer interface {
+	mustEmbedUnimplementedAuthServiceServer()
+}
+
+func RegisterAuthServiceServer(s grpc.ServiceRegistrar, srv AuthServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAuthServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AuthService_ServiceDesc, srv)
+}
+
+func _AuthService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_LoginMFA_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginMFARequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).LoginMFA(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_LoginMFA_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).LoginMFA(ctx, req.(*LoginMFARequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_Refresh_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).Refresh(ctx, req.(*RefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthService_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).Logout(ctx, req.(*LogoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AuthService_ServiceDesc is the grpc.ServiceDesc for AuthService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AuthService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "types.AuthService",
+	HandlerType: (*AuthServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Login",
+			Handler:    _AuthService_Login_Handler,
+		},
+		{
+			MethodName: "LoginMFA",
+			Handler:    _AuthService_LoginMFA_Handler,
+		},
+		{
+			MethodName: "Refresh",
+			Handler:    _AuthService_Refresh_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _AuthService_Logout_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "types_grpc/types.proto",
+}
+
+const (
+	FinanceService_GetFinanceReport_FullMethodName = "/types.FinanceService/GetFinanceReport"
+)
+
+// FinanceServiceClient is the client API for FinanceService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FinanceServiceClient interface {
+	GetFinanceReport(ctx context.Context, in *GetFinanceReportRequest, opts ...grpc.CallOption) (*GetFinanceReportResponse, error)
+}
+
+type financeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFinanceServiceClient(cc grpc.ClientConnInterface) FinanceServiceClient {
+	return &financeServiceClient{cc}
+}
+
+func (c *financeServiceClient) GetFinanceReport(ctx context.Context, in *GetFinanceReportRequest, opts ...grpc.CallOption) (*GetFinanceReportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetFinanceReportResponse)
+	err := c.cc.Invoke(ctx, FinanceService_GetFinanceReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FinanceServiceServer is the server API for FinanceService service.
+// All implementations must embed UnimplementedFinanceServiceServer
+// for forward compatibility.
+type FinanceServiceServer interface {
+	GetFinanceReport(context.Context, *GetFinanceReportRequest) (*GetFinanceReportResponse, error)
+	mustEmbedUnimplementedFinanceServiceServer()
+}
+
+// UnimplementedFinanceServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFinanceServiceServer struct{}
+
+func (UnimplementedFinanceServiceServer) GetFinanceReport(context.Context, *GetFinanceReportRequest) (*GetFinanceReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFinanceReport not implemented")
+}
+func (UnimplementedFinanceServiceServer) mustEmbedUnimplementedFinanceServiceServer() {}
+func (UnimplementedFinanceServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeFinanceServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FinanceServiceServer will
+// result in compilation errors.
+type UnsafeFinanceServiceServer interface {
+	mustEmbedUnimplementedFinanceServiceServer()
+}
+
+func RegisterFinanceServiceServer(s grpc.ServiceRegistrar, srv FinanceServiceServer) {
+	// If the following call pancis, it indicates UnimplementedFinanceServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FinanceService_ServiceDesc, srv)
+}
+
+func _FinanceService_GetFinanceReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFinanceReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FinanceServiceServer).GetFinanceReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FinanceService_GetFinanceReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FinanceServiceServer).GetFinanceReport(ctx, req.(*GetFinanceReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FinanceService_ServiceDesc is the grpc.ServiceDesc for FinanceService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FinanceService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "types.FinanceService",
+	HandlerType: (*FinanceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetFinanceReport",
+			Handler:    _FinanceService_GetFinanceReport_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -0,0 +1,59 @@
+package eventlog
+
+import (
+	"database/sql"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Signature of Event Store
+// CreateEvent(types.DomainEvent) (int64, error)
+// GetEvents(topic string, afterID int64, limit int) ([]types.DomainEvent, error)
+// GetLastEventID(topic string) (int64, error)
+//
+// the log is append-only, the IDs are the cursors of the streams.
+
+func (s *Store) CreateEvent(e types.DomainEvent) (int64, error) {
+	res, err := s.db.Exec(
+		"INSERT INTO domain_events (topic, kind, entityId, createdAt) VALUES (?, ?, ?, ?)",
+		e.Topic, e.Kind, e.EntityID, e.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetEvents returns the events of the topic after the ID, oldest first.
+func (s *Store) GetEvents(topic string, afterID int64, limit int) ([]types.DomainEvent, error) {
+	rows, err := s.db.Query("SELECT * FROM domain_events WHERE topic = ? AND id > ? ORDER BY id LIMIT ?", topic, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := make([]types.DomainEvent, 0)
+	for rows.Next() {
+		var e types.DomainEvent
+		if err := rows.Scan(&e.ID, &e.Topic, &e.Kind, &e.EntityID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// GetLastEventID is the cursor of the newest event of the topic, 0 when
+// there is none.
+func (s *Store) GetLastEventID(topic string) (int64, error) {
+	var id int64
+	err := s.db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM domain_events WHERE topic = ?", topic).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,36 @@
+package finance
+
+import (
+	"context"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"google.golang.org/grpc"
+)
+
+// HandlerServer is the gRPC transport of the report, it only converts the
+// messages of the service.
+type HandlerServer struct {
+	pb.UnimplementedFinanceServiceServer
+	service types.FinanceService
+}
+
+func NewServer(service types.FinanceService) *HandlerServer {
+	return &HandlerServer{service: service}
+}
+
+func NewHandlerServer(grpcServer *grpc.Server, service types.FinanceService) {
+	pb.RegisterFinanceServiceServer(grpcServer, NewServer(service))
+}
+
+func (h *HandlerServer) GetFinanceReport(ctx context.Context, req *pb.GetFinanceReportRequest) (*pb.GetFinanceReportResponse, error) {
+	report, err := h.service.GetFinanceReport(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetFinanceReportResponse{
+		TotalRevenue:   report.TotalRevenue,
+		TotalItemsSold: int32(report.TotalItemsSold),
+		OrderCount:     int32(report.OrderCount),
+	}, nil
+}
@@ -0,0 +1,44 @@
+package finance
+
+import (
+	"context"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+// Service is the report of the admin dashboard behind the REST and gRPC
+// transports. Errors are statuses, see utils.WriteStatusError.
+type Service struct {
+	orderStore types.OrderStore
+}
+
+func NewService(orderStore types.OrderStore) *Service {
+	return &Service{orderStore: orderStore}
+}
+
+// GetFinanceReport sums the sold lines of every order.
+func (s *Service) GetFinanceReport(ctx context.Context) (*types.FinanceReport, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	orderItems, err := s.orderStore.GetOrderItems()
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.orderStore.GetOrders()
+	if err != nil {
+		return nil, err
+	}
+	report := calculateDashboard(orderItems, orders)
+	return &report, nil
+}
+
+func calculateDashboard(orderItems []types.OrderItem, orders []types.Order) types.FinanceReport {
+	report := types.FinanceReport{OrderCount: len(orders)}
+	for _, item := range orderItems {
+		report.TotalRevenue += item.Price * float64(item.Quantity)
+		report.TotalItemsSold += item.Quantity
+	}
+	return report
+}
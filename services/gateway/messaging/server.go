@@ -1,6 +1,8 @@
 package messaging
 
 import (
+	"log"
+
 	"github.com/nats-io/nats.go"
 )
 
@@ -14,3 +16,25 @@ func Connect() (*nats.Conn, error) {
 	nc, err := nats.Connect(nats.DefaultURL)
 	return nc, err
 }
+
+// LowStockSubject is the subject the low-stock alerts of the inventory are
+// published on.
+const LowStockSubject = "inventory.low_stock"
+
+// PublishLowStockEvent publishes a low-stock alert to NATS
+func PublishLowStockEvent(nc *nats.Conn, alert []byte) error {
+	return nc.Publish(LowStockSubject, alert)
+}
+
+// LowStockPublisher connects to NATS and returns the publisher of the
+// low-stock alerts, nil when NATS can't be reached.
+func LowStockPublisher() func(alert []byte) error {
+	nc, err := Connect()
+	if err != nil {
+		log.Printf("NATS is unavailable, low-stock events won't be published: %v", err)
+		return nil
+	}
+	return func(alert []byte) error {
+		return PublishLowStockEvent(nc, alert)
+	}
+}
@@ -0,0 +1,184 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/inbox"
+	"github.com/fayleenpc/tj-jeans/internal/ratelimiter"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type Handler struct {
+	store      types.NotificationStore
+	hub        *inbox.Hub
+	userStore  types.UserStore
+	tokenStore types.TokenStore
+	now        func() time.Time
+}
+
+func NewHandler(store types.NotificationStore, hub *inbox.Hub, userStore types.UserStore, tokenStore types.TokenStore) *Handler {
+	return &Handler{store: store, hub: hub, userStore: userStore, tokenStore: tokenStore, now: time.Now}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/me/notifications", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetNotifications), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/me/notifications/stream", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleStreamNotifications), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/me/notifications/read", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleMarkAllNotificationsRead), h.userStore, h.tokenStore)).Methods("POST")
+	router.HandleFunc("/me/notifications/{notification_id}/read", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleMarkNotificationRead), h.userStore, h.tokenStore)).Methods("POST")
+}
+
+func (h *Handler) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetNotifications")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	notifications, err := h.store.GetNotifications(userID, filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	unread, err := h.store.CountUnreadNotifications(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.NotificationInbox{Unread: unread, Notifications: notifications})
+}
+
+func (h *Handler) handleStreamNotifications(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleStreamNotifications")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	lastID := 0
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 0 {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid Last-Event-ID"))
+			return
+		}
+		lastID = id
+	}
+
+	// subscribed before the missed notifications are read so none falls in
+	// between, the stream skips the ones it already sent
+	userID := auth.GetUserIDFromContext(r.Context())
+	live, cancel := h.hub.Subscribe(userID)
+	defer cancel()
+
+	var missed []types.Notification
+	if lastID > 0 {
+		var err error
+		missed, err = h.store.GetNotifications(userID, types.NotificationFilter{AfterID: lastID, Limit: maxLimit})
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if err := inbox.Stream(w, r, missed, live); err != nil {
+		log.Printf("notification stream of user %d closed: %v", userID, err)
+	}
+}
+
+func (h *Handler) handleMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleMarkNotificationRead")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	notificationID, err := strconv.Atoi(mux.Vars(r)["notification_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid notification id"))
+		return
+	}
+	read, err := h.store.MarkNotificationRead(notificationID, auth.GetUserIDFromContext(r.Context()), h.now())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if read == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("no unread notification %d", notificationID))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]int{"read_id": notificationID})
+}
+
+func (h *Handler) handleMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleMarkAllNotificationsRead")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	read, err := h.store.MarkAllNotificationsRead(auth.GetUserIDFromContext(r.Context()), h.now())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]int64{"read": read})
+}
+
+// ParseFilter reads the filter of the inbox from the query of a request.
+func ParseFilter(query url.Values) (types.NotificationFilter, error) {
+	filter := types.NotificationFilter{Limit: defaultLimit}
+	if value := query.Get("unread"); value != "" {
+		unread, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid unread, expected true or false")
+		}
+		filter.Unread = unread
+	}
+	ints := map[string]*int{
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	}
+	for name, field := range ints {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("invalid %s", name)
+		}
+		*field = n
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+	return filter, nil
+}
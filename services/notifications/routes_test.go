@@ -0,0 +1,210 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/inbox"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/gorilla/mux"
+)
+
+func TestNotificationsServiceHandler(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	serve := func(store *mockNotificationStore, hub *inbox.Hub, req *http.Request) *httptest.ResponseRecorder {
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, 1))
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		handler := NewHandler(store, hub, nil, nil)
+		handler.now = func() time.Time { return now }
+		router.HandleFunc("/me/notifications", handler.handleGetNotifications).Methods("GET")
+		router.HandleFunc("/me/notifications/stream", handler.handleStreamNotifications).Methods("GET")
+		router.HandleFunc("/me/notifications/read", handler.handleMarkAllNotificationsRead).Methods("POST")
+		router.HandleFunc("/me/notifications/{notification_id}/read", handler.handleMarkNotificationRead).Methods("POST")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	newRequest := func(method string, path string) *http.Request {
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	t.Run("should list the inbox of the current user", func(t *testing.T) {
+		store := &mockNotificationStore{notifications: []types.Notification{
+			{ID: 1, UserID: 1, Kind: types.NotificationOrderCreated},
+			{ID: 2, UserID: 2, Kind: types.NotificationNewOrder},
+			{ID: 3, UserID: 1, Kind: types.NotificationOrderPaid},
+		}}
+		rr := serve(store, inbox.NewHub(), newRequest(http.MethodGet, "/me/notifications?unread=true"))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if !store.filter.Unread || store.filter.Limit != defaultLimit {
+			t.Errorf("unexpected filter %+v", store.filter)
+		}
+		var payload types.NotificationInbox
+		json.NewDecoder(rr.Body).Decode(&payload)
+		if payload.Unread != 2 || len(payload.Notifications) != 2 {
+			t.Errorf("unexpected inbox %+v", payload)
+		}
+	})
+	t.Run("should only mark the notifications of the current user read", func(t *testing.T) {
+		store := &mockNotificationStore{notifications: []types.Notification{{ID: 1, UserID: 1}, {ID: 2, UserID: 2}}}
+		if rr := serve(store, inbox.NewHub(), newRequest(http.MethodPost, "/me/notifications/1/read")); rr.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if store.notifications[0].ReadAt == nil || !store.notifications[0].ReadAt.Equal(now) {
+			t.Errorf("expected the notification to be read now, got %v", store.notifications[0].ReadAt)
+		}
+		if rr := serve(store, inbox.NewHub(), newRequest(http.MethodPost, "/me/notifications/1/read")); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+		if rr := serve(store, inbox.NewHub(), newRequest(http.MethodPost, "/me/notifications/2/read")); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+		if rr := serve(store, inbox.NewHub(), newRequest(http.MethodPost, "/me/notifications/x/read")); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+	t.Run("should mark every notification read", func(t *testing.T) {
+		store := &mockNotificationStore{notifications: []types.Notification{{ID: 1, UserID: 1}, {ID: 2, UserID: 1}, {ID: 3, UserID: 2}}}
+		rr := serve(store, inbox.NewHub(), newRequest(http.MethodPost, "/me/notifications/read"))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		var payload map[string]int64
+		json.NewDecoder(rr.Body).Decode(&payload)
+		if payload["read"] != 2 || store.notifications[2].ReadAt != nil {
+			t.Errorf("unexpected read %+v", payload)
+		}
+	})
+	t.Run("should replay the missed notifications and stream the new ones", func(t *testing.T) {
+		store := &mockNotificationStore{notifications: []types.Notification{
+			{ID: 1, UserID: 1, Title: "seen"},
+			{ID: 2, UserID: 1, Title: "missed"},
+		}}
+		hub := inbox.NewHub()
+		ctx, cancel := context.WithCancel(context.Background())
+		req := newRequest(http.MethodGet, "/me/notifications/stream").WithContext(ctx)
+		req.Header.Set("Last-Event-ID", "1")
+
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- serve(store, hub, req) }()
+		// the stream is subscribed once the missed notifications are read
+		for !store.replayed() {
+			time.Sleep(time.Millisecond)
+		}
+		hub.Deliver(types.Notification{ID: 3, UserID: 1, Title: "live"})
+		hub.Deliver(types.Notification{ID: 4, UserID: 2, Title: "someone else"})
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		rr := <-done
+
+		body := rr.Body.String()
+		if rr.Code != http.StatusOK || !strings.Contains(body, `"title":"missed"`) || !strings.Contains(body, `"title":"live"`) {
+			t.Errorf("unexpected stream %d %q", rr.Code, body)
+		}
+		if strings.Contains(body, `"title":"seen"`) || strings.Contains(body, "someone else") {
+			t.Errorf("unexpected notifications in the stream %q", body)
+		}
+
+		req = newRequest(http.MethodGet, "/me/notifications/stream")
+		req.Header.Set("Last-Event-ID", "x")
+		if rr := serve(store, hub, req); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Limit != defaultLimit || filter.Unread {
+		t.Errorf("unexpected default filter %+v", filter)
+	}
+
+	filter, err = ParseFilter(url.Values{"unread": {"true"}, "limit": {"10000"}, "offset": {"20"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filter.Unread || filter.Limit != maxLimit || filter.Offset != 20 {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+
+	for _, query := range []url.Values{{"unread": {"maybe"}}, {"limit": {"-1"}}, {"offset": {"x"}}} {
+		if _, err := ParseFilter(query); err == nil {
+			t.Errorf("expected %v to be invalid", query)
+		}
+	}
+}
+
+type mockNotificationStore struct {
+	types.NotificationStore
+	mu            sync.Mutex
+	notifications []types.Notification
+	filter        types.NotificationFilter
+}
+
+func (m *mockNotificationStore) GetNotifications(userID int, filter types.NotificationFilter) ([]types.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filter = filter
+	notifications := make([]types.Notification, 0)
+	for _, n := range m.notifications {
+		if n.UserID != userID || n.ID <= filter.AfterID || (filter.Unread && n.ReadAt != nil) {
+			continue
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+func (m *mockNotificationStore) CountUnreadNotifications(userID int) (int, error) {
+	unread := 0
+	for _, n := range m.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			unread++
+		}
+	}
+	return unread, nil
+}
+func (m *mockNotificationStore) MarkNotificationRead(id int, userID int, at time.Time) (int64, error) {
+	for i, n := range m.notifications {
+		if n.ID == id && n.UserID == userID && n.ReadAt == nil {
+			m.notifications[i].ReadAt = &at
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+func (m *mockNotificationStore) MarkAllNotificationsRead(userID int, at time.Time) (int64, error) {
+	var read int64
+	for i, n := range m.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			m.notifications[i].ReadAt = &at
+			read++
+		}
+	}
+	return read, nil
+}
+
+// replayed reports whether the stream read the missed notifications.
+func (m *mockNotificationStore) replayed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.filter.AfterID > 0
+}
@@ -0,0 +1,129 @@
+package notifications
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Signature of Notification Store
+// CreateNotification(types.Notification) (int64, error)
+// GetNotifications(userID int, filter types.NotificationFilter) ([]types.Notification, error)
+// CountUnreadNotifications(userID int) (int, error)
+// MarkNotificationRead(id int, userID int, at time.Time) (int64, error)
+// MarkAllNotificationsRead(userID int, at time.Time) (int64, error)
+// GetUserIDsByRole(role string) ([]int, error)
+
+func (s *Store) CreateNotification(n types.Notification) (int64, error) {
+	res, err := s.db.Exec(
+		"INSERT INTO notifications (userId, kind, title, body, link, createdAt) VALUES (?, ?, ?, ?, ?, ?)",
+		n.UserID, n.Kind, n.Title, n.Body, n.Link, n.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetNotifications returns the inbox of the user newest first, the
+// notifications after AfterID are returned oldest first to be replayed.
+func (s *Store) GetNotifications(userID int, filter types.NotificationFilter) ([]types.Notification, error) {
+	query := "SELECT * FROM notifications WHERE userId = ?"
+	args := []any{userID}
+	if filter.Unread {
+		query += " AND readAt IS NULL"
+	}
+	if filter.AfterID > 0 {
+		query += " AND id > ? ORDER BY id"
+		args = append(args, filter.AfterID)
+	} else {
+		query += " ORDER BY id DESC"
+	}
+	query += " LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	notifications := make([]types.Notification, 0)
+	for rows.Next() {
+		n, err := scanRowIntoNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *n)
+	}
+	return notifications, rows.Err()
+}
+
+func (s *Store) CountUnreadNotifications(userID int) (int, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM notifications WHERE userId = ? AND readAt IS NULL", userID).Scan(&count)
+	return count, err
+}
+
+// MarkNotificationRead only marks a notification of the user, it is 0 when
+// the notification is someone else's or already read.
+func (s *Store) MarkNotificationRead(id int, userID int, at time.Time) (int64, error) {
+	res, err := s.db.Exec("UPDATE notifications SET readAt = ? WHERE id = ? AND userId = ? AND readAt IS NULL", at, id, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) MarkAllNotificationsRead(userID int, at time.Time) (int64, error) {
+	res, err := s.db.Exec("UPDATE notifications SET readAt = ? WHERE userId = ? AND readAt IS NULL", at, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetUserIDsByRole returns the users of the role that aren't deleted, the
+// admins get the notifications of the shop.
+func (s *Store) GetUserIDsByRole(role string) ([]int, error) {
+	rows, err := s.db.Query("SELECT id FROM users WHERE role = ? AND deletedAt IS NULL", role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func scanRowIntoNotification(rows *sql.Rows) (*types.Notification, error) {
+	n := new(types.Notification)
+	err := rows.Scan(
+		&n.ID,
+		&n.UserID,
+		&n.Kind,
+		&n.Title,
+		&n.Body,
+		&n.Link,
+		&n.ReadAt,
+		&n.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/fayleenpc/tj-jeans/internal/types"
 )
@@ -18,10 +19,12 @@ import (
 // UpdateOrder(order types.Order) (int64, error)
 // GetOrderItems() ([]types.OrderItem, error)
 // GetOrderItemsByIDs(ordersItemsIDs []int) ([]types.OrderItem, error)
-// CreateOrderItem(orderItem types.OrderItem) error
+// CreateOrderItem(orderItem types.OrderItem) (int64, error)
 // DeleteOrderItemByID(id int) (int64, error)
 // DeleteOrderItem(orderItem types.OrderItem)
 // UpdateOrderItem(orderItem types.OrderItem) (int64, error)
+// GetDeletedOrders() ([]types.Order, error)
+// RestoreOrderByID(id int) (int64, error)
 
 type Store struct {
 	db *sql.DB
@@ -32,7 +35,7 @@ func NewStore(db *sql.DB) *Store {
 }
 
 func (s *Store) GetOrders() ([]types.Order, error) {
-	rows, err := s.db.Query("SELECT * FROM orders")
+	rows, err := s.db.Query("SELECT * FROM orders WHERE deletedAt IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +52,7 @@ func (s *Store) GetOrders() ([]types.Order, error) {
 
 func (s *Store) GetOrdersByIDs(ordersIDs []int) ([]types.Order, error) {
 	placeholders := strings.Repeat(",?", len(ordersIDs)-1)
-	query := fmt.Sprintf("SELECT * FROM orders WHERE id IN (?%s)", placeholders)
+	query := fmt.Sprintf("SELECT * FROM orders WHERE id IN (?%s) AND deletedAt IS NULL", placeholders)
 
 	args := make([]interface{}, len(ordersIDs))
 	for i, v := range ordersIDs {
@@ -74,7 +77,7 @@ func (s *Store) GetOrdersByIDs(ordersIDs []int) ([]types.Order, error) {
 }
 
 func (s *Store) GetOrderByID(id int) (*types.Order, error) {
-	rows, err := s.db.Query("SELECT * FROM orders WHERE id = ?", id)
+	rows, err := s.db.Query("SELECT * FROM orders WHERE id = ? AND deletedAt IS NULL", id)
 	if err != nil {
 		return nil, err
 	}
@@ -86,15 +89,15 @@ func (s *Store) GetOrderByID(id int) (*types.Order, error) {
 		}
 	}
 	if order.ID != id {
-		return nil, fmt.Errorf("order not found")
+		return nil, fmt.Errorf("order %w", types.ErrNotFound)
 	}
 	return order, nil
 }
 
 func (s *Store) CreateOrder(order types.Order) (int64, error) {
 	res, err := s.db.Exec(
-		"INSERT INTO orders (userId, total, status, address) VALUES (?, ?, ?, ?)",
-		order.UserID, order.Total, order.Status, order.Address,
+		"INSERT INTO orders (userId, total, status, address, phoneNumber) VALUES (?, ?, ?, ?, ?)",
+		order.UserID, order.Total, order.Status, order.Address, order.PhoneNumber,
 	)
 	if err != nil {
 		return 0, err
@@ -107,31 +110,26 @@ func (s *Store) CreateOrder(order types.Order) (int64, error) {
 	return id, nil
 }
 
+// DeleteOrderByID moves the order to the trash with its items, it can be
+// restored until the retention purge removes it for good.
 func (s *Store) DeleteOrderByID(id int) (int64, error) {
 	res, err := s.db.Exec(
-		"DELETE from orders WHERE id = ?",
-		id,
+		"UPDATE orders SET deletedAt = ? WHERE id = ? AND deletedAt IS NULL",
+		time.Now(), id,
 	)
 	if err != nil {
 		return 0, err
 	}
-	return res.LastInsertId()
+	return res.RowsAffected()
 }
 
 func (s *Store) DeleteOrder(order types.Order) (int64, error) {
-	res, err := s.db.Exec(
-		"DELETE from orders WHERE id = ?",
-		order.ID,
-	)
-	if err != nil {
-		return 0, err
-	}
-	return res.LastInsertId()
+	return s.DeleteOrderByID(order.ID)
 }
 
 func (s *Store) UpdateOrder(order types.Order) (int64, error) {
 	res, err := s.db.Exec(
-		"UPDATE orders SET status = ?, address = ? WHERE id = ?",
+		"UPDATE orders SET status = ?, address = ? WHERE id = ? AND deletedAt IS NULL",
 		order.Status, order.Address, order.ID,
 	)
 
@@ -196,14 +194,17 @@ func (s *Store) GetOrderItemsByID(id int) (*types.OrderItem, error) {
 		}
 	}
 	if orderItem.ID != id {
-		return nil, fmt.Errorf("order not found")
+		return nil, fmt.Errorf("order %w", types.ErrNotFound)
 	}
 	return orderItem, nil
 }
 
-func (s *Store) CreateOrderItem(orderItem types.OrderItem) error {
-	_, err := s.db.Exec("INSERT INTO order_items (orderId, productId, qty, price) VALUES (?, ?, ?, ?)", orderItem.OrderID, orderItem.ProductID, orderItem.Quantity, orderItem.Price)
-	return err
+func (s *Store) CreateOrderItem(orderItem types.OrderItem) (int64, error) {
+	res, err := s.db.Exec("INSERT INTO order_items (orderId, productId, qty, price, warehouseId) VALUES (?, ?, ?, ?, ?)", orderItem.OrderID, orderItem.ProductID, orderItem.Quantity, orderItem.Price, orderItem.WarehouseID)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
 }
 
 func (s *Store) DeleteOrderItemByID(id int) (int64, error) {
@@ -240,6 +241,54 @@ func (s *Store) UpdateOrderItem(orderItem types.OrderItem) (int64, error) {
 	return res.LastInsertId()
 }
 
+func (s *Store) GetDeletedOrders() ([]types.Order, error) {
+	rows, err := s.db.Query("SELECT * FROM orders WHERE deletedAt IS NOT NULL ORDER BY deletedAt DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	orders := make([]types.Order, 0)
+	for rows.Next() {
+		order, err := scanRowIntoOrders(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	return orders, rows.Err()
+}
+
+func (s *Store) RestoreOrderByID(id int) (int64, error) {
+	res, err := s.db.Exec("UPDATE orders SET deletedAt = NULL WHERE id = ? AND deletedAt IS NOT NULL", id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PurgeDeletedOrders removes the orders that went to the trash before the
+// cutoff together with their items.
+func (s *Store) PurgeDeletedOrders(before time.Time) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM order_items WHERE orderId IN (SELECT id FROM orders WHERE deletedAt < ?)", before); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec("DELETE FROM orders WHERE deletedAt < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return purged, tx.Commit()
+}
+
 func scanRowIntoOrders(rows *sql.Rows) (*types.Order, error) {
 	order := new(types.Order)
 	err := rows.Scan(
@@ -249,6 +298,8 @@ func scanRowIntoOrders(rows *sql.Rows) (*types.Order, error) {
 		&order.Status,
 		&order.Address,
 		&order.CreatedAt,
+		&order.DeletedAt,
+		&order.PhoneNumber,
 	)
 	if err != nil {
 		return nil, err
@@ -264,6 +315,7 @@ func scanRowIntoOrderItems(rows *sql.Rows) (*types.OrderItem, error) {
 		&orderItems.ProductID,
 		&orderItems.Quantity,
 		&orderItems.Price,
+		&orderItems.WarehouseID,
 	)
 	if err != nil {
 		return nil, err
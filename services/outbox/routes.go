@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
+	"github.com/fayleenpc/tj-jeans/internal/ratelimiter"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type Handler struct {
+	store      types.MailOutboxStore
+	userStore  types.UserStore
+	tokenStore types.TokenStore
+	now        func() time.Time
+}
+
+func NewHandler(store types.MailOutboxStore, userStore types.UserStore, tokenStore types.TokenStore) *Handler {
+	return &Handler{store: store, userStore: userStore, tokenStore: tokenStore, now: time.Now}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/mail_outbox", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetOutboxMails), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/mail_outbox/{mail_id}/retry", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleRetryOutboxMail), h.userStore, h.tokenStore)).Methods("POST")
+}
+
+func (h *Handler) handleGetOutboxMails(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetOutboxMails")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	mails, err := h.store.GetOutboxMails(filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, mails)
+}
+
+func (h *Handler) handleRetryOutboxMail(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleRetryOutboxMail")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	mailID, err := strconv.Atoi(mux.Vars(r)["mail_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid mail id"))
+		return
+	}
+	retried, err := h.store.RetryOutboxMail(mailID, h.now())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if retried == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("no unsent mail %d in the outbox", mailID))
+		return
+	}
+	mailer.WakeOutbox()
+
+	utils.WriteJSON(w, http.StatusAccepted, map[string]int{"retried_id": mailID})
+}
+
+// ParseFilter reads the filter of the outbox from the query of a request.
+func ParseFilter(query url.Values) (types.OutboxFilter, error) {
+	filter := types.OutboxFilter{
+		Status:   query.Get("status"),
+		Template: query.Get("template"),
+		Limit:    defaultLimit,
+	}
+	switch filter.Status {
+	case "", types.OutboxPending, types.OutboxSent, types.OutboxFailed:
+	default:
+		return filter, fmt.Errorf("invalid status, expected pending, sent or failed")
+	}
+	ints := map[string]*int{
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	}
+	for name, field := range ints {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("invalid %s", name)
+		}
+		*field = n
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+	return filter, nil
+}
@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/gorilla/mux"
+)
+
+func TestOutboxServiceHandler(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	serve := func(store *mockOutboxStore, role string, method string, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(req.Context(), auth.UserKey, 1)
+		ctx = context.WithValue(ctx, auth.UserRoleKey, role)
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		handler := NewHandler(store, nil, nil)
+		handler.now = func() time.Time { return now }
+		router.HandleFunc("/mail_outbox", handler.handleGetOutboxMails).Methods("GET")
+		router.HandleFunc("/mail_outbox/{mail_id}/retry", handler.handleRetryOutboxMail).Methods("POST")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should only let admins see the outbox", func(t *testing.T) {
+		if rr := serve(&mockOutboxStore{}, "customer", http.MethodGet, "/mail_outbox"); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+		if rr := serve(&mockOutboxStore{}, "customer", http.MethodPost, "/mail_outbox/1/retry"); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+	t.Run("should list the filtered outbox", func(t *testing.T) {
+		failedAt := now.Add(-time.Hour)
+		store := &mockOutboxStore{mails: []types.OutboxMail{{ID: 3, Template: "shipped", Attempts: 5, LastError: "connection refused", FailedAt: &failedAt}}}
+		rr := serve(store, "admin", http.MethodGet, "/mail_outbox?status=failed")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if store.filter.Status != types.OutboxFailed {
+			t.Errorf("unexpected filter %+v", store.filter)
+		}
+		var mails []types.OutboxMail
+		json.NewDecoder(rr.Body).Decode(&mails)
+		if len(mails) != 1 || mails[0].ID != 3 || mails[0].LastError != "connection refused" {
+			t.Errorf("unexpected outbox %+v", mails)
+		}
+	})
+	t.Run("should retry an unsent mail", func(t *testing.T) {
+		store := &mockOutboxStore{mails: []types.OutboxMail{{ID: 3}}}
+		if rr := serve(store, "admin", http.MethodPost, "/mail_outbox/3/retry"); rr.Code != http.StatusAccepted {
+			t.Errorf("expected status code %d, got %d", http.StatusAccepted, rr.Code)
+		}
+		if !store.retriedAt.Equal(now) {
+			t.Errorf("expected the mail to be retried now, got %v", store.retriedAt)
+		}
+		if rr := serve(store, "admin", http.MethodPost, "/mail_outbox/9/retry"); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Limit != defaultLimit || filter.Status != "" {
+		t.Errorf("unexpected default filter %+v", filter)
+	}
+
+	filter, err = ParseFilter(url.Values{"status": {"pending"}, "template": {"verification"}, "limit": {"10000"}, "offset": {"20"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Status != types.OutboxPending || filter.Template != "verification" || filter.Limit != maxLimit || filter.Offset != 20 {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+
+	for _, query := range []url.Values{{"status": {"lost"}}, {"limit": {"-1"}}, {"offset": {"x"}}} {
+		if _, err := ParseFilter(query); err == nil {
+			t.Errorf("expected %v to be invalid", query)
+		}
+	}
+}
+
+type mockOutboxStore struct {
+	types.MailOutboxStore
+	mails     []types.OutboxMail
+	filter    types.OutboxFilter
+	retriedAt time.Time
+}
+
+func (m *mockOutboxStore) GetOutboxMails(filter types.OutboxFilter) ([]types.OutboxMail, error) {
+	m.filter = filter
+	return m.mails, nil
+}
+func (m *mockOutboxStore) RetryOutboxMail(id int, at time.Time) (int64, error) {
+	for _, mail := range m.mails {
+		if mail.ID == id {
+			m.retriedAt = at
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
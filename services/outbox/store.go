@@ -0,0 +1,168 @@
+package outbox
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Signature of Mail Outbox Store
+// CreateOutboxMail(types.OutboxMail) (int64, error)
+// GetOutboxMails(types.OutboxFilter) ([]types.OutboxMail, error)
+// GetDueOutboxMails(now time.Time, limit int) ([]types.OutboxMail, error)
+// ClaimOutboxMail(id int, now time.Time, until time.Time) (bool, error)
+// MarkOutboxMailSent(id int, at time.Time) error
+// MarkOutboxMailFailed(id int, attempts int, lastError string, nextAttemptAt *time.Time, at time.Time) error
+// RetryOutboxMail(id int, at time.Time) (int64, error)
+// PurgeOutboxMails(before time.Time) (int64, error)
+
+func (s *Store) CreateOutboxMail(mail types.OutboxMail) (int64, error) {
+	res, err := s.db.Exec(
+		"INSERT INTO mail_outbox (template, recipients, subject, textBody, htmlBody, nextAttemptAt) VALUES (?, ?, ?, ?, ?, ?)",
+		mail.Template, strings.Join(mail.To, ","), mail.Subject, mail.Text, mail.HTML, mail.NextAttemptAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (s *Store) GetOutboxMails(filter types.OutboxFilter) ([]types.OutboxMail, error) {
+	var conditions []string
+	var args []any
+	switch filter.Status {
+	case types.OutboxPending:
+		conditions = append(conditions, "sentAt IS NULL AND failedAt IS NULL")
+	case types.OutboxSent:
+		conditions = append(conditions, "sentAt IS NOT NULL")
+	case types.OutboxFailed:
+		conditions = append(conditions, "failedAt IS NOT NULL")
+	}
+	if filter.Template != "" {
+		conditions = append(conditions, "template = ?")
+		args = append(args, filter.Template)
+	}
+
+	query := "SELECT * FROM mail_outbox"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	return s.queryOutboxMails(query, args...)
+}
+
+// GetDueOutboxMails returns the mail neither sent nor failed whose next
+// attempt is due, oldest first.
+func (s *Store) GetDueOutboxMails(now time.Time, limit int) ([]types.OutboxMail, error) {
+	return s.queryOutboxMails(
+		"SELECT * FROM mail_outbox WHERE sentAt IS NULL AND failedAt IS NULL AND nextAttemptAt <= ? ORDER BY id LIMIT ?",
+		now, limit,
+	)
+}
+
+// ClaimOutboxMail pushes the next attempt of a due mail to until, it is
+// false when another worker claimed it first.
+func (s *Store) ClaimOutboxMail(id int, now time.Time, until time.Time) (bool, error) {
+	res, err := s.db.Exec(
+		"UPDATE mail_outbox SET nextAttemptAt = ? WHERE id = ? AND sentAt IS NULL AND failedAt IS NULL AND nextAttemptAt <= ?",
+		until, id, now,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// MarkOutboxMailSent records the mail as sent and clears its bodies, they
+// carry the tokens of the links and aren't needed once the mail is out.
+func (s *Store) MarkOutboxMailSent(id int, at time.Time) error {
+	_, err := s.db.Exec("UPDATE mail_outbox SET sentAt = ?, attempts = attempts + 1, lastError = '', textBody = '', htmlBody = '' WHERE id = ?", at, id)
+	return err
+}
+
+// MarkOutboxMailFailed records a failed attempt, the mail is retried at
+// nextAttemptAt or failed for good when it is nil.
+func (s *Store) MarkOutboxMailFailed(id int, attempts int, lastError string, nextAttemptAt *time.Time, at time.Time) error {
+	if nextAttemptAt == nil {
+		_, err := s.db.Exec("UPDATE mail_outbox SET attempts = ?, lastError = ?, failedAt = ? WHERE id = ?", attempts, lastError, at, id)
+		return err
+	}
+	_, err := s.db.Exec("UPDATE mail_outbox SET attempts = ?, lastError = ?, nextAttemptAt = ? WHERE id = ?", attempts, lastError, *nextAttemptAt, id)
+	return err
+}
+
+// RetryOutboxMail queues an unsent mail again with its attempts reset.
+func (s *Store) RetryOutboxMail(id int, at time.Time) (int64, error) {
+	res, err := s.db.Exec(
+		"UPDATE mail_outbox SET attempts = 0, failedAt = NULL, nextAttemptAt = ? WHERE id = ? AND sentAt IS NULL",
+		at, id,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PurgeOutboxMails removes the mail that was sent or failed for good before
+// the cutoff, the pending mail is left to the outbox.
+func (s *Store) PurgeOutboxMails(before time.Time) (int64, error) {
+	res, err := s.db.Exec("DELETE FROM mail_outbox WHERE sentAt < ? OR failedAt < ?", before, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) queryOutboxMails(query string, args ...any) ([]types.OutboxMail, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	mails := make([]types.OutboxMail, 0)
+	for rows.Next() {
+		mail, err := scanRowIntoOutboxMail(rows)
+		if err != nil {
+			return nil, err
+		}
+		mails = append(mails, *mail)
+	}
+	return mails, rows.Err()
+}
+
+func scanRowIntoOutboxMail(rows *sql.Rows) (*types.OutboxMail, error) {
+	mail := new(types.OutboxMail)
+	var recipients string
+	err := rows.Scan(
+		&mail.ID,
+		&mail.Template,
+		&recipients,
+		&mail.Subject,
+		&mail.Text,
+		&mail.HTML,
+		&mail.Attempts,
+		&mail.LastError,
+		&mail.NextAttemptAt,
+		&mail.SentAt,
+		&mail.FailedAt,
+		&mail.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	mail.To = strings.Split(recipients, ",")
+	return mail, nil
+}
@@ -0,0 +1,126 @@
+package products
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/catalog"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+func (h *Handler) handleImportProducts(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleImportProducts")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = catalog.FormatFromContentType(r.Header.Get("Content-Type"))
+	}
+	if _, ok := catalog.ContentType(format); !ok {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown format, expected csv or json"))
+		return
+	}
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid dry_run"))
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, config.Envs.ImportMaxBytes)
+	rows, rowErrors, err := catalog.Parse(body, format)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("the file is larger than %d bytes", tooLarge.Limit))
+			return
+		}
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	importer := catalog.NewImporter(h.store)
+	if len(rows) > int(config.Envs.ImportSyncRows) {
+		job := h.imports.Start(r.Context(), importer, rows, rowErrors, dryRun)
+		w.Header().Set("Location", "/api/v1/products/imports/"+job.ID)
+		utils.WriteJSON(w, http.StatusAccepted, job)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, importer.Import(r.Context(), rows, rowErrors, dryRun, nil))
+}
+
+func (h *Handler) handleGetImportJob(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetImportJob")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	job, ok := h.imports.Get(mux.Vars(r)["job_id"])
+	if !ok {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("import not found"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, job)
+}
+
+func (h *Handler) handleExportProducts(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleExportProducts")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = catalog.FormatCSV
+	}
+	contentType, ok := catalog.ContentType(format)
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown format, expected csv or json"))
+		return
+	}
+
+	products, err := h.store.GetProducts()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, format))
+	if err := catalog.Export(w, format, products); err != nil {
+		// the status is already sent, the client sees a truncated file
+		log.Printf("failed to export the products: %v", err)
+	}
+}
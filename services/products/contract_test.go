@@ -0,0 +1,357 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/gateway"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestProductContract runs the same scenario on the REST, the gateway and
+// the gRPC transports, they share the service so they must answer alike.
+func TestProductContract(t *testing.T) {
+	transports := []struct {
+		name string
+		new  func(t *testing.T, service *Service) productClient
+	}{
+		{"rest", newRESTProductClient},
+		{"gateway", newGatewayProductClient},
+		{"grpc", newGRPCProductClient},
+	}
+	for _, transport := range transports {
+		t.Run(transport.name, func(t *testing.T) {
+			store := newMemoryProductStore(types.Product{ID: 1, Name: "Slim Jeans", Price: 100, Quantity: 5})
+			client := transport.new(t, NewService(store, &mockStockStore{}))
+
+			expect := func(step string, got codes.Code, want codes.Code) {
+				t.Helper()
+				if got != want {
+					t.Errorf("%s: expected %v, got %v", step, want, got)
+				}
+			}
+
+			code, _ := client.get("customer", 1)
+			expect("customer get", code, codes.PermissionDenied)
+			code, name := client.get("admin", 1)
+			expect("admin get", code, codes.OK)
+			if name != "Slim Jeans" {
+				t.Errorf("expected the name of product 1, got %q", name)
+			}
+			code, _ = client.get("admin", 9)
+			expect("get missing", code, codes.NotFound)
+
+			expect("customer create", client.create("customer", types.Product{Name: "Denim Jacket"}), codes.PermissionDenied)
+			expect("negative quantity", client.create("admin", types.Product{Name: "Denim Jacket", Quantity: -1}), codes.InvalidArgument)
+			expect("admin create", client.create("admin", types.Product{Name: "Denim Jacket", Price: 250, Quantity: 2}), codes.OK)
+			code, name = client.get("admin", 2)
+			expect("get created", code, codes.OK)
+			if name != "Denim Jacket" {
+				t.Errorf("expected the name of the created product, got %q", name)
+			}
+
+			expect("customer delete", client.delete("customer", 2), codes.PermissionDenied)
+			expect("admin delete", client.delete("admin", 2), codes.OK)
+			code, _ = client.get("admin", 2)
+			expect("get deleted", code, codes.NotFound)
+			expect("delete missing", client.delete("admin", 9), codes.NotFound)
+
+			expect("restore kept", client.restore("admin", 1), codes.NotFound)
+			expect("customer restore", client.restore("customer", 2), codes.PermissionDenied)
+			expect("admin restore", client.restore("admin", 2), codes.OK)
+			code, _ = client.get("admin", 2)
+			expect("get restored", code, codes.OK)
+		})
+	}
+}
+
+// productClient is a transport of the contract, it answers with the code of
+// the call and the name of the product it read.
+type productClient interface {
+	get(role string, id int) (codes.Code, string)
+	create(role string, p types.Product) codes.Code
+	delete(role string, id int) codes.Code
+	restore(role string, id int) codes.Code
+}
+
+type httpProductClient struct {
+	t       *testing.T
+	handler http.Handler
+	// prefix is where the transport mounts its routes
+	prefix string
+	// body is the product in the messages of the transport
+	body func(types.Product) any
+}
+
+func newRESTProductClient(t *testing.T, service *Service) productClient {
+	h := &Handler{service: service}
+	router := mux.NewRouter()
+	router.HandleFunc("/products", h.handleCreateProduct).Methods("POST")
+	router.HandleFunc("/products/{product_id}/restore", h.handleRestoreProductByID).Methods("POST")
+	router.HandleFunc("/products/{product_id}", h.handleGetProductByID).Methods("GET")
+	router.HandleFunc("/products/{product_id}/delete", h.handleDeleteProductByID).Methods("DELETE")
+	return &httpProductClient{t: t, handler: router, body: func(p types.Product) any { return p }}
+}
+
+func newGatewayProductClient(t *testing.T, service *Service) productClient {
+	conn := dialContractServer(t, func(s *grpc.Server) { NewHandlerServer(s, service) })
+	gatewayMux := gateway.NewServeMux()
+	if err := pb.RegisterProductServiceHandler(context.Background(), gatewayMux, conn); err != nil {
+		t.Fatal(err)
+	}
+	return &httpProductClient{t: t, handler: gatewayMux, prefix: "/api/v1", body: func(p types.Product) any { return productToPB(p) }}
+}
+
+func (c *httpProductClient) do(role string, method string, path string, body any) (codes.Code, map[string]any) {
+	c.t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+	req, err := http.NewRequest(method, c.prefix+path, &payload)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserRoleKey, role))
+	// the gateway sends it in the metadata of the call
+	req.Header.Set(runtime.MetadataHeaderPrefix+"Role", role)
+	rr := httptest.NewRecorder()
+	c.handler.ServeHTTP(rr, req)
+
+	var response map[string]any
+	json.NewDecoder(rr.Body).Decode(&response)
+	return codeOfHTTPStatus(rr.Code), response
+}
+
+func (c *httpProductClient) get(role string, id int) (codes.Code, string) {
+	code, response := c.do(role, http.MethodGet, fmt.Sprintf("/products/%d", id), nil)
+	name, _ := response["name"].(string)
+	return code, name
+}
+
+func (c *httpProductClient) create(role string, p types.Product) codes.Code {
+	code, _ := c.do(role, http.MethodPost, "/products", c.body(p))
+	return code
+}
+
+func (c *httpProductClient) delete(role string, id int) codes.Code {
+	code, _ := c.do(role, http.MethodDelete, fmt.Sprintf("/products/%d/delete", id), nil)
+	return code
+}
+
+func (c *httpProductClient) restore(role string, id int) codes.Code {
+	code, _ := c.do(role, http.MethodPost, fmt.Sprintf("/products/%d/restore", id), nil)
+	return code
+}
+
+type grpcProductClient struct {
+	client pb.ProductServiceClient
+}
+
+func newGRPCProductClient(t *testing.T, service *Service) productClient {
+	conn := dialContractServer(t, func(s *grpc.Server) { NewHandlerServer(s, service) })
+	return &grpcProductClient{client: pb.NewProductServiceClient(conn)}
+}
+
+func (c *grpcProductClient) get(role string, id int) (codes.Code, string) {
+	response, err := c.client.GetProductByID(withContractRole(role), &pb.GetProductByIDRequest{Id: int32(id)})
+	return status.Code(err), response.GetProduct().GetName()
+}
+
+func (c *grpcProductClient) create(role string, p types.Product) codes.Code {
+	_, err := c.client.CreateProduct(withContractRole(role), &pb.CreateProductRequest{Product: productToPB(p)})
+	return status.Code(err)
+}
+
+func (c *grpcProductClient) delete(role string, id int) codes.Code {
+	_, err := c.client.DeleteProductByID(withContractRole(role), &pb.DeleteProductByIDRequest{Id: int32(id)})
+	return status.Code(err)
+}
+
+func (c *grpcProductClient) restore(role string, id int) codes.Code {
+	_, err := c.client.RestoreProductByID(withContractRole(role), &pb.RestoreProductByIDRequest{Id: int32(id)})
+	return status.Code(err)
+}
+
+// dialContractServer serves the registered services in memory, the role of a
+// call is sent in its metadata and put in the context like the auth
+// interceptor does.
+func dialContractServer(t *testing.T, register func(*grpc.Server)) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if roles := md.Get("role"); len(roles) > 0 {
+			ctx = context.WithValue(ctx, auth.UserRoleKey, roles[0])
+		}
+		return handler(ctx, req)
+	}))
+	register(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withContractRole(role string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "role", role)
+}
+
+// codeOfHTTPStatus is the reverse of utils.WriteStatusError for the codes of
+// the contract.
+func codeOfHTTPStatus(code int) codes.Code {
+	switch code {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return codes.OK
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	}
+	return codes.Internal
+}
+
+type memoryProductStore struct {
+	mu       sync.Mutex
+	products map[int]types.Product
+	nextID   int
+}
+
+func newMemoryProductStore(products ...types.Product) *memoryProductStore {
+	m := &memoryProductStore{products: map[int]types.Product{}}
+	for _, p := range products {
+		m.products[p.ID] = p
+		if p.ID > m.nextID {
+			m.nextID = p.ID
+		}
+	}
+	return m
+}
+
+func (m *memoryProductStore) list(deleted bool) []types.Product {
+	products := []types.Product{}
+	for _, p := range m.products {
+		if (p.DeletedAt != nil) == deleted {
+			products = append(products, p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products
+}
+
+func (m *memoryProductStore) GetProducts() ([]types.Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.list(false), nil
+}
+func (m *memoryProductStore) GetProductsByIDs(ids []int) ([]types.Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	products := []types.Product{}
+	for _, id := range ids {
+		if p, ok := m.products[id]; ok && p.DeletedAt == nil {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+func (m *memoryProductStore) GetProductsPage(afterID int, limit int) ([]types.Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	products := []types.Product{}
+	for _, p := range m.list(false) {
+		if p.ID > afterID && len(products) < limit {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+func (m *memoryProductStore) GetProductByID(id int) (*types.Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.products[id]
+	if !ok || p.DeletedAt != nil {
+		return nil, fmt.Errorf("product %w", types.ErrNotFound)
+	}
+	return &p, nil
+}
+func (m *memoryProductStore) GetProductBySKU(string) (*types.Product, error) { return nil, nil }
+func (m *memoryProductStore) CreateProduct(p types.Product) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	p.ID = m.nextID
+	p.CreatedAt = time.Now()
+	m.products[p.ID] = p
+	return int64(p.ID), nil
+}
+func (m *memoryProductStore) UpdateProduct(p types.Product, actorID int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.products[p.ID]; !ok {
+		return 0, nil
+	}
+	m.products[p.ID] = p
+	return 1, nil
+}
+func (m *memoryProductStore) DeleteProductByID(id int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.products[id]
+	if !ok || p.DeletedAt != nil {
+		return 0, nil
+	}
+	now := time.Now()
+	p.DeletedAt = &now
+	m.products[id] = p
+	return 1, nil
+}
+func (m *memoryProductStore) DeleteProduct(p types.Product) (int64, error) {
+	return m.DeleteProductByID(p.ID)
+}
+func (m *memoryProductStore) GetDeletedProducts() ([]types.Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.list(true), nil
+}
+func (m *memoryProductStore) RestoreProductByID(id int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.products[id]
+	if !ok || p.DeletedAt == nil {
+		return 0, nil
+	}
+	p.DeletedAt = nil
+	m.products[id] = p
+	return 1, nil
+}
@@ -1,18 +1,179 @@
 package products
 
 import (
+	"context"
+
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
 	"google.golang.org/grpc"
 )
 
+type HandlerClient struct {
+	Client pb.ProductServiceClient
+}
+
+func NewHandlerClient(client pb.ProductServiceClient) *HandlerClient {
+	return &HandlerClient{Client: client}
+}
+
+// HandlerServer is the gRPC transport of the catalogue, it only converts the
+// messages of the service.
 type HandlerServer struct {
 	pb.UnimplementedProductServiceServer
 	service types.ProductService
 }
 
+func NewServer(service types.ProductService) *HandlerServer {
+	return &HandlerServer{service: service}
+}
+
 func NewHandlerServer(grpcServer *grpc.Server, service types.ProductService) {
-	handler := &HandlerServer{service: service}
+	pb.RegisterProductServiceServer(grpcServer, NewServer(service))
+}
+
+func (h *HandlerServer) GetProducts(ctx context.Context, req *pb.GetProductsRequest) (*pb.GetProductsResponse, error) {
+	products, err := h.service.GetProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetProductsResponse{Products: productsToPB(products)}, nil
+}
+
+func (h *HandlerServer) GetProductsByIDs(ctx context.Context, req *pb.GetProductsByIDsRequest) (*pb.GetProductsByIDsResponse, error) {
+	ids := make([]int, 0, len(req.GetIds()))
+	for _, id := range req.GetIds() {
+		ids = append(ids, int(id))
+	}
+	products, err := h.service.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetProductsByIDsResponse{Products: productsToPB(products)}, nil
+}
+
+func (h *HandlerServer) GetProductByID(ctx context.Context, req *pb.GetProductByIDRequest) (*pb.GetProductByIDResponse, error) {
+	product, err := h.service.GetProductByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetProductByIDResponse{Product: productToPB(*product)}, nil
+}
+
+func (h *HandlerServer) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.CreateProductResponse, error) {
+	id, err := h.service.CreateProduct(ctx, productFromPB(req.GetProduct()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateProductResponse{Id: id}, nil
+}
+
+func (h *HandlerServer) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.UpdateProductResponse, error) {
+	updated, err := h.service.UpdateProduct(ctx, productFromPB(req.GetProduct()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateProductResponse{UpdatedCount: updated}, nil
+}
+
+func (h *HandlerServer) DeleteProductByID(ctx context.Context, req *pb.DeleteProductByIDRequest) (*pb.DeleteProductByIDResponse, error) {
+	deleted, err := h.service.DeleteProductByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteProductByIDResponse{DeletedCount: deleted}, nil
+}
+
+func (h *HandlerServer) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
+	deleted, err := h.service.DeleteProductByID(ctx, int(req.GetProduct().GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteProductResponse{DeletedCount: deleted}, nil
+}
+
+func (h *HandlerServer) GetDeletedProducts(ctx context.Context, req *pb.GetDeletedProductsRequest) (*pb.GetDeletedProductsResponse, error) {
+	products, err := h.service.GetDeletedProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetDeletedProductsResponse{Products: productsToPB(products)}, nil
+}
+
+func (h *HandlerServer) RestoreProductByID(ctx context.Context, req *pb.RestoreProductByIDRequest) (*pb.RestoreProductByIDResponse, error) {
+	if _, err := h.service.RestoreProductByID(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+	return &pb.RestoreProductByIDResponse{RestoredCount: 1}, nil
+}
+
+// ExportProducts streams the catalogue a product at a time.
+func (h *HandlerServer) ExportProducts(req *pb.ExportProductsRequest, stream pb.ProductService_ExportProductsServer) error {
+	return h.service.ExportProducts(stream.Context(), func(p types.Product) error {
+		return stream.Send(productToPB(p))
+	})
+}
+
+// StreamProducts streams the catalogue a page at a time.
+func (h *HandlerServer) StreamProducts(req *pb.StreamProductsRequest, stream pb.ProductService_StreamProductsServer) error {
+	return h.service.StreamProducts(stream.Context(), int(req.GetCursor()), int(req.GetPageSize()), func(page types.ProductPage) error {
+		return stream.Send(&pb.ProductPage{
+			Products:    productsToPB(page.Products),
+			Cursor:      int32(page.Cursor),
+			WatchCursor: page.WatchCursor,
+		})
+	})
+}
+
+// WatchProducts streams the changes of the catalogue as they happen.
+func (h *HandlerServer) WatchProducts(req *pb.WatchProductsRequest, stream pb.ProductService_WatchProductsServer) error {
+	return h.service.WatchProducts(stream.Context(), req.GetCursor(), func(e types.ProductEvent) error {
+		return stream.Send(&pb.ProductEvent{Cursor: e.Cursor, Kind: e.Kind, Product: productToPB(e.Product)})
+	})
+}
+
+func productToPB(p types.Product) *pb.Product {
+	productPB := &pb.Product{
+		Id:          int32(p.ID),
+		Name:        p.Name,
+		Description: p.Description,
+		Merchant:    p.Merchant,
+		Category:    p.Category,
+		Currency:    p.Currency,
+		Image:       p.Image,
+		Price:       p.Price,
+		Quantity:    int32(p.Quantity),
+		CreatedAt:   p.CreatedAt.Unix(),
+		Sku:         p.SKU,
+		Rating:      p.Rating,
+		ReviewCount: int32(p.ReviewCount),
+	}
+	if p.DeletedAt != nil {
+		productPB.DeletedAt = p.DeletedAt.Unix()
+	}
+	return productPB
+}
+
+func productsToPB(products []types.Product) []*pb.Product {
+	productsPB := make([]*pb.Product, 0, len(products))
+	for _, p := range products {
+		productsPB = append(productsPB, productToPB(p))
+	}
+	return productsPB
+}
 
-	pb.RegisterProductServiceServer(grpcServer, handler)
+// productFromPB is the product of a request, the timestamps and the rating
+// are the store's.
+func productFromPB(p *pb.Product) types.Product {
+	return types.Product{
+		ID:          int(p.GetId()),
+		Name:        p.GetName(),
+		Description: p.GetDescription(),
+		Merchant:    p.GetMerchant(),
+		Category:    p.GetCategory(),
+		Currency:    p.GetCurrency(),
+		Image:       p.GetImage(),
+		Price:       p.GetPrice(),
+		Quantity:    int(p.GetQuantity()),
+		SKU:         p.GetSku(),
+	}
 }
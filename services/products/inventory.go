@@ -0,0 +1,255 @@
+package products
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/inventory"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+func (h *Handler) handleGetStockMovements(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetStockMovements")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	movements, err := h.stockStore.GetStockMovements(productID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, movements)
+}
+
+func (h *Handler) handleCreateStockReceipt(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleCreateStockReceipt")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	var payload types.StockReceiptPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", err))
+		return
+	}
+	movement, err := h.service.ReceiveStock(r.Context(), productID, payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, movement)
+}
+
+func (h *Handler) handleCreateStockCount(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleCreateStockCount")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	var payload types.StockCountPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", err))
+		return
+	}
+
+	movement, err := h.service.CountStock(r.Context(), productID, payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	if movement == nil {
+		utils.WriteJSON(w, http.StatusOK, map[string]any{"adjusted": false})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, movement)
+}
+
+func (h *Handler) handleGetStockDrift(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetStockDrift")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	drift, err := h.stockStore.GetStockDrift()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, drift)
+}
+
+func (h *Handler) handleSetStockThreshold(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleSetStockThreshold")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	var payload types.StockThresholdPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", err))
+		return
+	}
+
+	if err := h.service.SetStockThreshold(r.Context(), productID, payload); err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) handleGetLowStock(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetLowStock")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	levels, err := h.stockStore.GetStockLevels(time.Now())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	low := make([]types.StockLevel, 0)
+	for _, l := range levels {
+		if l.Threshold > 0 && l.Quantity <= l.Threshold {
+			low = append(low, l)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, low)
+}
+
+func (h *Handler) handleGetReorderSuggestions(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetReorderSuggestions")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	window, err := queryDays(r, "window", int(config.Envs.ReorderWindowInDays))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	cover, err := queryDays(r, "cover", int(config.Envs.ReorderCoverInDays))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	levels, err := h.stockStore.GetStockLevels(time.Now().AddDate(0, 0, -window))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, reorderSuggestions(levels, window, cover))
+}
+
+// reorderSuggestions returns the products to reorder, the most needed first.
+func reorderSuggestions(levels []types.StockLevel, window int, cover int) []types.ReorderSuggestion {
+	suggestions := make([]types.ReorderSuggestion, 0)
+	for _, l := range levels {
+		qty := inventory.ReorderQuantity(l.Sold, window, cover, l.Quantity, l.Threshold)
+		if qty == 0 {
+			continue
+		}
+		suggestions = append(suggestions, types.ReorderSuggestion{
+			StockLevel: l,
+			DailySales: inventory.DailySales(l.Sold, window),
+			ReorderQty: qty,
+		})
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].ReorderQty > suggestions[j].ReorderQty
+	})
+	return suggestions
+}
+
+func queryDays(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days <= 0 || days > 365 {
+		return 0, fmt.Errorf("invalid %s, expected 1 to 365 days", name)
+	}
+	return days, nil
+}
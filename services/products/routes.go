@@ -3,9 +3,13 @@ package products
 import (
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/catalog"
+	"github.com/fayleenpc/tj-jeans/internal/config"
 	"github.com/fayleenpc/tj-jeans/internal/ratelimiter"
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	"github.com/fayleenpc/tj-jeans/internal/utils"
@@ -17,13 +21,17 @@ import (
 
 type Handler struct {
 	store      types.ProductStore
+	service    *Service
+	stockStore types.StockStore
 	userStore  types.UserStore
 	tokenStore types.TokenStore
 	redisStore *redis.Client
+	imports    *catalog.Jobs
 }
 
-func NewHandler(store types.ProductStore, userStore types.UserStore, tokenStore types.TokenStore, redisStore *redis.Client) *Handler {
-	return &Handler{store: store, userStore: userStore, tokenStore: tokenStore, redisStore: redisStore}
+func NewHandler(store types.ProductStore, stockStore types.StockStore, userStore types.UserStore, tokenStore types.TokenStore, redisStore *redis.Client) *Handler {
+	imports := catalog.NewJobs(time.Second * time.Duration(config.Envs.ImportJobRetentionInSeconds))
+	return &Handler{store: store, service: NewService(store, stockStore), stockStore: stockStore, userStore: userStore, tokenStore: tokenStore, redisStore: redisStore, imports: imports}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
@@ -31,9 +39,27 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/products", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleCreateProduct), h.userStore, h.tokenStore)).Methods("POST")
 	router.HandleFunc("/products", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleUpdateProduct), h.userStore, h.tokenStore)).Methods("PATCH")
 	router.HandleFunc("/products", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleDeleteProduct), h.userStore, h.tokenStore)).Methods("DELETE")
+	router.HandleFunc("/products/import", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleImportProducts), h.userStore, h.tokenStore)).Methods("POST")
+	router.HandleFunc("/products/imports/{job_id}", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetImportJob), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/products/export", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleExportProducts), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/products/trash", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetDeletedProducts), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/products/{product_id}/restore", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleRestoreProductByID), h.userStore, h.tokenStore)).Methods("POST")
 	router.HandleFunc("/products/{product_id}", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetProductByID), h.userStore, h.tokenStore)).Methods("GET")
 	router.HandleFunc("/products/{product_id}/update", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleUpdateProductByID), h.userStore, h.tokenStore)).Methods("PATCH")
 	router.HandleFunc("/products/{product_id}/delete", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleDeleteProductByID), h.userStore, h.tokenStore)).Methods("DELETE")
+
+	router.HandleFunc("/products/{product_id}/stock", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetStockMovements), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/products/{product_id}/stock/receipts", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleCreateStockReceipt), h.userStore, h.tokenStore)).Methods("POST")
+	router.HandleFunc("/products/{product_id}/stock/counts", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleCreateStockCount), h.userStore, h.tokenStore)).Methods("POST")
+	router.HandleFunc("/products/{product_id}/stock/threshold", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleSetStockThreshold), h.userStore, h.tokenStore)).Methods("PUT")
+	router.HandleFunc("/products/{product_id}/stock/transfers", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleCreateStockTransfer), h.userStore, h.tokenStore)).Methods("POST")
+	router.HandleFunc("/products/{product_id}/stock/warehouses", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetProductWarehouseStock), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/stock/low", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetLowStock), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/stock/reorder", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetReorderSuggestions), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/stock/drift", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetStockDrift), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/stock/warehouses", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetWarehouses), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/stock/warehouses", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleCreateWarehouse), h.userStore, h.tokenStore)).Methods("POST")
+	router.HandleFunc("/stock/warehouses/{warehouse_id}", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetWarehouseStock), h.userStore, h.tokenStore)).Methods("GET")
 }
 
 func (h *Handler) handleGetProductByID(w http.ResponseWriter, r *http.Request) {
@@ -42,23 +68,18 @@ func (h *Handler) handleGetProductByID(w http.ResponseWriter, r *http.Request) {
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		product, err := h.store.GetProductByID(productID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, product)
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
 	}
-
+	product, err := h.service.GetProductByID(r.Context(), productID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, product)
 }
 
 func (h *Handler) handleUpdateProductByID(w http.ResponseWriter, r *http.Request) {
@@ -67,37 +88,32 @@ func (h *Handler) handleUpdateProductByID(w http.ResponseWriter, r *http.Request
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		var payload types.Product
-		if err := utils.ParseJSON(r, &payload); err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		oldProduct, err := h.store.GetProductByID(productID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		if oldProduct.ID != payload.ID {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		updatedProductID, err := h.store.UpdateProduct(payload)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"updated_id": updatedProductID, "old_product": oldProduct, "updated_product": payload})
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
 	}
-
+	var payload types.Product
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.ID != productID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("the product id does not match the path"))
+		return
+	}
+	oldProduct, err := h.service.GetProductByID(r.Context(), productID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	updatedProductID, err := h.service.UpdateProduct(r.Context(), payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"updated_id": updatedProductID, "old_product": oldProduct, "updated_product": payload})
 }
 
 func (h *Handler) handleDeleteProductByID(w http.ResponseWriter, r *http.Request) {
@@ -106,179 +122,179 @@ func (h *Handler) handleDeleteProductByID(w http.ResponseWriter, r *http.Request
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		oldProduct, err := h.store.GetProductByID(productID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		deletedOldProductID, err := h.store.DeleteProductByID(oldProduct.ID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"deleted_id": deletedOldProductID, "deleted_product": oldProduct})
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
 	}
-
+	oldProduct, err := h.service.GetProductByID(r.Context(), productID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	deletedOldProductID, err := h.service.DeleteProductByID(r.Context(), oldProduct.ID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"deleted_id": deletedOldProductID, "deleted_product": oldProduct})
 }
 
-// handleGetProducts godoc
-//
-//	@Summary		Get a products using JWT Token ( accessToken )
-//	@Description	Get a products using JWT Token ( accessToken ), with login credentials
-//	@Tags			products
-//	@Accept			json
-//	@Produce		json
-//	@Success		200	{object}	[]types.Product
-//	@Failure		400	{object}	error
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
-//	@Security		ApiKeyAuth
-//	@Router			/api/v1/products [get]
 func (h *Handler) handleGetProducts(w http.ResponseWriter, r *http.Request) {
 	span := opentracing.GlobalTracer().StartSpan("handleGetProducts")
 	defer span.Finish()
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	ps, err := h.store.GetProducts()
+	ps, err := h.service.GetProducts(r.Context())
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		utils.WriteStatusError(w, err)
 		return
 	}
 	// PublishForProducts(ps)
 	// SubscribeForProduct(w, r)
 
+	ps, err = sortByRating(ps, r.URL.Query().Get("sort"), r.URL.Query().Get("min_rating"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	utils.WriteJSON(w, http.StatusOK, ps)
 }
 
-// handleCreateProduct godoc
-//
-//	@Summary		Post a products using JWT Token ( accessToken )
-//	@Description	Post a products using JWT Token ( accessToken ), with login credentials ( role admin )
-//	@Tags			products
-//	@Accept			json
-//	@Produce		json
-//	@Success		200	{object}	types.Product
-//	@Failure		400	{object}	error
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
-//	@Security		ApiKeyAuth
-//	@Router			/api/v1/products [post]
+// sortByRating filters out the products rated below minRating and sorts the
+// others by rating or by review count, ties keep the order of the store.
+func sortByRating(ps []types.Product, sortBy string, minRating string) ([]types.Product, error) {
+	if minRating != "" {
+		threshold, err := strconv.ParseFloat(minRating, 64)
+		if err != nil || threshold < 0 || threshold > 5 {
+			return nil, fmt.Errorf("invalid min_rating, expected a number from 0 to 5")
+		}
+		filtered := make([]types.Product, 0, len(ps))
+		for _, p := range ps {
+			if p.Rating >= threshold {
+				filtered = append(filtered, p)
+			}
+		}
+		ps = filtered
+	}
+
+	switch sortBy {
+	case "":
+	case "rating":
+		sort.SliceStable(ps, func(i, j int) bool {
+			if ps[i].Rating != ps[j].Rating {
+				return ps[i].Rating > ps[j].Rating
+			}
+			return ps[i].ReviewCount > ps[j].ReviewCount
+		})
+	case "reviews":
+		sort.SliceStable(ps, func(i, j int) bool { return ps[i].ReviewCount > ps[j].ReviewCount })
+	default:
+		return nil, fmt.Errorf("invalid sort, expected rating or reviews")
+	}
+	return ps, nil
+}
+
 func (h *Handler) handleCreateProduct(w http.ResponseWriter, r *http.Request) {
 	span := opentracing.GlobalTracer().StartSpan("handleCreateProduct")
 	defer span.Finish()
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	var payload types.Product
 	if err := utils.ParseJSON(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
-
-	if userRole == "admin" {
-		id, err := h.store.CreateProduct(payload)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
-			return
-		}
-
-		payload.ID = int(id)
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"created_id": id, "created_product": payload})
-	} else {
-		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+	id, err := h.service.CreateProduct(r.Context(), payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
 	}
 
+	payload.ID = int(id)
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"created_id": id, "created_product": payload})
 }
 
-// handleUpdateProduct godoc
-//
-//	@Summary		Update a products using JWT Token ( accessToken )
-//	@Description	Update a products using JWT Token ( accessToken ), with login credentials ( role admin )
-//	@Tags			products
-//	@Accept			json
-//	@Produce		json
-//	@Success		200	{object}	types.Product
-//	@Failure		400	{object}	error
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
-//	@Security		ApiKeyAuth
-//	@Router			/api/v1/products [patch]
 func (h *Handler) handleUpdateProduct(w http.ResponseWriter, r *http.Request) {
 	span := opentracing.GlobalTracer().StartSpan("handleUpdateProduct")
 	defer span.Finish()
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	var payload types.Product
 	if err := utils.ParseJSON(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
-
-	if userRole == "admin" {
-		id, err := h.store.UpdateProduct(payload)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
-			return
-		}
-
-		payload.ID = int(id)
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"updated_id": id, "updated_product": payload})
-	} else {
-		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+	id, err := h.service.UpdateProduct(r.Context(), payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
 	}
 
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"updated_id": id, "updated_product": payload})
 }
 
-// handleDeleteProduct godoc
-//
-//	@Summary		Delete a products using JWT Token ( accessToken )
-//	@Description	Delete a products using JWT Token ( accessToken ), with login credentials ( role admin )
-//	@Tags			products
-//	@Accept			json
-//	@Produce		json
-//	@Success		200	{object}	types.Product
-//	@Failure		400	{object}	error
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
-//	@Security		ApiKeyAuth
-//	@Router			/api/v1/products [delete]
 func (h *Handler) handleDeleteProduct(w http.ResponseWriter, r *http.Request) {
 	span := opentracing.GlobalTracer().StartSpan("handleDeleteProduct")
 	defer span.Finish()
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	var payload types.Product
 	if err := utils.ParseJSON(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
+	id, err := h.service.DeleteProductByID(r.Context(), payload.ID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
 
-	if userRole == "admin" {
-		id, err := h.store.DeleteProduct(payload)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
-			return
-		}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"deleted_id": id, "deleted_product": &payload})
+}
+
+func (h *Handler) handleGetDeletedProducts(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetDeletedProducts")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	products, err := h.service.GetDeletedProducts(r.Context())
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, products)
+}
+
+func (h *Handler) handleRestoreProductByID(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleRestoreProductByID")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
 
-		payload.ID = int(id)
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"deleted_id": id, "deleted_product": &payload})
-	} else {
-		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
 	}
 
+	product, err := h.service.RestoreProductByID(r.Context(), productID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, product)
 }
@@ -1,11 +1,21 @@
 package products
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	"github.com/gorilla/mux"
 )
@@ -14,7 +24,7 @@ func TestProductsServiceHandler(t *testing.T) {
 	userStore := &mockUserStore{}
 	tokenStore := &mockTokenStore{}
 	store := &mockProductsStore{}
-	handler := NewHandler(store, userStore, tokenStore, nil)
+	handler := NewHandler(store, &mockStockStore{}, userStore, tokenStore, nil)
 
 	t.Run("should fail handle the get product", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodGet, "/products", nil)
@@ -50,25 +60,538 @@ func TestProductsServiceHandler(t *testing.T) {
 	})
 }
 
-type mockProductsStore struct{}
+func TestStockServiceHandler(t *testing.T) {
+	auditStore := &mockAuditStore{}
+	audit.SetDefault(audit.NewRecorder(auditStore))
+	defer audit.SetDefault(nil)
+
+	serve := func(stockStore *mockStockStore, role string, method string, path string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req, err := http.NewRequest(method, path, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(req.Context(), auth.UserKey, 1)
+		ctx = context.WithValue(ctx, auth.UserRoleKey, role)
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		handler := NewHandler(&mockProductsStore{}, stockStore, &mockUserStore{}, &mockTokenStore{}, nil)
+		router.HandleFunc("/products/{product_id}/stock", handler.handleGetStockMovements).Methods("GET")
+		router.HandleFunc("/products/{product_id}/stock/receipts", handler.handleCreateStockReceipt).Methods("POST")
+		router.HandleFunc("/products/{product_id}/stock/counts", handler.handleCreateStockCount).Methods("POST")
+		router.HandleFunc("/stock/drift", handler.handleGetStockDrift).Methods("GET")
+		router.HandleFunc("/products/{product_id}/stock/threshold", handler.handleSetStockThreshold).Methods("PUT")
+		router.HandleFunc("/stock/low", handler.handleGetLowStock).Methods("GET")
+		router.HandleFunc("/stock/reorder", handler.handleGetReorderSuggestions).Methods("GET")
+		router.HandleFunc("/products/{product_id}/stock/transfers", handler.handleCreateStockTransfer).Methods("POST")
+		router.HandleFunc("/products/{product_id}/stock/warehouses", handler.handleGetProductWarehouseStock).Methods("GET")
+		router.HandleFunc("/stock/warehouses", handler.handleCreateWarehouse).Methods("POST")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should only let admins manage the stock", func(t *testing.T) {
+		stockStore := &mockStockStore{stock: map[stockKey]int{}}
+		if rr := serve(stockStore, "customer", http.MethodGet, "/products/1/stock", nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+		if rr := serve(stockStore, "customer", http.MethodPost, "/products/1/stock/receipts", types.StockReceiptPayload{WarehouseID: 1, Quantity: 5, Reference: "PO-1"}); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+		if len(stockStore.movements) != 0 {
+			t.Errorf("expected no movement, got %+v", stockStore.movements)
+		}
+	})
+	t.Run("should fail if the receipt is invalid", func(t *testing.T) {
+		stockStore := &mockStockStore{stock: map[stockKey]int{}}
+		if rr := serve(stockStore, "admin", http.MethodPost, "/products/1/stock/receipts", types.StockReceiptPayload{WarehouseID: 1, Quantity: -5, Reference: "PO-1"}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if rr := serve(stockStore, "admin", http.MethodPost, "/products/1/stock/receipts", types.StockReceiptPayload{WarehouseID: 1, Quantity: 5}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if rr := serve(stockStore, "admin", http.MethodPost, "/products/1/stock/receipts", types.StockReceiptPayload{Quantity: 5, Reference: "PO-1"}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected a receipt without a warehouse to fail, got %d", rr.Code)
+		}
+	})
+	t.Run("should record a goods receipt", func(t *testing.T) {
+		stockStore := &mockStockStore{stock: map[stockKey]int{{1, 1}: 2}}
+		rr := serve(stockStore, "admin", http.MethodPost, "/products/1/stock/receipts", types.StockReceiptPayload{WarehouseID: 1, Quantity: 5, Reference: "PO-1"})
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, rr.Code)
+		}
+		m := stockStore.movements[0]
+		if m.Type != types.StockReceipt || m.Quantity != 5 || m.Reference != "PO-1" || m.ActorID != 1 || m.Reason != "goods receipt" || m.WarehouseID != 1 {
+			t.Errorf("unexpected movement %+v", m)
+		}
+		if stockStore.stock[stockKey{1, 1}] != 7 {
+			t.Errorf("expected the stock to be 7, got %d", stockStore.stock[stockKey{1, 1}])
+		}
+	})
+	t.Run("should record the difference of a stock count", func(t *testing.T) {
+		stockStore := &mockStockStore{stock: map[stockKey]int{{1, 1}: 10, {2, 1}: 4}}
+		rr := serve(stockStore, "admin", http.MethodPost, "/products/1/stock/counts", types.StockCountPayload{WarehouseID: 1, Quantity: 8, Reference: "count-2026-10"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		m := stockStore.movements[0]
+		if m.Type != types.StockAdjustment || m.Quantity != -2 || m.WarehouseID != 1 {
+			t.Errorf("unexpected movement %+v", m)
+		}
+		if stockStore.stock[stockKey{2, 1}] != 4 {
+			t.Errorf("expected the count not to touch the other warehouses, got %v", stockStore.stock)
+		}
+		if rr := serve(stockStore, "admin", http.MethodPost, "/products/1/stock/counts", types.StockCountPayload{WarehouseID: 1, Quantity: 8}); rr.Code != http.StatusOK || len(stockStore.movements) != 1 {
+			t.Errorf("expected a matching count not to be recorded, got %d movements", len(stockStore.movements))
+		}
+	})
+	t.Run("should set the low-stock threshold", func(t *testing.T) {
+		stockStore := &mockStockStore{}
+		if rr := serve(stockStore, "admin", http.MethodPut, "/products/1/stock/threshold", types.StockThresholdPayload{Threshold: -1}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if rr := serve(stockStore, "admin", http.MethodPut, "/products/1/stock/threshold", types.StockThresholdPayload{Threshold: 8}); rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if stockStore.thresholds[1] != 8 {
+			t.Errorf("expected the threshold to be 8, got %v", stockStore.thresholds)
+		}
+	})
+	t.Run("should list the products low on stock", func(t *testing.T) {
+		stockStore := &mockStockStore{levels: []types.StockLevel{
+			{ProductID: 1, Quantity: 3, Threshold: 5},
+			{ProductID: 2, Quantity: 9, Threshold: 5},
+			{ProductID: 3, Quantity: 0, Threshold: 0},
+		}}
+		rr := serve(stockStore, "admin", http.MethodGet, "/stock/low", nil)
+		var low []types.StockLevel
+		json.NewDecoder(rr.Body).Decode(&low)
+		if len(low) != 1 || low[0].ProductID != 1 {
+			t.Errorf("unexpected low stock %+v", low)
+		}
+	})
+	t.Run("should suggest reorder quantities from the sales velocity", func(t *testing.T) {
+		stockStore := &mockStockStore{levels: []types.StockLevel{
+			{ProductID: 1, Quantity: 10, Threshold: 5, Sold: 60},
+			{ProductID: 2, Quantity: 100, Threshold: 5, Sold: 60},
+			{ProductID: 3, Quantity: 0, Threshold: 5, Sold: 90},
+		}}
+		if rr := serve(stockStore, "admin", http.MethodGet, "/stock/reorder?window=0", nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		rr := serve(stockStore, "admin", http.MethodGet, "/stock/reorder?window=30&cover=14", nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if days := time.Since(stockStore.since).Hours() / 24; days < 29.9 || days > 30.1 {
+			t.Errorf("expected the sales of the last 30 days, got %v days", days)
+		}
+		var suggestions []types.ReorderSuggestion
+		json.NewDecoder(rr.Body).Decode(&suggestions)
+		if len(suggestions) != 2 || suggestions[0].ProductID != 3 || suggestions[0].ReorderQty != 47 || suggestions[1].ReorderQty != 23 {
+			t.Errorf("unexpected suggestions %+v", suggestions)
+		}
+	})
+	t.Run("should transfer stock between warehouses", func(t *testing.T) {
+		stockStore := &mockStockStore{stock: map[stockKey]int{{1, 1}: 10}}
+		if rr := serve(stockStore, "admin", http.MethodPost, "/products/1/stock/transfers", types.StockTransferPayload{FromWarehouseID: 1, ToWarehouseID: 1, Quantity: 2}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected a transfer to the same warehouse to fail, got %d", rr.Code)
+		}
+		if rr := serve(stockStore, "admin", http.MethodPost, "/products/1/stock/transfers", types.StockTransferPayload{FromWarehouseID: 1, ToWarehouseID: 2, Quantity: 11}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected a transfer of more than the warehouse holds to fail, got %d", rr.Code)
+		}
+		rr := serve(stockStore, "admin", http.MethodPost, "/products/1/stock/transfers", types.StockTransferPayload{FromWarehouseID: 1, ToWarehouseID: 2, Quantity: 4, Reference: "TR-1"})
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, rr.Code)
+		}
+		if stockStore.stock[stockKey{1, 1}] != 6 || stockStore.stock[stockKey{2, 1}] != 4 {
+			t.Errorf("unexpected stock %v", stockStore.stock)
+		}
+		if len(stockStore.movements) != 2 || stockStore.movements[0].Type != types.StockTransfer || stockStore.movements[1].Reference != "TR-1" {
+			t.Errorf("unexpected movements %+v", stockStore.movements)
+		}
+
+		rr = serve(stockStore, "admin", http.MethodGet, "/products/1/stock/warehouses", nil)
+		var stock []types.WarehouseStock
+		json.NewDecoder(rr.Body).Decode(&stock)
+		if len(stock) != 2 || stock[0].WarehouseID != 1 || stock[0].Quantity != 6 {
+			t.Errorf("unexpected warehouse stock %+v", stock)
+		}
+	})
+	t.Run("should add a warehouse", func(t *testing.T) {
+		stockStore := &mockStockStore{}
+		if rr := serve(stockStore, "admin", http.MethodPost, "/stock/warehouses", types.WarehousePayload{Name: "Surabaya", Address: "Surabaya", Latitude: -97}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		rr := serve(stockStore, "admin", http.MethodPost, "/stock/warehouses", types.WarehousePayload{Name: "Surabaya", Address: "Surabaya", Latitude: -7.2575, Longitude: 112.7521})
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, rr.Code)
+		}
+		if len(stockStore.warehouses) != 1 || stockStore.warehouses[0].Name != "Surabaya" {
+			t.Errorf("unexpected warehouses %+v", stockStore.warehouses)
+		}
+	})
+	t.Run("should audit the stock changes under the admin", func(t *testing.T) {
+		// a receipt, a count, a threshold and a transfer then a warehouse
+		want := []string{audit.EntityStock, audit.EntityStock, audit.EntityStock, audit.EntityStock, audit.EntityWarehouse}
+		var got []string
+		for _, entry := range auditStore.entries {
+			got = append(got, entry.Entity)
+			if entry.ActorID != 1 {
+				t.Errorf("expected the entry to be made by user 1, got %+v", entry)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected entries %v, got %v", want, got)
+		}
+	})
+	t.Run("should audit the adjustment of a product update", func(t *testing.T) {
+		auditStore.entries = nil
+		service := NewService(newMemoryProductStore(types.Product{ID: 1, Name: "Jeans", Quantity: 2}), &mockStockStore{})
+		ctx := context.WithValue(context.Background(), auth.UserKey, 1)
+		ctx = context.WithValue(ctx, auth.UserRoleKey, "admin")
+		if _, err := service.UpdateProduct(ctx, types.Product{ID: 1, Name: "Jeans", Quantity: 5}); err != nil {
+			t.Fatal(err)
+		}
+		if len(auditStore.entries) != 2 || auditStore.entries[1].Entity != audit.EntityStock {
+			t.Fatalf("expected the product and its stock to be audited, got %+v", auditStore.entries)
+		}
+		var changes map[string]audit.Change
+		json.Unmarshal(auditStore.entries[1].Changes, &changes)
+		if fmt.Sprint(changes["qty"].After, changes["actor_id"].After) != "3 1" {
+			t.Errorf("expected an adjustment of 3 by user 1, got %s", auditStore.entries[1].Changes)
+		}
+	})
+}
+
+type mockAuditStore struct {
+	entries []types.AuditLog
+}
+
+func (m *mockAuditStore) CreateAuditLog(entry types.AuditLog) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+func (m *mockAuditStore) GetAuditLogs(types.AuditLogFilter) ([]types.AuditLog, error) {
+	return m.entries, nil
+}
+
+type stockKey struct {
+	warehouseID int
+	productID   int
+}
+
+func TestSortByRating(t *testing.T) {
+	ps := []types.Product{
+		{ID: 1, Rating: 4.5, ReviewCount: 2},
+		{ID: 2, Rating: 0},
+		{ID: 3, Rating: 4.5, ReviewCount: 10},
+		{ID: 4, Rating: 3.2, ReviewCount: 40},
+	}
+	ids := func(ps []types.Product) string {
+		var ids []int
+		for _, p := range ps {
+			ids = append(ids, p.ID)
+		}
+		return fmt.Sprint(ids)
+	}
+
+	tests := []struct {
+		sort      string
+		minRating string
+		want      string
+	}{
+		{"", "", "[1 2 3 4]"},
+		{"rating", "", "[3 1 4 2]"},
+		{"reviews", "", "[4 3 1 2]"},
+		{"rating", "4", "[3 1]"},
+	}
+	for _, tt := range tests {
+		got, err := sortByRating(slices.Clone(ps), tt.sort, tt.minRating)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids(got) != tt.want {
+			t.Errorf("sort=%q min_rating=%q: expected %s, got %s", tt.sort, tt.minRating, tt.want, ids(got))
+		}
+	}
+
+	if _, err := sortByRating(ps, "price", ""); err == nil {
+		t.Error("expected an unknown sort to fail")
+	}
+	if _, err := sortByRating(ps, "", "6"); err == nil {
+		t.Error("expected a rating above 5 to fail")
+	}
+}
+
+func TestCatalogServiceHandler(t *testing.T) {
+	serve := func(store *mockProductsStore, role string, method string, path string, contentType string, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		ctx := context.WithValue(req.Context(), auth.UserKey, 1)
+		ctx = context.WithValue(ctx, auth.UserRoleKey, role)
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		handler := NewHandler(store, &mockStockStore{}, &mockUserStore{}, &mockTokenStore{}, nil)
+		t.Cleanup(handler.imports.Stop)
+		router.HandleFunc("/products/import", handler.handleImportProducts).Methods("POST")
+		router.HandleFunc("/products/imports/{job_id}", handler.handleGetImportJob).Methods("GET")
+		router.HandleFunc("/products/export", handler.handleExportProducts).Methods("GET")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	file := "sku,name,currency,price,qty\nTJ-00001,Slim Jeans,IDR,250000,5\nTJ-00002,Denim Jacket,IDR,400000,3\nTJ-00003,,IDR,-1,2\n"
+
+	t.Run("should only let admins import and export", func(t *testing.T) {
+		store := &mockProductsStore{}
+		if rr := serve(store, "customer", http.MethodPost, "/products/import", "text/csv", file); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+		if rr := serve(store, "customer", http.MethodGet, "/products/export", "", ""); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+		if len(store.products) != 0 {
+			t.Errorf("expected no product, got %+v", store.products)
+		}
+	})
+	t.Run("should fail if the format is unknown", func(t *testing.T) {
+		if rr := serve(&mockProductsStore{}, "admin", http.MethodPost, "/products/import", "text/plain", file); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if rr := serve(&mockProductsStore{}, "admin", http.MethodPost, "/products/import", "text/csv", "name,price\nSlim Jeans,1\n"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected a header without a sku column to fail, got %d", rr.Code)
+		}
+	})
+	t.Run("should preview an import without changing the products", func(t *testing.T) {
+		store := &mockProductsStore{products: []types.Product{{ID: 1, SKU: "TJ-00001", Name: "Jeans"}}}
+		rr := serve(store, "admin", http.MethodPost, "/products/import?dry_run=true", "text/csv", file)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		var result types.ProductImportResult
+		if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		if !result.DryRun || result.Total != 3 || result.Created != 1 || result.Updated != 1 || len(result.Errors) != 2 {
+			t.Errorf("unexpected result %+v", result)
+		}
+		if len(store.products) != 1 || store.products[0].Name != "Jeans" {
+			t.Errorf("expected the products to be unchanged, got %+v", store.products)
+		}
+	})
+	t.Run("should upsert the products by sku", func(t *testing.T) {
+		store := &mockProductsStore{products: []types.Product{{ID: 1, SKU: "TJ-00001", Name: "Jeans"}}}
+		rr := serve(store, "admin", http.MethodPost, "/products/import", "text/csv", file)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		var result types.ProductImportResult
+		if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Created != 1 || result.Updated != 1 || result.Errors[0].Row != 4 {
+			t.Errorf("unexpected result %+v", result)
+		}
+		if len(store.products) != 2 || store.products[0].Name != "Slim Jeans" || store.products[0].Quantity != 5 || store.products[1].SKU != "TJ-00002" {
+			t.Errorf("unexpected products %+v", store.products)
+		}
+	})
+	t.Run("should import a large file in the background", func(t *testing.T) {
+		var b strings.Builder
+		b.WriteString("sku,name,currency,price,qty\n")
+		for i := 1; i <= 250; i++ {
+			fmt.Fprintf(&b, "TJ-%05d,Jeans %d,IDR,100000,1\n", i, i)
+		}
+		store := &mockProductsStore{}
+		rr := serve(store, "admin", http.MethodPost, "/products/import", "text/csv", b.String())
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("expected status code %d, got %d", http.StatusAccepted, rr.Code)
+		}
+		var job types.ProductImportJob
+		if err := json.NewDecoder(rr.Body).Decode(&job); err != nil {
+			t.Fatal(err)
+		}
+		if job.Total != 250 || rr.Header().Get("Location") != "/api/v1/products/imports/"+job.ID {
+			t.Errorf("unexpected job %+v at %q", job, rr.Header().Get("Location"))
+		}
+		if rr := serve(store, "admin", http.MethodGet, "/products/imports/unknown", "", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+	t.Run("should export the catalogue", func(t *testing.T) {
+		store := &mockProductsStore{products: []types.Product{{ID: 1, SKU: "TJ-00001", Name: "Slim Jeans", Currency: "IDR", Price: 250000, Quantity: 5}}}
+		rr := serve(store, "admin", http.MethodGet, "/products/export", "", "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if rr.Header().Get("Content-Type") != "text/csv" || !strings.Contains(rr.Body.String(), "TJ-00001,Slim Jeans,,,,IDR,,250000,5") {
+			t.Errorf("unexpected export %q %q", rr.Header().Get("Content-Type"), rr.Body.String())
+		}
+		rr = serve(store, "admin", http.MethodGet, "/products/export?format=json", "", "")
+		var rows []types.ProductImportRow
+		if err := json.NewDecoder(rr.Body).Decode(&rows); err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1 || rows[0].SKU != "TJ-00001" || rows[0].Price != 250000 {
+			t.Errorf("unexpected export %+v", rows)
+		}
+		if rr := serve(store, "admin", http.MethodGet, "/products/export?format=xml", "", ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
+
+type mockStockStore struct {
+	stock      map[stockKey]int
+	movements  []types.StockMovement
+	thresholds map[int]int
+	levels     []types.StockLevel
+	since      time.Time
+	warehouses []types.Warehouse
+}
+
+func (m *mockStockStore) RecordStockMovement(movement types.StockMovement) (int64, error) {
+	if err := validateStockMovement(movement); err != nil {
+		return 0, err
+	}
+	key := stockKey{movement.WarehouseID, movement.ProductID}
+	if m.stock[key]+movement.Quantity < 0 {
+		return 0, fmt.Errorf("not enough stock")
+	}
+	m.stock[key] += movement.Quantity
+	m.movements = append(m.movements, movement)
+	return int64(len(m.movements)), nil
+}
+func (m *mockStockStore) CountStock(warehouseID int, productID int, qty int, reference string, actorID int) (*types.StockMovement, error) {
+	current := m.stock[stockKey{warehouseID, productID}]
+	if qty == current {
+		return nil, nil
+	}
+	movement := types.StockMovement{ProductID: productID, Type: types.StockAdjustment, Quantity: qty - current, Reason: "stock count", Reference: reference, ActorID: actorID, WarehouseID: warehouseID}
+	if _, err := m.RecordStockMovement(movement); err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+func (m *mockStockStore) GetStockMovements(int) ([]types.StockMovement, error) {
+	return m.movements, nil
+}
+func (m *mockStockStore) GetStockDrift() ([]types.StockDrift, error) { return nil, nil }
+func (m *mockStockStore) SetStockThreshold(productID int, threshold int) error {
+	if m.thresholds == nil {
+		m.thresholds = map[int]int{}
+	}
+	m.thresholds[productID] = threshold
+	return nil
+}
+func (m *mockStockStore) GetStockLevels(since time.Time) ([]types.StockLevel, error) {
+	m.since = since
+	return m.levels, nil
+}
+func (m *mockStockStore) TransferStock(productID int, fromWarehouseID int, toWarehouseID int, qty int, reference string, actorID int) error {
+	if m.stock[stockKey{fromWarehouseID, productID}] < qty {
+		return fmt.Errorf("not enough stock")
+	}
+	for _, leg := range []types.StockMovement{
+		{WarehouseID: fromWarehouseID, Quantity: -qty},
+		{WarehouseID: toWarehouseID, Quantity: qty},
+	} {
+		leg.ProductID, leg.Type, leg.Reference, leg.ActorID = productID, types.StockTransfer, reference, actorID
+		if _, err := m.RecordStockMovement(leg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (m *mockStockStore) GetWarehouses() ([]types.Warehouse, error) { return m.warehouses, nil }
+func (m *mockStockStore) CreateWarehouse(w types.Warehouse) (int64, error) {
+	w.ID = len(m.warehouses) + 1
+	m.warehouses = append(m.warehouses, w)
+	return int64(w.ID), nil
+}
+func (m *mockStockStore) GetWarehouseStock(warehouseID int) ([]types.WarehouseStock, error) {
+	stock := []types.WarehouseStock{}
+	for key, qty := range m.stock {
+		if key.warehouseID == warehouseID {
+			stock = append(stock, types.WarehouseStock{WarehouseID: key.warehouseID, ProductID: key.productID, Quantity: qty})
+		}
+	}
+	return stock, nil
+}
+func (m *mockStockStore) GetStockByProductIDs(productIDs []int) ([]types.WarehouseStock, error) {
+	stock := []types.WarehouseStock{}
+	for key, qty := range m.stock {
+		if slices.Contains(productIDs, key.productID) {
+			stock = append(stock, types.WarehouseStock{WarehouseID: key.warehouseID, ProductID: key.productID, Quantity: qty})
+		}
+	}
+	sort.Slice(stock, func(i, j int) bool { return stock[i].WarehouseID < stock[j].WarehouseID })
+	return stock, nil
+}
+
+type mockProductsStore struct {
+	mu       sync.Mutex
+	products []types.Product
+}
 
 func (m *mockProductsStore) GetProducts() ([]types.Product, error) {
-	return []types.Product{}, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]types.Product{}, m.products...), nil
 }
 func (m *mockProductsStore) GetProductsByIDs([]int) ([]types.Product, error) {
 	return []types.Product{}, nil
 }
+func (m *mockProductsStore) GetProductsPage(int, int) ([]types.Product, error) {
+	return []types.Product{}, nil
+}
 func (m *mockProductsStore) GetProductByID(id int) (*types.Product, error) { return nil, nil }
-func (m *mockProductsStore) CreateProduct(types.Product) (int64, error) {
-	return 0, nil
+func (m *mockProductsStore) GetProductBySKU(sku string) (*types.Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.products {
+		if p.SKU == sku {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+func (m *mockProductsStore) CreateProduct(p types.Product) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p.ID = len(m.products) + 1
+	m.products = append(m.products, p)
+	return int64(p.ID), nil
 }
 func (m *mockProductsStore) DeleteProductByID(id int) (int64, error) { return 0, nil }
 func (m *mockProductsStore) DeleteProduct(types.Product) (int64, error) {
 	return 0, nil
 }
-func (m *mockProductsStore) UpdateProduct(types.Product) (int64, error) {
+func (m *mockProductsStore) UpdateProduct(p types.Product, actorID int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.products {
+		if m.products[i].ID == p.ID {
+			m.products[i] = p
+			return 1, nil
+		}
+	}
 	return 0, nil
 }
+func (m *mockProductsStore) GetDeletedProducts() ([]types.Product, error) { return nil, nil }
+func (m *mockProductsStore) RestoreProductByID(int) (int64, error)        { return 0, nil }
 
 type mockTokenStore struct{}
 
@@ -80,6 +603,36 @@ func (m *mockTokenStore) GetBlacklistTokenByString(string) (*types.Token, error)
 	return nil, nil
 }
 
+func (m *mockTokenStore) CreateVerificationToken(types.VerificationToken) error { return nil }
+func (m *mockTokenStore) GetVerificationTokenByHash(string) (*types.VerificationToken, error) {
+	return nil, nil
+}
+func (m *mockTokenStore) GetLatestVerificationTokenByEmail(string) (*types.VerificationToken, error) {
+	return nil, nil
+}
+func (m *mockTokenStore) UseVerificationToken(int) (int64, error)      { return 0, nil }
+func (m *mockTokenStore) RevokeVerificationTokensByEmail(string) error { return nil }
+func (m *mockTokenStore) CreatePasswordResetToken(types.PasswordResetToken) error {
+	return nil
+}
+func (m *mockTokenStore) GetPasswordResetTokenByHash(string) (*types.PasswordResetToken, error) {
+	return nil, nil
+}
+func (m *mockTokenStore) UsePasswordResetToken(int) (int64, error)    { return 0, nil }
+func (m *mockTokenStore) RevokePasswordResetTokensByUserID(int) error { return nil }
+func (m *mockTokenStore) RevokeUserTokens(int) error                  { return nil }
+func (m *mockTokenStore) GetUserTokensRevokedAt(int) (time.Time, error) {
+	return time.Time{}, nil
+}
+func (m *mockTokenStore) CreateAPIKey(types.APIKey) (int, error)     { return 0, nil }
+func (m *mockTokenStore) GetAPIKeys() ([]types.APIKey, error)        { return nil, nil }
+func (m *mockTokenStore) GetAPIKeyByID(int) (*types.APIKey, error)   { return nil, nil }
+func (m *mockTokenStore) RevokeAPIKey(int, time.Time) (int64, error) { return 0, nil }
+func (m *mockTokenStore) TouchAPIKey(int, time.Time) error           { return nil }
+func (m *mockTokenStore) GetAPIKeyByHash(string) (*types.APIKey, error) {
+	return nil, fmt.Errorf("api key not found")
+}
+
 type mockUserStore struct{}
 
 func (m *mockUserStore) GetUsers() ([]types.User, error)                   { return nil, nil }
@@ -91,5 +644,8 @@ func (m *mockUserStore) GetUserByEmail(string) (*types.User, error) {
 func (m *mockUserStore) GetUserByID(id int) (*types.User, error)   { return nil, nil }
 func (m *mockUserStore) DeleteUserByID(id int) (int64, error)      { return 0, nil }
 func (m *mockUserStore) DeleteUser(user types.User) (int64, error) { return 0, nil }
+func (m *mockUserStore) GetDeletedUsers() ([]types.User, error)    { return nil, nil }
+func (m *mockUserStore) RestoreUserByID(int) (int64, error)        { return 0, nil }
 func (m *mockUserStore) UpdateUser(user types.User) (int64, error) { return 0, nil }
+func (m *mockUserStore) UpdatePasswordByUserID(int, string) error  { return nil }
 func (m *mockUserStore) CreateUser(types.User) error               { return nil }
@@ -2,161 +2,334 @@ package products
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
-	"strings"
+	"errors"
+	"sort"
 
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/events"
 	"github.com/fayleenpc/tj-jeans/internal/types"
-	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+const (
+	defaultPageSize = 100
+	maxPageSize     = 500
+)
+
+// Service is the catalogue and its stock behind the REST, protobuf and gRPC
+// transports. Errors are statuses, see utils.WriteStatusError.
 type Service struct {
-	db *sql.DB
+	store      types.ProductStore
+	stockStore types.StockStore
+}
+
+func NewService(store types.ProductStore, stockStore types.StockStore) *Service {
+	return &Service{store: store, stockStore: stockStore}
+}
+
+func (s *Service) GetProducts(ctx context.Context) ([]types.Product, error) {
+	return s.store.GetProducts()
 }
 
-func NewService(db *sql.DB) *Service {
-	return &Service{
-		db: db,
+func (s *Service) GetProductsByIDs(ctx context.Context, ids []int) ([]types.Product, error) {
+	if len(ids) == 0 {
+		return []types.Product{}, nil
 	}
+	return s.store.GetProductsByIDs(ids)
 }
 
-func (s *Service) GetProducts(ctx context.Context, req *pb.GetProductsRequest) (*pb.GetProductsResponse, error) {
-	rows, err := s.db.Query("SELECT * FROM products")
-	if err != nil {
+func (s *Service) GetProductByID(ctx context.Context, id int) (*types.Product, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
 		return nil, err
 	}
-	products := make([]*pb.Product, 0)
-	for rows.Next() {
-		productPB, err := scanRowIntoProductPB(rows)
-		if err != nil {
-			return nil, err
-		}
-		products = append(products, productPB)
-	}
-	return &pb.GetProductsResponse{Products: products}, nil
+	return s.getProduct(id)
 }
-func (s *Service) GetProductsByIDs(ctx context.Context, productIDs *pb.GetProductsByIDsRequest) (*pb.GetProductsByIDsResponse, error) {
-	placeholders := strings.Repeat(",?", len(productIDs.GetIds())-1)
-	query := fmt.Sprintf("SELECT * FROM products WHERE id IN (?%s)", placeholders)
 
-	args := make([]interface{}, len(productIDs.GetIds()))
-	for i, v := range productIDs.GetIds() {
-		args[i] = v
+// CreateProduct adds the product, the store records the opening stock in the
+// ledger.
+func (s *Service) CreateProduct(ctx context.Context, p types.Product) (int64, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return 0, err
 	}
-
-	rows, err := s.db.Query(query, args...)
+	if p.Quantity < 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid quantity %d", p.Quantity)
+	}
+	id, err := s.store.CreateProduct(p)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
+	created, _ := s.getProduct(int(id))
+	audit.Record(ctx, audit.ActionCreate, audit.EntityProduct, int(id), nil, created)
+	events.Publish(events.TopicProduct, events.KindCreated, int(id))
+	return id, nil
+}
 
-	products := []*pb.Product{}
-	for rows.Next() {
-		productPB, err := scanRowIntoProductPB(rows)
-		if err != nil {
-			return nil, err
-		}
-		products = append(products, productPB)
+// UpdateProduct updates the product, the store records a changed quantity
+// in the ledger under the admin making the update.
+func (s *Service) UpdateProduct(ctx context.Context, p types.Product) (int64, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return 0, err
 	}
-
-	return &pb.GetProductsByIDsResponse{Products: products}, nil
+	before, err := s.getProduct(p.ID)
+	if err != nil {
+		return 0, err
+	}
+	actorID := auth.GetUserIDFromContext(ctx)
+	updated, err := s.store.UpdateProduct(p, actorID)
+	if err != nil {
+		return 0, err
+	}
+	after, _ := s.getProduct(p.ID)
+	audit.Record(ctx, audit.ActionUpdate, audit.EntityProduct, p.ID, before, after)
+	if p.Quantity != before.Quantity {
+		audit.Record(ctx, audit.ActionCreate, audit.EntityStock, p.ID, nil, productUpdateMovement(p, before.Quantity, actorID))
+	}
+	events.Publish(events.TopicProduct, events.KindUpdated, p.ID)
+	return updated, nil
 }
-func (s *Service) GetProductByID(ctx context.Context, id *pb.GetProductByIDRequest) (*pb.GetProductByIDResponse, error) {
-	rows, err := s.db.Query("SELECT * FROM products WHERe id = ?", id.GetId())
+
+// DeleteProductByID moves the product to the trash.
+func (s *Service) DeleteProductByID(ctx context.Context, id int) (int64, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return 0, err
+	}
+	before, err := s.getProduct(id)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	productPB := new(pb.Product)
-	for rows.Next() {
-		productPB, err = scanRowIntoProductPB(rows)
-		if err != nil {
-			return nil, err
-		}
+	deleted, err := s.store.DeleteProductByID(id)
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		audit.Record(ctx, audit.ActionDelete, audit.EntityProduct, id, before, nil)
+		events.Publish(events.TopicProduct, events.KindDeleted, id)
+	}
+	return deleted, nil
+}
 
+func (s *Service) GetDeletedProducts(ctx context.Context) ([]types.Product, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
 	}
-	return &pb.GetProductByIDResponse{Product: productPB}, nil
+	return s.store.GetDeletedProducts()
 }
 
-func (s *Service) CreateProduct(ctx context.Context, p *pb.CreateProductRequest) (*pb.CreateProductResponse, error) {
-	res, err := s.db.Exec(
-		"INSERT INTO products (name, description, merchant , category, currency , image , price, qty) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		p.GetProduct().GetName(), p.GetProduct().GetDescription(), p.Product.GetMerchant(), p.GetProduct().GetCategory(), p.GetProduct().GetCurrency(), p.GetProduct().GetImage(), p.GetProduct().GetPrice(), p.GetProduct().GetQuantity(),
-	)
+// RestoreProductByID takes the product out of the trash and returns it.
+func (s *Service) RestoreProductByID(ctx context.Context, id int) (*types.Product, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	restored, err := s.store.RestoreProductByID(id)
 	if err != nil {
 		return nil, err
 	}
-	id, err := res.LastInsertId()
+	if restored == 0 {
+		return nil, status.Errorf(codes.NotFound, "product %v is not in the trash", id)
+	}
+	product, err := s.getProduct(id)
 	if err != nil {
 		return nil, err
 	}
-	return &pb.CreateProductResponse{Id: id}, nil
+	audit.Record(ctx, audit.ActionRestore, audit.EntityProduct, id, nil, product)
+	events.Publish(events.TopicProduct, events.KindRestored, id)
+	return product, nil
 }
-func (s *Service) DeleteProductByID(ctx context.Context, id *pb.DeleteProductByIDRequest) (*pb.DeleteProductByIDResponse, error) {
-	res, err := s.db.Exec(
-		"DELETE from products WHERE id = ?",
-		id.GetId(),
-	)
+
+// ReceiveStock adds the received stock of the product to the ledger of the
+// warehouse under the admin receiving it.
+func (s *Service) ReceiveStock(ctx context.Context, productID int, payload types.StockReceiptPayload) (*types.StockMovement, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	if payload.Reason == "" {
+		payload.Reason = "goods receipt"
+	}
+	movement := types.StockMovement{
+		ProductID:   productID,
+		Type:        types.StockReceipt,
+		Quantity:    payload.Quantity,
+		Reason:      payload.Reason,
+		Reference:   payload.Reference,
+		ActorID:     auth.GetUserIDFromContext(ctx),
+		WarehouseID: payload.WarehouseID,
+	}
+	id, err := s.stockStore.RecordStockMovement(movement)
 	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	movement.ID = int(id)
+	audit.Record(ctx, audit.ActionCreate, audit.EntityStock, productID, nil, movement)
+	return &movement, nil
+}
+
+// CountStock sets the stock of the product in the warehouse to the counted
+// quantity, the adjustment is nil when the stock already matched.
+func (s *Service) CountStock(ctx context.Context, productID int, payload types.StockCountPayload) (*types.StockMovement, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
 		return nil, err
 	}
-	deletedProductID, _ := res.LastInsertId()
-	return &pb.DeleteProductByIDResponse{DeletedCount: deletedProductID}, nil
+	movement, err := s.stockStore.CountStock(payload.WarehouseID, productID, payload.Quantity, payload.Reference, auth.GetUserIDFromContext(ctx))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if movement != nil {
+		audit.Record(ctx, audit.ActionCreate, audit.EntityStock, productID, nil, movement)
+	}
+	return movement, nil
+}
+
+func (s *Service) SetStockThreshold(ctx context.Context, productID int, payload types.StockThresholdPayload) error {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return err
+	}
+	if err := s.stockStore.SetStockThreshold(productID, payload.Threshold); err != nil {
+		return err
+	}
+	audit.Record(ctx, audit.ActionUpdate, audit.EntityStock, productID, nil, payload)
+	return nil
 }
-func (s *Service) DeleteProduct(ctx context.Context, product *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
-	res, err := s.db.Exec(
-		"DELETE from products WHERE id = ?",
-		product.GetProduct().GetId(),
-	)
+
+// TransferStock moves the stock of the product between the warehouses of
+// the payload under the admin making the transfer.
+func (s *Service) TransferStock(ctx context.Context, productID int, payload types.StockTransferPayload) error {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return err
+	}
+	err := s.stockStore.TransferStock(productID, payload.FromWarehouseID, payload.ToWarehouseID, payload.Quantity, payload.Reference, auth.GetUserIDFromContext(ctx))
 	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	audit.Record(ctx, audit.ActionCreate, audit.EntityStock, productID, nil, payload)
+	return nil
+}
+
+func (s *Service) CreateWarehouse(ctx context.Context, payload types.WarehousePayload) (*types.Warehouse, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
 		return nil, err
 	}
-	deletedProductID, _ := res.LastInsertId()
-	return &pb.DeleteProductResponse{DeletedCount: deletedProductID}, nil
+	warehouse := types.Warehouse{
+		Name:      payload.Name,
+		Address:   payload.Address,
+		Latitude:  payload.Latitude,
+		Longitude: payload.Longitude,
+	}
+	id, err := s.stockStore.CreateWarehouse(warehouse)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	warehouse.ID = int(id)
+	audit.Record(ctx, audit.ActionCreate, audit.EntityWarehouse, warehouse.ID, nil, warehouse)
+	return &warehouse, nil
 }
-func (s *Service) UpdateProduct(ctx context.Context, product *pb.UpdateProductRequest) (*pb.UpdateProductResponse, error) {
-	res, err := s.db.Exec(
-		"UPDATE products SET name = ?, description = ?, merchant = ?, category = ?, currency = ?, image = ?, price = ?, qty = ? WHERE id = ?",
-		product.GetProduct().GetName(), product.GetProduct().GetDescription(), product.GetProduct().GetMerchant(), product.GetProduct().GetCategory(), product.GetProduct().GetCurrency(), product.GetProduct().GetImage(), product.GetProduct().GetPrice(), product.GetProduct().GetQuantity(), product.GetProduct().GetId(),
-	)
 
+// ExportProducts sends the catalogue a product at a time in the order of the
+// IDs, it stops at the first error of send.
+func (s *Service) ExportProducts(ctx context.Context, send func(types.Product) error) error {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return err
+	}
+	products, err := s.store.GetProducts()
 	if err != nil {
-		return nil, err
+		return err
+	}
+	sort.SliceStable(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	for _, p := range products {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		if err := send(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamProducts sends the catalogue after the cursor a page at a time in the
+// order of the IDs, the last page has less than pageSize products. The pages
+// carry the head of the event log taken before the first one, watching from
+// it gets every change the pages may have missed.
+func (s *Service) StreamProducts(ctx context.Context, cursor int, pageSize int, send func(types.ProductPage) error) error {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return err
+	}
+	if pageSize < 0 || pageSize > maxPageSize {
+		return status.Errorf(codes.InvalidArgument, "page size must be between 1 and %d", maxPageSize)
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	watchCursor, err := events.Head(events.TopicProduct)
+	if err != nil {
+		return err
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		products, err := s.store.GetProductsPage(cursor, pageSize)
+		if err != nil {
+			return err
+		}
+		if len(products) > 0 {
+			cursor = products[len(products)-1].ID
+		}
+		if err := send(types.ProductPage{Products: products, Cursor: cursor, WatchCursor: watchCursor}); err != nil {
+			return err
+		}
+		if len(products) < pageSize {
+			return nil
+		}
+	}
+}
+
+// WatchProducts sends the changes of the catalogue after the cursor as they
+// happen, until ctx is done. The products are read when their events are
+// sent, a change to a product trashed since is skipped for its delete.
+func (s *Service) WatchProducts(ctx context.Context, cursor int64, send func(types.ProductEvent) error) error {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return err
+	}
+	return events.Watch(ctx, events.TopicProduct, cursor, func(batch []types.DomainEvent) error {
+		ids := make([]int, 0, len(batch))
+		for _, e := range batch {
+			ids = append(ids, e.EntityID)
+		}
+		products, err := s.store.GetProductsByIDs(ids)
+		if err != nil {
+			return err
+		}
+		byID := make(map[int]types.Product, len(products))
+		for _, p := range products {
+			byID[p.ID] = p
+		}
+		for _, e := range batch {
+			p, ok := byID[e.EntityID]
+			if e.Kind == events.KindDeleted {
+				p, ok = types.Product{ID: e.EntityID}, true
+			}
+			if !ok {
+				continue
+			}
+			if err := send(types.ProductEvent{Cursor: e.ID, Kind: e.Kind, Product: p}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// getProduct returns the product out of the trash with the ID, the store
+// returns an empty one when there is none.
+func (s *Service) getProduct(id int) (*types.Product, error) {
+	product, err := s.store.GetProductByID(id)
+	if errors.Is(err, types.ErrNotFound) || (err == nil && (product == nil || product.ID == 0)) {
+		return nil, status.Errorf(codes.NotFound, "product %v not found", id)
 	}
-	updatedProductID, _ := res.LastInsertId()
-	return &pb.UpdateProductResponse{UpdatedCount: updatedProductID}, nil
-}
-
-//	{
-//		"error": "sql: Scan error on column index 9, name \"createdAt\":
-//		converting driver.Value type time.Time (\"2024-09-06 15:00:45 +0000 UTC\")
-//		to a int64: invalid syntax"
-//	}
-func scanRowIntoProductPB(rows *sql.Rows) (*pb.Product, error) {
-	product := new(types.Product)
-	err := rows.Scan(
-		&product.ID,
-		&product.Name,
-		&product.Description,
-		&product.Merchant,
-		&product.Category,
-		&product.Currency,
-		&product.Image,
-		&product.Price,
-		&product.Quantity,
-		&product.CreatedAt,
-	)
 	if err != nil {
 		return nil, err
 	}
-	productPB := new(pb.Product)
-	productPB.Category = product.Category
-	productPB.CreatedAt = product.CreatedAt.Unix()
-	productPB.Currency = product.Currency
-	productPB.Description = product.Description
-	productPB.Id = int32(product.ID)
-	productPB.Image = product.Image
-	productPB.Merchant = product.Merchant
-	productPB.Name = product.Name
-	productPB.Price = product.Price
-	productPB.Quantity = int32(product.Quantity)
-	return productPB, nil
+	return product, nil
 }
@@ -0,0 +1,479 @@
+package products
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/events"
+	"github.com/fayleenpc/tj-jeans/internal/inventory"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+// Signature of Stock Store
+// RecordStockMovement(m types.StockMovement) (int64, error)
+// CountStock(warehouseID int, productID int, qty int, reference string, actorID int) (*types.StockMovement, error)
+// GetStockMovements(productID int) ([]types.StockMovement, error)
+// GetStockDrift() ([]types.StockDrift, error)
+// SetStockThreshold(productID int, threshold int) error
+// GetStockLevels(since time.Time) ([]types.StockLevel, error)
+// TransferStock(productID int, fromWarehouseID int, toWarehouseID int, qty int, reference string, actorID int) error
+// GetWarehouses() ([]types.Warehouse, error)
+// CreateWarehouse(types.Warehouse) (int64, error)
+// GetWarehouseStock(warehouseID int) ([]types.WarehouseStock, error)
+// GetStockByProductIDs(productIDs []int) ([]types.WarehouseStock, error)
+//
+// stock only changes through the ledger, warehouse_stock and products.qty are
+// updated in the same transaction as the movement and cache the sum of the
+// product's entries in the warehouse and over all of them.
+// A movement that takes the stock down to the threshold of the product sends
+// a low-stock alert once it is committed, one that brings a sold out product
+// back wakes the back-in-stock worker.
+
+// RecordStockMovement adds the movement to the ledger of the product and
+// applies it to the cached quantity, it fails when the stock would go below
+// zero.
+func (s *Store) RecordStockMovement(m types.StockMovement) (int64, error) {
+	if err := validateStockMovement(m); err != nil {
+		return 0, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, notice, err := recordStockMovement(tx, m)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	notice.send()
+	return id, nil
+}
+
+// CountStock records the adjustment that brings the stock of the product in
+// the warehouse to the counted quantity, nil is returned when the count
+// matches.
+func (s *Store) CountStock(warehouseID int, productID int, qty int, reference string, actorID int) (*types.StockMovement, error) {
+	if qty < 0 {
+		return nil, fmt.Errorf("invalid stock count %d", qty)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockStock(tx, productID); err != nil {
+		return nil, err
+	}
+	current, err := lockWarehouseStock(tx, warehouseID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if current == qty {
+		return nil, tx.Commit()
+	}
+	m := types.StockMovement{
+		ProductID:   productID,
+		Type:        types.StockAdjustment,
+		Quantity:    qty - current,
+		Reason:      "stock count",
+		Reference:   reference,
+		ActorID:     actorID,
+		WarehouseID: warehouseID,
+	}
+	id, notice, err := recordStockMovement(tx, m)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	notice.send()
+	m.ID = int(id)
+	return &m, nil
+}
+
+func (s *Store) GetStockMovements(productID int) ([]types.StockMovement, error) {
+	rows, err := s.db.Query("SELECT * FROM stock_movements WHERE productId = ? ORDER BY id DESC", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	movements := make([]types.StockMovement, 0)
+	for rows.Next() {
+		m, err := scanRowIntoStockMovement(rows)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, *m)
+	}
+	return movements, rows.Err()
+}
+
+// TransferStock moves stock of the product between warehouses, the pair of
+// movements is recorded in one transaction and the total doesn't change so
+// no alert is sent.
+func (s *Store) TransferStock(productID int, fromWarehouseID int, toWarehouseID int, qty int, reference string, actorID int) error {
+	if qty <= 0 {
+		return fmt.Errorf("invalid transfer quantity %d", qty)
+	}
+	if fromWarehouseID == toWarehouseID {
+		return fmt.Errorf("can't transfer stock to the same warehouse")
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	legs := []types.StockMovement{
+		{WarehouseID: fromWarehouseID, Quantity: -qty, Reason: fmt.Sprintf("transfer to warehouse %d", toWarehouseID)},
+		{WarehouseID: toWarehouseID, Quantity: qty, Reason: fmt.Sprintf("transfer from warehouse %d", fromWarehouseID)},
+	}
+	for _, m := range legs {
+		m.ProductID = productID
+		m.Type = types.StockTransfer
+		m.Reference = reference
+		m.ActorID = actorID
+		if _, _, err := recordStockMovement(tx, m); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) GetWarehouses() ([]types.Warehouse, error) {
+	rows, err := s.db.Query("SELECT * FROM warehouses ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	warehouses := make([]types.Warehouse, 0)
+	for rows.Next() {
+		w, err := scanRowIntoWarehouse(rows)
+		if err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, *w)
+	}
+	return warehouses, rows.Err()
+}
+
+func (s *Store) CreateWarehouse(w types.Warehouse) (int64, error) {
+	res, err := s.db.Exec(
+		"INSERT INTO warehouses (name, address, latitude, longitude) VALUES (?, ?, ?, ?)",
+		w.Name, w.Address, w.Latitude, w.Longitude,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetWarehouseStock returns the stock of the products held in the warehouse.
+func (s *Store) GetWarehouseStock(warehouseID int) ([]types.WarehouseStock, error) {
+	return s.queryWarehouseStock("SELECT * FROM warehouse_stock WHERE warehouseId = ? AND qty <> 0 ORDER BY productId", warehouseID)
+}
+
+// GetStockByProductIDs returns the stock of the products in every warehouse
+// holding some.
+func (s *Store) GetStockByProductIDs(productIDs []int) ([]types.WarehouseStock, error) {
+	if len(productIDs) == 0 {
+		return []types.WarehouseStock{}, nil
+	}
+	placeholders := strings.Repeat(",?", len(productIDs)-1)
+	query := fmt.Sprintf("SELECT * FROM warehouse_stock WHERE productId IN (?%s) AND qty <> 0 ORDER BY productId, warehouseId", placeholders)
+	args := make([]interface{}, len(productIDs))
+	for i, v := range productIDs {
+		args[i] = v
+	}
+	return s.queryWarehouseStock(query, args...)
+}
+
+func (s *Store) queryWarehouseStock(query string, args ...interface{}) ([]types.WarehouseStock, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stock := make([]types.WarehouseStock, 0)
+	for rows.Next() {
+		var ws types.WarehouseStock
+		if err := rows.Scan(&ws.WarehouseID, &ws.ProductID, &ws.Quantity); err != nil {
+			return nil, err
+		}
+		stock = append(stock, ws)
+	}
+	return stock, rows.Err()
+}
+
+// GetStockDrift returns the products, trashed ones included, whose cached
+// quantity differs from the sum of their ledger, in total or in a warehouse.
+func (s *Store) GetStockDrift() ([]types.StockDrift, error) {
+	rows, err := s.db.Query(
+		"SELECT p.id, p.name, p.qty, COALESCE(SUM(m.qty), 0) AS ledgerQty FROM products p LEFT JOIN stock_movements m ON m.productId = p.id GROUP BY p.id, p.name, p.qty HAVING p.qty <> ledgerQty ORDER BY p.id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	drift := make([]types.StockDrift, 0)
+	for rows.Next() {
+		var d types.StockDrift
+		if err := rows.Scan(&d.ProductID, &d.Name, &d.Quantity, &d.LedgerQty); err != nil {
+			return nil, err
+		}
+		drift = append(drift, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(
+		"SELECT k.warehouseId, k.productId, p.name, COALESCE(ws.qty, 0) AS qty, COALESCE(l.ledgerQty, 0) AS ledgerQty FROM " +
+			"(SELECT warehouseId, productId FROM warehouse_stock UNION SELECT warehouseId, productId FROM stock_movements) k " +
+			"JOIN products p ON p.id = k.productId " +
+			"LEFT JOIN warehouse_stock ws ON ws.warehouseId = k.warehouseId AND ws.productId = k.productId " +
+			"LEFT JOIN (SELECT warehouseId, productId, SUM(qty) AS ledgerQty FROM stock_movements GROUP BY warehouseId, productId) l ON l.warehouseId = k.warehouseId AND l.productId = k.productId " +
+			"WHERE COALESCE(ws.qty, 0) <> COALESCE(l.ledgerQty, 0) ORDER BY k.productId, k.warehouseId",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d types.StockDrift
+		if err := rows.Scan(&d.WarehouseID, &d.ProductID, &d.Name, &d.Quantity, &d.LedgerQty); err != nil {
+			return nil, err
+		}
+		drift = append(drift, d)
+	}
+	return drift, rows.Err()
+}
+
+// SetStockThreshold sets the quantity at which the product is low on stock,
+// LOW_STOCK_THRESHOLD applies to the products without one.
+func (s *Store) SetStockThreshold(productID int, threshold int) error {
+	if threshold < 0 {
+		return fmt.Errorf("invalid threshold %d", threshold)
+	}
+	_, err := s.db.Exec(
+		"INSERT INTO stock_thresholds (productId, threshold) VALUES (?, ?) ON DUPLICATE KEY UPDATE threshold = VALUES(threshold)",
+		productID, threshold,
+	)
+	return err
+}
+
+// GetStockLevels returns the stock and threshold of the products with the
+// units sold by the orders made since, cancelled orders don't count.
+func (s *Store) GetStockLevels(since time.Time) ([]types.StockLevel, error) {
+	rows, err := s.db.Query(
+		"SELECT p.id, p.name, p.qty, COALESCE(t.threshold, ?), COALESCE(sales.sold, 0) FROM products p "+
+			"LEFT JOIN stock_thresholds t ON t.productId = p.id "+
+			"LEFT JOIN (SELECT oi.productId, SUM(oi.qty) AS sold FROM order_items oi JOIN orders o ON o.id = oi.orderId "+
+			"WHERE o.createdAt >= ? AND o.status <> 'cancelled' AND o.deletedAt IS NULL GROUP BY oi.productId) sales ON sales.productId = p.id "+
+			"WHERE p.deletedAt IS NULL ORDER BY p.id",
+		config.Envs.LowStockThreshold, since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	levels := make([]types.StockLevel, 0)
+	for rows.Next() {
+		var l types.StockLevel
+		if err := rows.Scan(&l.ProductID, &l.Name, &l.Quantity, &l.Threshold, &l.Sold); err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+	return levels, rows.Err()
+}
+
+// productUpdateMovement is the adjustment of an update taking the quantity
+// of the product from current to the one of the product.
+func productUpdateMovement(product types.Product, current int, actorID int) types.StockMovement {
+	return types.StockMovement{
+		ProductID:   product.ID,
+		Type:        types.StockAdjustment,
+		Quantity:    product.Quantity - current,
+		Reason:      "product update",
+		Reference:   fmt.Sprintf("product:%d", product.ID),
+		ActorID:     actorID,
+		WarehouseID: int(config.Envs.DefaultWarehouseID),
+	}
+}
+
+func validateStockMovement(m types.StockMovement) error {
+	switch m.Type {
+	case types.StockReceipt, types.StockReturn:
+		if m.Quantity <= 0 {
+			return fmt.Errorf("a %s must add stock", m.Type)
+		}
+	case types.StockSale:
+		if m.Quantity >= 0 {
+			return fmt.Errorf("a sale must take stock")
+		}
+	case types.StockAdjustment, types.StockReservation, types.StockTransfer:
+		if m.Quantity == 0 {
+			return fmt.Errorf("an empty %s", m.Type)
+		}
+	default:
+		return fmt.Errorf("unknown stock movement type %q", m.Type)
+	}
+	if m.WarehouseID <= 0 {
+		return fmt.Errorf("a stock movement needs a warehouse")
+	}
+	return nil
+}
+
+// lockStock reads the cached quantity of the product and locks its row until
+// the transaction ends.
+func lockStock(tx *sql.Tx, productID int) (int, error) {
+	var qty int
+	err := tx.QueryRow("SELECT qty FROM products WHERE id = ? AND deletedAt IS NULL FOR UPDATE", productID).Scan(&qty)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("product %d not found", productID)
+	}
+	return qty, err
+}
+
+// lockWarehouseStock reads the stock of the product in the warehouse and
+// locks its row until the transaction ends, a product the warehouse never
+// held has none.
+func lockWarehouseStock(tx *sql.Tx, warehouseID int, productID int) (int, error) {
+	var qty int
+	err := tx.QueryRow("SELECT qty FROM warehouse_stock WHERE warehouseId = ? AND productId = ? FOR UPDATE", warehouseID, productID).Scan(&qty)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return qty, err
+}
+
+// stockNotice is what a movement has to tell once its transaction is
+// committed.
+type stockNotice struct {
+	productID int
+	alert     *inventory.Alert
+	restocked bool
+}
+
+// send is called once the movement is committed, the watchers of the
+// catalogue get the new stock of the product.
+func (n stockNotice) send() {
+	if n.productID > 0 {
+		events.Publish(events.TopicProduct, events.KindStock, n.productID)
+	}
+	if n.alert != nil {
+		inventory.Notify(*n.alert)
+	}
+	if n.restocked {
+		inventory.WakeRestock()
+	}
+}
+
+// recordStockMovement applies the movement in tx, the alert of the returned
+// notice is set when it takes the stock down to the threshold and restocked
+// when it brings a sold out product back.
+func recordStockMovement(tx *sql.Tx, m types.StockMovement) (int64, stockNotice, error) {
+	var name string
+	var qty, threshold int
+	err := tx.QueryRow(
+		"SELECT p.name, p.qty, COALESCE(t.threshold, ?) FROM products p LEFT JOIN stock_thresholds t ON t.productId = p.id WHERE p.id = ? AND p.deletedAt IS NULL FOR UPDATE",
+		config.Envs.LowStockThreshold, m.ProductID,
+	).Scan(&name, &qty, &threshold)
+	if err == sql.ErrNoRows {
+		return 0, stockNotice{}, fmt.Errorf("product %d not found", m.ProductID)
+	}
+	if err != nil {
+		return 0, stockNotice{}, err
+	}
+	after := qty + m.Quantity
+	if after < 0 {
+		return 0, stockNotice{}, fmt.Errorf("not enough stock of %s, %d left", name, qty)
+	}
+	held, err := lockWarehouseStock(tx, m.WarehouseID, m.ProductID)
+	if err != nil {
+		return 0, stockNotice{}, err
+	}
+	if held+m.Quantity < 0 {
+		return 0, stockNotice{}, fmt.Errorf("not enough stock of %s in warehouse %d, %d left", name, m.WarehouseID, held)
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO warehouse_stock (warehouseId, productId, qty) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE qty = VALUES(qty)",
+		m.WarehouseID, m.ProductID, held+m.Quantity,
+	); err != nil {
+		return 0, stockNotice{}, err
+	}
+	if _, err := tx.Exec("UPDATE products SET qty = ? WHERE id = ?", after, m.ProductID); err != nil {
+		return 0, stockNotice{}, err
+	}
+	res, err := tx.Exec(
+		"INSERT INTO stock_movements (productId, type, qty, reason, reference, actorId, warehouseId) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		m.ProductID, m.Type, m.Quantity, m.Reason, m.Reference, m.ActorID, m.WarehouseID,
+	)
+	if err != nil {
+		return 0, stockNotice{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, stockNotice{}, err
+	}
+
+	notice := stockNotice{productID: m.ProductID, restocked: inventory.Restocked(qty, after)}
+	if inventory.Crossed(qty, after, threshold) {
+		notice.alert = &inventory.Alert{
+			ProductID: m.ProductID,
+			Name:      name,
+			Quantity:  after,
+			Previous:  qty,
+			Threshold: threshold,
+			Reference: m.Reference,
+			At:        time.Now(),
+		}
+	}
+	return id, notice, nil
+}
+
+func scanRowIntoStockMovement(rows *sql.Rows) (*types.StockMovement, error) {
+	m := new(types.StockMovement)
+	err := rows.Scan(
+		&m.ID,
+		&m.ProductID,
+		&m.Type,
+		&m.Quantity,
+		&m.Reason,
+		&m.Reference,
+		&m.ActorID,
+		&m.CreatedAt,
+		&m.WarehouseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func scanRowIntoWarehouse(rows *sql.Rows) (*types.Warehouse, error) {
+	w := new(types.Warehouse)
+	err := rows.Scan(
+		&w.ID,
+		&w.Name,
+		&w.Address,
+		&w.Latitude,
+		&w.Longitude,
+		&w.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/fayleenpc/tj-jeans/internal/config"
 	"github.com/fayleenpc/tj-jeans/internal/types"
 )
 
@@ -15,7 +17,13 @@ import (
 // CreateProduct(p types.Product) (int64, error)
 // DeleteProductByID(id int) (int64, error)
 // DeleteProduct(product types.Product) (int64, error)
-// UpdateProduct(product types.Product) (int64, error)
+// UpdateProduct(product types.Product, actorID int) (int64, error)
+// GetDeletedProducts() ([]types.Product, error)
+// RestoreProductByID(id int) (int64, error)
+// GetProductBySKU(sku string) (*types.Product, error)
+// GetProductsPage(afterID int, limit int) ([]types.Product, error)
+//
+// the quantity is only changed through the stock ledger, see stock.go.
 
 type Store struct {
 	db *sql.DB
@@ -26,7 +34,7 @@ func NewStore(db *sql.DB) *Store {
 }
 
 func (s *Store) GetProducts() ([]types.Product, error) {
-	rows, err := s.db.Query("SELECT * FROM products")
+	rows, err := s.db.Query("SELECT * FROM products WHERE deletedAt IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -41,9 +49,28 @@ func (s *Store) GetProducts() ([]types.Product, error) {
 	return products, nil
 }
 
+// GetProductsPage returns the products after the ID in the order of the IDs,
+// the pages of a stream don't move when products are added meanwhile.
+func (s *Store) GetProductsPage(afterID int, limit int) ([]types.Product, error) {
+	rows, err := s.db.Query("SELECT * FROM products WHERE id > ? AND deletedAt IS NULL ORDER BY id LIMIT ?", afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	products := make([]types.Product, 0)
+	for rows.Next() {
+		product, err := scanRowIntoProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *product)
+	}
+	return products, rows.Err()
+}
+
 func (s *Store) GetProductsByIDs(productIDs []int) ([]types.Product, error) {
 	placeholders := strings.Repeat(",?", len(productIDs)-1)
-	query := fmt.Sprintf("SELECT * FROM products WHERE id IN (?%s)", placeholders)
+	query := fmt.Sprintf("SELECT * FROM products WHERE id IN (?%s) AND deletedAt IS NULL", placeholders)
 
 	args := make([]interface{}, len(productIDs))
 	for i, v := range productIDs {
@@ -68,7 +95,7 @@ func (s *Store) GetProductsByIDs(productIDs []int) ([]types.Product, error) {
 }
 
 func (s *Store) GetProductByID(id int) (*types.Product, error) {
-	rows, err := s.db.Query("SELECT * FROM products WHERe id = ?", id)
+	rows, err := s.db.Query("SELECT * FROM products WHERE id = ? AND deletedAt IS NULL", id)
 	if err != nil {
 		return nil, err
 	}
@@ -82,10 +109,35 @@ func (s *Store) GetProductByID(id int) (*types.Product, error) {
 	return product, nil
 }
 
+// GetProductBySKU returns the product with the SKU, nil when there is none.
+func (s *Store) GetProductBySKU(sku string) (*types.Product, error) {
+	rows, err := s.db.Query("SELECT * FROM products WHERE sku = ? AND deletedAt IS NULL", sku)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	return scanRowIntoProduct(rows)
+}
+
+// CreateProduct inserts the product with its opening stock recorded in the
+// ledger of DEFAULT_WAREHOUSE_ID, a product without a SKU gets one from its
+// ID.
 func (s *Store) CreateProduct(p types.Product) (int64, error) {
-	res, err := s.db.Exec(
-		"INSERT INTO products (name, description, merchant , category, currency , image , price, qty) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		p.Name, p.Description, p.Merchant, p.Category, p.Currency, p.Image, p.Price, p.Quantity,
+	if p.Quantity < 0 {
+		return 0, fmt.Errorf("invalid quantity %d", p.Quantity)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"INSERT INTO products (name, description, merchant , category, currency , image , price, qty, sku) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		p.Name, p.Description, p.Merchant, p.Category, p.Currency, p.Image, p.Price, 0, sql.NullString{String: p.SKU, Valid: p.SKU != ""},
 	)
 	if err != nil {
 		return 0, err
@@ -94,45 +146,121 @@ func (s *Store) CreateProduct(p types.Product) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return id, nil
+	if p.SKU == "" {
+		if _, err := tx.Exec("UPDATE products SET sku = CONCAT('TJ-', LPAD(id, 5, '0')) WHERE id = ?", id); err != nil {
+			return 0, err
+		}
+	}
+	if p.Quantity > 0 {
+		_, _, err := recordStockMovement(tx, types.StockMovement{
+			ProductID:   int(id),
+			Type:        types.StockAdjustment,
+			Quantity:    p.Quantity,
+			Reason:      "opening stock",
+			Reference:   fmt.Sprintf("product:%d", id),
+			WarehouseID: int(config.Envs.DefaultWarehouseID),
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
 }
 
+// DeleteProductByID moves the product to the trash, the orders of it keep
+// pointing to it and it can be restored until the retention purge.
 func (s *Store) DeleteProductByID(id int) (int64, error) {
 	res, err := s.db.Exec(
-		"DELETE from products WHERE id = ?",
-		id,
+		"UPDATE products SET deletedAt = ? WHERE id = ? AND deletedAt IS NULL",
+		time.Now(), id,
 	)
 	if err != nil {
 		return 0, err
 	}
-	return res.LastInsertId()
+	return res.RowsAffected()
 }
 
 func (s *Store) DeleteProduct(product types.Product) (int64, error) {
-	res, err := s.db.Exec(
-		"DELETE from products WHERE id = ?",
-		product.ID,
+	return s.DeleteProductByID(product.ID)
+}
+
+// UpdateProduct updates the product, a quantity that differs from the stock
+// is recorded as an adjustment by the actor to the ledger of
+// DEFAULT_WAREHOUSE_ID like a stock count. An empty SKU keeps the current one.
+func (s *Store) UpdateProduct(product types.Product, actorID int) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	current, err := lockStock(tx, product.ID)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(
+		"UPDATE products SET name = ?, description = ?, merchant = ?, category = ?, currency = ?, image = ?, price = ?, sku = COALESCE(NULLIF(?, ''), sku) WHERE id = ? AND deletedAt IS NULL",
+		product.Name, product.Description, product.Merchant, product.Category, product.Currency, product.Image, product.Price, product.SKU, product.ID,
 	)
+
 	if err != nil {
 		return 0, err
 	}
+	var notice stockNotice
+	if product.Quantity != current {
+		_, notice, err = recordStockMovement(tx, productUpdateMovement(product, current, actorID))
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	notice.send()
 	return res.LastInsertId()
 }
 
-func (s *Store) UpdateProduct(product types.Product) (int64, error) {
+func (s *Store) GetDeletedProducts() ([]types.Product, error) {
+	rows, err := s.db.Query("SELECT * FROM products WHERE deletedAt IS NOT NULL ORDER BY deletedAt DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	products := make([]types.Product, 0)
+	for rows.Next() {
+		product, err := scanRowIntoProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *product)
+	}
+	return products, rows.Err()
+}
+
+func (s *Store) RestoreProductByID(id int) (int64, error) {
+	res, err := s.db.Exec("UPDATE products SET deletedAt = NULL WHERE id = ? AND deletedAt IS NOT NULL", id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PurgeDeletedProducts removes the products that went to the trash before
+// the cutoff, products that were ordered are kept for the order items.
+func (s *Store) PurgeDeletedProducts(before time.Time) (int64, error) {
 	res, err := s.db.Exec(
-		"UPDATE products SET name = ?, description = ?, merchant = ?, category = ?, currency = ?, image = ?, price = ?, qty = ? WHERE id = ?",
-		product.Name, product.Description, product.Merchant, product.Category, product.Currency, product.Image, product.Price, product.Quantity, product.ID,
+		"DELETE FROM products WHERE deletedAt < ? AND id NOT IN (SELECT productId FROM order_items)",
+		before,
 	)
-
 	if err != nil {
 		return 0, err
 	}
-	return res.LastInsertId()
+	return res.RowsAffected()
 }
 
 func scanRowIntoProduct(rows *sql.Rows) (*types.Product, error) {
 	product := new(types.Product)
+	var sku sql.NullString
 	err := rows.Scan(
 		&product.ID,
 		&product.Name,
@@ -144,9 +272,14 @@ func scanRowIntoProduct(rows *sql.Rows) (*types.Product, error) {
 		&product.Price,
 		&product.Quantity,
 		&product.CreatedAt,
+		&product.DeletedAt,
+		&sku,
+		&product.Rating,
+		&product.ReviewCount,
 	)
 	if err != nil {
 		return nil, err
 	}
+	product.SKU = sku.String
 	return product, nil
 }
@@ -0,0 +1,156 @@
+package products
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/events"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStreamProducts(t *testing.T) {
+	events.SetDefault(events.NewLog(&memoryEventStore{}, time.Hour))
+	defer events.SetDefault(nil)
+
+	store := newMemoryProductStore()
+	for i := 0; i < 5; i++ {
+		store.CreateProduct(types.Product{Name: "Slim Jeans", Price: 100})
+	}
+	service := NewService(store, &mockStockStore{})
+	admin := context.WithValue(context.Background(), auth.UserRoleKey, "admin")
+	events.Publish(events.TopicProduct, events.KindCreated, 1)
+
+	t.Run("should fail if the user is not an admin", func(t *testing.T) {
+		customer := context.WithValue(context.Background(), auth.UserRoleKey, "customer")
+		err := service.StreamProducts(customer, 0, 2, func(types.ProductPage) error { return nil })
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("expected %v, got %v", codes.PermissionDenied, err)
+		}
+	})
+	t.Run("should fail if the page size is out of range", func(t *testing.T) {
+		err := service.StreamProducts(admin, 0, maxPageSize+1, func(types.ProductPage) error { return nil })
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected %v, got %v", codes.InvalidArgument, err)
+		}
+	})
+	t.Run("should send the products after the cursor a page at a time", func(t *testing.T) {
+		var pages []types.ProductPage
+		err := service.StreamProducts(admin, 1, 2, func(page types.ProductPage) error {
+			pages = append(pages, page)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the last page is full so an empty one ends the stream
+		if len(pages) != 3 || len(pages[0].Products) != 2 || len(pages[1].Products) != 2 || len(pages[2].Products) != 0 {
+			t.Fatalf("expected 2 full pages and an end, got %+v", pages)
+		}
+		if pages[0].Products[0].ID != 2 || pages[1].Cursor != 5 {
+			t.Errorf("expected products 2 to 5, got %+v", pages)
+		}
+		if pages[0].WatchCursor != 1 {
+			t.Errorf("expected the head of the log as the watch cursor, got %v", pages[0].WatchCursor)
+		}
+	})
+}
+
+func TestWatchProducts(t *testing.T) {
+	events.SetDefault(events.NewLog(&memoryEventStore{}, time.Hour))
+	defer events.SetDefault(nil)
+
+	store := newMemoryProductStore(types.Product{ID: 1, Name: "Slim Jeans", Price: 100, Quantity: 5})
+	service := NewService(store, &mockStockStore{})
+	admin := context.WithValue(context.Background(), auth.UserRoleKey, "admin")
+	if _, err := service.UpdateProduct(admin, types.Product{ID: 1, Name: "Slim Jeans", Price: 90, Quantity: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	head, _ := events.Head(events.TopicProduct)
+	ctx, cancel := context.WithCancel(admin)
+	received := make(chan types.ProductEvent, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- service.WatchProducts(ctx, -1, func(e types.ProductEvent) error {
+			received <- e
+			return nil
+		})
+	}()
+	next := func() types.ProductEvent {
+		t.Helper()
+		select {
+		case e := <-received:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("expected an event")
+		}
+		return types.ProductEvent{}
+	}
+
+	t.Run("should start at the newest event without a cursor", func(t *testing.T) {
+		// wait for the watcher to read the head
+		time.Sleep(time.Millisecond * 50)
+		if _, err := service.CreateProduct(admin, types.Product{Name: "Denim Jacket", Price: 250}); err != nil {
+			t.Fatal(err)
+		}
+		if e := next(); e.Kind != events.KindCreated || e.Product.Name != "Denim Jacket" || e.Cursor <= head {
+			t.Errorf("expected the created product, got %+v", e)
+		}
+	})
+	t.Run("should send the id of a deleted product", func(t *testing.T) {
+		if _, err := service.DeleteProductByID(admin, 2); err != nil {
+			t.Fatal(err)
+		}
+		if e := next(); e.Kind != events.KindDeleted || e.Product.ID != 2 {
+			t.Errorf("expected the deleted product, got %+v", e)
+		}
+	})
+	t.Run("should end with the context", func(t *testing.T) {
+		cancel()
+		if err := <-done; status.Code(err) != codes.Canceled {
+			t.Errorf("expected %v, got %v", codes.Canceled, err)
+		}
+	})
+}
+
+type memoryEventStore struct {
+	mu     sync.Mutex
+	events []types.DomainEvent
+}
+
+func (m *memoryEventStore) CreateEvent(e types.DomainEvent) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.ID = int64(len(m.events) + 1)
+	m.events = append(m.events, e)
+	return e.ID, nil
+}
+
+func (m *memoryEventStore) GetEvents(topic string, afterID int64, limit int) ([]types.DomainEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := make([]types.DomainEvent, 0)
+	for _, e := range m.events {
+		if e.Topic == topic && e.ID > afterID && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *memoryEventStore) GetLastEventID(topic string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var id int64
+	for _, e := range m.events {
+		if e.Topic == topic {
+			id = e.ID
+		}
+	}
+	return id, nil
+}
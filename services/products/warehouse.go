@@ -0,0 +1,146 @@
+package products
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+func (h *Handler) handleGetWarehouses(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetWarehouses")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	warehouses, err := h.stockStore.GetWarehouses()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, warehouses)
+}
+
+func (h *Handler) handleCreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleCreateWarehouse")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.WarehousePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", err))
+		return
+	}
+
+	warehouse, err := h.service.CreateWarehouse(r.Context(), payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, warehouse)
+}
+
+func (h *Handler) handleGetWarehouseStock(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetWarehouseStock")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	warehouseID, err := strconv.Atoi(mux.Vars(r)["warehouse_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid warehouse id"))
+		return
+	}
+
+	stock, err := h.stockStore.GetWarehouseStock(warehouseID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, stock)
+}
+
+func (h *Handler) handleGetProductWarehouseStock(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetProductWarehouseStock")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	stock, err := h.stockStore.GetStockByProductIDs([]int{productID})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, stock)
+}
+
+func (h *Handler) handleCreateStockTransfer(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleCreateStockTransfer")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	var payload types.StockTransferPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", err))
+		return
+	}
+
+	if err := h.service.TransferStock(r.Context(), productID, payload); err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, payload)
+}
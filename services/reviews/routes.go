@@ -0,0 +1,281 @@
+package reviews
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/ratelimiter"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type Handler struct {
+	store      types.ReviewStore
+	userStore  types.UserStore
+	tokenStore types.TokenStore
+}
+
+func NewHandler(store types.ReviewStore, userStore types.UserStore, tokenStore types.TokenStore) *Handler {
+	return &Handler{store: store, userStore: userStore, tokenStore: tokenStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/products/{product_id}/reviews", ratelimiter.WithRateLimiter(h.handleGetProductReviews)).Methods("GET")
+	router.HandleFunc("/reviews", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleCreateReview), h.userStore, h.tokenStore)).Methods("POST")
+	router.HandleFunc("/reviews", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetReviews), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/reviews/{review_id}/status", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleSetReviewStatus), h.userStore, h.tokenStore)).Methods("PUT")
+	router.HandleFunc("/reviews/{review_id}/reply", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleReplyToReview), h.userStore, h.tokenStore)).Methods("PUT")
+}
+
+func (h *Handler) handleGetProductReviews(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetProductReviews")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	filter.ProductID = productID
+	filter.Status = types.ReviewApproved
+
+	reviews, err := h.store.GetReviews(filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, reviews)
+}
+
+func (h *Handler) handleCreateReview(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleCreateReview")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.ReviewPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", err))
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	purchased, err := h.store.HasPurchased(userID, payload.ProductID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !purchased {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("only customers who bought the product can review it"))
+		return
+	}
+	existing, err := h.store.GetReviews(types.ReviewFilter{ProductID: payload.ProductID, UserID: userID, Limit: 1})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(existing) > 0 {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("the product is already reviewed"))
+		return
+	}
+
+	review := types.Review{
+		ProductID: payload.ProductID,
+		UserID:    userID,
+		Rating:    payload.Rating,
+		Body:      payload.Body,
+		Photos:    payload.Photos,
+		Status:    types.ReviewPending,
+	}
+	if review.Photos == nil {
+		review.Photos = []string{}
+	}
+	id, err := h.store.CreateReview(review)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	review.ID = int(id)
+	audit.Record(r.Context(), audit.ActionCreate, audit.EntityReview, review.ID, nil, review)
+
+	utils.WriteJSON(w, http.StatusCreated, review)
+}
+
+func (h *Handler) handleGetReviews(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetReviews")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reviews, err := h.store.GetReviews(filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, reviews)
+}
+
+func (h *Handler) handleSetReviewStatus(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleSetReviewStatus")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	var payload types.ReviewStatusPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", err))
+		return
+	}
+
+	review, ok := h.getReview(w, r)
+	if !ok {
+		return
+	}
+	if err := h.store.SetReviewStatus(review.ID, payload.Status); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	updated := *review
+	updated.Status = payload.Status
+	audit.Record(r.Context(), audit.ActionUpdate, audit.EntityReview, review.ID, review, updated)
+
+	utils.WriteJSON(w, http.StatusOK, updated)
+}
+
+func (h *Handler) handleReplyToReview(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleReplyToReview")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) != "admin" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	var payload types.ReviewReplyPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", err))
+		return
+	}
+
+	review, ok := h.getReview(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if err := h.store.ReplyToReview(review.ID, payload.Reply, now); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	updated := *review
+	updated.Reply = payload.Reply
+	updated.RepliedAt = &now
+	audit.Record(r.Context(), audit.ActionUpdate, audit.EntityReview, review.ID, review, updated)
+
+	utils.WriteJSON(w, http.StatusOK, updated)
+}
+
+// getReview looks up the review of the path, the error is written when ok is
+// false.
+func (h *Handler) getReview(w http.ResponseWriter, r *http.Request) (*types.Review, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["review_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid review id"))
+		return nil, false
+	}
+	review, err := h.store.GetReviewByID(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return nil, false
+	}
+	return review, true
+}
+
+// ParseFilter reads the filter of a list of reviews from the query.
+func ParseFilter(query url.Values) (types.ReviewFilter, error) {
+	filter := types.ReviewFilter{Status: query.Get("status"), Limit: defaultLimit}
+	switch filter.Status {
+	case "", types.ReviewPending, types.ReviewApproved, types.ReviewHidden:
+	default:
+		return filter, fmt.Errorf("invalid status, expected pending, approved or hidden")
+	}
+	ints := map[string]*int{
+		"product_id": &filter.ProductID,
+		"limit":      &filter.Limit,
+		"offset":     &filter.Offset,
+	}
+	for name, field := range ints {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("invalid %s", name)
+		}
+		*field = n
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+	return filter, nil
+}
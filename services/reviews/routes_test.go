@@ -0,0 +1,192 @@
+package reviews
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/gorilla/mux"
+)
+
+func TestReviewServiceHandler(t *testing.T) {
+	serve := func(store *mockReviewStore, role string, method string, path string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req, err := http.NewRequest(method, path, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(req.Context(), auth.UserKey, 7)
+		ctx = context.WithValue(ctx, auth.UserRoleKey, role)
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		handler := NewHandler(store, nil, nil)
+		router.HandleFunc("/products/{product_id}/reviews", handler.handleGetProductReviews).Methods("GET")
+		router.HandleFunc("/reviews", handler.handleCreateReview).Methods("POST")
+		router.HandleFunc("/reviews", handler.handleGetReviews).Methods("GET")
+		router.HandleFunc("/reviews/{review_id}/status", handler.handleSetReviewStatus).Methods("PUT")
+		router.HandleFunc("/reviews/{review_id}/reply", handler.handleReplyToReview).Methods("PUT")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should fail if the review is invalid", func(t *testing.T) {
+		store := &mockReviewStore{purchased: true}
+		for _, payload := range []types.ReviewPayload{
+			{ProductID: 1, Rating: 6, Body: "Great"},
+			{ProductID: 1, Rating: 0, Body: "Great"},
+			{ProductID: 1, Rating: 5},
+			{ProductID: 1, Rating: 5, Body: "Great", Photos: []string{"not a url"}},
+		} {
+			if rr := serve(store, "customer", http.MethodPost, "/reviews", payload); rr.Code != http.StatusBadRequest {
+				t.Errorf("expected %+v to fail with %d, got %d", payload, http.StatusBadRequest, rr.Code)
+			}
+		}
+	})
+	t.Run("should only let customers who bought the product review it", func(t *testing.T) {
+		store := &mockReviewStore{}
+		rr := serve(store, "customer", http.MethodPost, "/reviews", types.ReviewPayload{ProductID: 1, Rating: 5, Body: "Great"})
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+		if len(store.reviews) != 0 {
+			t.Errorf("expected no review, got %+v", store.reviews)
+		}
+	})
+	t.Run("should create a pending review once per product", func(t *testing.T) {
+		store := &mockReviewStore{purchased: true}
+		payload := types.ReviewPayload{ProductID: 1, Rating: 4, Body: "Fits well", Photos: []string{"https://example.com/1.jpg"}}
+		rr := serve(store, "customer", http.MethodPost, "/reviews", payload)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, rr.Code)
+		}
+		r := store.reviews[0]
+		if r.UserID != 7 || r.ProductID != 1 || r.Rating != 4 || r.Status != types.ReviewPending || len(r.Photos) != 1 {
+			t.Errorf("unexpected review %+v", r)
+		}
+		if rr := serve(store, "customer", http.MethodPost, "/reviews", payload); rr.Code != http.StatusConflict {
+			t.Errorf("expected status code %d, got %d", http.StatusConflict, rr.Code)
+		}
+	})
+	t.Run("should only list the approved reviews of a product", func(t *testing.T) {
+		store := &mockReviewStore{}
+		rr := serve(store, "", http.MethodGet, "/products/3/reviews?limit=5", nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if store.filter.ProductID != 3 || store.filter.Status != types.ReviewApproved || store.filter.Limit != 5 {
+			t.Errorf("unexpected filter %+v", store.filter)
+		}
+	})
+	t.Run("should only let admins moderate the reviews", func(t *testing.T) {
+		store := &mockReviewStore{reviews: []types.Review{{ID: 1, ProductID: 1, Status: types.ReviewPending}}}
+		if rr := serve(store, "customer", http.MethodGet, "/reviews?status=pending", nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+		if rr := serve(store, "customer", http.MethodPut, "/reviews/1/status", types.ReviewStatusPayload{Status: types.ReviewApproved}); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+		if rr := serve(store, "customer", http.MethodPut, "/reviews/1/reply", types.ReviewReplyPayload{Reply: "Thanks"}); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+		if store.reviews[0].Status != types.ReviewPending || store.reviews[0].Reply != "" {
+			t.Errorf("expected the review to be unchanged, got %+v", store.reviews[0])
+		}
+	})
+	t.Run("should approve, hide and reply to a review", func(t *testing.T) {
+		store := &mockReviewStore{reviews: []types.Review{{ID: 1, ProductID: 1, Status: types.ReviewPending}}}
+		if rr := serve(store, "admin", http.MethodPut, "/reviews/1/status", types.ReviewStatusPayload{Status: "deleted"}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if rr := serve(store, "admin", http.MethodPut, "/reviews/2/status", types.ReviewStatusPayload{Status: types.ReviewApproved}); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+		if rr := serve(store, "admin", http.MethodPut, "/reviews/1/status", types.ReviewStatusPayload{Status: types.ReviewApproved}); rr.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if store.reviews[0].Status != types.ReviewApproved {
+			t.Errorf("expected the review to be approved, got %+v", store.reviews[0])
+		}
+		if rr := serve(store, "admin", http.MethodPut, "/reviews/1/status", types.ReviewStatusPayload{Status: types.ReviewHidden}); rr.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if store.reviews[0].Status != types.ReviewHidden {
+			t.Errorf("expected the review to be hidden, got %+v", store.reviews[0])
+		}
+		rr := serve(store, "admin", http.MethodPut, "/reviews/1/reply", types.ReviewReplyPayload{Reply: "Thanks for your review"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if store.reviews[0].Reply != "Thanks for your review" || store.reviews[0].RepliedAt == nil {
+			t.Errorf("unexpected reply %+v", store.reviews[0])
+		}
+	})
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(url.Values{"status": {"pending"}, "product_id": {"2"}, "limit": {"1000"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Status != types.ReviewPending || filter.ProductID != 2 || filter.Limit != maxLimit {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+	for _, query := range []url.Values{{"status": {"deleted"}}, {"product_id": {"x"}}, {"offset": {"-1"}}} {
+		if _, err := ParseFilter(query); err == nil {
+			t.Errorf("expected %v to be invalid", query)
+		}
+	}
+}
+
+type mockReviewStore struct {
+	reviews   []types.Review
+	purchased bool
+	filter    types.ReviewFilter
+}
+
+func (m *mockReviewStore) CreateReview(review types.Review) (int64, error) {
+	review.ID = len(m.reviews) + 1
+	m.reviews = append(m.reviews, review)
+	return int64(review.ID), nil
+}
+func (m *mockReviewStore) HasPurchased(int, int) (bool, error) { return m.purchased, nil }
+func (m *mockReviewStore) GetReviews(filter types.ReviewFilter) ([]types.Review, error) {
+	m.filter = filter
+	reviews := []types.Review{}
+	for _, r := range m.reviews {
+		if (filter.ProductID == 0 || r.ProductID == filter.ProductID) && (filter.UserID == 0 || r.UserID == filter.UserID) && (filter.Status == "" || r.Status == filter.Status) {
+			reviews = append(reviews, r)
+		}
+	}
+	return reviews, nil
+}
+func (m *mockReviewStore) GetReviewByID(id int) (*types.Review, error) {
+	for _, r := range m.reviews {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, fmt.Errorf("review not found")
+}
+func (m *mockReviewStore) SetReviewStatus(id int, status string) error {
+	m.reviews[id-1].Status = status
+	return nil
+}
+func (m *mockReviewStore) ReplyToReview(id int, reply string, at time.Time) error {
+	m.reviews[id-1].Reply = reply
+	m.reviews[id-1].RepliedAt = &at
+	return nil
+}
@@ -0,0 +1,178 @@
+package reviews
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Signature of Review Store
+// CreateReview(types.Review) (int64, error)
+// HasPurchased(userID int, productID int) (bool, error)
+// GetReviews(types.ReviewFilter) ([]types.Review, error)
+// GetReviewByID(int) (*types.Review, error)
+// SetReviewStatus(id int, status string) error
+// ReplyToReview(id int, reply string, at time.Time) error
+//
+// products.rating and products.reviewCount are the aggregate of the approved
+// reviews, they are updated with the status in the same transaction.
+
+func (s *Store) CreateReview(review types.Review) (int64, error) {
+	if review.Photos == nil {
+		review.Photos = []string{}
+	}
+	photos, err := json.Marshal(review.Photos)
+	if err != nil {
+		return 0, err
+	}
+	res, err := s.db.Exec(
+		"INSERT INTO reviews (productId, userId, rating, body, photos, status) VALUES (?, ?, ?, ?, ?, ?)",
+		review.ProductID, review.UserID, review.Rating, review.Body, string(photos), types.ReviewPending,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// HasPurchased is true when the user has an order of the product that wasn't
+// cancelled.
+func (s *Store) HasPurchased(userID int, productID int) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM order_items oi JOIN orders o ON o.id = oi.orderId WHERE o.userId = ? AND oi.productId = ? AND o.status <> 'cancelled' AND o.deletedAt IS NULL)",
+		userID, productID,
+	).Scan(&exists)
+	return exists, err
+}
+
+func (s *Store) GetReviews(filter types.ReviewFilter) ([]types.Review, error) {
+	var conditions []string
+	var args []any
+	if filter.ProductID != 0 {
+		conditions = append(conditions, "productId = ?")
+		args = append(args, filter.ProductID)
+	}
+	if filter.UserID != 0 {
+		conditions = append(conditions, "userId = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	query := "SELECT * FROM reviews"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	reviews := make([]types.Review, 0)
+	for rows.Next() {
+		review, err := scanRowIntoReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, *review)
+	}
+	return reviews, rows.Err()
+}
+
+func (s *Store) GetReviewByID(id int) (*types.Review, error) {
+	rows, err := s.db.Query("SELECT * FROM reviews WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, fmt.Errorf("review not found")
+	}
+	return scanRowIntoReview(rows)
+}
+
+func (s *Store) SetReviewStatus(id int, status string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var productID int
+	if err := tx.QueryRow("SELECT productId FROM reviews WHERE id = ? FOR UPDATE", id).Scan(&productID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("review not found")
+		}
+		return err
+	}
+	if _, err := tx.Exec("UPDATE reviews SET status = ? WHERE id = ?", status, id); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE products SET rating = (SELECT COALESCE(AVG(rating), 0) FROM reviews WHERE productId = ? AND status = 'approved'), reviewCount = (SELECT COUNT(*) FROM reviews WHERE productId = ? AND status = 'approved') WHERE id = ?",
+		productID, productID, productID,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) ReplyToReview(id int, reply string, at time.Time) error {
+	res, err := s.db.Exec("UPDATE reviews SET reply = ?, repliedAt = ? WHERE id = ?", reply, at, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("review not found")
+	}
+	return nil
+}
+
+func scanRowIntoReview(rows *sql.Rows) (*types.Review, error) {
+	review := new(types.Review)
+	var photos string
+	var reply sql.NullString
+	err := rows.Scan(
+		&review.ID,
+		&review.ProductID,
+		&review.UserID,
+		&review.Rating,
+		&review.Body,
+		&photos,
+		&review.Status,
+		&reply,
+		&review.RepliedAt,
+		&review.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	review.Reply = reply.String
+	if err := json.Unmarshal([]byte(photos), &review.Photos); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
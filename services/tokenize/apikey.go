@@ -0,0 +1,111 @@
+package tokenize
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+var errInvalidAPIKeyPayload = errors.New("invalid api key")
+
+// issueAPIKey stores the hash of a new key, the plain key is only handed back
+// so it can be shown to the admin once.
+func issueAPIKey(store types.APIKeyStore, payload types.CreateAPIKeyPayload, createdBy int, now time.Time) (*types.APIKey, string, error) {
+	for _, scope := range payload.Scopes {
+		if !auth.ValidScope(scope) {
+			return nil, "", fmt.Errorf("%w: invalid scope %q", errInvalidAPIKeyPayload, scope)
+		}
+	}
+	if payload.ExpiresAt != nil && !payload.ExpiresAt.After(now) {
+		return nil, "", fmt.Errorf("%w: expires_at must be in the future", errInvalidAPIKeyPayload)
+	}
+
+	key, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	apiKey := types.APIKey{
+		UserID:    payload.UserID,
+		Name:      payload.Name,
+		Prefix:    prefix,
+		KeyHash:   auth.HashToken(key),
+		Scopes:    payload.Scopes,
+		ExpiresAt: payload.ExpiresAt,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+	}
+	id, err := store.CreateAPIKey(apiKey)
+	if err != nil {
+		return nil, "", err
+	}
+	apiKey.ID = id
+	return &apiKey, key, nil
+}
+
+func (h *Handler) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleCreateAPIKey")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.CreateAPIKeyPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	apiKey, key, err := h.service.CreateAPIKey(r.Context(), payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.ResponseAPIKey{APIKey: apiKey, Key: key})
+}
+
+func (h *Handler) handleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetAPIKeys")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	keys, err := h.service.GetAPIKeys(r.Context())
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, keys)
+}
+
+func (h *Handler) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleRevokeAPIKey")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	apiKeyID, err := strconv.Atoi(mux.Vars(r)["api_key_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid api key id"))
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), apiKeyID); err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
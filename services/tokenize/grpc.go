@@ -1,17 +1,193 @@
 package tokenize
 
 import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
 )
 
+// HandlerServer is the gRPC transport of the tokens and the API keys, it only
+// converts the messages of the service.
 type HandlerServer struct {
 	pb.UnimplementedTokenServiceServer
 	service types.TokenService
 }
 
+func NewServer(service types.TokenService) *HandlerServer {
+	return &HandlerServer{service: service}
+}
+
 func NewHandlerServer(grpcServer *grpc.Server, service types.TokenService) {
-	handler := &HandlerServer{service: service}
-	pb.RegisterTokenServiceServer(grpcServer, handler)
+	pb.RegisterTokenServiceServer(grpcServer, NewServer(service))
+}
+
+func (h *HandlerServer) GetBlacklistedTokens(ctx context.Context, req *pb.GetBlacklistedTokensRequest) (*pb.GetBlacklistedTokensResponse, error) {
+	tokens, err := h.service.GetBlacklistedTokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokensPB := make([]*pb.Token, 0, len(tokens))
+	for _, t := range tokens {
+		tokensPB = append(tokensPB, tokenToPB(t))
+	}
+	return &pb.GetBlacklistedTokensResponse{Tokens: tokensPB}, nil
+}
+
+func (h *HandlerServer) CreateBlacklistToken(ctx context.Context, req *pb.CreateBlacklistTokenRequest) (*pb.CreateBlacklistTokenResponse, error) {
+	if _, err := h.service.CreateBlacklistToken(ctx, types.Token{Token: req.GetToken().GetToken()}); err != nil {
+		return nil, err
+	}
+	return &pb.CreateBlacklistTokenResponse{}, nil
+}
+
+func (h *HandlerServer) GetBlacklistTokenByString(ctx context.Context, req *pb.GetBlacklistTokenByStringRequest) (*pb.GetBlacklistTokenByStringResponse, error) {
+	token, err := h.service.GetBlacklistTokenByString(ctx, req.GetToken())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetBlacklistTokenByStringResponse{Token: tokenToPB(*token)}, nil
+}
+
+func (h *HandlerServer) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	payload := types.CreateAPIKeyPayload{UserID: int(req.GetUserId()), Name: req.GetName(), Scopes: req.GetScopes()}
+	if req.GetExpiresAt() != 0 {
+		expiresAt := time.Unix(req.GetExpiresAt(), 0)
+		payload.ExpiresAt = &expiresAt
+	}
+	apiKey, key, err := h.service.CreateAPIKey(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateAPIKeyResponse{ApiKey: apiKeyToPB(*apiKey), Key: key}, nil
+}
+
+func (h *HandlerServer) GetAPIKeys(ctx context.Context, req *pb.GetAPIKeysRequest) (*pb.GetAPIKeysResponse, error) {
+	keys, err := h.service.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keysPB := make([]*pb.APIKey, 0, len(keys))
+	for _, k := range keys {
+		keysPB = append(keysPB, apiKeyToPB(k))
+	}
+	return &pb.GetAPIKeysResponse{ApiKeys: keysPB}, nil
+}
+
+func (h *HandlerServer) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error) {
+	if err := h.service.RevokeAPIKey(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+	return &pb.RevokeAPIKeyResponse{}, nil
+}
+
+func tokenToPB(t types.Token) *pb.Token {
+	return &pb.Token{
+		Id:        int32(t.ID),
+		Token:     t.Token,
+		CreatedAt: t.CreatedAt.Unix(),
+	}
+}
+
+func apiKeyToPB(k types.APIKey) *pb.APIKey {
+	apiKeyPB := &pb.APIKey{
+		Id:        int32(k.ID),
+		UserId:    int32(k.UserID),
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedBy: int32(k.CreatedBy),
+		CreatedAt: k.CreatedAt.Unix(),
+	}
+	if k.ExpiresAt != nil {
+		apiKeyPB.ExpiresAt = k.ExpiresAt.Unix()
+	}
+	if k.LastUsedAt != nil {
+		apiKeyPB.LastUsedAt = k.LastUsedAt.Unix()
+	}
+	if k.RevokedAt != nil {
+		apiKeyPB.RevokedAt = k.RevokedAt.Unix()
+	}
+	return apiKeyPB
+}
+
+// AuthHandlerServer is the gRPC transport of the logins, the login guard
+// counts the failures of the address of the peer.
+type AuthHandlerServer struct {
+	pb.UnimplementedAuthServiceServer
+	service types.AuthService
+}
+
+func NewAuthServer(service types.AuthService) *AuthHandlerServer {
+	return &AuthHandlerServer{service: service}
+}
+
+func NewAuthHandlerServer(grpcServer *grpc.Server, service types.AuthService) {
+	pb.RegisterAuthServiceServer(grpcServer, NewAuthServer(service))
+}
+
+func (h *AuthHandlerServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	payload := types.LoginUserPayload{Email: req.GetEmail(), Password: req.GetPassword()}
+	response, err := h.service.Login(ctx, payload, peerIP(ctx), mailer.Locale(req.GetLocale()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.LoginResponse{
+		AccessToken:          response.AccessToken,
+		SecretToken:          response.SecretToken,
+		MfaRequired:          response.MFARequired,
+		MfaEnrolmentRequired: response.MFAEnrolmentRequired,
+		MfaToken:             response.MFAToken,
+	}, nil
+}
+
+func (h *AuthHandlerServer) LoginMFA(ctx context.Context, req *pb.LoginMFARequest) (*pb.LoginMFAResponse, error) {
+	payload := types.LoginMFAPayload{MFAToken: req.GetMfaToken(), Code: req.GetCode()}
+	response, err := h.service.LoginMFA(ctx, payload, peerIP(ctx), mailer.Locale(req.GetLocale()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.LoginMFAResponse{
+		AccessToken:   response.AccessToken,
+		SecretToken:   response.SecretToken,
+		RecoveryCodes: response.RecoveryCodes,
+	}, nil
+}
+
+func (h *AuthHandlerServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.RefreshResponse, error) {
+	refreshed, err := h.service.Refresh(ctx, types.RefreshTokenPayload{AccessToken: req.GetAccessToken(), SecretToken: req.GetSecretToken()})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RefreshResponse{AccessToken: refreshed.AccessToken, SecretToken: refreshed.SecretToken}, nil
+}
+
+func (h *AuthHandlerServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	if err := h.service.Logout(ctx, types.RefreshTokenPayload{AccessToken: req.GetAccessToken(), SecretToken: req.GetSecretToken()}); err != nil {
+		return nil, err
+	}
+	return &pb.LogoutResponse{}, nil
+}
+
+// peerIP is the address of the client of the call without its port, the
+// audit interceptor already read it from the x-forwarded-for of the gateway.
+func peerIP(ctx context.Context) string {
+	if ip := audit.RequestInfoFromContext(ctx).IP; ip != "" {
+		return ip
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
 }
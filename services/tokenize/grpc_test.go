@@ -0,0 +1,93 @@
+package tokenize
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/loginguard"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthServer(t *testing.T) {
+	hashedPassword, _ := auth.HashPassword("asd")
+	userStore := &mockUserStore{users: map[string]*types.User{
+		"user@gmail.com":       {ID: 1, Email: "user@gmail.com", Password: hashedPassword, Verified: true, Role: "customer"},
+		"unverified@gmail.com": {ID: 2, Email: "unverified@gmail.com", Password: hashedPassword, Role: "customer"},
+	}}
+	service := NewService(&mockTokenStore{}, userStore, &mockMFAStore{}, nil)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	service.now = clock
+	service.sleep = func(time.Duration) {}
+	service.guard = loginguard.New(loginguard.NewMemoryStore(clock), loginguard.Policy{
+		MaxAccountFailures: 2,
+		MaxIPFailures:      100,
+		MaxIPAccounts:      100,
+		Window:             time.Minute * 15,
+		Lockout:            time.Minute * 15,
+	})
+	service.guard.Now = clock
+	server := NewAuthServer(service)
+	// a locked account is mailed its unlock link
+	captureMail(t)
+
+	// the login guard counts the failures of the address of the peer
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 51000}})
+	login := func(email string, password string) (*pb.LoginResponse, error) {
+		return server.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	}
+
+	t.Run("should give the tokens of a verified user", func(t *testing.T) {
+		response, err := login("user@gmail.com", "asd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.GetAccessToken() == "" || response.GetSecretToken() == "" {
+			t.Errorf("expected the tokens, got %+v", response)
+		}
+	})
+	t.Run("should refuse an unverified email", func(t *testing.T) {
+		if _, err := login("unverified@gmail.com", "asd"); status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected %v, got %v", codes.FailedPrecondition, err)
+		}
+	})
+	t.Run("should tell when to try again once locked", func(t *testing.T) {
+		if _, err := login("user@gmail.com", "wrong"); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected %v, got %v", codes.InvalidArgument, err)
+		}
+		login("user@gmail.com", "wrong")
+		_, err := login("user@gmail.com", "asd")
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("expected %v, got %v", codes.ResourceExhausted, err)
+		}
+		var retryAfter time.Duration
+		for _, detail := range status.Convert(err).Details() {
+			if retry, ok := detail.(*errdetails.RetryInfo); ok {
+				retryAfter = retry.GetRetryDelay().AsDuration()
+			}
+		}
+		if retryAfter <= 0 || retryAfter > time.Minute*15 {
+			t.Errorf("expected the lockout in the retry info, got %v", retryAfter)
+		}
+	})
+	t.Run("should refuse an invalid mfa token", func(t *testing.T) {
+		_, err := server.LoginMFA(ctx, &pb.LoginMFARequest{MfaToken: "invalid", Code: "123456"})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("expected %v, got %v", codes.Unauthenticated, err)
+		}
+	})
+	t.Run("should refuse a logout without a user", func(t *testing.T) {
+		_, err := server.Logout(ctx, &pb.LogoutRequest{AccessToken: "access", SecretToken: "secret"})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("expected %v, got %v", codes.Unauthenticated, err)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package tokenize
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+)
+
+// issueVerificationToken revokes the pending verification tokens of the email
+// and stores the hash of a new one, the plain token is only handed back so it
+// can be mailed to the user.
+func (h *Handler) issueVerificationToken(email string) (string, error) {
+	token, err := mailer.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	if err := h.store.RevokeVerificationTokensByEmail(email); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	err = h.store.CreateVerificationToken(types.VerificationToken{
+		Email:     email,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: now.Add(time.Second * time.Duration(config.Envs.EmailVerificationExpirationInSeconds)),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func isVerificationTokenUsable(t *types.VerificationToken, now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// issuePasswordResetToken revokes the pending reset tokens of the user and
+// stores the hash of a new one, like issueVerificationToken.
+func (h *Handler) issuePasswordResetToken(userID int) (string, error) {
+	token, err := mailer.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	if err := h.store.RevokePasswordResetTokensByUserID(userID); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	err = h.store.CreatePasswordResetToken(types.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: now.Add(time.Second * time.Duration(config.Envs.PasswordResetExpirationInSeconds)),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func isPasswordResetTokenUsable(t *types.PasswordResetToken, now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// updatePassword stores the new password hash and revokes every session and
+// token of the user, including pending reset tokens.
+func (h *Handler) updatePassword(userID int, password string) error {
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := h.userStore.UpdatePasswordByUserID(userID, hashedPassword); err != nil {
+		return err
+	}
+	if err := h.store.RevokePasswordResetTokensByUserID(userID); err != nil {
+		return err
+	}
+	return h.store.RevokeUserTokens(userID)
+}
+
+var errInvalidCredentials = errors.New("invalid email or password")
+
+var (
+	dummyPasswordHashOnce sync.Once
+	dummyPasswordHashVal  string
+)
+
+// dummyPasswordHash is compared against when the email is unknown, so the
+// login takes as long as for a wrong password.
+func dummyPasswordHash() string {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHashVal, _ = auth.HashPassword("dummy-password-for-unknown-emails")
+	})
+	return dummyPasswordHashVal
+}
+
+// checkLoginGuard is ResourceExhausted while the account or the address is
+// locked, otherwise it applies the progressive delay of the previous
+// failures.
+func (s *Service) checkLoginGuard(ctx context.Context, email string, ip string) error {
+	retryAfter, delay, err := s.guard.Check(ctx, email, ip)
+	if err != nil {
+		log.Printf("failed to check login guard for %v: %v", ip, err)
+		return nil
+	}
+	if retryAfter > 0 {
+		return utils.RetryAfterError("too many failed login attempts, please try again later", retryAfter)
+	}
+	s.sleep(delay)
+	return nil
+}
+
+// failLogin counts the failure and emails the unlock link when the account
+// of an existing user just got locked, the answer to the client is unchanged.
+func (s *Service) failLogin(ctx context.Context, locale string, email string, ip string, u *types.User) {
+	locked, err := s.guard.Fail(ctx, email, ip)
+	if err != nil {
+		log.Printf("failed to record login failure for %v: %v", ip, err)
+		return
+	}
+	if !locked || u == nil {
+		return
+	}
+	token, err := s.guard.IssueUnlockToken(ctx, u.Email)
+	if err != nil {
+		log.Printf("failed to issue unlock token for %v: %v", u.Email, err)
+		return
+	}
+	// sent in the background so existing accounts don't answer slower
+	go func() {
+		if err := mailer.SendAccountUnlockEmail(locale, u.Email, token); err != nil {
+			log.Printf("error sending unlock email to %v: %v", u.Email, err)
+		}
+	}()
+}
+
+// finishLogin finishes the login of u with response, the second step is
+// required when the user enabled 2FA, admins must always enrol.
+func (s *Service) finishLogin(u *types.User, response types.ResponseLogin) (*types.ResponseLogin, error) {
+	mfa, err := s.mfaStore.GetUserMFA(u.ID)
+	mfaEnabled := err == nil && mfa.Enabled
+	if mfaEnabled || u.Role == "admin" {
+		mfaToken, err := auth.CreateMFAPendingJWT(u.ID, s.now())
+		if err != nil {
+			return nil, err
+		}
+		response.MFARequired = true
+		response.MFAEnrolmentRequired = !mfaEnabled
+		response.MFAToken = mfaToken
+		return &response, nil
+	}
+
+	accessToken, secretToken, err := createJWT(u)
+	if err != nil {
+		return nil, err
+	}
+	response.AccessToken = accessToken
+	response.SecretToken = secretToken
+	return &response, nil
+}
+
+// createJWT signs the access token and the secret token of u.
+func createJWT(u *types.User) (string, string, error) {
+	secret := []byte(config.Envs.JWTSecret)
+	access := []byte(config.Envs.JWTRefresh)
+	return auth.CreateJWT(access, secret, u.ID, u.Role, u.FirstName+" "+u.LastName, u.Email, u.PhoneNumber, u.Address)
+}
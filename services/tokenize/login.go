@@ -0,0 +1,178 @@
+package tokenize
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Login checks the password of the email, the answer holds the tokens or the
+// pending mfa token of the second step. Unverified emails are a
+// FailedPrecondition and locked accounts a ResourceExhausted telling when to
+// try again.
+func (s *Service) Login(ctx context.Context, payload types.LoginUserPayload, ip string, locale string) (*types.ResponseLogin, error) {
+	if err := utils.Validate.Struct(payload); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid payload %v", err)
+	}
+	if err := s.checkLoginGuard(ctx, payload.Email, ip); err != nil {
+		return nil, err
+	}
+
+	// unknown emails still pay for a bcrypt comparison and get the same answer
+	u, err := s.userStore.GetUserByEmail(payload.Email)
+	passwordHash := dummyPasswordHash()
+	if err == nil {
+		passwordHash = u.Password
+	} else {
+		u = nil
+	}
+	if !auth.ComparePasswords(passwordHash, []byte(payload.Password)) || err != nil {
+		s.failLogin(ctx, locale, payload.Email, ip, u)
+		return nil, status.Error(codes.InvalidArgument, errInvalidCredentials.Error())
+	}
+
+	if err := s.guard.Succeed(ctx, u.Email); err != nil {
+		log.Printf("failed to reset login failures of %v: %v", u.Email, err)
+	}
+
+	if !u.Verified {
+		return nil, status.Error(codes.FailedPrecondition, "email not verified, please verify your email first")
+	}
+
+	return s.finishLogin(u, types.ResponseLogin{})
+}
+
+// LoginMFA finishes a login with the TOTP or a recovery code of the user, the
+// first code of an enrolment started from the login enables 2FA and the
+// recovery codes are handed back.
+func (s *Service) LoginMFA(ctx context.Context, payload types.LoginMFAPayload, ip string, locale string) (*types.ResponseLoginMFA, error) {
+	if err := utils.Validate.Struct(payload); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid payload %v", err)
+	}
+
+	userID, err := auth.ValidateMFAPendingJWT(payload.MFAToken, s.now())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	u, err := s.userStore.GetUserByID(userID)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid mfa token")
+	}
+
+	mfa, err := s.mfaStore.GetUserMFA(u.ID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "two-factor authentication enrolment required")
+	}
+
+	// wrong codes count as failed logins of the account
+	if err := s.checkLoginGuard(ctx, u.Email, ip); err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	if mfa.Enabled {
+		if !s.checkMFACode(mfa, payload.Code) {
+			s.failLogin(ctx, locale, u.Email, ip, u)
+			return nil, status.Error(codes.Unauthenticated, "invalid code")
+		}
+	} else {
+		// first code of an enrolment started from the login, e.g. for admins
+		recoveryCodes, err = s.confirmMFA(u.ID, payload.Code)
+		if err != nil {
+			s.failLogin(ctx, locale, u.Email, ip, u)
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+	}
+
+	if err := s.guard.Succeed(ctx, u.Email); err != nil {
+		log.Printf("failed to reset login failures of %v: %v", u.Email, err)
+	}
+
+	accessToken, secretToken, err := createJWT(u)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ResponseLoginMFA{AccessToken: accessToken, SecretToken: secretToken, RecoveryCodes: recoveryCodes}, nil
+}
+
+// Refresh blacklists the access token and signs a new one with the claims of
+// the secret token, the secret token is kept.
+func (s *Service) Refresh(ctx context.Context, payload types.RefreshTokenPayload) (*types.RefreshTokenPayload, error) {
+	if err := s.checkNotBlacklisted(payload.SecretToken); err != nil {
+		return nil, err
+	}
+
+	// Blacklist the token
+	if _, err := s.store.CreateBlacklistTokens(types.Token{Token: payload.AccessToken}); err != nil {
+		return nil, err
+	}
+
+	token, err := validateRefreshToken(payload.SecretToken)
+	if err != nil || !token.Valid {
+		return nil, status.Error(codes.Unauthenticated, "invalid jwt secret")
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	userIDClaim, _ := claims["userID"].(string)
+	userID, _ := strconv.Atoi(userIDClaim)
+	if auth.IsTokenRevoked(token, userID, s.store) {
+		return nil, status.Error(codes.Unauthenticated, "revoked token jwt secret")
+	}
+	userRole, _ := claims["userRole"].(string)
+	userName, _ := claims["userName"].(string)
+	userEmail, _ := claims["userEmail"].(string)
+	userPhoneNumber, _ := claims["userPhoneNumber"].(string)
+	userAddress, _ := claims["userAddress"].(string)
+
+	newAccessToken, _, err := auth.CreateJWT([]byte(config.Envs.JWTRefresh), []byte(nil), userID, userRole, userName, userEmail, userPhoneNumber, userAddress)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return &types.RefreshTokenPayload{AccessToken: newAccessToken, SecretToken: payload.SecretToken}, nil
+}
+
+// Logout blacklists both tokens of the signed in user.
+func (s *Service) Logout(ctx context.Context, payload types.RefreshTokenPayload) error {
+	if auth.GetUserIDFromContext(ctx) <= 0 {
+		return status.Error(codes.Unauthenticated, "sign in to logout")
+	}
+	if err := s.checkNotBlacklisted(payload.SecretToken); err != nil {
+		return err
+	}
+	if _, err := s.store.CreateBlacklistTokens(types.Token{Token: payload.SecretToken}); err != nil {
+		return err
+	}
+	if _, err := s.store.CreateBlacklistTokens(types.Token{Token: payload.AccessToken}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) checkNotBlacklisted(secretToken string) error {
+	t, err := s.store.GetBlacklistTokenByString(secretToken)
+	if err == nil && t != nil && t.Token == secretToken {
+		return status.Error(codes.Unauthenticated, "blacklisted token jwt secret")
+	}
+	return nil
+}
+
+// writeLoginError is WriteStatusError but an unverified email is forbidden,
+// the web tells it apart from wrong credentials.
+func writeLoginError(w http.ResponseWriter, err error) {
+	if status.Code(err) == codes.FailedPrecondition {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("%s", status.Convert(err).Message()))
+		return
+	}
+	utils.WriteStatusError(w, err)
+}
@@ -0,0 +1,284 @@
+package tokenize
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/go-playground/validator"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+var errMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+// enrolMFA stores a new secret for the user until the first code confirms it.
+func (s *Service) enrolMFA(u *types.User) (*types.ResponseMFAEnrolment, error) {
+	if mfa, err := s.mfaStore.GetUserMFA(u.ID); err == nil && mfa.Enabled {
+		return nil, errMFAAlreadyEnabled
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaStore.CreateUserMFA(types.UserMFA{UserID: u.ID, Secret: secret, CreatedAt: s.now()}); err != nil {
+		return nil, err
+	}
+	return &types.ResponseMFAEnrolment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(secret, config.Envs.MFAIssuer, u.Email),
+	}, nil
+}
+
+// confirmMFA enables a pending enrolment with its first valid code and hands
+// back the recovery codes, they are only shown this once.
+func (s *Service) confirmMFA(userID int, code string) ([]string, error) {
+	mfa, err := s.mfaStore.GetUserMFA(userID)
+	if err != nil {
+		return nil, fmt.Errorf("two-factor authentication is not enrolled")
+	}
+	if mfa.Enabled {
+		return nil, errMFAAlreadyEnabled
+	}
+	if !s.checkTOTP(mfa, code) {
+		return nil, fmt.Errorf("invalid code")
+	}
+	if err := s.mfaStore.EnableUserMFA(userID, s.now()); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(userID)
+}
+
+func (s *Service) issueRecoveryCodes(userID int) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashToken(code)
+	}
+	if err := s.mfaStore.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkTOTP accepts a code only once, the matching time step is recorded.
+func (s *Service) checkTOTP(mfa *types.UserMFA, code string) bool {
+	step, ok := auth.ValidateTOTP(mfa.Secret, code, s.now())
+	if !ok || step <= mfa.LastUsedStep {
+		return false
+	}
+	used, err := s.mfaStore.UseUserMFAStep(mfa.UserID, step)
+	return err == nil && used == 1
+}
+
+// checkMFACode accepts either a code from the authenticator or an unused recovery code.
+func (s *Service) checkMFACode(mfa *types.UserMFA, code string) bool {
+	if s.checkTOTP(mfa, code) {
+		return true
+	}
+	used, err := s.mfaStore.UseRecoveryCode(mfa.UserID, auth.HashToken(auth.NormalizeRecoveryCode(code)), s.now())
+	return err == nil && used == 1
+}
+
+func (h *Handler) handleLoginMFA(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleLoginMFA")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.LoginMFAPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.login().LoginMFA(r.Context(), payload, utils.ClientIP(r), mailer.Locale(r.Header.Get("Accept-Language")))
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleLoginMFAEnroll(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleLoginMFAEnroll")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.MFAPendingPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errv := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
+		return
+	}
+
+	userID, err := auth.ValidateMFAPendingJWT(payload.MFAToken, h.now())
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	u, err := h.userStore.GetUserByID(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid mfa token"))
+		return
+	}
+
+	h.writeEnrolment(w, u)
+}
+
+func (h *Handler) handleEnrollMFA(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleEnrollMFA")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	u, err := h.userStore.GetUserByID(auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+		return
+	}
+
+	h.writeEnrolment(w, u)
+}
+
+func (h *Handler) writeEnrolment(w http.ResponseWriter, u *types.User) {
+	enrolment, err := h.login().enrolMFA(u)
+	if err == errMFAAlreadyEnabled {
+		utils.WriteError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, enrolment)
+}
+
+func (h *Handler) handleVerifyMFA(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleVerifyMFA")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.MFACodePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errv := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
+		return
+	}
+
+	codes, err := h.login().confirmMFA(auth.GetUserIDFromContext(r.Context()), payload.Code)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ResponseRecoveryCodes{RecoveryCodes: codes})
+}
+
+func (h *Handler) handleDisableMFA(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleDisableMFA")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	if auth.GetUserRoleFromContext(r.Context()) == "admin" {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("two-factor authentication is mandatory for admin"))
+		return
+	}
+
+	var payload types.MFACodePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errv := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
+		return
+	}
+
+	mfa, err := h.mfaStore.GetUserMFA(auth.GetUserIDFromContext(r.Context()))
+	if err != nil || !mfa.Enabled {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("two-factor authentication is not enabled"))
+		return
+	}
+
+	if !h.login().checkMFACode(mfa, payload.Code) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid code"))
+		return
+	}
+
+	if err := h.mfaStore.DisableUserMFA(mfa.UserID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleRegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleRegenerateRecoveryCodes")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.MFACodePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errv := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
+		return
+	}
+
+	mfa, err := h.mfaStore.GetUserMFA(auth.GetUserIDFromContext(r.Context()))
+	if err != nil || !mfa.Enabled {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("two-factor authentication is not enabled"))
+		return
+	}
+
+	if !h.login().checkTOTP(mfa, payload.Code) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid code"))
+		return
+	}
+
+	codes, err := h.login().issueRecoveryCodes(mfa.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ResponseRecoveryCodes{RecoveryCodes: codes})
+}
@@ -0,0 +1,269 @@
+package tokenize
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
+	"github.com/fayleenpc/tj-jeans/internal/oidclogin"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+var (
+	errUnverifiedIdentityEmail = errors.New("the login provider has not verified the email of this account")
+	// errDeletedAccountEmail is given when signing up with the email of an
+	// account in the trash, it is freed once the account is purged.
+	errDeletedAccountEmail = errors.New("the account with this email was deleted, contact us to restore it")
+)
+
+// userForIdentity returns the user linked to the identity, a new identity is
+// linked to the user with the same email, or to a new user, but only when
+// the provider verified the email.
+func (h *Handler) userForIdentity(ctx context.Context, provider string, claims *oidclogin.Claims) (*types.User, error) {
+	now := h.now()
+	if identity, err := h.identityStore.GetUserIdentity(provider, claims.Subject); err == nil {
+		if err := h.identityStore.UpdateUserIdentityLogin(identity.ID, claims.Email, now); err != nil {
+			log.Printf("failed to record login of identity %v: %v", identity.ID, err)
+		}
+		return h.userStore.GetUserByID(identity.UserID)
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errUnverifiedIdentityEmail
+	}
+
+	u, err := h.userStore.GetUserByEmail(claims.Email)
+	if err != nil {
+		if u, err = h.createIdentityUser(ctx, claims); err != nil {
+			return nil, err
+		}
+	} else if !u.Verified {
+		if err := h.claimUnverifiedUser(u); err != nil {
+			return nil, err
+		}
+	}
+
+	err = h.identityStore.CreateUserIdentity(types.UserIdentity{
+		UserID:      u.ID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		CreatedAt:   now,
+		LastLoginAt: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// claimUnverifiedUser hands an unverified account to the owner of the email
+// the provider proved. Anyone could have registered it first, so its password
+// is replaced with a random one and whatever was issued for it is revoked,
+// like for createIdentityUser the user resets the password to use one.
+func (h *Handler) claimUnverifiedUser(u *types.User) error {
+	password, err := mailer.GenerateToken()
+	if err != nil {
+		return err
+	}
+	if err := h.updatePassword(u.ID, password); err != nil {
+		return err
+	}
+	if err := h.store.RevokeVerificationTokensByEmail(u.Email); err != nil {
+		return err
+	}
+	if err := h.userStore.UpdateVerifiedUserByEmail(u.Email); err != nil {
+		return err
+	}
+	u.Verified = true
+	return nil
+}
+
+// createIdentityUser registers a customer from the claims, the phone number
+// and address are filled in later. The password is random so the account
+// can only be used through the provider until the user resets it.
+func (h *Handler) createIdentityUser(ctx context.Context, claims *oidclogin.Claims) (*types.User, error) {
+	password, err := mailer.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	firstName, lastName := identityName(claims)
+	err = h.userStore.CreateUser(types.User{
+		FirstName:   firstName,
+		LastName:    lastName,
+		Email:       claims.Email,
+		Password:    hashedPassword,
+		PhoneNumber: claims.PhoneNumber,
+		Verified:    true,
+		Role:        "customer",
+	})
+	if errors.Is(err, types.ErrEmailTaken) {
+		return nil, errDeletedAccountEmail
+	}
+	if err != nil {
+		return nil, err
+	}
+	u, err := h.userStore.GetUserByEmail(claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.ActionCreate, audit.EntityUser, u.ID, nil, u)
+	return u, nil
+}
+
+func identityName(claims *oidclogin.Claims) (string, string) {
+	if claims.GivenName != "" || claims.FamilyName != "" {
+		return claims.GivenName, claims.FamilyName
+	}
+	firstName, lastName, _ := strings.Cut(strings.TrimSpace(claims.Name), " ")
+	return firstName, strings.TrimSpace(lastName)
+}
+
+// fillProfile copies the claims into the profile fields the user has left
+// empty, it reports whether the profile is still missing anything needed to
+// checkout.
+func (h *Handler) fillProfile(ctx context.Context, u *types.User, claims *oidclogin.Claims) bool {
+	oldUser := *u
+	firstName, lastName := identityName(claims)
+	changed := false
+	if u.FirstName == "" && firstName != "" {
+		u.FirstName, changed = firstName, true
+	}
+	if u.LastName == "" && lastName != "" {
+		u.LastName, changed = lastName, true
+	}
+	if u.PhoneNumber == "" && claims.PhoneNumber != "" {
+		u.PhoneNumber, changed = claims.PhoneNumber, true
+	}
+	if changed {
+		if _, err := h.userStore.UpdateUser(*u); err != nil {
+			log.Printf("failed to fill in the profile of user %v: %v", u.ID, err)
+		} else {
+			audit.Record(ctx, audit.ActionUpdate, audit.EntityUser, u.ID, oldUser, u)
+		}
+	}
+	return u.PhoneNumber == "" || u.Address == ""
+}
+
+func (h *Handler) handleOIDCProviders(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleOIDCProviders")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	utils.WriteJSON(w, http.StatusOK, types.ResponseOIDCProviders{Providers: h.oidc.Names()})
+}
+
+func (h *Handler) handleOIDCAuthorize(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleOIDCAuthorize")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	name := mux.Vars(r)["provider"]
+	provider, err := h.oidc.Get(r.Context(), name)
+	if errors.Is(err, oidclogin.ErrUnknownProvider) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	flow, err := oidclogin.NewFlow(name)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	flowToken, err := flow.Token(h.now())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ResponseOIDCAuthorization{AuthorizationURL: provider.AuthCodeURL(flow), FlowToken: flowToken})
+}
+
+func (h *Handler) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleOIDCCallback")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.OIDCCallbackPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errv := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
+		return
+	}
+
+	name := mux.Vars(r)["provider"]
+	flow, err := oidclogin.ParseFlowToken(payload.FlowToken, h.now())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	// the state ties the callback to the browser that started the login
+	if flow.Provider != name || subtle.ConstantTimeCompare([]byte(flow.State), []byte(payload.State)) != 1 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid login flow"))
+		return
+	}
+
+	provider, err := h.oidc.Get(r.Context(), name)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	claims, err := provider.Exchange(r.Context(), payload.Code, flow)
+	if err != nil {
+		log.Printf("failed to login with %v: %v", name, err)
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("login with %v failed", name))
+		return
+	}
+
+	u, err := h.userForIdentity(r.Context(), name, claims)
+	if errors.Is(err, errUnverifiedIdentityEmail) {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+	if errors.Is(err, errDeletedAccountEmail) {
+		utils.WriteError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := h.login().finishLogin(u, types.ResponseLogin{ProfileIncomplete: h.fillProfile(r.Context(), u, claims)})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, response)
+}
@@ -1,14 +1,19 @@
 package tokenize
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/fayleenpc/tj-jeans/internal/audit"
 	"github.com/fayleenpc/tj-jeans/internal/auth"
 	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/loginguard"
 	"github.com/fayleenpc/tj-jeans/internal/mailer"
+	"github.com/fayleenpc/tj-jeans/internal/oidclogin"
 	"github.com/fayleenpc/tj-jeans/internal/ratelimiter"
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	"github.com/fayleenpc/tj-jeans/internal/utils"
@@ -21,13 +26,44 @@ import (
 )
 
 type Handler struct {
-	store      types.TokenStore
-	userStore  types.UserStore
-	redisStore *redis.Client
+	store         types.TokenStore
+	userStore     types.UserStore
+	mfaStore      types.MFAStore
+	identityStore types.IdentityStore
+	redisStore    *redis.Client
+	guard         *loginguard.Guard
+	oidc          *oidclogin.Providers
+	service       *Service
+	// now is the clock used for one time codes and pending tokens, tests replace it
+	now func() time.Time
+	// sleep applies the progressive delay of the login guard, tests replace it
+	sleep func(time.Duration)
 }
 
-func NewHandler(store types.TokenStore, userStore types.UserStore, redisStore *redis.Client) *Handler {
-	return &Handler{store: store, userStore: userStore, redisStore: redisStore}
+func NewHandler(store types.TokenStore, userStore types.UserStore, mfaStore types.MFAStore, identityStore types.IdentityStore, redisStore *redis.Client) *Handler {
+	service := NewService(store, userStore, mfaStore, redisStore)
+	return &Handler{
+		store:         store,
+		userStore:     userStore,
+		mfaStore:      mfaStore,
+		identityStore: identityStore,
+		redisStore:    redisStore,
+		guard:         service.guard,
+		oidc:          oidclogin.NewFromConfig(),
+		service:       service,
+		now:           time.Now,
+		sleep:         time.Sleep,
+	}
+}
+
+// login is the service with the guard, the clock and the delay of the
+// handler, the tests replace them on the handler.
+func (h *Handler) login() *Service {
+	service := *h.service
+	service.guard = h.guard
+	service.now = h.now
+	service.sleep = h.sleep
+	return &service
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
@@ -35,8 +71,25 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/logout", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleLogout), h.userStore, h.store)).Methods("POST")
 	router.HandleFunc("/blacklisted_tokens", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetBlacklistedTokens), h.userStore, h.store)).Methods("GET")
 	router.HandleFunc("/verify", ratelimiter.WithRateLimiter(h.handleVerify)).Methods("GET")
+	router.HandleFunc("/verify/resend", ratelimiter.WithRateLimiter(h.handleResendVerification)).Methods("POST")
+	router.HandleFunc("/password/forgot", ratelimiter.WithRateLimiter(h.handleForgotPassword)).Methods("POST")
+	router.HandleFunc("/password/reset", ratelimiter.WithRateLimiter(h.handleResetPassword)).Methods("POST")
+	router.HandleFunc("/me/password", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleChangePassword), h.userStore, h.store)).Methods("POST")
 	router.HandleFunc("/login", ratelimiter.WithRateLimiter(h.handleLogin)).Methods("POST")
+	router.HandleFunc("/login/unlock", ratelimiter.WithRateLimiter(h.handleUnlockLogin)).Methods("GET")
+	router.HandleFunc("/login/mfa", ratelimiter.WithRateLimiter(h.handleLoginMFA)).Methods("POST")
+	router.HandleFunc("/login/mfa/enroll", ratelimiter.WithRateLimiter(h.handleLoginMFAEnroll)).Methods("POST")
+	router.HandleFunc("/login/oidc", ratelimiter.WithRateLimiter(h.handleOIDCProviders)).Methods("GET")
+	router.HandleFunc("/login/oidc/{provider}", ratelimiter.WithRateLimiter(h.handleOIDCAuthorize)).Methods("GET")
+	router.HandleFunc("/login/oidc/{provider}/callback", ratelimiter.WithRateLimiter(h.handleOIDCCallback)).Methods("POST")
+	router.HandleFunc("/me/mfa/enroll", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleEnrollMFA), h.userStore, h.store)).Methods("POST")
+	router.HandleFunc("/me/mfa/verify", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleVerifyMFA), h.userStore, h.store)).Methods("POST")
+	router.HandleFunc("/me/mfa/disable", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleDisableMFA), h.userStore, h.store)).Methods("POST")
+	router.HandleFunc("/me/mfa/recovery_codes", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleRegenerateRecoveryCodes), h.userStore, h.store)).Methods("POST")
 	router.HandleFunc("/register", ratelimiter.WithRateLimiter(h.handleRegister)).Methods("POST")
+	router.HandleFunc("/api_keys", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleCreateAPIKey), h.userStore, h.store)).Methods("POST")
+	router.HandleFunc("/api_keys", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetAPIKeys), h.userStore, h.store)).Methods("GET")
+	router.HandleFunc("/api_keys/{api_key_id}", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleRevokeAPIKey), h.userStore, h.store)).Methods("DELETE")
 }
 
 func validateRefreshToken(token string) (*jwt.Token, error) {
@@ -54,9 +107,9 @@ func (h *Handler) handleGetBlacklistedTokens(w http.ResponseWriter, r *http.Requ
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	tokens, err := h.store.GetBlacklistedTokens()
+	tokens, err := h.service.GetBlacklistedTokens(r.Context())
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		utils.WriteStatusError(w, err)
 		return
 	}
 
@@ -76,215 +129,267 @@ func (h *Handler) handleGetBlacklistedTokens(w http.ResponseWriter, r *http.Requ
 // 	return request.RefreshToken
 // }
 
-// handleRefresh godoc
-//
-//	@Summary		Refresh a JWT Token using secretToken
-//	@Description	Refresh a JWT Token using secretToken then give accessToken
-//	@Tags			tokenize
-//	@Accept			json
-//	@Produce		json
-//	@Success		200	{object}	types.Token
-//	@Failure		400	{object}	error
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
-//	@Security		ApiKeyAuth
-//	@Router			/api/v1/refresh [get]
 func (h *Handler) handleRefresh(w http.ResponseWriter, r *http.Request) {
 	span := opentracing.GlobalTracer().StartSpan("handleRefresh")
 	defer span.Finish()
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	var payload types.RefreshTokenPayload
-	if auth.GetUserIDFromContext(r.Context()) != 0 {
-		log.Println("got user for refreshing token")
+	payload, err := readTokens(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	refreshed, err := h.service.Refresh(r.Context(), payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
 	}
+
+	utils.WriteJSON(w, http.StatusOK, refreshed)
+}
+
+// readTokens reads the tokens from the Authorization headers of the web or
+// from the body.
+func readTokens(r *http.Request) (types.RefreshTokenPayload, error) {
+	var payload types.RefreshTokenPayload
 	if r.Header.Get("Authorization") != "" && r.Header.Get("Authorization-X") != "" {
 		payload.AccessToken = r.Header.Get("Authorization")
 		payload.SecretToken = r.Header.Get("Authorization-X")
-		log.Printf("Authorization : %v, Authorization-X: %v\n", r.Header.Get("Authorization"), r.Header.Get("Authorization-X"))
-	} else {
-		if err := utils.ParseJSON(r, &payload); err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
+		return payload, nil
 	}
-	log.Printf("%+v", payload)
-	t, err := h.store.GetBlacklistTokenByString(payload.SecretToken)
-	if t.Token == payload.SecretToken && err == nil {
-		log.Printf("blacklisted token: %v", t)
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("blacklisted token jwt secret %v", t))
+	err := utils.ParseJSON(r, &payload)
+	return payload, err
+}
+
+func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleLogout")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+	payload, err := readTokens(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	// Blacklist the token
-	if _, err := h.store.CreateBlacklistTokens(types.Token{Token: payload.AccessToken}); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+	if err := h.service.Logout(r.Context(), payload); err != nil {
+		utils.WriteStatusError(w, err)
 		return
 	}
 
-	token, err := validateRefreshToken(payload.SecretToken)
-	if err != nil || !token.Valid {
-		utils.WriteError(w, http.StatusUnauthorized, err)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleVerify(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleVerify")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
 		return
 	}
 
-	claims := token.Claims.(jwt.MapClaims)
-	userID, _ := strconv.Atoi(claims["userID"].(string))
-	userRole := claims["userRole"].(string)
-	userName := claims["userName"].(string)
-	userEmail := claims["userEmail"].(string)
-	userPhoneNumber := claims["userPhoneNumber"].(string)
-	userAddress := claims["userAddress"].(string)
+	t, err := h.store.GetVerificationTokenByHash(auth.HashToken(token))
+	if err != nil || !isVerificationTokenUsable(t, time.Now()) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
 
-	newAccessToken, _, err := auth.CreateJWT([]byte(config.Envs.JWTRefresh), []byte(nil), userID, userRole, userName, userEmail, userPhoneNumber, userAddress)
+	used, err := h.store.UseVerificationToken(t.ID)
 	if err != nil {
-		utils.WriteError(w, http.StatusUnauthorized, err)
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if used == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, map[string]string{"access_token": newAccessToken, "secret_token": payload.SecretToken})
+	if err := h.userStore.UpdateVerifiedUserByEmail(t.Email); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid email"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("Email %s has been verified successfully!\n", t.Email))
 }
 
-// handleLogout godoc
-//
-//	@Summary		Logout a JWT Token using secretToken
-//	@Description	Logout a JWT Token using secretToken then give accessToken
-//	@Tags			tokenize
-//	@Accept			json
-//	@Produce		json
-//	@Success		204	{object}
-//	@Failure		400	{object}	error
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
-//	@Security		ApiKeyAuth
-//	@Router			/api/v1/logout [post]
-func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
-	span := opentracing.GlobalTracer().StartSpan("handleLogout")
+func (h *Handler) handleResendVerification(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleResendVerification")
 	defer span.Finish()
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	var payload struct {
-		SecretToken string `json:"secret_token"`
-		AccessToken string `json:"access_token"`
+
+	var payload types.ResendVerificationPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
 	}
 
-	if r.Header.Get("Authorization") != "" && r.Header.Get("Authorization-X") != "" {
-		payload.AccessToken = r.Header.Get("Authorization")
-		payload.SecretToken = r.Header.Get("Authorization-X")
-		log.Printf("Authorization : %v, Authorization-X: %v\n", r.Header.Get("Authorization"), r.Header.Get("Authorization-X"))
-	} else {
-		if err := utils.ParseJSON(r, &payload); err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
+	if err := utils.Validate.Struct(payload); err != nil {
+		errv := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
+		return
+	}
+
+	// same answer for unknown and already verified emails so this can't be used to probe accounts
+	accepted := map[string]string{"message": "if the email is registered and not verified yet, a new verification link has been sent"}
+
+	u, err := h.userStore.GetUserByEmail(payload.Email)
+	if err != nil || u.Verified {
+		utils.WriteJSON(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	window := time.Second * time.Duration(config.Envs.EmailVerificationResendInSeconds)
+	if last, err := h.store.GetLatestVerificationTokenByEmail(u.Email); err == nil {
+		if wait := window - time.Since(last.CreatedAt); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("verification email was sent recently, please try again later"))
 			return
 		}
 	}
-	log.Printf("%+v", payload)
-	t, err := h.store.GetBlacklistTokenByString(payload.SecretToken)
-	if t.Token == payload.SecretToken && err == nil {
-		log.Printf("blacklisted token: %v", t)
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("blacklisted token jwt secret %v", t))
+
+	token, err := h.issueVerificationToken(u.Email)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error generating token verification"))
 		return
 	}
 
-	// Blacklist the token
-	if _, err := h.store.CreateBlacklistTokens(types.Token{Token: payload.SecretToken}); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+	if err := mailer.SendVerificationEmail(mailer.Locale(r.Header.Get("Accept-Language")), u.Email, token); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error sending verification email"))
 		return
 	}
-	// Blacklist the token
-	if _, err := h.store.CreateBlacklistTokens(types.Token{Token: payload.AccessToken}); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+
+	utils.WriteJSON(w, http.StatusAccepted, accepted)
+}
+
+func (h *Handler) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleForgotPassword")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.ForgotPasswordPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	if err := utils.Validate.Struct(payload); err != nil {
+		errv := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
+		return
+	}
+
+	// same answer for unknown emails so this can't be used to probe accounts
+	accepted := types.ResponsePassword{Message: "if the email is registered, a password reset link has been sent"}
+
+	u, err := h.userStore.GetUserByEmail(payload.Email)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	token, err := h.issuePasswordResetToken(u.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error generating password reset token"))
+		return
+	}
+
+	if err := mailer.SendPasswordResetEmail(mailer.Locale(r.Header.Get("Accept-Language")), u.Email, token); err != nil {
+		log.Printf("error sending password reset email to %v: %v", u.Email, err)
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, accepted)
 }
 
-// handleVerify godoc
-//
-//	@Summary		Verify a user to API
-//	@Description	Verify a user to API using in-memory storage
-//	@Tags			user
-//	@Accept			json
-//	@Produce		json
-//	@Success		200	{object}	mailer.EmailVerificationTokens
-//	@Failure		400	{object}	error
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
-//	@Security		ApiKeyAuth
-//	@Router			/api/v1/verify [get]
-func (h *Handler) handleVerify(w http.ResponseWriter, r *http.Request) {
-	span := opentracing.GlobalTracer().StartSpan("handleVerify")
+func (h *Handler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleResetPassword")
 	defer span.Finish()
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
 
-	token := r.URL.Query().Get("token")
-	// verify_email := r.URL.Query().Get("verify_user")
-	email, exists := mailer.EmailVerificationTokens[token]
-	if !exists {
+	var payload types.ResetPasswordPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errv := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
+		return
+	}
+
+	t, err := h.store.GetPasswordResetTokenByHash(auth.HashToken(payload.Token))
+	if err != nil || !isPasswordResetTokenUsable(t, time.Now()) {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
 		return
 	}
 
-	if err := h.userStore.UpdateVerifiedUserByEmail(email); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid email"))
+	used, err := h.store.UsePasswordResetToken(t.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if used == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
 		return
 	}
-	log.Println(mailer.EmailVerificationTokens)
 
-	delete(mailer.EmailVerificationTokens, token)
-	// Here you can update the user's status in your database or mark the email as verified.
-	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("Email %s has been verified successfully!\n", email))
+	if err := h.updatePassword(t.UserID, payload.Password); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ResponsePassword{Message: "password has been reset, please login again"})
 }
 
-// handleLogin godoc
-//
-//	@Summary		Login a user to API
-//	@Description	Login a user to API then Create JWT Token (accessToken, secretToken)
-//	@Tags			user
-//	@Accept			json
-//	@Produce		json
-//	@Success		200	{object}	types.LoginUserPayload
-//	@Failure		400	{object}	error
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
-//	@Security		ApiKeyAuth
-//	@Router			/api/v1/login [post]
-func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
-	span := opentracing.GlobalTracer().StartSpan("handleLogin")
+func (h *Handler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleChangePassword")
 	defer span.Finish()
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
 
-	var payload types.LoginUserPayload
+	var payload types.ChangePasswordPayload
 	if err := utils.ParseJSON(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	//validate the payload
-
 	if err := utils.Validate.Struct(payload); err != nil {
 		errv := err.(validator.ValidationErrors)
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
 		return
 	}
 
-	u, err := h.userStore.GetUserByEmail(payload.Email)
+	u, err := h.userStore.GetUserByID(auth.GetUserIDFromContext(r.Context()))
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("not found, invalid email"))
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+		return
+	}
+
+	if !auth.ComparePasswords(u.Password, []byte(payload.CurrentPassword)) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid current password"))
 		return
 	}
 
-	if !auth.ComparePasswords(u.Password, []byte(payload.Password)) {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("not found, invalid password"))
+	if err := h.updatePassword(u.ID, payload.NewPassword); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+
 	secret := []byte(config.Envs.JWTSecret)
 	access := []byte(config.Envs.JWTRefresh)
 	accessToken, secretToken, err := auth.CreateJWT(access, secret, u.ID, u.Role, u.FirstName+" "+u.LastName, u.Email, u.PhoneNumber, u.Address)
@@ -292,26 +397,53 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
-	// session.SetJWTSecretToken(w, secretToken)
-	// session.SetJWTAccessToken(w, accessToken)
 
-	utils.WriteJSON(w, http.StatusOK, map[string]string{"access_token": accessToken, "secret_token": secretToken})
+	utils.WriteJSON(w, http.StatusOK, types.ResponsePassword{Message: "password has been changed", AccessToken: accessToken, SecretToken: secretToken})
+}
+
+func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleLogin")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.LoginUserPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.login().Login(r.Context(), payload, utils.ClientIP(r), mailer.Locale(r.Header.Get("Accept-Language")))
+	if err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleUnlockLogin(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleUnlockLogin")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	if _, err := h.guard.Unlock(r.Context(), token, utils.ClientIP(r)); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
 
+	utils.WriteJSON(w, http.StatusOK, "Your account has been unlocked, you can login again.")
 }
 
-// handleRegister godoc
-//
-//	@Summary		Register a user to API
-//	@Description	Register a user to API without JWT Token
-//	@Tags			user
-//	@Accept			json
-//	@Produce		json
-//	@Success		200	{object}	types.RegisterUserPayload
-//	@Failure		400	{object}	error
-//	@Failure		404	{object}	error
-//	@Failure		500	{object}	error
-//	@Security		ApiKeyAuth
-//	@Router			/api/v1/register [post]
 func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	span := opentracing.GlobalTracer().StartSpan("handleRegister")
 	defer span.Finish()
@@ -346,19 +478,6 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// get token verification
-	tokenVerification, err := mailer.GenerateToken()
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error generating token verification"))
-		return
-	}
-	// verification here
-	mailer.EmailVerificationTokens[tokenVerification] = payload.Email
-	// if err := auth.SendVerificationEmail(payload.Email, tokenVerification); err != nil {
-	// 	utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error sending verification email"))
-	// 	return
-	// }
-
 	// if it doesn't create new user
 	err = h.userStore.CreateUser(types.User{
 		FirstName:   payload.FirstName,
@@ -370,10 +489,28 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		Verified:    false,
 		Role:        "customer",
 	})
+	if errors.Is(err, types.ErrEmailTaken) {
+		utils.WriteError(w, http.StatusConflict, errDeletedAccountEmail)
+		return
+	}
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, map[string]string{"verify_url": config.Envs.PublicHost + ":" + config.Envs.Port + "/api/v1/verify?token=" + tokenVerification})
+	if u, err := h.userStore.GetUserByEmail(payload.Email); err == nil {
+		audit.Record(r.Context(), audit.ActionCreate, audit.EntityUser, u.ID, nil, u)
+	}
+
+	// get token verification
+	tokenVerification, err := h.issueVerificationToken(payload.Email)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error generating token verification"))
+		return
+	}
+	if err := mailer.SendVerificationEmail(mailer.Locale(r.Header.Get("Accept-Language")), payload.Email, tokenVerification); err != nil {
+		log.Printf("error sending verification email to %v: %v", payload.Email, err)
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.ResponseRegister{Message: "account has been registered, please check your email to verify it"})
 }
@@ -2,20 +2,49 @@ package tokenize
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/loginguard"
+	"github.com/fayleenpc/tj-jeans/internal/mailer"
+	"github.com/fayleenpc/tj-jeans/internal/oidclogin"
+	"github.com/fayleenpc/tj-jeans/internal/oidclogin/oidctest"
 	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/mux"
 )
 
+// captureMail keeps the mail sent during the test.
+func captureMail(t *testing.T) *mailer.Capture {
+	capture := mailer.NewCapture("")
+	mailer.SetBackend(capture)
+	t.Cleanup(func() { mailer.SetBackend(nil) })
+	return capture
+}
+
+// mailToken returns the token the link of m carries in the query parameter.
+func mailToken(t *testing.T, m *mailer.Message, param string) string {
+	match := regexp.MustCompile(`[?&]` + param + `=([^\s"&<]+)`).FindStringSubmatch(m.Text)
+	if match == nil {
+		t.Fatalf("expected a %v in the mail, got %q", param, m.Text)
+	}
+	return match[1]
+}
+
 func TestRegisterUsersServiceHandler(t *testing.T) {
-	userStore := &mockUserStore{}
+	userStore := &mockUserStore{deleted: []string{"deleted@gmail.com"}}
 	store := &mockTokenStore{}
-	handler := NewHandler(store, userStore, nil)
+	handler := NewHandler(store, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
+	capture := captureMail(t)
 
 	t.Run("should fail if the user payload is invalid", func(t *testing.T) {
 		payload := types.RegisterUserPayload{
@@ -63,12 +92,69 @@ func TestRegisterUsersServiceHandler(t *testing.T) {
 			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
 		}
 	})
+	t.Run("should only mail the verification link", func(t *testing.T) {
+		payload := types.RegisterUserPayload{
+			FirstName:   "user",
+			LastName:    "123",
+			Email:       "mailed@gmail.com",
+			Password:    "asd",
+			PhoneNumber: "081234567890",
+			Address:     "Jl. Merdeka 1",
+		}
+		marshalled, _ := json.Marshal(payload)
+		req, err := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/register", handler.handleRegister)
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, rr.Code)
+		}
+		m := capture.Last()
+		if m == nil || m.Template != mailer.TemplateVerification || fmt.Sprint(m.To) != "[mailed@gmail.com]" {
+			t.Fatalf("expected verification mail to mailed@gmail.com, got %+v", m)
+		}
+		if token := mailToken(t, m, "token"); strings.Contains(rr.Body.String(), token) {
+			t.Errorf("expected the response not to carry the verification token, got %s", rr.Body.String())
+		}
+	})
+	t.Run("should conflict with the email of a deleted account", func(t *testing.T) {
+		payload := types.RegisterUserPayload{
+			FirstName:   "user",
+			LastName:    "123",
+			Email:       "deleted@gmail.com",
+			Password:    "asd",
+			PhoneNumber: "081234567890",
+			Address:     "Jl. Merdeka 1",
+		}
+		marshalled, _ := json.Marshal(payload)
+		req, err := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/register", handler.handleRegister)
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusConflict {
+			t.Errorf("expected status code %d, got %d", http.StatusConflict, rr.Code)
+		}
+	})
 }
 
 func TestLoginUsersServiceHandler(t *testing.T) {
 	userStore := &mockUserStore{}
 	store := &mockTokenStore{}
-	handler := NewHandler(store, userStore, nil)
+	handler := NewHandler(store, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
 
 	t.Run("should fail if the user payload is invalid", func(t *testing.T) {
 		payload := types.RegisterUserPayload{
@@ -117,7 +203,7 @@ func TestLoginUsersServiceHandler(t *testing.T) {
 func TestTokenizeServiceHandler(t *testing.T) {
 	userStore := &mockUserStore{}
 	store := &mockTokenStore{}
-	handler := NewHandler(store, userStore, nil)
+	handler := NewHandler(store, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
 
 	t.Run("should fail if the token payload is invalid", func(t *testing.T) {
 		payload := types.Token{
@@ -161,7 +247,910 @@ func TestTokenizeServiceHandler(t *testing.T) {
 	})
 }
 
-type mockTokenStore struct{}
+func TestVerifyServiceHandler(t *testing.T) {
+	userStore := &mockUserStore{}
+	store := &mockTokenStore{}
+	handler := NewHandler(store, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
+
+	now := time.Now()
+	store.CreateVerificationToken(types.VerificationToken{Email: "valid@gmail.com", TokenHash: auth.HashToken("valid"), ExpiresAt: now.Add(time.Hour), CreatedAt: now})
+	store.CreateVerificationToken(types.VerificationToken{Email: "expired@gmail.com", TokenHash: auth.HashToken("expired"), ExpiresAt: now.Add(-time.Minute), CreatedAt: now.Add(-time.Hour)})
+
+	verify := func(token string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/verify?token="+token, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/verify", handler.handleVerify)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should fail if the token is unknown", func(t *testing.T) {
+		if rr := verify("unknown"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+	t.Run("should fail if the token is expired", func(t *testing.T) {
+		if rr := verify("expired"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+	t.Run("should correctly verify the user only once", func(t *testing.T) {
+		if rr := verify("valid"); rr.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if len(userStore.verified) != 1 || userStore.verified[0] != "valid@gmail.com" {
+			t.Errorf("expected valid@gmail.com to be verified, got %v", userStore.verified)
+		}
+		if rr := verify("valid"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
+
+func TestResendVerificationServiceHandler(t *testing.T) {
+	userStore := &mockUserStore{users: map[string]*types.User{
+		"pending@gmail.com":  {ID: 1, Email: "pending@gmail.com"},
+		"verified@gmail.com": {ID: 2, Email: "verified@gmail.com", Verified: true},
+	}}
+	store := &mockTokenStore{}
+	handler := NewHandler(store, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
+	captureMail(t)
+
+	store.CreateVerificationToken(types.VerificationToken{Email: "pending@gmail.com", TokenHash: auth.HashToken("pending"), ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now()})
+
+	resend := func(email string) *httptest.ResponseRecorder {
+		marshalled, _ := json.Marshal(types.ResendVerificationPayload{Email: email})
+		req, err := http.NewRequest(http.MethodPost, "/verify/resend", bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/verify/resend", handler.handleResendVerification)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should not reveal unknown or verified emails", func(t *testing.T) {
+		for _, email := range []string{"unknown@gmail.com", "verified@gmail.com"} {
+			if rr := resend(email); rr.Code != http.StatusAccepted {
+				t.Errorf("expected status code %d for %v, got %d", http.StatusAccepted, email, rr.Code)
+			}
+		}
+	})
+	t.Run("should throttle resending", func(t *testing.T) {
+		rr := resend("pending@gmail.com")
+		if rr.Code != http.StatusTooManyRequests {
+			t.Errorf("expected status code %d, got %d", http.StatusTooManyRequests, rr.Code)
+		}
+		if rr.Header().Get("Retry-After") == "" {
+			t.Error("expected Retry-After header to be set")
+		}
+	})
+}
+
+func TestLoginUnverifiedUsersServiceHandler(t *testing.T) {
+	hashedPassword, _ := auth.HashPassword("asd")
+	userStore := &mockUserStore{users: map[string]*types.User{
+		"pending@gmail.com": {ID: 1, Email: "pending@gmail.com", Password: hashedPassword},
+	}}
+	store := &mockTokenStore{}
+	handler := NewHandler(store, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
+
+	marshalled, _ := json.Marshal(types.LoginUserPayload{Email: "pending@gmail.com", Password: "asd"})
+	req, err := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(marshalled))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+
+	router.HandleFunc("/login", handler.handleLogin)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected status code %d, got %d", http.StatusForbidden, rr.Code)
+	}
+}
+
+func TestForgotPasswordServiceHandler(t *testing.T) {
+	userStore := &mockUserStore{users: map[string]*types.User{
+		"user@gmail.com": {ID: 1, Email: "user@gmail.com"},
+	}}
+	store := &mockTokenStore{}
+	handler := NewHandler(store, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
+	capture := captureMail(t)
+
+	forgot := func(email string) *httptest.ResponseRecorder {
+		marshalled, _ := json.Marshal(types.ForgotPasswordPayload{Email: email})
+		req, err := http.NewRequest(http.MethodPost, "/password/forgot", bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/password/forgot", handler.handleForgotPassword)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should not reveal unknown emails", func(t *testing.T) {
+		if rr := forgot("unknown@gmail.com"); rr.Code != http.StatusAccepted {
+			t.Errorf("expected status code %d, got %d", http.StatusAccepted, rr.Code)
+		}
+		if len(store.passwordResetTokens) != 0 {
+			t.Errorf("expected no reset token, got %d", len(store.passwordResetTokens))
+		}
+		if m := capture.Last(); m != nil {
+			t.Errorf("expected no mail, got %+v", m)
+		}
+	})
+	t.Run("should issue a single pending reset token", func(t *testing.T) {
+		forgot("user@gmail.com")
+		if rr := forgot("user@gmail.com"); rr.Code != http.StatusAccepted {
+			t.Errorf("expected status code %d, got %d", http.StatusAccepted, rr.Code)
+		}
+		pending := 0
+		for _, token := range store.passwordResetTokens {
+			if token.UsedAt == nil {
+				pending++
+			}
+		}
+		if pending != 1 {
+			t.Errorf("expected 1 pending reset token, got %d", pending)
+		}
+	})
+	t.Run("should email the pending reset token", func(t *testing.T) {
+		m := capture.Last()
+		if m == nil || m.Template != mailer.TemplatePasswordReset || fmt.Sprint(m.To) != "[user@gmail.com]" {
+			t.Fatalf("expected reset mail to user@gmail.com, got %+v", m)
+		}
+		hash := auth.HashToken(mailToken(t, m, "reset_token"))
+		for _, token := range store.passwordResetTokens {
+			if token.TokenHash == hash && token.UsedAt == nil {
+				return
+			}
+		}
+		t.Error("expected the mailed token to be the pending reset token")
+	})
+}
+
+func TestResetPasswordServiceHandler(t *testing.T) {
+	userStore := &mockUserStore{users: map[string]*types.User{
+		"user@gmail.com": {ID: 1, Email: "user@gmail.com"},
+	}}
+	store := &mockTokenStore{}
+	handler := NewHandler(store, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
+
+	now := time.Now()
+	store.CreatePasswordResetToken(types.PasswordResetToken{UserID: 1, TokenHash: auth.HashToken("valid"), ExpiresAt: now.Add(time.Hour), CreatedAt: now})
+	store.CreatePasswordResetToken(types.PasswordResetToken{UserID: 1, TokenHash: auth.HashToken("expired"), ExpiresAt: now.Add(-time.Minute), CreatedAt: now.Add(-time.Hour)})
+
+	reset := func(token string) *httptest.ResponseRecorder {
+		marshalled, _ := json.Marshal(types.ResetPasswordPayload{Token: token, Password: "newpassword"})
+		req, err := http.NewRequest(http.MethodPost, "/password/reset", bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/password/reset", handler.handleResetPassword)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should fail if the token is expired", func(t *testing.T) {
+		if rr := reset("expired"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+	t.Run("should reset the password only once and revoke tokens", func(t *testing.T) {
+		if rr := reset("valid"); rr.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if !auth.ComparePasswords(userStore.users["user@gmail.com"].Password, []byte("newpassword")) {
+			t.Error("expected password to be updated")
+		}
+		if _, err := store.GetUserTokensRevokedAt(1); err != nil {
+			t.Error("expected user tokens to be revoked")
+		}
+		if rr := reset("valid"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
+
+func TestChangePasswordServiceHandler(t *testing.T) {
+	hashedPassword, _ := auth.HashPassword("asd")
+	userStore := &mockUserStore{users: map[string]*types.User{
+		"user@gmail.com": {ID: 1, Email: "user@gmail.com", Password: hashedPassword},
+	}}
+	store := &mockTokenStore{}
+	handler := NewHandler(store, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
+
+	change := func(current string) *httptest.ResponseRecorder {
+		marshalled, _ := json.Marshal(types.ChangePasswordPayload{CurrentPassword: current, NewPassword: "newpassword"})
+		req, err := http.NewRequest(http.MethodPost, "/me/password", bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, 1))
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/me/password", handler.handleChangePassword)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should fail if the current password is wrong", func(t *testing.T) {
+		if rr := change("wrong"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+	t.Run("should change the password and give tokens that are not revoked", func(t *testing.T) {
+		rr := change("asd")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if !auth.ComparePasswords(userStore.users["user@gmail.com"].Password, []byte("newpassword")) {
+			t.Error("expected password to be updated")
+		}
+		var response types.ResponsePassword
+		json.NewDecoder(rr.Body).Decode(&response)
+		token, err := jwt.Parse(response.SecretToken, func(*jwt.Token) (interface{}, error) {
+			return []byte(config.Envs.JWTSecret), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth.IsTokenRevoked(token, 1, store) {
+			t.Error("expected new secret token not to be revoked")
+		}
+	})
+}
+
+func TestLoginMFAServiceHandler(t *testing.T) {
+	hashedPassword, _ := auth.HashPassword("asd")
+	userStore := &mockUserStore{users: map[string]*types.User{
+		"admin@gmail.com":    {ID: 1, Email: "admin@gmail.com", Password: hashedPassword, Verified: true, Role: "admin"},
+		"customer@gmail.com": {ID: 2, Email: "customer@gmail.com", Password: hashedPassword, Verified: true, Role: "customer"},
+	}}
+	mfaStore := &mockMFAStore{}
+	handler := NewHandler(&mockTokenStore{}, userStore, mfaStore, &mockIdentityStore{}, nil)
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	handler.now = func() time.Time { return now }
+	handler.sleep = func(time.Duration) {}
+
+	post := func(path string, handlerFunc http.HandlerFunc, payload any) *httptest.ResponseRecorder {
+		marshalled, _ := json.Marshal(payload)
+		req, err := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc(path, handlerFunc)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	login := func(email string) types.ResponseLogin {
+		rr := post("/login", handler.handleLogin, types.LoginUserPayload{Email: email, Password: "asd"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		var response types.ResponseLogin
+		json.NewDecoder(rr.Body).Decode(&response)
+		return response
+	}
+
+	t.Run("should login customers without 2FA in one step", func(t *testing.T) {
+		response := login("customer@gmail.com")
+		if response.MFARequired || response.AccessToken == "" {
+			t.Errorf("expected access token without mfa, got %+v", response)
+		}
+	})
+
+	var secret string
+	var recoveryCodes []string
+	t.Run("should require admins to enrol before getting tokens", func(t *testing.T) {
+		response := login("admin@gmail.com")
+		if !response.MFARequired || !response.MFAEnrolmentRequired || response.AccessToken != "" {
+			t.Fatalf("expected pending mfa enrolment, got %+v", response)
+		}
+
+		rr := post("/login/mfa/enroll", handler.handleLoginMFAEnroll, types.MFAPendingPayload{MFAToken: response.MFAToken})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		var enrolment types.ResponseMFAEnrolment
+		json.NewDecoder(rr.Body).Decode(&enrolment)
+		secret = enrolment.Secret
+		if !strings.HasPrefix(enrolment.ProvisioningURI, "otpauth://totp/") {
+			t.Errorf("expected provisioning uri, got %v", enrolment.ProvisioningURI)
+		}
+
+		code, _ := auth.TOTPCode(secret, now)
+		rr = post("/login/mfa", handler.handleLoginMFA, types.LoginMFAPayload{MFAToken: response.MFAToken, Code: code})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		var loginMFA types.ResponseLoginMFA
+		json.NewDecoder(rr.Body).Decode(&loginMFA)
+		if loginMFA.AccessToken == "" || loginMFA.SecretToken == "" || len(loginMFA.RecoveryCodes) == 0 {
+			t.Errorf("expected tokens and recovery codes, got %+v", loginMFA)
+		}
+		recoveryCodes = loginMFA.RecoveryCodes
+	})
+	t.Run("should refuse a replayed code", func(t *testing.T) {
+		response := login("admin@gmail.com")
+		if response.MFAEnrolmentRequired {
+			t.Fatal("expected enrolment to be done")
+		}
+		code, _ := auth.TOTPCode(secret, now)
+		rr := post("/login/mfa", handler.handleLoginMFA, types.LoginMFAPayload{MFAToken: response.MFAToken, Code: code})
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+	t.Run("should accept the code of the next period", func(t *testing.T) {
+		response := login("admin@gmail.com")
+		now = now.Add(30 * time.Second)
+		code, _ := auth.TOTPCode(secret, now)
+		rr := post("/login/mfa", handler.handleLoginMFA, types.LoginMFAPayload{MFAToken: response.MFAToken, Code: code})
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+	})
+	t.Run("should accept a recovery code only once", func(t *testing.T) {
+		for _, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+			response := login("admin@gmail.com")
+			rr := post("/login/mfa", handler.handleLoginMFA, types.LoginMFAPayload{MFAToken: response.MFAToken, Code: strings.ToUpper(recoveryCodes[0])})
+			if rr.Code != want {
+				t.Errorf("expected status code %d, got %d", want, rr.Code)
+			}
+		}
+	})
+	t.Run("should refuse an expired mfa token", func(t *testing.T) {
+		response := login("admin@gmail.com")
+		now = now.Add(time.Hour)
+		code, _ := auth.TOTPCode(secret, now)
+		rr := post("/login/mfa", handler.handleLoginMFA, types.LoginMFAPayload{MFAToken: response.MFAToken, Code: code})
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+}
+
+func TestLoginBruteForceServiceHandler(t *testing.T) {
+	hashedPassword, _ := auth.HashPassword("asd")
+	userStore := &mockUserStore{users: map[string]*types.User{
+		"user@gmail.com": {ID: 1, Email: "user@gmail.com", Password: hashedPassword, Verified: true, Role: "customer"},
+	}}
+	handler := NewHandler(&mockTokenStore{}, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	handler.now = clock
+	handler.guard = loginguard.New(loginguard.NewMemoryStore(clock), loginguard.Policy{
+		MaxAccountFailures: 3,
+		MaxIPFailures:      100,
+		MaxIPAccounts:      100,
+		Window:             time.Minute * 15,
+		Lockout:            time.Minute * 15,
+		BaseDelay:          time.Millisecond * 100,
+		MaxDelay:           time.Second,
+	})
+	var events []loginguard.Event
+	handler.guard.Audit = func(e loginguard.Event) { events = append(events, e) }
+	handler.guard.Now = clock
+	var delays []time.Duration
+	handler.sleep = func(d time.Duration) { delays = append(delays, d) }
+	capture := captureMail(t)
+
+	login := func(email string, password string) *httptest.ResponseRecorder {
+		marshalled, _ := json.Marshal(types.LoginUserPayload{Email: email, Password: password})
+		req, err := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = "10.0.0.1:51000"
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/login", handler.handleLogin)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should answer the same for unknown emails and wrong passwords", func(t *testing.T) {
+		unknown := login("unknown@gmail.com", "asd")
+		wrong := login("user@gmail.com", "wrong")
+		if unknown.Code != http.StatusBadRequest || wrong.Code != unknown.Code || wrong.Body.String() != unknown.Body.String() {
+			t.Errorf("expected identical answers, got %d %q and %d %q", unknown.Code, unknown.Body.String(), wrong.Code, wrong.Body.String())
+		}
+	})
+	t.Run("should delay then lock the account", func(t *testing.T) {
+		login("user@gmail.com", "wrong")
+		login("user@gmail.com", "wrong")
+		rr := login("user@gmail.com", "asd")
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status code %d, got %d", http.StatusTooManyRequests, rr.Code)
+		}
+		if rr.Header().Get("Retry-After") == "" {
+			t.Error("expected Retry-After header to be set")
+		}
+		// the failures of the address count as well
+		want := []time.Duration{0, time.Millisecond * 100, time.Millisecond * 200, time.Millisecond * 400}
+		if fmt.Sprint(delays) != fmt.Sprint(want) {
+			t.Errorf("expected delays %v, got %v", want, delays)
+		}
+		if len(events) != 1 || events[0].Type != loginguard.EventAccountLocked {
+			t.Errorf("expected account locked event, got %+v", events)
+		}
+	})
+	t.Run("should email the unlock link to the locked account", func(t *testing.T) {
+		// the mail is sent in the background
+		deadline := time.Now().Add(time.Second)
+		for capture.Last() == nil && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond * 10)
+		}
+		m := capture.Last()
+		if m == nil || m.Template != mailer.TemplateAccountUnlock || fmt.Sprint(m.To) != "[user@gmail.com]" {
+			t.Fatalf("expected unlock mail to user@gmail.com, got %+v", m)
+		}
+		email, err := handler.guard.Unlock(context.Background(), mailToken(t, m, "token"), "10.0.0.1")
+		if err != nil || email != "user@gmail.com" {
+			t.Errorf("expected the mailed token to unlock user@gmail.com, got %q %v", email, err)
+		}
+	})
+	t.Run("should lock unknown emails the same way", func(t *testing.T) {
+		login("unknown@gmail.com", "asd")
+		login("unknown@gmail.com", "asd")
+		if rr := login("unknown@gmail.com", "asd"); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("expected status code %d, got %d", http.StatusTooManyRequests, rr.Code)
+		}
+	})
+	t.Run("should let the user in once the lockout is over", func(t *testing.T) {
+		now = now.Add(time.Minute * 16)
+		if rr := login("user@gmail.com", "asd"); rr.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+	})
+}
+
+func TestDisableMFAServiceHandler(t *testing.T) {
+	mfaStore := &mockMFAStore{mfa: map[int]*types.UserMFA{
+		1: {UserID: 1, Secret: "JBSWY3DPEHPK3PXP", Enabled: true},
+	}}
+	handler := NewHandler(&mockTokenStore{}, &mockUserStore{}, mfaStore, &mockIdentityStore{}, nil)
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	handler.now = func() time.Time { return now }
+
+	disable := func(role string, code string) *httptest.ResponseRecorder {
+		marshalled, _ := json.Marshal(types.MFACodePayload{Code: code})
+		req, err := http.NewRequest(http.MethodPost, "/me/mfa/disable", bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(req.Context(), auth.UserKey, 1)
+		req = req.WithContext(context.WithValue(ctx, auth.UserRoleKey, role))
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/me/mfa/disable", handler.handleDisableMFA)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	code, _ := auth.TOTPCode("JBSWY3DPEHPK3PXP", now)
+	t.Run("should not let admins disable 2FA", func(t *testing.T) {
+		if rr := disable("admin", code); rr.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+	t.Run("should fail with a wrong code", func(t *testing.T) {
+		if rr := disable("customer", "000000"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+	t.Run("should disable 2FA", func(t *testing.T) {
+		if rr := disable("customer", code); rr.Code != http.StatusNoContent {
+			t.Errorf("expected status code %d, got %d", http.StatusNoContent, rr.Code)
+		}
+		if _, err := mfaStore.GetUserMFA(1); err == nil {
+			t.Error("expected 2FA to be removed")
+		}
+	})
+}
+
+func TestOIDCLoginServiceHandler(t *testing.T) {
+	provider, err := oidctest.NewProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Close()
+
+	squatterPassword, err := auth.HashPassword("squatter-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userStore := &mockUserStore{users: map[string]*types.User{
+		"existing@gmail.com": {ID: 1, FirstName: "Existing", Email: "existing@gmail.com", Password: squatterPassword, Verified: false, Role: "customer"},
+	}, deleted: []string{"deleted@gmail.com"}}
+	identityStore := &mockIdentityStore{}
+	store := &mockTokenStore{}
+	handler := NewHandler(store, userStore, &mockMFAStore{}, identityStore, nil)
+	handler.oidc = oidclogin.New([]config.OIDCProvider{provider.Config("mock")}, func(name string) string {
+		return "http://localhost:8080/service/login/oidc/" + name + "/callback"
+	})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/login/oidc", handler.handleOIDCProviders).Methods("GET")
+	router.HandleFunc("/login/oidc/{provider}", handler.handleOIDCAuthorize).Methods("GET")
+	router.HandleFunc("/login/oidc/{provider}/callback", handler.handleOIDCCallback).Methods("POST")
+
+	authorize := func(providerName string) (*httptest.ResponseRecorder, types.ResponseOIDCAuthorization) {
+		req, err := http.NewRequest(http.MethodGet, "/login/oidc/"+providerName, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var response types.ResponseOIDCAuthorization
+		json.NewDecoder(rr.Body).Decode(&response)
+		return rr, response
+	}
+	callback := func(payload types.OIDCCallbackPayload) (*httptest.ResponseRecorder, types.ResponseLogin) {
+		marshalled, _ := json.Marshal(payload)
+		req, err := http.NewRequest(http.MethodPost, "/login/oidc/mock/callback", bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var response types.ResponseLogin
+		json.NewDecoder(rr.Body).Decode(&response)
+		return rr, response
+	}
+	login := func(claims map[string]interface{}) (*httptest.ResponseRecorder, types.ResponseLogin) {
+		rr, authorization := authorize("mock")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		code, state, err := provider.Authorize(authorization.AuthorizationURL, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return callback(types.OIDCCallbackPayload{Code: code, State: state, FlowToken: authorization.FlowToken})
+	}
+
+	t.Run("should fail for an unknown provider", func(t *testing.T) {
+		if rr, _ := authorize("unknown"); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+	t.Run("should register a new user without phone number and address", func(t *testing.T) {
+		rr, response := login(map[string]interface{}{
+			"sub": "new-subject", "email": "new@gmail.com", "email_verified": true, "given_name": "New", "family_name": "User",
+		})
+		if rr.Code != http.StatusOK || response.AccessToken == "" || response.SecretToken == "" {
+			t.Fatalf("expected tokens, got %d %+v", rr.Code, response)
+		}
+		if !response.ProfileIncomplete {
+			t.Error("expected profile to be incomplete")
+		}
+		u, err := userStore.GetUserByEmail("new@gmail.com")
+		if err != nil || !u.Verified || u.FirstName != "New" || u.LastName != "User" || u.Role != "customer" {
+			t.Errorf("expected a verified customer, got %+v %v", u, err)
+		}
+		if len(identityStore.identities) != 1 || identityStore.identities[0].UserID != u.ID {
+			t.Errorf("expected identity to be linked, got %+v", identityStore.identities)
+		}
+	})
+	t.Run("should conflict with the email of a deleted account", func(t *testing.T) {
+		rr, _ := login(map[string]interface{}{"sub": "deleted-subject", "email": "deleted@gmail.com", "email_verified": true})
+		if rr.Code != http.StatusConflict {
+			t.Errorf("expected status code %d, got %d", http.StatusConflict, rr.Code)
+		}
+	})
+	t.Run("should login a linked identity after its email changed", func(t *testing.T) {
+		rr, response := login(map[string]interface{}{"sub": "new-subject", "email": "renamed@gmail.com", "email_verified": false})
+		if rr.Code != http.StatusOK || response.AccessToken == "" {
+			t.Fatalf("expected tokens, got %d %+v", rr.Code, response)
+		}
+		if len(identityStore.identities) != 1 || identityStore.identities[0].Email != "renamed@gmail.com" {
+			t.Errorf("expected identity to be updated, got %+v", identityStore.identities)
+		}
+	})
+	t.Run("should not link an unverified email", func(t *testing.T) {
+		if rr, _ := login(map[string]interface{}{"sub": "other-subject", "email": "existing@gmail.com", "email_verified": false}); rr.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+	t.Run("should link a verified email to the existing user and fill in the profile", func(t *testing.T) {
+		rr, response := login(map[string]interface{}{
+			"sub": "existing-subject", "email": "existing@gmail.com", "email_verified": true, "name": "Someone Else", "phone_number": "081234567890",
+		})
+		if rr.Code != http.StatusOK || response.AccessToken == "" {
+			t.Fatalf("expected tokens, got %d %+v", rr.Code, response)
+		}
+		u := userStore.users["existing@gmail.com"]
+		if u.FirstName != "Existing" || u.LastName != "Else" || u.PhoneNumber != "081234567890" || !u.Verified {
+			t.Errorf("expected only missing fields to be filled in, got %+v", u)
+		}
+		if identity, err := identityStore.GetUserIdentity("mock", "existing-subject"); err != nil || identity.UserID != 1 {
+			t.Errorf("expected identity to be linked to user 1, got %+v %v", identity, err)
+		}
+	})
+	t.Run("should take the password of whoever registered the unverified email first", func(t *testing.T) {
+		// the previous case claimed the account registered before the provider
+		// proved the email
+		u := userStore.users["existing@gmail.com"]
+		if auth.ComparePasswords(u.Password, []byte("squatter-password")) {
+			t.Error("expected the local password to be replaced")
+		}
+		if _, ok := store.revokedAt[u.ID]; !ok {
+			t.Error("expected the tokens of the account to be revoked")
+		}
+	})
+	t.Run("should reject a code returned to another flow", func(t *testing.T) {
+		_, first := authorize("mock")
+		_, second := authorize("mock")
+		code, state, _ := provider.Authorize(first.AuthorizationURL, map[string]interface{}{"sub": "new-subject"})
+		if rr, _ := callback(types.OIDCCallbackPayload{Code: code, State: state, FlowToken: second.FlowToken}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
+
+func TestAPIKeyServiceHandler(t *testing.T) {
+	userStore := &mockUserStore{users: map[string]*types.User{
+		"admin@gmail.com":   {ID: 1, Email: "admin@gmail.com", Role: "admin"},
+		"partner@gmail.com": {ID: 2, Email: "partner@gmail.com", Role: "customer"},
+	}}
+	store := &mockTokenStore{}
+	handler := NewHandler(store, userStore, &mockMFAStore{}, &mockIdentityStore{}, nil)
+
+	create := func(role string, payload types.CreateAPIKeyPayload) (*httptest.ResponseRecorder, types.ResponseAPIKey) {
+		marshalled, _ := json.Marshal(payload)
+		req, err := http.NewRequest(http.MethodPost, "/api_keys", bytes.NewBuffer(marshalled))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(req.Context(), auth.UserKey, 1)
+		req = req.WithContext(context.WithValue(ctx, auth.UserRoleKey, role))
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/api_keys", handler.handleCreateAPIKey)
+		router.ServeHTTP(rr, req)
+
+		var response types.ResponseAPIKey
+		json.NewDecoder(rr.Body).Decode(&response)
+		return rr, response
+	}
+
+	// call sends the key to a route behind WithJWTAuth like the API registers them
+	call := func(method string, path string, key string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-API-Key", key)
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		ok := func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}
+		router.HandleFunc("/api/v1/products", auth.WithJWTAuth(ok, userStore, store)).Methods("GET", "POST")
+		router.HandleFunc("/api/v1/me/password", auth.WithJWTAuth(ok, userStore, store)).Methods("POST")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	payload := types.CreateAPIKeyPayload{UserID: 2, Name: "partner feed", Scopes: []string{"products:read"}}
+
+	t.Run("should fail if the user is not an admin", func(t *testing.T) {
+		if rr, _ := create("customer", payload); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+	t.Run("should fail if a scope is invalid", func(t *testing.T) {
+		invalid := payload
+		invalid.Scopes = []string{"products:delete"}
+		if rr, _ := create("admin", invalid); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+	t.Run("should fail if the user does not exist", func(t *testing.T) {
+		missing := payload
+		missing.UserID = 99
+		if rr, _ := create("admin", missing); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+	t.Run("should create a key that is only stored hashed", func(t *testing.T) {
+		rr, response := create("admin", payload)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, rr.Code)
+		}
+		if !strings.HasPrefix(response.Key, response.APIKey.Prefix) {
+			t.Errorf("expected key %q to start with prefix %q", response.Key, response.APIKey.Prefix)
+		}
+		stored := store.apiKeys[len(store.apiKeys)-1]
+		if stored.KeyHash != auth.HashToken(response.Key) || stored.CreatedBy != 1 {
+			t.Errorf("unexpected stored key %+v", stored)
+		}
+	})
+	t.Run("should act as the user within the scopes of the key", func(t *testing.T) {
+		_, response := create("admin", payload)
+		if rr := call(http.MethodGet, "/api/v1/products", response.Key); rr.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if rr := call(http.MethodPost, "/api/v1/products", response.Key); rr.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+		stored, _ := store.GetAPIKeyByID(response.APIKey.ID)
+		if stored.LastUsedAt == nil {
+			t.Error("expected last use of the key to be recorded")
+		}
+	})
+	t.Run("should not manage credentials with a key", func(t *testing.T) {
+		wildcard := payload
+		wildcard.Scopes = []string{"*"}
+		_, response := create("admin", wildcard)
+		if rr := call(http.MethodPost, "/api/v1/me/password", response.Key); rr.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+	t.Run("should deny revoked and expired keys", func(t *testing.T) {
+		_, response := create("admin", payload)
+
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api_keys/%d", response.APIKey.ID), nil)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserRoleKey, "admin"))
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+		router.HandleFunc("/api_keys/{api_key_id}", handler.handleRevokeAPIKey)
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNoContent {
+			t.Fatalf("expected status code %d, got %d", http.StatusNoContent, rr.Code)
+		}
+		if rr := call(http.MethodGet, "/api/v1/products", response.Key); rr.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+
+		_, response = create("admin", payload)
+		expired := time.Now().Add(-time.Minute)
+		store.apiKeys[response.APIKey.ID-1].ExpiresAt = &expired
+		if rr := call(http.MethodGet, "/api/v1/products", response.Key); rr.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+}
+
+type mockMFAStore struct {
+	mfa           map[int]*types.UserMFA
+	recoveryCodes map[int]map[string]bool
+}
+
+func (m *mockMFAStore) GetUserMFA(userID int) (*types.UserMFA, error) {
+	if mfa, ok := m.mfa[userID]; ok {
+		copied := *mfa
+		return &copied, nil
+	}
+	return nil, fmt.Errorf("mfa not found")
+}
+func (m *mockMFAStore) CreateUserMFA(mfa types.UserMFA) error {
+	if m.mfa == nil {
+		m.mfa = map[int]*types.UserMFA{}
+	}
+	if existing, ok := m.mfa[mfa.UserID]; ok && existing.Enabled {
+		return nil
+	}
+	m.mfa[mfa.UserID] = &mfa
+	return nil
+}
+func (m *mockMFAStore) EnableUserMFA(userID int, enabledAt time.Time) error {
+	m.mfa[userID].Enabled = true
+	m.mfa[userID].EnabledAt = &enabledAt
+	return nil
+}
+func (m *mockMFAStore) DisableUserMFA(userID int) error {
+	delete(m.mfa, userID)
+	delete(m.recoveryCodes, userID)
+	return nil
+}
+func (m *mockMFAStore) UseUserMFAStep(userID int, step int64) (int64, error) {
+	if mfa, ok := m.mfa[userID]; ok && mfa.LastUsedStep < step {
+		mfa.LastUsedStep = step
+		return 1, nil
+	}
+	return 0, nil
+}
+func (m *mockMFAStore) ReplaceRecoveryCodes(userID int, hashes []string) error {
+	if m.recoveryCodes == nil {
+		m.recoveryCodes = map[int]map[string]bool{}
+	}
+	m.recoveryCodes[userID] = map[string]bool{}
+	for _, hash := range hashes {
+		m.recoveryCodes[userID][hash] = false
+	}
+	return nil
+}
+func (m *mockMFAStore) UseRecoveryCode(userID int, hash string, usedAt time.Time) (int64, error) {
+	if used, ok := m.recoveryCodes[userID][hash]; ok && !used {
+		m.recoveryCodes[userID][hash] = true
+		return 1, nil
+	}
+	return 0, nil
+}
+
+type mockIdentityStore struct {
+	identities []types.UserIdentity
+}
+
+func (m *mockIdentityStore) GetUserIdentity(provider string, subject string) (*types.UserIdentity, error) {
+	for i := range m.identities {
+		if m.identities[i].Provider == provider && m.identities[i].Subject == subject {
+			return &m.identities[i], nil
+		}
+	}
+	return nil, fmt.Errorf("identity not found")
+}
+func (m *mockIdentityStore) GetUserIdentitiesByUserID(userID int) ([]types.UserIdentity, error) {
+	var identities []types.UserIdentity
+	for _, identity := range m.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+func (m *mockIdentityStore) CreateUserIdentity(identity types.UserIdentity) error {
+	identity.ID = len(m.identities) + 1
+	m.identities = append(m.identities, identity)
+	return nil
+}
+func (m *mockIdentityStore) UpdateUserIdentityLogin(id int, email string, loginAt time.Time) error {
+	for i := range m.identities {
+		if m.identities[i].ID == id {
+			m.identities[i].Email = email
+			m.identities[i].LastLoginAt = &loginAt
+		}
+	}
+	return nil
+}
+
+type mockTokenStore struct {
+	verificationTokens  []types.VerificationToken
+	passwordResetTokens []types.PasswordResetToken
+	revokedAt           map[int]time.Time
+	apiKeys             []types.APIKey
+}
 
 func (m *mockTokenStore) GetBlacklistedTokens() ([]types.Token, error) { return nil, nil }
 
@@ -169,19 +1158,191 @@ func (m *mockTokenStore) CreateBlacklistTokens(types.Token) (*types.Token, error
 	return nil, nil
 }
 func (m *mockTokenStore) GetBlacklistTokenByString(string) (*types.Token, error) {
-	return nil, nil
+	return &types.Token{}, fmt.Errorf("token not found")
+}
+func (m *mockTokenStore) CreateVerificationToken(t types.VerificationToken) error {
+	t.ID = len(m.verificationTokens) + 1
+	m.verificationTokens = append(m.verificationTokens, t)
+	return nil
+}
+func (m *mockTokenStore) GetVerificationTokenByHash(hash string) (*types.VerificationToken, error) {
+	for i := range m.verificationTokens {
+		if m.verificationTokens[i].TokenHash == hash {
+			return &m.verificationTokens[i], nil
+		}
+	}
+	return nil, fmt.Errorf("verification token not found")
+}
+func (m *mockTokenStore) GetLatestVerificationTokenByEmail(email string) (*types.VerificationToken, error) {
+	for i := len(m.verificationTokens) - 1; i >= 0; i-- {
+		if m.verificationTokens[i].Email == email {
+			return &m.verificationTokens[i], nil
+		}
+	}
+	return nil, fmt.Errorf("verification token not found")
+}
+func (m *mockTokenStore) UseVerificationToken(id int) (int64, error) {
+	for i := range m.verificationTokens {
+		if m.verificationTokens[i].ID == id && m.verificationTokens[i].UsedAt == nil {
+			now := time.Now()
+			m.verificationTokens[i].UsedAt = &now
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+func (m *mockTokenStore) RevokeVerificationTokensByEmail(email string) error {
+	for i := range m.verificationTokens {
+		if m.verificationTokens[i].Email == email && m.verificationTokens[i].UsedAt == nil {
+			now := time.Now()
+			m.verificationTokens[i].UsedAt = &now
+		}
+	}
+	return nil
 }
 
-type mockUserStore struct{}
+func (m *mockTokenStore) CreatePasswordResetToken(t types.PasswordResetToken) error {
+	t.ID = len(m.passwordResetTokens) + 1
+	m.passwordResetTokens = append(m.passwordResetTokens, t)
+	return nil
+}
+func (m *mockTokenStore) GetPasswordResetTokenByHash(hash string) (*types.PasswordResetToken, error) {
+	for i := range m.passwordResetTokens {
+		if m.passwordResetTokens[i].TokenHash == hash {
+			return &m.passwordResetTokens[i], nil
+		}
+	}
+	return nil, fmt.Errorf("password reset token not found")
+}
+func (m *mockTokenStore) UsePasswordResetToken(id int) (int64, error) {
+	for i := range m.passwordResetTokens {
+		if m.passwordResetTokens[i].ID == id && m.passwordResetTokens[i].UsedAt == nil {
+			now := time.Now()
+			m.passwordResetTokens[i].UsedAt = &now
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+func (m *mockTokenStore) RevokePasswordResetTokensByUserID(userID int) error {
+	for i := range m.passwordResetTokens {
+		if m.passwordResetTokens[i].UserID == userID && m.passwordResetTokens[i].UsedAt == nil {
+			now := time.Now()
+			m.passwordResetTokens[i].UsedAt = &now
+		}
+	}
+	return nil
+}
+func (m *mockTokenStore) RevokeUserTokens(userID int) error {
+	if m.revokedAt == nil {
+		m.revokedAt = map[int]time.Time{}
+	}
+	m.revokedAt[userID] = time.Now().Truncate(time.Millisecond)
+	return nil
+}
+func (m *mockTokenStore) GetUserTokensRevokedAt(userID int) (time.Time, error) {
+	if t, ok := m.revokedAt[userID]; ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("no revoked tokens")
+}
+
+func (m *mockTokenStore) CreateAPIKey(k types.APIKey) (int, error) {
+	k.ID = len(m.apiKeys) + 1
+	m.apiKeys = append(m.apiKeys, k)
+	return k.ID, nil
+}
+func (m *mockTokenStore) GetAPIKeys() ([]types.APIKey, error) { return m.apiKeys, nil }
+func (m *mockTokenStore) GetAPIKeyByID(id int) (*types.APIKey, error) {
+	for i := range m.apiKeys {
+		if m.apiKeys[i].ID == id {
+			return &m.apiKeys[i], nil
+		}
+	}
+	return nil, fmt.Errorf("api key not found")
+}
+func (m *mockTokenStore) GetAPIKeyByHash(hash string) (*types.APIKey, error) {
+	for i := range m.apiKeys {
+		if m.apiKeys[i].KeyHash == hash {
+			k := m.apiKeys[i]
+			return &k, nil
+		}
+	}
+	return nil, fmt.Errorf("api key not found")
+}
+func (m *mockTokenStore) RevokeAPIKey(id int, revokedAt time.Time) (int64, error) {
+	for i := range m.apiKeys {
+		if m.apiKeys[i].ID == id && m.apiKeys[i].RevokedAt == nil {
+			m.apiKeys[i].RevokedAt = &revokedAt
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+func (m *mockTokenStore) TouchAPIKey(id int, usedAt time.Time) error {
+	for i := range m.apiKeys {
+		if m.apiKeys[i].ID == id {
+			m.apiKeys[i].LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+type mockUserStore struct {
+	users    map[string]*types.User
+	verified []string
+	// deleted are the emails of the accounts in the trash
+	deleted []string
+}
 
 func (m *mockUserStore) GetUsers() ([]types.User, error)                   { return nil, nil }
 func (m *mockUserStore) GetUsersByIDs(userIDS []int) ([]types.User, error) { return nil, nil }
-func (m *mockUserStore) UpdateVerifiedUserByEmail(string) error            { return nil }
-func (m *mockUserStore) GetUserByEmail(string) (*types.User, error) {
+func (m *mockUserStore) UpdateVerifiedUserByEmail(email string) error {
+	m.verified = append(m.verified, email)
+	return nil
+}
+func (m *mockUserStore) GetUserByEmail(email string) (*types.User, error) {
+	if u, ok := m.users[email]; ok {
+		return u, nil
+	}
+	return nil, fmt.Errorf("user not found")
+}
+func (m *mockUserStore) GetUserByID(id int) (*types.User, error) {
+	for _, u := range m.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
 	return nil, fmt.Errorf("user not found")
 }
-func (m *mockUserStore) GetUserByID(id int) (*types.User, error)   { return nil, nil }
 func (m *mockUserStore) DeleteUserByID(id int) (int64, error)      { return 0, nil }
 func (m *mockUserStore) DeleteUser(user types.User) (int64, error) { return 0, nil }
-func (m *mockUserStore) UpdateUser(user types.User) (int64, error) { return 0, nil }
-func (m *mockUserStore) CreateUser(types.User) error               { return nil }
+func (m *mockUserStore) GetDeletedUsers() ([]types.User, error)    { return nil, nil }
+func (m *mockUserStore) RestoreUserByID(int) (int64, error)        { return 0, nil }
+func (m *mockUserStore) UpdateUser(user types.User) (int64, error) {
+	if u, ok := m.users[user.Email]; ok {
+		*u = user
+		return 1, nil
+	}
+	return 0, nil
+}
+func (m *mockUserStore) UpdatePasswordByUserID(id int, hashedPassword string) error {
+	u, err := m.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	u.Password = hashedPassword
+	return nil
+}
+func (m *mockUserStore) CreateUser(user types.User) error {
+	for _, email := range m.deleted {
+		if email == user.Email {
+			return fmt.Errorf("email %s %w", email, types.ErrEmailTaken)
+		}
+	}
+	if m.users != nil {
+		user.ID = len(m.users) + 1
+		m.users[user.Email] = &user
+	}
+	return nil
+}
@@ -3,58 +3,125 @@ package tokenize
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
 
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/loginguard"
 	"github.com/fayleenpc/tj-jeans/internal/types"
-	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// Service is the logins, the blacklisted tokens and the API keys behind the
+// REST, protobuf and gRPC transports. Errors are statuses, see
+// utils.WriteStatusError.
 type Service struct {
-	db *sql.DB
+	store     types.TokenStore
+	userStore types.UserStore
+	mfaStore  types.MFAStore
+	guard     *loginguard.Guard
+	// now is the clock of the one time codes, the pending tokens and the
+	// keys, tests replace it
+	now func() time.Time
+	// sleep applies the progressive delay of the login guard, tests replace it
+	sleep func(time.Duration)
 }
 
-func NewService(db *sql.DB) *Service {
+// NewService locks the logins through redis so every instance counts the
+// failures, in memory when redisStore is nil.
+func NewService(store types.TokenStore, userStore types.UserStore, mfaStore types.MFAStore, redisStore *redis.Client) *Service {
+	var guardStore loginguard.Store = loginguard.NewMemoryStore(time.Now)
+	if redisStore != nil {
+		guardStore = loginguard.NewRedisStore(redisStore)
+	}
 	return &Service{
-		db: db,
+		store:     store,
+		userStore: userStore,
+		mfaStore:  mfaStore,
+		guard:     loginguard.New(guardStore, loginguard.PolicyFromConfig()),
+		now:       time.Now,
+		sleep:     time.Sleep,
 	}
 }
 
-func (s *Service) GetBlacklistedTokens(ctx context.Context, req *pb.GetBlacklistedTokensRequest) (*pb.GetBlacklistedTokensResponse, error) {
-	rows, err := s.db.Query("SELECT * FROM blacklisted_tokens")
-	if err != nil {
+// GetBlacklistedTokens lists the tokens revoked by a logout, any signed in
+// user can see them.
+func (s *Service) GetBlacklistedTokens(ctx context.Context) ([]types.Token, error) {
+	return s.store.GetBlacklistedTokens()
+}
+
+func (s *Service) CreateBlacklistToken(ctx context.Context, token types.Token) (*types.Token, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
 		return nil, err
 	}
-	tokensPB := make([]*pb.Token, 0)
-	for rows.Next() {
-		t, err := scanRowIntoBlacklistedTokensPB(rows)
-		if err != nil {
-			return nil, err
-		}
-		tokensPB = append(tokensPB, t)
+	if token.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "the token is required")
 	}
-	return &pb.GetBlacklistedTokensResponse{Tokens: tokensPB}, nil
+	return s.store.CreateBlacklistTokens(token)
 }
 
-func (s *Service) CreateBlacklistTokens(ctx context.Context, t *pb.CreateBlacklistTokenRequest) (*pb.CreateBlacklistTokenResponse, error) {
-	return nil, nil
+func (s *Service) GetBlacklistTokenByString(ctx context.Context, token string) (*types.Token, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	blacklisted, err := s.store.GetBlacklistTokenByString(token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "the token is not blacklisted")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return blacklisted, nil
 }
 
-func (s *Service) GetBlacklistTokenByString(ctx context.Context, t *pb.GetBlacklistTokenByStringRequest) (*pb.GetBlacklistTokenByStringResponse, error) {
-	return nil, nil
-}
+// CreateAPIKey issues a key for the user of the payload, the plain key is
+// only returned here.
+func (s *Service) CreateAPIKey(ctx context.Context, payload types.CreateAPIKeyPayload) (*types.APIKey, string, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, "", err
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		return nil, "", status.Errorf(codes.InvalidArgument, "invalid payload %v", err)
+	}
+	if _, err := s.userStore.GetUserByID(payload.UserID); err != nil {
+		return nil, "", status.Errorf(codes.NotFound, "user %v not found", payload.UserID)
+	}
 
-func scanRowIntoBlacklistedTokensPB(rows *sql.Rows) (*pb.Token, error) {
-	token := new(types.Token)
-	err := rows.Scan(
-		&token.ID,
-		&token.Token,
-		&token.CreatedAt,
-	)
+	apiKey, key, err := issueAPIKey(s.store, payload, auth.GetUserIDFromContext(ctx), s.now())
+	if errors.Is(err, errInvalidAPIKeyPayload) {
+		return nil, "", status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
+		return nil, "", err
+	}
+	audit.Record(ctx, audit.ActionCreate, audit.EntityAPIKey, apiKey.ID, nil, apiKey)
+	return apiKey, key, nil
+}
+
+func (s *Service) GetAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
 		return nil, err
 	}
-	tokenPB := new(pb.Token)
-	tokenPB.Id = int32(token.ID)
-	tokenPB.Token = token.Token
-	tokenPB.CreatedAt = token.CreatedAt.Unix()
-	return tokenPB, nil
+	return s.store.GetAPIKeys()
+}
+
+// RevokeAPIKey denies the requests made with the key from now on.
+func (s *Service) RevokeAPIKey(ctx context.Context, id int) error {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return err
+	}
+	now := s.now()
+	revoked, err := s.store.RevokeAPIKey(id, now)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return status.Errorf(codes.NotFound, "api key %v not found or already revoked", id)
+	}
+	audit.Record(ctx, audit.ActionDelete, audit.EntityAPIKey, id, map[string]any{"revoked_at": nil}, map[string]any{"revoked_at": now})
+	return nil
 }
@@ -2,6 +2,9 @@ package tokenize
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/fayleenpc/tj-jeans/internal/types"
 )
@@ -51,6 +54,337 @@ func (s *Store) GetBlacklistTokenByString(t string) (*types.Token, error) {
 	return &dbToken, err
 }
 
+func (s *Store) CreateVerificationToken(t types.VerificationToken) error {
+	_, err := s.db.Exec(
+		"INSERT INTO verification_tokens (email, tokenHash, expiresAt, createdAt) VALUES (?, ?, ?, ?)",
+		t.Email, t.TokenHash, t.ExpiresAt, t.CreatedAt,
+	)
+	return err
+}
+
+func (s *Store) GetVerificationTokenByHash(hash string) (*types.VerificationToken, error) {
+	rows, err := s.db.Query("SELECT * FROM verification_tokens WHERE tokenHash = ?", hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	token := new(types.VerificationToken)
+	for rows.Next() {
+		token, err = scanRowIntoVerificationToken(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if token.ID == 0 {
+		return nil, fmt.Errorf("verification token not found")
+	}
+	return token, nil
+}
+
+func (s *Store) GetLatestVerificationTokenByEmail(email string) (*types.VerificationToken, error) {
+	rows, err := s.db.Query("SELECT * FROM verification_tokens WHERE email = ? ORDER BY createdAt DESC LIMIT 1", email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	token := new(types.VerificationToken)
+	for rows.Next() {
+		token, err = scanRowIntoVerificationToken(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if token.ID == 0 {
+		return nil, fmt.Errorf("verification token not found")
+	}
+	return token, nil
+}
+
+// UseVerificationToken marks the token as used, it only succeeds once so the
+// returned rows affected tells the caller whether it won the race.
+func (s *Store) UseVerificationToken(id int) (int64, error) {
+	res, err := s.db.Exec(
+		"UPDATE verification_tokens SET usedAt = ? WHERE id = ? AND usedAt IS NULL",
+		time.Now(), id,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) RevokeVerificationTokensByEmail(email string) error {
+	_, err := s.db.Exec(
+		"UPDATE verification_tokens SET usedAt = ? WHERE email = ? AND usedAt IS NULL",
+		time.Now(), email,
+	)
+	return err
+}
+
+func (s *Store) CreatePasswordResetToken(t types.PasswordResetToken) error {
+	_, err := s.db.Exec(
+		"INSERT INTO password_reset_tokens (userId, tokenHash, expiresAt, createdAt) VALUES (?, ?, ?, ?)",
+		t.UserID, t.TokenHash, t.ExpiresAt, t.CreatedAt,
+	)
+	return err
+}
+
+func (s *Store) GetPasswordResetTokenByHash(hash string) (*types.PasswordResetToken, error) {
+	rows, err := s.db.Query("SELECT * FROM password_reset_tokens WHERE tokenHash = ?", hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	token := new(types.PasswordResetToken)
+	for rows.Next() {
+		token, err = scanRowIntoPasswordResetToken(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if token.ID == 0 {
+		return nil, fmt.Errorf("password reset token not found")
+	}
+	return token, nil
+}
+
+func (s *Store) UsePasswordResetToken(id int) (int64, error) {
+	res, err := s.db.Exec(
+		"UPDATE password_reset_tokens SET usedAt = ? WHERE id = ? AND usedAt IS NULL",
+		time.Now(), id,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) RevokePasswordResetTokensByUserID(userID int) error {
+	_, err := s.db.Exec(
+		"UPDATE password_reset_tokens SET usedAt = ? WHERE userId = ? AND usedAt IS NULL",
+		time.Now(), userID,
+	)
+	return err
+}
+
+// RevokeUserTokens invalidates every access and secret token issued to the user
+// up to now, the auth middleware compares it against the token issuedAt claim.
+func (s *Store) RevokeUserTokens(userID int) error {
+	_, err := s.db.Exec(
+		"INSERT INTO user_token_revocations (userId, revokedAt) VALUES (?, ?) ON DUPLICATE KEY UPDATE revokedAt = VALUES(revokedAt)",
+		userID, time.Now().Truncate(time.Millisecond),
+	)
+	return err
+}
+
+func (s *Store) GetUserTokensRevokedAt(userID int) (time.Time, error) {
+	var revokedAt time.Time
+	err := s.db.QueryRow("SELECT revokedAt FROM user_token_revocations WHERE userId = ?", userID).Scan(&revokedAt)
+	return revokedAt, err
+}
+
+func (s *Store) GetUserMFA(userID int) (*types.UserMFA, error) {
+	rows, err := s.db.Query("SELECT * FROM user_mfa WHERE userId = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	mfa := new(types.UserMFA)
+	for rows.Next() {
+		mfa, err = scanRowIntoUserMFA(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if mfa.UserID == 0 {
+		return nil, fmt.Errorf("mfa not found")
+	}
+	return mfa, nil
+}
+
+// CreateUserMFA stores a new, not yet enabled, secret for the user and
+// replaces a previous enrolment that was never confirmed.
+func (s *Store) CreateUserMFA(mfa types.UserMFA) error {
+	_, err := s.db.Exec(
+		"INSERT INTO user_mfa (userId, secret, enabled, lastUsedStep, createdAt) VALUES (?, ?, FALSE, 0, ?) ON DUPLICATE KEY UPDATE secret = IF(enabled, secret, VALUES(secret)), createdAt = IF(enabled, createdAt, VALUES(createdAt))",
+		mfa.UserID, mfa.Secret, mfa.CreatedAt,
+	)
+	return err
+}
+
+func (s *Store) EnableUserMFA(userID int, enabledAt time.Time) error {
+	_, err := s.db.Exec("UPDATE user_mfa SET enabled = TRUE, enabledAt = ? WHERE userId = ?", enabledAt, userID)
+	return err
+}
+
+func (s *Store) DisableUserMFA(userID int) error {
+	if _, err := s.db.Exec("DELETE FROM user_mfa_recovery_codes WHERE userId = ?", userID); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("DELETE FROM user_mfa WHERE userId = ?", userID)
+	return err
+}
+
+// UseUserMFAStep records the time step of an accepted code, it affects no row
+// when the step was already used so a code can't be replayed.
+func (s *Store) UseUserMFAStep(userID int, step int64) (int64, error) {
+	res, err := s.db.Exec("UPDATE user_mfa SET lastUsedStep = ? WHERE userId = ? AND lastUsedStep < ?", step, userID, step)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM user_mfa_recovery_codes WHERE userId = ?", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO user_mfa_recovery_codes (userId, codeHash) VALUES (?, ?)", userID, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) UseRecoveryCode(userID int, codeHash string, usedAt time.Time) (int64, error) {
+	res, err := s.db.Exec(
+		"UPDATE user_mfa_recovery_codes SET usedAt = ? WHERE userId = ? AND codeHash = ? AND usedAt IS NULL",
+		usedAt, userID, codeHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) GetUserIdentity(provider string, subject string) (*types.UserIdentity, error) {
+	rows, err := s.db.Query("SELECT * FROM user_identities WHERE provider = ? AND subject = ?", provider, subject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	identity := new(types.UserIdentity)
+	for rows.Next() {
+		identity, err = scanRowIntoUserIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if identity.ID == 0 {
+		return nil, fmt.Errorf("identity not found")
+	}
+	return identity, nil
+}
+
+func (s *Store) GetUserIdentitiesByUserID(userID int) ([]types.UserIdentity, error) {
+	rows, err := s.db.Query("SELECT * FROM user_identities WHERE userId = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	identities := make([]types.UserIdentity, 0)
+	for rows.Next() {
+		identity, err := scanRowIntoUserIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, *identity)
+	}
+	return identities, nil
+}
+
+func (s *Store) CreateUserIdentity(identity types.UserIdentity) error {
+	_, err := s.db.Exec(
+		"INSERT INTO user_identities (userId, provider, subject, email, createdAt, lastLoginAt) VALUES (?, ?, ?, ?, ?, ?)",
+		identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.CreatedAt, identity.LastLoginAt,
+	)
+	return err
+}
+
+// UpdateUserIdentityLogin records a login and the email the provider has on
+// file now, which may have changed since the identity was linked.
+func (s *Store) UpdateUserIdentityLogin(id int, email string, loginAt time.Time) error {
+	_, err := s.db.Exec("UPDATE user_identities SET email = ?, lastLoginAt = ? WHERE id = ?", email, loginAt, id)
+	return err
+}
+
+// CreateAPIKey stores the key and returns its id, scopes are kept comma separated.
+func (s *Store) CreateAPIKey(key types.APIKey) (int, error) {
+	res, err := s.db.Exec(
+		"INSERT INTO api_keys (userId, name, prefix, keyHash, scopes, expiresAt, createdBy, createdAt) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		key.UserID, key.Name, key.Prefix, key.KeyHash, strings.Join(key.Scopes, ","), key.ExpiresAt, key.CreatedBy, key.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (s *Store) GetAPIKeys() ([]types.APIKey, error) {
+	rows, err := s.db.Query("SELECT * FROM api_keys ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := make([]types.APIKey, 0)
+	for rows.Next() {
+		key, err := scanRowIntoAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+func (s *Store) GetAPIKeyByID(id int) (*types.APIKey, error) {
+	return s.getAPIKey("SELECT * FROM api_keys WHERE id = ?", id)
+}
+
+func (s *Store) GetAPIKeyByHash(keyHash string) (*types.APIKey, error) {
+	return s.getAPIKey("SELECT * FROM api_keys WHERE keyHash = ?", keyHash)
+}
+
+func (s *Store) getAPIKey(query string, args ...any) (*types.APIKey, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	key := new(types.APIKey)
+	for rows.Next() {
+		key, err = scanRowIntoAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if key.ID == 0 {
+		return nil, fmt.Errorf("api key not found")
+	}
+	return key, nil
+}
+
+func (s *Store) RevokeAPIKey(id int, revokedAt time.Time) (int64, error) {
+	res, err := s.db.Exec("UPDATE api_keys SET revokedAt = ? WHERE id = ? AND revokedAt IS NULL", revokedAt, id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) TouchAPIKey(id int, usedAt time.Time) error {
+	_, err := s.db.Exec("UPDATE api_keys SET lastUsedAt = ? WHERE id = ?", usedAt, id)
+	return err
+}
+
 func scanRowIntoBlacklistedTokens(rows *sql.Rows) (*types.Token, error) {
 	token := new(types.Token)
 	err := rows.Scan(
@@ -63,3 +397,91 @@ func scanRowIntoBlacklistedTokens(rows *sql.Rows) (*types.Token, error) {
 	}
 	return token, nil
 }
+
+func scanRowIntoVerificationToken(rows *sql.Rows) (*types.VerificationToken, error) {
+	token := new(types.VerificationToken)
+	err := rows.Scan(
+		&token.ID,
+		&token.Email,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func scanRowIntoPasswordResetToken(rows *sql.Rows) (*types.PasswordResetToken, error) {
+	token := new(types.PasswordResetToken)
+	err := rows.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func scanRowIntoUserMFA(rows *sql.Rows) (*types.UserMFA, error) {
+	mfa := new(types.UserMFA)
+	err := rows.Scan(
+		&mfa.UserID,
+		&mfa.Secret,
+		&mfa.Enabled,
+		&mfa.LastUsedStep,
+		&mfa.CreatedAt,
+		&mfa.EnabledAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return mfa, nil
+}
+
+func scanRowIntoUserIdentity(rows *sql.Rows) (*types.UserIdentity, error) {
+	identity := new(types.UserIdentity)
+	err := rows.Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+		&identity.LastLoginAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func scanRowIntoAPIKey(rows *sql.Rows) (*types.APIKey, error) {
+	key := new(types.APIKey)
+	var scopes string
+	err := rows.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedBy,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Split(scopes, ",")
+	return key, nil
+}
@@ -0,0 +1,210 @@
+package users
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/go-playground/validator"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+func (h *Handler) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetMe")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	u, err := h.store.GetUserByID(auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, u)
+}
+
+func (h *Handler) handleUpdateMe(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleUpdateMe")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.UpdateProfilePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errv := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
+		return
+	}
+
+	u, err := h.store.GetUserByID(auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	oldUser := *u
+	if payload.FirstName != nil {
+		u.FirstName = *payload.FirstName
+	}
+	if payload.LastName != nil {
+		u.LastName = *payload.LastName
+	}
+	if payload.PhoneNumber != nil {
+		u.PhoneNumber = *payload.PhoneNumber
+	}
+	if payload.Address != nil {
+		u.Address = *payload.Address
+	}
+	if _, err := h.accountStore.UpdateProfile(*u); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	audit.Record(r.Context(), audit.ActionUpdate, audit.EntityUser, u.ID, oldUser, u)
+
+	utils.WriteJSON(w, http.StatusOK, u)
+}
+
+func (h *Handler) handleExportMe(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleExportMe")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	u, err := h.store.GetUserByID(auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	export, err := h.exportAccount(u)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="account-%d.json"`, u.ID))
+	utils.WriteJSON(w, http.StatusOK, export)
+}
+
+func (h *Handler) exportAccount(u *types.User) (*types.AccountExport, error) {
+	export := &types.AccountExport{
+		ExportedAt: time.Now(),
+		Profile:    *u,
+		Addresses:  []string{},
+		Orders:     []types.AccountExportOrder{},
+	}
+	if u.Address != "" {
+		export.Addresses = append(export.Addresses, u.Address)
+	}
+
+	orders, err := h.accountStore.GetOrdersByUserID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	orderIDs := make([]int, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	items, err := h.accountStore.GetOrderItemsByOrderIDs(orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		exported := types.AccountExportOrder{Order: order, Items: []types.OrderItem{}}
+		for _, item := range items {
+			if item.OrderID == order.ID {
+				exported.Items = append(exported.Items, item)
+			}
+		}
+		export.Orders = append(export.Orders, exported)
+		if order.Address != "" && !containsString(export.Addresses, order.Address) {
+			export.Addresses = append(export.Addresses, order.Address)
+		}
+	}
+
+	if revokedAt, err := h.tokenStore.GetUserTokensRevokedAt(u.ID); err == nil {
+		export.Sessions.TokensRevokedAt = &revokedAt
+	}
+	identities, err := h.identityStore.GetUserIdentitiesByUserID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	export.Sessions.Identities = append([]types.UserIdentity{}, identities...)
+
+	apiKeys, err := h.tokenStore.GetAPIKeys()
+	if err != nil {
+		return nil, err
+	}
+	export.Sessions.APIKeys = []types.APIKey{}
+	for _, apiKey := range apiKeys {
+		if apiKey.UserID == u.ID {
+			export.Sessions.APIKeys = append(export.Sessions.APIKeys, apiKey)
+		}
+	}
+	return export, nil
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) handleDeleteMe(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleDeleteMe")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	var payload types.DeleteAccountPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errv := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errv))
+		return
+	}
+
+	u, err := h.store.GetUserByID(auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	// the password keeps a stolen session or API key from deleting the account
+	if !auth.ComparePasswords(u.Password, []byte(payload.Password)) {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("invalid password"))
+		return
+	}
+
+	if err := h.accountStore.AnonymiseUser(u.ID, time.Now()); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	audit.Record(r.Context(), audit.ActionDelete, audit.EntityUser, u.ID, u, nil)
+	if err := h.tokenStore.RevokeUserTokens(u.ID); err != nil {
+		log.Printf("failed to revoke the tokens of deleted user %v: %v", u.ID, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,322 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/gateway"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestUserContract runs the same scenario on the REST, the gateway and the
+// gRPC transports, they share the service so they must answer alike.
+func TestUserContract(t *testing.T) {
+	transports := []struct {
+		name string
+		new  func(t *testing.T, service *Service) userClient
+	}{
+		{"rest", newRESTUserClient},
+		{"gateway", newGatewayUserClient},
+		{"grpc", newGRPCUserClient},
+	}
+	for _, transport := range transports {
+		t.Run(transport.name, func(t *testing.T) {
+			store := newMemoryUserStore(types.User{ID: 1, FirstName: "Jane", LastName: "Doe", Email: "jane@gmail.com", PhoneNumber: "088855553333", Address: "Jl. Braga 1", Role: "customer"})
+			client := transport.new(t, NewService(store))
+
+			expect := func(step string, got codes.Code, want codes.Code) {
+				t.Helper()
+				if got != want {
+					t.Errorf("%s: expected %v, got %v", step, want, got)
+				}
+			}
+
+			code, _ := client.get("customer", 1)
+			expect("customer get", code, codes.PermissionDenied)
+			code, firstName := client.get("admin", 1)
+			expect("admin get", code, codes.OK)
+			if firstName != "Jane" {
+				t.Errorf("expected the first name of user 1, got %q", firstName)
+			}
+			code, _ = client.get("admin", 9)
+			expect("get missing", code, codes.NotFound)
+
+			// the admins don't send the address, it is the user's to change
+			renamed := types.User{ID: 1, FirstName: "Janet", LastName: "Doe", Email: "jane@gmail.com", PhoneNumber: "088855553333"}
+			expect("customer update", client.update("customer", renamed), codes.PermissionDenied)
+			expect("admin update", client.update("admin", renamed), codes.OK)
+			code, firstName = client.get("admin", 1)
+			expect("get updated", code, codes.OK)
+			if firstName != "Janet" {
+				t.Errorf("expected the updated first name, got %q", firstName)
+			}
+			if address := store.users[1].Address; address != "Jl. Braga 1" {
+				t.Errorf("expected the address to be kept, got %q", address)
+			}
+			expect("update missing", client.update("admin", types.User{ID: 9, FirstName: "Nobody"}), codes.NotFound)
+
+			expect("customer delete", client.delete("customer", 1), codes.PermissionDenied)
+			expect("admin delete", client.delete("admin", 1), codes.OK)
+			code, _ = client.get("admin", 1)
+			expect("get deleted", code, codes.NotFound)
+			expect("delete missing", client.delete("admin", 9), codes.NotFound)
+
+			expect("customer restore", client.restore("customer", 1), codes.PermissionDenied)
+			expect("admin restore", client.restore("admin", 1), codes.OK)
+			expect("restore kept", client.restore("admin", 1), codes.NotFound)
+			code, _ = client.get("admin", 1)
+			expect("get restored", code, codes.OK)
+		})
+	}
+}
+
+// userClient is a transport of the contract, it answers with the code of the
+// call and the first name of the user it read.
+type userClient interface {
+	get(role string, id int) (codes.Code, string)
+	update(role string, u types.User) codes.Code
+	delete(role string, id int) codes.Code
+	restore(role string, id int) codes.Code
+}
+
+type httpUserClient struct {
+	t       *testing.T
+	handler http.Handler
+	// prefix is where the transport mounts its routes
+	prefix string
+	// body is the user in the messages of the transport
+	body func(types.User) any
+}
+
+func newRESTUserClient(t *testing.T, service *Service) userClient {
+	h := &Handler{service: service}
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{user_id}/restore", h.handleRestoreUserByID).Methods("POST")
+	router.HandleFunc("/users/{user_id}", h.handleGetUserByID).Methods("GET")
+	router.HandleFunc("/users/{user_id}/update", h.handleUpdateUserByID).Methods("PATCH")
+	router.HandleFunc("/users/{user_id}/delete", h.handleDeleteUserByID).Methods("DELETE")
+	return &httpUserClient{t: t, handler: router, body: func(u types.User) any { return u }}
+}
+
+func newGatewayUserClient(t *testing.T, service *Service) userClient {
+	conn := dialContractServer(t, func(s *grpc.Server) { NewHandlerServer(s, service) })
+	gatewayMux := gateway.NewServeMux()
+	if err := pb.RegisterUserServiceHandler(context.Background(), gatewayMux, conn); err != nil {
+		t.Fatal(err)
+	}
+	return &httpUserClient{t: t, handler: gatewayMux, prefix: "/api/v1", body: func(u types.User) any { return userToPB(u) }}
+}
+
+func (c *httpUserClient) do(role string, method string, path string, body any) (codes.Code, map[string]any) {
+	c.t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+	req, err := http.NewRequest(method, c.prefix+path, &payload)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserRoleKey, role))
+	// the gateway sends it in the metadata of the call
+	req.Header.Set(runtime.MetadataHeaderPrefix+"Role", role)
+	rr := httptest.NewRecorder()
+	c.handler.ServeHTTP(rr, req)
+
+	var response map[string]any
+	json.NewDecoder(rr.Body).Decode(&response)
+	return codeOfHTTPStatus(rr.Code), response
+}
+
+func (c *httpUserClient) get(role string, id int) (codes.Code, string) {
+	code, response := c.do(role, http.MethodGet, fmt.Sprintf("/users/%d", id), nil)
+	firstName, _ := response["first_name"].(string)
+	return code, firstName
+}
+
+func (c *httpUserClient) update(role string, u types.User) codes.Code {
+	code, _ := c.do(role, http.MethodPatch, fmt.Sprintf("/users/%d/update", u.ID), c.body(u))
+	return code
+}
+
+func (c *httpUserClient) delete(role string, id int) codes.Code {
+	code, _ := c.do(role, http.MethodDelete, fmt.Sprintf("/users/%d/delete", id), nil)
+	return code
+}
+
+func (c *httpUserClient) restore(role string, id int) codes.Code {
+	code, _ := c.do(role, http.MethodPost, fmt.Sprintf("/users/%d/restore", id), nil)
+	return code
+}
+
+type grpcUserClient struct {
+	client pb.UserServiceClient
+}
+
+func newGRPCUserClient(t *testing.T, service *Service) userClient {
+	conn := dialContractServer(t, func(s *grpc.Server) { NewHandlerServer(s, service) })
+	return &grpcUserClient{client: pb.NewUserServiceClient(conn)}
+}
+
+func (c *grpcUserClient) get(role string, id int) (codes.Code, string) {
+	response, err := c.client.GetUserByID(withContractRole(role), &pb.GetUserByIDRequest{Id: int32(id)})
+	return status.Code(err), response.GetUser().GetFirstName()
+}
+
+func (c *grpcUserClient) update(role string, u types.User) codes.Code {
+	_, err := c.client.UpdateUser(withContractRole(role), &pb.UpdateUserRequest{User: userToPB(u)})
+	return status.Code(err)
+}
+
+func (c *grpcUserClient) delete(role string, id int) codes.Code {
+	_, err := c.client.DeleteUserByID(withContractRole(role), &pb.DeleteUserByIDRequest{Id: int32(id)})
+	return status.Code(err)
+}
+
+func (c *grpcUserClient) restore(role string, id int) codes.Code {
+	_, err := c.client.RestoreUserByID(withContractRole(role), &pb.RestoreUserByIDRequest{Id: int32(id)})
+	return status.Code(err)
+}
+
+// dialContractServer serves the registered services in memory, the role of a
+// call is sent in its metadata and put in the context like the auth
+// interceptor does.
+func dialContractServer(t *testing.T, register func(*grpc.Server)) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if roles := md.Get("role"); len(roles) > 0 {
+			ctx = context.WithValue(ctx, auth.UserRoleKey, roles[0])
+		}
+		return handler(ctx, req)
+	}))
+	register(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withContractRole(role string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "role", role)
+}
+
+// codeOfHTTPStatus is the reverse of utils.WriteStatusError for the codes of
+// the contract.
+func codeOfHTTPStatus(code int) codes.Code {
+	switch code {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return codes.OK
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	}
+	return codes.Internal
+}
+
+// memoryUserStore keeps the users like the MySQL store, the trashed ones are
+// hidden and an update leaves the address alone, the methods the contract
+// doesn't use are the mock's.
+type memoryUserStore struct {
+	mockUserStore
+	mu    sync.Mutex
+	users map[int]types.User
+}
+
+func newMemoryUserStore(users ...types.User) *memoryUserStore {
+	m := &memoryUserStore{users: map[int]types.User{}}
+	for _, u := range users {
+		m.users[u.ID] = u
+	}
+	return m
+}
+
+func (m *memoryUserStore) GetUserByID(id int) (*types.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[id]
+	if !ok || u.DeletedAt != nil {
+		return nil, fmt.Errorf("user %w", types.ErrNotFound)
+	}
+	return &u, nil
+}
+func (m *memoryUserStore) UpdateUser(u types.User) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.users[u.ID]
+	if !ok || stored.DeletedAt != nil {
+		return 0, nil
+	}
+	stored.FirstName = u.FirstName
+	stored.LastName = u.LastName
+	stored.PhoneNumber = u.PhoneNumber
+	m.users[u.ID] = stored
+	return 1, nil
+}
+func (m *memoryUserStore) DeleteUserByID(id int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[id]
+	if !ok || u.DeletedAt != nil {
+		return 0, nil
+	}
+	now := time.Now()
+	u.DeletedAt = &now
+	m.users[id] = u
+	return 1, nil
+}
+func (m *memoryUserStore) GetDeletedUsers() ([]types.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	users := []types.User{}
+	for _, u := range m.users {
+		if u.DeletedAt != nil {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+func (m *memoryUserStore) RestoreUserByID(id int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[id]
+	if !ok || u.DeletedAt == nil {
+		return 0, nil
+	}
+	u.DeletedAt = nil
+	m.users[id] = u
+	return 1, nil
+}
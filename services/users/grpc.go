@@ -1,17 +1,156 @@
 package users
 
 import (
+	"context"
+
 	"github.com/fayleenpc/tj-jeans/internal/types"
 	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
 	"google.golang.org/grpc"
 )
 
+// HandlerServer is the gRPC transport of the users, it only converts the
+// messages of the service.
 type HandlerServer struct {
 	pb.UnimplementedUserServiceServer
 	service types.UserService
 }
 
+func NewServer(service types.UserService) *HandlerServer {
+	return &HandlerServer{service: service}
+}
+
 func NewHandlerServer(grpcServer *grpc.Server, service types.UserService) {
-	handler := &HandlerServer{service: service}
-	pb.RegisterUserServiceServer(grpcServer, handler)
+	pb.RegisterUserServiceServer(grpcServer, NewServer(service))
+}
+
+func (h *HandlerServer) GetUsers(ctx context.Context, req *pb.GetUsersRequest) (*pb.GetUsersResponse, error) {
+	users, err := h.service.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetUsersResponse{Users: usersToPB(users)}, nil
+}
+
+func (h *HandlerServer) GetUsersByIDs(ctx context.Context, req *pb.GetUsersByIDsRequest) (*pb.GetUsersByIDsResponse, error) {
+	ids := make([]int, 0, len(req.GetIds()))
+	for _, id := range req.GetIds() {
+		ids = append(ids, int(id))
+	}
+	users, err := h.service.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetUsersByIDsResponse{Users: usersToPB(users)}, nil
+}
+
+func (h *HandlerServer) UpdateVerifiedUserByEmail(ctx context.Context, req *pb.UpdateVerifiedUserByEmailRequest) (*pb.CreateUserResponse, error) {
+	if err := h.service.UpdateVerifiedUserByEmail(ctx, req.GetEmail()); err != nil {
+		return nil, err
+	}
+	return &pb.CreateUserResponse{}, nil
+}
+
+func (h *HandlerServer) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.GetUserByEmailResponse, error) {
+	user, err := h.service.GetUserByEmail(ctx, req.GetEmail())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetUserByEmailResponse{User: userToPB(*user)}, nil
+}
+
+func (h *HandlerServer) GetUserByID(ctx context.Context, req *pb.GetUserByIDRequest) (*pb.GetUserByIDResponse, error) {
+	user, err := h.service.GetUserByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetUserByIDResponse{User: userToPB(*user)}, nil
+}
+
+func (h *HandlerServer) DeleteUserByID(ctx context.Context, req *pb.DeleteUserByIDRequest) (*pb.DeleteUserByIDResponse, error) {
+	deleted, err := h.service.DeleteUserByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteUserByIDResponse{DeletedCount: deleted}, nil
+}
+
+func (h *HandlerServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	deleted, err := h.service.DeleteUserByID(ctx, int(req.GetUser().GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteUserResponse{DeletedCount: deleted}, nil
+}
+
+func (h *HandlerServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	updated, err := h.service.UpdateUser(ctx, userFromPB(req.GetUser()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateUserResponse{UpdatedCount: updated}, nil
+}
+
+func (h *HandlerServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if err := h.service.CreateUser(ctx, userFromPB(req.GetUser())); err != nil {
+		return nil, err
+	}
+	return &pb.CreateUserResponse{}, nil
+}
+
+func (h *HandlerServer) GetDeletedUsers(ctx context.Context, req *pb.GetDeletedUsersRequest) (*pb.GetDeletedUsersResponse, error) {
+	users, err := h.service.GetDeletedUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetDeletedUsersResponse{Users: usersToPB(users)}, nil
+}
+
+func (h *HandlerServer) RestoreUserByID(ctx context.Context, req *pb.RestoreUserByIDRequest) (*pb.RestoreUserByIDResponse, error) {
+	if _, err := h.service.RestoreUserByID(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+	return &pb.RestoreUserByIDResponse{RestoredCount: 1}, nil
+}
+
+// userToPB leaves the password out like the JSON of types.User, the hash
+// never leaves the store.
+func userToPB(u types.User) *pb.User {
+	userPB := &pb.User{
+		Id:          int32(u.ID),
+		FirstName:   u.FirstName,
+		LastName:    u.LastName,
+		Email:       u.Email,
+		Verified:    u.Verified,
+		Role:        u.Role,
+		PhoneNumber: u.PhoneNumber,
+		Address:     u.Address,
+		CreatedAt:   u.CreatedAt.Unix(),
+	}
+	if u.DeletedAt != nil {
+		userPB.DeletedAt = u.DeletedAt.Unix()
+	}
+	return userPB
+}
+
+func usersToPB(users []types.User) []*pb.User {
+	usersPB := make([]*pb.User, 0, len(users))
+	for _, u := range users {
+		usersPB = append(usersPB, userToPB(u))
+	}
+	return usersPB
+}
+
+// userFromPB is the user of a request, the password is the plain one of
+// CreateUser and the timestamps are the store's.
+func userFromPB(u *pb.User) types.User {
+	return types.User{
+		ID:          int(u.GetId()),
+		FirstName:   u.GetFirstName(),
+		LastName:    u.GetLastName(),
+		Email:       u.GetEmail(),
+		Password:    u.GetPassword(),
+		Role:        u.GetRole(),
+		PhoneNumber: u.GetPhoneNumber(),
+		Address:     u.GetAddress(),
+	}
 }
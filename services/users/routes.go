@@ -2,7 +2,6 @@ package users
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -17,17 +16,26 @@ import (
 )
 
 type Handler struct {
-	store      types.UserStore
-	tokenStore types.TokenStore
-	redisStore *redis.Client
+	store         types.UserStore
+	accountStore  types.AccountStore
+	tokenStore    types.TokenStore
+	identityStore types.IdentityStore
+	redisStore    *redis.Client
+	service       *Service
 }
 
-func NewHandler(store types.UserStore, tokenStore types.TokenStore, redisStore *redis.Client) *Handler {
-	return &Handler{store: store, tokenStore: tokenStore, redisStore: redisStore}
+func NewHandler(store types.UserStore, accountStore types.AccountStore, tokenStore types.TokenStore, identityStore types.IdentityStore, redisStore *redis.Client) *Handler {
+	return &Handler{store: store, accountStore: accountStore, tokenStore: tokenStore, identityStore: identityStore, redisStore: redisStore, service: NewService(store)}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/me", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetMe), h.store, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/me", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleUpdateMe), h.store, h.tokenStore)).Methods("PATCH")
+	router.HandleFunc("/me", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleDeleteMe), h.store, h.tokenStore)).Methods("DELETE")
+	router.HandleFunc("/me/export", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleExportMe), h.store, h.tokenStore)).Methods("GET")
 	router.HandleFunc("/users", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetUsers), h.store, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/users/trash", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetDeletedUsers), h.store, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/users/{user_id}/restore", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleRestoreUserByID), h.store, h.tokenStore)).Methods("POST")
 	router.HandleFunc("/users/{user_id}", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetUserByID), h.store, h.tokenStore)).Methods("GET")
 	router.HandleFunc("/users/{user_id}/update", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleUpdateUserByID), h.store, h.tokenStore)).Methods("PATCH")
 	router.HandleFunc("/users/{user_id}/delete", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleDeleteUserByID), h.store, h.tokenStore)).Methods("DELETE")
@@ -39,28 +47,14 @@ func (h *Handler) handleGetUsers(w http.ResponseWriter, r *http.Request) {
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
-
-	if userRole == "admin" {
-		redisString := h.redisStore.Get(r.Context(), "handleGetUsers")
-		if result, err := redisString.Result(); err == nil {
-			utils.WriteJSON(w, http.StatusOK, result)
-		}
-		users, err := h.store.GetUsers()
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
-			return
-		}
-		redisStatus := h.redisStore.Set(r.Context(), "handleGetUsers", users, 3600)
-		if redisStatus.Err() != nil {
-			log.Fatalf("Could not set key: %v", err)
-		}
-
-		utils.WriteJSON(w, http.StatusOK, users)
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+
+	users, err := h.service.GetUsers(r.Context())
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
 	}
 
+	utils.WriteJSON(w, http.StatusOK, users)
 }
 
 func (h *Handler) handleGetUserByID(w http.ResponseWriter, r *http.Request) {
@@ -69,23 +63,18 @@ func (h *Handler) handleGetUserByID(w http.ResponseWriter, r *http.Request) {
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		user, err := h.store.GetUserByID(userID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, user)
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
 	}
-
+	user, err := h.service.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, user)
 }
 
 func (h *Handler) handleUpdateUserByID(w http.ResponseWriter, r *http.Request) {
@@ -94,37 +83,32 @@ func (h *Handler) handleUpdateUserByID(w http.ResponseWriter, r *http.Request) {
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
 	userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		var payload types.User
-		if err := utils.ParseJSON(r, &payload); err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		oldUser, err := h.store.GetUserByID(userID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		if oldUser.ID != payload.ID {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		updatedUserID, err := h.store.UpdateUser(payload)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"updated_id": updatedUserID, "old_user": oldUser, "updated_user": payload})
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
 	}
-
+	var payload types.User
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.ID != userID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("the user id does not match the path"))
+		return
+	}
+	oldUser, err := h.service.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	updatedUserID, err := h.service.UpdateUser(r.Context(), payload)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"updated_id": updatedUserID, "old_user": oldUser, "updated_user": payload})
 }
 
 func (h *Handler) handleDeleteUserByID(w http.ResponseWriter, r *http.Request) {
@@ -133,26 +117,58 @@ func (h *Handler) handleDeleteUserByID(w http.ResponseWriter, r *http.Request) {
 
 	span.SetTag(string(ext.Component), "http")
 	span.SetTag("http.method", r.Method)
-	userRole := auth.GetUserRoleFromContext(r.Context())
+
+	userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+	oldUser, err := h.service.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	deletedUserID, err := h.service.DeleteUserByID(r.Context(), userID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"deleted_id": deletedUserID, "deleted_user": oldUser})
+}
+
+func (h *Handler) handleGetDeletedUsers(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetDeletedUsers")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	users, err := h.service.GetDeletedUsers(r.Context())
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, users)
+}
+
+func (h *Handler) handleRestoreUserByID(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleRestoreUserByID")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
 	userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
-	if userRole == "admin" {
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		oldUser, err := h.store.GetUserByID(userID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		deletedUserID, err := h.store.DeleteUserByID(oldUser.ID)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, err)
-			return
-		}
-		utils.WriteJSON(w, http.StatusOK, map[string]any{"deleted_id": deletedUserID, "deleted_user": oldUser})
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission, denied"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
 	}
 
+	user, err := h.service.RestoreUserByID(r.Context(), userID)
+	if err != nil {
+		utils.WriteStatusError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, user)
 }
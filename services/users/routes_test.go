@@ -1 +1,366 @@
 package users
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMeServiceHandler(t *testing.T) {
+	hashedPassword, _ := auth.HashPassword("asd")
+	newStores := func() (*mockUserStore, *mockAccountStore, *mockTokenStore, *Handler) {
+		userStore := &mockUserStore{users: map[int]*types.User{
+			1: {ID: 1, FirstName: "Jane", LastName: "Doe", Email: "jane@gmail.com", Password: hashedPassword, PhoneNumber: "088855553333", Address: "Jalan Merdeka 1"},
+		}}
+		accountStore := &mockAccountStore{
+			orders: []types.Order{
+				{ID: 10, UserID: 1, Total: 200, Status: "completed", Address: "Jalan Merdeka 1"},
+				{ID: 11, UserID: 1, Total: 50, Status: "pending", Address: "Jalan Sudirman 2"},
+			},
+			items: []types.OrderItem{{ID: 1, OrderID: 10, ProductID: 3, Quantity: 2, Price: 100}},
+		}
+		tokenStore := &mockTokenStore{apiKeys: []types.APIKey{{ID: 1, UserID: 1, Name: "script"}, {ID: 2, UserID: 2, Name: "other"}}}
+		identityStore := &mockIdentityStore{identities: []types.UserIdentity{{ID: 1, UserID: 1, Provider: "google", Email: "jane@gmail.com"}}}
+		return userStore, accountStore, tokenStore, NewHandler(userStore, accountStore, tokenStore, identityStore, nil)
+	}
+
+	serve := func(handler *Handler, method string, path string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req, err := http.NewRequest(method, path, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, 1))
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/me", handler.handleGetMe).Methods("GET")
+		router.HandleFunc("/me", handler.handleUpdateMe).Methods("PATCH")
+		router.HandleFunc("/me", handler.handleDeleteMe).Methods("DELETE")
+		router.HandleFunc("/me/export", handler.handleExportMe).Methods("GET")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should get the profile of the current user", func(t *testing.T) {
+		_, _, _, handler := newStores()
+		rr := serve(handler, http.MethodGet, "/me", nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if strings.Contains(rr.Body.String(), "password") {
+			t.Error("expected the password not to be returned")
+		}
+	})
+	t.Run("should fail if the profile update is invalid", func(t *testing.T) {
+		_, _, _, handler := newStores()
+		if rr := serve(handler, http.MethodPatch, "/me", map[string]string{"phone_number": "123"}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if rr := serve(handler, http.MethodPatch, "/me", map[string]string{"first_name": ""}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+	t.Run("should only update the fields that are sent", func(t *testing.T) {
+		_, accountStore, _, handler := newStores()
+		rr := serve(handler, http.MethodPatch, "/me", map[string]string{"address": "Jalan Thamrin 3"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if len(accountStore.profiles) != 1 {
+			t.Fatalf("expected 1 profile update, got %+v", accountStore.profiles)
+		}
+		u := accountStore.profiles[0]
+		if u.Address != "Jalan Thamrin 3" || u.FirstName != "Jane" || u.PhoneNumber != "088855553333" {
+			t.Errorf("unexpected profile %+v", u)
+		}
+	})
+	t.Run("should export the account", func(t *testing.T) {
+		_, _, _, handler := newStores()
+		rr := serve(handler, http.MethodGet, "/me/export", nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if !strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment") {
+			t.Errorf("expected an attachment, got %q", rr.Header().Get("Content-Disposition"))
+		}
+		var export types.AccountExport
+		json.NewDecoder(rr.Body).Decode(&export)
+		if export.Profile.Email != "jane@gmail.com" {
+			t.Errorf("unexpected profile %+v", export.Profile)
+		}
+		if len(export.Addresses) != 2 {
+			t.Errorf("expected 2 addresses, got %v", export.Addresses)
+		}
+		if len(export.Orders) != 2 || len(export.Orders[0].Items) != 1 || len(export.Orders[1].Items) != 0 {
+			t.Errorf("unexpected orders %+v", export.Orders)
+		}
+		if len(export.Sessions.Identities) != 1 || len(export.Sessions.APIKeys) != 1 {
+			t.Errorf("unexpected sessions %+v", export.Sessions)
+		}
+	})
+	t.Run("should fail to delete the account with a wrong password", func(t *testing.T) {
+		_, accountStore, _, handler := newStores()
+		if rr := serve(handler, http.MethodDelete, "/me", types.DeleteAccountPayload{Password: "wrong"}); rr.Code != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %d", http.StatusForbidden, rr.Code)
+		}
+		if accountStore.anonymised != nil {
+			t.Error("expected the account not to be anonymised")
+		}
+	})
+	t.Run("should anonymise the account and revoke its tokens", func(t *testing.T) {
+		_, accountStore, tokenStore, handler := newStores()
+		if rr := serve(handler, http.MethodDelete, "/me", types.DeleteAccountPayload{Password: "asd"}); rr.Code != http.StatusNoContent {
+			t.Fatalf("expected status code %d, got %d", http.StatusNoContent, rr.Code)
+		}
+		if len(accountStore.anonymised) != 1 || accountStore.anonymised[0] != 1 {
+			t.Errorf("expected user 1 to be anonymised, got %v", accountStore.anonymised)
+		}
+		if _, ok := tokenStore.revokedAt[1]; !ok {
+			t.Error("expected the tokens of the user to be revoked")
+		}
+	})
+}
+
+func TestTrashServiceHandler(t *testing.T) {
+	deletedAt := time.Now().Add(-time.Hour)
+	newHandler := func() (*mockUserStore, *Handler) {
+		userStore := &mockUserStore{
+			users: map[int]*types.User{
+				1: {ID: 1, FirstName: "Jane", LastName: "Doe", Email: "jane@gmail.com"},
+			},
+			deleted: map[int]*types.User{
+				2: {ID: 2, FirstName: "John", LastName: "Doe", Email: "john@gmail.com", DeletedAt: &deletedAt},
+			},
+		}
+		return userStore, NewHandler(userStore, &mockAccountStore{}, &mockTokenStore{}, &mockIdentityStore{}, nil)
+	}
+
+	serve := func(handler *Handler, role string, method string, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(req.Context(), auth.UserKey, 1)
+		ctx = context.WithValue(ctx, auth.UserRoleKey, role)
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		router.HandleFunc("/users/trash", handler.handleGetDeletedUsers).Methods("GET")
+		router.HandleFunc("/users/{user_id}/restore", handler.handleRestoreUserByID).Methods("POST")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should only let admins see the trash", func(t *testing.T) {
+		_, handler := newHandler()
+		if rr := serve(handler, "customer", http.MethodGet, "/users/trash"); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+	t.Run("should list the deleted users", func(t *testing.T) {
+		_, handler := newHandler()
+		rr := serve(handler, "admin", http.MethodGet, "/users/trash")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		var users []types.User
+		json.NewDecoder(rr.Body).Decode(&users)
+		if len(users) != 1 || users[0].ID != 2 || users[0].DeletedAt == nil {
+			t.Errorf("unexpected trash %+v", users)
+		}
+	})
+	t.Run("should fail to restore a user that is not in the trash", func(t *testing.T) {
+		_, handler := newHandler()
+		if rr := serve(handler, "admin", http.MethodPost, "/users/1/restore"); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+	t.Run("should restore a deleted user", func(t *testing.T) {
+		userStore, handler := newHandler()
+		rr := serve(handler, "admin", http.MethodPost, "/users/2/restore")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if u, ok := userStore.users[2]; !ok || u.DeletedAt != nil {
+			t.Errorf("expected user 2 to be restored, got %+v", u)
+		}
+	})
+	t.Run("should refuse to create a user with the email of a deleted user", func(t *testing.T) {
+		_, handler := newHandler()
+		ctx := context.WithValue(context.Background(), auth.UserRoleKey, "admin")
+		err := handler.service.CreateUser(ctx, types.User{Email: "john@gmail.com", Password: "asd"})
+		if status.Code(err) != codes.AlreadyExists {
+			t.Errorf("expected code %v, got %v", codes.AlreadyExists, err)
+		}
+	})
+}
+
+type mockUserStore struct {
+	users   map[int]*types.User
+	deleted map[int]*types.User
+}
+
+func (m *mockUserStore) GetUsers() ([]types.User, error)                   { return nil, nil }
+func (m *mockUserStore) GetUsersByIDs(userIDS []int) ([]types.User, error) { return nil, nil }
+func (m *mockUserStore) UpdateVerifiedUserByEmail(string) error            { return nil }
+func (m *mockUserStore) GetUserByEmail(string) (*types.User, error) {
+	return nil, fmt.Errorf("user not found")
+}
+func (m *mockUserStore) GetUserByID(id int) (*types.User, error) {
+	if u, ok := m.users[id]; ok {
+		copied := *u
+		return &copied, nil
+	}
+	return nil, fmt.Errorf("user not found")
+}
+func (m *mockUserStore) DeleteUserByID(id int) (int64, error)      { return 0, nil }
+func (m *mockUserStore) DeleteUser(user types.User) (int64, error) { return 0, nil }
+func (m *mockUserStore) GetDeletedUsers() ([]types.User, error) {
+	users := []types.User{}
+	for _, u := range m.deleted {
+		users = append(users, *u)
+	}
+	return users, nil
+}
+func (m *mockUserStore) RestoreUserByID(id int) (int64, error) {
+	u, ok := m.deleted[id]
+	if !ok {
+		return 0, nil
+	}
+	u.DeletedAt = nil
+	m.users[id] = u
+	delete(m.deleted, id)
+	return 1, nil
+}
+func (m *mockUserStore) UpdateUser(user types.User) (int64, error) {
+	if u, ok := m.users[user.ID]; ok {
+		user.Address = u.Address
+	}
+	m.users[user.ID] = &user
+	return 0, nil
+}
+func (m *mockUserStore) UpdatePasswordByUserID(int, string) error { return nil }
+func (m *mockUserStore) CreateUser(user types.User) error {
+	for _, u := range m.deleted {
+		if u.Email == user.Email {
+			return fmt.Errorf("email %s %w", u.Email, types.ErrEmailTaken)
+		}
+	}
+	return nil
+}
+
+type mockAccountStore struct {
+	profiles   []types.User
+	orders     []types.Order
+	items      []types.OrderItem
+	anonymised []int
+}
+
+func (m *mockAccountStore) UpdateProfile(user types.User) (int64, error) {
+	m.profiles = append(m.profiles, user)
+	return 1, nil
+}
+
+func (m *mockAccountStore) GetOrdersByUserID(userID int) ([]types.Order, error) {
+	orders := []types.Order{}
+	for _, order := range m.orders {
+		if order.UserID == userID {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
+func (m *mockAccountStore) GetOrderItemsByOrderIDs([]int) ([]types.OrderItem, error) {
+	return m.items, nil
+}
+func (m *mockAccountStore) AnonymiseUser(id int, deletedAt time.Time) error {
+	m.anonymised = append(m.anonymised, id)
+	return nil
+}
+
+type mockIdentityStore struct {
+	identities []types.UserIdentity
+}
+
+func (m *mockIdentityStore) GetUserIdentity(string, string) (*types.UserIdentity, error) {
+	return nil, fmt.Errorf("identity not found")
+}
+func (m *mockIdentityStore) GetUserIdentitiesByUserID(userID int) ([]types.UserIdentity, error) {
+	identities := []types.UserIdentity{}
+	for _, identity := range m.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+func (m *mockIdentityStore) CreateUserIdentity(types.UserIdentity) error          { return nil }
+func (m *mockIdentityStore) UpdateUserIdentityLogin(int, string, time.Time) error { return nil }
+
+type mockTokenStore struct {
+	revokedAt map[int]time.Time
+	apiKeys   []types.APIKey
+}
+
+func (m *mockTokenStore) GetBlacklistedTokens() ([]types.Token, error) { return nil, nil }
+func (m *mockTokenStore) CreateBlacklistTokens(types.Token) (*types.Token, error) {
+	return nil, nil
+}
+func (m *mockTokenStore) GetBlacklistTokenByString(string) (*types.Token, error) {
+	return nil, fmt.Errorf("token not found")
+}
+func (m *mockTokenStore) CreateVerificationToken(types.VerificationToken) error { return nil }
+func (m *mockTokenStore) GetVerificationTokenByHash(string) (*types.VerificationToken, error) {
+	return nil, nil
+}
+func (m *mockTokenStore) GetLatestVerificationTokenByEmail(string) (*types.VerificationToken, error) {
+	return nil, nil
+}
+func (m *mockTokenStore) UseVerificationToken(int) (int64, error)      { return 0, nil }
+func (m *mockTokenStore) RevokeVerificationTokensByEmail(string) error { return nil }
+func (m *mockTokenStore) CreatePasswordResetToken(types.PasswordResetToken) error {
+	return nil
+}
+func (m *mockTokenStore) GetPasswordResetTokenByHash(string) (*types.PasswordResetToken, error) {
+	return nil, nil
+}
+func (m *mockTokenStore) UsePasswordResetToken(int) (int64, error)    { return 0, nil }
+func (m *mockTokenStore) RevokePasswordResetTokensByUserID(int) error { return nil }
+func (m *mockTokenStore) RevokeUserTokens(userID int) error {
+	if m.revokedAt == nil {
+		m.revokedAt = map[int]time.Time{}
+	}
+	m.revokedAt[userID] = time.Now()
+	return nil
+}
+func (m *mockTokenStore) GetUserTokensRevokedAt(userID int) (time.Time, error) {
+	if t, ok := m.revokedAt[userID]; ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("no revoked tokens")
+}
+func (m *mockTokenStore) CreateAPIKey(types.APIKey) (int, error)        { return 0, nil }
+func (m *mockTokenStore) GetAPIKeys() ([]types.APIKey, error)           { return m.apiKeys, nil }
+func (m *mockTokenStore) GetAPIKeyByID(int) (*types.APIKey, error)      { return nil, nil }
+func (m *mockTokenStore) GetAPIKeyByHash(string) (*types.APIKey, error) { return nil, nil }
+func (m *mockTokenStore) RevokeAPIKey(int, time.Time) (int64, error)    { return 0, nil }
+func (m *mockTokenStore) TouchAPIKey(int, time.Time) error              { return nil }
@@ -2,188 +2,186 @@ package users
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
-	"strings"
+	"errors"
 
+	"github.com/fayleenpc/tj-jeans/internal/audit"
+	"github.com/fayleenpc/tj-jeans/internal/auth"
 	"github.com/fayleenpc/tj-jeans/internal/types"
-	pb "github.com/fayleenpc/tj-jeans/services/common/types_grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// Service is the users behind the REST, protobuf and gRPC transports, they
+// only translate their requests so a rule, an audit record or a field lands
+// on all of them at once. Errors are statuses, see utils.WriteStatusError.
 type Service struct {
-	db *sql.DB
+	store types.UserStore
 }
 
-func NewService(db *sql.DB) *Service {
-	return &Service{
-		db: db,
+func NewService(store types.UserStore) *Service {
+	return &Service{store: store}
+}
+
+func (s *Service) GetUsers(ctx context.Context) ([]types.User, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
 	}
+	return s.store.GetUsers()
 }
 
-func (s *Service) GetUsers(ctx context.Context, req *pb.GetUsersRequest) (*pb.GetUsersResponse, error) {
-	rows, err := s.db.Query("SELECT * FROM users")
-	if err != nil {
+func (s *Service) GetUsersByIDs(ctx context.Context, ids []int) ([]types.User, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
 		return nil, err
 	}
-	users := make([]*pb.User, 0)
-	for rows.Next() {
-		userPB, err := scanRowIntoUserPB(rows)
-		if err != nil {
-			return nil, err
-		}
-		users = append(users, userPB)
+	if len(ids) == 0 {
+		return []types.User{}, nil
 	}
-	return &pb.GetUsersResponse{Users: users}, nil
+	return s.store.GetUsersByIDs(ids)
 }
 
-func (s *Service) GetUsersByIDs(ctx context.Context, userIDS *pb.GetUsersByIDsRequest) (*pb.GetUsersByIDsResponse, error) {
-	placeholders := strings.Repeat(",?", len(userIDS.GetIds())-1)
-	query := fmt.Sprintf("SELECT * FROM users WHERE id IN (?%s)", placeholders)
-	args := make([]interface{}, len(userIDS.GetIds()))
-	for i, v := range userIDS.GetIds() {
-		args[i] = v
+func (s *Service) GetUserByID(ctx context.Context, id int) (*types.User, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
 	}
+	return s.getUser(id)
+}
 
-	rows, err := s.db.Query(query, args...)
-	if err != nil {
+func (s *Service) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
 		return nil, err
 	}
-
-	users := []*pb.User{}
-	for rows.Next() {
-		userPB, err := scanRowIntoUserPB(rows)
-		if err != nil {
-			return nil, err
-		}
-		users = append(users, userPB)
+	user, err := s.store.GetUserByEmail(email)
+	if errors.Is(err, types.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "user %v not found", email)
 	}
-
-	return &pb.GetUsersByIDsResponse{Users: users}, nil
+	return user, err
 }
 
-func (s *Service) UpdateVerifiedUserByEmail(ctx context.Context, email *pb.UpdateVerifiedUserByEmailRequest) error {
-	_, err := s.db.Exec(
-		"UPDATE users SET verified = ? WHERE email = ?",
-		1, email.GetEmail(),
-	)
+// CreateUser adds a user made by an admin with the plain password of the
+// user, it is stored hashed. The customers sign up through /register.
+func (s *Service) CreateUser(ctx context.Context, user types.User) error {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return err
+	}
+	if user.Email == "" || user.Password == "" {
+		return status.Error(codes.InvalidArgument, "the email and the password are required")
+	}
+	if _, err := s.store.GetUserByEmail(user.Email); err == nil {
+		return status.Errorf(codes.AlreadyExists, "user with email %s already exists", user.Email)
+	}
+	hashedPassword, err := auth.HashPassword(user.Password)
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+	if user.Role == "" {
+		user.Role = "customer"
+	}
+	err = s.store.CreateUser(user)
+	if errors.Is(err, types.ErrEmailTaken) {
+		return status.Errorf(codes.AlreadyExists, "user with email %s was deleted, restore it from the trash", user.Email)
+	}
 	if err != nil {
 		return err
 	}
+	if created, err := s.store.GetUserByEmail(user.Email); err == nil {
+		audit.Record(ctx, audit.ActionCreate, audit.EntityUser, created.ID, nil, created)
+	}
 	return nil
 }
 
-func (s *Service) GetUserByEmail(ctx context.Context, email *pb.GetUserByEmailRequest) (*pb.GetUserByEmailResponse, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE email = ?", email.GetEmail())
-	if err != nil {
-		return nil, err
+func (s *Service) UpdateVerifiedUserByEmail(ctx context.Context, email string) error {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return err
+	}
+	before, err := s.store.GetUserByEmail(email)
+	if errors.Is(err, types.ErrNotFound) {
+		return status.Errorf(codes.NotFound, "user %v not found", email)
 	}
-	userPB := new(pb.User)
-	for rows.Next() {
-		userPB, err = scanRowIntoUserPB(rows)
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return err
 	}
-	if userPB.GetEmail() != email.GetEmail() {
-		return nil, fmt.Errorf("email not found")
+	if err := s.store.UpdateVerifiedUserByEmail(email); err != nil {
+		return err
 	}
-	return &pb.GetUserByEmailResponse{User: userPB}, nil
+	after := *before
+	after.Verified = true
+	audit.Record(ctx, audit.ActionUpdate, audit.EntityUser, before.ID, before, after)
+	return nil
 }
 
-func (s *Service) GetUserByID(ctx context.Context, id *pb.GetUserByIDRequest) (*pb.GetUserByIDResponse, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE id = ?", id.GetId())
-	if err != nil {
-		return nil, err
+// UpdateUser updates the names and the phone number of the user, the address
+// is left to the user's own profile.
+func (s *Service) UpdateUser(ctx context.Context, user types.User) (int64, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return 0, err
 	}
-	userPB := new(pb.User)
-	for rows.Next() {
-		userPB, err = scanRowIntoUserPB(rows)
-		if err != nil {
-			return nil, err
-		}
+	before, err := s.getUser(user.ID)
+	if err != nil {
+		return 0, err
 	}
-	if userPB.GetId() == 0 {
-		return nil, fmt.Errorf("user not found")
+	updated, err := s.store.UpdateUser(user)
+	if err != nil {
+		return 0, err
 	}
-	return &pb.GetUserByIDResponse{User: userPB}, nil
+	after, _ := s.getUser(user.ID)
+	audit.Record(ctx, audit.ActionUpdate, audit.EntityUser, user.ID, before, after)
+	return updated, nil
 }
 
-func (s *Service) DeleteUserByID(ctx context.Context, id *pb.DeleteUserByIDRequest) (*pb.DeleteUserByIDResponse, error) {
-	res, err := s.db.Exec(
-		"DELETE from users WHERE id = ?",
-		id.GetId(),
-	)
+// DeleteUserByID moves the user to the trash.
+func (s *Service) DeleteUserByID(ctx context.Context, id int) (int64, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return 0, err
+	}
+	before, err := s.getUser(id)
 	if err != nil {
-		return &pb.DeleteUserByIDResponse{}, err
+		return 0, err
 	}
-	deletedID, _ := res.LastInsertId()
-	return &pb.DeleteUserByIDResponse{DeletedCount: deletedID}, nil
-}
-
-func (s *Service) DeleteUser(ctx context.Context, user *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
-	res, err := s.db.Exec(
-		"DELETE from users WHERE id = ?",
-		user.GetUser().GetId(),
-	)
+	deleted, err := s.store.DeleteUserByID(id)
 	if err != nil {
-		return &pb.DeleteUserResponse{}, err
+		return 0, err
+	}
+	if deleted > 0 {
+		audit.Record(ctx, audit.ActionDelete, audit.EntityUser, id, before, nil)
 	}
-	deletedUser, _ := res.LastInsertId()
-	return &pb.DeleteUserResponse{DeletedCount: deletedUser}, nil
+	return deleted, nil
 }
 
-func (s *Service) UpdateUser(ctx context.Context, user *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
-	res, err := s.db.Exec(
-		"UPDATE users SET firstName = ?, lastName = ?, phoneNumber = ? WHERE id = ?",
-		user.GetUser().GetFirstName(), user.GetUser().GetLastName(), user.GetUser().GetPhoneNumber(), user.GetUser().GetId(),
-	)
-
-	if err != nil {
-		return &pb.UpdateUserResponse{}, err
+func (s *Service) GetDeletedUsers(ctx context.Context) ([]types.User, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
 	}
-	updatedUser, _ := res.LastInsertId()
-	return &pb.UpdateUserResponse{UpdatedCount: updatedUser}, nil
+	return s.store.GetDeletedUsers()
 }
 
-func (s *Service) CreateUser(ctx context.Context, user *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
-	_, err := s.db.Exec(
-		"INSERT INTO users (firstName, lastName, email, password, phoneNumber, address, verified, role) VALUES (?,?,?,?,?,?,?,?)",
-		user.GetUser().GetFirstName(), user.GetUser().GetLastName(), user.GetUser().GetEmail(), user.GetUser().GetPassword(), user.GetUser().GetPhoneNumber(), user.GetUser().GetAddress(), user.GetUser().GetVerified(), user.GetUser().GetRole(),
-	)
+// RestoreUserByID takes the user out of the trash and returns it.
+func (s *Service) RestoreUserByID(ctx context.Context, id int) (*types.User, error) {
+	if err := auth.RequireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+	restored, err := s.store.RestoreUserByID(id)
 	if err != nil {
 		return nil, err
 	}
-	return &pb.CreateUserResponse{}, nil
+	if restored == 0 {
+		return nil, status.Errorf(codes.NotFound, "user %v is not in the trash", id)
+	}
+	user, err := s.getUser(id)
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.ActionRestore, audit.EntityUser, id, nil, user)
+	return user, nil
 }
 
-func scanRowIntoUserPB(rows *sql.Rows) (*pb.User, error) {
-	user := new(types.User)
-
-	err := rows.Scan(
-		&user.ID,
-		&user.FirstName,
-		&user.LastName,
-		&user.Email,
-		&user.Password,
-		&user.PhoneNumber,
-		&user.Address,
-		&user.Verified,
-		&user.Role,
-		&user.CreatedAt,
-	)
+func (s *Service) getUser(id int) (*types.User, error) {
+	user, err := s.store.GetUserByID(id)
+	if errors.Is(err, types.ErrNotFound) || (err == nil && user == nil) {
+		return nil, status.Errorf(codes.NotFound, "user %v not found", id)
+	}
 	if err != nil {
 		return nil, err
 	}
-	userPB := new(pb.User)
-	userPB.Address = user.Address
-	userPB.CreatedAt = user.CreatedAt.Unix()
-	userPB.Email = user.Email
-	userPB.FirstName = user.FirstName
-	userPB.Id = int32(user.ID)
-	userPB.LastName = user.LastName
-	userPB.Password = user.Password
-	userPB.PhoneNumber = user.PhoneNumber
-	userPB.Role = user.Role
-	userPB.Verified = user.Verified
-	return userPB, nil
+	return user, nil
 }
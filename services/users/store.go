@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/fayleenpc/tj-jeans/internal/db"
 	"github.com/fayleenpc/tj-jeans/internal/types"
 )
 
@@ -17,7 +19,14 @@ import (
 // DeleteUserByID(id int) (int64, error)
 // DeleteUser(user types.User)
 // UpdateUser(user types.User) (int64, error)
+// UpdatePasswordByUserID(id int, hashedPassword string) error
 // CreateUser(user types.User) error
+// GetDeletedUsers() ([]types.User, error)
+// RestoreUserByID(id int) (int64, error)
+// UpdateProfile(user types.User) (int64, error)
+// GetOrdersByUserID(userID int) ([]types.Order, error)
+// GetOrderItemsByOrderIDs(orderIDs []int) ([]types.OrderItem, error)
+// AnonymiseUser(id int, deletedAt time.Time) error
 
 type Store struct {
 	db *sql.DB
@@ -30,7 +39,7 @@ func NewStore(db *sql.DB) *Store {
 }
 
 func (s *Store) GetUsers() ([]types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users")
+	rows, err := s.db.Query("SELECT * FROM users WHERE deletedAt IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +56,7 @@ func (s *Store) GetUsers() ([]types.User, error) {
 
 func (s *Store) GetUsersByIDs(userIDS []int) ([]types.User, error) {
 	placeholders := strings.Repeat(",?", len(userIDS)-1)
-	query := fmt.Sprintf("SELECT * FROM users WHERE id IN (?%s)", placeholders)
+	query := fmt.Sprintf("SELECT * FROM users WHERE id IN (?%s) AND deletedAt IS NULL", placeholders)
 
 	args := make([]interface{}, len(userIDS))
 	for i, v := range userIDS {
@@ -83,7 +92,7 @@ func (s *Store) UpdateVerifiedUserByEmail(email string) error {
 }
 
 func (s *Store) GetUserByEmail(email string) (*types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE email = ?", email)
+	rows, err := s.db.Query("SELECT * FROM users WHERE email = ? AND deletedAt IS NULL", email)
 	if err != nil {
 		return nil, err
 	}
@@ -95,13 +104,13 @@ func (s *Store) GetUserByEmail(email string) (*types.User, error) {
 		}
 	}
 	if user.Email != email {
-		return nil, fmt.Errorf("email not found")
+		return nil, fmt.Errorf("email %w", types.ErrNotFound)
 	}
 	return user, nil
 }
 
 func (s *Store) GetUserByID(id int) (*types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE id = ?", id)
+	rows, err := s.db.Query("SELECT * FROM users WHERE id = ? AND deletedAt IS NULL", id)
 	if err != nil {
 		return nil, err
 	}
@@ -113,43 +122,60 @@ func (s *Store) GetUserByID(id int) (*types.User, error) {
 		}
 	}
 	if user.ID == 0 {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("user %w", types.ErrNotFound)
 	}
 	return user, nil
 }
 
+// DeleteUserByID moves the user to the trash, it can be restored until the
+// retention purge removes it for good.
 func (s *Store) DeleteUserByID(id int) (int64, error) {
 	res, err := s.db.Exec(
-		"DELETE from users WHERE id = ?",
-		id,
+		"UPDATE users SET deletedAt = ? WHERE id = ? AND deletedAt IS NULL",
+		time.Now(), id,
 	)
 	if err != nil {
 		return 0, err
 	}
-	return res.LastInsertId()
+	return res.RowsAffected()
 }
 
 func (s *Store) DeleteUser(user types.User) (int64, error) {
+	return s.DeleteUserByID(user.ID)
+}
+
+func (s *Store) UpdateUser(user types.User) (int64, error) {
 	res, err := s.db.Exec(
-		"DELETE from users WHERE id = ?",
-		user.ID,
+		"UPDATE users SET firstName = ?, lastName = ?, phoneNumber = ? WHERE id = ? AND deletedAt IS NULL",
+		user.FirstName, user.LastName, user.PhoneNumber, user.ID,
 	)
+
 	if err != nil {
 		return 0, err
 	}
 	return res.LastInsertId()
 }
 
-func (s *Store) UpdateUser(user types.User) (int64, error) {
+// UpdateProfile updates what the user edits of their own profile, unlike
+// UpdateUser it includes the address.
+func (s *Store) UpdateProfile(user types.User) (int64, error) {
 	res, err := s.db.Exec(
-		"UPDATE users SET firstName = ?, lastName = ?, phoneNumber = ? WHERE id = ?",
-		user.FirstName, user.LastName, user.PhoneNumber, user.ID,
+		"UPDATE users SET firstName = ?, lastName = ?, phoneNumber = ?, address = ? WHERE id = ? AND deletedAt IS NULL",
+		user.FirstName, user.LastName, user.PhoneNumber, user.Address, user.ID,
 	)
 
 	if err != nil {
 		return 0, err
 	}
-	return res.LastInsertId()
+	return res.RowsAffected()
+}
+
+func (s *Store) UpdatePasswordByUserID(id int, hashedPassword string) error {
+	_, err := s.db.Exec(
+		"UPDATE users SET password = ? WHERE id = ?",
+		hashedPassword, id,
+	)
+	return err
 }
 
 func (s *Store) CreateUser(user types.User) error {
@@ -157,12 +183,204 @@ func (s *Store) CreateUser(user types.User) error {
 		"INSERT INTO users (firstName, lastName, email, password, phoneNumber, address, verified, role) VALUES (?,?,?,?,?,?,?,?)",
 		user.FirstName, user.LastName, user.Email, user.Password, user.PhoneNumber, user.Address, user.Verified, user.Role,
 	)
+	if db.IsDuplicateEntry(err) {
+		return fmt.Errorf("email %s %w", user.Email, types.ErrEmailTaken)
+	}
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func (s *Store) GetDeletedUsers() ([]types.User, error) {
+	rows, err := s.db.Query("SELECT * FROM users WHERE deletedAt IS NOT NULL ORDER BY deletedAt DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	users := make([]types.User, 0)
+	for rows.Next() {
+		user, err := scanRowIntoUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+func (s *Store) RestoreUserByID(id int) (int64, error) {
+	res, err := s.db.Exec("UPDATE users SET deletedAt = NULL WHERE id = ? AND deletedAt IS NOT NULL", id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PurgeDeletedUsers removes the users that went to the trash before the
+// cutoff. Users with orders or who created API keys are kept, the orders
+// still point to them.
+func (s *Store) PurgeDeletedUsers(before time.Time) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		"SELECT id FROM users WHERE deletedAt < ? AND id NOT IN (SELECT userId FROM orders) AND id NOT IN (SELECT createdBy FROM api_keys) FOR UPDATE",
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]interface{}, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	placeholders := strings.Repeat(",?", len(ids)-1)
+	// the tables that reference users, then the users themselves
+	for _, table := range []string{"user_identities", "user_mfa_recovery_codes", "user_mfa", "password_reset_tokens", "user_token_revocations", "api_keys", "users"} {
+		column := "userId"
+		if table == "users" {
+			column = "id"
+		}
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s IN (?%s)", table, column, placeholders)
+		if _, err := tx.Exec(query, ids...); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(ids)), tx.Commit()
+}
+
+func (s *Store) GetOrdersByUserID(userID int) ([]types.Order, error) {
+	rows, err := s.db.Query("SELECT * FROM orders WHERE userId = ? AND deletedAt IS NULL ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	orders := make([]types.Order, 0)
+	for rows.Next() {
+		order, err := scanRowIntoOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	return orders, rows.Err()
+}
+
+func (s *Store) GetOrderItemsByOrderIDs(orderIDs []int) ([]types.OrderItem, error) {
+	items := make([]types.OrderItem, 0)
+	if len(orderIDs) == 0 {
+		return items, nil
+	}
+	placeholders := strings.Repeat(",?", len(orderIDs)-1)
+	query := fmt.Sprintf("SELECT * FROM order_items WHERE orderId IN (?%s) ORDER BY id", placeholders)
+
+	args := make([]interface{}, len(orderIDs))
+	for i, v := range orderIDs {
+		args[i] = v
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item := new(types.OrderItem)
+		if err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.Price, &item.WarehouseID); err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// AnonymiseUser replaces the personal data of a user and drops every way to
+// sign in as them. The user row and the orders stay, with their totals and
+// items, so the finance reports still add up.
+func (s *Store) AnonymiseUser(id int, deletedAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var email string
+	if err := tx.QueryRow("SELECT email FROM users WHERE id = ? FOR UPDATE", id).Scan(&email); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE users SET firstName = ?, lastName = ?, email = ?, password = ?, phoneNumber = ?, address = ?, verified = ? WHERE id = ?",
+		"Deleted", "User", fmt.Sprintf("deleted-%d@deleted.invalid", id), "", "", "", false, id,
+	)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range anonymiseStatements(id, email, deletedAt) {
+		if _, err := tx.Exec(stmt.query, stmt.args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type statement struct {
+	query string
+	args  []interface{}
+}
+
+// anonymiseStatements clear what is left of the user around the account, the
+// orders are kept for the books without the address and phone number they
+// were shipped to.
+func anonymiseStatements(id int, email string, deletedAt time.Time) []statement {
+	return []statement{
+		{"UPDATE orders SET address = '', phoneNumber = '' WHERE userId = ?", []interface{}{id}},
+		{"DELETE FROM user_identities WHERE userId = ?", []interface{}{id}},
+		{"DELETE FROM restock_subscriptions WHERE userId = ?", []interface{}{id}},
+		{"DELETE FROM wishlist WHERE userId = ?", []interface{}{id}},
+		{"DELETE FROM user_mfa_recovery_codes WHERE userId = ?", []interface{}{id}},
+		{"DELETE FROM user_mfa WHERE userId = ?", []interface{}{id}},
+		{"DELETE FROM password_reset_tokens WHERE userId = ?", []interface{}{id}},
+		{"DELETE FROM verification_tokens WHERE email = ?", []interface{}{email}},
+		{"DELETE FROM mail_outbox WHERE FIND_IN_SET(?, recipients)", []interface{}{email}},
+		{"UPDATE api_keys SET revokedAt = ? WHERE userId = ? AND revokedAt IS NULL", []interface{}{deletedAt, id}},
+	}
+}
+
+func scanRowIntoOrder(rows *sql.Rows) (*types.Order, error) {
+	order := new(types.Order)
+	err := rows.Scan(
+		&order.ID,
+		&order.UserID,
+		&order.Total,
+		&order.Status,
+		&order.Address,
+		&order.CreatedAt,
+		&order.DeletedAt,
+		&order.PhoneNumber,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
 func scanRowIntoUser(rows *sql.Rows) (*types.User, error) {
 	user := new(types.User)
 	err := rows.Scan(
@@ -176,6 +394,7 @@ func scanRowIntoUser(rows *sql.Rows) (*types.User, error) {
 		&user.Verified,
 		&user.Role,
 		&user.CreatedAt,
+		&user.DeletedAt,
 	)
 	if err != nil {
 		return nil, err
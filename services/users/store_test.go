@@ -0,0 +1,35 @@
+package users
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnonymiseStatements(t *testing.T) {
+	statements := anonymiseStatements(1, "user@gmail.com", time.Now())
+
+	var orders string
+	for _, stmt := range statements {
+		if strings.HasPrefix(stmt.query, "UPDATE orders ") {
+			orders = stmt.query
+		}
+	}
+	for _, column := range []string{"address = ''", "phoneNumber = ''"} {
+		if !strings.Contains(orders, column) {
+			t.Errorf("expected the orders to be updated with %v, got %q", column, orders)
+		}
+	}
+
+	for _, table := range []string{"restock_subscriptions", "wishlist", "mail_outbox"} {
+		deleted := false
+		for _, stmt := range statements {
+			if strings.HasPrefix(stmt.query, "DELETE FROM "+table+" ") {
+				deleted = true
+			}
+		}
+		if !deleted {
+			t.Errorf("expected the %v rows of the user to be deleted", table)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package wishlist
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/config"
+	"github.com/fayleenpc/tj-jeans/internal/ratelimiter"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/fayleenpc/tj-jeans/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+type Handler struct {
+	store        types.WishlistStore
+	restockStore types.RestockStore
+	productStore types.ProductStore
+	userStore    types.UserStore
+	tokenStore   types.TokenStore
+	now          func() time.Time
+}
+
+func NewHandler(store types.WishlistStore, restockStore types.RestockStore, productStore types.ProductStore, userStore types.UserStore, tokenStore types.TokenStore) *Handler {
+	return &Handler{store: store, restockStore: restockStore, productStore: productStore, userStore: userStore, tokenStore: tokenStore, now: time.Now}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/me/wishlist", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetWishlist), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/me/wishlist/{product_id}", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleAddToWishlist), h.userStore, h.tokenStore)).Methods("PUT")
+	router.HandleFunc("/me/wishlist/{product_id}", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleRemoveFromWishlist), h.userStore, h.tokenStore)).Methods("DELETE")
+	router.HandleFunc("/me/back_in_stock", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleGetRestockSubscriptions), h.userStore, h.tokenStore)).Methods("GET")
+	router.HandleFunc("/me/back_in_stock/{product_id}", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleSubscribeRestock), h.userStore, h.tokenStore)).Methods("PUT")
+	router.HandleFunc("/me/back_in_stock/{product_id}", auth.WithJWTAuth(ratelimiter.WithRateLimiter(h.handleUnsubscribeRestock), h.userStore, h.tokenStore)).Methods("DELETE")
+}
+
+func (h *Handler) handleGetWishlist(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetWishlist")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	items, err := h.store.GetWishlist(auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, items)
+}
+
+func (h *Handler) handleAddToWishlist(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleAddToWishlist")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	product, ok := h.getProduct(w, r)
+	if !ok {
+		return
+	}
+	userID := auth.GetUserIDFromContext(r.Context())
+	if err := h.store.AddToWishlist(userID, product.ID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	items, err := h.store.GetWishlist(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, items)
+}
+
+func (h *Handler) handleRemoveFromWishlist(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleRemoveFromWishlist")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+	userID := auth.GetUserIDFromContext(r.Context())
+	removed, err := h.store.RemoveFromWishlist(userID, productID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if removed == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("the product is not in the wishlist"))
+		return
+	}
+
+	items, err := h.store.GetWishlist(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, items)
+}
+
+func (h *Handler) handleGetRestockSubscriptions(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleGetRestockSubscriptions")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	subscriptions, err := h.restockStore.GetRestockSubscriptions(auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, subscriptions)
+}
+
+func (h *Handler) handleSubscribeRestock(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleSubscribeRestock")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	product, ok := h.getProduct(w, r)
+	if !ok {
+		return
+	}
+	if product.Quantity > 0 {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("%s is in stock", product.Name))
+		return
+	}
+	userID := auth.GetUserIDFromContext(r.Context())
+	expiresAt := h.now().AddDate(0, 0, int(config.Envs.BackInStockTTLInDays))
+	if err := h.restockStore.SubscribeRestock(userID, product.ID, expiresAt); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	subscriptions, err := h.restockStore.GetRestockSubscriptions(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, subscriptions)
+}
+
+func (h *Handler) handleUnsubscribeRestock(w http.ResponseWriter, r *http.Request) {
+	span := opentracing.GlobalTracer().StartSpan("handleUnsubscribeRestock")
+	defer span.Finish()
+
+	span.SetTag(string(ext.Component), "http")
+	span.SetTag("http.method", r.Method)
+
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+	userID := auth.GetUserIDFromContext(r.Context())
+	removed, err := h.restockStore.UnsubscribeRestock(userID, productID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if removed == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("no back-in-stock subscription for the product"))
+		return
+	}
+
+	subscriptions, err := h.restockStore.GetRestockSubscriptions(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, subscriptions)
+}
+
+// getProduct looks up the product of the path, the error is written when ok
+// is false.
+func (h *Handler) getProduct(w http.ResponseWriter, r *http.Request) (*types.Product, bool) {
+	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return nil, false
+	}
+	product, err := h.productStore.GetProductByID(productID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if product == nil || product.ID == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("product not found"))
+		return nil, false
+	}
+	return product, true
+}
@@ -0,0 +1,143 @@
+package wishlist
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/auth"
+	"github.com/fayleenpc/tj-jeans/internal/types"
+	"github.com/gorilla/mux"
+)
+
+func TestWishlistServiceHandler(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	products := &mockProductStore{products: map[int]types.Product{
+		1: {ID: 1, Name: "Slim Jeans", Quantity: 4},
+		2: {ID: 2, Name: "Denim Jacket", Quantity: 0},
+	}}
+	serve := func(store *mockWishlistStore, method string, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(req.Context(), auth.UserKey, 7)
+		ctx = context.WithValue(ctx, auth.UserRoleKey, "customer")
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		router := mux.NewRouter()
+
+		handler := NewHandler(store, store, products, nil, nil)
+		handler.now = func() time.Time { return now }
+		router.HandleFunc("/me/wishlist", handler.handleGetWishlist).Methods("GET")
+		router.HandleFunc("/me/wishlist/{product_id}", handler.handleAddToWishlist).Methods("PUT")
+		router.HandleFunc("/me/wishlist/{product_id}", handler.handleRemoveFromWishlist).Methods("DELETE")
+		router.HandleFunc("/me/back_in_stock", handler.handleGetRestockSubscriptions).Methods("GET")
+		router.HandleFunc("/me/back_in_stock/{product_id}", handler.handleSubscribeRestock).Methods("PUT")
+		router.HandleFunc("/me/back_in_stock/{product_id}", handler.handleUnsubscribeRestock).Methods("DELETE")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should add and remove products of the wishlist", func(t *testing.T) {
+		store := &mockWishlistStore{}
+		if rr := serve(store, http.MethodPut, "/me/wishlist/9"); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+		rr := serve(store, http.MethodPut, "/me/wishlist/1")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		var items []types.WishlistItem
+		json.NewDecoder(rr.Body).Decode(&items)
+		if len(items) != 1 || items[0].ProductID != 1 {
+			t.Errorf("unexpected wishlist %+v", items)
+		}
+		if rr := serve(store, http.MethodDelete, "/me/wishlist/1"); rr.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if rr := serve(store, http.MethodDelete, "/me/wishlist/1"); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+	t.Run("should only subscribe to sold out products", func(t *testing.T) {
+		store := &mockWishlistStore{}
+		if rr := serve(store, http.MethodPut, "/me/back_in_stock/1"); rr.Code != http.StatusConflict {
+			t.Errorf("expected status code %d, got %d", http.StatusConflict, rr.Code)
+		}
+		if len(store.subscriptions) != 0 {
+			t.Errorf("expected no subscription, got %+v", store.subscriptions)
+		}
+	})
+	t.Run("should subscribe to a sold out product until it expires", func(t *testing.T) {
+		store := &mockWishlistStore{}
+		rr := serve(store, http.MethodPut, "/me/back_in_stock/2")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		var subscriptions []types.RestockSubscription
+		json.NewDecoder(rr.Body).Decode(&subscriptions)
+		if len(subscriptions) != 1 || subscriptions[0].UserID != 7 || subscriptions[0].ProductID != 2 || !subscriptions[0].ExpiresAt.Equal(now.AddDate(0, 0, 90)) {
+			t.Errorf("unexpected subscriptions %+v", subscriptions)
+		}
+		if rr := serve(store, http.MethodDelete, "/me/back_in_stock/2"); rr.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if len(store.subscriptions) != 0 {
+			t.Errorf("expected the subscription to be removed, got %+v", store.subscriptions)
+		}
+	})
+}
+
+type mockWishlistStore struct {
+	types.RestockStore
+	items         []types.WishlistItem
+	subscriptions []types.RestockSubscription
+}
+
+func (m *mockWishlistStore) GetWishlist(userID int) ([]types.WishlistItem, error) {
+	return append([]types.WishlistItem{}, m.items...), nil
+}
+func (m *mockWishlistStore) AddToWishlist(userID int, productID int) error {
+	m.items = append(m.items, types.WishlistItem{ProductID: productID})
+	return nil
+}
+func (m *mockWishlistStore) RemoveFromWishlist(userID int, productID int) (int64, error) {
+	for i, item := range m.items {
+		if item.ProductID == productID {
+			m.items = append(m.items[:i], m.items[i+1:]...)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+func (m *mockWishlistStore) SubscribeRestock(userID int, productID int, expiresAt time.Time) error {
+	m.subscriptions = append(m.subscriptions, types.RestockSubscription{ID: len(m.subscriptions) + 1, UserID: userID, ProductID: productID, ExpiresAt: expiresAt})
+	return nil
+}
+func (m *mockWishlistStore) UnsubscribeRestock(userID int, productID int) (int64, error) {
+	for i, sub := range m.subscriptions {
+		if sub.UserID == userID && sub.ProductID == productID {
+			m.subscriptions = append(m.subscriptions[:i], m.subscriptions[i+1:]...)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+func (m *mockWishlistStore) GetRestockSubscriptions(userID int) ([]types.RestockSubscription, error) {
+	return append([]types.RestockSubscription{}, m.subscriptions...), nil
+}
+
+type mockProductStore struct {
+	types.ProductStore
+	products map[int]types.Product
+}
+
+func (m *mockProductStore) GetProductByID(id int) (*types.Product, error) {
+	p := m.products[id]
+	return &p, nil
+}
@@ -0,0 +1,157 @@
+package wishlist
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fayleenpc/tj-jeans/internal/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Signature of Wishlist Store
+// GetWishlist(userID int) ([]types.WishlistItem, error)
+// AddToWishlist(userID int, productID int) error
+// RemoveFromWishlist(userID int, productID int) (int64, error)
+//
+// Signature of Restock Store
+// SubscribeRestock(userID int, productID int, expiresAt time.Time) error
+// UnsubscribeRestock(userID int, productID int) (int64, error)
+// GetRestockSubscriptions(userID int) ([]types.RestockSubscription, error)
+// GetDueRestockNotices(now time.Time, limit int) ([]types.RestockNotice, error)
+// ClaimRestockNotice(subscriptionID int, at time.Time) (bool, error)
+// ExpireRestockSubscriptions(now time.Time) (int64, error)
+
+func (s *Store) GetWishlist(userID int) ([]types.WishlistItem, error) {
+	rows, err := s.db.Query(
+		"SELECT p.id, p.name, p.currency, p.image, p.price, p.qty, w.createdAt FROM wishlist w JOIN products p ON p.id = w.productId WHERE w.userId = ? AND p.deletedAt IS NULL ORDER BY w.createdAt DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]types.WishlistItem, 0)
+	for rows.Next() {
+		var item types.WishlistItem
+		err := rows.Scan(&item.ProductID, &item.Name, &item.Currency, &item.Image, &item.Price, &item.Quantity, &item.AddedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// AddToWishlist keeps the date the product was first added when it already
+// is in the wishlist.
+func (s *Store) AddToWishlist(userID int, productID int) error {
+	_, err := s.db.Exec("INSERT IGNORE INTO wishlist (userId, productId) VALUES (?, ?)", userID, productID)
+	return err
+}
+
+func (s *Store) RemoveFromWishlist(userID int, productID int) (int64, error) {
+	res, err := s.db.Exec("DELETE FROM wishlist WHERE userId = ? AND productId = ?", userID, productID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// SubscribeRestock subscribes the user to the product, subscribing again
+// extends the subscription and mails it again on the next restock.
+func (s *Store) SubscribeRestock(userID int, productID int, expiresAt time.Time) error {
+	_, err := s.db.Exec(
+		"INSERT INTO restock_subscriptions (userId, productId, expiresAt) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE expiresAt = VALUES(expiresAt), notifiedAt = NULL",
+		userID, productID, expiresAt,
+	)
+	return err
+}
+
+func (s *Store) UnsubscribeRestock(userID int, productID int) (int64, error) {
+	res, err := s.db.Exec("DELETE FROM restock_subscriptions WHERE userId = ? AND productId = ?", userID, productID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) GetRestockSubscriptions(userID int) ([]types.RestockSubscription, error) {
+	rows, err := s.db.Query("SELECT * FROM restock_subscriptions WHERE userId = ? ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	subscriptions := make([]types.RestockSubscription, 0)
+	for rows.Next() {
+		sub, err := scanRowIntoRestockSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *sub)
+	}
+	return subscriptions, rows.Err()
+}
+
+// GetDueRestockNotices returns the subscriptions that weren't mailed yet and
+// whose product is back in stock, oldest first.
+func (s *Store) GetDueRestockNotices(now time.Time, limit int) ([]types.RestockNotice, error) {
+	rows, err := s.db.Query(
+		"SELECT r.id, u.email, u.firstName, p.id, p.name FROM restock_subscriptions r JOIN products p ON p.id = r.productId JOIN users u ON u.id = r.userId WHERE r.notifiedAt IS NULL AND r.expiresAt > ? AND p.qty > 0 AND p.deletedAt IS NULL AND u.deletedAt IS NULL ORDER BY r.id LIMIT ?",
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	notices := make([]types.RestockNotice, 0)
+	for rows.Next() {
+		var n types.RestockNotice
+		if err := rows.Scan(&n.SubscriptionID, &n.Email, &n.FirstName, &n.ProductID, &n.ProductName); err != nil {
+			return nil, err
+		}
+		notices = append(notices, n)
+	}
+	return notices, rows.Err()
+}
+
+// ClaimRestockNotice marks the subscription as mailed, it is false when
+// another worker claimed it first.
+func (s *Store) ClaimRestockNotice(subscriptionID int, at time.Time) (bool, error) {
+	res, err := s.db.Exec("UPDATE restock_subscriptions SET notifiedAt = ? WHERE id = ? AND notifiedAt IS NULL", at, subscriptionID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *Store) ExpireRestockSubscriptions(now time.Time) (int64, error) {
+	res, err := s.db.Exec("DELETE FROM restock_subscriptions WHERE expiresAt <= ?", now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanRowIntoRestockSubscription(rows *sql.Rows) (*types.RestockSubscription, error) {
+	sub := new(types.RestockSubscription)
+	err := rows.Scan(
+		&sub.ID,
+		&sub.UserID,
+		&sub.ProductID,
+		&sub.CreatedAt,
+		&sub.ExpiresAt,
+		&sub.NotifiedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}